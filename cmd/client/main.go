@@ -39,21 +39,47 @@ func main() {
 	case "bootstrap":
 		commands.BootstrapCommand()
 	case "search":
-		if len(os.Args) < 3 {
-			fmt.Println("Error: Search pattern is required")
-			printUsage()
-			os.Exit(1)
-		}
-		pattern := os.Args[2]
-		commands.SearchCommand(pattern)
+		commands.SearchCommand()
 	case "restore":
 		commands.RestoreCommand()
 	case "decrypt":
 		commands.DecryptCommand()
 	case "files":
 		commands.FilesCommand()
+	case "versions":
+		commands.VersionsCommand()
+	case "diff":
+		commands.DiffCommand()
 	case "add-backup":
 		commands.AddBackupCommand()
+	case "pin":
+		commands.PinCommand()
+	case "unpin":
+		commands.UnpinCommand()
+	case "replications":
+		commands.ReplicationsCommand()
+	case "add-replication":
+		commands.AddReplicationCommand()
+	case "replicate":
+		commands.ReplicateCommand()
+	case "replicas":
+		commands.ReplicasCommand()
+	case "add-replica-target":
+		commands.AddReplicaTargetCommand()
+	case "storage-pools":
+		commands.StoragePoolsCommand()
+	case "add-storage-pool":
+		commands.AddStoragePoolCommand()
+	case "migrate-backup":
+		commands.MigrateBackupCommand()
+	case "tiering":
+		commands.TieringCommand()
+	case "forecast":
+		commands.ForecastCommand()
+	case "quota":
+		commands.QuotaCommand()
+	case "orphans":
+		commands.OrphansCommand()
 	default:
 		fmt.Printf("Unknown command: %s\n", command)
 		printUsage()
@@ -70,8 +96,24 @@ func printUsage() {
 	fmt.Println("  add-backup   Create a new backup task")
 	fmt.Println("  run          Trigger a backup immediately (required: <backup-id>)")
 	fmt.Println("  bootstrap    Bootstrap a new host (args: --host, --user, --name, [--port])")
-	fmt.Println("  search       Search for files in backups (required: <pattern>)")
+	fmt.Println("  search       Search the file catalog (args: [pattern], optional: --regex --host --backup --after --before --min-size --max-size --page --page-size)")
 	fmt.Println("  restore      Restore files or directories (required: <backup-id>)")
-	fmt.Println("  files        List files in a backup (required: <backup-id>, optional: --path <subpath>)")
+	fmt.Println("  files        List files in a backup (required: <backup-id>, optional: --path <subpath>, --at <snapshot>)")
+	fmt.Println("  versions     List the stored versions of a file (required: <backup-id> --path <file>, optional: --restore <n> --dest <dir>)")
+	fmt.Println("  diff         Show what changed between two snapshots (required: <backup-id> --from <snapshot>, optional: --to <snapshot> --json)")
+	fmt.Println("  pin          Pin a snapshot so retention keeps it (required: <backup-id>, optional: <snapshot> --label --note --expires; lists pins without <snapshot>)")
+	fmt.Println("  unpin        Remove a snapshot pin (required: <backup-id> <snapshot>)")
+	fmt.Println("  replications List replication jobs (optional: <job-id> to list its runs)")
+	fmt.Println("  add-replication Restore a backup to a host on a schedule (required: <backup-id> --name --to-host --to-path)")
+	fmt.Println("  replicate    Run a replication job now (required: <job-id>)")
+	fmt.Println("  replicas     List offsite replica targets (optional: <backup-id> to list its replicated snapshots, --sync to copy now)")
+	fmt.Println("  add-replica-target Copy snapshots to S3-compatible storage or, with --kind rsync, an SSH host (required: --name, then --endpoint --bucket --access-key --secret-key or --host --user --path)")
+	fmt.Println("  storage-pools List storage pools and their free space")
+	fmt.Println("  add-storage-pool Create a storage pool (required: --name --root, optional: --capacity --labels)")
+	fmt.Println("  migrate-backup Move a backup to another storage pool (required: <backup-id>, optional: --pool <id>)")
+	fmt.Println("  tiering      Show or set where old snapshots go (required: <backup-id>, optional: --after-days <n> --pool <id> [--encrypt] or --replica <id>, --off, --now)")
+	fmt.Println("  forecast     Show when the backup roots are projected to fill (optional: --model linear|seasonal)")
+	fmt.Println("  quota        Cap the storage of a host or backup (required: host|backup <id>, optional: --bytes <size> --per-run <size>; zero lifts a limit)")
+	fmt.Println("  orphans      List data no backup references (optional: --reclaim <path> to delete it, --adopt <path> --backup <id> to hand it to a backup)")
 	fmt.Println("  decrypt      Decrypt a backup file offline (args: --file, --out, --id, --key)")
 }
@@ -0,0 +1,90 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/events"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/interfaces"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+)
+
+// AnomalyService compares every backup run with the runs before it and
+// raises an alert when a snapshot shrinks or a run changes far more files
+// than usual, which is what broken mounts and ransomware look like.
+type AnomalyService struct {
+	historyRepo interfaces.SizeHistoryRepository
+	retention   *BackupRetentionService
+	events      interfaces.EventPublisher
+	thresholds  valueobjects.AnomalyThresholds
+	autoHold    bool
+}
+
+// NewAnomalyService creates the service. With autoHold a backup showing an
+// anomaly is put under legal hold, so retention cannot purge the snapshots
+// taken before it; retention may be nil when autoHold is off.
+func NewAnomalyService(historyRepo interfaces.SizeHistoryRepository, retention *BackupRetentionService, events interfaces.EventPublisher, thresholds valueobjects.AnomalyThresholds, autoHold bool) *AnomalyService {
+	return &AnomalyService{
+		historyRepo: historyRepo,
+		retention:   retention,
+		events:      events,
+		thresholds:  thresholds,
+		autoHold:    autoHold,
+	}
+}
+
+// Check judges the run that just completed against the recorded history of
+// the backup, so it must be called before the run itself is recorded.
+func (s *AnomalyService) Check(ctx context.Context, backup *entities.Backup, hostName string, changes valueobjects.FileChanges) ([]valueobjects.SizeAnomaly, error) {
+	samples, err := s.historyRepo.FindByBackupID(ctx, backup.ID(), historySince(forecastHistoryDays))
+	if err != nil {
+		return nil, err
+	}
+	history := make([]valueobjects.RunSample, 0, len(samples))
+	for _, sample := range samples {
+		history = append(history, sample.Run())
+	}
+
+	run := valueobjects.RunSample{Size: backup.Usage().Size, Changes: changes}
+	anomalies := valueobjects.DetectSizeAnomalies(history, run, s.thresholds)
+	if len(anomalies) == 0 {
+		return nil, nil
+	}
+
+	held := false
+	if s.autoHold && s.retention != nil && !backup.LegalHold() {
+		reason := "Automatic hold: " + describeAnomaly(anomalies[0])
+		if _, err := s.retention.SetBackupLegalHold(ctx, backup.ID().String(), dto.LegalHoldRequest{Enabled: true, Reason: reason}, nil); err != nil {
+			log.Printf("Failed to hold backup %s after an anomaly: %v", backup.ID(), err)
+		} else {
+			backup.SetLegalHold(true)
+			held = true
+		}
+	}
+
+	for _, anomaly := range anomalies {
+		log.Printf("Backup %s looks anomalous: %s", backup.ID(), describeAnomaly(anomaly))
+		if s.events == nil {
+			continue
+		}
+		event := events.NewBackupAnomaly(backup.ID().String(), backup.HostID().String(), hostName, backup.Path(), string(anomaly.Kind), anomaly.Ratio, anomaly.Baseline, run.Size, changes.Files, changes.Changed, held)
+		if err := s.events.Publish(ctx, event); err != nil {
+			log.Printf("Failed to publish BackupAnomaly event: %v", err)
+		}
+	}
+	return anomalies, nil
+}
+
+func describeAnomaly(anomaly valueobjects.SizeAnomaly) string {
+	switch anomaly.Kind {
+	case valueobjects.AnomalyShrink:
+		return fmt.Sprintf("snapshot shrank by %.0f%% from a usual %s", anomaly.Ratio*100, shared.FormatSize(int64(anomaly.Baseline)))
+	case valueobjects.AnomalyChurn:
+		return fmt.Sprintf("%.0f%% of files changed against a usual %.0f%%", anomaly.Ratio*100, anomaly.Baseline*100)
+	}
+	return string(anomaly.Kind)
+}
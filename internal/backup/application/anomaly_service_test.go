@@ -0,0 +1,104 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application/assembler"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/events"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/backup/infrastructure/persistence/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type anomalyFixture struct {
+	*storagePoolFixture
+	history   *SizeHistoryService
+	holds     *memory.LegalHoldEventRepositoryMemory
+	publisher *recordingEventPublisher
+}
+
+func newAnomalyFixture(t *testing.T, autoHold bool) (*anomalyFixture, *AnomalyService) {
+	t.Helper()
+	f := newStoragePoolFixture(t)
+	historyRepo := memory.NewSizeHistoryRepositoryMemory()
+	holds := memory.NewLegalHoldEventRepositoryMemory()
+	retention := NewBackupRetentionService(f.backupRepo, f.hostRepo, memory.NewSnapshotPinRepositoryMemory(), holds, f.queryBus, assembler.NewBackupAssembler(), f.service)
+	publisher := &recordingEventPublisher{}
+	service := NewAnomalyService(historyRepo, retention, publisher, valueobjects.AnomalyThresholds{Shrink: 0.5, Churn: 0.8}, autoHold)
+
+	fixture := &anomalyFixture{
+		storagePoolFixture: f,
+		history:            NewSizeHistoryService(historyRepo, f.backupRepo, f.hostRepo, f.queryBus, f.service, nil, 0),
+		holds:              holds,
+		publisher:          publisher,
+	}
+	return fixture, service
+}
+
+// run checks and records one run of the fixture backup, as the result
+// consumer does.
+func (f *anomalyFixture) run(t *testing.T, service *AnomalyService, size int64, changes valueobjects.FileChanges) []valueobjects.SizeAnomaly {
+	t.Helper()
+	f.backup.SetUsage(valueobjects.SpaceUsage{Size: size, Stored: size})
+	anomalies, err := service.Check(context.Background(), f.backup, "prod", changes)
+	require.NoError(t, err)
+	require.NoError(t, f.history.Record(context.Background(), f.backup, changes))
+	return anomalies
+}
+
+func TestAnomalyService_ReportsShrink(t *testing.T) {
+	f, service := newAnomalyFixture(t, false)
+	restoreNow(t)
+	entities.NowFunc = func() time.Time { return time.Date(2024, 3, 10, 2, 0, 0, 0, time.UTC) }
+
+	for i := 0; i < 4; i++ {
+		assert.Empty(t, f.run(t, service, 1000, valueobjects.FileChanges{Files: 100, Changed: 3}))
+	}
+	anomalies := f.run(t, service, 80, valueobjects.FileChanges{Files: 8, Changed: 0})
+	require.Len(t, anomalies, 1)
+	assert.Equal(t, valueobjects.AnomalyShrink, anomalies[0].Kind)
+
+	require.Len(t, f.publisher.events, 1)
+	event, ok := f.publisher.events[0].(events.BackupAnomaly)
+	require.True(t, ok)
+	assert.Equal(t, events.BackupAnomalyEvent, event.Name())
+	assert.Equal(t, "shrink", event.Kind)
+	assert.Equal(t, "prod", event.HostName)
+	assert.InDelta(t, 0.92, event.Ratio, 0.001)
+	assert.False(t, event.Held)
+
+	stored, err := f.backupRepo.FindByID(context.Background(), f.backup.ID())
+	require.NoError(t, err)
+	assert.False(t, stored.LegalHold(), "no hold without auto-hold")
+}
+
+func TestAnomalyService_HoldsOnChurn(t *testing.T) {
+	f, service := newAnomalyFixture(t, true)
+	restoreNow(t)
+	entities.NowFunc = func() time.Time { return time.Date(2024, 3, 10, 2, 0, 0, 0, time.UTC) }
+
+	for i := 0; i < 3; i++ {
+		f.run(t, service, 1000, valueobjects.FileChanges{Files: 100, Changed: 2})
+	}
+	anomalies := f.run(t, service, 1100, valueobjects.FileChanges{Files: 100, Changed: 95})
+	require.Len(t, anomalies, 1)
+	assert.Equal(t, valueobjects.AnomalyChurn, anomalies[0].Kind)
+
+	require.Len(t, f.publisher.events, 1)
+	event := f.publisher.events[0].(events.BackupAnomaly)
+	assert.True(t, event.Held)
+	assert.Equal(t, int64(95), event.ChangedFiles)
+
+	stored, err := f.backupRepo.FindByID(context.Background(), f.backup.ID())
+	require.NoError(t, err)
+	assert.True(t, stored.LegalHold())
+	holds, err := f.holds.FindByTarget(context.Background(), entities.LegalHoldScopeBackup, f.backup.ID().String())
+	require.NoError(t, err)
+	require.Len(t, holds, 1)
+	assert.Contains(t, holds[0].Reason, "95% of files changed")
+	assert.Nil(t, holds[0].UserID)
+}
@@ -3,6 +3,7 @@ package assembler
 import (
 	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
 	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
 )
 
 type BackupAssembler struct{}
@@ -13,21 +14,82 @@ func NewBackupAssembler() *BackupAssembler {
 
 func (a *BackupAssembler) ToBackupResponse(backup *entities.Backup, hostName, hostAddress string) *dto.BackupResponse {
 	return &dto.BackupResponse{
-		ID:          backup.ID().String(),
-		HostID:      backup.HostID().String(),
-		HostName:    hostName,
-		HostAddress: hostAddress,
-		Path:        backup.Path(),
-		Destination: backup.Destination(),
-		Status:      backup.Status().String(),
-		Schedule:    backup.Schedule().CronExpression,
-		LastRun:     backup.Schedule().LastRun,
-		Excludes:    backup.Excludes(),
-		Incremental: backup.Incremental(),
-		Size:        backup.Size(),
-		Retention:   backup.Retention(),
-		Encrypted:   backup.Encrypted(),
-		Hooks:       a.ToHookDTOs(backup.Hooks()),
+		ID:              backup.ID().String(),
+		HostID:          backup.HostID().String(),
+		HostName:        hostName,
+		HostAddress:     hostAddress,
+		Path:            backup.Path(),
+		Destination:     backup.Destination(),
+		Status:          backup.Status().String(),
+		Schedule:        backup.Schedule().CronExpression,
+		LastRun:         backup.Schedule().LastRun,
+		Excludes:        backup.Excludes(),
+		Incremental:     backup.Incremental(),
+		Size:            backup.Usage().Size,
+		UniqueSize:      backup.Usage().Unique,
+		StoredSize:      backup.Usage().Stored,
+		Retention:       backup.Retention(),
+		RetentionPolicy: a.ToRetentionPolicyDTO(backup.RetentionPolicy()),
+		Encrypted:       backup.Encrypted(),
+		LegalHold:       backup.LegalHold(),
+		ContentIndex:    backup.ContentIndex(),
+		StoragePoolID:   backup.StoragePoolID(),
+		Migrating:       backup.Migrating(),
+		Quota:           dto.ToQuotaResponse(backup.Quota(), backup.Usage().Stored),
+		Hooks:           a.ToHookDTOs(backup.Hooks()),
+	}
+}
+
+func (a *BackupAssembler) ToRetentionPolicyDTO(policy valueobjects.RetentionPolicy) dto.RetentionPolicyDTO {
+	return dto.RetentionPolicyDTO{
+		KeepDaily:   policy.KeepDaily,
+		KeepWeekly:  policy.KeepWeekly,
+		KeepMonthly: policy.KeepMonthly,
+		KeepYearly:  policy.KeepYearly,
+	}
+}
+
+func (a *BackupAssembler) ToRetentionPreviewResponse(backup *entities.Backup, plan valueobjects.RetentionPlan, legalHold bool) *dto.RetentionPreviewResponse {
+	keep := make([]dto.RetainedSnapshotDTO, 0, len(plan.Keep))
+	for _, k := range plan.Keep {
+		rules := make([]string, 0, len(k.Rules))
+		for _, r := range k.Rules {
+			rules = append(rules, string(r))
+		}
+		keep = append(keep, dto.RetainedSnapshotDTO{Name: k.Name, Rules: rules})
+	}
+
+	return &dto.RetentionPreviewResponse{
+		BackupID:  backup.ID().String(),
+		Retention: backup.Retention(),
+		Policy:    a.ToRetentionPolicyDTO(backup.RetentionPolicy()),
+		LegalHold: legalHold,
+		Keep:      keep,
+		Purge:     plan.Purge,
+	}
+}
+
+func (a *BackupAssembler) ToSnapshotPinResponse(pin *entities.SnapshotPin) dto.SnapshotPinResponse {
+	return dto.SnapshotPinResponse{
+		BackupID:  pin.BackupID.String(),
+		Snapshot:  pin.Snapshot,
+		Label:     pin.Label,
+		Note:      pin.Note,
+		ExpiresAt: pin.ExpiresAt,
+		CreatedAt: pin.CreatedAt,
+		Active:    pin.Active(entities.NowFunc()),
+	}
+}
+
+func (a *BackupAssembler) ToLegalHoldEventResponse(event *entities.LegalHoldEvent) dto.LegalHoldEventResponse {
+	return dto.LegalHoldEventResponse{
+		ID:         event.ID,
+		Scope:      string(event.Scope),
+		TargetID:   event.TargetID,
+		Enabled:    event.Enabled,
+		Reason:     event.Reason,
+		UserID:     event.UserID,
+		OccurredAt: event.OccurredAt,
 	}
 }
 
@@ -2,7 +2,9 @@ package application
 
 import (
 	"context"
+	"sort"
 
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
 	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
 	"github.com/rrbarrero/justbackup/internal/backup/domain/interfaces"
 	workerStatsApp "github.com/rrbarrero/justbackup/internal/workerstats/application"
@@ -27,6 +29,28 @@ type DashboardStats struct {
 	TotalBackups  int                   `json:"total_backups"`
 	ActiveWorkers int                   `json:"active_workers"`
 	BackupStats   *entities.BackupStats `json:"backup_stats"`
+	Storage       []HostStorage         `json:"storage"`
+}
+
+// HostStorage is the space the backups of one host take on disk, in bytes,
+// and how much of its quota that uses.
+type HostStorage struct {
+	HostID     string             `json:"host_id"`
+	HostName   string             `json:"host_name"`
+	StoredSize int64              `json:"stored_size"`
+	Quota      *dto.QuotaResponse `json:"quota,omitempty"`
+	Backups    []BackupStorage    `json:"backups"`
+}
+
+// BackupStorage is the space one backup takes on disk. Size is the apparent
+// size of its latest snapshot and UniqueSize what the latest run added.
+type BackupStorage struct {
+	BackupID   string             `json:"backup_id"`
+	Path       string             `json:"path"`
+	Size       int64              `json:"size"`
+	UniqueSize int64              `json:"unique_size"`
+	StoredSize int64              `json:"stored_size"`
+	Quota      *dto.QuotaResponse `json:"quota,omitempty"`
 }
 
 func (s *DashboardService) GetStats(ctx context.Context) (*DashboardStats, error) {
@@ -40,6 +64,11 @@ func (s *DashboardService) GetStats(ctx context.Context) (*DashboardStats, error
 		return nil, err
 	}
 
+	storage, err := s.storage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	workerStats, err := s.workerStatsService.GetStats(ctx)
 	activeWorkers := 0
 	if err == nil {
@@ -55,5 +84,64 @@ func (s *DashboardService) GetStats(ctx context.Context) (*DashboardStats, error
 		TotalBackups:  backupStats.Total,
 		ActiveWorkers: activeWorkers,
 		BackupStats:   backupStats,
+		Storage:       storage,
 	}, nil
 }
+
+// storage groups the space usage of the backups by host, largest first.
+func (s *DashboardService) storage(ctx context.Context) ([]HostStorage, error) {
+	backups, err := s.backupRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hosts, err := s.hostRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*entities.Host, len(hosts))
+	for _, h := range hosts {
+		byID[h.ID().String()] = h
+	}
+
+	byHost := make(map[string]*HostStorage)
+	for _, b := range backups {
+		hostID := b.HostID().String()
+		host, ok := byHost[hostID]
+		if !ok {
+			host = &HostStorage{HostID: hostID}
+			if h, found := byID[hostID]; found {
+				host.HostName = h.Name()
+			}
+			byHost[hostID] = host
+		}
+		usage := b.Usage()
+		host.StoredSize += usage.Stored
+		host.Backups = append(host.Backups, BackupStorage{
+			BackupID:   b.ID().String(),
+			Path:       b.Path(),
+			Size:       usage.Size,
+			UniqueSize: usage.Unique,
+			StoredSize: usage.Stored,
+			Quota:      dto.ToQuotaResponse(b.Quota(), usage.Stored),
+		})
+	}
+
+	storage := make([]HostStorage, 0, len(byHost))
+	for _, host := range byHost {
+		if h, found := byID[host.HostID]; found {
+			host.Quota = dto.ToQuotaResponse(h.Quota(), host.StoredSize)
+		}
+		sort.Slice(host.Backups, func(i, j int) bool {
+			return host.Backups[i].StoredSize > host.Backups[j].StoredSize
+		})
+		storage = append(storage, *host)
+	}
+	sort.Slice(storage, func(i, j int) bool {
+		if storage[i].StoredSize != storage[j].StoredSize {
+			return storage[i].StoredSize > storage[j].StoredSize
+		}
+		return storage[i].HostName < storage[j].HostName
+	})
+	return storage, nil
+}
@@ -0,0 +1,49 @@
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/backup/infrastructure/persistence/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardService_StorageByHost(t *testing.T) {
+	ctx := context.Background()
+	backupRepo := memory.NewBackupRepositoryMemoryEmpty()
+	hostRepo := memory.NewHostRepositoryMemory()
+
+	web := entities.NewHost("web", "web.local", "root", 22, "web", false)
+	db := entities.NewHost("db", "db.local", "root", 22, "db", false)
+	web.SetQuota(valueobjects.Quota{Bytes: 1000})
+	for _, h := range []*entities.Host{web, db} {
+		require.NoError(t, hostRepo.Save(ctx, h))
+	}
+
+	save := func(host *entities.Host, path string, usage valueobjects.SpaceUsage) {
+		backup, err := entities.NewBackup(host.ID(), path, path, entities.NewBackupSchedule("@daily"), nil, true, 5, false)
+		require.NoError(t, err)
+		backup.SetUsage(usage)
+		require.NoError(t, backupRepo.Save(ctx, backup))
+	}
+	save(web, "/srv/www", valueobjects.SpaceUsage{Size: 100, Unique: 10, Stored: 300})
+	save(web, "/etc", valueobjects.SpaceUsage{Size: 50, Unique: 50, Stored: 500})
+	save(db, "/var/lib/mysql", valueobjects.SpaceUsage{Size: 1000, Unique: 200, Stored: 4000})
+
+	storage, err := NewDashboardService(backupRepo, hostRepo, nil).storage(ctx)
+
+	require.NoError(t, err)
+	require.Len(t, storage, 2, "hosts without backups are left out")
+	assert.Equal(t, "db", storage[0].HostName)
+	assert.Equal(t, int64(4000), storage[0].StoredSize)
+	assert.Equal(t, "web", storage[1].HostName)
+	assert.Equal(t, int64(800), storage[1].StoredSize)
+	assert.Equal(t, &dto.QuotaResponse{Bytes: 1000, Used: 800}, storage[1].Quota)
+	assert.Nil(t, storage[0].Quota)
+	require.Len(t, storage[1].Backups, 2)
+	assert.Equal(t, BackupStorage{BackupID: storage[1].Backups[0].BackupID, Path: "/etc", Size: 50, UniqueSize: 50, StoredSize: 500}, storage[1].Backups[0])
+}
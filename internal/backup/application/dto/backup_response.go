@@ -3,24 +3,27 @@ package dto
 import "time"
 
 type BackupResponse struct {
-	ID          string    `json:"id"`
-	HostID      string    `json:"host_id"`
-	HostName    string    `json:"host_name"`
-	HostAddress string    `json:"host_address"`
-	Path        string    `json:"path"`
-	Destination string    `json:"destination"`
-	Status      string    `json:"status"`
-	Schedule    string    `json:"schedule"`
-	LastRun     time.Time `json:"last_run"`
-	Excludes    []string  `json:"excludes"`
-	Incremental bool      `json:"incremental"`
-	Size        string    `json:"size"`
-	Retention   int       `json:"retention"`
-	Encrypted   bool      `json:"encrypted"`
-	Hooks       []HookDTO `json:"hooks"`
-}
-
-type FileSearchResult struct {
-	Path   string          `json:"path"`
-	Backup *BackupResponse `json:"backup,omitempty"`
+	ID              string             `json:"id"`
+	HostID          string             `json:"host_id"`
+	HostName        string             `json:"host_name"`
+	HostAddress     string             `json:"host_address"`
+	Path            string             `json:"path"`
+	Destination     string             `json:"destination"`
+	Status          string             `json:"status"`
+	Schedule        string             `json:"schedule"`
+	LastRun         time.Time          `json:"last_run"`
+	Excludes        []string           `json:"excludes"`
+	Incremental     bool               `json:"incremental"`
+	Size            int64              `json:"size"`
+	UniqueSize      int64              `json:"unique_size"`
+	StoredSize      int64              `json:"stored_size"`
+	Retention       int                `json:"retention"`
+	RetentionPolicy RetentionPolicyDTO `json:"retention_policy"`
+	Encrypted       bool               `json:"encrypted"`
+	LegalHold       bool               `json:"legal_hold"`
+	ContentIndex    bool               `json:"content_index"`
+	StoragePoolID   string             `json:"storage_pool_id,omitempty"`
+	Migrating       bool               `json:"migrating,omitempty"`
+	Quota           *QuotaResponse     `json:"quota,omitempty"`
+	Hooks           []HookDTO          `json:"hooks"`
 }
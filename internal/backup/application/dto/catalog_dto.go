@@ -0,0 +1,66 @@
+package dto
+
+import "time"
+
+// FileSearchRequest holds the filters of a file catalog search. Dates accept
+// RFC 3339 or YYYY-MM-DD and sizes accept units such as "10MB". Empty fields
+// do not filter.
+type FileSearchRequest struct {
+	Pattern        string
+	Regex          string
+	HostID         string
+	BackupID       string
+	ModifiedAfter  string
+	ModifiedBefore string
+	MinSize        string
+	MaxSize        string
+	Page           int
+	PageSize       int
+}
+
+type FileSearchResult struct {
+	Path     string          `json:"path"`
+	Snapshot string          `json:"snapshot,omitempty"`
+	Size     int64           `json:"size"`
+	ModTime  time.Time       `json:"mod_time"`
+	Hash     string          `json:"hash,omitempty"`
+	Backup   *BackupResponse `json:"backup,omitempty"`
+}
+
+type FileSearchResponse struct {
+	Results  []*FileSearchResult `json:"results"`
+	Total    int                 `json:"total"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"page_size"`
+}
+
+// FileGrepRequest holds a content index search. Empty IDs do not filter.
+type FileGrepRequest struct {
+	Query    string
+	HostID   string
+	BackupID string
+	Page     int
+	PageSize int
+}
+
+type FileGrepLine struct {
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// FileGrepResult is one version of a matching file, with the snapshots that
+// hold it, newest first, and its matching lines.
+type FileGrepResult struct {
+	Path      string          `json:"path"`
+	Snapshots []string        `json:"snapshots"`
+	Hash      string          `json:"hash"`
+	Lines     []FileGrepLine  `json:"lines"`
+	Backup    *BackupResponse `json:"backup,omitempty"`
+}
+
+type FileGrepResponse struct {
+	Results  []*FileGrepResult `json:"results"`
+	Total    int               `json:"total"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"page_size"`
+}
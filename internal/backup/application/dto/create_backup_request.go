@@ -1,13 +1,21 @@
 package dto
 
 type CreateBackupRequest struct {
-	HostID      string              `json:"host_id"`
-	Path        string              `json:"path"`
-	Destination string              `json:"destination"`
-	Schedule    string              `json:"schedule"` // Cron expression
-	Excludes    []string            `json:"excludes"`
-	Incremental bool                `json:"incremental"`
-	Retention   int                 `json:"retention"`
-	Encrypted   bool                `json:"encrypted"`
-	Hooks       []CreateHookRequest `json:"hooks"`
+	HostID      string   `json:"host_id"`
+	Path        string   `json:"path"`
+	Destination string   `json:"destination"`
+	Schedule    string   `json:"schedule"` // Cron expression
+	Excludes    []string `json:"excludes"`
+	Incremental bool     `json:"incremental"`
+	Retention   int      `json:"retention"`
+	// RetentionPolicy is optional; when omitted the calendar rules are left unchanged.
+	RetentionPolicy *RetentionPolicyDTO `json:"retention_policy,omitempty"`
+	Encrypted       bool                `json:"encrypted"`
+	ContentIndex    bool                `json:"content_index"`
+	// StoragePoolID places the backup in a pool. When empty it goes in the
+	// pool of its host, or else the pool with the most free space among
+	// those carrying every one of PoolLabels.
+	StoragePoolID string              `json:"storage_pool_id,omitempty"`
+	PoolLabels    []string            `json:"pool_labels,omitempty"`
+	Hooks         []CreateHookRequest `json:"hooks"`
 }
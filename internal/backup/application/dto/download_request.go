@@ -0,0 +1,20 @@
+package dto
+
+import "time"
+
+type DownloadRequest struct {
+	BackupID string
+	Path     string // Path inside the backup
+	Snapshot string // Optional snapshot selector: name, "latest" or a point in time
+	Format   string // "tar.gz" (default) or "zip"
+}
+
+// DownloadResponse describes an archive staged on the server for download.
+type DownloadResponse struct {
+	FilePath    string
+	FileName    string
+	ContentType string
+	Size        int64
+	ModTime     time.Time
+	ETag        string
+}
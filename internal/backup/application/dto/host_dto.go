@@ -14,14 +14,17 @@ type CreateHostRequest struct {
 }
 
 type HostResponse struct {
-	ID                 string `json:"id"`
-	Name               string `json:"name"`
-	Hostname           string `json:"hostname"`
-	User               string `json:"user"`
-	Port               int    `json:"port"`
-	Path               string `json:"path"`
-	IsWorkstation      bool   `json:"is_workstation"`
-	FailedBackupsCount int    `json:"failed_backups_count"`
+	ID                 string         `json:"id"`
+	Name               string         `json:"name"`
+	Hostname           string         `json:"hostname"`
+	User               string         `json:"user"`
+	Port               int            `json:"port"`
+	Path               string         `json:"path"`
+	IsWorkstation      bool           `json:"is_workstation"`
+	LegalHold          bool           `json:"legal_hold"`
+	StoragePoolID      string         `json:"storage_pool_id,omitempty"`
+	Quota              *QuotaResponse `json:"quota,omitempty"`
+	FailedBackupsCount int            `json:"failed_backups_count"`
 }
 
 func ToHostResponse(h *entities.Host) *HostResponse {
@@ -33,5 +36,7 @@ func ToHostResponse(h *entities.Host) *HostResponse {
 		Port:          h.Port(),
 		Path:          h.Path(),
 		IsWorkstation: h.IsWorkstation(),
+		LegalHold:     h.LegalHold(),
+		StoragePoolID: h.StoragePoolID(),
 	}
 }
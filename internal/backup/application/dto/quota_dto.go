@@ -0,0 +1,33 @@
+package dto
+
+import "github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+
+// QuotaRequest sets the storage quota of a host or backup. Bytes caps what
+// is stored on disk and SnapshotBytes what a single run may add; zero lifts
+// either limit.
+type QuotaRequest struct {
+	Bytes         int64 `json:"bytes"`
+	SnapshotBytes int64 `json:"snapshot_bytes"`
+}
+
+// QuotaResponse is a storage quota and the bytes stored under it.
+type QuotaResponse struct {
+	Bytes         int64 `json:"bytes"`
+	SnapshotBytes int64 `json:"snapshot_bytes"`
+	Used          int64 `json:"used"`
+	Exceeded      bool  `json:"exceeded"`
+}
+
+// ToQuotaResponse describes quota with used bytes stored under it, or
+// returns nil when no quota is set.
+func ToQuotaResponse(quota valueobjects.Quota, used int64) *QuotaResponse {
+	if quota.IsZero() {
+		return nil
+	}
+	return &QuotaResponse{
+		Bytes:         quota.Bytes,
+		SnapshotBytes: quota.SnapshotBytes,
+		Used:          used,
+		Exceeded:      quota.Bytes > 0 && used >= quota.Bytes,
+	}
+}
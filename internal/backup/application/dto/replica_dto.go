@@ -0,0 +1,78 @@
+package dto
+
+import "time"
+
+type ReplicaS3Config struct {
+	Endpoint  string `json:"endpoint"` // e.g. https://s3.eu-west-1.amazonaws.com or http://minio:9000
+	Region    string `json:"region,omitempty"`
+	Bucket    string `json:"bucket"`
+	Prefix    string `json:"prefix,omitempty"`
+	AccessKey string `json:"access_key"`
+	// Never returned. Leave empty on update to keep the current one.
+	SecretKey string `json:"secret_key,omitempty"`
+	PathStyle bool   `json:"path_style,omitempty"` // Needed by MinIO
+}
+
+// ReplicaRsyncConfig is a directory of a remote host the worker reaches over
+// SSH with its own key.
+type ReplicaRsyncConfig struct {
+	Host string `json:"host"`
+	User string `json:"user"`
+	Port int    `json:"port,omitempty"` // 22 if empty
+	Path string `json:"path"`           // Absolute, each backup goes in <path>/<backup id>
+}
+
+type ReplicaTargetRequest struct {
+	Name  string              `json:"name"`
+	Kind  string              `json:"kind"` // "s3" or "rsync"
+	S3    *ReplicaS3Config    `json:"s3,omitempty"`
+	Rsync *ReplicaRsyncConfig `json:"rsync,omitempty"`
+	// Upload rate limit in KiB/s, no limit if 0
+	BandwidthLimit int `json:"bandwidth_limit"`
+	// Snapshots of incremental backups kept on the target, apart from the
+	// retention of the backup. Every snapshot copied is kept if 0.
+	Retention int      `json:"retention"`
+	BackupIDs []string `json:"backup_ids"` // Every backup if empty
+	Enabled   *bool    `json:"enabled"`
+}
+
+type ReplicaTargetResponse struct {
+	ID             string              `json:"id"`
+	Name           string              `json:"name"`
+	Kind           string              `json:"kind"`
+	S3             *ReplicaS3Config    `json:"s3,omitempty"`
+	Rsync          *ReplicaRsyncConfig `json:"rsync,omitempty"`
+	BandwidthLimit int                 `json:"bandwidth_limit"`
+	Retention      int                 `json:"retention"`
+	BackupIDs      []string            `json:"backup_ids"`
+	Enabled        bool                `json:"enabled"`
+	CreatedAt      time.Time           `json:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at"`
+}
+
+type ReplicaSnapshotResponse struct {
+	Snapshot      string    `json:"snapshot"`
+	Files         int64     `json:"files"`
+	Bytes         int64     `json:"bytes"`
+	UploadedBytes int64     `json:"uploaded_bytes"`
+	ReplicatedAt  time.Time `json:"replicated_at"`
+}
+
+// BackupReplicaResponse is the state of a backup on a replica target. The
+// target is in sync when it was copied to after the last run of the backup;
+// otherwise LagSeconds is how long ago that run finished.
+type BackupReplicaResponse struct {
+	TargetID         string                    `json:"target_id"`
+	TargetName       string                    `json:"target_name"`
+	Kind             string                    `json:"kind"`
+	Enabled          bool                      `json:"enabled"`
+	LatestSnapshot   string                    `json:"latest_snapshot,omitempty"`
+	LastReplicatedAt *time.Time                `json:"last_replicated_at,omitempty"`
+	InSync           bool                      `json:"in_sync"`
+	LagSeconds       int64                     `json:"lag_seconds"`
+	Snapshots        []ReplicaSnapshotResponse `json:"snapshots"`
+}
+
+type ReplicateResponse struct {
+	TaskIDs []string `json:"task_ids"`
+}
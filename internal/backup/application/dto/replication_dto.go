@@ -0,0 +1,58 @@
+package dto
+
+import "time"
+
+type ReplicationJobRequest struct {
+	Name     string `json:"name"`
+	BackupID string `json:"backup_id"` // Ignored on update
+	Path     string `json:"path"`      // Path inside the backup, the whole backup if empty
+	// Snapshot selector: name, "latest" or a point in time. Incremental
+	// backups default to "latest".
+	Snapshot     string `json:"snapshot"`
+	TargetHostID string `json:"target_host_id"`
+	TargetPath   string `json:"target_path"`
+	Schedule     string `json:"schedule"`
+	// What to do with files that already exist at the target: "overwrite"
+	// (default), "skip", "newer" or "rename"
+	Conflict          string `json:"conflict"`
+	Delete            bool   `json:"delete"`
+	PreserveOwnership bool   `json:"preserve_ownership"`
+	NumericIDs        bool   `json:"numeric_ids"`
+	NoOwnership       bool   `json:"no_ownership"`
+	PreserveTimes     bool   `json:"preserve_times"`
+	Enabled           *bool  `json:"enabled"`
+}
+
+type ReplicationJobResponse struct {
+	ID                string     `json:"id"`
+	Name              string     `json:"name"`
+	BackupID          string     `json:"backup_id"`
+	Path              string     `json:"path"`
+	Snapshot          string     `json:"snapshot"`
+	TargetHostID      string     `json:"target_host_id"`
+	TargetPath        string     `json:"target_path"`
+	Schedule          string     `json:"schedule"`
+	Conflict          string     `json:"conflict"`
+	Delete            bool       `json:"delete"`
+	PreserveOwnership bool       `json:"preserve_ownership"`
+	NumericIDs        bool       `json:"numeric_ids"`
+	NoOwnership       bool       `json:"no_ownership"`
+	PreserveTimes     bool       `json:"preserve_times"`
+	Enabled           bool       `json:"enabled"`
+	Status            string     `json:"status"`
+	LastRunAt         *time.Time `json:"last_run_at,omitempty"`
+	NextRunAt         *time.Time `json:"next_run_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+type ReplicationRunResponse struct {
+	ID         string     `json:"id"`
+	JobID      string     `json:"job_id"`
+	TaskID     string     `json:"task_id,omitempty"`
+	Trigger    string     `json:"trigger"`
+	Status     string     `json:"status"`
+	Message    string     `json:"message,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
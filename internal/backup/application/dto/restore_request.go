@@ -3,9 +3,38 @@ package dto
 type RestoreRequest struct {
 	BackupID     string `json:"backup_id"`
 	Path         string `json:"path"`          // Path inside the backup
+	Snapshot     string `json:"snapshot"`      // Optional snapshot selector: name, "latest" or a point in time
 	RestoreType  string `json:"restore_type"`  // "local" or "remote"
 	RestoreAddr  string `json:"restore_addr"`  // For local: CLI address
 	RestoreToken string `json:"restore_token"` // For local: Auth token
-	TargetHostID string `json:"target_host_id"`
-	TargetPath   string `json:"target_path"`
+	// For local: SHA-256 fingerprint of the ephemeral TLS certificate of the CLI
+	RestoreFingerprint string `json:"restore_fingerprint"`
+	TargetHostID       string `json:"target_host_id"`
+	TargetPath         string `json:"target_path"`
+	// For remote: what to do with files that already exist at the target:
+	// "overwrite" (default), "skip", "newer" or "rename". The CLI applies it
+	// itself to local restores.
+	Conflict string `json:"conflict"`
+	// For remote: remove files at the target that are not in the backup
+	Delete bool `json:"delete"`
+	// Restore owners and groups, by name or by numeric ID. Remote restores
+	// keep them by name unless NoOwnership is set.
+	PreserveOwnership bool `json:"preserve_ownership"`
+	NumericIDs        bool `json:"numeric_ids"`
+	// For remote: leave owners and groups to the target
+	NoOwnership bool `json:"no_ownership"`
+	// For remote: keep the modification times of the restored files
+	PreserveTimes bool `json:"preserve_times"`
+	// For remote: return the itemized changes instead of restoring
+	DryRun bool `json:"dry_run"`
+	// For remote: the ID to publish the task under, allocated by a caller
+	// that records the task before its result can arrive. A new one when empty.
+	TaskID string `json:"-"`
+}
+
+// RestorePreviewResponse lists the changes a remote restore would make, in
+// rsync itemized form.
+type RestorePreviewResponse struct {
+	Changes   []string `json:"changes"`
+	Truncated bool     `json:"truncated"`
 }
@@ -0,0 +1,58 @@
+package dto
+
+import "time"
+
+// RetentionPolicyDTO holds the calendar based retention rules of a backup.
+// The keep-last count is carried by the backup's Retention field.
+type RetentionPolicyDTO struct {
+	KeepDaily   int `json:"keep_daily"`
+	KeepWeekly  int `json:"keep_weekly"`
+	KeepMonthly int `json:"keep_monthly"`
+	KeepYearly  int `json:"keep_yearly"`
+}
+
+type RetainedSnapshotDTO struct {
+	Name  string   `json:"name"`
+	Rules []string `json:"rules"`
+}
+
+type RetentionPreviewResponse struct {
+	BackupID  string                `json:"backup_id"`
+	Retention int                   `json:"retention"`
+	Policy    RetentionPolicyDTO    `json:"retention_policy"`
+	LegalHold bool                  `json:"legal_hold"`
+	Keep      []RetainedSnapshotDTO `json:"keep"`
+	Purge     []string              `json:"purge"`
+}
+
+type PinSnapshotRequest struct {
+	Snapshot  string     `json:"snapshot"`
+	Label     string     `json:"label,omitempty"`
+	Note      string     `json:"note,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type SnapshotPinResponse struct {
+	BackupID  string     `json:"backup_id"`
+	Snapshot  string     `json:"snapshot"`
+	Label     string     `json:"label"`
+	Note      string     `json:"note"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	Active    bool       `json:"active"`
+}
+
+type LegalHoldRequest struct {
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason"`
+}
+
+type LegalHoldEventResponse struct {
+	ID         string    `json:"id"`
+	Scope      string    `json:"scope"`
+	TargetID   string    `json:"target_id"`
+	Enabled    bool      `json:"enabled"`
+	Reason     string    `json:"reason"`
+	UserID     *int      `json:"user_id,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
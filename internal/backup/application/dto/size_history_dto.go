@@ -0,0 +1,48 @@
+package dto
+
+import "time"
+
+// SizeSampleResponse is the space usage a backup reported at the end of one
+// run, in bytes, and how many of its files the run changed.
+type SizeSampleResponse struct {
+	BackupID     string    `json:"backup_id"`
+	Size         int64     `json:"size"`
+	UniqueSize   int64     `json:"unique_size"`
+	StoredSize   int64     `json:"stored_size"`
+	Files        int64     `json:"files"`
+	ChangedFiles int64     `json:"changed_files"`
+	SampledAt    time.Time `json:"sampled_at"`
+}
+
+// DailySizeResponse is the space the backups took on disk at the end of a
+// day, each counted with its last sample so far.
+type DailySizeResponse struct {
+	Date       string `json:"date"` // YYYY-MM-DD
+	StoredSize int64  `json:"stored_size"`
+}
+
+type SizeHistoryResponse struct {
+	Samples []SizeSampleResponse `json:"samples"`
+	Daily   []DailySizeResponse  `json:"daily"`
+}
+
+// RootForecastResponse projects when the data on a backup root fills it.
+// DaysUntilFull and FullAt are null when it is not projected to fill within
+// ten years.
+type RootForecastResponse struct {
+	Root          string     `json:"root"`
+	StoragePoolID string     `json:"storage_pool_id,omitempty"`
+	Model         string     `json:"model"` // "linear" or "seasonal"
+	Total         int64      `json:"total"`
+	Used          int64      `json:"used"`
+	Free          int64      `json:"free"`
+	StoredSize    int64      `json:"stored_size"`
+	GrowthPerDay  int64      `json:"growth_per_day"`
+	DaysUntilFull *int       `json:"days_until_full"`
+	FullAt        *time.Time `json:"full_at"`
+}
+
+type CapacityForecastResponse struct {
+	HistoryDays int                    `json:"history_days"`
+	Roots       []RootForecastResponse `json:"roots"`
+}
@@ -0,0 +1,45 @@
+package dto
+
+import "time"
+
+type SnapshotResponse struct {
+	Name      string    `json:"name"`
+	Time      time.Time `json:"time"`
+	Size      int64     `json:"size"`
+	FileCount *int64    `json:"file_count,omitempty"`
+	Encrypted bool      `json:"encrypted"`
+	Latest    bool      `json:"latest"`
+	Pinned    bool      `json:"pinned"`
+	// Moved off the storage of the backup by tiering, to the tier "pool" or
+	// "replica"; restores bring it back on demand
+	Archived bool   `json:"archived"`
+	Tier     string `json:"tier,omitempty"`
+}
+
+type FileVersionResponse struct {
+	Hash      string    `json:"hash"`
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"mod_time"`
+	Snapshots []string  `json:"snapshots"`
+}
+
+type FileVersionsResponse struct {
+	Path               string                `json:"path"`
+	Versions           []FileVersionResponse `json:"versions"`
+	EncryptedSnapshots []string              `json:"encrypted_snapshots,omitempty"`
+}
+
+type DiffEntryResponse struct {
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`
+	SizeDelta int64  `json:"size_delta"`
+}
+
+type SnapshotDiffResponse struct {
+	From      string              `json:"from"`
+	To        string              `json:"to"`
+	Added     []DiffEntryResponse `json:"added"`
+	Removed   []DiffEntryResponse `json:"removed"`
+	Modified  []DiffEntryResponse `json:"modified"`
+	SizeDelta int64               `json:"size_delta"`
+}
@@ -0,0 +1,45 @@
+package dto
+
+import "time"
+
+type StoragePoolRequest struct {
+	Name string `json:"name"`
+	// Directory of the pool as the worker sees it, e.g. /mnt/pool-ssd
+	RootPath string `json:"root_path"`
+	// Bytes the pool may fill, its whole filesystem if 0
+	Capacity int64    `json:"capacity"`
+	Labels   []string `json:"labels"`
+}
+
+// StoragePoolUsage is how full the filesystem of a pool is, as reported by
+// the worker, and how much the pool may still take within its capacity.
+type StoragePoolUsage struct {
+	Total     int64 `json:"total"`
+	Free      int64 `json:"free"`
+	Used      int64 `json:"used"`
+	Available int64 `json:"available"`
+}
+
+type StoragePoolResponse struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	RootPath string   `json:"root_path"`
+	Capacity int64    `json:"capacity"`
+	Labels   []string `json:"labels"`
+	Backups  int      `json:"backups"`
+	Hosts    int      `json:"hosts"`
+	// Missing when the worker could not be asked
+	Usage     *StoragePoolUsage `json:"usage,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// AssignStoragePoolRequest assigns a host or migrates a backup to a pool, or
+// to the default backup root when the ID is empty.
+type AssignStoragePoolRequest struct {
+	StoragePoolID string `json:"storage_pool_id"`
+}
+
+type MigrateStorageResponse struct {
+	TaskID string `json:"task_id"`
+}
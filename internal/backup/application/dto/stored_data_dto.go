@@ -0,0 +1,41 @@
+package dto
+
+import "time"
+
+// OrphanResponse is an entry under a storage root that no backup references.
+// Size is what it takes on disk.
+type OrphanResponse struct {
+	Root    string    `json:"root"`
+	Path    string    `json:"path"`
+	IsDir   bool      `json:"is_dir"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+type OrphanedDataResponse struct {
+	Orphans   []OrphanResponse `json:"orphans"`
+	TotalSize int64            `json:"total_size"`
+	// Storage roots the worker could not find
+	Missing []string `json:"missing,omitempty"`
+}
+
+// ReclaimOrphanRequest deletes an orphaned entry for good.
+type ReclaimOrphanRequest struct {
+	Path string `json:"path"`
+}
+
+// AdoptOrphanRequest makes an orphaned entry the data of a backup again.
+type AdoptOrphanRequest struct {
+	Path     string `json:"path"`
+	BackupID string `json:"backup_id"`
+}
+
+// DeleteDataResponse lists the worker tasks removing the stored data of a
+// deleted backup or host.
+type DeleteDataResponse struct {
+	TaskIDs []string `json:"task_ids"`
+}
+
+type ReclaimOrphanResponse struct {
+	TaskID string `json:"task_id"`
+}
@@ -0,0 +1,36 @@
+package dto
+
+import "time"
+
+// TieringPolicyRequest sets where the snapshots of an incremental backup go
+// once they are AfterDays old: into an archive in a cold storage pool, or to
+// a replica target that already holds them.
+type TieringPolicyRequest struct {
+	AfterDays       int    `json:"after_days"`
+	StoragePoolID   string `json:"storage_pool_id,omitempty"`
+	ReplicaTargetID string `json:"replica_target_id,omitempty"`
+	// Encrypt the archives in the storage pool
+	Encrypt bool `json:"encrypt,omitempty"`
+}
+
+type ArchivedSnapshotResponse struct {
+	Snapshot        string    `json:"snapshot"`
+	Tier            string    `json:"tier"` // "pool" or "replica"
+	StoragePoolID   string    `json:"storage_pool_id,omitempty"`
+	ReplicaTargetID string    `json:"replica_target_id,omitempty"`
+	Size            int64     `json:"size"`
+	Encrypted       bool      `json:"encrypted"`
+	ArchivedAt      time.Time `json:"archived_at"`
+}
+
+// TieringResponse is the tiering policy of a backup, nil if it has none, and
+// the snapshots moved off its storage.
+type TieringResponse struct {
+	BackupID string                     `json:"backup_id"`
+	Policy   *TieringPolicyRequest      `json:"policy"`
+	Archived []ArchivedSnapshotResponse `json:"archived"`
+}
+
+type TierResponse struct {
+	TaskID string `json:"task_id"`
+}
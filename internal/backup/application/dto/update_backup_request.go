@@ -1,13 +1,16 @@
 package dto
 
 type UpdateBackupRequest struct {
-	ID          string              `json:"id"`
-	Path        string              `json:"path"`
-	Destination string              `json:"destination"`
-	Schedule    string              `json:"schedule"`
-	Excludes    []string            `json:"excludes"`
-	Incremental bool                `json:"incremental"`
-	Retention   int                 `json:"retention"`
-	Encrypted   bool                `json:"encrypted"`
-	Hooks       []CreateHookRequest `json:"hooks"`
+	ID          string   `json:"id"`
+	Path        string   `json:"path"`
+	Destination string   `json:"destination"`
+	Schedule    string   `json:"schedule"`
+	Excludes    []string `json:"excludes"`
+	Incremental bool     `json:"incremental"`
+	Retention   int      `json:"retention"`
+	// RetentionPolicy is optional; when omitted the calendar rules are left unchanged.
+	RetentionPolicy *RetentionPolicyDTO `json:"retention_policy,omitempty"`
+	Encrypted       bool                `json:"encrypted"`
+	ContentIndex    bool                `json:"content_index"`
+	Hooks           []CreateHookRequest `json:"hooks"`
 }
@@ -54,6 +54,11 @@ func (s *HostService) ListHosts(ctx context.Context) ([]*dto.HostResponse, error
 	for _, host := range hosts {
 		resp := dto.ToHostResponse(host)
 		resp.FailedBackupsCount = failedCounts[host.ID().String()]
+		if !host.Quota().IsZero() {
+			if backups, err := s.backupRepo.FindByHostID(ctx, host.ID()); err == nil {
+				resp.Quota = dto.ToQuotaResponse(host.Quota(), entities.HostStoredBytes(backups))
+			}
+		}
 		responses = append(responses, resp)
 	}
 
@@ -96,6 +101,7 @@ func (s *HostService) GetHost(ctx context.Context, id string) (*dto.HostResponse
 			}
 		}
 		resp.FailedBackupsCount = count
+		resp.Quota = dto.ToQuotaResponse(host.Quota(), entities.HostStoredBytes(backups))
 	}
 
 	return resp, nil
@@ -135,5 +141,25 @@ func (s *HostService) DeleteHost(ctx context.Context, id string) error {
 		return err
 	}
 
+	host, err := s.repo.Get(ctx, hostID)
+	if err != nil {
+		return err
+	}
+
+	if host.LegalHold() {
+		return entities.ErrLegalHold
+	}
+
+	// Deleting a host deletes its backups, so a hold on any of them blocks it.
+	backups, err := s.backupRepo.FindByHostID(ctx, hostID)
+	if err != nil {
+		return err
+	}
+	for _, b := range backups {
+		if b.LegalHold() {
+			return entities.ErrLegalHold
+		}
+	}
+
 	return s.repo.Delete(ctx, hostID)
 }
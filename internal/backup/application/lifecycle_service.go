@@ -2,6 +2,7 @@ package application
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/rrbarrero/justbackup/internal/backup/application/assembler"
@@ -13,22 +14,28 @@ import (
 
 type BackupLifecycleService struct {
 	repo        interfaces.BackupRepository
+	catalogRepo interfaces.FileCatalogRepository
 	hostService *HostService
 	publisher   interfaces.TaskPublisher
 	assembler   *assembler.BackupAssembler
+	pools       *StoragePoolService
 }
 
 func NewBackupLifecycleService(
 	repo interfaces.BackupRepository,
+	catalogRepo interfaces.FileCatalogRepository,
 	hostService *HostService,
 	publisher interfaces.TaskPublisher,
 	assembler *assembler.BackupAssembler,
+	pools *StoragePoolService,
 ) *BackupLifecycleService {
 	return &BackupLifecycleService{
 		repo:        repo,
+		catalogRepo: catalogRepo,
 		hostService: hostService,
 		publisher:   publisher,
 		assembler:   assembler,
+		pools:       pools,
 	}
 }
 
@@ -48,6 +55,17 @@ func (s *BackupLifecycleService) CreateBackup(ctx context.Context, req dto.Creat
 	if err != nil {
 		return nil, err
 	}
+	if err := applyRetentionPolicy(backup, req.Retention, req.RetentionPolicy); err != nil {
+		return nil, err
+	}
+	backup.SetContentIndex(req.ContentIndex)
+	if s.pools != nil {
+		poolID, err := s.pools.Place(ctx, hostResp.StoragePoolID, req.StoragePoolID, req.PoolLabels)
+		if err != nil {
+			return nil, err
+		}
+		backup.SetStoragePool(poolID)
+	}
 
 	// Handle embedded hooks
 	for _, h := range req.Hooks {
@@ -80,10 +98,15 @@ func (s *BackupLifecycleService) UpdateBackup(ctx context.Context, req dto.Updat
 		return nil, err
 	}
 
+	wasIndexed := backup.ContentIndex()
 	schedule := entities.NewBackupSchedule(req.Schedule)
 	if err := backup.Update(req.Path, req.Destination, schedule, req.Excludes, req.Incremental, req.Retention, req.Encrypted); err != nil {
 		return nil, err
 	}
+	if err := applyRetentionPolicy(backup, req.Retention, req.RetentionPolicy); err != nil {
+		return nil, err
+	}
+	backup.SetContentIndex(req.ContentIndex)
 
 	// Handle embedded hooks (replace all)
 	newHooks := make([]*entities.BackupHook, 0, len(req.Hooks))
@@ -99,15 +122,51 @@ func (s *BackupLifecycleService) UpdateBackup(ctx context.Context, req dto.Updat
 		return nil, err
 	}
 
+	// Indexed text is dropped as soon as the index is turned off rather
+	// than lingering until the snapshots holding it are purged.
+	if wasIndexed && !backup.ContentIndex() {
+		if err := s.catalogRepo.DeleteContents(ctx, backup.ID()); err != nil {
+			return nil, err
+		}
+	}
+
 	return s.assembler.ToBackupResponse(backup, hostResp.Name, hostResp.Hostname), nil
 }
 
+// applyRetentionPolicy sets the calendar retention rules of a backup when the
+// request carries them.
+func applyRetentionPolicy(backup *entities.Backup, keepLast int, req *dto.RetentionPolicyDTO) error {
+	if req == nil {
+		return nil
+	}
+	policy, err := valueobjects.NewRetentionPolicy(keepLast, req.KeepDaily, req.KeepWeekly, req.KeepMonthly, req.KeepYearly)
+	if err != nil {
+		return err
+	}
+	backup.SetRetentionPolicy(policy)
+	return nil
+}
+
 func (s *BackupLifecycleService) DeleteBackup(ctx context.Context, id string) error {
 	bid, err := valueobjects.NewBackupIDFromString(id)
 	if err != nil {
 		return err
 	}
 
+	backup, err := s.repo.FindByID(ctx, bid)
+	if err != nil {
+		return err
+	}
+
+	host, err := s.hostService.GetHostEntity(ctx, backup.HostID().String())
+	if err != nil {
+		return err
+	}
+
+	if entities.UnderLegalHold(backup, host) {
+		return entities.ErrLegalHold
+	}
+
 	return s.repo.Delete(ctx, bid)
 }
 
@@ -121,6 +180,9 @@ func (s *BackupLifecycleService) RunBackup(ctx context.Context, id string) (stri
 	if err != nil {
 		return "", err
 	}
+	if backup.Migrating() {
+		return "", fmt.Errorf("%w: run it once its data is in place", valueobjects.ErrBackupMigrating)
+	}
 
 	if err := s.publisher.Publish(ctx, backup); err != nil {
 		return "", err
@@ -129,6 +191,22 @@ func (s *BackupLifecycleService) RunBackup(ctx context.Context, id string) (stri
 	return backup.ID().String(), nil
 }
 
+// RebuildCatalog asks a worker to catalog the files of every plain snapshot
+// of a backup again, e.g. for snapshots taken before the catalog existed.
+func (s *BackupLifecycleService) RebuildCatalog(ctx context.Context, id string) (string, error) {
+	bid, err := valueobjects.NewBackupIDFromString(id)
+	if err != nil {
+		return "", err
+	}
+
+	backup, err := s.repo.FindByID(ctx, bid)
+	if err != nil {
+		return "", err
+	}
+
+	return s.publisher.PublishCatalogTask(ctx, backup, "")
+}
+
 func (s *BackupLifecycleService) RunHostBackups(ctx context.Context, hostID string) ([]string, error) {
 	hid, err := entities.NewHostIDFromString(hostID)
 	if err != nil {
@@ -142,6 +220,9 @@ func (s *BackupLifecycleService) RunHostBackups(ctx context.Context, hostID stri
 
 	var taskIDs []string
 	for _, b := range backups {
+		if b.Migrating() {
+			continue
+		}
 		if err := s.publisher.Publish(ctx, b); err != nil {
 			return nil, err
 		}
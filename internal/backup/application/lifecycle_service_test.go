@@ -8,6 +8,7 @@ import (
 	"github.com/rrbarrero/justbackup/internal/backup/application/assembler"
 	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
 	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/infrastructure/persistence/memory"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -18,7 +19,7 @@ func TestBackupLifecycleService_CreateBackup(t *testing.T) {
 	mockPublisher := new(MockTaskPublisher)
 	hostService := NewHostService(mockHostRepo, mockRepo)
 	backupAssembler := assembler.NewBackupAssembler()
-	service := NewBackupLifecycleService(mockRepo, hostService, mockPublisher, backupAssembler)
+	service := NewBackupLifecycleService(mockRepo, memory.NewFileCatalogRepositoryMemory(), hostService, mockPublisher, backupAssembler, nil)
 	ctx := context.Background()
 
 	validHostID := "d85f812d-7c2a-4c2f-b8d9-2e0f4f9f7d2f" // Example valid UUID
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/interfaces"
 	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
 	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
 	"github.com/stretchr/testify/mock"
@@ -130,8 +131,8 @@ func (m *MockTaskPublisher) PublishMeasureTask(ctx context.Context, hostID strin
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockTaskPublisher) PublishSearchTask(ctx context.Context, pattern string) (string, error) {
-	args := m.Called(ctx, pattern)
+func (m *MockTaskPublisher) PublishCatalogTask(ctx context.Context, backup *entities.Backup, snapshot string) (string, error) {
+	args := m.Called(ctx, backup, snapshot)
 	return args.String(0), args.Error(1)
 }
 
@@ -140,8 +141,13 @@ func (m *MockTaskPublisher) Publish(ctx context.Context, backup *entities.Backup
 	return args.Error(0)
 }
 
-func (m *MockTaskPublisher) PublishRestoreTask(ctx context.Context, backup *entities.Backup, path string, restoreAddr string, restoreToken string) (string, error) {
-	args := m.Called(ctx, backup, path, restoreAddr, restoreToken)
+func (m *MockTaskPublisher) PublishRestoreTask(ctx context.Context, backup *entities.Backup, path string, archivePath string, replica *valueobjects.ReplicaLocation, cold *valueobjects.ColdLocation, restoreAddr string, restoreToken string, restoreFingerprint string) (string, error) {
+	args := m.Called(ctx, backup, path, archivePath, replica, cold, restoreAddr, restoreToken, restoreFingerprint)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTaskPublisher) PublishListArchiveTask(ctx context.Context, backupID string, archive string, archivePath string) (string, error) {
+	args := m.Called(ctx, backupID, archive, archivePath)
 	return args.String(0), args.Error(1)
 }
 
@@ -150,8 +156,58 @@ func (m *MockTaskPublisher) PublishListFilesTask(ctx context.Context, path strin
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockTaskPublisher) PublishRemoteRestoreTask(ctx context.Context, backup *entities.Backup, path string, targetHost *entities.Host, targetPath string) (string, error) {
-	args := m.Called(ctx, backup, path, targetHost, targetPath)
+func (m *MockTaskPublisher) PublishListSnapshotsTask(ctx context.Context, path string) (string, error) {
+	args := m.Called(ctx, path)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTaskPublisher) PublishFileVersionsTask(ctx context.Context, path string, filePath string) (string, error) {
+	args := m.Called(ctx, path, filePath)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTaskPublisher) PublishDiffSnapshotsTask(ctx context.Context, path string, from string, to string) (string, error) {
+	args := m.Called(ctx, path, from, to)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTaskPublisher) PublishRemoteRestoreTask(ctx context.Context, backup *entities.Backup, path string, archivePath string, replica *valueobjects.ReplicaLocation, cold *valueobjects.ColdLocation, targetHost *entities.Host, targetPath string, options valueobjects.RestoreOptions, taskID string) (string, error) {
+	args := m.Called(ctx, backup, path, archivePath, replica, cold, targetHost, targetPath, options, taskID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTaskPublisher) PublishDownloadTask(ctx context.Context, backup *entities.Backup, path string, archivePath string, replica *valueobjects.ReplicaLocation, cold *valueobjects.ColdLocation, format valueobjects.DownloadFormat, stream string) (string, error) {
+	args := m.Called(ctx, backup, path, archivePath, replica, cold, format, stream)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTaskPublisher) PublishReplicateTask(ctx context.Context, backup *entities.Backup, endpoint valueobjects.ReplicaEndpoint, replicated []string, retention int) (string, error) {
+	args := m.Called(ctx, backup, endpoint, replicated, retention)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTaskPublisher) PublishDiskUsageTask(ctx context.Context, path string) (string, error) {
+	args := m.Called(ctx, path)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTaskPublisher) PublishMigrateStorageTask(ctx context.Context, backup *entities.Backup, root string, poolID string) (string, error) {
+	args := m.Called(ctx, backup, root, poolID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTaskPublisher) PublishTierTask(ctx context.Context, backup *entities.Backup, policy valueobjects.TieringPolicy, coldRoot string, replicated []string) (string, error) {
+	args := m.Called(ctx, backup, policy, coldRoot, replicated)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTaskPublisher) PublishDeleteDataTask(ctx context.Context, backupID string, root string, path string, cold []valueobjects.ColdLocation) (string, error) {
+	args := m.Called(ctx, backupID, root, path, cold)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTaskPublisher) PublishScanOrphansTask(ctx context.Context, roots []string, referenced []string) (string, error) {
+	args := m.Called(ctx, roots, referenced)
 	return args.String(0), args.Error(1)
 }
 
@@ -192,12 +248,55 @@ type MockWorkerQueryBus struct {
 	mock.Mock
 }
 
-func (m *MockWorkerQueryBus) SearchFiles(ctx context.Context, pattern string) (workerDto.SearchFilesResult, error) {
-	args := m.Called(ctx, pattern)
-	return args.Get(0).(workerDto.SearchFilesResult), args.Error(1)
-}
-
 func (m *MockWorkerQueryBus) ListFiles(ctx context.Context, path string) (workerDto.ListFilesResult, error) {
 	args := m.Called(ctx, path)
 	return args.Get(0).(workerDto.ListFilesResult), args.Error(1)
 }
+
+func (m *MockWorkerQueryBus) ListArchive(ctx context.Context, backupID string, archive string, archivePath string) (workerDto.ListFilesResult, error) {
+	args := m.Called(ctx, backupID, archive, archivePath)
+	return args.Get(0).(workerDto.ListFilesResult), args.Error(1)
+}
+
+func (m *MockWorkerQueryBus) ListSnapshots(ctx context.Context, path string) (workerDto.ListSnapshotsResult, error) {
+	args := m.Called(ctx, path)
+	return args.Get(0).(workerDto.ListSnapshotsResult), args.Error(1)
+}
+
+func (m *MockWorkerQueryBus) FileVersions(ctx context.Context, path string, filePath string) (workerDto.FileVersionsResult, error) {
+	args := m.Called(ctx, path, filePath)
+	return args.Get(0).(workerDto.FileVersionsResult), args.Error(1)
+}
+
+func (m *MockWorkerQueryBus) DiffSnapshots(ctx context.Context, path string, from string, to string) (workerDto.SnapshotDiffResult, error) {
+	args := m.Called(ctx, path, from, to)
+	return args.Get(0).(workerDto.SnapshotDiffResult), args.Error(1)
+}
+
+func (m *MockWorkerQueryBus) PreviewRemoteRestore(ctx context.Context, backup *entities.Backup, path string, archivePath string, replica *valueobjects.ReplicaLocation, cold *valueobjects.ColdLocation, targetHost *entities.Host, targetPath string, options valueobjects.RestoreOptions) (workerDto.RestorePreviewResult, error) {
+	args := m.Called(ctx, backup, path, archivePath, replica, cold, targetHost, targetPath, options)
+	return args.Get(0).(workerDto.RestorePreviewResult), args.Error(1)
+}
+
+func (m *MockWorkerQueryBus) DiskUsage(ctx context.Context, path string) (workerDto.DiskUsageResult, error) {
+	args := m.Called(ctx, path)
+	return args.Get(0).(workerDto.DiskUsageResult), args.Error(1)
+}
+
+func (m *MockWorkerQueryBus) ScanOrphans(ctx context.Context, roots []string, referenced []string) (workerDto.OrphanScanResult, error) {
+	args := m.Called(ctx, roots, referenced)
+	return args.Get(0).(workerDto.OrphanScanResult), args.Error(1)
+}
+
+// recordingDownloadStager starts every download and records its key.
+type recordingDownloadStager struct {
+	keys []string
+}
+
+func (s *recordingDownloadStager) Stage(ctx context.Context, key string, start func(ctx context.Context, stream string) error) (*interfaces.StagedDownload, error) {
+	s.keys = append(s.keys, key)
+	if err := start(ctx, "download_stream:"+key); err != nil {
+		return nil, err
+	}
+	return &interfaces.StagedDownload{Path: "/tmp/" + key + ".download", Size: 42}, nil
+}
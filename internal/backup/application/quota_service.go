@@ -0,0 +1,80 @@
+package application
+
+import (
+	"context"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/interfaces"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+)
+
+// QuotaService sets the storage quotas that cap how much of the backup root
+// a host or a single backup may take. Runs are checked against them by the
+// worker, with the budget the scheduler sends along each task.
+type QuotaService struct {
+	backupRepo interfaces.BackupRepository
+	hostRepo   interfaces.HostRepository
+}
+
+func NewQuotaService(backupRepo interfaces.BackupRepository, hostRepo interfaces.HostRepository) *QuotaService {
+	return &QuotaService{
+		backupRepo: backupRepo,
+		hostRepo:   hostRepo,
+	}
+}
+
+// SetHostQuota caps what all backups of a host store together.
+func (s *QuotaService) SetHostQuota(ctx context.Context, hostID string, req dto.QuotaRequest) (*dto.QuotaResponse, error) {
+	quota, err := valueobjects.NewQuota(req.Bytes, req.SnapshotBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	hid, err := entities.NewHostIDFromString(hostID)
+	if err != nil {
+		return nil, err
+	}
+
+	host, err := s.hostRepo.Get(ctx, hid)
+	if err != nil {
+		return nil, err
+	}
+
+	backups, err := s.backupRepo.FindByHostID(ctx, hid)
+	if err != nil {
+		return nil, err
+	}
+
+	host.SetQuota(quota)
+	if err := s.hostRepo.Update(ctx, host); err != nil {
+		return nil, err
+	}
+
+	return dto.ToQuotaResponse(quota, entities.HostStoredBytes(backups)), nil
+}
+
+// SetBackupQuota caps what a single backup stores.
+func (s *QuotaService) SetBackupQuota(ctx context.Context, backupID string, req dto.QuotaRequest) (*dto.QuotaResponse, error) {
+	quota, err := valueobjects.NewQuota(req.Bytes, req.SnapshotBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	bid, err := valueobjects.NewBackupIDFromString(backupID)
+	if err != nil {
+		return nil, err
+	}
+
+	backup, err := s.backupRepo.FindByID(ctx, bid)
+	if err != nil {
+		return nil, err
+	}
+
+	backup.SetQuota(quota)
+	if err := s.backupRepo.Save(ctx, backup); err != nil {
+		return nil, err
+	}
+
+	return dto.ToQuotaResponse(quota, backup.Usage().Stored), nil
+}
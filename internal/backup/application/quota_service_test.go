@@ -0,0 +1,65 @@
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaService_SetHostQuota(t *testing.T) {
+	f := newStoragePoolFixture(t)
+	ctx := context.Background()
+	f.backup.SetUsage(valueobjects.SpaceUsage{Size: 800, Stored: 1200})
+	require.NoError(t, f.backupRepo.Save(ctx, f.backup))
+	service := NewQuotaService(f.backupRepo, f.hostRepo)
+	hosts := NewHostService(f.hostRepo, f.backupRepo)
+
+	resp, err := service.SetHostQuota(ctx, f.host.ID().String(), dto.QuotaRequest{Bytes: 1000})
+	require.NoError(t, err)
+	assert.Equal(t, &dto.QuotaResponse{Bytes: 1000, Used: 1200, Exceeded: true}, resp)
+
+	host, err := hosts.GetHost(ctx, f.host.ID().String())
+	require.NoError(t, err)
+	assert.Equal(t, resp, host.Quota)
+
+	listed, err := hosts.ListHosts(ctx)
+	require.NoError(t, err)
+	for _, h := range listed {
+		if h.ID == f.host.ID().String() {
+			assert.Equal(t, resp, h.Quota)
+		} else {
+			assert.Nil(t, h.Quota)
+		}
+	}
+
+	// Zero lifts the quota.
+	resp, err = service.SetHostQuota(ctx, f.host.ID().String(), dto.QuotaRequest{})
+	require.NoError(t, err)
+	assert.Nil(t, resp)
+	host, err = hosts.GetHost(ctx, f.host.ID().String())
+	require.NoError(t, err)
+	assert.Nil(t, host.Quota)
+}
+
+func TestQuotaService_SetBackupQuota(t *testing.T) {
+	f := newStoragePoolFixture(t)
+	ctx := context.Background()
+	f.backup.SetUsage(valueobjects.SpaceUsage{Size: 800, Stored: 400})
+	require.NoError(t, f.backupRepo.Save(ctx, f.backup))
+	service := NewQuotaService(f.backupRepo, f.hostRepo)
+
+	resp, err := service.SetBackupQuota(ctx, f.backup.ID().String(), dto.QuotaRequest{Bytes: 1000, SnapshotBytes: 300})
+	require.NoError(t, err)
+	assert.Equal(t, &dto.QuotaResponse{Bytes: 1000, SnapshotBytes: 300, Used: 400}, resp)
+
+	stored, err := f.backupRepo.FindByID(ctx, f.backup.ID())
+	require.NoError(t, err)
+	assert.Equal(t, valueobjects.Quota{Bytes: 1000, SnapshotBytes: 300}, stored.Quota())
+
+	_, err = service.SetBackupQuota(ctx, f.backup.ID().String(), dto.QuotaRequest{Bytes: -1})
+	assert.ErrorIs(t, err, valueobjects.ErrInvalidQuota)
+}
@@ -0,0 +1,507 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/interfaces"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+)
+
+// ReplicaService manages replica targets, the offsite storage the snapshots
+// of backups are copied to after each successful run, and keeps track of
+// which snapshots each target holds so restores can fall back to them.
+type ReplicaService struct {
+	targetRepo   interfaces.ReplicaTargetRepository
+	snapshotRepo interfaces.ReplicaSnapshotRepository
+	backupRepo   interfaces.BackupRepository
+	publisher    interfaces.TaskPublisher
+}
+
+func NewReplicaService(
+	targetRepo interfaces.ReplicaTargetRepository,
+	snapshotRepo interfaces.ReplicaSnapshotRepository,
+	backupRepo interfaces.BackupRepository,
+	publisher interfaces.TaskPublisher,
+) *ReplicaService {
+	return &ReplicaService{
+		targetRepo:   targetRepo,
+		snapshotRepo: snapshotRepo,
+		backupRepo:   backupRepo,
+		publisher:    publisher,
+	}
+}
+
+func (s *ReplicaService) CreateTarget(ctx context.Context, req dto.ReplicaTargetRequest) (*dto.ReplicaTargetResponse, error) {
+	spec, err := s.parseTargetRequest(ctx, req, valueobjects.S3Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := entities.NewReplicaTarget(req.Name, spec.kind, spec.s3, spec.rsync, spec.policy, spec.backupIDs)
+	if err != nil {
+		return nil, err
+	}
+	if req.Enabled != nil && !*req.Enabled {
+		target.Disable()
+	}
+
+	if err := s.targetRepo.Save(ctx, target); err != nil {
+		return nil, err
+	}
+	return toReplicaTargetResponse(target), nil
+}
+
+func (s *ReplicaService) UpdateTarget(ctx context.Context, id string, req dto.ReplicaTargetRequest) (*dto.ReplicaTargetResponse, error) {
+	target, err := s.findTarget(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := s.parseTargetRequest(ctx, req, target.S3())
+	if err != nil {
+		return nil, err
+	}
+	if err := target.Update(req.Name, spec.kind, spec.s3, spec.rsync, spec.policy, spec.backupIDs); err != nil {
+		return nil, err
+	}
+	if req.Enabled != nil {
+		if *req.Enabled {
+			target.Enable()
+		} else {
+			target.Disable()
+		}
+	}
+
+	if err := s.targetRepo.Save(ctx, target); err != nil {
+		return nil, err
+	}
+	return toReplicaTargetResponse(target), nil
+}
+
+// replicaTargetSpec is a parsed target request.
+type replicaTargetSpec struct {
+	kind      valueobjects.ReplicaTargetKind
+	s3        valueobjects.S3Config
+	rsync     valueobjects.RsyncConfig
+	policy    valueobjects.ReplicaPolicy
+	backupIDs []valueobjects.BackupID
+}
+
+// parseTargetRequest validates the kind, settings and backups of a target
+// request. An empty secret key keeps the current one.
+func (s *ReplicaService) parseTargetRequest(ctx context.Context, req dto.ReplicaTargetRequest, current valueobjects.S3Config) (replicaTargetSpec, error) {
+	kind, err := valueobjects.ParseReplicaTargetKind(req.Kind)
+	if err != nil {
+		return replicaTargetSpec{}, err
+	}
+
+	spec := replicaTargetSpec{
+		kind:   kind,
+		policy: valueobjects.ReplicaPolicy{BandwidthLimit: req.BandwidthLimit, Retention: req.Retention},
+	}
+	switch kind {
+	case valueobjects.ReplicaTargetS3:
+		if req.S3 == nil {
+			return replicaTargetSpec{}, fmt.Errorf("%w: s3 settings are required", valueobjects.ErrInvalidReplicaTarget)
+		}
+		spec.s3 = valueobjects.S3Config{
+			Endpoint:  req.S3.Endpoint,
+			Region:    req.S3.Region,
+			Bucket:    req.S3.Bucket,
+			Prefix:    req.S3.Prefix,
+			AccessKey: req.S3.AccessKey,
+			SecretKey: req.S3.SecretKey,
+			PathStyle: req.S3.PathStyle,
+		}
+		if spec.s3.SecretKey == "" {
+			spec.s3.SecretKey = current.SecretKey
+		}
+	case valueobjects.ReplicaTargetRsync:
+		if req.Rsync == nil {
+			return replicaTargetSpec{}, fmt.Errorf("%w: rsync settings are required", valueobjects.ErrInvalidReplicaTarget)
+		}
+		spec.rsync = valueobjects.RsyncConfig{
+			Host: req.Rsync.Host,
+			User: req.Rsync.User,
+			Port: req.Rsync.Port,
+			Path: req.Rsync.Path,
+		}
+	}
+
+	spec.backupIDs = make([]valueobjects.BackupID, 0, len(req.BackupIDs))
+	for _, id := range req.BackupIDs {
+		backupID, err := valueobjects.NewBackupIDFromString(id)
+		if err != nil {
+			return replicaTargetSpec{}, err
+		}
+		if _, err := s.backupRepo.FindByID(ctx, backupID); err != nil {
+			return replicaTargetSpec{}, err
+		}
+		spec.backupIDs = append(spec.backupIDs, backupID)
+	}
+	return spec, nil
+}
+
+func (s *ReplicaService) ListTargets(ctx context.Context) ([]dto.ReplicaTargetResponse, error) {
+	targets, err := s.targetRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	responses := make([]dto.ReplicaTargetResponse, 0, len(targets))
+	for _, target := range targets {
+		responses = append(responses, *toReplicaTargetResponse(target))
+	}
+	return responses, nil
+}
+
+func (s *ReplicaService) GetTarget(ctx context.Context, id string) (*dto.ReplicaTargetResponse, error) {
+	target, err := s.findTarget(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toReplicaTargetResponse(target), nil
+}
+
+// DeleteTarget deletes a target and the record of what it holds. The data
+// already copied to it is left in place.
+func (s *ReplicaService) DeleteTarget(ctx context.Context, id string) error {
+	target, err := s.findTarget(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.snapshotRepo.DeleteByTarget(ctx, target.ID()); err != nil {
+		return err
+	}
+	return s.targetRepo.Delete(ctx, target.ID())
+}
+
+// Sync copies the snapshots of a backup that its targets do not hold yet
+// now, outside of a backup run.
+func (s *ReplicaService) Sync(ctx context.Context, backupID string) (*dto.ReplicateResponse, error) {
+	bid, err := valueobjects.NewBackupIDFromString(backupID)
+	if err != nil {
+		return nil, err
+	}
+	backup, err := s.backupRepo.FindByID(ctx, bid)
+	if err != nil {
+		return nil, err
+	}
+
+	taskIDs, err := s.replicate(ctx, backup)
+	if err != nil {
+		return nil, err
+	}
+	if len(taskIDs) == 0 {
+		return nil, valueobjects.ErrNoReplicaTarget
+	}
+	return &dto.ReplicateResponse{TaskIDs: taskIDs}, nil
+}
+
+// OnBackupCompleted copies the new snapshots of a backup after a successful
+// run.
+func (s *ReplicaService) OnBackupCompleted(ctx context.Context, backup *entities.Backup) {
+	taskIDs, err := s.replicate(ctx, backup)
+	if err != nil {
+		log.Printf("Failed to replicate backup %s: %v", backup.ID(), err)
+		return
+	}
+	if len(taskIDs) > 0 {
+		log.Printf("Published %d replicate tasks for backup %s", len(taskIDs), backup.ID())
+	}
+}
+
+// replicate publishes a replicate task to every enabled target covering the
+// backup, telling each the snapshots it already holds and how many it keeps.
+// A mirror is copied again on every run, against the copy held.
+func (s *ReplicaService) replicate(ctx context.Context, backup *entities.Backup) ([]string, error) {
+	targets, err := s.targetRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	replicated, err := s.replicatedByTarget(ctx, backup.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	var taskIDs []string
+	for _, target := range targets {
+		if !target.Enabled() || !target.Covers(backup.ID()) {
+			continue
+		}
+		taskID, err := s.publisher.PublishReplicateTask(ctx, backup, target.Endpoint(), replicated[target.ID()], target.Policy().Retention)
+		if err != nil {
+			return taskIDs, fmt.Errorf("target %s: %w", target.Name(), err)
+		}
+		taskIDs = append(taskIDs, taskID)
+	}
+	return taskIDs, nil
+}
+
+func (s *ReplicaService) replicatedByTarget(ctx context.Context, backupID valueobjects.BackupID) (map[uuid.UUID][]string, error) {
+	snapshots, err := s.snapshotRepo.FindByBackupID(ctx, backupID)
+	if err != nil {
+		return nil, err
+	}
+	replicated := make(map[uuid.UUID][]string)
+	for _, snapshot := range snapshots {
+		replicated[snapshot.TargetID] = append(replicated[snapshot.TargetID], snapshot.Snapshot)
+	}
+	return replicated, nil
+}
+
+// RecordResult records the snapshots a replicate task copied, including
+// those a failed task finished, forgets those it pruned, and returns the
+// target they were copied to.
+func (s *ReplicaService) RecordResult(ctx context.Context, result workerDto.ReplicateResult) (*entities.ReplicaTarget, error) {
+	targetID, err := uuid.Parse(result.TargetID)
+	if err != nil {
+		return nil, shared.ErrInvalidID
+	}
+	backupID, err := valueobjects.NewBackupIDFromString(result.BackupID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.targetRepo.FindByID(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, snapshot := range result.Snapshots {
+		err := s.snapshotRepo.Save(ctx, &entities.ReplicaSnapshot{
+			TargetID:      targetID,
+			BackupID:      backupID,
+			Snapshot:      snapshot.Name,
+			Files:         snapshot.Files,
+			Bytes:         snapshot.Bytes,
+			UploadedBytes: snapshot.UploadedBytes,
+			ReplicatedAt:  entities.NowFunc(),
+		})
+		if err != nil {
+			return target, err
+		}
+	}
+	for _, name := range result.Pruned {
+		if err := s.snapshotRepo.Delete(ctx, targetID, backupID, name); err != nil {
+			return target, err
+		}
+	}
+	return target, nil
+}
+
+// ListBackupReplicas returns the state of a backup on each target covering
+// it or still holding snapshots of it, with how far each lags behind the
+// last run of the backup.
+func (s *ReplicaService) ListBackupReplicas(ctx context.Context, backupID string) ([]dto.BackupReplicaResponse, error) {
+	bid, err := valueobjects.NewBackupIDFromString(backupID)
+	if err != nil {
+		return nil, err
+	}
+	backup, err := s.backupRepo.FindByID(ctx, bid)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := s.targetRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := s.snapshotRepo.FindByBackupID(ctx, bid)
+	if err != nil {
+		return nil, err
+	}
+
+	held := make(map[uuid.UUID][]*entities.ReplicaSnapshot)
+	for _, snapshot := range snapshots {
+		held[snapshot.TargetID] = append(held[snapshot.TargetID], snapshot)
+	}
+
+	lastRun := backup.Schedule().LastRun
+	responses := make([]dto.BackupReplicaResponse, 0, len(targets))
+	for _, target := range targets {
+		if !target.Covers(bid) && len(held[target.ID()]) == 0 {
+			continue
+		}
+		response := dto.BackupReplicaResponse{
+			TargetID:   target.ID().String(),
+			TargetName: target.Name(),
+			Kind:       string(target.Kind()),
+			Enabled:    target.Enabled(),
+			Snapshots:  make([]dto.ReplicaSnapshotResponse, 0, len(held[target.ID()])),
+		}
+		for _, snapshot := range held[target.ID()] {
+			response.Snapshots = append(response.Snapshots, dto.ReplicaSnapshotResponse{
+				Snapshot:      snapshot.Snapshot,
+				Files:         snapshot.Files,
+				Bytes:         snapshot.Bytes,
+				UploadedBytes: snapshot.UploadedBytes,
+				ReplicatedAt:  snapshot.ReplicatedAt,
+			})
+			response.LatestSnapshot = max(response.LatestSnapshot, snapshot.Snapshot)
+			if response.LastReplicatedAt == nil || snapshot.ReplicatedAt.After(*response.LastReplicatedAt) {
+				replicatedAt := snapshot.ReplicatedAt
+				response.LastReplicatedAt = &replicatedAt
+			}
+		}
+
+		response.InSync = lastRun.IsZero() || (response.LastReplicatedAt != nil && !response.LastReplicatedAt.Before(lastRun))
+		if !response.InSync {
+			response.LagSeconds = int64(entities.NowFunc().Sub(lastRun).Seconds())
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
+
+// replicaIndex returns the snapshots of a backup held by its enabled
+// targets.
+func (s *ReplicaService) replicaIndex(ctx context.Context, backup *entities.Backup) (*replicaIndex, error) {
+	snapshots, err := s.snapshotRepo.FindByBackupID(ctx, backup.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	index := &replicaIndex{backup: backup, endpoints: make(map[string]valueobjects.ReplicaEndpoint)}
+	targets := make(map[uuid.UUID]*entities.ReplicaTarget)
+	for _, snapshot := range snapshots {
+		target, seen := targets[snapshot.TargetID]
+		if !seen {
+			target, err = s.targetRepo.FindByID(ctx, snapshot.TargetID)
+			if err != nil && err != shared.ErrNotFound {
+				return nil, err
+			}
+			targets[snapshot.TargetID] = target
+		}
+		if target == nil || !target.Enabled() {
+			continue
+		}
+		if _, ok := index.endpoints[snapshot.Snapshot]; ok {
+			continue
+		}
+		index.endpoints[snapshot.Snapshot] = target.Endpoint()
+		index.names = append(index.names, snapshot.Snapshot)
+		index.latest = max(index.latest, snapshot.Snapshot)
+	}
+	return index, nil
+}
+
+// replicaIndex maps the replicated snapshots of a backup to a target
+// holding them.
+type replicaIndex struct {
+	backup    *entities.Backup
+	endpoints map[string]valueobjects.ReplicaEndpoint
+	names     []string
+	latest    string
+}
+
+// resolve picks the replicated snapshot a selector points to, laid out the
+// way it is at the root of the backup.
+func (i *replicaIndex) resolve(selector valueobjects.SnapshotSelector) (snapshotLocation, error) {
+	name, err := selector.Resolve(i.names)
+	if err != nil {
+		return snapshotLocation{}, err
+	}
+	if i.backup.Encrypted() {
+		return snapshotLocation{Archive: name + valueobjects.EncryptedSnapshotSuffix}, nil
+	}
+	return snapshotLocation{Dir: name}, nil
+}
+
+// locate returns where the source a restore reads under root is found on a
+// target, or nil when no target holds it.
+func (i *replicaIndex) locate(root string, source string) *valueobjects.ReplicaLocation {
+	if !i.backup.Incremental() {
+		endpoint, ok := i.endpoints[valueobjects.SnapshotLatest]
+		if !ok {
+			return nil
+		}
+		location := &valueobjects.ReplicaLocation{ReplicaEndpoint: endpoint, Snapshot: valueobjects.SnapshotLatest}
+		switch {
+		case source == root+valueobjects.EncryptedSnapshotSuffix:
+			location.Path = path.Base(root) + valueobjects.EncryptedSnapshotSuffix
+		case source == root:
+			location.Path = "."
+		case strings.HasPrefix(source, root+"/"):
+			location.Path = strings.TrimPrefix(source, root+"/")
+		default:
+			return nil
+		}
+		return location
+	}
+
+	// Sources of an incremental backup start with the snapshot directory or
+	// archive, where "latest" stands for the newest snapshot.
+	rel, ok := strings.CutPrefix(source, root+"/")
+	if !ok {
+		return nil
+	}
+	first, rest, _ := strings.Cut(rel, "/")
+	name, suffix := first, ""
+	if strings.HasSuffix(first, valueobjects.EncryptedSnapshotSuffix) {
+		name, suffix = strings.TrimSuffix(first, valueobjects.EncryptedSnapshotSuffix), valueobjects.EncryptedSnapshotSuffix
+	}
+	if name == valueobjects.SnapshotLatest {
+		name = i.latest
+	}
+	endpoint, ok := i.endpoints[name]
+	if !ok {
+		return nil
+	}
+	return &valueobjects.ReplicaLocation{ReplicaEndpoint: endpoint, Snapshot: name, Path: path.Join(name+suffix, rest)}
+}
+
+func (s *ReplicaService) findTarget(ctx context.Context, id string) (*entities.ReplicaTarget, error) {
+	targetID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, shared.ErrInvalidID
+	}
+	return s.targetRepo.FindByID(ctx, targetID)
+}
+
+// toReplicaTargetResponse leaves the secret key out.
+func toReplicaTargetResponse(target *entities.ReplicaTarget) *dto.ReplicaTargetResponse {
+	backupIDs := make([]string, 0, len(target.BackupIDs()))
+	for _, id := range target.BackupIDs() {
+		backupIDs = append(backupIDs, id.String())
+	}
+
+	response := &dto.ReplicaTargetResponse{
+		ID:             target.ID().String(),
+		Name:           target.Name(),
+		Kind:           string(target.Kind()),
+		BandwidthLimit: target.Policy().BandwidthLimit,
+		Retention:      target.Policy().Retention,
+		BackupIDs:      backupIDs,
+		Enabled:        target.Enabled(),
+		CreatedAt:      target.CreatedAt(),
+		UpdatedAt:      target.UpdatedAt(),
+	}
+	switch target.Kind() {
+	case valueobjects.ReplicaTargetS3:
+		s3 := target.S3()
+		response.S3 = &dto.ReplicaS3Config{
+			Endpoint:  s3.Endpoint,
+			Region:    s3.Region,
+			Bucket:    s3.Bucket,
+			Prefix:    s3.Prefix,
+			AccessKey: s3.AccessKey,
+			PathStyle: s3.PathStyle,
+		}
+	case valueobjects.ReplicaTargetRsync:
+		rsync := target.Rsync()
+		response.Rsync = &dto.ReplicaRsyncConfig{
+			Host: rsync.Host,
+			User: rsync.User,
+			Port: rsync.Port,
+			Path: rsync.Path,
+		}
+	}
+	return response
+}
@@ -0,0 +1,294 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/backup/infrastructure/persistence/memory"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type replicaFixture struct {
+	service   *ReplicaService
+	restore   *BackupRestoreService
+	publisher *MockTaskPublisher
+	queryBus  *MockWorkerQueryBus
+	backup    *entities.Backup
+}
+
+func newReplicaFixture(t *testing.T, incremental bool) *replicaFixture {
+	t.Helper()
+	backupRepo := new(MockBackupRepository)
+	hostRepo := new(MockHostRepository)
+	publisher := new(MockTaskPublisher)
+	queryBus := new(MockWorkerQueryBus)
+
+	service := NewReplicaService(memory.NewReplicaTargetRepositoryMemory(), memory.NewReplicaSnapshotRepositoryMemory(), backupRepo, publisher)
+	restore := NewBackupRestoreService(backupRepo, NewHostService(hostRepo, backupRepo), publisher, queryBus, nil, service, nil, nil)
+
+	hostID := entities.NewHostID()
+	host := entities.NewHostWithID(hostID, "prod", "prod.local", "root", 22, "prod", false)
+	backupID := valueobjects.NewBackupID()
+	backup, _ := entities.NewBackupWithID(backupID, hostID, "/srv/app", "app", entities.NewBackupSchedule("@daily"), nil, incremental, 5, false)
+
+	backupRepo.On("FindByID", mock.Anything, backupID).Return(backup, nil)
+	backupRepo.On("FindByHostID", mock.Anything, hostID).Return([]*entities.Backup{}, nil)
+	hostRepo.On("Get", mock.Anything, hostID).Return(host, nil)
+
+	return &replicaFixture{service: service, restore: restore, publisher: publisher, queryBus: queryBus, backup: backup}
+}
+
+func s3TargetRequest(name string) dto.ReplicaTargetRequest {
+	return dto.ReplicaTargetRequest{
+		Name: name,
+		Kind: "s3",
+		S3: &dto.ReplicaS3Config{
+			Endpoint:  "http://minio:9000",
+			Bucket:    "backups",
+			AccessKey: "access",
+			SecretKey: "secret",
+			PathStyle: true,
+		},
+	}
+}
+
+func TestReplicaService_TargetSecretIsKept(t *testing.T) {
+	f := newReplicaFixture(t, true)
+	ctx := context.Background()
+
+	created, err := f.service.CreateTarget(ctx, s3TargetRequest("minio"))
+	require.NoError(t, err)
+	assert.Empty(t, created.S3.SecretKey, "the secret key is never returned")
+
+	req := s3TargetRequest("minio-eu")
+	req.S3.SecretKey = ""
+	updated, err := f.service.UpdateTarget(ctx, created.ID, req)
+	require.NoError(t, err)
+	assert.Equal(t, "minio-eu", updated.Name)
+
+	targets, err := f.service.targetRepo.FindAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	assert.Equal(t, "secret", targets[0].S3().SecretKey)
+
+	req = s3TargetRequest("no bucket")
+	req.S3.Bucket = ""
+	_, err = f.service.CreateTarget(ctx, req)
+	assert.ErrorIs(t, err, valueobjects.ErrInvalidReplicaTarget)
+}
+
+func TestReplicaService_SyncPassesReplicatedSnapshots(t *testing.T) {
+	f := newReplicaFixture(t, true)
+	ctx := context.Background()
+
+	_, err := f.service.Sync(ctx, f.backup.ID().String())
+	assert.ErrorIs(t, err, valueobjects.ErrNoReplicaTarget)
+
+	target, err := f.service.CreateTarget(ctx, s3TargetRequest("minio"))
+	require.NoError(t, err)
+	_, err = f.service.RecordResult(ctx, workerDto.ReplicateResult{
+		TargetID:  target.ID,
+		BackupID:  f.backup.ID().String(),
+		Snapshots: []workerDto.ReplicatedSnapshot{{Name: "2024-03-01_02-00-00", Files: 3, Bytes: 30, UploadedBytes: 30}},
+	})
+	require.NoError(t, err)
+
+	f.publisher.On("PublishReplicateTask", ctx, f.backup, mock.MatchedBy(func(e valueobjects.ReplicaEndpoint) bool {
+		return e.TargetID == target.ID && e.S3 != nil && e.S3.SecretKey == "secret"
+	}), []string{"2024-03-01_02-00-00"}, 0).Return("task-1", nil).Once()
+
+	resp, err := f.service.Sync(ctx, f.backup.ID().String())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"task-1"}, resp.TaskIDs)
+	f.publisher.AssertExpectations(t)
+
+	replicas, err := f.service.ListBackupReplicas(ctx, f.backup.ID().String())
+	require.NoError(t, err)
+	require.Len(t, replicas, 1)
+	assert.Equal(t, "minio", replicas[0].TargetName)
+	require.Len(t, replicas[0].Snapshots, 1)
+	assert.Equal(t, int64(30), replicas[0].Snapshots[0].Bytes)
+}
+
+func TestReplicaService_RsyncTargetWithPolicy(t *testing.T) {
+	f := newReplicaFixture(t, true)
+	ctx := context.Background()
+
+	req := dto.ReplicaTargetRequest{
+		Name:           "offsite",
+		Kind:           "rsync",
+		Rsync:          &dto.ReplicaRsyncConfig{Host: "vault.local", User: "backup", Path: "/srv/replica"},
+		BandwidthLimit: 2048,
+		Retention:      3,
+	}
+	created, err := f.service.CreateTarget(ctx, req)
+	require.NoError(t, err)
+	require.NotNil(t, created.Rsync)
+	assert.Equal(t, 22, created.Rsync.Port)
+	assert.Nil(t, created.S3)
+
+	f.publisher.On("PublishReplicateTask", ctx, f.backup, mock.MatchedBy(func(e valueobjects.ReplicaEndpoint) bool {
+		return e.Rsync != nil && e.Rsync.Host == "vault.local" && e.BandwidthLimit == 2048
+	}), []string(nil), 3).Return("task-1", nil).Once()
+	_, err = f.service.Sync(ctx, f.backup.ID().String())
+	require.NoError(t, err)
+	f.publisher.AssertExpectations(t)
+
+	req.Rsync.Path = "relative"
+	_, err = f.service.CreateTarget(ctx, req)
+	assert.ErrorIs(t, err, valueobjects.ErrInvalidReplicaTarget)
+
+	req.Rsync.Path = "/srv/replica"
+	req.Retention = -1
+	_, err = f.service.CreateTarget(ctx, req)
+	assert.ErrorIs(t, err, valueobjects.ErrInvalidReplicaTarget)
+}
+
+func TestReplicaService_ListBackupReplicasReportsLag(t *testing.T) {
+	f := newReplicaFixture(t, true)
+	ctx := context.Background()
+	now := time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)
+	defer func(orig func() time.Time) { entities.NowFunc = orig }(entities.NowFunc)
+
+	target, err := f.service.CreateTarget(ctx, s3TargetRequest("minio"))
+	require.NoError(t, err)
+	_, err = f.service.CreateTarget(ctx, dto.ReplicaTargetRequest{
+		Name:  "vault",
+		Kind:  "rsync",
+		Rsync: &dto.ReplicaRsyncConfig{Host: "vault.local", User: "backup", Path: "/srv/replica"},
+	})
+	require.NoError(t, err)
+
+	entities.NowFunc = func() time.Time { return now.Add(-26 * time.Hour) }
+	_, err = f.service.RecordResult(ctx, workerDto.ReplicateResult{
+		TargetID: target.ID,
+		BackupID: f.backup.ID().String(),
+		Snapshots: []workerDto.ReplicatedSnapshot{
+			{Name: "2024-03-01_02-00-00"},
+			{Name: "2024-03-01_08-00-00"},
+		},
+	})
+	require.NoError(t, err)
+
+	// The backup ran again two hours ago and neither target has the run.
+	entities.NowFunc = func() time.Time { return now.Add(-2 * time.Hour) }
+	require.NoError(t, f.backup.Complete())
+	entities.NowFunc = func() time.Time { return now }
+
+	replicas, err := f.service.ListBackupReplicas(ctx, f.backup.ID().String())
+	require.NoError(t, err)
+	require.Len(t, replicas, 2)
+	assert.Equal(t, "2024-03-01_08-00-00", replicas[0].LatestSnapshot)
+	assert.False(t, replicas[0].InSync)
+	assert.Equal(t, int64(7200), replicas[0].LagSeconds)
+	assert.Equal(t, "vault", replicas[1].TargetName)
+	assert.Nil(t, replicas[1].LastReplicatedAt)
+	assert.False(t, replicas[1].InSync)
+
+	// Pruned snapshots are forgotten and a copy after the run is in sync.
+	_, err = f.service.RecordResult(ctx, workerDto.ReplicateResult{
+		TargetID:  target.ID,
+		BackupID:  f.backup.ID().String(),
+		Snapshots: []workerDto.ReplicatedSnapshot{{Name: "2024-03-02_10-00-00"}},
+		Pruned:    []string{"2024-03-01_02-00-00"},
+	})
+	require.NoError(t, err)
+	replicas, err = f.service.ListBackupReplicas(ctx, f.backup.ID().String())
+	require.NoError(t, err)
+	assert.True(t, replicas[0].InSync)
+	assert.Zero(t, replicas[0].LagSeconds)
+	assert.Len(t, replicas[0].Snapshots, 2)
+}
+
+func TestReplicaService_OnBackupCompletedSkipsDisabledTargets(t *testing.T) {
+	f := newReplicaFixture(t, false)
+	ctx := context.Background()
+
+	req := s3TargetRequest("paused")
+	disabled := false
+	req.Enabled = &disabled
+	_, err := f.service.CreateTarget(ctx, req)
+	require.NoError(t, err)
+
+	f.service.OnBackupCompleted(ctx, f.backup)
+	f.publisher.AssertNotCalled(t, "PublishReplicateTask", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestBackupRestoreService_Restore_FallsBackToReplica(t *testing.T) {
+	f := newReplicaFixture(t, true)
+	ctx := context.Background()
+	root := "/mnt/backups/prod/app"
+
+	target, err := f.service.CreateTarget(ctx, s3TargetRequest("minio"))
+	require.NoError(t, err)
+	_, err = f.service.RecordResult(ctx, workerDto.ReplicateResult{
+		TargetID: target.ID,
+		BackupID: f.backup.ID().String(),
+		Snapshots: []workerDto.ReplicatedSnapshot{
+			{Name: "2024-03-01_02-00-00"},
+			{Name: "2024-03-02_02-00-00"},
+		},
+	})
+	require.NoError(t, err)
+
+	// The backup storage is gone, so snapshots are only found on the target.
+	f.queryBus.On("ListFiles", ctx, root).Return(workerDto.ListFilesResult{}, errors.New("no such file or directory"))
+
+	tests := []struct {
+		name     string
+		snapshot string
+		path     string
+		source   string
+		replica  string
+	}{
+		{"selected snapshot", "2024-03-01 12:00", "etc", root + "/2024-03-01_02-00-00/etc", "2024-03-01_02-00-00/etc"},
+		{"latest snapshot", "latest", "", root + "/2024-03-02_02-00-00", "2024-03-02_02-00-00"},
+		{"latest link without selector", "", "latest/etc", root + "/latest/etc", "2024-03-02_02-00-00/etc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f.publisher.On("PublishRestoreTask", ctx, f.backup, tt.source, "", mock.MatchedBy(func(l *valueobjects.ReplicaLocation) bool {
+				return l != nil && l.TargetID == target.ID && l.Path == tt.replica
+			}), mock.Anything, "127.0.0.1:8080", "token", "").Return("task-1", nil).Once()
+
+			_, err := f.restore.Restore(ctx, dto.RestoreRequest{
+				BackupID:     f.backup.ID().String(),
+				RestoreType:  "local",
+				Path:         tt.path,
+				Snapshot:     tt.snapshot,
+				RestoreAddr:  "127.0.0.1:8080",
+				RestoreToken: "token",
+			})
+			require.NoError(t, err)
+			f.publisher.AssertExpectations(t)
+		})
+	}
+
+	_, err = f.restore.Restore(ctx, dto.RestoreRequest{
+		BackupID:    f.backup.ID().String(),
+		RestoreType: "local",
+		Snapshot:    "2024-02-01",
+	})
+	assert.Error(t, err, "a snapshot neither local nor replicated is not found")
+}
+
+func TestReplicaIndex_LocateMirror(t *testing.T) {
+	f := newReplicaFixture(t, false)
+	root := "/mnt/backups/prod/app"
+	index := &replicaIndex{backup: f.backup, endpoints: map[string]valueobjects.ReplicaEndpoint{
+		valueobjects.SnapshotLatest: {TargetID: "t1", Kind: valueobjects.ReplicaTargetS3},
+	}}
+
+	assert.Equal(t, ".", index.locate(root, root).Path)
+	assert.Equal(t, "etc/hosts", index.locate(root, root+"/etc/hosts").Path)
+	assert.Equal(t, "app.tar.gz.enc", index.locate(root, root+".tar.gz.enc").Path)
+	assert.Nil(t, index.locate(root, "/mnt/backups/prod/other"))
+}
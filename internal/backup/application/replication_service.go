@@ -0,0 +1,361 @@
+package application
+
+import (
+	"context"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/interfaces"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+)
+
+// replicationRunHistory is how many runs of a job ListRuns returns.
+const replicationRunHistory = 50
+
+// ReplicationService manages replication jobs, which run a remote restore
+// of a backup on a schedule to keep a target host in sync with it.
+type ReplicationService struct {
+	jobRepo        interfaces.ReplicationJobRepository
+	runRepo        interfaces.ReplicationRunRepository
+	backupRepo     interfaces.BackupRepository
+	hostService    *HostService
+	restoreService *BackupRestoreService
+}
+
+func NewReplicationService(
+	jobRepo interfaces.ReplicationJobRepository,
+	runRepo interfaces.ReplicationRunRepository,
+	backupRepo interfaces.BackupRepository,
+	hostService *HostService,
+	restoreService *BackupRestoreService,
+) *ReplicationService {
+	return &ReplicationService{
+		jobRepo:        jobRepo,
+		runRepo:        runRepo,
+		backupRepo:     backupRepo,
+		hostService:    hostService,
+		restoreService: restoreService,
+	}
+}
+
+func (s *ReplicationService) CreateJob(ctx context.Context, req dto.ReplicationJobRequest) (*dto.ReplicationJobResponse, error) {
+	backupID, err := valueobjects.NewBackupIDFromString(req.BackupID)
+	if err != nil {
+		return nil, err
+	}
+	backup, err := s.backupRepo.FindByID(ctx, backupID)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, targetHostID, options, err := s.validateJobRequest(ctx, backup, req)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := entities.NewReplicationJob(req.Name, backupID, req.Path, snapshot, targetHostID, req.TargetPath, req.Schedule, options)
+	if err != nil {
+		return nil, err
+	}
+	if req.Enabled != nil && !*req.Enabled {
+		job.Disable()
+	}
+
+	if err := s.jobRepo.Save(ctx, job); err != nil {
+		return nil, err
+	}
+	return toReplicationJobResponse(job), nil
+}
+
+func (s *ReplicationService) UpdateJob(ctx context.Context, id string, req dto.ReplicationJobRequest) (*dto.ReplicationJobResponse, error) {
+	job, err := s.findJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	backup, err := s.backupRepo.FindByID(ctx, job.BackupID())
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, targetHostID, options, err := s.validateJobRequest(ctx, backup, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := job.Update(req.Name, req.Path, snapshot, targetHostID, req.TargetPath, req.Schedule, options); err != nil {
+		return nil, err
+	}
+	if req.Enabled != nil {
+		if *req.Enabled {
+			if err := job.Enable(); err != nil {
+				return nil, err
+			}
+		} else {
+			job.Disable()
+		}
+	}
+
+	if err := s.jobRepo.Save(ctx, job); err != nil {
+		return nil, err
+	}
+	return toReplicationJobResponse(job), nil
+}
+
+// validateJobRequest checks the parts of a job request that depend on the
+// backup and the target host, and returns the snapshot selector, target
+// host and restore options the job is created with.
+func (s *ReplicationService) validateJobRequest(ctx context.Context, backup *entities.Backup, req dto.ReplicationJobRequest) (string, entities.HostID, valueobjects.RestoreOptions, error) {
+	selector, err := valueobjects.ParseSnapshotSelector(req.Snapshot)
+	if err != nil {
+		return "", entities.HostID{}, valueobjects.RestoreOptions{}, err
+	}
+	snapshot := selector.String()
+	if backup.Incremental() && selector.IsZero() {
+		// The root of an incremental backup holds every snapshot.
+		snapshot = valueobjects.SnapshotLatest
+	}
+	if !backup.Incremental() && !selector.IsZero() && snapshot != valueobjects.SnapshotLatest {
+		return "", entities.HostID{}, valueobjects.RestoreOptions{}, valueobjects.ErrNotIncremental
+	}
+
+	targetHost, err := s.hostService.GetHostEntity(ctx, req.TargetHostID)
+	if err != nil {
+		return "", entities.HostID{}, valueobjects.RestoreOptions{}, err
+	}
+
+	options, err := restoreOptions(dto.RestoreRequest{
+		RestoreType:       "remote",
+		Conflict:          req.Conflict,
+		Delete:            req.Delete,
+		PreserveOwnership: req.PreserveOwnership,
+		NumericIDs:        req.NumericIDs,
+		NoOwnership:       req.NoOwnership,
+		PreserveTimes:     req.PreserveTimes,
+	})
+	if err != nil {
+		return "", entities.HostID{}, valueobjects.RestoreOptions{}, err
+	}
+
+	return snapshot, targetHost.ID(), options, nil
+}
+
+func (s *ReplicationService) ListJobs(ctx context.Context) ([]dto.ReplicationJobResponse, error) {
+	jobs, err := s.jobRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	responses := make([]dto.ReplicationJobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		responses = append(responses, *toReplicationJobResponse(job))
+	}
+	return responses, nil
+}
+
+func (s *ReplicationService) GetJob(ctx context.Context, id string) (*dto.ReplicationJobResponse, error) {
+	job, err := s.findJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toReplicationJobResponse(job), nil
+}
+
+func (s *ReplicationService) DeleteJob(ctx context.Context, id string) error {
+	jobID, err := uuid.Parse(id)
+	if err != nil {
+		return shared.ErrInvalidID
+	}
+	return s.jobRepo.Delete(ctx, jobID)
+}
+
+// ListRuns returns the latest runs of a job, newest first.
+func (s *ReplicationService) ListRuns(ctx context.Context, id string) ([]dto.ReplicationRunResponse, error) {
+	job, err := s.findJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	runs, err := s.runRepo.FindByJobID(ctx, job.ID(), replicationRunHistory)
+	if err != nil {
+		return nil, err
+	}
+	responses := make([]dto.ReplicationRunResponse, 0, len(runs))
+	for _, run := range runs {
+		responses = append(responses, toReplicationRunResponse(run))
+	}
+	return responses, nil
+}
+
+// RunJob starts a run of a job now, outside its schedule.
+func (s *ReplicationService) RunJob(ctx context.Context, id string) (*dto.ReplicationRunResponse, error) {
+	job, err := s.findJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	run, err := s.startRun(ctx, job, entities.ReplicationTriggerManual)
+	if err != nil {
+		return nil, err
+	}
+	response := toReplicationRunResponse(run)
+	return &response, nil
+}
+
+// ProcessDueJobs starts the jobs whose next run is due. A job still running
+// from its previous run skips this one.
+func (s *ReplicationService) ProcessDueJobs(ctx context.Context) error {
+	jobs, err := s.jobRepo.FindDue(ctx, entities.NowFunc())
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		log.Printf("Processing due replication job: %s", job.ID())
+
+		// Reschedule first, so the run is saved along with the next run time
+		// and a fast result is not overwritten by a later save.
+		if err := job.CalculateNextRun(); err != nil {
+			log.Printf("Failed to calculate next run for replication job %s: %v", job.ID(), err)
+			continue
+		}
+
+		if _, err := s.startRun(ctx, job, entities.ReplicationTriggerSchedule); err != nil {
+			log.Printf("Failed to start replication job %s: %v", job.ID(), err)
+			if err := s.jobRepo.Save(ctx, job); err != nil {
+				log.Printf("Failed to save replication job %s: %v", job.ID(), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// startRun publishes the remote restore of a job and records the run. A run
+// that cannot be published is recorded as failed.
+func (s *ReplicationService) startRun(ctx context.Context, job *entities.ReplicationJob, trigger string) (*entities.ReplicationRun, error) {
+	if job.Running(entities.NowFunc()) {
+		return nil, entities.ErrReplicationRunning
+	}
+
+	// The run and the lock are recorded before the task is published, so a
+	// result arriving at once finds them.
+	taskID := uuid.New().String()
+	run := entities.NewReplicationRun(job.ID(), taskID, trigger)
+	if err := job.Start(taskID); err != nil {
+		return nil, err
+	}
+	if err := s.saveRun(ctx, job, run); err != nil {
+		return nil, err
+	}
+
+	options := job.Options()
+	_, err := s.restoreService.Restore(ctx, dto.RestoreRequest{
+		BackupID:          job.BackupID().String(),
+		Path:              job.Path(),
+		Snapshot:          job.Snapshot(),
+		RestoreType:       "remote",
+		TargetHostID:      job.TargetHostID().String(),
+		TargetPath:        job.TargetPath(),
+		Conflict:          string(options.Conflict),
+		Delete:            options.Delete,
+		PreserveOwnership: options.PreserveOwnership,
+		NumericIDs:        options.NumericIDs,
+		NoOwnership:       options.NoOwnership,
+		PreserveTimes:     options.PreserveTimes,
+		TaskID:            taskID,
+	})
+	if err != nil {
+		run.Finish(false, err.Error())
+		job.Finish(taskID, false)
+		if saveErr := s.saveRun(ctx, job, run); saveErr != nil {
+			log.Printf("Failed to record replication run of job %s: %v", job.ID(), saveErr)
+		}
+		return nil, err
+	}
+	return run, nil
+}
+
+func (s *ReplicationService) saveRun(ctx context.Context, job *entities.ReplicationJob, run *entities.ReplicationRun) error {
+	if err := s.runRepo.Save(ctx, run); err != nil {
+		return err
+	}
+	return s.jobRepo.Save(ctx, job)
+}
+
+// RecordResult records the outcome of the remote restore task of a run and
+// releases the lock of its job. It returns nil, with no error, for a task
+// that is not a replication run.
+func (s *ReplicationService) RecordResult(ctx context.Context, taskID string, succeeded bool, message string) (*entities.ReplicationJob, error) {
+	run, err := s.runRepo.FindByTaskID(ctx, taskID)
+	if err == shared.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	run.Finish(succeeded, message)
+	if err := s.runRepo.Save(ctx, run); err != nil {
+		return nil, err
+	}
+
+	job, err := s.jobRepo.FindByID(ctx, run.JobID)
+	if err != nil {
+		return nil, err
+	}
+	if !job.Finish(taskID, succeeded) {
+		log.Printf("Replication job %s no longer waits for task %s", job.ID(), taskID)
+		return job, nil
+	}
+	if err := s.jobRepo.Save(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (s *ReplicationService) findJob(ctx context.Context, id string) (*entities.ReplicationJob, error) {
+	jobID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, shared.ErrInvalidID
+	}
+	return s.jobRepo.FindByID(ctx, jobID)
+}
+
+func toReplicationJobResponse(job *entities.ReplicationJob) *dto.ReplicationJobResponse {
+	options := job.Options()
+	return &dto.ReplicationJobResponse{
+		ID:                job.ID().String(),
+		Name:              job.Name(),
+		BackupID:          job.BackupID().String(),
+		Path:              job.Path(),
+		Snapshot:          job.Snapshot(),
+		TargetHostID:      job.TargetHostID().String(),
+		TargetPath:        job.TargetPath(),
+		Schedule:          job.Schedule(),
+		Conflict:          string(options.Conflict),
+		Delete:            options.Delete,
+		PreserveOwnership: options.PreserveOwnership,
+		NumericIDs:        options.NumericIDs,
+		NoOwnership:       options.NoOwnership,
+		PreserveTimes:     options.PreserveTimes,
+		Enabled:           job.Enabled(),
+		Status:            job.Status().String(),
+		LastRunAt:         job.LastRunAt(),
+		NextRunAt:         job.NextRunAt(),
+		CreatedAt:         job.CreatedAt(),
+		UpdatedAt:         job.UpdatedAt(),
+	}
+}
+
+func toReplicationRunResponse(run *entities.ReplicationRun) dto.ReplicationRunResponse {
+	return dto.ReplicationRunResponse{
+		ID:         run.ID.String(),
+		JobID:      run.JobID.String(),
+		TaskID:     run.TaskID,
+		Trigger:    run.Trigger,
+		Status:     run.Status.String(),
+		Message:    run.Message,
+		StartedAt:  run.StartedAt,
+		FinishedAt: run.FinishedAt,
+	}
+}
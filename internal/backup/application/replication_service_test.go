@@ -0,0 +1,197 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/backup/infrastructure/persistence/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type replicationFixture struct {
+	service   *ReplicationService
+	publisher *MockTaskPublisher
+	backup    *entities.Backup
+	target    *entities.Host
+}
+
+func newReplicationFixture(t *testing.T, incremental bool) *replicationFixture {
+	t.Helper()
+	backupRepo := new(MockBackupRepository)
+	hostRepo := new(MockHostRepository)
+	publisher := new(MockTaskPublisher)
+
+	hostService := NewHostService(hostRepo, backupRepo)
+	restoreService := NewBackupRestoreService(backupRepo, hostService, publisher, new(MockWorkerQueryBus), nil, nil, nil, nil)
+	service := NewReplicationService(memory.NewReplicationJobRepositoryMemory(), memory.NewReplicationRunRepositoryMemory(), backupRepo, hostService, restoreService)
+
+	sourceID := entities.NewHostID()
+	source := entities.NewHostWithID(sourceID, "prod", "prod.local", "root", 22, "prod", false)
+	target := entities.NewHostWithID(entities.NewHostID(), "staging", "staging.local", "root", 22, "staging", false)
+
+	backupID := valueobjects.NewBackupID()
+	backup, _ := entities.NewBackupWithID(backupID, sourceID, "/srv/app", "app", entities.NewBackupSchedule("@daily"), nil, incremental, 1, false)
+
+	backupRepo.On("FindByID", mock.Anything, backupID).Return(backup, nil)
+	backupRepo.On("FindByHostID", mock.Anything, mock.Anything).Return([]*entities.Backup{}, nil)
+	hostRepo.On("Get", mock.Anything, sourceID).Return(source, nil)
+	hostRepo.On("Get", mock.Anything, target.ID()).Return(target, nil)
+
+	return &replicationFixture{
+		service:   service,
+		publisher: publisher,
+		backup:    backup,
+		target:    target,
+	}
+}
+
+func (f *replicationFixture) request() dto.ReplicationJobRequest {
+	return dto.ReplicationJobRequest{
+		Name:         "staging",
+		BackupID:     f.backup.ID().String(),
+		TargetHostID: f.target.ID().String(),
+		TargetPath:   "/srv/app",
+		Schedule:     "0 3 * * *",
+		Conflict:     "newer",
+		Delete:       true,
+	}
+}
+
+func TestReplicationService_CreateJob_DefaultsIncrementalToLatest(t *testing.T) {
+	f := newReplicationFixture(t, true)
+
+	job, err := f.service.CreateJob(context.Background(), f.request())
+
+	assert.NoError(t, err)
+	assert.Equal(t, valueobjects.SnapshotLatest, job.Snapshot)
+	assert.Equal(t, "newer", job.Conflict)
+	assert.True(t, job.Delete)
+	assert.True(t, job.Enabled)
+	assert.NotNil(t, job.NextRunAt)
+	assert.Equal(t, string(valueobjects.BackupStatusPending), job.Status)
+}
+
+func TestReplicationService_CreateJob_Invalid(t *testing.T) {
+	f := newReplicationFixture(t, false)
+
+	req := f.request()
+	req.Snapshot = "2024-01-01_00-00-00"
+	_, err := f.service.CreateJob(context.Background(), req)
+	assert.ErrorIs(t, err, valueobjects.ErrNotIncremental)
+
+	req = f.request()
+	req.Schedule = "not a schedule"
+	_, err = f.service.CreateJob(context.Background(), req)
+	assert.ErrorIs(t, err, entities.ErrInvalidReplicationJob)
+
+	req = f.request()
+	req.Conflict = "merge"
+	_, err = f.service.CreateJob(context.Background(), req)
+	assert.ErrorIs(t, err, valueobjects.ErrInvalidConflictPolicy)
+}
+
+func TestReplicationService_RunJob_LocksUntilResult(t *testing.T) {
+	f := newReplicationFixture(t, false)
+	ctx := context.Background()
+
+	job, err := f.service.CreateJob(ctx, f.request())
+	assert.NoError(t, err)
+
+	options := valueobjects.RestoreOptions{Conflict: valueobjects.ConflictNewer, Delete: true}
+	f.publisher.On("PublishRemoteRestoreTask", ctx, f.backup, "/mnt/backups/prod/app", "", mock.Anything, mock.Anything, f.target, "/srv/app", options, mock.AnythingOfType("string")).Return("task-1", nil).Once()
+
+	run, err := f.service.RunJob(ctx, job.ID)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, run.TaskID)
+	assert.Equal(t, entities.ReplicationTriggerManual, run.Trigger)
+
+	_, err = f.service.RunJob(ctx, job.ID)
+	assert.ErrorIs(t, err, entities.ErrReplicationRunning)
+
+	finished, err := f.service.RecordResult(ctx, run.TaskID, false, "rsync failed")
+	assert.NoError(t, err)
+	assert.Equal(t, valueobjects.BackupStatusFailed, finished.Status())
+	assert.NotNil(t, finished.LastRunAt())
+
+	runs, err := f.service.ListRuns(ctx, job.ID)
+	assert.NoError(t, err)
+	assert.Len(t, runs, 1)
+	assert.Equal(t, "failed", runs[0].Status)
+	assert.Equal(t, "rsync failed", runs[0].Message)
+	assert.NotNil(t, runs[0].FinishedAt)
+
+	f.publisher.On("PublishRemoteRestoreTask", ctx, f.backup, "/mnt/backups/prod/app", "", mock.Anything, mock.Anything, f.target, "/srv/app", options, mock.AnythingOfType("string")).Return("task-2", nil).Once()
+	_, err = f.service.RunJob(ctx, job.ID)
+	assert.NoError(t, err)
+	f.publisher.AssertExpectations(t)
+}
+
+func TestReplicationService_RunJob_RecordsResultArrivingAtOnce(t *testing.T) {
+	f := newReplicationFixture(t, false)
+	ctx := context.Background()
+
+	job, err := f.service.CreateJob(ctx, f.request())
+	assert.NoError(t, err)
+
+	// The worker reports back before publishing returns.
+	var finished *entities.ReplicationJob
+	f.publisher.On("PublishRemoteRestoreTask", ctx, f.backup, mock.Anything, "", mock.Anything, mock.Anything, f.target, "/srv/app", mock.Anything, mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) {
+			finished, err = f.service.RecordResult(ctx, args.String(9), true, "")
+		}).
+		Return("task-1", nil).Once()
+
+	_, runErr := f.service.RunJob(ctx, job.ID)
+	assert.NoError(t, runErr)
+	assert.NoError(t, err)
+	if assert.NotNil(t, finished, "the result finds its run") {
+		assert.Equal(t, valueobjects.BackupStatusCompleted, finished.Status())
+	}
+
+	updated, err := f.service.GetJob(ctx, job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, string(valueobjects.BackupStatusCompleted), updated.Status)
+}
+
+func TestReplicationService_RecordResult_IgnoresOtherTasks(t *testing.T) {
+	f := newReplicationFixture(t, false)
+
+	job, err := f.service.RecordResult(context.Background(), "ad-hoc-restore", true, "")
+
+	assert.NoError(t, err)
+	assert.Nil(t, job)
+}
+
+func TestReplicationService_ProcessDueJobs(t *testing.T) {
+	f := newReplicationFixture(t, false)
+	ctx := context.Background()
+
+	job, err := f.service.CreateJob(ctx, f.request())
+	assert.NoError(t, err)
+
+	f.publisher.On("PublishRemoteRestoreTask", ctx, f.backup, mock.Anything, "", mock.Anything, mock.Anything, f.target, "/srv/app", mock.Anything, mock.Anything).Return("", errors.New("redis down")).Once()
+
+	original := entities.NowFunc
+	defer func() { entities.NowFunc = original }()
+	due := job.NextRunAt.Add(1)
+	entities.NowFunc = func() time.Time { return due }
+
+	assert.NoError(t, f.service.ProcessDueJobs(ctx))
+
+	updated, err := f.service.GetJob(ctx, job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, string(valueobjects.BackupStatusFailed), updated.Status)
+	assert.True(t, updated.NextRunAt.After(due))
+
+	runs, err := f.service.ListRuns(ctx, job.ID)
+	assert.NoError(t, err)
+	assert.Len(t, runs, 1)
+	assert.Equal(t, entities.ReplicationTriggerSchedule, runs[0].Trigger)
+	assert.Equal(t, "redis down", runs[0].Message)
+}
@@ -2,9 +2,12 @@ package application
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
 	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
@@ -16,74 +19,325 @@ type BackupRestoreService struct {
 	repo        interfaces.BackupRepository
 	hostService *HostService
 	publisher   interfaces.TaskPublisher
+	queryBus    interfaces.WorkerQueryBus
+	stager      interfaces.DownloadStager
+	replicas    *ReplicaService
+	pools       *StoragePoolService
+	tiering     *TieringService
 }
 
 func NewBackupRestoreService(
 	repo interfaces.BackupRepository,
 	hostService *HostService,
 	publisher interfaces.TaskPublisher,
+	queryBus interfaces.WorkerQueryBus,
+	stager interfaces.DownloadStager,
+	replicas *ReplicaService,
+	pools *StoragePoolService,
+	tiering *TieringService,
 ) *BackupRestoreService {
 	return &BackupRestoreService{
 		repo:        repo,
 		hostService: hostService,
 		publisher:   publisher,
+		queryBus:    queryBus,
+		stager:      stager,
+		replicas:    replicas,
+		pools:       pools,
+		tiering:     tiering,
 	}
 }
 
 func (s *BackupRestoreService) Restore(ctx context.Context, req dto.RestoreRequest) (string, error) {
-	bid, err := valueobjects.NewBackupIDFromString(req.BackupID)
+	options, err := restoreOptions(req)
 	if err != nil {
 		return "", err
 	}
-
-	backup, err := s.repo.FindByID(ctx, bid)
-	if err != nil {
-		return "", err
+	if options.DryRun {
+		return "", fmt.Errorf("%w: a dry run returns a preview instead of a task", valueobjects.ErrInvalidRestoreOptions)
 	}
 
-	fullPath, err := s.calculateSourcePath(ctx, backup, req.Path)
+	backup, source, err := s.resolveRestoreSource(ctx, req)
 	if err != nil {
 		return "", err
 	}
 
 	if req.RestoreType == "local" {
-		return s.handleLocalRestore(ctx, backup, fullPath, req)
+		return s.handleLocalRestore(ctx, backup, source, req)
 	}
 
 	if req.RestoreType == "remote" {
-		return s.handleRemoteRestore(ctx, backup, fullPath, req)
+		return s.handleRemoteRestore(ctx, backup, source, req, options)
 	}
 
 	return "", fmt.Errorf("restore type %s not supported", req.RestoreType)
 }
 
-func (s *BackupRestoreService) calculateSourcePath(ctx context.Context, backup *entities.Backup, reqPath string) (string, error) {
+// Preview runs a remote restore as a dry run and returns the changes it
+// would make at the target, without writing anything.
+func (s *BackupRestoreService) Preview(ctx context.Context, req dto.RestoreRequest) (*dto.RestorePreviewResponse, error) {
+	options, err := restoreOptions(req)
+	if err != nil {
+		return nil, err
+	}
+	if req.RestoreType != "remote" {
+		return nil, fmt.Errorf("%w: only remote restores can be previewed", valueobjects.ErrInvalidRestoreOptions)
+	}
+
+	backup, source, err := s.resolveRestoreSource(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	targetHost, targetPath, err := s.remoteRestoreTarget(ctx, backup, req)
+	if err != nil {
+		return nil, err
+	}
+
+	preview, err := s.queryBus.PreviewRemoteRestore(ctx, backup, source.Path, source.ArchivePath, source.Replica, source.Cold, targetHost, targetPath, options)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := preview.Changes
+	if changes == nil {
+		changes = []string{}
+	}
+	return &dto.RestorePreviewResponse{Changes: changes, Truncated: preview.Truncated}, nil
+}
+
+// restoreOptions validates the target options of a restore request. Local
+// restores are written by the CLI, which applies the conflict policy and
+// ownership itself, so the options that only rsync can honour are rejected
+// for them.
+func restoreOptions(req dto.RestoreRequest) (valueobjects.RestoreOptions, error) {
+	conflict, err := valueobjects.ParseConflictPolicy(req.Conflict)
+	if err != nil {
+		return valueobjects.RestoreOptions{}, err
+	}
+	if req.RestoreType == "local" && req.Delete {
+		return valueobjects.RestoreOptions{}, fmt.Errorf("%w: delete is only supported for remote restores", valueobjects.ErrInvalidRestoreOptions)
+	}
+	if req.RestoreType == "local" && (req.NoOwnership || req.PreserveTimes) {
+		return valueobjects.RestoreOptions{}, fmt.Errorf("%w: no ownership and preserve times are only supported for remote restores", valueobjects.ErrInvalidRestoreOptions)
+	}
+	if req.NoOwnership && (req.PreserveOwnership || req.NumericIDs) {
+		return valueobjects.RestoreOptions{}, fmt.Errorf("%w: no ownership excludes preserving ownership", valueobjects.ErrInvalidRestoreOptions)
+	}
+
+	return valueobjects.RestoreOptions{
+		Conflict:          conflict,
+		Delete:            req.Delete,
+		PreserveOwnership: req.PreserveOwnership || req.NumericIDs,
+		NumericIDs:        req.NumericIDs,
+		NoOwnership:       req.NoOwnership,
+		PreserveTimes:     req.PreserveTimes,
+		DryRun:            req.DryRun,
+	}, nil
+}
+
+func (s *BackupRestoreService) resolveRestoreSource(ctx context.Context, req dto.RestoreRequest) (*entities.Backup, restoreSource, error) {
+	selector, err := valueobjects.ParseSnapshotSelector(req.Snapshot)
+	if err != nil {
+		return nil, restoreSource{}, err
+	}
+
+	bid, err := valueobjects.NewBackupIDFromString(req.BackupID)
+	if err != nil {
+		return nil, restoreSource{}, err
+	}
+
+	backup, err := s.repo.FindByID(ctx, bid)
+	if err != nil {
+		return nil, restoreSource{}, err
+	}
+
+	source, err := s.calculateSourcePath(ctx, backup, req.Path, selector)
+	if err != nil {
+		return nil, restoreSource{}, err
+	}
+	return backup, source, nil
+}
+
+// Download has a worker stream an archive of a path of the backup to the
+// server, where it is staged so it can be downloaded over the API with range
+// requests. Requests for the same content share one staged archive.
+func (s *BackupRestoreService) Download(ctx context.Context, req dto.DownloadRequest) (*dto.DownloadResponse, error) {
+	format, err := valueobjects.ParseDownloadFormat(req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	selector, err := valueobjects.ParseSnapshotSelector(req.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	bid, err := valueobjects.NewBackupIDFromString(req.BackupID)
+	if err != nil {
+		return nil, err
+	}
+
+	backup, err := s.repo.FindByID(ctx, bid)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := s.calculateSourcePath(ctx, backup, req.Path, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	key := downloadKey(backup, source.Path, source.ArchivePath, format)
+	staged, err := s.stager.Stage(ctx, key, func(ctx context.Context, stream string) error {
+		_, err := s.publisher.PublishDownloadTask(ctx, backup, source.Path, source.ArchivePath, source.Replica, source.Cold, format, stream)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.DownloadResponse{
+		FilePath:    staged.Path,
+		FileName:    downloadName(backup, req.Path, format),
+		ContentType: format.ContentType(),
+		Size:        staged.Size,
+		ModTime:     staged.ModTime,
+		ETag:        key,
+	}, nil
+}
+
+// downloadKey identifies the content of a download. It changes with every
+// run of the backup, so an archive staged before a run is never served for
+// the new latest state.
+func downloadKey(backup *entities.Backup, source string, archivePath string, format valueobjects.DownloadFormat) string {
+	parts := []string{
+		backup.ID().String(),
+		backup.Schedule().LastRun.UTC().Format(time.RFC3339Nano),
+		source,
+		archivePath,
+		string(format),
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// downloadName names the archive after the requested path, or after the
+// backup destination when the whole backup is downloaded.
+func downloadName(backup *entities.Backup, reqPath string, format valueobjects.DownloadFormat) string {
+	name := path.Base(path.Clean("/" + reqPath))
+	if name == "/" {
+		name = path.Base(path.Clean("/" + backup.Destination()))
+	}
+	if name == "/" {
+		name = backup.ID().String()
+	}
+	return name + "." + format.Extension()
+}
+
+// restoreSource is what a worker restores from. A path inside an encrypted
+// archive is kept separately as ArchivePath; the worker finds it through the
+// archive index. Replica locates Path on a replica target holding it, which
+// the worker pulls from when Path is missing, and Cold the archive in a cold
+// storage pool it is unpacked from instead.
+type restoreSource struct {
+	Path        string
+	ArchivePath string
+	Replica     *valueobjects.ReplicaLocation
+	Cold        *valueobjects.ColdLocation
+}
+
+// calculateSourcePath returns what the worker restores from. A snapshot
+// that is gone locally but held by a replica target or archived in a cold
+// storage pool is still found.
+func (s *BackupRestoreService) calculateSourcePath(ctx context.Context, backup *entities.Backup, reqPath string, selector valueobjects.SnapshotSelector) (restoreSource, error) {
 	hostResp, err := s.hostService.GetHost(ctx, backup.HostID().String())
 	if err != nil {
-		return "", err
+		return restoreSource{}, err
+	}
+	root, err := s.pools.BackupPath(ctx, backup, hostResp.Path)
+	if err != nil {
+		return restoreSource{}, err
+	}
+
+	var replicas *replicaIndex
+	if s.replicas != nil {
+		if replicas, err = s.replicas.replicaIndex(ctx, backup); err != nil {
+			return restoreSource{}, err
+		}
+	}
+
+	source, err := localSourcePath(ctx, s.queryBus, backup, root, reqPath, selector, replicas)
+	if err != nil && s.tiering != nil && !selector.IsZero() && backup.Incremental() {
+		// Tiering moves the oldest snapshots first, so a selector that
+		// matches none left is resolved among the archived ones alone.
+		if cold, coldErr := s.tiering.coldSource(ctx, backup, root, reqPath, selector); coldErr == nil {
+			source, err = cold, nil
+		}
+	}
+	if err != nil {
+		return restoreSource{}, err
+	}
+	if replicas != nil {
+		source.Replica = replicas.locate(root, source.Path)
+	}
+	return source, nil
+}
+
+// localSourcePath returns what the worker restores from under the root of
+// the backup. A selected snapshot missing there is looked up among the
+// replicated ones, which keep the same layout.
+func localSourcePath(ctx context.Context, queryBus interfaces.WorkerQueryBus, backup *entities.Backup, root string, reqPath string, selector valueobjects.SnapshotSelector, replicas *replicaIndex) (restoreSource, error) {
+	if !selector.IsZero() && backup.Incremental() {
+		loc, err := resolveSnapshot(ctx, queryBus, backup, root, selector)
+		if err != nil && replicas != nil && len(replicas.names) > 0 {
+			if replicated, replicaErr := replicas.resolve(selector); replicaErr == nil {
+				loc, err = replicated, nil
+			}
+		}
+		if err != nil {
+			return restoreSource{}, err
+		}
+		if subPath := strings.Trim(reqPath, "/"); loc.Dir == "" && subPath != "" && subPath != "." {
+			return restoreSource{Path: path.Join(root, loc.Archive), ArchivePath: subPath}, nil
+		}
+		source, err := snapshotPath(root, loc, reqPath)
+		return restoreSource{Path: source}, err
+	}
+	if !selector.IsZero() && selector.String() != valueobjects.SnapshotLatest {
+		return restoreSource{}, valueobjects.ErrNotIncremental
 	}
-	fullPath := path.Join("/mnt/backups", hostResp.Path, backup.Destination())
 
 	cleanPath := strings.Trim(reqPath, "./")
 
-	if backup.Encrypted() && cleanPath == "" {
+	if backup.Encrypted() {
 		if backup.Incremental() {
-			fullPath = path.Join(fullPath, "latest.tar.gz.enc")
-		} else {
-			fullPath = fullPath + ".tar.gz.enc"
+			return restoreSource{Path: path.Join(root, "latest.tar.gz.enc"), ArchivePath: cleanPath}, nil
 		}
+		return restoreSource{Path: root + ".tar.gz.enc", ArchivePath: cleanPath}, nil
 	} else if reqPath != "" {
-		fullPath = path.Join(fullPath, reqPath)
+		return restoreSource{Path: path.Join(root, reqPath)}, nil
 	}
 
-	return fullPath, nil
+	return restoreSource{Path: root}, nil
+}
+
+func (s *BackupRestoreService) handleLocalRestore(ctx context.Context, backup *entities.Backup, source restoreSource, req dto.RestoreRequest) (string, error) {
+	return s.publisher.PublishRestoreTask(ctx, backup, source.Path, source.ArchivePath, source.Replica, source.Cold, req.RestoreAddr, req.RestoreToken, req.RestoreFingerprint)
 }
 
-func (s *BackupRestoreService) handleLocalRestore(ctx context.Context, backup *entities.Backup, fullPath string, req dto.RestoreRequest) (string, error) {
-	return s.publisher.PublishRestoreTask(ctx, backup, fullPath, req.RestoreAddr, req.RestoreToken)
+func (s *BackupRestoreService) handleRemoteRestore(ctx context.Context, backup *entities.Backup, source restoreSource, req dto.RestoreRequest, options valueobjects.RestoreOptions) (string, error) {
+	targetHost, targetPath, err := s.remoteRestoreTarget(ctx, backup, req)
+	if err != nil {
+		return "", err
+	}
+
+	return s.publisher.PublishRemoteRestoreTask(ctx, backup, source.Path, source.ArchivePath, source.Replica, source.Cold, targetHost, targetPath, options, req.TaskID)
 }
 
-func (s *BackupRestoreService) handleRemoteRestore(ctx context.Context, backup *entities.Backup, fullPath string, req dto.RestoreRequest) (string, error) {
+// remoteRestoreTarget returns the host and path a remote restore writes to.
+// The host defaults to the one the backup was taken from.
+func (s *BackupRestoreService) remoteRestoreTarget(ctx context.Context, backup *entities.Backup, req dto.RestoreRequest) (*entities.Host, string, error) {
 	hostID := req.TargetHostID
 	if hostID == "" {
 		hostID = backup.HostID().String()
@@ -91,13 +345,13 @@ func (s *BackupRestoreService) handleRemoteRestore(ctx context.Context, backup *
 
 	targetHost, err := s.hostService.GetHostEntity(ctx, hostID)
 	if err != nil {
-		return "", err
+		return nil, "", err
 	}
 
 	targetPath := req.TargetPath
 	if targetPath == "" {
-		return "", fmt.Errorf("target_path is required for remote restore")
+		return nil, "", fmt.Errorf("target_path is required for remote restore")
 	}
 
-	return s.publisher.PublishRemoteRestoreTask(ctx, backup, fullPath, targetHost, targetPath)
+	return targetHost, targetPath, nil
 }
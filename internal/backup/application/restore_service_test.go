@@ -7,7 +7,9 @@ import (
 	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
 	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
 	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestBackupRestoreService_Restore_Local_Simple_Success(t *testing.T) {
@@ -18,7 +20,7 @@ func TestBackupRestoreService_Restore_Local_Simple_Success(t *testing.T) {
 	mockPublisher := new(MockTaskPublisher)
 
 	hostService := NewHostService(mockHostRepo, mockBackupRepo)
-	service := NewBackupRestoreService(mockBackupRepo, hostService, mockPublisher)
+	service := NewBackupRestoreService(mockBackupRepo, hostService, mockPublisher, new(MockWorkerQueryBus), nil, nil, nil, nil)
 
 	// Setup data
 	validHostID := entities.NewHostID()
@@ -39,11 +41,12 @@ func TestBackupRestoreService_Restore_Local_Simple_Success(t *testing.T) {
 	// Backup is created with pending status, etc.
 
 	req := dto.RestoreRequest{
-		BackupID:     backupID.String(),
-		RestoreType:  "local",
-		Path:         "", // Full restore
-		RestoreAddr:  "127.0.0.1:8080",
-		RestoreToken: "token123",
+		BackupID:           backupID.String(),
+		RestoreType:        "local",
+		Path:               "", // Full restore
+		RestoreAddr:        "127.0.0.1:8080",
+		RestoreToken:       "token123",
+		RestoreFingerprint: "ab12",
 	}
 
 	// Mock expectations
@@ -55,7 +58,7 @@ func TestBackupRestoreService_Restore_Local_Simple_Success(t *testing.T) {
 
 	expectedPath := "/mnt/backups/backups/dest_folder"
 
-	mockPublisher.On("PublishRestoreTask", ctx, backup, expectedPath, "127.0.0.1:8080", "token123").Return("task-id-1", nil)
+	mockPublisher.On("PublishRestoreTask", ctx, backup, expectedPath, "", mock.Anything, mock.Anything, "127.0.0.1:8080", "token123", "ab12").Return("task-id-1", nil)
 
 	// Execute
 	taskID, err := service.Restore(ctx, req)
@@ -76,7 +79,7 @@ func TestBackupRestoreService_Restore_Local_Encrypted_File_Success(t *testing.T)
 	mockPublisher := new(MockTaskPublisher)
 
 	hostService := NewHostService(mockHostRepo, mockBackupRepo)
-	service := NewBackupRestoreService(mockBackupRepo, hostService, mockPublisher)
+	service := NewBackupRestoreService(mockBackupRepo, hostService, mockPublisher, new(MockWorkerQueryBus), nil, nil, nil, nil)
 
 	validHostID := entities.NewHostID()
 	host := entities.NewHostWithID(validHostID, "Test Host", "localhost", "user", 22, "/backups", false)
@@ -108,7 +111,7 @@ func TestBackupRestoreService_Restore_Local_Encrypted_File_Success(t *testing.T)
 
 	expectedPath := "/mnt/backups/backups/dest_folder/specific/file.txt"
 
-	mockPublisher.On("PublishRestoreTask", ctx, backup, expectedPath, "", "").Return("task-id-2", nil)
+	mockPublisher.On("PublishRestoreTask", ctx, backup, expectedPath, "", mock.Anything, mock.Anything, "", "", "").Return("task-id-2", nil)
 
 	taskID, err := service.Restore(ctx, req)
 
@@ -124,7 +127,7 @@ func TestBackupRestoreService_Restore_Remote_Success(t *testing.T) {
 	mockPublisher := new(MockTaskPublisher)
 
 	hostService := NewHostService(mockHostRepo, mockBackupRepo)
-	service := NewBackupRestoreService(mockBackupRepo, hostService, mockPublisher)
+	service := NewBackupRestoreService(mockBackupRepo, hostService, mockPublisher, new(MockWorkerQueryBus), nil, nil, nil, nil)
 
 	// Source Host
 	sourceHostID := entities.NewHostID()
@@ -153,6 +156,9 @@ func TestBackupRestoreService_Restore_Remote_Success(t *testing.T) {
 		Path:         "",
 		TargetHostID: targetHostID.String(), // Remote restore target
 		TargetPath:   "/tmp/restore",
+		Conflict:     "rename",
+		Delete:       true,
+		NumericIDs:   true,
 	}
 
 	mockBackupRepo.On("FindByID", ctx, backupID).Return(backup, nil)
@@ -167,7 +173,13 @@ func TestBackupRestoreService_Restore_Remote_Success(t *testing.T) {
 	// Path calculation: /mnt/backups + /src + backup-dest = /mnt/backups/src/backup-dest
 	expectedMsg := "/mnt/backups/src/backup-dest"
 
-	mockPublisher.On("PublishRemoteRestoreTask", ctx, backup, expectedMsg, targetHost, "/tmp/restore").Return("task-remote-1", nil)
+	options := valueobjects.RestoreOptions{
+		Conflict:          valueobjects.ConflictRename,
+		Delete:            true,
+		PreserveOwnership: true,
+		NumericIDs:        true,
+	}
+	mockPublisher.On("PublishRemoteRestoreTask", ctx, backup, expectedMsg, "", mock.Anything, mock.Anything, targetHost, "/tmp/restore", options, "").Return("task-remote-1", nil)
 
 	taskID, err := service.Restore(ctx, req)
 
@@ -183,7 +195,7 @@ func TestBackupRestoreService_Restore_Remote_MissingTargetPath(t *testing.T) {
 	mockPublisher := new(MockTaskPublisher)
 
 	hostService := NewHostService(mockHostRepo, mockBackupRepo)
-	service := NewBackupRestoreService(mockBackupRepo, hostService, mockPublisher)
+	service := NewBackupRestoreService(mockBackupRepo, hostService, mockPublisher, new(MockWorkerQueryBus), nil, nil, nil, nil)
 
 	sourceHostID := entities.NewHostID()
 	sourceHost := entities.NewHostWithID(sourceHostID, "Source", "1.1.1.1", "user", 22, "/src", false)
@@ -229,6 +241,77 @@ func TestBackupRestoreService_Restore_Remote_MissingTargetPath(t *testing.T) {
 	assert.Contains(t, err.Error(), "target_path is required")
 }
 
+func TestBackupRestoreService_Preview(t *testing.T) {
+	ctx := context.Background()
+
+	mockBackupRepo := new(MockBackupRepository)
+	mockHostRepo := new(MockHostRepository)
+	mockQueryBus := new(MockWorkerQueryBus)
+
+	hostService := NewHostService(mockHostRepo, mockBackupRepo)
+	service := NewBackupRestoreService(mockBackupRepo, hostService, new(MockTaskPublisher), mockQueryBus, nil, nil, nil, nil)
+
+	hostID := entities.NewHostID()
+	host := entities.NewHostWithID(hostID, "Source", "1.1.1.1", "user", 22, "/src", false)
+
+	backupID := valueobjects.NewBackupID()
+	backup, _ := entities.NewBackupWithID(
+		backupID,
+		hostID,
+		"/data",
+		"backup-dest",
+		entities.NewBackupSchedule("@daily"),
+		[]string{},
+		false,
+		5,
+		false,
+	)
+
+	mockBackupRepo.On("FindByID", ctx, backupID).Return(backup, nil)
+	mockHostRepo.On("Get", ctx, hostID).Return(host, nil)
+	mockBackupRepo.On("FindByHostID", ctx, hostID).Return([]*entities.Backup{}, nil)
+
+	options := valueobjects.RestoreOptions{Conflict: valueobjects.ConflictSkip, DryRun: true}
+	mockQueryBus.On("PreviewRemoteRestore", ctx, backup, "/mnt/backups/src/backup-dest/etc", "", mock.Anything, mock.Anything, host, "/srv/restore", options).
+		Return(workerDto.RestorePreviewResult{Changes: []string{">f+++++++++ etc/hosts"}}, nil)
+
+	req := dto.RestoreRequest{
+		BackupID:    backupID.String(),
+		Path:        "etc",
+		RestoreType: "remote",
+		TargetPath:  "/srv/restore",
+		Conflict:    "skip",
+		DryRun:      true,
+	}
+
+	preview, err := service.Preview(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{">f+++++++++ etc/hosts"}, preview.Changes)
+
+	_, err = service.Restore(ctx, req)
+	assert.ErrorIs(t, err, valueobjects.ErrInvalidRestoreOptions)
+}
+
+func TestBackupRestoreService_Restore_InvalidOptions(t *testing.T) {
+	service := NewBackupRestoreService(new(MockBackupRepository), nil, new(MockTaskPublisher), new(MockWorkerQueryBus), nil, nil, nil, nil)
+	backupID := valueobjects.NewBackupID().String()
+
+	_, err := service.Restore(context.Background(), dto.RestoreRequest{BackupID: backupID, RestoreType: "remote", Conflict: "merge"})
+	assert.ErrorIs(t, err, valueobjects.ErrInvalidConflictPolicy)
+
+	_, err = service.Restore(context.Background(), dto.RestoreRequest{BackupID: backupID, RestoreType: "local", Delete: true})
+	assert.ErrorIs(t, err, valueobjects.ErrInvalidRestoreOptions)
+
+	_, err = service.Preview(context.Background(), dto.RestoreRequest{BackupID: backupID, RestoreType: "local", DryRun: true})
+	assert.ErrorIs(t, err, valueobjects.ErrInvalidRestoreOptions)
+
+	_, err = service.Restore(context.Background(), dto.RestoreRequest{BackupID: backupID, RestoreType: "local", PreserveTimes: true})
+	assert.ErrorIs(t, err, valueobjects.ErrInvalidRestoreOptions)
+
+	_, err = service.Restore(context.Background(), dto.RestoreRequest{BackupID: backupID, RestoreType: "remote", NoOwnership: true, NumericIDs: true})
+	assert.ErrorIs(t, err, valueobjects.ErrInvalidRestoreOptions)
+}
+
 func TestBackupRestoreService_Restore_UnsupportedType(t *testing.T) {
 	ctx := context.Background()
 	mockBackupRepo := new(MockBackupRepository)
@@ -236,7 +319,7 @@ func TestBackupRestoreService_Restore_UnsupportedType(t *testing.T) {
 	mockPublisher := new(MockTaskPublisher)
 
 	hostService := NewHostService(mockHostRepo, mockBackupRepo)
-	service := NewBackupRestoreService(mockBackupRepo, hostService, mockPublisher)
+	service := NewBackupRestoreService(mockBackupRepo, hostService, mockPublisher, new(MockWorkerQueryBus), nil, nil, nil, nil)
 
 	validHostID := entities.NewHostID()
 	host := entities.NewHostWithID(validHostID, "h", "h", "u", 22, "p", false)
@@ -269,3 +352,133 @@ func TestBackupRestoreService_Restore_UnsupportedType(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "restore type cloud not supported")
 }
+
+func TestBackupRestoreService_Restore_SnapshotSelector(t *testing.T) {
+	ctx := context.Background()
+
+	validHostID := entities.NewHostID()
+	host := entities.NewHostWithID(validHostID, "Test Host", "localhost", "user", 22, "/backups", false)
+	backupID := valueobjects.NewBackupID()
+	backup, _ := entities.NewBackupWithID(backupID, validHostID, "/source/data", "dest_folder", entities.NewBackupSchedule("@daily"), []string{}, true, 5, false)
+
+	listing := workerDto.ListFilesResult{Files: []workerDto.FileListItem{
+		{Name: "2024-03-01_02-00-00", IsDir: true},
+		{Name: "2024-03-02_02-00-00.tar.gz.enc", IsDir: false},
+		{Name: "2024-03-03_02-00-00", IsDir: true},
+		{Name: "latest", IsDir: true},
+	}}
+
+	tests := []struct {
+		name         string
+		snapshot     string
+		path         string
+		expectedPath string
+		archivePath  string
+		err          error
+	}{
+		{"exact snapshot", "2024-03-01_02-00-00", "etc/hosts", "/mnt/backups/backups/dest_folder/2024-03-01_02-00-00/etc/hosts", "", nil},
+		{"latest", "latest", "etc", "/mnt/backups/backups/dest_folder/2024-03-03_02-00-00/etc", "", nil},
+		{"as of a point in time", "2024-03-02 12:00", "", "/mnt/backups/backups/dest_folder/2024-03-02_02-00-00.tar.gz.enc", "", nil},
+		{"path inside an encrypted snapshot", "2024-03-02_02-00-00", "/etc/", "/mnt/backups/backups/dest_folder/2024-03-02_02-00-00.tar.gz.enc", "etc", nil},
+		{"before the first snapshot", "2024-02-01", "", "", "", valueobjects.ErrSnapshotNotFound},
+		{"invalid selector", "yesterday", "", "", "", valueobjects.ErrInvalidSnapshotSelector},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockBackupRepo := new(MockBackupRepository)
+			mockHostRepo := new(MockHostRepository)
+			mockPublisher := new(MockTaskPublisher)
+			mockQueryBus := new(MockWorkerQueryBus)
+			service := NewBackupRestoreService(mockBackupRepo, NewHostService(mockHostRepo, mockBackupRepo), mockPublisher, mockQueryBus, nil, nil, nil, nil)
+
+			mockBackupRepo.On("FindByID", ctx, backupID).Return(backup, nil)
+			mockHostRepo.On("Get", ctx, validHostID).Return(host, nil)
+			mockBackupRepo.On("FindByHostID", ctx, validHostID).Return([]*entities.Backup{}, nil)
+			mockQueryBus.On("ListFiles", ctx, "/mnt/backups/backups/dest_folder").Return(listing, nil)
+			if tt.err == nil {
+				mockPublisher.On("PublishRestoreTask", ctx, backup, tt.expectedPath, tt.archivePath, mock.Anything, mock.Anything, "127.0.0.1:8080", "token", "").Return("task-id", nil)
+			}
+
+			_, err := service.Restore(ctx, dto.RestoreRequest{
+				BackupID:     backupID.String(),
+				RestoreType:  "local",
+				Path:         tt.path,
+				Snapshot:     tt.snapshot,
+				RestoreAddr:  "127.0.0.1:8080",
+				RestoreToken: "token",
+			})
+
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				mockPublisher.AssertNotCalled(t, "PublishRestoreTask")
+				return
+			}
+			assert.NoError(t, err)
+			mockPublisher.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBackupRestoreService_Restore_SnapshotOnPlainMirror(t *testing.T) {
+	ctx := context.Background()
+
+	mockBackupRepo := new(MockBackupRepository)
+	mockHostRepo := new(MockHostRepository)
+	service := NewBackupRestoreService(mockBackupRepo, NewHostService(mockHostRepo, mockBackupRepo), new(MockTaskPublisher), new(MockWorkerQueryBus), nil, nil, nil, nil)
+
+	validHostID := entities.NewHostID()
+	host := entities.NewHostWithID(validHostID, "Test Host", "localhost", "user", 22, "/backups", false)
+	backupID := valueobjects.NewBackupID()
+	backup, _ := entities.NewBackupWithID(backupID, validHostID, "/source/data", "dest_folder", entities.NewBackupSchedule("@daily"), []string{}, false, 5, false)
+
+	mockBackupRepo.On("FindByID", ctx, backupID).Return(backup, nil)
+	mockHostRepo.On("Get", ctx, validHostID).Return(host, nil)
+	mockBackupRepo.On("FindByHostID", ctx, validHostID).Return([]*entities.Backup{}, nil)
+
+	_, err := service.Restore(ctx, dto.RestoreRequest{BackupID: backupID.String(), RestoreType: "local", Snapshot: "2024-03-01_02-00-00"})
+
+	assert.ErrorIs(t, err, valueobjects.ErrNotIncremental)
+}
+
+func TestBackupRestoreService_Download(t *testing.T) {
+	ctx := context.Background()
+
+	mockBackupRepo := new(MockBackupRepository)
+	mockHostRepo := new(MockHostRepository)
+	mockPublisher := new(MockTaskPublisher)
+	stager := &recordingDownloadStager{}
+
+	hostService := NewHostService(mockHostRepo, mockBackupRepo)
+	service := NewBackupRestoreService(mockBackupRepo, hostService, mockPublisher, new(MockWorkerQueryBus), stager, nil, nil, nil)
+
+	validHostID := entities.NewHostID()
+	host := entities.NewHostWithID(validHostID, "Test Host", "localhost", "user", 22, "/backups", false)
+	backupID := valueobjects.NewBackupID()
+	backup, _ := entities.NewBackupWithID(backupID, validHostID, "/etc", "etc", entities.NewBackupSchedule("@daily"), []string{}, false, 5, true)
+
+	mockBackupRepo.On("FindByID", ctx, backupID).Return(backup, nil)
+	mockHostRepo.On("Get", ctx, validHostID).Return(host, nil)
+	mockBackupRepo.On("FindByHostID", ctx, validHostID).Return([]*entities.Backup{}, nil)
+	mockPublisher.On("PublishDownloadTask", mock.Anything, backup, "/mnt/backups/backups/etc.tar.gz.enc", "nginx", mock.Anything, mock.Anything, valueobjects.DownloadFormatTarGz, mock.Anything).Return("task-1", nil)
+
+	req := dto.DownloadRequest{BackupID: backupID.String(), Path: "/nginx/"}
+	resp, err := service.Download(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, "nginx.tar.gz", resp.FileName)
+	assert.Equal(t, "application/gzip", resp.ContentType)
+	assert.Equal(t, int64(42), resp.Size)
+	assert.Equal(t, stager.keys[0], resp.ETag)
+
+	// The same content is staged under the same key until the backup runs again.
+	_, err = service.Download(ctx, req)
+	assert.NoError(t, err)
+	assert.NoError(t, backup.Complete())
+	_, err = service.Download(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, stager.keys[0], stager.keys[1])
+	assert.NotEqual(t, stager.keys[0], stager.keys[2])
+
+	_, err = service.Download(ctx, dto.DownloadRequest{BackupID: backupID.String(), Format: "rar"})
+	assert.ErrorIs(t, err, valueobjects.ErrInvalidDownloadFormat)
+}
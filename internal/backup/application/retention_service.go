@@ -0,0 +1,244 @@
+package application
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application/assembler"
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/interfaces"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+)
+
+type BackupRetentionService struct {
+	repo      interfaces.BackupRepository
+	hostRepo  interfaces.HostRepository
+	pinRepo   interfaces.SnapshotPinRepository
+	eventRepo interfaces.LegalHoldEventRepository
+	queryBus  interfaces.WorkerQueryBus
+	assembler *assembler.BackupAssembler
+	pools     *StoragePoolService
+}
+
+func NewBackupRetentionService(
+	repo interfaces.BackupRepository,
+	hostRepo interfaces.HostRepository,
+	pinRepo interfaces.SnapshotPinRepository,
+	eventRepo interfaces.LegalHoldEventRepository,
+	queryBus interfaces.WorkerQueryBus,
+	assembler *assembler.BackupAssembler,
+	pools *StoragePoolService,
+) *BackupRetentionService {
+	return &BackupRetentionService{
+		repo:      repo,
+		hostRepo:  hostRepo,
+		pinRepo:   pinRepo,
+		eventRepo: eventRepo,
+		queryBus:  queryBus,
+		assembler: assembler,
+		pools:     pools,
+	}
+}
+
+// PreviewRetention lists the snapshots currently on disk and reports what the
+// backup's retention policy would keep and purge, without deleting anything.
+func (s *BackupRetentionService) PreviewRetention(ctx context.Context, backupID string) (*dto.RetentionPreviewResponse, error) {
+	bid, err := valueobjects.NewBackupIDFromString(backupID)
+	if err != nil {
+		return nil, err
+	}
+
+	backup, err := s.repo.FindByID(ctx, bid)
+	if err != nil {
+		return nil, err
+	}
+
+	host, err := s.hostRepo.Get(ctx, backup.HostID())
+	if err != nil {
+		return nil, err
+	}
+	legalHold := entities.UnderLegalHold(backup, host)
+
+	// Plain mirrors are overwritten in place and only ever hold the latest copy.
+	if !backup.Incremental() {
+		mirror := path.Base(strings.TrimRight(backup.Destination(), "/"))
+		if backup.Encrypted() {
+			mirror += valueobjects.EncryptedSnapshotSuffix
+		}
+		plan := valueobjects.RetentionPlan{
+			Keep: []valueobjects.RetainedSnapshot{{
+				Name:  mirror,
+				Rules: []valueobjects.RetentionRule{valueobjects.RetentionRuleLatest},
+			}},
+			Purge: []string{},
+		}
+		return s.assembler.ToRetentionPreviewResponse(backup, plan, legalHold), nil
+	}
+
+	pins, err := s.pinRepo.FindByBackupID(ctx, bid)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := s.pools.BackupPath(ctx, backup, host.Path())
+	if err != nil {
+		return nil, err
+	}
+	listResult, err := s.queryBus.ListFiles(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	snapshots := make([]string, 0, len(listResult.Files))
+	for _, f := range listResult.Files {
+		name, ok := valueobjects.SnapshotNameFromArtifact(f.Name, f.IsDir)
+		if ok && !seen[name] {
+			seen[name] = true
+			snapshots = append(snapshots, name)
+		}
+	}
+	sort.Strings(snapshots)
+
+	// 'latest' always points to the newest promoted snapshot.
+	latest := ""
+	if len(snapshots) > 0 {
+		latest = snapshots[len(snapshots)-1]
+	}
+
+	plan := backup.RetentionPolicy().PlanSnapshots(snapshots, latest, entities.ActivePinnedSnapshots(pins, entities.NowFunc()))
+	if legalHold {
+		plan = plan.Hold()
+	}
+	return s.assembler.ToRetentionPreviewResponse(backup, plan, legalHold), nil
+}
+
+// PinSnapshot exempts a snapshot from retention. Pinning an already pinned
+// snapshot replaces its label, note and expiry.
+func (s *BackupRetentionService) PinSnapshot(ctx context.Context, backupID string, req dto.PinSnapshotRequest) (*dto.SnapshotPinResponse, error) {
+	bid, err := valueobjects.NewBackupIDFromString(backupID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.FindByID(ctx, bid); err != nil {
+		return nil, err
+	}
+
+	pin, err := entities.NewSnapshotPin(bid, req.Snapshot, req.Label, req.Note, req.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.pinRepo.Save(ctx, pin); err != nil {
+		return nil, err
+	}
+
+	resp := s.assembler.ToSnapshotPinResponse(pin)
+	return &resp, nil
+}
+
+func (s *BackupRetentionService) ListPins(ctx context.Context, backupID string) ([]dto.SnapshotPinResponse, error) {
+	bid, err := valueobjects.NewBackupIDFromString(backupID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.FindByID(ctx, bid); err != nil {
+		return nil, err
+	}
+
+	pins, err := s.pinRepo.FindByBackupID(ctx, bid)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]dto.SnapshotPinResponse, 0, len(pins))
+	for _, p := range pins {
+		res = append(res, s.assembler.ToSnapshotPinResponse(p))
+	}
+	return res, nil
+}
+
+func (s *BackupRetentionService) UnpinSnapshot(ctx context.Context, backupID, snapshot string) error {
+	bid, err := valueobjects.NewBackupIDFromString(backupID)
+	if err != nil {
+		return err
+	}
+
+	return s.pinRepo.Delete(ctx, bid, snapshot)
+}
+
+// SetBackupLegalHold places or releases a legal hold on a backup and records
+// who changed it. userID is nil when the actor is unknown.
+func (s *BackupRetentionService) SetBackupLegalHold(ctx context.Context, backupID string, req dto.LegalHoldRequest, userID *int) (*dto.LegalHoldEventResponse, error) {
+	if req.Enabled && strings.TrimSpace(req.Reason) == "" {
+		return nil, entities.ErrLegalHoldReasonRequired
+	}
+
+	bid, err := valueobjects.NewBackupIDFromString(backupID)
+	if err != nil {
+		return nil, err
+	}
+
+	backup, err := s.repo.FindByID(ctx, bid)
+	if err != nil {
+		return nil, err
+	}
+
+	backup.SetLegalHold(req.Enabled)
+	if err := s.repo.Save(ctx, backup); err != nil {
+		return nil, err
+	}
+
+	return s.recordLegalHoldEvent(ctx, entities.NewLegalHoldEvent(entities.LegalHoldScopeBackup, backupID, req.Enabled, req.Reason, userID))
+}
+
+// SetHostLegalHold places or releases a legal hold covering every backup of a host.
+func (s *BackupRetentionService) SetHostLegalHold(ctx context.Context, hostID string, req dto.LegalHoldRequest, userID *int) (*dto.LegalHoldEventResponse, error) {
+	if req.Enabled && strings.TrimSpace(req.Reason) == "" {
+		return nil, entities.ErrLegalHoldReasonRequired
+	}
+
+	hid, err := entities.NewHostIDFromString(hostID)
+	if err != nil {
+		return nil, err
+	}
+
+	host, err := s.hostRepo.Get(ctx, hid)
+	if err != nil {
+		return nil, err
+	}
+
+	host.SetLegalHold(req.Enabled)
+	if err := s.hostRepo.Update(ctx, host); err != nil {
+		return nil, err
+	}
+
+	return s.recordLegalHoldEvent(ctx, entities.NewLegalHoldEvent(entities.LegalHoldScopeHost, hostID, req.Enabled, req.Reason, userID))
+}
+
+// ListLegalHoldEvents returns the audit trail of a backup or host hold, newest first.
+func (s *BackupRetentionService) ListLegalHoldEvents(ctx context.Context, scope entities.LegalHoldScope, targetID string) ([]dto.LegalHoldEventResponse, error) {
+	events, err := s.eventRepo.FindByTarget(ctx, scope, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]dto.LegalHoldEventResponse, 0, len(events))
+	for _, e := range events {
+		res = append(res, s.assembler.ToLegalHoldEventResponse(e))
+	}
+	return res, nil
+}
+
+func (s *BackupRetentionService) recordLegalHoldEvent(ctx context.Context, event *entities.LegalHoldEvent) (*dto.LegalHoldEventResponse, error) {
+	if err := s.eventRepo.Save(ctx, event); err != nil {
+		return nil, err
+	}
+	resp := s.assembler.ToLegalHoldEventResponse(event)
+	return &resp, nil
+}
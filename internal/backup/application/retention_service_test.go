@@ -0,0 +1,207 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application/assembler"
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/backup/infrastructure/persistence/memory"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBackupRetentionService_PreviewRetention(t *testing.T) {
+	mockRepo := new(MockBackupRepository)
+	mockHostRepo := new(MockHostRepository)
+	mockQueryBus := new(MockWorkerQueryBus)
+	pinRepo := memory.NewSnapshotPinRepositoryMemory()
+	service := NewBackupRetentionService(mockRepo, mockHostRepo, pinRepo, memory.NewLegalHoldEventRepositoryMemory(), mockQueryBus, assembler.NewBackupAssembler(), nil)
+	ctx := context.Background()
+
+	hostID := entities.NewHostID()
+	host := entities.NewHostWithID(hostID, "Host1", "host1", "user", 22, "host_path", false)
+
+	backupID := valueobjects.NewBackupID()
+	backup, _ := entities.NewBackupWithID(backupID, hostID, "/src", "backup_dest", entities.NewBackupSchedule("@daily"), nil, true, 1, false)
+	backup.SetRetentionPolicy(valueobjects.RetentionPolicy{KeepLast: 1, KeepDaily: 2})
+
+	mockRepo.On("FindByID", ctx, backupID).Return(backup, nil)
+	mockHostRepo.On("Get", ctx, hostID).Return(host, nil)
+	mockQueryBus.On("ListFiles", ctx, "/mnt/backups/host_path/backup_dest").Return(workerDto.ListFilesResult{
+		Files: []workerDto.FileListItem{
+			{Name: "2024-01-02_10-00-00", IsDir: true},
+			{Name: "latest", IsDir: true},
+			{Name: ".partial-2024-01-03_10-00-00", IsDir: true},
+			{Name: "2024-01-01_10-00-00", IsDir: true},
+			{Name: "2024-01-02_09-00-00.tar.gz.enc", IsDir: false},
+			{Name: "2024-01-03_10-00-00", IsDir: true},
+		},
+	}, nil)
+
+	expired := time.Now().Add(-time.Hour)
+	_ = pinRepo.Save(ctx, &entities.SnapshotPin{BackupID: backupID, Snapshot: "2024-01-01_10-00-00", ExpiresAt: &expired})
+
+	preview, err := service.PreviewRetention(ctx, backupID.String())
+
+	assert.NoError(t, err)
+	assert.False(t, preview.LegalHold)
+	assert.Equal(t, 1, preview.Retention)
+	assert.Equal(t, 2, preview.Policy.KeepDaily)
+	assert.Len(t, preview.Keep, 2)
+	assert.Equal(t, "2024-01-02_10-00-00", preview.Keep[0].Name)
+	assert.Equal(t, []string{"daily"}, preview.Keep[0].Rules)
+	assert.Equal(t, "2024-01-03_10-00-00", preview.Keep[1].Name)
+	assert.Equal(t, []string{"last", "daily", "latest"}, preview.Keep[1].Rules)
+	assert.Equal(t, []string{"2024-01-01_10-00-00", "2024-01-02_09-00-00"}, preview.Purge)
+}
+
+func TestBackupRetentionService_PreviewRetention_PlainMirror(t *testing.T) {
+	mockRepo := new(MockBackupRepository)
+	mockHostRepo := new(MockHostRepository)
+	mockQueryBus := new(MockWorkerQueryBus)
+	pinRepo := memory.NewSnapshotPinRepositoryMemory()
+	service := NewBackupRetentionService(mockRepo, mockHostRepo, pinRepo, memory.NewLegalHoldEventRepositoryMemory(), mockQueryBus, assembler.NewBackupAssembler(), nil)
+	ctx := context.Background()
+
+	hostID := entities.NewHostID()
+	host := entities.NewHostWithID(hostID, "Host1", "host1", "user", 22, "host_path", false)
+
+	backupID := valueobjects.NewBackupID()
+	backup, _ := entities.NewBackupWithID(backupID, hostID, "/src", "backup_dest/", entities.NewBackupSchedule("@daily"), nil, false, 3, true)
+
+	mockRepo.On("FindByID", ctx, backupID).Return(backup, nil)
+	mockHostRepo.On("Get", ctx, hostID).Return(host, nil)
+
+	preview, err := service.PreviewRetention(ctx, backupID.String())
+
+	assert.NoError(t, err)
+	assert.Len(t, preview.Keep, 1)
+	assert.Equal(t, "backup_dest.tar.gz.enc", preview.Keep[0].Name)
+	assert.Equal(t, []string{"latest"}, preview.Keep[0].Rules)
+	assert.Empty(t, preview.Purge)
+	mockQueryBus.AssertNotCalled(t, "ListFiles", mock.Anything, mock.Anything)
+}
+
+func TestBackupRetentionService_PreviewRetention_PinsAndLegalHold(t *testing.T) {
+	mockRepo := new(MockBackupRepository)
+	mockHostRepo := new(MockHostRepository)
+	mockQueryBus := new(MockWorkerQueryBus)
+	pinRepo := memory.NewSnapshotPinRepositoryMemory()
+	service := NewBackupRetentionService(mockRepo, mockHostRepo, pinRepo, memory.NewLegalHoldEventRepositoryMemory(), mockQueryBus, assembler.NewBackupAssembler(), nil)
+	ctx := context.Background()
+
+	hostID := entities.NewHostID()
+	host := entities.NewHostWithID(hostID, "Host1", "host1", "user", 22, "host_path", false)
+
+	backupID := valueobjects.NewBackupID()
+	backup, _ := entities.NewBackupWithID(backupID, hostID, "/src", "backup_dest", entities.NewBackupSchedule("@daily"), nil, true, 1, false)
+
+	mockRepo.On("FindByID", ctx, backupID).Return(backup, nil)
+	mockHostRepo.On("Get", ctx, hostID).Return(host, nil)
+	mockQueryBus.On("ListFiles", ctx, "/mnt/backups/host_path/backup_dest").Return(workerDto.ListFilesResult{
+		Files: []workerDto.FileListItem{
+			{Name: "2024-01-01_10-00-00", IsDir: true},
+			{Name: "2024-01-02_10-00-00", IsDir: true},
+			{Name: "2024-01-03_10-00-00", IsDir: true},
+		},
+	}, nil)
+
+	pin, _ := entities.NewSnapshotPin(backupID, "2024-01-01_10-00-00", "audit", "", nil)
+	_ = pinRepo.Save(ctx, pin)
+
+	t.Run("pinned snapshot is kept", func(t *testing.T) {
+		preview, err := service.PreviewRetention(ctx, backupID.String())
+
+		assert.NoError(t, err)
+		assert.Equal(t, "2024-01-01_10-00-00", preview.Keep[0].Name)
+		assert.Equal(t, []string{"pinned"}, preview.Keep[0].Rules)
+		assert.Equal(t, []string{"2024-01-02_10-00-00"}, preview.Purge)
+	})
+
+	t.Run("host legal hold keeps everything", func(t *testing.T) {
+		host.SetLegalHold(true)
+		defer host.SetLegalHold(false)
+
+		preview, err := service.PreviewRetention(ctx, backupID.String())
+
+		assert.NoError(t, err)
+		assert.True(t, preview.LegalHold)
+		assert.Len(t, preview.Keep, 3)
+		assert.Equal(t, []string{"legal_hold"}, preview.Keep[1].Rules)
+		assert.Empty(t, preview.Purge)
+	})
+}
+
+func TestBackupRetentionService_Pins(t *testing.T) {
+	mockRepo := new(MockBackupRepository)
+	service := NewBackupRetentionService(mockRepo, new(MockHostRepository), memory.NewSnapshotPinRepositoryMemory(), memory.NewLegalHoldEventRepositoryMemory(), new(MockWorkerQueryBus), assembler.NewBackupAssembler(), nil)
+	ctx := context.Background()
+
+	backupID := valueobjects.NewBackupID()
+	backup, _ := entities.NewBackupWithID(backupID, entities.NewHostID(), "/src", "dest", entities.NewBackupSchedule("@daily"), nil, true, 1, false)
+	mockRepo.On("FindByID", ctx, backupID).Return(backup, nil)
+
+	_, err := service.PinSnapshot(ctx, backupID.String(), dto.PinSnapshotRequest{Snapshot: "latest"})
+	assert.ErrorIs(t, err, entities.ErrInvalidSnapshotName)
+
+	pin, err := service.PinSnapshot(ctx, backupID.String(), dto.PinSnapshotRequest{Snapshot: "2024-01-01_10-00-00", Label: "before-migration"})
+	assert.NoError(t, err)
+	assert.True(t, pin.Active)
+
+	pins, err := service.ListPins(ctx, backupID.String())
+	assert.NoError(t, err)
+	assert.Len(t, pins, 1)
+	assert.Equal(t, "before-migration", pins[0].Label)
+
+	assert.NoError(t, service.UnpinSnapshot(ctx, backupID.String(), "2024-01-01_10-00-00"))
+	assert.ErrorIs(t, service.UnpinSnapshot(ctx, backupID.String(), "2024-01-01_10-00-00"), shared.ErrNotFound)
+}
+
+func TestBackupRetentionService_SetLegalHold(t *testing.T) {
+	mockRepo := new(MockBackupRepository)
+	mockHostRepo := new(MockHostRepository)
+	service := NewBackupRetentionService(mockRepo, mockHostRepo, memory.NewSnapshotPinRepositoryMemory(), memory.NewLegalHoldEventRepositoryMemory(), new(MockWorkerQueryBus), assembler.NewBackupAssembler(), nil)
+	ctx := context.Background()
+
+	hostID := entities.NewHostID()
+	host := entities.NewHostWithID(hostID, "Host1", "host1", "user", 22, "host_path", false)
+	backupID := valueobjects.NewBackupID()
+	backup, _ := entities.NewBackupWithID(backupID, hostID, "/src", "dest", entities.NewBackupSchedule("@daily"), nil, true, 1, false)
+
+	mockRepo.On("FindByID", ctx, backupID).Return(backup, nil)
+	mockRepo.On("Save", ctx, backup).Return(nil)
+	mockHostRepo.On("Get", ctx, hostID).Return(host, nil)
+	mockHostRepo.On("Update", ctx, host).Return(nil)
+
+	userID := 7
+
+	_, err := service.SetBackupLegalHold(ctx, backupID.String(), dto.LegalHoldRequest{Enabled: true}, &userID)
+	assert.ErrorIs(t, err, entities.ErrLegalHoldReasonRequired)
+	assert.False(t, backup.LegalHold())
+
+	event, err := service.SetBackupLegalHold(ctx, backupID.String(), dto.LegalHoldRequest{Enabled: true, Reason: "litigation"}, &userID)
+	assert.NoError(t, err)
+	assert.True(t, backup.LegalHold())
+	assert.Equal(t, "backup", event.Scope)
+	assert.Equal(t, &userID, event.UserID)
+
+	_, err = service.SetBackupLegalHold(ctx, backupID.String(), dto.LegalHoldRequest{Enabled: false}, nil)
+	assert.NoError(t, err)
+	assert.False(t, backup.LegalHold())
+
+	_, err = service.SetHostLegalHold(ctx, hostID.String(), dto.LegalHoldRequest{Enabled: true, Reason: "audit"}, nil)
+	assert.NoError(t, err)
+	assert.True(t, host.LegalHold())
+
+	events, err := service.ListLegalHoldEvents(ctx, entities.LegalHoldScopeBackup, backupID.String())
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+	assert.False(t, events[0].Enabled)
+	assert.Equal(t, "litigation", events[1].Reason)
+}
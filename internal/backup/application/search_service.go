@@ -2,160 +2,394 @@ package application
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	pathpkg "path"
 	"strings"
+	"time"
 
 	"github.com/rrbarrero/justbackup/internal/backup/application/assembler"
 	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
 	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
 	"github.com/rrbarrero/justbackup/internal/backup/domain/interfaces"
 	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
 )
 
 type BackupSearchService struct {
 	repo        interfaces.BackupRepository
+	catalogRepo interfaces.FileCatalogRepository
 	hostService *HostService
 	queryBus    interfaces.WorkerQueryBus
 	assembler   *assembler.BackupAssembler
+	pools       *StoragePoolService
 }
 
 func NewBackupSearchService(
 	repo interfaces.BackupRepository,
+	catalogRepo interfaces.FileCatalogRepository,
 	hostService *HostService,
 	queryBus interfaces.WorkerQueryBus,
 	assembler *assembler.BackupAssembler,
+	pools *StoragePoolService,
 ) *BackupSearchService {
 	return &BackupSearchService{
 		repo:        repo,
+		catalogRepo: catalogRepo,
 		hostService: hostService,
 		queryBus:    queryBus,
 		assembler:   assembler,
+		pools:       pools,
 	}
 }
 
-const baseMountPoint = "/mnt/backups"
-
-func (s *BackupSearchService) SearchFiles(ctx context.Context, pattern string) ([]*dto.FileSearchResult, error) {
-	searchResult, err := s.queryBus.SearchFiles(ctx, pattern)
+// SearchFiles searches the file catalog, which holds the files of every
+// snapshot as of the last backup run.
+func (s *BackupSearchService) SearchFiles(ctx context.Context, req dto.FileSearchRequest) (*dto.FileSearchResponse, error) {
+	filter, err := s.catalogFilter(ctx, req)
 	if err != nil {
 		return nil, err
 	}
+	if filter, err = filter.Normalize(); err != nil {
+		return nil, err
+	}
 
-	if len(searchResult.Files) == 0 {
-		return []*dto.FileSearchResult{}, nil
+	entries, total, err := s.catalogRepo.Search(ctx, filter)
+	if err != nil {
+		return nil, err
 	}
 
-	metadata, err := s.loadBackupMetadata(ctx)
+	ids := make([]valueobjects.BackupID, 0, len(entries))
+	for _, e := range entries {
+		ids = append(ids, e.BackupID)
+	}
+	backups, err := s.loadBackupResponses(ctx, ids)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.matchFilesToBackups(searchResult.Files, metadata), nil
+	results := make([]*dto.FileSearchResult, 0, len(entries))
+	for _, e := range entries {
+		results = append(results, &dto.FileSearchResult{
+			Path:     e.Path,
+			Snapshot: e.Snapshot,
+			Size:     e.Size,
+			ModTime:  e.ModTime,
+			Hash:     e.Hash,
+			Backup:   backups[e.BackupID.String()],
+		})
+	}
+
+	return &dto.FileSearchResponse{
+		Results:  results,
+		Total:    total,
+		Page:     filter.Page,
+		PageSize: filter.PageSize,
+	}, nil
 }
 
-func (s *BackupSearchService) ListFiles(ctx context.Context, backupID string, path string) ([]*dto.BackupFileResponse, error) {
-	bid, err := valueobjects.NewBackupIDFromString(backupID)
-	if err != nil {
+// maxGrepLinesPerFile bounds the matching lines reported for one file.
+const maxGrepLinesPerFile = 10
+
+// GrepFiles searches the content index, which holds the text of the small
+// text files of backups that enable it, as of the last backup run.
+func (s *BackupSearchService) GrepFiles(ctx context.Context, req dto.FileGrepRequest) (*dto.FileGrepResponse, error) {
+	filter := valueobjects.ContentFilter{
+		Query:    req.Query,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}
+	var err error
+	if filter.BackupIDs, err = s.backupScope(ctx, req.HostID, req.BackupID, valueobjects.ErrInvalidContentQuery); err != nil {
 		return nil, err
 	}
-
-	backup, err := s.repo.FindByID(ctx, bid)
-	if err != nil {
+	if filter, err = filter.Normalize(); err != nil {
 		return nil, err
 	}
 
-	hostResp, err := s.hostService.GetHost(ctx, backup.HostID().String())
+	matches, total, err := s.catalogRepo.SearchContents(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
 
-	fullPath := s.computeFullBackupPath(hostResp.Path, backup.Destination())
-	if path != "" {
-		fullPath = fullPath + "/" + strings.TrimPrefix(path, "/")
+	ids := make([]valueobjects.BackupID, 0, len(matches))
+	for _, m := range matches {
+		ids = append(ids, m.BackupID)
 	}
-
-	listResult, err := s.queryBus.ListFiles(ctx, fullPath)
+	backups, err := s.loadBackupResponses(ctx, ids)
 	if err != nil {
 		return nil, err
 	}
 
-	var files []*dto.BackupFileResponse
-	for _, f := range listResult.Files {
-		files = append(files, &dto.BackupFileResponse{
-			Name:  f.Name,
-			IsDir: f.IsDir,
-			Size:  f.Size,
+	results := make([]*dto.FileGrepResult, 0, len(matches))
+	for _, m := range matches {
+		lines := make([]dto.FileGrepLine, 0)
+		for _, l := range m.MatchingLines(filter.Query, maxGrepLinesPerFile) {
+			lines = append(lines, dto.FileGrepLine{Line: l.Number, Text: l.Text})
+		}
+		results = append(results, &dto.FileGrepResult{
+			Path:      m.Path,
+			Snapshots: m.Snapshots,
+			Hash:      m.Hash,
+			Lines:     lines,
+			Backup:    backups[m.BackupID.String()],
 		})
 	}
 
-	return files, nil
+	return &dto.FileGrepResponse{
+		Results:  results,
+		Total:    total,
+		Page:     filter.Page,
+		PageSize: filter.PageSize,
+	}, nil
+}
+
+func (s *BackupSearchService) catalogFilter(ctx context.Context, req dto.FileSearchRequest) (valueobjects.CatalogFilter, error) {
+	filter := valueobjects.CatalogFilter{
+		Glob:     req.Pattern,
+		Regex:    req.Regex,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}
+
+	var err error
+	if filter.BackupIDs, err = s.backupScope(ctx, req.HostID, req.BackupID, valueobjects.ErrInvalidCatalogFilter); err != nil {
+		return filter, err
+	}
+	if filter.ModifiedAfter, err = parseCatalogTime(req.ModifiedAfter, false); err != nil {
+		return filter, err
+	}
+	if filter.ModifiedBefore, err = parseCatalogTime(req.ModifiedBefore, true); err != nil {
+		return filter, err
+	}
+	if filter.MinSize, err = parseCatalogSize(req.MinSize); err != nil {
+		return filter, err
+	}
+	if filter.MaxSize, err = parseCatalogSize(req.MaxSize); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// backupScope resolves optional host and backup filters to the backups to
+// search; nil means every backup. Malformed IDs are reported as errInvalid.
+func (s *BackupSearchService) backupScope(ctx context.Context, hostID string, backupID string, errInvalid error) ([]valueobjects.BackupID, error) {
+	var ids []valueobjects.BackupID
+	if backupID != "" {
+		bid, err := valueobjects.NewBackupIDFromString(backupID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad backup ID", errInvalid)
+		}
+		ids = []valueobjects.BackupID{bid}
+	}
+	if hostID != "" {
+		hid, err := entities.NewHostIDFromString(hostID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad host ID", errInvalid)
+		}
+		backups, err := s.repo.FindByHostID(ctx, hid)
+		if err != nil {
+			return nil, err
+		}
+		ids = intersectBackupIDs(ids, backups)
+	}
+	return ids, nil
+}
+
+// intersectBackupIDs restricts ids to the given backups; a nil ids means no
+// restriction yet. The result is never nil, so a host without backups
+// matches nothing.
+func intersectBackupIDs(ids []valueobjects.BackupID, backups []*entities.Backup) []valueobjects.BackupID {
+	result := make([]valueobjects.BackupID, 0, len(backups))
+	for _, b := range backups {
+		if ids == nil {
+			result = append(result, b.ID())
+			continue
+		}
+		for _, id := range ids {
+			if id.Equals(b.ID()) {
+				result = append(result, b.ID())
+			}
+		}
+	}
+	return result
 }
 
-type backupMetadata struct {
-	Backup *entities.Backup
-	Host   *dto.HostResponse
-	Prefix string
+// parseCatalogTime parses an RFC 3339 time or a bare date. A bare date used
+// as an upper bound means the end of that day.
+func parseCatalogTime(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad date %q", valueobjects.ErrInvalidCatalogFilter, value)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &t, nil
 }
 
-func (s *BackupSearchService) loadBackupMetadata(ctx context.Context) ([]backupMetadata, error) {
-	backups, err := s.repo.FindAll(ctx)
+func parseCatalogSize(value string) (*int64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	size, err := shared.ParseSize(value)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %v", valueobjects.ErrInvalidCatalogFilter, err)
 	}
+	return &size, nil
+}
 
+// loadBackupResponses describes the given backups, keyed by backup ID.
+// Backups that no longer exist are left out.
+func (s *BackupSearchService) loadBackupResponses(ctx context.Context, ids []valueobjects.BackupID) (map[string]*dto.BackupResponse, error) {
+	backups := make(map[string]*entities.Backup)
 	hostIDs := make([]entities.HostID, 0)
 	seenHosts := make(map[string]bool)
-	for _, b := range backups {
+	for _, id := range ids {
+		if _, ok := backups[id.String()]; ok {
+			continue
+		}
+		b, err := s.repo.FindByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, shared.ErrNotFound) {
+				backups[id.String()] = nil
+				continue
+			}
+			return nil, err
+		}
+		backups[id.String()] = b
 		if !seenHosts[b.HostID().String()] {
 			hostIDs = append(hostIDs, b.HostID())
 			seenHosts[b.HostID().String()] = true
 		}
 	}
 
+	responses := make(map[string]*dto.BackupResponse, len(backups))
+	if len(hostIDs) == 0 {
+		return responses, nil
+	}
 	hostMap, err := s.hostService.GetHostsByIDs(ctx, hostIDs)
 	if err != nil {
 		return nil, err
 	}
-
-	var meta []backupMetadata
-	for _, b := range backups {
+	for id, b := range backups {
+		if b == nil {
+			continue
+		}
 		if h, ok := hostMap[b.HostID().String()]; ok {
-			prefix := s.computeFullBackupPath(h.Path, b.Destination())
-			meta = append(meta, backupMetadata{
-				Backup: b,
-				Host:   h,
-				Prefix: prefix,
-			})
+			responses[id] = s.assembler.ToBackupResponse(b, h.Name, h.Hostname)
 		}
 	}
-	return meta, nil
+	return responses, nil
 }
 
-func (s *BackupSearchService) matchFilesToBackups(files []string, metadata []backupMetadata) []*dto.FileSearchResult {
-	var results []*dto.FileSearchResult
-	for _, filePath := range files {
-		var matched backupMetadata
+// ListFiles lists a directory of a backup. snapshot optionally selects a
+// snapshot of an incremental backup (see valueobjects.ParseSnapshotSelector);
+// path is then relative to that snapshot instead of the backup root.
+// Directories inside encrypted archives are listed from the archive index.
+func (s *BackupSearchService) ListFiles(ctx context.Context, backupID string, path string, snapshot string) ([]*dto.BackupFileResponse, error) {
+	selector, err := valueobjects.ParseSnapshotSelector(snapshot)
+	if err != nil {
+		return nil, err
+	}
 
-		// Find the matching backup using prefix matching
-		for _, m := range metadata {
-			if filePath == m.Prefix || strings.HasPrefix(filePath, m.Prefix+"/") {
-				matched = m
-				break
-			}
-		}
+	bid, err := valueobjects.NewBackupIDFromString(backupID)
+	if err != nil {
+		return nil, err
+	}
+
+	backup, err := s.repo.FindByID(ctx, bid)
+	if err != nil {
+		return nil, err
+	}
 
-		res := &dto.FileSearchResult{
-			Path: filePath,
+	hostResp, err := s.hostService.GetHost(ctx, backup.HostID().String())
+	if err != nil {
+		return nil, err
+	}
+
+	fullPath, err := s.pools.BackupPath(ctx, backup, hostResp.Path)
+	if err != nil {
+		return nil, err
+	}
+	if !selector.IsZero() && backup.Incremental() {
+		loc, err := resolveSnapshot(ctx, s.queryBus, backup, fullPath, selector)
+		if err != nil {
+			return nil, err
+		}
+		if loc.Dir == "" {
+			return s.listArchive(ctx, backup, fullPath, loc.Archive, path)
 		}
+		if fullPath, err = snapshotPath(fullPath, loc, path); err != nil {
+			return nil, err
+		}
+	} else if !selector.IsZero() && selector.String() != valueobjects.SnapshotLatest {
+		return nil, valueobjects.ErrNotIncremental
+	} else if backup.Encrypted() && !backup.Incremental() {
+		// An encrypted mirror is a single archive next to where the
+		// plain mirror would be.
+		parent, name := pathpkg.Split(fullPath)
+		return s.listArchive(ctx, backup, strings.TrimSuffix(parent, "/"), name+valueobjects.EncryptedSnapshotSuffix, path)
+	} else if path != "" {
+		fullPath = fullPath + "/" + strings.TrimPrefix(path, "/")
+	}
 
-		if matched.Backup != nil {
-			res.Backup = s.assembler.ToBackupResponse(matched.Backup, matched.Host.Name, matched.Host.Hostname)
+	listResult, err := s.queryBus.ListFiles(ctx, fullPath)
+	if err != nil {
+		return nil, err
+	}
+	return toBackupFileResponses(listResult.Files), nil
+}
+
+// listArchive lists a directory inside the encrypted archive dir/archive.
+// Archives written before indexes existed can only be listed as themselves.
+func (s *BackupSearchService) listArchive(ctx context.Context, backup *entities.Backup, dir string, archive string, path string) ([]*dto.BackupFileResponse, error) {
+	path = strings.Trim(path, "/")
+	listResult, err := s.queryBus.ListArchive(ctx, backup.ID().String(), pathpkg.Join(dir, archive), path)
+	if err != nil {
+		return nil, err
+	}
+	if !listResult.MissingIndex {
+		return toBackupFileResponses(listResult.Files), nil
+	}
+	if path != "" {
+		return nil, valueobjects.ErrEncryptedSnapshot
+	}
+
+	dirResult, err := s.queryBus.ListFiles(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []*dto.BackupFileResponse
+	for _, f := range dirResult.Files {
+		if f.Name == archive {
+			files = append(files, &dto.BackupFileResponse{Name: f.Name, IsDir: f.IsDir, Size: f.Size})
 		}
+	}
+	return files, nil
+}
 
-		results = append(results, res)
+func toBackupFileResponses(items []workerDto.FileListItem) []*dto.BackupFileResponse {
+	var files []*dto.BackupFileResponse
+	for _, f := range items {
+		files = append(files, &dto.BackupFileResponse{
+			Name:  f.Name,
+			IsDir: f.IsDir,
+			Size:  f.Size,
+		})
 	}
-	return results
+	return files
 }
 
-func (s *BackupSearchService) computeFullBackupPath(hostPath, destination string) string {
-	return baseMountPoint + "/" + strings.Trim(hostPath, "/") + "/" + strings.Trim(destination, "/")
+// backupRootPath returns the directory holding a backup under the root of
+// its storage pool.
+func backupRootPath(root, hostPath, destination string) string {
+	return root + "/" + strings.Trim(hostPath, "/") + "/" + strings.Trim(destination, "/")
 }
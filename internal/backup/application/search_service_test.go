@@ -3,60 +3,240 @@ package application
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/rrbarrero/justbackup/internal/backup/application/assembler"
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
 	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
 	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/backup/infrastructure/persistence/memory"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
 	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
 func TestBackupSearchService_SearchFiles(t *testing.T) {
-	mockRepo := new(MockBackupRepository)
-	mockHostRepo := new(MockHostRepository)
-	mockQueryBus := new(MockWorkerQueryBus)
-	hostService := NewHostService(mockHostRepo, mockRepo)
-	backupAssembler := assembler.NewBackupAssembler()
-	service := NewBackupSearchService(mockRepo, hostService, mockQueryBus, backupAssembler)
 	ctx := context.Background()
 
-	// Setup data
 	hostID := entities.NewHostID()
 	host := entities.NewHostWithID(hostID, "Host1", "host1", "user", 22, "host_path", false)
-
 	backupID := valueobjects.NewBackupID()
-	backup, _ := entities.NewBackupWithID(backupID, hostID, "/src", "backup_dest", entities.NewBackupSchedule("@daily"), nil, false, 0, false)
-
-	t.Run("success finding files associated with backup", func(t *testing.T) {
-		// Mock search result from worker
-		filesFound := []string{
-			"/mnt/backups/host_path/backup_dest/file1.txt",
-			"/mnt/backups/host_path/backup_dest/subdir/file2.txt",
-			"/mnt/backups/other/file3.txt", // Should not match
+	backup, _ := entities.NewBackupWithID(backupID, hostID, "/src", "backup_dest", entities.NewBackupSchedule("@daily"), nil, true, 5, false)
+	otherID := valueobjects.NewBackupID()
+
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 12, 0, 0, 0, time.UTC) }
+	catalogRepo := memory.NewFileCatalogRepositoryMemory()
+	_ = catalogRepo.SaveBatch(ctx, []*entities.CatalogEntry{
+		{BackupID: backupID, Snapshot: "2024-01-01_10-00-00", Path: "etc/hosts", Size: 10, ModTime: day(1), Hash: "a"},
+		{BackupID: backupID, Snapshot: "2024-01-02_10-00-00", Path: "etc/hosts", Size: 12, ModTime: day(2), Hash: "b"},
+		{BackupID: backupID, Snapshot: "2024-01-02_10-00-00", Path: "home/notes.txt", Size: 4096, ModTime: day(2), Hash: "c"},
+		{BackupID: otherID, Path: "docs/readme.txt", Size: 100, ModTime: day(3), Hash: "d"},
+	})
+
+	newService := func() (*BackupSearchService, *MockBackupRepository, *MockHostRepository) {
+		mockRepo := new(MockBackupRepository)
+		mockHostRepo := new(MockHostRepository)
+		mockRepo.On("FindByID", ctx, backupID).Return(backup, nil)
+		mockRepo.On("FindByID", ctx, otherID).Return(nil, shared.ErrNotFound)
+		mockHostRepo.On("GetByIDs", ctx, mock.Anything).Return([]*entities.Host{host}, nil)
+		return NewBackupSearchService(mockRepo, catalogRepo, NewHostService(mockHostRepo, mockRepo), new(MockWorkerQueryBus), assembler.NewBackupAssembler(), nil), mockRepo, mockHostRepo
+	}
+
+	t.Run("matches the glob against file names across backups", func(t *testing.T) {
+		service, _, _ := newService()
+
+		resp, err := service.SearchFiles(ctx, dto.FileSearchRequest{Pattern: "*.txt"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, resp.Total)
+		assert.Len(t, resp.Results, 2)
+		assert.Equal(t, "docs/readme.txt", resp.Results[0].Path)
+		assert.Nil(t, resp.Results[0].Backup)
+		assert.Equal(t, "home/notes.txt", resp.Results[1].Path)
+		assert.Equal(t, "2024-01-02_10-00-00", resp.Results[1].Snapshot)
+		assert.Equal(t, backupID.String(), resp.Results[1].Backup.ID)
+	})
+
+	t.Run("filters by host, date range and size", func(t *testing.T) {
+		service, mockRepo, _ := newService()
+		mockRepo.On("FindByHostID", ctx, hostID).Return([]*entities.Backup{backup}, nil)
+
+		resp, err := service.SearchFiles(ctx, dto.FileSearchRequest{
+			HostID:         hostID.String(),
+			ModifiedAfter:  "2024-01-02",
+			ModifiedBefore: "2024-01-02",
+			MaxSize:        "1K",
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, resp.Results, 1)
+		assert.Equal(t, "etc/hosts", resp.Results[0].Path)
+		assert.Equal(t, int64(12), resp.Results[0].Size)
+	})
+
+	t.Run("paginates", func(t *testing.T) {
+		service, _, _ := newService()
+
+		resp, err := service.SearchFiles(ctx, dto.FileSearchRequest{Regex: "^(etc|home)/", Page: 2, PageSize: 2})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, resp.Total)
+		assert.Equal(t, 2, resp.Page)
+		assert.Equal(t, 2, resp.PageSize)
+		assert.Len(t, resp.Results, 1)
+		assert.Equal(t, "home/notes.txt", resp.Results[0].Path)
+	})
+
+	t.Run("rejects invalid filters", func(t *testing.T) {
+		service, _, _ := newService()
+
+		for _, req := range []dto.FileSearchRequest{
+			{Regex: "("},
+			{MinSize: "ten"},
+			{ModifiedAfter: "yesterday"},
+			{BackupID: "not-a-uuid"},
+		} {
+			_, err := service.SearchFiles(ctx, req)
+			assert.ErrorIs(t, err, valueobjects.ErrInvalidCatalogFilter)
 		}
+	})
+}
+
+func TestBackupSearchService_ListFiles_Snapshot(t *testing.T) {
+	ctx := context.Background()
+
+	hostID := entities.NewHostID()
+	host := entities.NewHostWithID(hostID, "Host1", "host1", "user", 22, "host_path", false)
+	backupID := valueobjects.NewBackupID()
+	backup, _ := entities.NewBackupWithID(backupID, hostID, "/src", "backup_dest", entities.NewBackupSchedule("@daily"), nil, true, 5, false)
+
+	root := workerDto.ListFilesResult{Files: []workerDto.FileListItem{
+		{Name: "2024-01-01_10-00-00", IsDir: true},
+		{Name: "2024-01-02_10-00-00.tar.gz.enc", IsDir: false, Size: 512},
+		{Name: "latest", IsDir: true},
+	}}
+
+	newService := func() (*BackupSearchService, *MockWorkerQueryBus) {
+		mockRepo := new(MockBackupRepository)
+		mockHostRepo := new(MockHostRepository)
+		mockQueryBus := new(MockWorkerQueryBus)
+		mockRepo.On("FindByID", ctx, backupID).Return(backup, nil)
+		mockHostRepo.On("Get", ctx, hostID).Return(host, nil)
+		mockRepo.On("FindByHostID", ctx, hostID).Return([]*entities.Backup{backup}, nil)
+		mockQueryBus.On("ListFiles", ctx, "/mnt/backups/host_path/backup_dest").Return(root, nil)
+		return NewBackupSearchService(mockRepo, memory.NewFileCatalogRepositoryMemory(), NewHostService(mockHostRepo, mockRepo), mockQueryBus, assembler.NewBackupAssembler(), nil), mockQueryBus
+	}
+
+	t.Run("lists inside the selected snapshot directory", func(t *testing.T) {
+		service, mockQueryBus := newService()
+		mockQueryBus.On("ListFiles", ctx, "/mnt/backups/host_path/backup_dest/2024-01-01_10-00-00/etc").Return(workerDto.ListFilesResult{
+			Files: []workerDto.FileListItem{{Name: "hosts", Size: 10}},
+		}, nil)
 
-		searchResult := workerDto.SearchFilesResult{Files: filesFound}
+		files, err := service.ListFiles(ctx, backupID.String(), "/etc", "as of 2024-01-01 23:00")
 
-		mockQueryBus.On("SearchFiles", ctx, "*.txt").Return(searchResult, nil)
+		assert.NoError(t, err)
+		assert.Len(t, files, 1)
+		assert.Equal(t, "hosts", files[0].Name)
+	})
+
+	t.Run("lists inside an encrypted snapshot from its index", func(t *testing.T) {
+		service, mockQueryBus := newService()
+		mockQueryBus.On("ListArchive", ctx, backupID.String(), "/mnt/backups/host_path/backup_dest/2024-01-02_10-00-00.tar.gz.enc", "etc").Return(workerDto.ListFilesResult{
+			Files: []workerDto.FileListItem{{Name: "hosts", Size: 10}},
+		}, nil)
+
+		files, err := service.ListFiles(ctx, backupID.String(), "/etc/", "2024-01-02_10-00-00")
+
+		assert.NoError(t, err)
+		assert.Len(t, files, 1)
+		assert.Equal(t, "hosts", files[0].Name)
+	})
+
+	t.Run("shows only the archive of an unindexed encrypted snapshot", func(t *testing.T) {
+		service, mockQueryBus := newService()
+		mockQueryBus.On("ListArchive", ctx, backupID.String(), "/mnt/backups/host_path/backup_dest/2024-01-02_10-00-00.tar.gz.enc", "").Return(workerDto.ListFilesResult{MissingIndex: true}, nil)
+
+		files, err := service.ListFiles(ctx, backupID.String(), "", "2024-01-02_10-00-00")
+
+		assert.NoError(t, err)
+		assert.Len(t, files, 1)
+		assert.Equal(t, "2024-01-02_10-00-00.tar.gz.enc", files[0].Name)
+	})
+
+	t.Run("rejects browsing inside an unindexed encrypted snapshot", func(t *testing.T) {
+		service, mockQueryBus := newService()
+		mockQueryBus.On("ListArchive", ctx, backupID.String(), "/mnt/backups/host_path/backup_dest/2024-01-02_10-00-00.tar.gz.enc", "etc").Return(workerDto.ListFilesResult{MissingIndex: true}, nil)
+
+		_, err := service.ListFiles(ctx, backupID.String(), "etc", "latest")
+
+		assert.ErrorIs(t, err, valueobjects.ErrEncryptedSnapshot)
+	})
+}
 
-		mockRepo.On("FindAll", ctx).Return([]*entities.Backup{backup}, nil)
+func TestBackupSearchService_GrepFiles(t *testing.T) {
+	ctx := context.Background()
+
+	hostID := entities.NewHostID()
+	host := entities.NewHostWithID(hostID, "Host1", "host1", "user", 22, "host_path", false)
+	backupID := valueobjects.NewBackupID()
+	backup, _ := entities.NewBackupWithID(backupID, hostID, "/src", "backup_dest", entities.NewBackupSchedule("@daily"), nil, true, 5, false)
+	otherID := valueobjects.NewBackupID()
+
+	catalogRepo := memory.NewFileCatalogRepositoryMemory()
+	_ = catalogRepo.SaveBatch(ctx, []*entities.CatalogEntry{
+		{BackupID: backupID, Snapshot: "2024-01-01_10-00-00", Path: "app/config.py", Hash: "a"},
+		{BackupID: backupID, Snapshot: "2024-01-02_10-00-00", Path: "app/config.py", Hash: "a"},
+		{BackupID: backupID, Snapshot: "2024-01-02_10-00-00", Path: "app/main.py", Hash: "b"},
+		{BackupID: otherID, Path: "etc/app.env", Hash: "c"},
+	})
+	_ = catalogRepo.SaveContents(ctx, []*entities.FileContent{
+		{BackupID: backupID, Hash: "a", Content: "import os\nAPI_KEY = os.environ['API_KEY']\n"},
+		{BackupID: backupID, Hash: "b", Content: "def main():\n    pass\n"},
+		{BackupID: otherID, Hash: "c", Content: "api_key=secret\n"},
+	})
+
+	newService := func() (*BackupSearchService, *MockBackupRepository) {
+		mockRepo := new(MockBackupRepository)
+		mockHostRepo := new(MockHostRepository)
+		mockRepo.On("FindByID", ctx, backupID).Return(backup, nil)
+		mockRepo.On("FindByID", ctx, otherID).Return(nil, shared.ErrNotFound)
 		mockHostRepo.On("GetByIDs", ctx, mock.Anything).Return([]*entities.Host{host}, nil)
+		return NewBackupSearchService(mockRepo, catalogRepo, NewHostService(mockHostRepo, mockRepo), new(MockWorkerQueryBus), assembler.NewBackupAssembler(), nil), mockRepo
+	}
+
+	t.Run("returns matching lines once per version", func(t *testing.T) {
+		service, _ := newService()
+
+		resp, err := service.GrepFiles(ctx, dto.FileGrepRequest{Query: "api_key"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, resp.Total)
+		assert.Equal(t, "app/config.py", resp.Results[0].Path)
+		assert.Equal(t, []string{"2024-01-02_10-00-00", "2024-01-01_10-00-00"}, resp.Results[0].Snapshots)
+		assert.Equal(t, []dto.FileGrepLine{{Line: 2, Text: "API_KEY = os.environ['API_KEY']"}}, resp.Results[0].Lines)
+		assert.Equal(t, backupID.String(), resp.Results[0].Backup.ID)
+		assert.Equal(t, "etc/app.env", resp.Results[1].Path)
+		assert.Nil(t, resp.Results[1].Backup)
+	})
 
-		results, err := service.SearchFiles(ctx, "*.txt")
+	t.Run("filters by host", func(t *testing.T) {
+		service, mockRepo := newService()
+		mockRepo.On("FindByHostID", ctx, hostID).Return([]*entities.Backup{backup}, nil)
+
+		resp, err := service.GrepFiles(ctx, dto.FileGrepRequest{Query: "API_KEY", HostID: hostID.String()})
 
 		assert.NoError(t, err)
-		assert.Len(t, results, 3)
+		assert.Equal(t, 1, resp.Total)
+		assert.Equal(t, "app/config.py", resp.Results[0].Path)
+	})
 
-		// Check matches
-		assert.Equal(t, "/mnt/backups/host_path/backup_dest/file1.txt", results[0].Path)
-		assert.NotNil(t, results[0].Backup)
-		assert.Equal(t, backupID.String(), results[0].Backup.ID)
+	t.Run("rejects short queries", func(t *testing.T) {
+		service, _ := newService()
 
-		assert.Equal(t, "/mnt/backups/host_path/backup_dest/subdir/file2.txt", results[1].Path)
-		assert.NotNil(t, results[1].Backup)
+		_, err := service.GrepFiles(ctx, dto.FileGrepRequest{Query: "ap"})
 
-		assert.Equal(t, "/mnt/backups/other/file3.txt", results[2].Path)
-		assert.Nil(t, results[2].Backup)
+		assert.ErrorIs(t, err, valueobjects.ErrInvalidContentQuery)
 	})
 }
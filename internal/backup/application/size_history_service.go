@@ -0,0 +1,264 @@
+package application
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/events"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/interfaces"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+)
+
+// forecastHistoryDays is how much size history capacity forecasts are based
+// on.
+const forecastHistoryDays = 90
+
+// SizeHistoryService keeps the size of every backup run, so growth can be
+// followed per backup and per host, and projects when the backup roots fill.
+type SizeHistoryService struct {
+	historyRepo interfaces.SizeHistoryRepository
+	backupRepo  interfaces.BackupRepository
+	hostRepo    interfaces.HostRepository
+	queryBus    interfaces.WorkerQueryBus
+	pools       *StoragePoolService
+	events      interfaces.EventPublisher
+	warningDays int
+}
+
+// NewSizeHistoryService creates the service. A capacity warning is raised
+// when a root is projected to fill within warningDays; zero disables it.
+func NewSizeHistoryService(historyRepo interfaces.SizeHistoryRepository, backupRepo interfaces.BackupRepository, hostRepo interfaces.HostRepository, queryBus interfaces.WorkerQueryBus, pools *StoragePoolService, events interfaces.EventPublisher, warningDays int) *SizeHistoryService {
+	return &SizeHistoryService{
+		historyRepo: historyRepo,
+		backupRepo:  backupRepo,
+		hostRepo:    hostRepo,
+		queryBus:    queryBus,
+		pools:       pools,
+		events:      events,
+		warningDays: warningDays,
+	}
+}
+
+// Record samples the usage a backup reported at the end of a run and the
+// files the run changed.
+func (s *SizeHistoryService) Record(ctx context.Context, backup *entities.Backup, changes valueobjects.FileChanges) error {
+	return s.historyRepo.Save(ctx, entities.NewSizeSample(backup, changes))
+}
+
+// BackupHistory returns the size samples of a backup over the last days.
+func (s *SizeHistoryService) BackupHistory(ctx context.Context, backupID string, days int) (*dto.SizeHistoryResponse, error) {
+	if days <= 0 {
+		return nil, valueobjects.ErrInvalidHistoryDays
+	}
+	id, err := valueobjects.NewBackupIDFromString(backupID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.backupRepo.FindByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	samples, err := s.historyRepo.FindByBackupID(ctx, id, historySince(days))
+	if err != nil {
+		return nil, err
+	}
+	return toSizeHistoryResponse(samples), nil
+}
+
+// HostHistory returns the size samples of the backups of a host over the
+// last days, with their daily total.
+func (s *SizeHistoryService) HostHistory(ctx context.Context, hostID string, days int) (*dto.SizeHistoryResponse, error) {
+	if days <= 0 {
+		return nil, valueobjects.ErrInvalidHistoryDays
+	}
+	id, err := entities.NewHostIDFromString(hostID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.hostRepo.Get(ctx, id); err != nil {
+		return nil, err
+	}
+
+	samples, err := s.historyRepo.FindByHostID(ctx, id, historySince(days))
+	if err != nil {
+		return nil, err
+	}
+	return toSizeHistoryResponse(samples), nil
+}
+
+// Forecast projects, for the default backup root and every storage pool
+// holding backups, when the growth of the backups on it fills it.
+func (s *SizeHistoryService) Forecast(ctx context.Context, model string) (*dto.CapacityForecastResponse, error) {
+	growthModel, err := valueobjects.ParseGrowthModel(model)
+	if err != nil {
+		return nil, err
+	}
+	return s.forecast(ctx, growthModel)
+}
+
+// CheckCapacity raises a capacity warning for every root projected to fill
+// within the warning threshold. Weekly cycles are taken into account once
+// there is enough history for them.
+func (s *SizeHistoryService) CheckCapacity(ctx context.Context) error {
+	if s.warningDays <= 0 || s.events == nil {
+		return nil
+	}
+	forecast, err := s.forecast(ctx, valueobjects.GrowthModelSeasonal)
+	if err != nil {
+		return err
+	}
+	for _, root := range forecast.Roots {
+		if root.DaysUntilFull == nil || *root.DaysUntilFull >= s.warningDays {
+			continue
+		}
+		log.Printf("Backup root %s is projected to fill in %d days", root.Root, *root.DaysUntilFull)
+		event := events.NewCapacityWarning(root.Root, root.StoragePoolID, root.Free, root.GrowthPerDay, *root.DaysUntilFull, *root.FullAt)
+		if err := s.events.Publish(ctx, event); err != nil {
+			log.Printf("Failed to publish CapacityWarning event: %v", err)
+		}
+	}
+	return nil
+}
+
+type forecastRoot struct {
+	poolID  string
+	backups map[valueobjects.BackupID]bool
+}
+
+func (s *SizeHistoryService) forecast(ctx context.Context, model valueobjects.GrowthModel) (*dto.CapacityForecastResponse, error) {
+	backups, err := s.backupRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	roots := map[string]*forecastRoot{
+		valueobjects.DefaultStorageRoot: {backups: make(map[valueobjects.BackupID]bool)},
+	}
+	for _, b := range backups {
+		path, err := s.pools.BackupRoot(ctx, b)
+		if err != nil {
+			log.Printf("Failed to find the root of backup %s: %v", b.ID(), err)
+			continue
+		}
+		root, ok := roots[path]
+		if !ok {
+			root = &forecastRoot{poolID: b.StoragePoolID(), backups: make(map[valueobjects.BackupID]bool)}
+			roots[path] = root
+		}
+		root.backups[b.ID()] = true
+	}
+
+	samples, err := s.historyRepo.FindSince(ctx, historySince(forecastHistoryDays))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.CapacityForecastResponse{HistoryDays: forecastHistoryDays, Roots: make([]dto.RootForecastResponse, 0, len(roots))}
+	now := entities.NowFunc().UTC()
+	for path, root := range roots {
+		var rootSamples []*entities.SizeSample
+		for _, sample := range samples {
+			if root.backups[sample.BackupID] {
+				rootSamples = append(rootSamples, sample)
+			}
+		}
+
+		// The worker's own root is asked for as the empty path.
+		diskPath := path
+		if root.poolID == "" {
+			diskPath = ""
+		}
+		usage, err := s.queryBus.DiskUsage(ctx, diskPath)
+		if err != nil {
+			return nil, err
+		}
+
+		daily := dailyStoredSizes(rootSamples, now)
+		totals := make([]int64, len(daily))
+		for i, d := range daily {
+			totals[i] = d.StoredSize
+		}
+		var start time.Time
+		if len(daily) > 0 {
+			start, _ = time.Parse(time.DateOnly, daily[0].Date)
+		}
+		growth := valueobjects.ForecastGrowth(totals, start, usage.Free, model)
+
+		forecast := dto.RootForecastResponse{
+			Root:          path,
+			StoragePoolID: root.poolID,
+			Model:         string(growth.Model),
+			Total:         usage.Total,
+			Used:          usage.Used,
+			Free:          usage.Free,
+			GrowthPerDay:  int64(math.Round(growth.GrowthPerDay)),
+			DaysUntilFull: growth.DaysUntilFull,
+		}
+		if len(totals) > 0 {
+			forecast.StoredSize = totals[len(totals)-1]
+		}
+		if growth.DaysUntilFull != nil {
+			fullAt := now.AddDate(0, 0, *growth.DaysUntilFull)
+			forecast.FullAt = &fullAt
+		}
+		resp.Roots = append(resp.Roots, forecast)
+	}
+	sort.Slice(resp.Roots, func(i, j int) bool { return resp.Roots[i].Root < resp.Roots[j].Root })
+	return resp, nil
+}
+
+func historySince(days int) time.Time {
+	return entities.NowFunc().UTC().AddDate(0, 0, -days)
+}
+
+func toSizeHistoryResponse(samples []*entities.SizeSample) *dto.SizeHistoryResponse {
+	resp := &dto.SizeHistoryResponse{Samples: make([]dto.SizeSampleResponse, 0, len(samples))}
+	for _, s := range samples {
+		resp.Samples = append(resp.Samples, dto.SizeSampleResponse{
+			BackupID:     s.BackupID.String(),
+			Size:         s.Usage.Size,
+			UniqueSize:   s.Usage.Unique,
+			StoredSize:   s.Usage.Stored,
+			Files:        s.Changes.Files,
+			ChangedFiles: s.Changes.Changed,
+			SampledAt:    s.SampledAt,
+		})
+	}
+	var until time.Time
+	if len(samples) > 0 {
+		until = samples[len(samples)-1].SampledAt
+	}
+	resp.Daily = dailyStoredSizes(samples, until)
+	return resp
+}
+
+// dailyStoredSizes totals the stored size of the sampled backups for every
+// day from the first sample until the day of until, each backup counted with
+// its last sample so far. The samples are oldest first.
+func dailyStoredSizes(samples []*entities.SizeSample, until time.Time) []dto.DailySizeResponse {
+	daily := make([]dto.DailySizeResponse, 0)
+	if len(samples) == 0 {
+		return daily
+	}
+
+	latest := make(map[valueobjects.BackupID]int64)
+	var total int64
+	next := 0
+	last := until.UTC().Format(time.DateOnly)
+	for day := samples[0].SampledAt.UTC(); ; day = day.AddDate(0, 0, 1) {
+		date := day.Format(time.DateOnly)
+		for ; next < len(samples) && samples[next].SampledAt.UTC().Format(time.DateOnly) <= date; next++ {
+			s := samples[next]
+			total += s.Usage.Stored - latest[s.BackupID]
+			latest[s.BackupID] = s.Usage.Stored
+		}
+		daily = append(daily, dto.DailySizeResponse{Date: date, StoredSize: total})
+		if date >= last {
+			return daily
+		}
+	}
+}
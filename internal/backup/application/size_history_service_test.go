@@ -0,0 +1,120 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/events"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/backup/infrastructure/persistence/memory"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingEventPublisher struct {
+	events []shared.DomainEvent
+}
+
+func (p *recordingEventPublisher) Publish(ctx context.Context, event shared.DomainEvent) error {
+	p.events = append(p.events, event)
+	return nil
+}
+
+// restoreNow puts the clock back once the test is done.
+func restoreNow(t *testing.T) {
+	original := entities.NowFunc
+	t.Cleanup(func() { entities.NowFunc = original })
+}
+
+// recordRuns records one run a day of the fixture backup, ending today,
+// with the stored sizes given, and leaves the clock at today.
+func recordRuns(t *testing.T, f *storagePoolFixture, history *SizeHistoryService, today time.Time, stored ...int64) {
+	t.Helper()
+	for i, size := range stored {
+		day := today.AddDate(0, 0, i-len(stored)+1)
+		entities.NowFunc = func() time.Time { return day }
+		f.backup.SetUsage(valueobjects.SpaceUsage{Size: size, Unique: 10, Stored: size})
+		require.NoError(t, history.Record(context.Background(), f.backup, valueobjects.FileChanges{}))
+	}
+	entities.NowFunc = func() time.Time { return today }
+}
+
+func TestSizeHistoryService_History(t *testing.T) {
+	f := newStoragePoolFixture(t)
+	ctx := context.Background()
+	history := NewSizeHistoryService(memory.NewSizeHistoryRepositoryMemory(), f.backupRepo, f.hostRepo, f.queryBus, f.service, nil, 30)
+	today := time.Date(2024, 3, 10, 2, 0, 0, 0, time.UTC)
+	restoreNow(t)
+
+	other, err := entities.NewBackup(f.host.ID(), "/etc", "etc", entities.NewBackupSchedule("@daily"), nil, true, 5, false)
+	require.NoError(t, err)
+	other.SetUsage(valueobjects.SpaceUsage{Size: 50, Stored: 50})
+	entities.NowFunc = func() time.Time { return today.AddDate(0, 0, -1) }
+	require.NoError(t, history.Record(ctx, other, valueobjects.FileChanges{}))
+	recordRuns(t, f, history, today, 100, 150, 200)
+
+	resp, err := history.BackupHistory(ctx, f.backup.ID().String(), 30)
+	require.NoError(t, err)
+	require.Len(t, resp.Samples, 3)
+	assert.Equal(t, int64(200), resp.Samples[2].StoredSize)
+	assert.Equal(t, int64(10), resp.Samples[2].UniqueSize)
+
+	resp, err = history.HostHistory(ctx, f.host.ID().String(), 30)
+	require.NoError(t, err)
+	assert.Len(t, resp.Samples, 4)
+	require.Len(t, resp.Daily, 3)
+	assert.Equal(t, "2024-03-08", resp.Daily[0].Date)
+	assert.Equal(t, int64(100), resp.Daily[0].StoredSize)
+	assert.Equal(t, int64(200), resp.Daily[1].StoredSize)
+	assert.Equal(t, int64(250), resp.Daily[2].StoredSize)
+
+	resp, err = history.BackupHistory(ctx, f.backup.ID().String(), 1)
+	require.NoError(t, err)
+	assert.Len(t, resp.Samples, 2, "the run a day ago is still within the last day")
+
+	_, err = history.BackupHistory(ctx, f.backup.ID().String(), 0)
+	assert.ErrorIs(t, err, valueobjects.ErrInvalidHistoryDays)
+	_, err = history.HostHistory(ctx, entities.NewHostID().String(), 30)
+	assert.ErrorIs(t, err, shared.ErrNotFound)
+}
+
+func TestSizeHistoryService_ForecastWarnsBeforeFull(t *testing.T) {
+	f := newStoragePoolFixture(t)
+	ctx := context.Background()
+	publisher := &recordingEventPublisher{}
+	history := NewSizeHistoryService(memory.NewSizeHistoryRepositoryMemory(), f.backupRepo, f.hostRepo, f.queryBus, f.service, publisher, 30)
+	today := time.Date(2024, 3, 10, 2, 0, 0, 0, time.UTC)
+	restoreNow(t)
+	recordRuns(t, f, history, today, 1000, 2000, 3000, 4000)
+	f.queryBus.On("DiskUsage", ctx, "").Return(workerDto.DiskUsageResult{Total: 100000, Used: 80000, Free: 20000}, nil)
+
+	forecast, err := history.Forecast(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, forecast.Roots, 1)
+	root := forecast.Roots[0]
+	assert.Equal(t, valueobjects.DefaultStorageRoot, root.Root)
+	assert.Equal(t, "linear", root.Model)
+	assert.Equal(t, int64(4000), root.StoredSize)
+	assert.Equal(t, int64(1000), root.GrowthPerDay)
+	require.NotNil(t, root.DaysUntilFull)
+	assert.Equal(t, 20, *root.DaysUntilFull)
+	assert.Equal(t, today.AddDate(0, 0, 20), *root.FullAt)
+
+	_, err = history.Forecast(ctx, "exponential")
+	assert.ErrorIs(t, err, valueobjects.ErrInvalidGrowthModel)
+
+	require.NoError(t, history.CheckCapacity(ctx))
+	require.Len(t, publisher.events, 1)
+	warning := publisher.events[0].(events.CapacityWarning)
+	assert.Equal(t, 20, warning.DaysUntilFull)
+	assert.Equal(t, int64(20000), warning.Free)
+
+	relaxed := NewSizeHistoryService(memory.NewSizeHistoryRepositoryMemory(), f.backupRepo, f.hostRepo, f.queryBus, f.service, publisher, 10)
+	recordRuns(t, f, relaxed, today, 1000, 2000, 3000, 4000)
+	require.NoError(t, relaxed.CheckCapacity(ctx))
+	assert.Len(t, publisher.events, 1, "20 days left is beyond the threshold")
+}
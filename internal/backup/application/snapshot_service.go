@@ -0,0 +1,335 @@
+package application
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/interfaces"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+)
+
+type BackupSnapshotService struct {
+	repo        interfaces.BackupRepository
+	hostService *HostService
+	pinRepo     interfaces.SnapshotPinRepository
+	queryBus    interfaces.WorkerQueryBus
+	pools       *StoragePoolService
+	tiering     *TieringService
+}
+
+func NewBackupSnapshotService(
+	repo interfaces.BackupRepository,
+	hostService *HostService,
+	pinRepo interfaces.SnapshotPinRepository,
+	queryBus interfaces.WorkerQueryBus,
+	pools *StoragePoolService,
+	tiering *TieringService,
+) *BackupSnapshotService {
+	return &BackupSnapshotService{
+		repo:        repo,
+		hostService: hostService,
+		pinRepo:     pinRepo,
+		queryBus:    queryBus,
+		pools:       pools,
+		tiering:     tiering,
+	}
+}
+
+// ListSnapshots returns the snapshots of an incremental backup, oldest first,
+// including those tiering moved off its storage, which are marked archived.
+func (s *BackupSnapshotService) ListSnapshots(ctx context.Context, backupID string) ([]dto.SnapshotResponse, error) {
+	bid, err := valueobjects.NewBackupIDFromString(backupID)
+	if err != nil {
+		return nil, err
+	}
+
+	backup, err := s.repo.FindByID(ctx, bid)
+	if err != nil {
+		return nil, err
+	}
+
+	if !backup.Incremental() {
+		return nil, valueobjects.ErrNotIncremental
+	}
+
+	hostResp, err := s.hostService.GetHost(ctx, backup.HostID().String())
+	if err != nil {
+		return nil, err
+	}
+
+	pins, err := s.pinRepo.FindByBackupID(ctx, bid)
+	if err != nil {
+		return nil, err
+	}
+	pinned := make(map[string]bool, len(pins))
+	for _, name := range entities.ActivePinnedSnapshots(pins, entities.NowFunc()) {
+		pinned[name] = true
+	}
+
+	root, err := s.pools.BackupPath(ctx, backup, hostResp.Path)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.queryBus.ListSnapshots(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]dto.SnapshotResponse, 0, len(result.Snapshots))
+	for _, snap := range result.Snapshots {
+		snapshots = append(snapshots, dto.SnapshotResponse{
+			Name:      snap.Name,
+			Time:      snap.Time,
+			Size:      snap.Size,
+			FileCount: snap.FileCount,
+			Encrypted: snap.Encrypted,
+			Latest:    snap.Latest,
+			Pinned:    pinned[snap.Name],
+		})
+	}
+
+	if s.tiering == nil {
+		return snapshots, nil
+	}
+	archived, err := s.tiering.archived(ctx, backup)
+	if err != nil {
+		return nil, err
+	}
+	local := make(map[string]bool, len(snapshots))
+	for _, snap := range snapshots {
+		local[snap.Name] = true
+	}
+	for _, a := range archived {
+		if local[a.Snapshot] {
+			continue
+		}
+		t, _ := valueobjects.ParseSnapshotTime(a.Snapshot)
+		snapshots = append(snapshots, dto.SnapshotResponse{
+			Name:      a.Snapshot,
+			Time:      t,
+			Size:      a.Size,
+			Encrypted: a.Encrypted,
+			Pinned:    pinned[a.Snapshot],
+			Archived:  true,
+			Tier:      string(a.Tier),
+		})
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Name < snapshots[j].Name })
+	return snapshots, nil
+}
+
+// ListVersions returns the distinct versions of a file across the snapshots
+// of an incremental backup, oldest first. Snapshots stored only as encrypted
+// archives cannot be inspected and are reported separately.
+func (s *BackupSnapshotService) ListVersions(ctx context.Context, backupID string, filePath string) (*dto.FileVersionsResponse, error) {
+	bid, err := valueobjects.NewBackupIDFromString(backupID)
+	if err != nil {
+		return nil, err
+	}
+
+	backup, err := s.repo.FindByID(ctx, bid)
+	if err != nil {
+		return nil, err
+	}
+
+	if !backup.Incremental() {
+		return nil, valueobjects.ErrNotIncremental
+	}
+
+	hostResp, err := s.hostService.GetHost(ctx, backup.HostID().String())
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := s.pools.BackupPath(ctx, backup, hostResp.Path)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.queryBus.FileVersions(ctx, root, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.FileVersionsResponse{
+		Path:               filePath,
+		Versions:           make([]dto.FileVersionResponse, 0, len(result.Versions)),
+		EncryptedSnapshots: result.EncryptedSnapshots,
+	}
+	for _, v := range result.Versions {
+		resp.Versions = append(resp.Versions, dto.FileVersionResponse{
+			Hash:      v.Hash,
+			Size:      v.Size,
+			ModTime:   v.ModTime,
+			Snapshots: v.Snapshots,
+		})
+	}
+	return resp, nil
+}
+
+// Diff compares two snapshots of an incremental backup. An empty to selects
+// the latest snapshot. Both snapshots must be plain directories; encrypted
+// archives cannot be compared.
+func (s *BackupSnapshotService) Diff(ctx context.Context, backupID string, from string, to string) (*dto.SnapshotDiffResponse, error) {
+	fromSelector, err := valueobjects.ParseSnapshotSelector(from)
+	if err != nil {
+		return nil, err
+	}
+	if fromSelector.IsZero() {
+		return nil, valueobjects.ErrInvalidSnapshotSelector
+	}
+	if to == "" {
+		to = valueobjects.SnapshotLatest
+	}
+	toSelector, err := valueobjects.ParseSnapshotSelector(to)
+	if err != nil {
+		return nil, err
+	}
+
+	bid, err := valueobjects.NewBackupIDFromString(backupID)
+	if err != nil {
+		return nil, err
+	}
+
+	backup, err := s.repo.FindByID(ctx, bid)
+	if err != nil {
+		return nil, err
+	}
+
+	if !backup.Incremental() {
+		return nil, valueobjects.ErrNotIncremental
+	}
+
+	hostResp, err := s.hostService.GetHost(ctx, backup.HostID().String())
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := s.pools.BackupPath(ctx, backup, hostResp.Path)
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := listSnapshotLocations(ctx, s.queryBus, root)
+	if err != nil {
+		return nil, err
+	}
+
+	fromLoc, err := snapshots.resolve(fromSelector)
+	if err != nil {
+		return nil, err
+	}
+	toLoc, err := snapshots.resolve(toSelector)
+	if err != nil {
+		return nil, err
+	}
+	if fromLoc.Dir == "" || toLoc.Dir == "" {
+		return nil, valueobjects.ErrEncryptedSnapshot
+	}
+
+	result, err := s.queryBus.DiffSnapshots(ctx, root, fromLoc.Dir, toLoc.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.SnapshotDiffResponse{
+		From:      fromLoc.Dir,
+		To:        toLoc.Dir,
+		Added:     toDiffEntryResponses(result.Added),
+		Removed:   toDiffEntryResponses(result.Removed),
+		Modified:  toDiffEntryResponses(result.Modified),
+		SizeDelta: result.SizeDelta,
+	}, nil
+}
+
+func toDiffEntryResponses(entries []workerDto.DiffEntry) []dto.DiffEntryResponse {
+	resp := make([]dto.DiffEntryResponse, 0, len(entries))
+	for _, e := range entries {
+		resp = append(resp, dto.DiffEntryResponse{Path: e.Path, Size: e.Size, SizeDelta: e.SizeDelta})
+	}
+	return resp
+}
+
+// snapshotLocation is where a selected snapshot lives under the backup root.
+// Dir is empty when the snapshot only exists as an encrypted archive.
+type snapshotLocation struct {
+	Dir     string
+	Archive string
+}
+
+// resolveSnapshot finds the snapshot a selector picks among the artifacts at
+// the root of a backup. For a plain mirror only "latest" is valid and it
+// resolves to the root itself.
+func resolveSnapshot(ctx context.Context, queryBus interfaces.WorkerQueryBus, backup *entities.Backup, root string, selector valueobjects.SnapshotSelector) (snapshotLocation, error) {
+	if !backup.Incremental() {
+		if selector.String() == valueobjects.SnapshotLatest {
+			return snapshotLocation{Dir: "."}, nil
+		}
+		return snapshotLocation{}, valueobjects.ErrNotIncremental
+	}
+
+	snapshots, err := listSnapshotLocations(ctx, queryBus, root)
+	if err != nil {
+		return snapshotLocation{}, err
+	}
+	return snapshots.resolve(selector)
+}
+
+// snapshotLocations indexes the snapshots found at the root of a backup.
+type snapshotLocations struct {
+	names     []string
+	artifacts map[string]*snapshotLocation
+}
+
+func listSnapshotLocations(ctx context.Context, queryBus interfaces.WorkerQueryBus, root string) (*snapshotLocations, error) {
+	listResult, err := queryBus.ListFiles(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := &snapshotLocations{artifacts: make(map[string]*snapshotLocation)}
+	for _, f := range listResult.Files {
+		name, ok := valueobjects.SnapshotNameFromArtifact(f.Name, f.IsDir)
+		if !ok {
+			continue
+		}
+		loc, seen := snapshots.artifacts[name]
+		if !seen {
+			loc = &snapshotLocation{}
+			snapshots.artifacts[name] = loc
+			snapshots.names = append(snapshots.names, name)
+		}
+		if f.IsDir {
+			loc.Dir = f.Name
+		} else {
+			loc.Archive = f.Name
+		}
+	}
+	return snapshots, nil
+}
+
+func (l *snapshotLocations) resolve(selector valueobjects.SnapshotSelector) (snapshotLocation, error) {
+	name, err := selector.Resolve(l.names)
+	if err != nil {
+		return snapshotLocation{}, err
+	}
+	return *l.artifacts[name], nil
+}
+
+// snapshotPath joins a path inside a backup to the selected snapshot.
+func snapshotPath(root string, loc snapshotLocation, subPath string) (string, error) {
+	subPath = strings.Trim(subPath, "/")
+	if subPath == "." {
+		subPath = ""
+	}
+	if loc.Dir != "" {
+		return path.Join(root, loc.Dir, subPath), nil
+	}
+	if subPath != "" {
+		return "", valueobjects.ErrEncryptedSnapshot
+	}
+	return path.Join(root, loc.Archive), nil
+}
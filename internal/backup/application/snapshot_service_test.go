@@ -0,0 +1,169 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/backup/infrastructure/persistence/memory"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackupSnapshotService_ListSnapshots(t *testing.T) {
+	mockRepo := new(MockBackupRepository)
+	mockHostRepo := new(MockHostRepository)
+	mockQueryBus := new(MockWorkerQueryBus)
+	pinRepo := memory.NewSnapshotPinRepositoryMemory()
+	service := NewBackupSnapshotService(mockRepo, NewHostService(mockHostRepo, mockRepo), pinRepo, mockQueryBus, nil, nil)
+	ctx := context.Background()
+
+	hostID := entities.NewHostID()
+	host := entities.NewHostWithID(hostID, "Host1", "host1", "user", 22, "host_path", false)
+
+	backupID := valueobjects.NewBackupID()
+	backup, _ := entities.NewBackupWithID(backupID, hostID, "/src", "backup_dest", entities.NewBackupSchedule("@daily"), nil, true, 5, false)
+
+	files := int64(12)
+	mockRepo.On("FindByID", ctx, backupID).Return(backup, nil)
+	mockHostRepo.On("Get", ctx, hostID).Return(host, nil)
+	mockRepo.On("FindByHostID", ctx, hostID).Return([]*entities.Backup{backup}, nil)
+	mockQueryBus.On("ListSnapshots", ctx, "/mnt/backups/host_path/backup_dest").Return(workerDto.ListSnapshotsResult{
+		Snapshots: []workerDto.SnapshotInfo{
+			{Name: "2024-01-01_10-00-00", Time: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), Size: 2048, FileCount: &files},
+			{Name: "2024-01-02_10-00-00", Time: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), Size: 1024, Encrypted: true},
+			{Name: "2024-01-03_10-00-00", Time: time.Date(2024, 1, 3, 10, 0, 0, 0, time.UTC), Size: 4096, FileCount: &files, Latest: true},
+		},
+	}, nil)
+
+	expired := time.Now().Add(-time.Hour)
+	_ = pinRepo.Save(ctx, &entities.SnapshotPin{BackupID: backupID, Snapshot: "2024-01-01_10-00-00"})
+	_ = pinRepo.Save(ctx, &entities.SnapshotPin{BackupID: backupID, Snapshot: "2024-01-02_10-00-00", ExpiresAt: &expired})
+
+	snapshots, err := service.ListSnapshots(ctx, backupID.String())
+
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 3)
+	assert.True(t, snapshots[0].Pinned)
+	assert.Equal(t, int64(12), *snapshots[0].FileCount)
+	assert.False(t, snapshots[1].Pinned)
+	assert.True(t, snapshots[1].Encrypted)
+	assert.Nil(t, snapshots[1].FileCount)
+	assert.True(t, snapshots[2].Latest)
+	assert.Equal(t, int64(4096), snapshots[2].Size)
+}
+
+func TestBackupSnapshotService_ListSnapshots_PlainMirror(t *testing.T) {
+	mockRepo := new(MockBackupRepository)
+	mockQueryBus := new(MockWorkerQueryBus)
+	service := NewBackupSnapshotService(mockRepo, NewHostService(new(MockHostRepository), mockRepo), memory.NewSnapshotPinRepositoryMemory(), mockQueryBus, nil, nil)
+	ctx := context.Background()
+
+	backupID := valueobjects.NewBackupID()
+	backup, _ := entities.NewBackupWithID(backupID, entities.NewHostID(), "/src", "backup_dest", entities.NewBackupSchedule("@daily"), nil, false, 5, false)
+	mockRepo.On("FindByID", ctx, backupID).Return(backup, nil)
+
+	_, err := service.ListSnapshots(ctx, backupID.String())
+
+	assert.ErrorIs(t, err, valueobjects.ErrNotIncremental)
+	mockQueryBus.AssertNotCalled(t, "ListSnapshots")
+}
+
+func TestBackupSnapshotService_ListVersions(t *testing.T) {
+	mockRepo := new(MockBackupRepository)
+	mockHostRepo := new(MockHostRepository)
+	mockQueryBus := new(MockWorkerQueryBus)
+	service := NewBackupSnapshotService(mockRepo, NewHostService(mockHostRepo, mockRepo), memory.NewSnapshotPinRepositoryMemory(), mockQueryBus, nil, nil)
+	ctx := context.Background()
+
+	hostID := entities.NewHostID()
+	host := entities.NewHostWithID(hostID, "Host1", "host1", "user", 22, "host_path", false)
+	backupID := valueobjects.NewBackupID()
+	backup, _ := entities.NewBackupWithID(backupID, hostID, "/src", "backup_dest", entities.NewBackupSchedule("@daily"), nil, true, 5, false)
+
+	modTime := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	mockRepo.On("FindByID", ctx, backupID).Return(backup, nil)
+	mockHostRepo.On("Get", ctx, hostID).Return(host, nil)
+	mockRepo.On("FindByHostID", ctx, hostID).Return([]*entities.Backup{backup}, nil)
+	mockQueryBus.On("FileVersions", ctx, "/mnt/backups/host_path/backup_dest", "/etc/nginx/nginx.conf").Return(workerDto.FileVersionsResult{
+		Versions: []workerDto.FileVersion{
+			{Hash: "aaa", Size: 10, ModTime: modTime, Snapshots: []string{"2024-01-01_10-00-00", "2024-01-02_10-00-00"}},
+			{Hash: "bbb", Size: 12, ModTime: modTime.Add(time.Hour), Snapshots: []string{"2024-01-03_10-00-00"}},
+		},
+		EncryptedSnapshots: []string{"2024-01-04_10-00-00"},
+	}, nil)
+
+	versions, err := service.ListVersions(ctx, backupID.String(), "/etc/nginx/nginx.conf")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "/etc/nginx/nginx.conf", versions.Path)
+	assert.Len(t, versions.Versions, 2)
+	assert.Equal(t, "aaa", versions.Versions[0].Hash)
+	assert.Equal(t, []string{"2024-01-01_10-00-00", "2024-01-02_10-00-00"}, versions.Versions[0].Snapshots)
+	assert.Equal(t, int64(12), versions.Versions[1].Size)
+	assert.Equal(t, []string{"2024-01-04_10-00-00"}, versions.EncryptedSnapshots)
+}
+
+func TestBackupSnapshotService_Diff(t *testing.T) {
+	ctx := context.Background()
+
+	hostID := entities.NewHostID()
+	host := entities.NewHostWithID(hostID, "Host1", "host1", "user", 22, "host_path", false)
+	backupID := valueobjects.NewBackupID()
+	backup, _ := entities.NewBackupWithID(backupID, hostID, "/src", "backup_dest", entities.NewBackupSchedule("@daily"), nil, true, 5, false)
+	root := "/mnt/backups/host_path/backup_dest"
+
+	newService := func() (*BackupSnapshotService, *MockWorkerQueryBus) {
+		mockRepo := new(MockBackupRepository)
+		mockHostRepo := new(MockHostRepository)
+		mockQueryBus := new(MockWorkerQueryBus)
+		mockRepo.On("FindByID", ctx, backupID).Return(backup, nil)
+		mockHostRepo.On("Get", ctx, hostID).Return(host, nil)
+		mockRepo.On("FindByHostID", ctx, hostID).Return([]*entities.Backup{backup}, nil)
+		mockQueryBus.On("ListFiles", ctx, root).Return(workerDto.ListFilesResult{Files: []workerDto.FileListItem{
+			{Name: "2024-01-01_10-00-00", IsDir: true},
+			{Name: "2024-01-02_10-00-00", IsDir: true},
+			{Name: "2024-01-03_10-00-00.tar.gz.enc"},
+			{Name: "latest", IsDir: true},
+		}}, nil)
+		return NewBackupSnapshotService(mockRepo, NewHostService(mockHostRepo, mockRepo), memory.NewSnapshotPinRepositoryMemory(), mockQueryBus, nil, nil), mockQueryBus
+	}
+
+	t.Run("resolves both selectors", func(t *testing.T) {
+		service, mockQueryBus := newService()
+		mockQueryBus.On("DiffSnapshots", ctx, root, "2024-01-01_10-00-00", "2024-01-02_10-00-00").Return(workerDto.SnapshotDiffResult{
+			Added:     []workerDto.DiffEntry{{Path: "etc/new.conf", Size: 10, SizeDelta: 10}},
+			Modified:  []workerDto.DiffEntry{{Path: "etc/hosts", Size: 20, SizeDelta: -2}},
+			SizeDelta: 8,
+		}, nil)
+
+		diff, err := service.Diff(ctx, backupID.String(), "as of 2024-01-01 12:00", "2024-01-02_10-00-00")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "2024-01-01_10-00-00", diff.From)
+		assert.Equal(t, "2024-01-02_10-00-00", diff.To)
+		assert.Len(t, diff.Added, 1)
+		assert.Empty(t, diff.Removed)
+		assert.Equal(t, int64(-2), diff.Modified[0].SizeDelta)
+		assert.Equal(t, int64(8), diff.SizeDelta)
+	})
+
+	t.Run("rejects encrypted snapshots", func(t *testing.T) {
+		service, mockQueryBus := newService()
+
+		_, err := service.Diff(ctx, backupID.String(), "2024-01-01_10-00-00", "")
+
+		assert.ErrorIs(t, err, valueobjects.ErrEncryptedSnapshot)
+		mockQueryBus.AssertNotCalled(t, "DiffSnapshots")
+	})
+
+	t.Run("requires from", func(t *testing.T) {
+		service, _ := newService()
+
+		_, err := service.Diff(ctx, backupID.String(), "", "latest")
+
+		assert.ErrorIs(t, err, valueobjects.ErrInvalidSnapshotSelector)
+	})
+}
@@ -0,0 +1,482 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+
+	"github.com/google/uuid"
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/interfaces"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+)
+
+// StoragePoolService manages the storage pools the worker keeps backups in,
+// places new backups on them and moves backups between them. Backups and
+// hosts in no pool use the default backup root.
+type StoragePoolService struct {
+	poolRepo   interfaces.StoragePoolRepository
+	backupRepo interfaces.BackupRepository
+	hostRepo   interfaces.HostRepository
+	publisher  interfaces.TaskPublisher
+	queryBus   interfaces.WorkerQueryBus
+}
+
+func NewStoragePoolService(
+	poolRepo interfaces.StoragePoolRepository,
+	backupRepo interfaces.BackupRepository,
+	hostRepo interfaces.HostRepository,
+	publisher interfaces.TaskPublisher,
+	queryBus interfaces.WorkerQueryBus,
+) *StoragePoolService {
+	return &StoragePoolService{
+		poolRepo:   poolRepo,
+		backupRepo: backupRepo,
+		hostRepo:   hostRepo,
+		publisher:  publisher,
+		queryBus:   queryBus,
+	}
+}
+
+// ListPools returns the pools with how many backups and hosts use each and
+// how full each is.
+func (s *StoragePoolService) ListPools(ctx context.Context) ([]*dto.StoragePoolResponse, error) {
+	pools, err := s.poolRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	backups, hosts, err := s.usage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*dto.StoragePoolResponse, 0, len(pools))
+	for _, pool := range pools {
+		resp := toStoragePoolResponse(pool, backups, hosts)
+		resp.Usage = s.poolUsage(ctx, pool)
+		responses = append(responses, resp)
+	}
+	return responses, nil
+}
+
+func (s *StoragePoolService) GetPool(ctx context.Context, id string) (*dto.StoragePoolResponse, error) {
+	pool, err := s.findPool(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	backups, hosts, err := s.usage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := toStoragePoolResponse(pool, backups, hosts)
+	resp.Usage = s.poolUsage(ctx, pool)
+	return resp, nil
+}
+
+func (s *StoragePoolService) CreatePool(ctx context.Context, req dto.StoragePoolRequest) (*dto.StoragePoolResponse, error) {
+	pool, err := entities.NewStoragePool(req.Name, req.RootPath, req.Capacity, req.Labels)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkUnique(ctx, pool); err != nil {
+		return nil, err
+	}
+
+	if err := s.poolRepo.Save(ctx, pool); err != nil {
+		return nil, err
+	}
+	return toStoragePoolResponse(pool, nil, nil), nil
+}
+
+// UpdatePool replaces the definition of a pool. Its root cannot change
+// while backups are in it, since their data would be left behind.
+func (s *StoragePoolService) UpdatePool(ctx context.Context, id string, req dto.StoragePoolRequest) (*dto.StoragePoolResponse, error) {
+	pool, err := s.findPool(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	backups, hosts, err := s.usage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate on a copy so a rejected update leaves the stored pool untouched.
+	updated := entities.RestoreStoragePool(pool.ID(), pool.Name(), pool.RootPath(), pool.Capacity(), pool.Labels(), pool.CreatedAt(), pool.UpdatedAt())
+	if err := updated.Update(req.Name, req.RootPath, req.Capacity, req.Labels); err != nil {
+		return nil, err
+	}
+	if updated.RootPath() != pool.RootPath() && backups[pool.ID().String()] > 0 {
+		return nil, fmt.Errorf("%w: migrate its backups before changing its root", valueobjects.ErrStoragePoolInUse)
+	}
+	if err := s.checkUnique(ctx, updated); err != nil {
+		return nil, err
+	}
+
+	if err := s.poolRepo.Save(ctx, updated); err != nil {
+		return nil, err
+	}
+	return toStoragePoolResponse(updated, backups, hosts), nil
+}
+
+// DeletePool deletes a pool no backup or host is assigned to.
+func (s *StoragePoolService) DeletePool(ctx context.Context, id string) error {
+	pool, err := s.findPool(ctx, id)
+	if err != nil {
+		return err
+	}
+	backups, hosts, err := s.usage(ctx)
+	if err != nil {
+		return err
+	}
+	if n := backups[pool.ID().String()]; n > 0 {
+		return fmt.Errorf("%w: %d backups are in it", valueobjects.ErrStoragePoolInUse, n)
+	}
+	if n := hosts[pool.ID().String()]; n > 0 {
+		return fmt.Errorf("%w: %d hosts are assigned to it", valueobjects.ErrStoragePoolInUse, n)
+	}
+	return s.poolRepo.Delete(ctx, pool.ID())
+}
+
+// AssignHost makes new backups of a host go in a pool, or be placed by free
+// space when the pool is empty. Its existing backups stay where they are.
+func (s *StoragePoolService) AssignHost(ctx context.Context, hostID string, req dto.AssignStoragePoolRequest) (*dto.HostResponse, error) {
+	hid, err := entities.NewHostIDFromString(hostID)
+	if err != nil {
+		return nil, err
+	}
+	host, err := s.hostRepo.Get(ctx, hid)
+	if err != nil {
+		return nil, err
+	}
+	if req.StoragePoolID != "" {
+		if _, err := s.findPool(ctx, req.StoragePoolID); err != nil {
+			return nil, err
+		}
+	}
+
+	host.SetStoragePool(req.StoragePoolID)
+	if err := s.hostRepo.Update(ctx, host); err != nil {
+		return nil, err
+	}
+	return dto.ToHostResponse(host), nil
+}
+
+// Place picks the pool a new backup goes in: poolID when set, else the pool
+// of its host if it carries labels, else the pool with the most space left
+// among those carrying every one of labels. An empty result is the default
+// backup root, used while there are no pools.
+func (s *StoragePoolService) Place(ctx context.Context, hostPoolID string, poolID string, labels []string) (string, error) {
+	if poolID != "" {
+		pool, err := s.findPool(ctx, poolID)
+		if err != nil {
+			return "", err
+		}
+		return pool.ID().String(), nil
+	}
+	if hostPoolID != "" {
+		pool, err := s.findPool(ctx, hostPoolID)
+		if err != nil {
+			return "", err
+		}
+		if pool.HasLabels(labels) {
+			return pool.ID().String(), nil
+		}
+	}
+
+	pools, err := s.poolRepo.FindAll(ctx)
+	if err != nil {
+		return "", err
+	}
+	var candidates []*entities.StoragePool
+	for _, pool := range pools {
+		if pool.HasLabels(labels) {
+			candidates = append(candidates, pool)
+		}
+	}
+	if len(candidates) == 0 {
+		if len(labels) > 0 {
+			return "", fmt.Errorf("%w: no storage pool carries labels %v", valueobjects.ErrInvalidStoragePool, labels)
+		}
+		return "", nil
+	}
+
+	// Pools the worker cannot report on are only used when none can.
+	best, bestAvailable := candidates[0], int64(-1)
+	for _, pool := range candidates {
+		usage := s.poolUsage(ctx, pool)
+		if usage != nil && usage.Available > bestAvailable {
+			best, bestAvailable = pool, usage.Available
+		}
+	}
+	return best.ID().String(), nil
+}
+
+// BackupRoot returns the root of the pool a backup is in, as the worker
+// sees it. It is the default backup root for backups in no pool, and when
+// there is no pool service at all.
+func (s *StoragePoolService) BackupRoot(ctx context.Context, backup *entities.Backup) (string, error) {
+	if s == nil || backup.StoragePoolID() == "" {
+		return valueobjects.DefaultStorageRoot, nil
+	}
+	pool, err := s.findPool(ctx, backup.StoragePoolID())
+	if err != nil {
+		return "", err
+	}
+	return pool.RootPath(), nil
+}
+
+// Roots returns every storage root the worker keeps data in: the default
+// backup root and the root of each pool.
+func (s *StoragePoolService) Roots(ctx context.Context) ([]string, error) {
+	roots := []string{valueobjects.DefaultStorageRoot}
+	if s == nil {
+		return roots, nil
+	}
+	pools, err := s.poolRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, pool := range pools {
+		roots = append(roots, pool.RootPath())
+	}
+	return roots, nil
+}
+
+// BackupPath returns the directory holding a backup as seen by the worker.
+func (s *StoragePoolService) BackupPath(ctx context.Context, backup *entities.Backup, hostPath string) (string, error) {
+	root, err := s.BackupRoot(ctx, backup)
+	if err != nil {
+		return "", err
+	}
+	return backupRootPath(root, hostPath, backup.Destination()), nil
+}
+
+// Migrate asks a worker to move the data of a backup to another pool, or to
+// the default backup root when the pool is empty. The backup is marked as
+// migrating until the worker reports back, and switched to the pool once it
+// reports the move done. A target directory another backup keeps its data
+// in is refused.
+func (s *StoragePoolService) Migrate(ctx context.Context, backupID string, req dto.AssignStoragePoolRequest) (*dto.MigrateStorageResponse, error) {
+	bid, err := valueobjects.NewBackupIDFromString(backupID)
+	if err != nil {
+		return nil, err
+	}
+	backup, err := s.backupRepo.FindByID(ctx, bid)
+	if err != nil {
+		return nil, err
+	}
+	if backup.StoragePoolID() == req.StoragePoolID {
+		return nil, valueobjects.ErrSameStoragePool
+	}
+	if backup.Status() == valueobjects.BackupStatusRunning {
+		return nil, fmt.Errorf("%w: wait for it to finish before migrating it", valueobjects.ErrBackupRunning)
+	}
+	if backup.Migrating() {
+		return nil, fmt.Errorf("%w: wait for the migration in progress to finish", valueobjects.ErrBackupMigrating)
+	}
+
+	root := ""
+	if req.StoragePoolID != "" {
+		pool, err := s.findPool(ctx, req.StoragePoolID)
+		if err != nil {
+			return nil, err
+		}
+		root = pool.RootPath()
+	}
+	if err := s.checkMigrationTarget(ctx, backup, root); err != nil {
+		return nil, err
+	}
+
+	if err := backup.StartMigration(); err != nil {
+		return nil, err
+	}
+	if err := s.backupRepo.Save(ctx, backup); err != nil {
+		return nil, err
+	}
+	taskID, err := s.publisher.PublishMigrateStorageTask(ctx, backup, root, req.StoragePoolID)
+	if err != nil {
+		backup.EndMigration()
+		if saveErr := s.backupRepo.Save(ctx, backup); saveErr != nil {
+			log.Printf("Failed to clear the migration of backup %s: %v", backup.ID(), saveErr)
+		}
+		return nil, err
+	}
+	return &dto.MigrateStorageResponse{TaskID: taskID}, nil
+}
+
+// checkMigrationTarget refuses to move a backup to a directory of root, the
+// default backup root when empty, that is, holds or lies in the directory of
+// another backup.
+func (s *StoragePoolService) checkMigrationTarget(ctx context.Context, backup *entities.Backup, root string) error {
+	if root == "" {
+		root = valueobjects.DefaultStorageRoot
+	}
+	host, err := s.hostRepo.Get(ctx, backup.HostID())
+	if err != nil {
+		return err
+	}
+	target := path.Clean(backupRootPath(root, host.Path(), backup.Destination()))
+
+	backups, err := s.backupRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	refs := make([]string, 0, len(backups))
+	for _, other := range backups {
+		if other.ID() == backup.ID() {
+			continue
+		}
+		otherHost := host
+		if other.HostID() != backup.HostID() {
+			if otherHost, err = s.hostRepo.Get(ctx, other.HostID()); err != nil {
+				return err
+			}
+		}
+		dir, err := s.BackupPath(ctx, other, otherHost.Path())
+		if err != nil {
+			return fmt.Errorf("failed to locate the data of backup %s: %w", other.ID(), err)
+		}
+		refs = append(refs, path.Clean(dir))
+	}
+	if ref, ok := overlapping(target, refs); ok {
+		return fmt.Errorf("%w: %s overlaps %s of another backup", valueobjects.ErrMigrationTarget, target, ref)
+	}
+	return nil
+}
+
+// RecordMigration switches a backup to the pool its data was moved to and
+// rebuilds its file catalog there.
+func (s *StoragePoolService) RecordMigration(ctx context.Context, result workerDto.MigrateStorageResult) (*entities.Backup, error) {
+	bid, err := valueobjects.NewBackupIDFromString(result.BackupID)
+	if err != nil {
+		return nil, err
+	}
+	backup, err := s.backupRepo.FindByID(ctx, bid)
+	if err != nil {
+		return nil, err
+	}
+
+	backup.SetStoragePool(result.StoragePoolID)
+	backup.EndMigration()
+	if err := s.backupRepo.Save(ctx, backup); err != nil {
+		return nil, err
+	}
+	if _, err := s.publisher.PublishCatalogTask(ctx, backup, ""); err != nil {
+		log.Printf("Failed to publish catalog task for migrated backup %s: %v", backup.ID(), err)
+	}
+	return backup, nil
+}
+
+// RecordFailedMigration clears the migration of a backup whose data the
+// worker could not move, leaving it in the pool it was in.
+func (s *StoragePoolService) RecordFailedMigration(ctx context.Context, result workerDto.MigrateStorageResult) error {
+	bid, err := valueobjects.NewBackupIDFromString(result.BackupID)
+	if err != nil {
+		return err
+	}
+	backup, err := s.backupRepo.FindByID(ctx, bid)
+	if err != nil {
+		return err
+	}
+	backup.EndMigration()
+	return s.backupRepo.Save(ctx, backup)
+}
+
+func (s *StoragePoolService) findPool(ctx context.Context, id string) (*entities.StoragePool, error) {
+	pid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, shared.ErrInvalidID
+	}
+	return s.poolRepo.FindByID(ctx, pid)
+}
+
+// checkUnique rejects a pool sharing its name or root with another one, or
+// rooted at the default backup root.
+func (s *StoragePoolService) checkUnique(ctx context.Context, pool *entities.StoragePool) error {
+	if pool.RootPath() == valueobjects.DefaultStorageRoot {
+		return fmt.Errorf("%w: %s is the default backup root", valueobjects.ErrInvalidStoragePool, pool.RootPath())
+	}
+	pools, err := s.poolRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, other := range pools {
+		if other.ID() == pool.ID() {
+			continue
+		}
+		if other.Name() == pool.Name() {
+			return fmt.Errorf("%w: name %q is taken", valueobjects.ErrInvalidStoragePool, pool.Name())
+		}
+		if other.RootPath() == pool.RootPath() {
+			return fmt.Errorf("%w: root %s is used by pool %s", valueobjects.ErrInvalidStoragePool, pool.RootPath(), other.Name())
+		}
+	}
+	return nil
+}
+
+// usage counts the backups in and the hosts assigned to each pool.
+func (s *StoragePoolService) usage(ctx context.Context) (map[string]int, map[string]int, error) {
+	backups, err := s.backupRepo.FindAll(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	hosts, err := s.hostRepo.List(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	backupCounts := make(map[string]int)
+	for _, backup := range backups {
+		if backup.StoragePoolID() != "" {
+			backupCounts[backup.StoragePoolID()]++
+		}
+	}
+	hostCounts := make(map[string]int)
+	for _, host := range hosts {
+		if host.StoragePoolID() != "" {
+			hostCounts[host.StoragePoolID()]++
+		}
+	}
+	return backupCounts, hostCounts, nil
+}
+
+// poolUsage asks the worker how full a pool is, nil when it cannot tell.
+func (s *StoragePoolService) poolUsage(ctx context.Context, pool *entities.StoragePool) *dto.StoragePoolUsage {
+	if s.queryBus == nil {
+		return nil
+	}
+	usage, err := s.queryBus.DiskUsage(ctx, pool.RootPath())
+	if err != nil {
+		log.Printf("Failed to get disk usage of storage pool %s: %v", pool.Name(), err)
+		return nil
+	}
+	return &dto.StoragePoolUsage{
+		Total:     usage.Total,
+		Free:      usage.Free,
+		Used:      usage.Used,
+		Available: pool.Available(usage.Used, usage.Free),
+	}
+}
+
+func toStoragePoolResponse(pool *entities.StoragePool, backups, hosts map[string]int) *dto.StoragePoolResponse {
+	labels := pool.Labels()
+	if labels == nil {
+		labels = []string{}
+	}
+	return &dto.StoragePoolResponse{
+		ID:        pool.ID().String(),
+		Name:      pool.Name(),
+		RootPath:  pool.RootPath(),
+		Capacity:  pool.Capacity(),
+		Labels:    labels,
+		Backups:   backups[pool.ID().String()],
+		Hosts:     hosts[pool.ID().String()],
+		CreatedAt: pool.CreatedAt(),
+		UpdatedAt: pool.UpdatedAt(),
+	}
+}
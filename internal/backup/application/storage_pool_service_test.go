@@ -0,0 +1,191 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/backup/infrastructure/persistence/memory"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type storagePoolFixture struct {
+	service    *StoragePoolService
+	backupRepo *memory.BackupRepositoryMemory
+	hostRepo   *memory.HostRepositoryMemory
+	publisher  *MockTaskPublisher
+	queryBus   *MockWorkerQueryBus
+	host       *entities.Host
+	backup     *entities.Backup
+}
+
+func newStoragePoolFixture(t *testing.T) *storagePoolFixture {
+	t.Helper()
+	backupRepo := memory.NewBackupRepositoryMemoryEmpty()
+	hostRepo := memory.NewHostRepositoryMemory()
+	publisher := new(MockTaskPublisher)
+	queryBus := new(MockWorkerQueryBus)
+
+	host := entities.NewHost("prod", "prod.local", "root", 22, "prod", false)
+	require.NoError(t, hostRepo.Save(context.Background(), host))
+	backup, err := entities.NewBackup(host.ID(), "/srv/app", "app", entities.NewBackupSchedule("@daily"), nil, true, 5, false)
+	require.NoError(t, err)
+	require.NoError(t, backupRepo.Save(context.Background(), backup))
+
+	service := NewStoragePoolService(memory.NewStoragePoolRepositoryMemory(), backupRepo, hostRepo, publisher, queryBus)
+	return &storagePoolFixture{service: service, backupRepo: backupRepo, hostRepo: hostRepo, publisher: publisher, queryBus: queryBus, host: host, backup: backup}
+}
+
+func (f *storagePoolFixture) createPool(t *testing.T, name string, capacity int64, labels []string, used, free int64) string {
+	t.Helper()
+	root := "/mnt/" + name
+	pool, err := f.service.CreatePool(context.Background(), dto.StoragePoolRequest{Name: name, RootPath: root, Capacity: capacity, Labels: labels})
+	require.NoError(t, err)
+	f.queryBus.On("DiskUsage", mock.Anything, root).Return(workerDto.DiskUsageResult{Total: used + free, Used: used, Free: free}, nil)
+	return pool.ID
+}
+
+func TestStoragePoolService_PlacesByFreeSpace(t *testing.T) {
+	f := newStoragePoolFixture(t)
+	ctx := context.Background()
+	small := f.createPool(t, "ssd-small", 0, []string{"ssd"}, 900, 100)
+	capped := f.createPool(t, "ssd-capped", 1000, []string{"ssd"}, 950, 5000)
+	archive := f.createPool(t, "archive", 0, []string{"hdd", "archive"}, 100, 9000)
+
+	// The capped pool has the most free space on its filesystem but only
+	// 50 bytes left within its capacity.
+	poolID, err := f.service.Place(ctx, "", "", []string{"ssd"})
+	require.NoError(t, err)
+	assert.Equal(t, small, poolID)
+
+	poolID, err = f.service.Place(ctx, "", "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, archive, poolID)
+
+	poolID, err = f.service.Place(ctx, capped, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, capped, poolID, "the pool of the host comes first")
+
+	poolID, err = f.service.Place(ctx, capped, "", []string{"archive"})
+	require.NoError(t, err)
+	assert.Equal(t, archive, poolID, "the pool of the host must carry the labels")
+
+	poolID, err = f.service.Place(ctx, "", small, []string{"archive"})
+	require.NoError(t, err)
+	assert.Equal(t, small, poolID, "an explicit pool wins")
+
+	_, err = f.service.Place(ctx, "", "", []string{"tape"})
+	assert.True(t, errors.Is(err, valueobjects.ErrInvalidStoragePool))
+}
+
+func TestStoragePoolService_PlacesInDefaultRootWithoutPools(t *testing.T) {
+	f := newStoragePoolFixture(t)
+
+	poolID, err := f.service.Place(context.Background(), "", "", nil)
+	require.NoError(t, err)
+	assert.Empty(t, poolID)
+
+	path, err := f.service.BackupPath(context.Background(), f.backup, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, "/mnt/backups/prod/app", path)
+}
+
+func TestStoragePoolService_RejectsChangesToPoolsInUse(t *testing.T) {
+	f := newStoragePoolFixture(t)
+	ctx := context.Background()
+	poolID := f.createPool(t, "fast", 0, nil, 0, 100)
+	f.backup.SetStoragePool(poolID)
+
+	err := f.service.DeletePool(ctx, poolID)
+	assert.True(t, errors.Is(err, valueobjects.ErrStoragePoolInUse))
+
+	_, err = f.service.UpdatePool(ctx, poolID, dto.StoragePoolRequest{Name: "fast", RootPath: "/mnt/elsewhere"})
+	assert.True(t, errors.Is(err, valueobjects.ErrStoragePoolInUse))
+
+	pool, err := f.service.UpdatePool(ctx, poolID, dto.StoragePoolRequest{Name: "faster", RootPath: "/mnt/fast", Labels: []string{"ssd"}})
+	require.NoError(t, err)
+	assert.Equal(t, "faster", pool.Name)
+	assert.Equal(t, 1, pool.Backups)
+
+	_, err = f.service.CreatePool(ctx, dto.StoragePoolRequest{Name: "other", RootPath: "/mnt/fast/"})
+	assert.True(t, errors.Is(err, valueobjects.ErrInvalidStoragePool), "roots are unique")
+
+	f.backup.SetStoragePool("")
+	_, err = f.service.AssignHost(ctx, f.host.ID().String(), dto.AssignStoragePoolRequest{StoragePoolID: poolID})
+	require.NoError(t, err)
+	err = f.service.DeletePool(ctx, poolID)
+	assert.True(t, errors.Is(err, valueobjects.ErrStoragePoolInUse), "hosts keep their pool")
+
+	_, err = f.service.AssignHost(ctx, f.host.ID().String(), dto.AssignStoragePoolRequest{})
+	require.NoError(t, err)
+	assert.NoError(t, f.service.DeletePool(ctx, poolID))
+}
+
+func TestStoragePoolService_MigratesBackup(t *testing.T) {
+	f := newStoragePoolFixture(t)
+	ctx := context.Background()
+	poolID := f.createPool(t, "archive", 0, nil, 0, 100)
+
+	f.publisher.On("PublishMigrateStorageTask", mock.Anything, f.backup, "/mnt/archive", poolID).Return("task-1", nil).Once()
+	resp, err := f.service.Migrate(ctx, f.backup.ID().String(), dto.AssignStoragePoolRequest{StoragePoolID: poolID})
+	require.NoError(t, err)
+	assert.Equal(t, "task-1", resp.TaskID)
+	assert.Empty(t, f.backup.StoragePoolID(), "the backup moves once the worker is done")
+	assert.True(t, f.backup.Migrating())
+
+	_, err = f.service.Migrate(ctx, f.backup.ID().String(), dto.AssignStoragePoolRequest{StoragePoolID: poolID})
+	assert.True(t, errors.Is(err, valueobjects.ErrBackupMigrating))
+
+	f.publisher.On("PublishCatalogTask", mock.Anything, f.backup, "").Return("catalog-1", nil).Once()
+	_, err = f.service.RecordMigration(ctx, workerDto.MigrateStorageResult{BackupID: f.backup.ID().String(), StoragePoolID: poolID})
+	require.NoError(t, err)
+	assert.Equal(t, poolID, f.backup.StoragePoolID())
+	assert.False(t, f.backup.Migrating())
+
+	path, err := f.service.BackupPath(ctx, f.backup, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, "/mnt/archive/prod/app", path)
+
+	_, err = f.service.Migrate(ctx, f.backup.ID().String(), dto.AssignStoragePoolRequest{StoragePoolID: poolID})
+	assert.True(t, errors.Is(err, valueobjects.ErrSameStoragePool))
+
+	require.NoError(t, f.backup.Start())
+	_, err = f.service.Migrate(ctx, f.backup.ID().String(), dto.AssignStoragePoolRequest{})
+	assert.True(t, errors.Is(err, valueobjects.ErrBackupRunning))
+
+	f.publisher.AssertExpectations(t)
+}
+
+func TestStoragePoolService_MigrationFailureAndTargetInUse(t *testing.T) {
+	f := newStoragePoolFixture(t)
+	ctx := context.Background()
+	poolID := f.createPool(t, "archive", 0, nil, 0, 100)
+
+	f.publisher.On("PublishMigrateStorageTask", mock.Anything, f.backup, "/mnt/archive", poolID).Return("", errors.New("redis down")).Once()
+	_, err := f.service.Migrate(ctx, f.backup.ID().String(), dto.AssignStoragePoolRequest{StoragePoolID: poolID})
+	require.Error(t, err)
+	assert.False(t, f.backup.Migrating(), "nothing is moving when the task was never sent")
+
+	f.publisher.On("PublishMigrateStorageTask", mock.Anything, f.backup, "/mnt/archive", poolID).Return("task-1", nil).Once()
+	_, err = f.service.Migrate(ctx, f.backup.ID().String(), dto.AssignStoragePoolRequest{StoragePoolID: poolID})
+	require.NoError(t, err)
+	require.NoError(t, f.service.RecordFailedMigration(ctx, workerDto.MigrateStorageResult{BackupID: f.backup.ID().String(), StoragePoolID: poolID}))
+	assert.False(t, f.backup.Migrating())
+	assert.Empty(t, f.backup.StoragePoolID())
+
+	// Another backup of the host keeps its data where this one would go.
+	other, err := entities.NewBackup(f.host.ID(), "/srv/app", "app", entities.NewBackupSchedule("@daily"), nil, true, 5, false)
+	require.NoError(t, err)
+	other.SetStoragePool(poolID)
+	require.NoError(t, f.backupRepo.Save(ctx, other))
+	_, err = f.service.Migrate(ctx, f.backup.ID().String(), dto.AssignStoragePoolRequest{StoragePoolID: poolID})
+	assert.True(t, errors.Is(err, valueobjects.ErrMigrationTarget))
+
+	f.publisher.AssertExpectations(t)
+}
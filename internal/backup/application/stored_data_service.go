@@ -0,0 +1,390 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/events"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/interfaces"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+)
+
+// StoredDataService deletes the data backups keep on the worker along with
+// the backups, and finds the data under the storage roots no backup
+// references any more, to reclaim it or hand it back to a backup.
+type StoredDataService struct {
+	backupRepo  interfaces.BackupRepository
+	hostRepo    interfaces.HostRepository
+	publisher   interfaces.TaskPublisher
+	queryBus    interfaces.WorkerQueryBus
+	pools       *StoragePoolService
+	tiering     *TieringService
+	lifecycle   *BackupLifecycleService
+	hostService *HostService
+	events      interfaces.EventPublisher
+}
+
+// NewStoredDataService creates the service. tiering may be nil when no
+// snapshots are archived in cold storage pools, and events when nobody is
+// told about orphaned data.
+func NewStoredDataService(
+	backupRepo interfaces.BackupRepository,
+	hostRepo interfaces.HostRepository,
+	publisher interfaces.TaskPublisher,
+	queryBus interfaces.WorkerQueryBus,
+	pools *StoragePoolService,
+	tiering *TieringService,
+	lifecycle *BackupLifecycleService,
+	hostService *HostService,
+	events interfaces.EventPublisher,
+) *StoredDataService {
+	return &StoredDataService{
+		backupRepo:  backupRepo,
+		hostRepo:    hostRepo,
+		publisher:   publisher,
+		queryBus:    queryBus,
+		pools:       pools,
+		tiering:     tiering,
+		lifecycle:   lifecycle,
+		hostService: hostService,
+		events:      events,
+	}
+}
+
+// storedData is where the data of a backup lies on the worker.
+type storedData struct {
+	backupID string
+	root     string
+	dir      string
+	cold     []valueobjects.ColdLocation
+}
+
+func (s *StoredDataService) storedData(ctx context.Context, backup *entities.Backup, host *entities.Host) (storedData, error) {
+	root, err := s.pools.BackupRoot(ctx, backup)
+	if err != nil {
+		return storedData{}, err
+	}
+	data := storedData{
+		backupID: backup.ID().String(),
+		root:     root,
+		dir:      path.Clean(backupRootPath(root, host.Path(), backup.Destination())),
+	}
+	if s.tiering != nil {
+		if data.cold, err = s.tiering.ColdArchives(ctx, backup); err != nil {
+			return storedData{}, err
+		}
+	}
+	return data, nil
+}
+
+// DeleteBackup deletes a backup as BackupLifecycleService.DeleteBackup does
+// and then asks a worker to remove its snapshots, on its storage and in cold
+// storage pools. Where they lie is worked out before the backup is gone.
+func (s *StoredDataService) DeleteBackup(ctx context.Context, id string) (*dto.DeleteDataResponse, error) {
+	bid, err := valueobjects.NewBackupIDFromString(id)
+	if err != nil {
+		return nil, err
+	}
+	backup, err := s.backupRepo.FindByID(ctx, bid)
+	if err != nil {
+		return nil, err
+	}
+	host, err := s.hostRepo.Get(ctx, backup.HostID())
+	if err != nil {
+		return nil, err
+	}
+
+	_, refs, err := s.references(ctx, map[valueobjects.BackupID]bool{backup.ID(): true})
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.deletableData(ctx, backup, host, refs)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.lifecycle.DeleteBackup(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.deleteData(ctx, []storedData{data})
+}
+
+// DeleteHost deletes a host as HostService.DeleteHost does and then asks a
+// worker to remove the data of each of its backups.
+func (s *StoredDataService) DeleteHost(ctx context.Context, id string) (*dto.DeleteDataResponse, error) {
+	hostID, err := entities.NewHostIDFromString(id)
+	if err != nil {
+		return nil, err
+	}
+	host, err := s.hostRepo.Get(ctx, hostID)
+	if err != nil {
+		return nil, err
+	}
+	backups, err := s.backupRepo.FindByHostID(ctx, hostID)
+	if err != nil {
+		return nil, err
+	}
+
+	skip := make(map[valueobjects.BackupID]bool, len(backups))
+	for _, backup := range backups {
+		skip[backup.ID()] = true
+	}
+	_, refs, err := s.references(ctx, skip)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]storedData, 0, len(backups))
+	for _, backup := range backups {
+		data, err := s.deletableData(ctx, backup, host, refs)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, data)
+	}
+	if err := s.hostService.DeleteHost(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.deleteData(ctx, targets)
+}
+
+// deletableData returns where the data of a backup lies, refusing a backup
+// that is writing to it and a directory that is not the backup's own: one
+// that is or holds the directory of its host, or that overlaps the data of
+// another backup in refs.
+func (s *StoredDataService) deletableData(ctx context.Context, backup *entities.Backup, host *entities.Host, refs []string) (storedData, error) {
+	if backup.Status() == valueobjects.BackupStatusRunning {
+		return storedData{}, fmt.Errorf("%w: wait for backup %s to finish before deleting its data", valueobjects.ErrBackupRunning, backup.ID())
+	}
+	if backup.Migrating() {
+		return storedData{}, fmt.Errorf("%w: wait for the data of backup %s to be moved before deleting it", valueobjects.ErrBackupMigrating, backup.ID())
+	}
+	data, err := s.storedData(ctx, backup, host)
+	if err != nil {
+		return storedData{}, err
+	}
+
+	hostDir := path.Clean(backupRootPath(data.root, host.Path(), ""))
+	if !pathWithin(hostDir, data.dir) {
+		return storedData{}, fmt.Errorf("%w: %s of backup %s is not a directory below %s", valueobjects.ErrSharedData, data.dir, backup.ID(), hostDir)
+	}
+	if ref, ok := overlapping(data.dir, refs); ok {
+		return storedData{}, fmt.Errorf("%w: %s of backup %s overlaps %s of another backup", valueobjects.ErrSharedData, data.dir, backup.ID(), ref)
+	}
+	return data, nil
+}
+
+func (s *StoredDataService) deleteData(ctx context.Context, targets []storedData) (*dto.DeleteDataResponse, error) {
+	resp := &dto.DeleteDataResponse{TaskIDs: []string{}}
+	for _, data := range targets {
+		taskID, err := s.publisher.PublishDeleteDataTask(ctx, data.backupID, data.root, data.dir, data.cold)
+		if err != nil {
+			// The backup is gone already; its data shows up as orphaned.
+			return nil, fmt.Errorf("failed to delete the data of backup %s, reclaim it as orphaned data: %w", data.backupID, err)
+		}
+		resp.TaskIDs = append(resp.TaskIDs, taskID)
+	}
+	return resp, nil
+}
+
+// references returns the storage roots and the directories backups keep
+// their data in under them, on their storage and in cold storage pools,
+// leaving out the backups in skip.
+func (s *StoredDataService) references(ctx context.Context, skip map[valueobjects.BackupID]bool) ([]string, []string, error) {
+	roots, err := s.pools.Roots(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	backups, err := s.backupRepo.FindAll(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hosts := make(map[entities.HostID]*entities.Host)
+	refs := make([]string, 0, len(backups))
+	for _, backup := range backups {
+		if skip[backup.ID()] {
+			continue
+		}
+		host, ok := hosts[backup.HostID()]
+		if !ok {
+			if host, err = s.hostRepo.Get(ctx, backup.HostID()); err != nil {
+				return nil, nil, err
+			}
+			hosts[backup.HostID()] = host
+		}
+		data, err := s.storedData(ctx, backup, host)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to locate the data of backup %s: %w", backup.ID(), err)
+		}
+		refs = append(refs, data.dir)
+		for _, c := range data.cold {
+			refs = append(refs, path.Dir(c.Archive))
+		}
+	}
+	return roots, refs, nil
+}
+
+// Scan asks a worker for what lies under the storage roots that no backup
+// references.
+func (s *StoredDataService) Scan(ctx context.Context) (*dto.OrphanedDataResponse, error) {
+	roots, refs, err := s.references(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	scan, err := s.queryBus.ScanOrphans(ctx, roots, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.OrphanedDataResponse{Orphans: make([]dto.OrphanResponse, 0, len(scan.Orphans)), Missing: scan.Missing}
+	for _, o := range scan.Orphans {
+		resp.Orphans = append(resp.Orphans, dto.OrphanResponse{Root: o.Root, Path: o.Path, IsDir: o.IsDir, Size: o.Size, ModTime: o.ModTime})
+		resp.TotalSize += o.Size
+	}
+	return resp, nil
+}
+
+// CheckOrphans scans for orphaned data and raises an OrphanedDataFound event
+// when there is any. It backs the orphan scan maintenance task.
+func (s *StoredDataService) CheckOrphans(ctx context.Context) error {
+	resp, err := s.Scan(ctx)
+	if err != nil {
+		return err
+	}
+	if len(resp.Orphans) == 0 {
+		return nil
+	}
+
+	var roots []string
+	seen := make(map[string]bool)
+	for _, o := range resp.Orphans {
+		if !seen[o.Root] {
+			seen[o.Root] = true
+			roots = append(roots, o.Root)
+		}
+	}
+	log.Printf("Found %d orphaned entries taking %s under %s", len(resp.Orphans), shared.FormatSize(resp.TotalSize), strings.Join(roots, ", "))
+	if s.events == nil {
+		return nil
+	}
+	if err := s.events.Publish(ctx, events.NewOrphanedDataFound(len(resp.Orphans), resp.TotalSize, roots)); err != nil {
+		log.Printf("Failed to publish OrphanedDataFound event: %v", err)
+	}
+	return nil
+}
+
+// Reclaim asks a worker to delete an orphaned entry for good. The path is
+// checked against the backups as they are now rather than when it was
+// scanned.
+func (s *StoredDataService) Reclaim(ctx context.Context, req dto.ReclaimOrphanRequest) (*dto.ReclaimOrphanResponse, error) {
+	root, err := s.checkOrphaned(ctx, req.Path)
+	if err != nil {
+		return nil, err
+	}
+	taskID, err := s.publisher.PublishDeleteDataTask(ctx, "", root, req.Path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.ReclaimOrphanResponse{TaskID: taskID}, nil
+}
+
+// Adopt points a backup at an orphaned entry on the storage of its host,
+// such as the directory it kept its data in before its destination changed.
+// The data the backup keeps now is orphaned in turn.
+func (s *StoredDataService) Adopt(ctx context.Context, req dto.AdoptOrphanRequest) error {
+	bid, err := valueobjects.NewBackupIDFromString(req.BackupID)
+	if err != nil {
+		return err
+	}
+	backup, err := s.backupRepo.FindByID(ctx, bid)
+	if err != nil {
+		return err
+	}
+	host, err := s.hostRepo.Get(ctx, backup.HostID())
+	if err != nil {
+		return err
+	}
+	if _, err := s.checkOrphaned(ctx, req.Path); err != nil {
+		return err
+	}
+
+	root, err := s.pools.BackupRoot(ctx, backup)
+	if err != nil {
+		return err
+	}
+	hostDir := path.Clean(backupRootPath(root, host.Path(), ""))
+	if !pathWithin(hostDir, req.Path) {
+		return fmt.Errorf("%w: %s is not under %s, where the backups of host %s are kept", valueobjects.ErrInvalidAdoption, req.Path, hostDir, host.Name())
+	}
+
+	destination := strings.TrimPrefix(req.Path, hostDir+"/")
+	if strings.HasSuffix(destination, valueobjects.EncryptedSnapshotSuffix) {
+		if !backup.Encrypted() || backup.Incremental() {
+			return fmt.Errorf("%w: %s is the archive of an encrypted mirror", valueobjects.ErrInvalidAdoption, req.Path)
+		}
+		destination = strings.TrimSuffix(destination, valueobjects.EncryptedSnapshotSuffix)
+	} else if strings.Contains(path.Base(destination), valueobjects.EncryptedSnapshotSuffix) {
+		return fmt.Errorf("%w: %s belongs to the archive of an encrypted mirror", valueobjects.ErrInvalidAdoption, req.Path)
+	}
+
+	if err := backup.AdoptDestination(destination); err != nil {
+		return err
+	}
+	return s.backupRepo.Save(ctx, backup)
+}
+
+// checkOrphaned makes sure no backup keeps its data in, under or above p,
+// which must lie under a storage root, and returns that root.
+func (s *StoredDataService) checkOrphaned(ctx context.Context, p string) (string, error) {
+	if !path.IsAbs(p) || path.Clean(p) != p {
+		return "", fmt.Errorf("%w: %q is not a clean absolute path", valueobjects.ErrNotOrphaned, p)
+	}
+	roots, refs, err := s.references(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+
+	root := ""
+	for _, r := range roots {
+		r = path.Clean(r)
+		if p == r || pathWithin(p, r) {
+			return "", fmt.Errorf("%w: %s holds the storage root %s", valueobjects.ErrNotOrphaned, p, r)
+		}
+		if pathWithin(r, p) && len(r) > len(root) {
+			root = r
+		}
+	}
+	if root == "" {
+		return "", fmt.Errorf("%w: %s is outside every storage root", valueobjects.ErrNotOrphaned, p)
+	}
+	if state := path.Join(root, valueobjects.WorkerStateDir); p == state || pathWithin(state, p) {
+		return "", fmt.Errorf("%w: the worker keeps its state in %s", valueobjects.ErrNotOrphaned, state)
+	}
+
+	if ref, ok := overlapping(p, refs); ok {
+		return "", fmt.Errorf("%w: a backup keeps its data in %s", valueobjects.ErrNotOrphaned, ref)
+	}
+	return root, nil
+}
+
+// overlapping returns the directory among refs that p is, holds, lies in or
+// is the encrypted archive of.
+func overlapping(p string, refs []string) (string, bool) {
+	for _, ref := range refs {
+		if p == ref || pathWithin(p, ref) || pathWithin(ref, p) || strings.HasPrefix(p, ref+valueobjects.EncryptedSnapshotSuffix) {
+			return ref, true
+		}
+	}
+	return "", false
+}
+
+// pathWithin reports whether p lies strictly below dir.
+func pathWithin(dir string, p string) bool {
+	return strings.HasPrefix(p, strings.TrimSuffix(dir, "/")+"/")
+}
@@ -0,0 +1,144 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application/assembler"
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/events"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/backup/infrastructure/persistence/memory"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newStoredDataFixture(t *testing.T) (*storagePoolFixture, *StoredDataService, *recordingEventPublisher) {
+	t.Helper()
+	f := newStoragePoolFixture(t)
+	hostService := NewHostService(f.hostRepo, f.backupRepo)
+	lifecycle := NewBackupLifecycleService(f.backupRepo, memory.NewFileCatalogRepositoryMemory(), hostService, f.publisher, assembler.NewBackupAssembler(), f.service)
+	publisher := &recordingEventPublisher{}
+	service := NewStoredDataService(f.backupRepo, f.hostRepo, f.publisher, f.queryBus, f.service, nil, lifecycle, hostService, publisher)
+	return f, service, publisher
+}
+
+func TestStoredDataService_DeleteBackupWithData(t *testing.T) {
+	f, service, _ := newStoredDataFixture(t)
+	ctx := context.Background()
+	backupID := f.backup.ID().String()
+
+	require.NoError(t, f.backup.Start())
+	_, err := service.DeleteBackup(ctx, backupID)
+	assert.ErrorIs(t, err, valueobjects.ErrBackupRunning)
+	require.NoError(t, f.backup.Complete())
+
+	f.publisher.On("PublishDeleteDataTask", ctx, backupID, "/mnt/backups", "/mnt/backups/prod/app", []valueobjects.ColdLocation(nil)).Return("task-1", nil).Once()
+	resp, err := service.DeleteBackup(ctx, backupID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"task-1"}, resp.TaskIDs)
+	f.publisher.AssertExpectations(t)
+
+	_, err = f.backupRepo.FindByID(ctx, f.backup.ID())
+	assert.Error(t, err, "the backup is gone")
+}
+
+func TestStoredDataService_DeleteRefusesSharedData(t *testing.T) {
+	f, service, _ := newStoredDataFixture(t)
+	ctx := context.Background()
+
+	// A backup whose destination lies inside the data of another one.
+	nested, err := entities.NewBackup(f.host.ID(), "/srv/app/logs", "app/logs", entities.NewBackupSchedule("@daily"), nil, true, 5, false)
+	require.NoError(t, err)
+	require.NoError(t, f.backupRepo.Save(ctx, nested))
+
+	_, err = service.DeleteBackup(ctx, nested.ID().String())
+	assert.ErrorIs(t, err, valueobjects.ErrSharedData)
+	_, err = service.DeleteBackup(ctx, f.backup.ID().String())
+	assert.ErrorIs(t, err, valueobjects.ErrSharedData)
+
+	// Stored before destinations were checked, it keeps its data in the
+	// directory of the host.
+	legacy := entities.RestoreBackup(valueobjects.NewBackupID(), f.host.ID(), "/srv", "", valueobjects.BackupStatusCompleted, entities.NewBackupSchedule("@daily"), time.Now(), time.Now(), nil, nil, true, true, valueobjects.SpaceUsage{}, 5, false)
+	require.NoError(t, f.backupRepo.Save(ctx, legacy))
+	_, err = service.DeleteBackup(ctx, legacy.ID().String())
+	assert.ErrorIs(t, err, valueobjects.ErrSharedData)
+	_, err = service.DeleteHost(ctx, f.host.ID().String())
+	assert.ErrorIs(t, err, valueobjects.ErrSharedData)
+
+	f.publisher.AssertNotCalled(t, "PublishDeleteDataTask", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	_, err = f.backupRepo.FindByID(ctx, nested.ID())
+	assert.NoError(t, err, "nothing is deleted")
+}
+
+func TestStoredDataService_ScanAndCheck(t *testing.T) {
+	f, service, publisher := newStoredDataFixture(t)
+	ctx := context.Background()
+	f.createPool(t, "ssd", 0, nil, 0, 1000)
+
+	f.queryBus.On("ScanOrphans", mock.Anything, []string{"/mnt/backups", "/mnt/ssd"}, []string{"/mnt/backups/prod/app"}).Return(workerDto.OrphanScanResult{
+		Orphans: []workerDto.OrphanEntry{
+			{Root: "/mnt/backups", Path: "/mnt/backups/prod/old", IsDir: true, Size: 100},
+			{Root: "/mnt/ssd", Path: "/mnt/ssd/gone", IsDir: true, Size: 50},
+		},
+	}, nil)
+
+	resp, err := service.Scan(ctx)
+	require.NoError(t, err)
+	require.Len(t, resp.Orphans, 2)
+	assert.Equal(t, int64(150), resp.TotalSize)
+
+	require.NoError(t, service.CheckOrphans(ctx))
+	require.Len(t, publisher.events, 1)
+	event := publisher.events[0].(events.OrphanedDataFound)
+	assert.Equal(t, 2, event.Count)
+	assert.Equal(t, []string{"/mnt/backups", "/mnt/ssd"}, event.Roots)
+}
+
+func TestStoredDataService_ReclaimOnlyOrphans(t *testing.T) {
+	f, service, _ := newStoredDataFixture(t)
+	ctx := context.Background()
+
+	for _, p := range []string{
+		"/mnt/backups/prod/app",
+		"/mnt/backups/prod",
+		"/mnt/backups/prod/app/2024-01-01_00-00-00",
+		"/mnt/backups/prod/app" + valueobjects.EncryptedSnapshotSuffix,
+		"/mnt/backups",
+		"/mnt",
+		"/etc/passwd",
+		"relative/path",
+		"/mnt/backups/prod/../../etc",
+		"/mnt/backups/" + valueobjects.WorkerStateDir,
+		"/mnt/backups/" + valueobjects.WorkerStateDir + "/catalog/some-backup",
+	} {
+		_, err := service.Reclaim(ctx, dto.ReclaimOrphanRequest{Path: p})
+		assert.ErrorIs(t, err, valueobjects.ErrNotOrphaned, p)
+	}
+
+	f.publisher.On("PublishDeleteDataTask", ctx, "", "/mnt/backups", "/mnt/backups/prod/old", []valueobjects.ColdLocation(nil)).Return("task-1", nil).Once()
+	resp, err := service.Reclaim(ctx, dto.ReclaimOrphanRequest{Path: "/mnt/backups/prod/old"})
+	require.NoError(t, err)
+	assert.Equal(t, "task-1", resp.TaskID)
+	f.publisher.AssertExpectations(t)
+}
+
+func TestStoredDataService_Adopt(t *testing.T) {
+	f, service, _ := newStoredDataFixture(t)
+	ctx := context.Background()
+	backupID := f.backup.ID().String()
+
+	err := service.Adopt(ctx, dto.AdoptOrphanRequest{Path: "/mnt/backups/other/app", BackupID: backupID})
+	assert.ErrorIs(t, err, valueobjects.ErrInvalidAdoption, "another host")
+	err = service.Adopt(ctx, dto.AdoptOrphanRequest{Path: "/mnt/backups/prod/old" + valueobjects.EncryptedSnapshotSuffix, BackupID: backupID})
+	assert.ErrorIs(t, err, valueobjects.ErrInvalidAdoption, "not an encrypted mirror")
+
+	require.NoError(t, service.Adopt(ctx, dto.AdoptOrphanRequest{Path: "/mnt/backups/prod/old/app", BackupID: backupID}))
+	stored, err := f.backupRepo.FindByID(ctx, f.backup.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "old/app", stored.Destination())
+}
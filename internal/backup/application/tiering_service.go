@@ -0,0 +1,402 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/interfaces"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+)
+
+// TieringService moves the old snapshots of incremental backups off their
+// storage, following the tiering policy of each backup, and keeps track of
+// where they went so listings and restores still find them.
+type TieringService struct {
+	policyRepo   interfaces.TieringPolicyRepository
+	archivedRepo interfaces.ArchivedSnapshotRepository
+	backupRepo   interfaces.BackupRepository
+	hostRepo     interfaces.HostRepository
+	targetRepo   interfaces.ReplicaTargetRepository
+	publisher    interfaces.TaskPublisher
+	pools        *StoragePoolService
+	replicas     *ReplicaService
+}
+
+func NewTieringService(
+	policyRepo interfaces.TieringPolicyRepository,
+	archivedRepo interfaces.ArchivedSnapshotRepository,
+	backupRepo interfaces.BackupRepository,
+	hostRepo interfaces.HostRepository,
+	targetRepo interfaces.ReplicaTargetRepository,
+	publisher interfaces.TaskPublisher,
+	pools *StoragePoolService,
+	replicas *ReplicaService,
+) *TieringService {
+	return &TieringService{
+		policyRepo:   policyRepo,
+		archivedRepo: archivedRepo,
+		backupRepo:   backupRepo,
+		hostRepo:     hostRepo,
+		targetRepo:   targetRepo,
+		publisher:    publisher,
+		pools:        pools,
+		replicas:     replicas,
+	}
+}
+
+// GetTiering returns the tiering policy of a backup and the snapshots moved
+// off its storage.
+func (s *TieringService) GetTiering(ctx context.Context, backupID string) (*dto.TieringResponse, error) {
+	backup, err := s.findBackup(ctx, backupID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.TieringResponse{BackupID: backup.ID().String(), Archived: []dto.ArchivedSnapshotResponse{}}
+	policy, err := s.policyRepo.FindByBackupID(ctx, backup.ID())
+	if err != nil && !errors.Is(err, shared.ErrNotFound) {
+		return nil, err
+	}
+	if policy != nil {
+		resp.Policy = toTieringPolicyRequest(*policy)
+	}
+
+	archived, err := s.archived(ctx, backup)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range archived {
+		resp.Archived = append(resp.Archived, dto.ArchivedSnapshotResponse{
+			Snapshot:        a.Snapshot,
+			Tier:            string(a.Tier),
+			StoragePoolID:   a.StoragePoolID,
+			ReplicaTargetID: a.ReplicaTargetID,
+			Size:            a.Size,
+			Encrypted:       a.Encrypted,
+			ArchivedAt:      a.ArchivedAt,
+		})
+	}
+	return resp, nil
+}
+
+// SetPolicy sets the tiering policy of an incremental backup. Snapshots
+// already moved stay where they are.
+func (s *TieringService) SetPolicy(ctx context.Context, backupID string, req dto.TieringPolicyRequest) (*dto.TieringResponse, error) {
+	backup, err := s.findBackup(ctx, backupID)
+	if err != nil {
+		return nil, err
+	}
+	if !backup.Incremental() {
+		return nil, valueobjects.ErrNotIncremental
+	}
+
+	policy := valueobjects.TieringPolicy{
+		AfterDays:       req.AfterDays,
+		StoragePoolID:   req.StoragePoolID,
+		ReplicaTargetID: req.ReplicaTargetID,
+		Encrypt:         req.Encrypt,
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkTier(ctx, backup, policy); err != nil {
+		return nil, err
+	}
+
+	if err := s.policyRepo.Save(ctx, backup.ID(), policy); err != nil {
+		return nil, err
+	}
+	return s.GetTiering(ctx, backupID)
+}
+
+// DeletePolicy stops tiering a backup. Snapshots already moved stay where
+// they are and can still be restored.
+func (s *TieringService) DeletePolicy(ctx context.Context, backupID string) error {
+	bid, err := valueobjects.NewBackupIDFromString(backupID)
+	if err != nil {
+		return err
+	}
+	return s.policyRepo.Delete(ctx, bid)
+}
+
+// Tier moves the snapshots of a backup its policy says are due now, instead
+// of waiting for the scheduled run.
+func (s *TieringService) Tier(ctx context.Context, backupID string) (*dto.TierResponse, error) {
+	backup, err := s.findBackup(ctx, backupID)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := s.policyRepo.FindByBackupID(ctx, backup.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	taskID, err := s.tier(ctx, backup, *policy)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.TierResponse{TaskID: taskID}, nil
+}
+
+// TierAll moves the due snapshots of every backup with a tiering policy.
+// It runs as a maintenance task.
+func (s *TieringService) TierAll(ctx context.Context) error {
+	policies, err := s.policyRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	for backupID, policy := range policies {
+		backup, err := s.findBackup(ctx, backupID)
+		if err != nil {
+			log.Printf("Failed to load backup %s to tier: %v", backupID, err)
+			continue
+		}
+		log.Printf("Queueing tier task for backup: %s (after %d days)", backupID, policy.AfterDays)
+		if _, err := s.tier(ctx, backup, policy); err != nil {
+			log.Printf("Failed to publish tier task for backup %s: %v", backupID, err)
+		}
+	}
+	return nil
+}
+
+// tier publishes a tier task for a backup. A replica tier is only given the
+// snapshots its target holds, the only ones it may remove.
+func (s *TieringService) tier(ctx context.Context, backup *entities.Backup, policy valueobjects.TieringPolicy) (string, error) {
+	if err := s.checkTier(ctx, backup, policy); err != nil {
+		return "", err
+	}
+
+	coldRoot := ""
+	var replicated []string
+	if policy.Kind() == valueobjects.TierPool {
+		pool, err := s.pools.findPool(ctx, policy.StoragePoolID)
+		if err != nil {
+			return "", err
+		}
+		coldRoot = pool.RootPath()
+	} else {
+		byTarget, err := s.replicas.replicatedByTarget(ctx, backup.ID())
+		if err != nil {
+			return "", err
+		}
+		replicated = byTarget[uuid.MustParse(policy.ReplicaTargetID)]
+	}
+
+	return s.publisher.PublishTierTask(ctx, backup, policy, coldRoot, replicated)
+}
+
+// checkTier checks that the tier of a policy can take the snapshots of the
+// backup: an existing pool other than the one the backup is in, or an
+// enabled replica target covering the backup.
+func (s *TieringService) checkTier(ctx context.Context, backup *entities.Backup, policy valueobjects.TieringPolicy) error {
+	if policy.Kind() == valueobjects.TierPool {
+		if s.pools == nil {
+			return fmt.Errorf("%w: storage pools are not available", valueobjects.ErrInvalidTieringPolicy)
+		}
+		if policy.StoragePoolID == backup.StoragePoolID() {
+			return fmt.Errorf("%w: the storage pool is the one the backup is in", valueobjects.ErrInvalidTieringPolicy)
+		}
+		_, err := s.pools.findPool(ctx, policy.StoragePoolID)
+		return err
+	}
+
+	if s.replicas == nil {
+		return fmt.Errorf("%w: replica targets are not available", valueobjects.ErrInvalidTieringPolicy)
+	}
+	targetID, err := uuid.Parse(policy.ReplicaTargetID)
+	if err != nil {
+		return shared.ErrInvalidID
+	}
+	target, err := s.targetRepo.FindByID(ctx, targetID)
+	if err != nil {
+		return err
+	}
+	// Restores only fall back to enabled targets.
+	if !target.Enabled() || !target.Covers(backup.ID()) {
+		return fmt.Errorf("%w: the replica target must be enabled and cover the backup", valueobjects.ErrInvalidTieringPolicy)
+	}
+	return nil
+}
+
+// RecordResult records the snapshots a tier task moved, including those a
+// failed task finished.
+func (s *TieringService) RecordResult(ctx context.Context, result workerDto.TierResult) error {
+	backupID, err := valueobjects.NewBackupIDFromString(result.BackupID)
+	if err != nil {
+		return err
+	}
+
+	tier := valueobjects.TierPool
+	if result.ReplicaTargetID != "" {
+		tier = valueobjects.TierReplica
+	}
+	for _, a := range result.Archived {
+		err := s.archivedRepo.Save(ctx, &entities.ArchivedSnapshot{
+			BackupID:        backupID,
+			Snapshot:        a.Name,
+			Tier:            tier,
+			StoragePoolID:   result.StoragePoolID,
+			ReplicaTargetID: result.ReplicaTargetID,
+			Archive:         a.Archive,
+			Size:            a.Size,
+			Encrypted:       a.Encrypted,
+			ArchivedAt:      entities.NowFunc(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ForgetSnapshots forgets archived snapshots a purge removed.
+func (s *TieringService) ForgetSnapshots(ctx context.Context, backupID string, snapshots []string) error {
+	bid, err := valueobjects.NewBackupIDFromString(backupID)
+	if err != nil {
+		return err
+	}
+	for _, snapshot := range snapshots {
+		if err := s.archivedRepo.Delete(ctx, bid, snapshot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archived returns the snapshots moved off the storage of a backup that can
+// still be restored. Those on a replica target are left out once no enabled
+// target holds them.
+func (s *TieringService) archived(ctx context.Context, backup *entities.Backup) ([]*entities.ArchivedSnapshot, error) {
+	records, err := s.archivedRepo.FindByBackupID(ctx, backup.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	var replicas *replicaIndex
+	available := make([]*entities.ArchivedSnapshot, 0, len(records))
+	for _, a := range records {
+		if a.Tier == valueobjects.TierReplica {
+			if replicas == nil {
+				if s.replicas == nil {
+					continue
+				}
+				if replicas, err = s.replicas.replicaIndex(ctx, backup); err != nil {
+					return nil, err
+				}
+			}
+			if _, ok := replicas.endpoints[a.Snapshot]; !ok {
+				continue
+			}
+		}
+		available = append(available, a)
+	}
+	return available, nil
+}
+
+// ColdArchives returns where the archives of the snapshots of a backup in
+// cold storage pools lie on the worker. Archives in a pool that is gone are
+// left out.
+func (s *TieringService) ColdArchives(ctx context.Context, backup *entities.Backup) ([]valueobjects.ColdLocation, error) {
+	if s.pools == nil {
+		return nil, nil
+	}
+	records, err := s.archivedRepo.FindByBackupID(ctx, backup.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	var host *entities.Host
+	roots := make(map[string]string)
+	var locations []valueobjects.ColdLocation
+	for _, a := range records {
+		if a.Tier != valueobjects.TierPool {
+			continue
+		}
+		root, seen := roots[a.StoragePoolID]
+		if !seen {
+			pool, err := s.pools.findPool(ctx, a.StoragePoolID)
+			if err != nil {
+				log.Printf("Failed to find cold storage pool %s of backup %s: %v", a.StoragePoolID, backup.ID(), err)
+			} else {
+				root = pool.RootPath()
+			}
+			roots[a.StoragePoolID] = root
+		}
+		if root == "" {
+			continue
+		}
+		if host == nil {
+			if host, err = s.hostRepo.Get(ctx, backup.HostID()); err != nil {
+				return nil, err
+			}
+		}
+		locations = append(locations, valueobjects.ColdLocation{
+			Snapshot:  a.Snapshot,
+			Archive:   path.Join(backupRootPath(root, host.Path(), backup.Destination()), a.Archive),
+			Encrypted: a.Encrypted,
+		})
+	}
+	return locations, nil
+}
+
+// coldSource returns what a restore of the snapshot a selector picks among
+// those in cold storage pools reads. The archive of an encrypted backup is
+// read in place like the ones on its storage; anything else is unpacked by
+// the worker, which finds the source missing under root.
+func (s *TieringService) coldSource(ctx context.Context, backup *entities.Backup, root string, reqPath string, selector valueobjects.SnapshotSelector) (restoreSource, error) {
+	locations, err := s.ColdArchives(ctx, backup)
+	if err != nil {
+		return restoreSource{}, err
+	}
+	names := make([]string, 0, len(locations))
+	byName := make(map[string]valueobjects.ColdLocation, len(locations))
+	for _, loc := range locations {
+		names = append(names, loc.Snapshot)
+		byName[loc.Snapshot] = loc
+	}
+	name, err := selector.Resolve(names)
+	if err != nil {
+		return restoreSource{}, err
+	}
+
+	loc := byName[name]
+	subPath := strings.Trim(reqPath, "/")
+	if subPath == "." {
+		subPath = ""
+	}
+	if backup.Encrypted() && loc.Encrypted {
+		return restoreSource{Path: loc.Archive, ArchivePath: subPath}, nil
+	}
+	loc.Path = subPath
+	if loc.Path == "" {
+		loc.Path = "."
+	}
+	return restoreSource{Path: path.Join(root, name, subPath), Cold: &loc}, nil
+}
+
+func (s *TieringService) findBackup(ctx context.Context, backupID string) (*entities.Backup, error) {
+	bid, err := valueobjects.NewBackupIDFromString(backupID)
+	if err != nil {
+		return nil, err
+	}
+	return s.backupRepo.FindByID(ctx, bid)
+}
+
+func toTieringPolicyRequest(policy valueobjects.TieringPolicy) *dto.TieringPolicyRequest {
+	return &dto.TieringPolicyRequest{
+		AfterDays:       policy.AfterDays,
+		StoragePoolID:   policy.StoragePoolID,
+		ReplicaTargetID: policy.ReplicaTargetID,
+		Encrypt:         policy.Encrypt,
+	}
+}
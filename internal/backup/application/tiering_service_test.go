@@ -0,0 +1,116 @@
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/backup/infrastructure/persistence/memory"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTieringFixture(t *testing.T) (*storagePoolFixture, *TieringService, string) {
+	t.Helper()
+	f := newStoragePoolFixture(t)
+	cold := f.createPool(t, "cold", 0, []string{"hdd"}, 0, 1000)
+	tiering := NewTieringService(memory.NewTieringPolicyRepositoryMemory(), memory.NewArchivedSnapshotRepositoryMemory(), f.backupRepo, f.hostRepo, memory.NewReplicaTargetRepositoryMemory(), f.publisher, f.service, nil)
+	return f, tiering, cold
+}
+
+func TestTieringService_SetPolicy(t *testing.T) {
+	f, tiering, cold := newTieringFixture(t)
+	ctx := context.Background()
+	backupID := f.backup.ID().String()
+
+	_, err := tiering.SetPolicy(ctx, backupID, dto.TieringPolicyRequest{AfterDays: 0, StoragePoolID: cold})
+	assert.ErrorIs(t, err, valueobjects.ErrInvalidTieringPolicy)
+
+	_, err = tiering.SetPolicy(ctx, backupID, dto.TieringPolicyRequest{AfterDays: 30, ReplicaTargetID: "f3b1c7c2-5c39-4a47-9f60-7c3f0a3e4d11"})
+	assert.ErrorIs(t, err, valueobjects.ErrInvalidTieringPolicy, "replica targets are not available")
+
+	resp, err := tiering.SetPolicy(ctx, backupID, dto.TieringPolicyRequest{AfterDays: 30, StoragePoolID: cold, Encrypt: true})
+	require.NoError(t, err)
+	assert.Equal(t, &dto.TieringPolicyRequest{AfterDays: 30, StoragePoolID: cold, Encrypt: true}, resp.Policy)
+	assert.Empty(t, resp.Archived)
+
+	f.backup.SetStoragePool(cold)
+	require.NoError(t, f.backupRepo.Save(ctx, f.backup))
+	_, err = tiering.SetPolicy(ctx, backupID, dto.TieringPolicyRequest{AfterDays: 30, StoragePoolID: cold})
+	assert.ErrorIs(t, err, valueobjects.ErrInvalidTieringPolicy, "the backup is already in the pool")
+}
+
+func TestTieringService_ArchivedSnapshotsAreListedAndRestored(t *testing.T) {
+	f, tiering, cold := newTieringFixture(t)
+	ctx := context.Background()
+	backupID := f.backup.ID().String()
+
+	_, err := tiering.SetPolicy(ctx, backupID, dto.TieringPolicyRequest{AfterDays: 30, StoragePoolID: cold})
+	require.NoError(t, err)
+
+	policy := valueobjects.TieringPolicy{AfterDays: 30, StoragePoolID: cold}
+	f.publisher.On("PublishTierTask", ctx, mock.Anything, policy, "/mnt/cold", []string(nil)).Return("task-1", nil).Once()
+	tierResp, err := tiering.Tier(ctx, backupID)
+	require.NoError(t, err)
+	assert.Equal(t, "task-1", tierResp.TaskID)
+
+	require.NoError(t, tiering.RecordResult(ctx, workerDto.TierResult{
+		BackupID:      backupID,
+		StoragePoolID: cold,
+		Archived:      []workerDto.ArchivedSnapshot{{Name: "2024-01-01_00-00-00", Archive: "2024-01-01_00-00-00.tar.gz", Size: 2048}},
+	}))
+
+	root := "/mnt/backups/prod/app"
+	f.queryBus.On("ListSnapshots", mock.Anything, root).Return(workerDto.ListSnapshotsResult{Snapshots: []workerDto.SnapshotInfo{
+		{Name: "2024-03-01_00-00-00", Latest: true},
+	}}, nil)
+	snapshots, err := NewBackupSnapshotService(f.backupRepo, NewHostService(f.hostRepo, f.backupRepo), memory.NewSnapshotPinRepositoryMemory(), f.queryBus, f.service, tiering).ListSnapshots(ctx, backupID)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, "2024-01-01_00-00-00", snapshots[0].Name)
+	assert.True(t, snapshots[0].Archived)
+	assert.Equal(t, "pool", snapshots[0].Tier)
+	assert.Equal(t, int64(2048), snapshots[0].Size)
+	assert.False(t, snapshots[1].Archived)
+
+	f.queryBus.On("ListFiles", mock.Anything, root).Return(workerDto.ListFilesResult{Files: []workerDto.FileListItem{
+		{Name: "2024-03-01_00-00-00", IsDir: true},
+	}}, nil)
+	expectedCold := &valueobjects.ColdLocation{Snapshot: "2024-01-01_00-00-00", Archive: "/mnt/cold/prod/app/2024-01-01_00-00-00.tar.gz", Path: "etc/nginx"}
+	f.publisher.On("PublishRestoreTask", ctx, mock.Anything, root+"/2024-01-01_00-00-00/etc/nginx", "", mock.Anything, expectedCold, "127.0.0.1:8080", "token", "").Return("task-2", nil).Once()
+
+	restore := NewBackupRestoreService(f.backupRepo, NewHostService(f.hostRepo, f.backupRepo), f.publisher, f.queryBus, nil, nil, f.service, tiering)
+	taskID, err := restore.Restore(ctx, dto.RestoreRequest{
+		BackupID:     backupID,
+		Path:         "/etc/nginx",
+		Snapshot:     "2024-01-01_00-00-00",
+		RestoreType:  "local",
+		RestoreAddr:  "127.0.0.1:8080",
+		RestoreToken: "token",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "task-2", taskID)
+
+	archives, err := tiering.ColdArchives(ctx, f.backup)
+	require.NoError(t, err)
+	assert.Len(t, archives, 1)
+	require.NoError(t, tiering.ForgetSnapshots(ctx, backupID, []string{"2024-01-01_00-00-00"}))
+	archives, err = tiering.ColdArchives(ctx, f.backup)
+	require.NoError(t, err)
+	assert.Empty(t, archives)
+	f.publisher.AssertExpectations(t)
+}
+
+func TestTieringService_RejectsMirrors(t *testing.T) {
+	f, tiering, cold := newTieringFixture(t)
+	mirror, err := entities.NewBackup(f.host.ID(), "/srv/www", "www", entities.NewBackupSchedule("@daily"), nil, false, 0, false)
+	require.NoError(t, err)
+	require.NoError(t, f.backupRepo.Save(context.Background(), mirror))
+
+	_, err = tiering.SetPolicy(context.Background(), mirror.ID().String(), dto.TieringPolicyRequest{AfterDays: 30, StoragePoolID: cold})
+	assert.ErrorIs(t, err, valueobjects.ErrNotIncremental)
+}
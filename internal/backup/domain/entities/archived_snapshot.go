@@ -0,0 +1,23 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+)
+
+// ArchivedSnapshot records a snapshot a tiering policy moved off the storage
+// of its backup. A snapshot in a cold storage pool is the Archive file in the
+// directory of the backup under the root of StoragePoolID; one on a replica
+// target is found through the replicated snapshots of ReplicaTargetID.
+type ArchivedSnapshot struct {
+	BackupID        valueobjects.BackupID
+	Snapshot        string
+	Tier            valueobjects.TierKind
+	StoragePoolID   string
+	ReplicaTargetID string
+	Archive         string
+	Size            int64
+	Encrypted       bool
+	ArchivedAt      time.Time
+}
@@ -15,25 +15,33 @@ var NowFunc = time.Now
 
 type Backup struct {
 	shared.AggregateRoot
-	id          valueobjects.BackupID
-	hostID      HostID
-	path        string
-	destination string
-	status      valueobjects.BackupStatus
-	schedule    BackupSchedule
-	createdAt   time.Time
-	updatedAt   time.Time
-	nextRunAt   *time.Time
-	excludes    []string
-	enabled     bool
-	incremental bool
-	size        string
-	retention   int
-	encrypted   bool
-	hooks       []*BackupHook
+	id           valueobjects.BackupID
+	hostID       HostID
+	path         string
+	destination  string
+	status       valueobjects.BackupStatus
+	schedule     BackupSchedule
+	createdAt    time.Time
+	updatedAt    time.Time
+	nextRunAt    *time.Time
+	excludes     []string
+	enabled      bool
+	incremental  bool
+	usage        valueobjects.SpaceUsage
+	retention    valueobjects.RetentionPolicy
+	encrypted    bool
+	legalHold    bool
+	contentIndex bool
+	storagePool  string
+	migrating    bool
+	quota        valueobjects.Quota
+	hooks        []*BackupHook
 }
 
 func NewBackup(hostID HostID, path, destination string, schedule BackupSchedule, excludes []string, incremental bool, retention int, encrypted bool) (*Backup, error) {
+	if err := valueobjects.ValidateDestination(destination); err != nil {
+		return nil, err
+	}
 	currentTime := NowFunc()
 	b := &Backup{
 		id:          valueobjects.NewBackupID(),
@@ -47,8 +55,7 @@ func NewBackup(hostID HostID, path, destination string, schedule BackupSchedule,
 		excludes:    sanitizeExcludes(excludes),
 		enabled:     true,
 		incremental: incremental,
-		size:        "",
-		retention:   retention,
+		retention:   valueobjects.RetentionPolicy{KeepLast: retention},
 		encrypted:   encrypted,
 		hooks:       []*BackupHook{},
 	}
@@ -59,6 +66,9 @@ func NewBackup(hostID HostID, path, destination string, schedule BackupSchedule,
 }
 
 func NewBackupWithID(id valueobjects.BackupID, hostID HostID, path, destination string, schedule BackupSchedule, excludes []string, incremental bool, retention int, encrypted bool) (*Backup, error) {
+	if err := valueobjects.ValidateDestination(destination); err != nil {
+		return nil, err
+	}
 	currentTime := NowFunc()
 	b := &Backup{
 		id:          id,
@@ -72,8 +82,7 @@ func NewBackupWithID(id valueobjects.BackupID, hostID HostID, path, destination
 		excludes:    sanitizeExcludes(excludes),
 		enabled:     true,
 		incremental: incremental,
-		size:        "",
-		retention:   retention,
+		retention:   valueobjects.RetentionPolicy{KeepLast: retention},
 		encrypted:   encrypted,
 		hooks:       []*BackupHook{},
 	}
@@ -83,7 +92,7 @@ func NewBackupWithID(id valueobjects.BackupID, hostID HostID, path, destination
 	return b, nil
 }
 
-func RestoreBackup(id valueobjects.BackupID, hostID HostID, path, destination string, status valueobjects.BackupStatus, schedule BackupSchedule, createdAt, updatedAt time.Time, nextRunAt *time.Time, excludes []string, enabled bool, incremental bool, size string, retention int, encrypted bool) *Backup {
+func RestoreBackup(id valueobjects.BackupID, hostID HostID, path, destination string, status valueobjects.BackupStatus, schedule BackupSchedule, createdAt, updatedAt time.Time, nextRunAt *time.Time, excludes []string, enabled bool, incremental bool, usage valueobjects.SpaceUsage, retention int, encrypted bool) *Backup {
 	return &Backup{
 		id:          id,
 		hostID:      hostID,
@@ -97,8 +106,8 @@ func RestoreBackup(id valueobjects.BackupID, hostID HostID, path, destination st
 		excludes:    sanitizeExcludes(excludes),
 		enabled:     enabled,
 		incremental: incremental,
-		size:        size,
-		retention:   retention,
+		usage:       usage,
+		retention:   valueobjects.RetentionPolicy{KeepLast: retention},
 		encrypted:   encrypted,
 		hooks:       []*BackupHook{},
 	}
@@ -209,34 +218,135 @@ func (b *Backup) Incremental() bool {
 	return b.incremental
 }
 
-func (b *Backup) Size() string {
-	return b.size
+// Usage returns the space the backup took on its worker after the last run.
+func (b *Backup) Usage() valueobjects.SpaceUsage {
+	return b.usage
 }
 
-func (b *Backup) SetSize(size string) {
-	b.size = size
+func (b *Backup) SetUsage(usage valueobjects.SpaceUsage) {
+	b.usage = usage
 }
 
+// Retention returns the number of most recent snapshots that are always kept.
 func (b *Backup) Retention() int {
+	return b.retention.KeepLast
+}
+
+func (b *Backup) RetentionPolicy() valueobjects.RetentionPolicy {
 	return b.retention
 }
 
+// SetRetentionPolicy replaces the whole retention policy, including the
+// keep-last count exposed by Retention.
+func (b *Backup) SetRetentionPolicy(policy valueobjects.RetentionPolicy) {
+	b.retention = policy
+}
+
 func (b *Backup) Encrypted() bool {
 	return b.encrypted
 }
 
 func (b *Backup) Update(path, destination string, schedule BackupSchedule, excludes []string, incremental bool, retention int, encrypted bool) error {
+	if err := valueobjects.ValidateDestination(destination); err != nil {
+		return err
+	}
 	b.path = path
 	b.destination = destination
 	b.schedule = schedule
 	b.excludes = sanitizeExcludes(excludes)
 	b.incremental = incremental
-	b.retention = retention
+	b.retention.KeepLast = retention
 	b.encrypted = encrypted
 	b.updatedAt = NowFunc()
 	return b.CalculateNextRun()
 }
 
+// LegalHold reports whether purging and deleting this backup is suspended.
+func (b *Backup) LegalHold() bool {
+	return b.legalHold
+}
+
+func (b *Backup) SetLegalHold(enabled bool) {
+	b.legalHold = enabled
+}
+
+// ContentIndex reports whether the text files of this backup are indexed for
+// content search after each run. Encrypted backups never are, since the index
+// holds the text in the clear.
+func (b *Backup) ContentIndex() bool {
+	return b.contentIndex && !b.encrypted
+}
+
+func (b *Backup) SetContentIndex(enabled bool) {
+	b.contentIndex = enabled
+}
+
+// StoragePoolID is the storage pool the data of this backup lives in, empty
+// for the default backup root.
+func (b *Backup) StoragePoolID() string {
+	return b.storagePool
+}
+
+func (b *Backup) SetStoragePool(id string) {
+	b.storagePool = id
+}
+
+// Migrating reports whether the data of this backup is being moved to
+// another storage pool. It is not run until the move is over.
+func (b *Backup) Migrating() bool {
+	return b.migrating
+}
+
+func (b *Backup) SetMigrating(migrating bool) {
+	b.migrating = migrating
+}
+
+// StartMigration marks the backup as being moved to another storage pool,
+// refusing one that runs or is being moved already.
+func (b *Backup) StartMigration() error {
+	if b.status == valueobjects.BackupStatusRunning {
+		return valueobjects.ErrBackupRunning
+	}
+	if b.migrating {
+		return valueobjects.ErrBackupMigrating
+	}
+	b.migrating = true
+	b.updatedAt = NowFunc()
+	return nil
+}
+
+// EndMigration clears the mark left by StartMigration, whether the data
+// was moved or not.
+func (b *Backup) EndMigration() {
+	b.migrating = false
+	b.updatedAt = NowFunc()
+}
+
+// AdoptDestination points the backup at data already on its storage, such as
+// a directory it left behind when its destination changed, and forgets the
+// usage measured for the old one.
+func (b *Backup) AdoptDestination(destination string) error {
+	if b.status == valueobjects.BackupStatusRunning {
+		return valueobjects.ErrBackupRunning
+	}
+	if err := valueobjects.ValidateDestination(destination); err != nil {
+		return err
+	}
+	b.destination = destination
+	b.usage = valueobjects.SpaceUsage{}
+	b.updatedAt = NowFunc()
+	return nil
+}
+
+// Quota caps the space this backup may take, zero for no limit.
+func (b *Backup) Quota() valueobjects.Quota {
+	return b.quota
+}
+
+func (b *Backup) SetQuota(quota valueobjects.Quota) {
+	b.quota = quota
+}
+
 func (b *Backup) Hooks() []*BackupHook {
 	if b.hooks == nil {
 		return []*BackupHook{}
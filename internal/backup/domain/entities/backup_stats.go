@@ -1,9 +1,11 @@
 package entities
 
+// BackupStats counts backups by status. TotalSize is the space all backups
+// take on disk, in bytes.
 type BackupStats struct {
-	Total     int    `json:"total"`
-	Pending   int    `json:"pending"`
-	Completed int    `json:"completed"`
-	Failed    int    `json:"failed"`
-	TotalSize string `json:"total_size"`
+	Total     int   `json:"total"`
+	Pending   int   `json:"pending"`
+	Completed int   `json:"completed"`
+	Failed    int   `json:"failed"`
+	TotalSize int64 `json:"total_size"`
 }
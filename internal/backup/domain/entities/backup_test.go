@@ -150,8 +150,6 @@ func TestBackup(t *testing.T) {
 			destination  string
 			expectedDest string
 		}{
-			{"empty destination", "", ""},
-			{"single slash", "/", "/"},
 			{"trailing slash", "data/", "data/"},
 			{"leading slash", "/data", "/data"},
 			{"both slashes", "/data/", "/data/"},
@@ -166,4 +164,35 @@ func TestBackup(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("should reject destinations outside a directory of their own", func(t *testing.T) {
+		hostID := entities.NewHostID()
+		schedule := entities.NewBackupSchedule("0 0 * * *")
+
+		for _, destination := range []string{"", "/", ".", "..", "data/../other"} {
+			_, err := entities.NewBackup(hostID, "/data/source", destination, schedule, nil, false, 0, false)
+			assert.ErrorIs(t, err, valueobjects.ErrInvalidDestination, destination)
+		}
+
+		backup, err := entities.NewBackup(hostID, "/data/source", "data", schedule, nil, false, 0, false)
+		assert.NoError(t, err)
+		assert.ErrorIs(t, backup.Update("/data/source", "..", schedule, nil, false, 0, false), valueobjects.ErrInvalidDestination)
+		assert.Equal(t, "data", backup.Destination())
+	})
+}
+
+func TestBackup_RetentionPolicy(t *testing.T) {
+	hostID := entities.NewHostID()
+	schedule := entities.NewBackupSchedule("0 0 * * *")
+
+	backup, err := entities.NewBackup(hostID, "/src", "dest", schedule, nil, true, 5, false)
+	assert.NoError(t, err)
+	assert.Equal(t, valueobjects.RetentionPolicy{KeepLast: 5}, backup.RetentionPolicy())
+
+	backup.SetRetentionPolicy(valueobjects.RetentionPolicy{KeepLast: 2, KeepDaily: 7, KeepMonthly: 6})
+	assert.Equal(t, 2, backup.Retention())
+
+	// Updating the keep-last count leaves the calendar rules untouched.
+	assert.NoError(t, backup.Update("/src", "dest", schedule, nil, true, 3, false))
+	assert.Equal(t, valueobjects.RetentionPolicy{KeepLast: 3, KeepDaily: 7, KeepMonthly: 6}, backup.RetentionPolicy())
 }
@@ -0,0 +1,25 @@
+package entities
+
+import (
+	"path"
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+)
+
+// CatalogEntry is one file of one snapshot in the file catalog. Path is
+// relative to the snapshot root. Snapshot is empty for plain mirrors, whose
+// catalog is replaced by every run.
+type CatalogEntry struct {
+	BackupID valueobjects.BackupID
+	Snapshot string
+	Path     string
+	Size     int64
+	ModTime  time.Time
+	Hash     string
+}
+
+// Name returns the base name of the file, which glob patterns match against.
+func (e *CatalogEntry) Name() string {
+	return path.Base(e.Path)
+}
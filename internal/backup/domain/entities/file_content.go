@@ -0,0 +1,85 @@
+package entities
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+)
+
+// maxContentLineLength bounds how much of a long line, such as minified
+// code, is reported around a match.
+const maxContentLineLength = 300
+
+// FileContent is the text of a cataloged file in the content index. It is
+// stored once per backup and hash, however many snapshots hold that version.
+type FileContent struct {
+	BackupID valueobjects.BackupID
+	Hash     string
+	Content  string
+}
+
+// ContentMatch is one version of a file whose content matched a query, with
+// every snapshot holding that version, newest first.
+type ContentMatch struct {
+	BackupID  valueobjects.BackupID
+	Path      string
+	Hash      string
+	Snapshots []string
+	Content   string
+}
+
+// ContentLine is a line of a file, numbered from 1.
+type ContentLine struct {
+	Number int
+	Text   string
+}
+
+// MatchingLines returns up to max lines of the content that contain query,
+// ignoring case. Long lines are cut down to the part around the match.
+func (m *ContentMatch) MatchingLines(query string, max int) []ContentLine {
+	needle := strings.ToLower(query)
+	lines := make([]ContentLine, 0)
+	for i, line := range strings.Split(m.Content, "\n") {
+		if len(lines) == max {
+			break
+		}
+		at := strings.Index(strings.ToLower(line), needle)
+		if at < 0 {
+			continue
+		}
+		lines = append(lines, ContentLine{Number: i + 1, Text: clipLine(strings.TrimRight(line, "\r"), at)})
+	}
+	return lines
+}
+
+// clipLine shortens line to maxContentLineLength bytes around offset at,
+// keeping whole UTF-8 characters.
+func clipLine(line string, at int) string {
+	if len(line) <= maxContentLineLength {
+		return line
+	}
+	start := at - maxContentLineLength/3
+	if start < 0 {
+		start = 0
+	}
+	end := start + maxContentLineLength
+	if end > len(line) {
+		end = len(line)
+		start = end - maxContentLineLength
+	}
+	for start > 0 && !utf8.RuneStart(line[start]) {
+		start--
+	}
+	for end < len(line) && !utf8.RuneStart(line[end]) {
+		end++
+	}
+	clipped := line[start:end]
+	if start > 0 {
+		clipped = "…" + clipped
+	}
+	if end < len(line) {
+		clipped += "…"
+	}
+	return clipped
+}
@@ -0,0 +1,31 @@
+package entities
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentMatch_MatchingLines(t *testing.T) {
+	m := &ContentMatch{Content: "server {\r\n  Listen 80;\r\n  listen 443 ssl;\r\n}\r\n"}
+
+	lines := m.MatchingLines("LISTEN", 10)
+	assert.Equal(t, []ContentLine{{Number: 2, Text: "  Listen 80;"}, {Number: 3, Text: "  listen 443 ssl;"}}, lines)
+
+	assert.Len(t, m.MatchingLines("listen", 1), 1)
+	assert.Empty(t, m.MatchingLines("absent", 10))
+}
+
+func TestContentMatch_MatchingLinesClipsLongLines(t *testing.T) {
+	line := strings.Repeat("é", 400) + "needle" + strings.Repeat("x", 400)
+	m := &ContentMatch{Content: line}
+
+	lines := m.MatchingLines("needle", 10)
+	assert.Len(t, lines, 1)
+	text := lines[0].Text
+	assert.Contains(t, text, "needle")
+	assert.True(t, strings.HasPrefix(text, "…"))
+	assert.True(t, strings.HasSuffix(text, "…"))
+	assert.LessOrEqual(t, len(text), maxContentLineLength+2*len("…")+1)
+}
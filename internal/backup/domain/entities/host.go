@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
 	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
 )
 
@@ -35,6 +36,9 @@ type Host struct {
 	port          int
 	path          string
 	isWorkstation bool
+	legalHold     bool
+	storagePool   string
+	quota         valueobjects.Quota
 	createdAt     time.Time
 }
 
@@ -109,6 +113,35 @@ func (h *Host) CreatedAt() time.Time {
 	return h.createdAt
 }
 
+// LegalHold reports whether purging and deleting any backup of this host is suspended.
+func (h *Host) LegalHold() bool {
+	return h.legalHold
+}
+
+func (h *Host) SetLegalHold(enabled bool) {
+	h.legalHold = enabled
+}
+
+// StoragePoolID is the storage pool new backups of this host are placed in,
+// empty to place them by free space.
+func (h *Host) StoragePoolID() string {
+	return h.storagePool
+}
+
+func (h *Host) SetStoragePool(id string) {
+	h.storagePool = id
+}
+
+// Quota caps the space all backups of this host may take together, zero for
+// no limit.
+func (h *Host) Quota() valueobjects.Quota {
+	return h.quota
+}
+
+func (h *Host) SetQuota(quota valueobjects.Quota) {
+	h.quota = quota
+}
+
 func (h *Host) Update(name, hostname, user string, port int, path string, isWorkstation bool) {
 	h.name = name
 	h.hostname = hostname
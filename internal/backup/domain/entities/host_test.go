@@ -6,6 +6,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
 	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
 	"github.com/stretchr/testify/assert"
 )
@@ -114,3 +115,24 @@ func TestHost(t *testing.T) {
 		assert.Equal(t, createdAt, host.CreatedAt())
 	})
 }
+
+func TestRunQuotaBudget(t *testing.T) {
+	host := entities.NewHost("prod", "prod.local", "root", 22, "prod", false)
+	backup, err := entities.NewBackup(host.ID(), "/srv", "srv", entities.NewBackupSchedule("@daily"), nil, true, 5, false)
+	assert.NoError(t, err)
+	backup.SetUsage(valueobjects.SpaceUsage{Stored: 400})
+	other, err := entities.NewBackup(host.ID(), "/etc", "etc", entities.NewBackupSchedule("@daily"), nil, true, 5, false)
+	assert.NoError(t, err)
+	other.SetUsage(valueobjects.SpaceUsage{Stored: 500})
+	hostStored := entities.HostStoredBytes([]*entities.Backup{backup, other})
+	assert.Equal(t, int64(900), hostStored)
+
+	assert.False(t, entities.RunQuotaBudget(backup, host, hostStored).Limited())
+
+	backup.SetQuota(valueobjects.Quota{Bytes: 1000})
+	assert.Equal(t, valueobjects.QuotaBudget{Bytes: 600, Scope: valueobjects.QuotaScopeBackup}, entities.RunQuotaBudget(backup, host, hostStored))
+
+	// The host quota leaves less than the backup quota.
+	host.SetQuota(valueobjects.Quota{Bytes: 1200})
+	assert.Equal(t, valueobjects.QuotaBudget{Bytes: 300, Scope: valueobjects.QuotaScopeHost}, entities.RunQuotaBudget(backup, host, hostStored))
+}
@@ -0,0 +1,46 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrLegalHold is returned when purging or deleting data covered by a legal hold.
+var ErrLegalHold = errors.New("operation blocked by legal hold")
+
+var ErrLegalHoldReasonRequired = errors.New("a reason is required to place a legal hold")
+
+type LegalHoldScope string
+
+const (
+	LegalHoldScopeHost   LegalHoldScope = "host"
+	LegalHoldScopeBackup LegalHoldScope = "backup"
+)
+
+// LegalHoldEvent is the audit record of a legal hold being placed or released.
+type LegalHoldEvent struct {
+	ID         string
+	Scope      LegalHoldScope
+	TargetID   string
+	Enabled    bool
+	Reason     string
+	UserID     *int
+	OccurredAt time.Time
+}
+
+func NewLegalHoldEvent(scope LegalHoldScope, targetID string, enabled bool, reason string, userID *int) *LegalHoldEvent {
+	return &LegalHoldEvent{
+		Scope:      scope,
+		TargetID:   targetID,
+		Enabled:    enabled,
+		Reason:     reason,
+		UserID:     userID,
+		OccurredAt: NowFunc(),
+	}
+}
+
+// UnderLegalHold reports whether a backup is covered by its own hold or by a
+// hold on its host. host may be nil when it is unknown.
+func UnderLegalHold(backup *Backup, host *Host) bool {
+	return backup.LegalHold() || (host != nil && host.LegalHold())
+}
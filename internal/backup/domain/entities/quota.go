@@ -0,0 +1,23 @@
+package entities
+
+import "github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+
+// HostStoredBytes is what the backups of a host store on disk together.
+func HostStoredBytes(backups []*Backup) int64 {
+	var stored int64
+	for _, b := range backups {
+		stored += b.Usage().Stored
+	}
+	return stored
+}
+
+// RunQuotaBudget is what the next run of a backup may add before it breaks
+// its own quota or the quota of its host. hostStored is what all backups of
+// the host store; host may be nil when it is unknown.
+func RunQuotaBudget(backup *Backup, host *Host, hostStored int64) valueobjects.QuotaBudget {
+	budget := valueobjects.QuotaBudget{}.Tighten(backup.Quota(), backup.Usage().Stored, valueobjects.QuotaScopeBackup)
+	if host != nil {
+		budget = budget.Tighten(host.Quota(), hostStored, valueobjects.QuotaScopeHost)
+	}
+	return budget
+}
@@ -0,0 +1,149 @@
+package entities
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+)
+
+// ReplicaTarget is offsite storage the snapshots of backups are copied to
+// after each successful run. A target with no backups listed covers every
+// backup.
+type ReplicaTarget struct {
+	id        uuid.UUID
+	name      string
+	kind      valueobjects.ReplicaTargetKind
+	s3        valueobjects.S3Config
+	rsync     valueobjects.RsyncConfig
+	policy    valueobjects.ReplicaPolicy
+	backupIDs []valueobjects.BackupID
+	enabled   bool
+	createdAt time.Time
+	updatedAt time.Time
+}
+
+func NewReplicaTarget(name string, kind valueobjects.ReplicaTargetKind, s3 valueobjects.S3Config, rsync valueobjects.RsyncConfig, policy valueobjects.ReplicaPolicy, backupIDs []valueobjects.BackupID) (*ReplicaTarget, error) {
+	target := &ReplicaTarget{
+		id:        uuid.New(),
+		enabled:   true,
+		createdAt: NowFunc(),
+	}
+	if err := target.Update(name, kind, s3, rsync, policy, backupIDs); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+func RestoreReplicaTarget(id uuid.UUID, name string, kind valueobjects.ReplicaTargetKind, s3 valueobjects.S3Config, rsync valueobjects.RsyncConfig, policy valueobjects.ReplicaPolicy, backupIDs []valueobjects.BackupID, enabled bool, createdAt, updatedAt time.Time) *ReplicaTarget {
+	return &ReplicaTarget{
+		id:        id,
+		name:      name,
+		kind:      kind,
+		s3:        s3,
+		rsync:     rsync,
+		policy:    policy,
+		backupIDs: backupIDs,
+		enabled:   enabled,
+		createdAt: createdAt,
+		updatedAt: updatedAt,
+	}
+}
+
+func (t *ReplicaTarget) ID() uuid.UUID                        { return t.id }
+func (t *ReplicaTarget) Name() string                         { return t.name }
+func (t *ReplicaTarget) Kind() valueobjects.ReplicaTargetKind { return t.kind }
+func (t *ReplicaTarget) S3() valueobjects.S3Config            { return t.s3 }
+func (t *ReplicaTarget) Rsync() valueobjects.RsyncConfig      { return t.rsync }
+func (t *ReplicaTarget) Policy() valueobjects.ReplicaPolicy   { return t.policy }
+func (t *ReplicaTarget) BackupIDs() []valueobjects.BackupID   { return t.backupIDs }
+func (t *ReplicaTarget) Enabled() bool                        { return t.enabled }
+func (t *ReplicaTarget) CreatedAt() time.Time                 { return t.createdAt }
+func (t *ReplicaTarget) UpdatedAt() time.Time                 { return t.updatedAt }
+
+// Update replaces the definition of the target.
+func (t *ReplicaTarget) Update(name string, kind valueobjects.ReplicaTargetKind, s3 valueobjects.S3Config, rsync valueobjects.RsyncConfig, policy valueobjects.ReplicaPolicy, backupIDs []valueobjects.BackupID) error {
+	if name == "" {
+		return fmt.Errorf("%w: name is required", valueobjects.ErrInvalidReplicaTarget)
+	}
+	switch kind {
+	case valueobjects.ReplicaTargetS3:
+		if err := s3.Validate(); err != nil {
+			return err
+		}
+		rsync = valueobjects.RsyncConfig{}
+	case valueobjects.ReplicaTargetRsync:
+		if err := rsync.Validate(); err != nil {
+			return err
+		}
+		if rsync.Port == 0 {
+			rsync.Port = 22
+		}
+		s3 = valueobjects.S3Config{}
+	}
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+
+	t.name = name
+	t.kind = kind
+	t.s3 = s3
+	t.rsync = rsync
+	t.policy = policy
+	t.backupIDs = backupIDs
+	t.updatedAt = NowFunc()
+	return nil
+}
+
+func (t *ReplicaTarget) Enable() {
+	t.enabled = true
+	t.updatedAt = NowFunc()
+}
+
+func (t *ReplicaTarget) Disable() {
+	t.enabled = false
+	t.updatedAt = NowFunc()
+}
+
+// Endpoint returns the settings a worker reaches the target with.
+func (t *ReplicaTarget) Endpoint() valueobjects.ReplicaEndpoint {
+	endpoint := valueobjects.ReplicaEndpoint{TargetID: t.id.String(), Kind: t.kind, BandwidthLimit: t.policy.BandwidthLimit}
+	switch t.kind {
+	case valueobjects.ReplicaTargetS3:
+		s3 := t.s3
+		endpoint.S3 = &s3
+	case valueobjects.ReplicaTargetRsync:
+		rsync := t.rsync
+		endpoint.Rsync = &rsync
+	}
+	return endpoint
+}
+
+// Covers reports whether the snapshots of the backup are copied to the
+// target.
+func (t *ReplicaTarget) Covers(backupID valueobjects.BackupID) bool {
+	if len(t.backupIDs) == 0 {
+		return true
+	}
+	for _, id := range t.backupIDs {
+		if id == backupID {
+			return true
+		}
+	}
+	return false
+}
+
+// ReplicaSnapshot records a snapshot of a backup that is stored on a replica
+// target. Plain mirrors are replicated as the single snapshot "latest",
+// which each run refreshes. UploadedBytes is what the last copy of the
+// snapshot sent; the rest was already on the target.
+type ReplicaSnapshot struct {
+	TargetID      uuid.UUID
+	BackupID      valueobjects.BackupID
+	Snapshot      string
+	Files         int64
+	Bytes         int64
+	UploadedBytes int64
+	ReplicatedAt  time.Time
+}
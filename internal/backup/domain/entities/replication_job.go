@@ -0,0 +1,239 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+)
+
+var (
+	// ErrReplicationRunning is returned when a replication job is started
+	// while its previous run has not reported back yet.
+	ErrReplicationRunning = errors.New("replication job is already running")
+	// ErrInvalidReplicationJob is returned for a job missing a required field
+	// or with options a scheduled restore cannot take.
+	ErrInvalidReplicationJob = errors.New("invalid replication job")
+)
+
+// ReplicationLockTimeout is how long a started run keeps its job locked. A
+// run whose result never arrives, because its worker died, stops blocking the
+// job after it.
+const ReplicationLockTimeout = 24 * time.Hour
+
+// ReplicationJob restores a snapshot of a backup to a target host on a
+// schedule, keeping the target in sync with the backup.
+type ReplicationJob struct {
+	id            uuid.UUID
+	name          string
+	backupID      valueobjects.BackupID
+	path          string
+	snapshot      string
+	targetHostID  HostID
+	targetPath    string
+	schedule      string
+	options       valueobjects.RestoreOptions
+	enabled       bool
+	status        valueobjects.BackupStatus
+	currentTaskID string
+	startedAt     *time.Time
+	lastRunAt     *time.Time
+	nextRunAt     *time.Time
+	createdAt     time.Time
+	updatedAt     time.Time
+}
+
+func NewReplicationJob(name string, backupID valueobjects.BackupID, path, snapshot string, targetHostID HostID, targetPath, schedule string, options valueobjects.RestoreOptions) (*ReplicationJob, error) {
+	now := NowFunc()
+	job := &ReplicationJob{
+		id:        uuid.New(),
+		backupID:  backupID,
+		enabled:   true,
+		status:    valueobjects.BackupStatusPending,
+		createdAt: now,
+	}
+	if err := job.Update(name, path, snapshot, targetHostID, targetPath, schedule, options); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func RestoreReplicationJob(id uuid.UUID, name string, backupID valueobjects.BackupID, path, snapshot string, targetHostID HostID, targetPath, schedule string, options valueobjects.RestoreOptions, enabled bool, status valueobjects.BackupStatus, currentTaskID string, startedAt, lastRunAt, nextRunAt *time.Time, createdAt, updatedAt time.Time) *ReplicationJob {
+	return &ReplicationJob{
+		id:            id,
+		name:          name,
+		backupID:      backupID,
+		path:          path,
+		snapshot:      snapshot,
+		targetHostID:  targetHostID,
+		targetPath:    targetPath,
+		schedule:      schedule,
+		options:       options,
+		enabled:       enabled,
+		status:        status,
+		currentTaskID: currentTaskID,
+		startedAt:     startedAt,
+		lastRunAt:     lastRunAt,
+		nextRunAt:     nextRunAt,
+		createdAt:     createdAt,
+		updatedAt:     updatedAt,
+	}
+}
+
+func (j *ReplicationJob) ID() uuid.UUID                        { return j.id }
+func (j *ReplicationJob) Name() string                         { return j.name }
+func (j *ReplicationJob) BackupID() valueobjects.BackupID      { return j.backupID }
+func (j *ReplicationJob) Path() string                         { return j.path }
+func (j *ReplicationJob) Snapshot() string                     { return j.snapshot }
+func (j *ReplicationJob) TargetHostID() HostID                 { return j.targetHostID }
+func (j *ReplicationJob) TargetPath() string                   { return j.targetPath }
+func (j *ReplicationJob) Schedule() string                     { return j.schedule }
+func (j *ReplicationJob) Options() valueobjects.RestoreOptions { return j.options }
+func (j *ReplicationJob) Enabled() bool                        { return j.enabled }
+func (j *ReplicationJob) Status() valueobjects.BackupStatus    { return j.status }
+func (j *ReplicationJob) CurrentTaskID() string                { return j.currentTaskID }
+func (j *ReplicationJob) StartedAt() *time.Time                { return j.startedAt }
+func (j *ReplicationJob) LastRunAt() *time.Time                { return j.lastRunAt }
+func (j *ReplicationJob) NextRunAt() *time.Time                { return j.nextRunAt }
+func (j *ReplicationJob) CreatedAt() time.Time                 { return j.createdAt }
+func (j *ReplicationJob) UpdatedAt() time.Time                 { return j.updatedAt }
+
+// Update replaces the definition of the job and reschedules it. The backup
+// it replicates cannot change.
+func (j *ReplicationJob) Update(name, path, snapshot string, targetHostID HostID, targetPath, schedule string, options valueobjects.RestoreOptions) error {
+	if name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidReplicationJob)
+	}
+	if targetPath == "" {
+		return fmt.Errorf("%w: target path is required", ErrInvalidReplicationJob)
+	}
+	if schedule == "" {
+		return fmt.Errorf("%w: schedule is required", ErrInvalidReplicationJob)
+	}
+	if options.DryRun {
+		return fmt.Errorf("%w: a replication job cannot be a dry run", ErrInvalidReplicationJob)
+	}
+	if _, err := valueobjects.ParseSnapshotSelector(snapshot); err != nil {
+		return err
+	}
+
+	j.name = name
+	j.path = path
+	j.snapshot = snapshot
+	j.targetHostID = targetHostID
+	j.targetPath = targetPath
+	j.schedule = schedule
+	j.options = options
+	j.updatedAt = NowFunc()
+	return j.CalculateNextRun()
+}
+
+func (j *ReplicationJob) Enable() error {
+	j.enabled = true
+	j.updatedAt = NowFunc()
+	return j.CalculateNextRun()
+}
+
+func (j *ReplicationJob) Disable() {
+	j.enabled = false
+	j.nextRunAt = nil
+	j.updatedAt = NowFunc()
+}
+
+func (j *ReplicationJob) CalculateNextRun() error {
+	if !j.enabled {
+		j.nextRunAt = nil
+		return nil
+	}
+
+	parser := cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+	schedule, err := parser.Parse(j.schedule)
+	if err != nil {
+		return fmt.Errorf("%w: invalid schedule: %v", ErrInvalidReplicationJob, err)
+	}
+
+	next := schedule.Next(NowFunc())
+	j.nextRunAt = &next
+	return nil
+}
+
+// Running reports whether a run of the job holds its lock at the given time.
+func (j *ReplicationJob) Running(now time.Time) bool {
+	return j.status == valueobjects.BackupStatusRunning &&
+		j.startedAt != nil && now.Sub(*j.startedAt) < ReplicationLockTimeout
+}
+
+// Start locks the job for the run carried by the task.
+func (j *ReplicationJob) Start(taskID string) error {
+	now := NowFunc()
+	if j.Running(now) {
+		return ErrReplicationRunning
+	}
+	j.status = valueobjects.BackupStatusRunning
+	j.currentTaskID = taskID
+	j.startedAt = &now
+	j.updatedAt = now
+	return nil
+}
+
+// Finish releases the lock held by the run of the task and records its
+// outcome. It reports false for a task that no longer holds the lock, such as
+// the late result of a run whose lock expired.
+func (j *ReplicationJob) Finish(taskID string, succeeded bool) bool {
+	if j.currentTaskID != taskID {
+		return false
+	}
+	now := NowFunc()
+	if succeeded {
+		j.status = valueobjects.BackupStatusCompleted
+	} else {
+		j.status = valueobjects.BackupStatusFailed
+	}
+	j.currentTaskID = ""
+	j.lastRunAt = &now
+	j.updatedAt = now
+	return true
+}
+
+// Trigger of a replication run.
+const (
+	ReplicationTriggerSchedule = "schedule"
+	ReplicationTriggerManual   = "manual"
+)
+
+// ReplicationRun is one run of a replication job, kept as its history.
+type ReplicationRun struct {
+	ID         uuid.UUID
+	JobID      uuid.UUID
+	TaskID     string
+	Trigger    string
+	Status     valueobjects.BackupStatus
+	Message    string
+	StartedAt  time.Time
+	FinishedAt *time.Time
+}
+
+func NewReplicationRun(jobID uuid.UUID, taskID, trigger string) *ReplicationRun {
+	return &ReplicationRun{
+		ID:        uuid.New(),
+		JobID:     jobID,
+		TaskID:    taskID,
+		Trigger:   trigger,
+		Status:    valueobjects.BackupStatusRunning,
+		StartedAt: NowFunc(),
+	}
+}
+
+func (r *ReplicationRun) Finish(succeeded bool, message string) {
+	now := NowFunc()
+	if succeeded {
+		r.Status = valueobjects.BackupStatusCompleted
+	} else {
+		r.Status = valueobjects.BackupStatusFailed
+	}
+	r.Message = message
+	r.FinishedAt = &now
+}
@@ -0,0 +1,57 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewReplicationJob(t *testing.T) {
+	backupID := valueobjects.NewBackupID()
+	hostID := NewHostID()
+
+	job, err := NewReplicationJob("staging", backupID, "", "latest", hostID, "/srv", "0 3 * * *", valueobjects.RestoreOptions{Conflict: valueobjects.ConflictSkip})
+	assert.NoError(t, err)
+	assert.True(t, job.Enabled())
+	assert.NotNil(t, job.NextRunAt())
+	assert.Equal(t, valueobjects.BackupStatusPending, job.Status())
+
+	_, err = NewReplicationJob("staging", backupID, "", "", hostID, "", "0 3 * * *", valueobjects.RestoreOptions{})
+	assert.ErrorIs(t, err, ErrInvalidReplicationJob)
+
+	_, err = NewReplicationJob("staging", backupID, "", "", hostID, "/srv", "0 3 * * *", valueobjects.RestoreOptions{DryRun: true})
+	assert.ErrorIs(t, err, ErrInvalidReplicationJob)
+
+	_, err = NewReplicationJob("staging", backupID, "", "yesterday", hostID, "/srv", "0 3 * * *", valueobjects.RestoreOptions{})
+	assert.ErrorIs(t, err, valueobjects.ErrInvalidSnapshotSelector)
+}
+
+func TestReplicationJobLock(t *testing.T) {
+	original := NowFunc
+	defer func() { NowFunc = original }()
+	now := time.Date(2024, 6, 1, 3, 0, 0, 0, time.UTC)
+	NowFunc = func() time.Time { return now }
+
+	job, err := NewReplicationJob("staging", valueobjects.NewBackupID(), "", "", NewHostID(), "/srv", "0 3 * * *", valueobjects.RestoreOptions{})
+	assert.NoError(t, err)
+
+	assert.NoError(t, job.Start("task-1"))
+	assert.True(t, job.Running(now))
+	assert.ErrorIs(t, job.Start("task-2"), ErrReplicationRunning)
+
+	// A run that never reports back stops holding the lock.
+	now = now.Add(ReplicationLockTimeout)
+	assert.False(t, job.Running(now))
+	assert.NoError(t, job.Start("task-2"))
+
+	// The late result of the expired run does not release the new one.
+	assert.False(t, job.Finish("task-1", true))
+	assert.True(t, job.Running(now))
+
+	assert.True(t, job.Finish("task-2", true))
+	assert.False(t, job.Running(now))
+	assert.Equal(t, valueobjects.BackupStatusCompleted, job.Status())
+	assert.Equal(t, now, *job.LastRunAt())
+}
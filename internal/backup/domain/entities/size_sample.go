@@ -0,0 +1,34 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+)
+
+// SizeSample records the space usage a backup reported at the end of one run,
+// and the files the run changed, so its growth can be followed over time.
+type SizeSample struct {
+	BackupID  valueobjects.BackupID
+	HostID    HostID
+	Usage     valueobjects.SpaceUsage
+	Changes   valueobjects.FileChanges
+	SampledAt time.Time
+}
+
+// NewSizeSample samples the current usage of a backup after a run that made
+// the given changes.
+func NewSizeSample(backup *Backup, changes valueobjects.FileChanges) *SizeSample {
+	return &SizeSample{
+		BackupID:  backup.ID(),
+		HostID:    backup.HostID(),
+		Usage:     backup.Usage(),
+		Changes:   changes,
+		SampledAt: NowFunc().UTC(),
+	}
+}
+
+// Run is what anomaly detection judges the run by.
+func (s *SizeSample) Run() valueobjects.RunSample {
+	return valueobjects.RunSample{Size: s.Usage.Size, Changes: s.Changes}
+}
@@ -0,0 +1,51 @@
+package entities
+
+import (
+	"errors"
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+)
+
+var ErrInvalidSnapshotName = errors.New("invalid snapshot name: expected YYYY-MM-DD_HH-MM-SS")
+
+// SnapshotPin exempts a snapshot of a backup from retention until it expires.
+// A nil ExpiresAt pins the snapshot forever.
+type SnapshotPin struct {
+	BackupID  valueobjects.BackupID
+	Snapshot  string
+	Label     string
+	Note      string
+	ExpiresAt *time.Time
+	CreatedAt time.Time
+}
+
+func NewSnapshotPin(backupID valueobjects.BackupID, snapshot, label, note string, expiresAt *time.Time) (*SnapshotPin, error) {
+	if _, ok := valueobjects.ParseSnapshotTime(snapshot); !ok {
+		return nil, ErrInvalidSnapshotName
+	}
+	return &SnapshotPin{
+		BackupID:  backupID,
+		Snapshot:  snapshot,
+		Label:     label,
+		Note:      note,
+		ExpiresAt: expiresAt,
+		CreatedAt: NowFunc(),
+	}, nil
+}
+
+// Active reports whether the pin still protects its snapshot at the given time.
+func (p *SnapshotPin) Active(now time.Time) bool {
+	return p.ExpiresAt == nil || now.Before(*p.ExpiresAt)
+}
+
+// ActivePinnedSnapshots returns the snapshots protected by unexpired pins.
+func ActivePinnedSnapshots(pins []*SnapshotPin, now time.Time) []string {
+	snapshots := make([]string, 0, len(pins))
+	for _, p := range pins {
+		if p.Active(now) {
+			snapshots = append(snapshots, p.Snapshot)
+		}
+	}
+	return snapshots
+}
@@ -0,0 +1,49 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSnapshotPin(t *testing.T) {
+	backupID := valueobjects.NewBackupID()
+
+	pin, err := NewSnapshotPin(backupID, "2024-01-01_00-00-00", "label", "note", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-01-01_00-00-00", pin.Snapshot)
+
+	_, err = NewSnapshotPin(backupID, "latest", "", "", nil)
+	assert.ErrorIs(t, err, ErrInvalidSnapshotName)
+}
+
+func TestActivePinnedSnapshots(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	pins := []*SnapshotPin{
+		{Snapshot: "2024-01-01_00-00-00"},
+		{Snapshot: "2024-01-02_00-00-00", ExpiresAt: &past},
+		{Snapshot: "2024-01-03_00-00-00", ExpiresAt: &future},
+	}
+
+	assert.Equal(t, []string{"2024-01-01_00-00-00", "2024-01-03_00-00-00"}, ActivePinnedSnapshots(pins, now))
+}
+
+func TestUnderLegalHold(t *testing.T) {
+	host := NewHost("host", "host.example.com", "user", 22, "path", false)
+	backup, _ := NewBackup(host.ID(), "/src", "dest", NewBackupSchedule("@daily"), nil, true, 1, false)
+
+	assert.False(t, UnderLegalHold(backup, host))
+	assert.False(t, UnderLegalHold(backup, nil))
+
+	host.SetLegalHold(true)
+	assert.True(t, UnderLegalHold(backup, host))
+
+	host.SetLegalHold(false)
+	backup.SetLegalHold(true)
+	assert.True(t, UnderLegalHold(backup, nil))
+}
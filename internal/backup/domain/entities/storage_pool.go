@@ -0,0 +1,103 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+)
+
+// StoragePool is a place the worker keeps backups in, such as a RAID set
+// mounted at its own root. Capacity caps how much of its filesystem the
+// pool may fill, 0 for all of it. Labels let backups be placed on pools of
+// a kind, like "ssd" or "archive".
+type StoragePool struct {
+	id        uuid.UUID
+	name      string
+	rootPath  string
+	capacity  int64
+	labels    []string
+	createdAt time.Time
+	updatedAt time.Time
+}
+
+func NewStoragePool(name string, rootPath string, capacity int64, labels []string) (*StoragePool, error) {
+	pool := &StoragePool{
+		id:        uuid.New(),
+		createdAt: NowFunc(),
+	}
+	if err := pool.Update(name, rootPath, capacity, labels); err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
+func RestoreStoragePool(id uuid.UUID, name string, rootPath string, capacity int64, labels []string, createdAt, updatedAt time.Time) *StoragePool {
+	return &StoragePool{
+		id:        id,
+		name:      name,
+		rootPath:  rootPath,
+		capacity:  capacity,
+		labels:    labels,
+		createdAt: createdAt,
+		updatedAt: updatedAt,
+	}
+}
+
+func (p *StoragePool) ID() uuid.UUID        { return p.id }
+func (p *StoragePool) Name() string         { return p.name }
+func (p *StoragePool) RootPath() string     { return p.rootPath }
+func (p *StoragePool) Capacity() int64      { return p.capacity }
+func (p *StoragePool) Labels() []string     { return p.labels }
+func (p *StoragePool) CreatedAt() time.Time { return p.createdAt }
+func (p *StoragePool) UpdatedAt() time.Time { return p.updatedAt }
+
+// Update replaces the definition of the pool. The root is the directory of
+// the pool as the worker sees it.
+func (p *StoragePool) Update(name string, rootPath string, capacity int64, labels []string) error {
+	if name == "" {
+		return fmt.Errorf("%w: name is required", valueobjects.ErrInvalidStoragePool)
+	}
+	rootPath = strings.TrimSuffix(rootPath, "/")
+	if !strings.HasPrefix(rootPath, "/") {
+		return fmt.Errorf("%w: root path must be absolute", valueobjects.ErrInvalidStoragePool)
+	}
+	if capacity < 0 {
+		return fmt.Errorf("%w: capacity cannot be negative", valueobjects.ErrInvalidStoragePool)
+	}
+
+	p.name = name
+	p.rootPath = rootPath
+	p.capacity = capacity
+	p.labels = labels
+	p.updatedAt = NowFunc()
+	return nil
+}
+
+// HasLabels reports whether the pool carries every one of labels.
+func (p *StoragePool) HasLabels(labels []string) bool {
+	for _, want := range labels {
+		found := false
+		for _, label := range p.labels {
+			if label == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Available returns the space left in the pool given the usage of its
+// filesystem, within its capacity.
+func (p *StoragePool) Available(used, free int64) int64 {
+	if p.capacity > 0 && p.capacity-used < free {
+		free = p.capacity - used
+	}
+	return max(free, 0)
+}
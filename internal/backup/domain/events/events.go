@@ -7,6 +7,16 @@ import (
 const (
 	BackupCompletedEvent = "backup.completed"
 	BackupFailedEvent    = "backup.failed"
+
+	ReplicationCompletedEvent = "replication.completed"
+	ReplicationFailedEvent    = "replication.failed"
+	ReplicaFailedEvent        = "replica.failed"
+
+	CapacityWarningEvent = "capacity.warning"
+
+	BackupAnomalyEvent = "backup.anomaly"
+
+	OrphanedDataFoundEvent = "storage.orphaned_data"
 )
 
 type BackupCompleted struct {
@@ -15,7 +25,8 @@ type BackupCompleted struct {
 	HostName   string    `json:"host_name"`
 	SourcePath string    `json:"source_path"`
 	OccurredAt time.Time `json:"occurred_at"`
-	Size       string    `json:"size"`
+	Size       int64     `json:"size"`
+	UniqueSize int64     `json:"unique_size"`
 }
 
 func (e BackupCompleted) Name() string {
@@ -43,7 +54,7 @@ func (e BackupFailed) OccurredOn() time.Time {
 	return e.OccurredAt
 }
 
-func NewBackupCompleted(backupID string, hostID string, hostName string, sourcePath string, size string) BackupCompleted {
+func NewBackupCompleted(backupID string, hostID string, hostName string, sourcePath string, size, uniqueSize int64) BackupCompleted {
 	return BackupCompleted{
 		BackupID:   backupID,
 		HostID:     hostID,
@@ -51,6 +62,7 @@ func NewBackupCompleted(backupID string, hostID string, hostName string, sourceP
 		SourcePath: sourcePath,
 		OccurredAt: time.Now(),
 		Size:       size,
+		UniqueSize: uniqueSize,
 	}
 }
 
@@ -64,3 +76,191 @@ func NewBackupFailed(backupID string, hostID string, hostName string, sourcePath
 		ErrorMessage: errorMessage,
 	}
 }
+
+type ReplicationCompleted struct {
+	JobID      string    `json:"job_id"`
+	JobName    string    `json:"job_name"`
+	BackupID   string    `json:"backup_id"`
+	TargetHost string    `json:"target_host"`
+	TargetPath string    `json:"target_path"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+func (e ReplicationCompleted) Name() string {
+	return ReplicationCompletedEvent
+}
+
+func (e ReplicationCompleted) OccurredOn() time.Time {
+	return e.OccurredAt
+}
+
+type ReplicationFailed struct {
+	JobID        string    `json:"job_id"`
+	JobName      string    `json:"job_name"`
+	BackupID     string    `json:"backup_id"`
+	TargetHost   string    `json:"target_host"`
+	TargetPath   string    `json:"target_path"`
+	OccurredAt   time.Time `json:"occurred_at"`
+	ErrorMessage string    `json:"error_message"`
+}
+
+func (e ReplicationFailed) Name() string {
+	return ReplicationFailedEvent
+}
+
+func (e ReplicationFailed) OccurredOn() time.Time {
+	return e.OccurredAt
+}
+
+func NewReplicationCompleted(jobID string, jobName string, backupID string, targetHost string, targetPath string) ReplicationCompleted {
+	return ReplicationCompleted{
+		JobID:      jobID,
+		JobName:    jobName,
+		BackupID:   backupID,
+		TargetHost: targetHost,
+		TargetPath: targetPath,
+		OccurredAt: time.Now(),
+	}
+}
+
+func NewReplicationFailed(jobID string, jobName string, backupID string, targetHost string, targetPath string, errorMessage string) ReplicationFailed {
+	return ReplicationFailed{
+		JobID:        jobID,
+		JobName:      jobName,
+		BackupID:     backupID,
+		TargetHost:   targetHost,
+		TargetPath:   targetPath,
+		OccurredAt:   time.Now(),
+		ErrorMessage: errorMessage,
+	}
+}
+
+// ReplicaFailed is raised when the snapshots of a backup could not be copied
+// to a replica target.
+type ReplicaFailed struct {
+	TargetID     string    `json:"target_id"`
+	TargetName   string    `json:"target_name"`
+	BackupID     string    `json:"backup_id"`
+	OccurredAt   time.Time `json:"occurred_at"`
+	ErrorMessage string    `json:"error_message"`
+}
+
+func (e ReplicaFailed) Name() string {
+	return ReplicaFailedEvent
+}
+
+func (e ReplicaFailed) OccurredOn() time.Time {
+	return e.OccurredAt
+}
+
+func NewReplicaFailed(targetID string, targetName string, backupID string, errorMessage string) ReplicaFailed {
+	return ReplicaFailed{
+		TargetID:     targetID,
+		TargetName:   targetName,
+		BackupID:     backupID,
+		OccurredAt:   time.Now(),
+		ErrorMessage: errorMessage,
+	}
+}
+
+// CapacityWarning is raised when the growth of the backups on a backup root
+// is projected to fill it sooner than the warning threshold.
+type CapacityWarning struct {
+	Root          string    `json:"root"`
+	StoragePoolID string    `json:"storage_pool_id,omitempty"`
+	Free          int64     `json:"free"`
+	GrowthPerDay  int64     `json:"growth_per_day"`
+	DaysUntilFull int       `json:"days_until_full"`
+	FullAt        time.Time `json:"full_at"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+func (e CapacityWarning) Name() string {
+	return CapacityWarningEvent
+}
+
+func (e CapacityWarning) OccurredOn() time.Time {
+	return e.OccurredAt
+}
+
+func NewCapacityWarning(root string, storagePoolID string, free int64, growthPerDay int64, daysUntilFull int, fullAt time.Time) CapacityWarning {
+	return CapacityWarning{
+		Root:          root,
+		StoragePoolID: storagePoolID,
+		Free:          free,
+		GrowthPerDay:  growthPerDay,
+		DaysUntilFull: daysUntilFull,
+		FullAt:        fullAt,
+		OccurredAt:    time.Now(),
+	}
+}
+
+// BackupAnomaly is raised when a completed run stands out from the runs
+// before it. Kind is "shrink" when the snapshot lost Ratio of its usual size,
+// Baseline bytes, and "churn" when the run changed Ratio of its files against
+// a usual Baseline. Held reports whether the backup was put under legal hold.
+type BackupAnomaly struct {
+	BackupID     string    `json:"backup_id"`
+	HostID       string    `json:"host_id"`
+	HostName     string    `json:"host_name"`
+	SourcePath   string    `json:"source_path"`
+	Kind         string    `json:"kind"`
+	Ratio        float64   `json:"ratio"`
+	Baseline     float64   `json:"baseline"`
+	Size         int64     `json:"size"`
+	Files        int64     `json:"files"`
+	ChangedFiles int64     `json:"changed_files"`
+	Held         bool      `json:"held"`
+	OccurredAt   time.Time `json:"occurred_at"`
+}
+
+func (e BackupAnomaly) Name() string {
+	return BackupAnomalyEvent
+}
+
+func (e BackupAnomaly) OccurredOn() time.Time {
+	return e.OccurredAt
+}
+
+func NewBackupAnomaly(backupID string, hostID string, hostName string, sourcePath string, kind string, ratio float64, baseline float64, size int64, files int64, changedFiles int64, held bool) BackupAnomaly {
+	return BackupAnomaly{
+		BackupID:     backupID,
+		HostID:       hostID,
+		HostName:     hostName,
+		SourcePath:   sourcePath,
+		Kind:         kind,
+		Ratio:        ratio,
+		Baseline:     baseline,
+		Size:         size,
+		Files:        files,
+		ChangedFiles: changedFiles,
+		Held:         held,
+		OccurredAt:   time.Now(),
+	}
+}
+
+// OrphanedDataFound is raised when a scan of the storage roots finds data no
+// backup references, left behind by deleted backups or changed destinations.
+type OrphanedDataFound struct {
+	Count      int       `json:"count"`
+	Size       int64     `json:"size"`
+	Roots      []string  `json:"roots"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+func (e OrphanedDataFound) Name() string {
+	return OrphanedDataFoundEvent
+}
+
+func (e OrphanedDataFound) OccurredOn() time.Time {
+	return e.OccurredAt
+}
+
+func NewOrphanedDataFound(count int, size int64, roots []string) OrphanedDataFound {
+	return OrphanedDataFound{
+		Count:      count,
+		Size:       size,
+		Roots:      roots,
+		OccurredAt: time.Now(),
+	}
+}
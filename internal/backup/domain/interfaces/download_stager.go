@@ -0,0 +1,23 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+)
+
+// StagedDownload is an archive streamed by a worker and kept on the server,
+// so it can be served with range requests and resumed.
+type StagedDownload struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// DownloadStager receives archives streamed by workers and keeps them for a
+// while under a key that identifies their content.
+type DownloadStager interface {
+	// Stage returns the download staged under key. When none is staged or in
+	// progress, it calls start with the name of the stream the worker must
+	// write to. It waits until the worker has streamed the whole archive.
+	Stage(ctx context.Context, key string, start func(ctx context.Context, stream string) error) (*StagedDownload, error)
+}
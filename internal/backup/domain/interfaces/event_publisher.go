@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"context"
+
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+)
+
+// EventPublisher publishes domain events to whoever listens for them.
+type EventPublisher interface {
+	Publish(ctx context.Context, event shared.DomainEvent) error
+}
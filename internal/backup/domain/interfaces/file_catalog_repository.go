@@ -0,0 +1,30 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+)
+
+type FileCatalogRepository interface {
+	// SaveBatch upserts entries, which may span several snapshots.
+	SaveBatch(ctx context.Context, entries []*entities.CatalogEntry) error
+	// DeleteSnapshot removes every entry of a snapshot.
+	DeleteSnapshot(ctx context.Context, backupID valueobjects.BackupID, snapshot string) error
+	// Search returns one page of entries matching the filter and the total
+	// number of matches.
+	Search(ctx context.Context, filter valueobjects.CatalogFilter) ([]*entities.CatalogEntry, int, error)
+
+	// SaveContents adds file text to the content index, keyed by backup and
+	// hash. Text already indexed is kept.
+	SaveContents(ctx context.Context, contents []*entities.FileContent) error
+	// PruneContents drops the indexed text of a backup that no catalog entry
+	// refers to anymore.
+	PruneContents(ctx context.Context, backupID valueobjects.BackupID) error
+	// DeleteContents drops all indexed text of a backup.
+	DeleteContents(ctx context.Context, backupID valueobjects.BackupID) error
+	// SearchContents returns one page of cataloged files whose text matches
+	// the filter, one per distinct version, and the total number of matches.
+	SearchContents(ctx context.Context, filter valueobjects.ContentFilter) ([]*entities.ContentMatch, int, error)
+}
@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+)
+
+type LegalHoldEventRepository interface {
+	Save(ctx context.Context, event *entities.LegalHoldEvent) error
+	FindByTarget(ctx context.Context, scope entities.LegalHoldScope, targetID string) ([]*entities.LegalHoldEvent, error)
+}
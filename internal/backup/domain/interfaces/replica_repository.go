@@ -0,0 +1,28 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+)
+
+type ReplicaTargetRepository interface {
+	Save(ctx context.Context, target *entities.ReplicaTarget) error
+	FindByID(ctx context.Context, id uuid.UUID) (*entities.ReplicaTarget, error)
+	FindAll(ctx context.Context) ([]*entities.ReplicaTarget, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// ReplicaSnapshotRepository keeps which snapshots each replica target holds.
+type ReplicaSnapshotRepository interface {
+	// Save records a snapshot on a target, replacing an earlier record of it.
+	Save(ctx context.Context, snapshot *entities.ReplicaSnapshot) error
+	// FindByBackupID returns the replicated snapshots of a backup on every
+	// target, ordered by target and snapshot name.
+	FindByBackupID(ctx context.Context, backupID valueobjects.BackupID) ([]*entities.ReplicaSnapshot, error)
+	// Delete forgets a snapshot the target no longer holds.
+	Delete(ctx context.Context, targetID uuid.UUID, backupID valueobjects.BackupID, snapshot string) error
+	DeleteByTarget(ctx context.Context, targetID uuid.UUID) error
+}
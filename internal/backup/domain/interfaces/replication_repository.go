@@ -0,0 +1,25 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+)
+
+type ReplicationJobRepository interface {
+	Save(ctx context.Context, job *entities.ReplicationJob) error
+	FindByID(ctx context.Context, id uuid.UUID) (*entities.ReplicationJob, error)
+	FindAll(ctx context.Context) ([]*entities.ReplicationJob, error)
+	// FindDue returns the enabled jobs whose next run is at or before now.
+	FindDue(ctx context.Context, now time.Time) ([]*entities.ReplicationJob, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type ReplicationRunRepository interface {
+	Save(ctx context.Context, run *entities.ReplicationRun) error
+	FindByTaskID(ctx context.Context, taskID string) (*entities.ReplicationRun, error)
+	// FindByJobID returns the latest runs of a job, newest first.
+	FindByJobID(ctx context.Context, jobID uuid.UUID, limit int) ([]*entities.ReplicationRun, error)
+}
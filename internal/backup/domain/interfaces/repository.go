@@ -20,11 +20,22 @@ type BackupRepository interface {
 
 type TaskPublisher interface {
 	PublishMeasureTask(ctx context.Context, hostID string, path string) (string, error)
-	PublishSearchTask(ctx context.Context, pattern string) (string, error)
+	PublishCatalogTask(ctx context.Context, backup *entities.Backup, snapshot string) (string, error)
 	Publish(ctx context.Context, backup *entities.Backup) error
-	PublishRestoreTask(ctx context.Context, backup *entities.Backup, path string, restoreAddr string, restoreToken string) (string, error)
+	PublishRestoreTask(ctx context.Context, backup *entities.Backup, path string, archivePath string, replica *valueobjects.ReplicaLocation, cold *valueobjects.ColdLocation, restoreAddr string, restoreToken string, restoreFingerprint string) (string, error)
 	PublishListFilesTask(ctx context.Context, path string) (string, error)
-	PublishRemoteRestoreTask(ctx context.Context, backup *entities.Backup, path string, targetHost *entities.Host, targetPath string) (string, error)
+	PublishListArchiveTask(ctx context.Context, backupID string, archive string, archivePath string) (string, error)
+	PublishListSnapshotsTask(ctx context.Context, path string) (string, error)
+	PublishFileVersionsTask(ctx context.Context, path string, filePath string) (string, error)
+	PublishDiffSnapshotsTask(ctx context.Context, path string, from string, to string) (string, error)
+	PublishRemoteRestoreTask(ctx context.Context, backup *entities.Backup, path string, archivePath string, replica *valueobjects.ReplicaLocation, cold *valueobjects.ColdLocation, targetHost *entities.Host, targetPath string, options valueobjects.RestoreOptions, taskID string) (string, error)
+	PublishDownloadTask(ctx context.Context, backup *entities.Backup, path string, archivePath string, replica *valueobjects.ReplicaLocation, cold *valueobjects.ColdLocation, format valueobjects.DownloadFormat, stream string) (string, error)
+	PublishReplicateTask(ctx context.Context, backup *entities.Backup, endpoint valueobjects.ReplicaEndpoint, replicated []string, retention int) (string, error)
+	PublishDiskUsageTask(ctx context.Context, path string) (string, error)
+	PublishMigrateStorageTask(ctx context.Context, backup *entities.Backup, root string, poolID string) (string, error)
+	PublishTierTask(ctx context.Context, backup *entities.Backup, policy valueobjects.TieringPolicy, coldRoot string, replicated []string) (string, error)
+	PublishDeleteDataTask(ctx context.Context, backupID string, root string, path string, cold []valueobjects.ColdLocation) (string, error)
+	PublishScanOrphansTask(ctx context.Context, roots []string, referenced []string) (string, error)
 }
 
 type ResultStore interface {
@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+)
+
+// SizeHistoryRepository keeps the size samples of backup runs. Every finder
+// returns the samples taken at or after since, oldest first.
+type SizeHistoryRepository interface {
+	Save(ctx context.Context, sample *entities.SizeSample) error
+	FindByBackupID(ctx context.Context, backupID valueobjects.BackupID, since time.Time) ([]*entities.SizeSample, error)
+	FindByHostID(ctx context.Context, hostID entities.HostID, since time.Time) ([]*entities.SizeSample, error)
+	FindSince(ctx context.Context, since time.Time) ([]*entities.SizeSample, error)
+}
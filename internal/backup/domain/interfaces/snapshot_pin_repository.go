@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+)
+
+type SnapshotPinRepository interface {
+	Save(ctx context.Context, pin *entities.SnapshotPin) error
+	FindByBackupID(ctx context.Context, backupID valueobjects.BackupID) ([]*entities.SnapshotPin, error)
+	Delete(ctx context.Context, backupID valueobjects.BackupID, snapshot string) error
+}
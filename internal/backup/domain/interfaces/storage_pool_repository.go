@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+)
+
+type StoragePoolRepository interface {
+	Save(ctx context.Context, pool *entities.StoragePool) error
+	FindByID(ctx context.Context, id uuid.UUID) (*entities.StoragePool, error)
+	// FindAll returns the pools ordered by name.
+	FindAll(ctx context.Context) ([]*entities.StoragePool, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
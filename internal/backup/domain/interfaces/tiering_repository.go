@@ -0,0 +1,31 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+)
+
+// TieringPolicyRepository keeps the tiering policy of the backups that have
+// one.
+type TieringPolicyRepository interface {
+	Save(ctx context.Context, backupID valueobjects.BackupID, policy valueobjects.TieringPolicy) error
+	// FindByBackupID returns shared.ErrNotFound when the backup has no policy.
+	FindByBackupID(ctx context.Context, backupID valueobjects.BackupID) (*valueobjects.TieringPolicy, error)
+	// FindAll returns the policies by the ID of their backup.
+	FindAll(ctx context.Context) (map[string]valueobjects.TieringPolicy, error)
+	Delete(ctx context.Context, backupID valueobjects.BackupID) error
+}
+
+// ArchivedSnapshotRepository keeps which snapshots tiering moved off the
+// storage of their backup.
+type ArchivedSnapshotRepository interface {
+	// Save records an archived snapshot, replacing an earlier record of it.
+	Save(ctx context.Context, snapshot *entities.ArchivedSnapshot) error
+	// FindByBackupID returns the archived snapshots of a backup ordered by
+	// name.
+	FindByBackupID(ctx context.Context, backupID valueobjects.BackupID) ([]*entities.ArchivedSnapshot, error)
+	// Delete forgets a snapshot that is gone from its tier.
+	Delete(ctx context.Context, backupID valueobjects.BackupID, snapshot string) error
+}
@@ -3,11 +3,23 @@ package interfaces
 import (
 	"context"
 
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
 	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
 )
 
 // WorkerQueryBus defines the interface for querying workers synchronously (request-response over messaging)
 type WorkerQueryBus interface {
-	SearchFiles(ctx context.Context, pattern string) (workerDto.SearchFilesResult, error)
 	ListFiles(ctx context.Context, path string) (workerDto.ListFilesResult, error)
+	ListArchive(ctx context.Context, backupID string, archive string, archivePath string) (workerDto.ListFilesResult, error)
+	ListSnapshots(ctx context.Context, path string) (workerDto.ListSnapshotsResult, error)
+	FileVersions(ctx context.Context, path string, filePath string) (workerDto.FileVersionsResult, error)
+	DiffSnapshots(ctx context.Context, path string, from string, to string) (workerDto.SnapshotDiffResult, error)
+	PreviewRemoteRestore(ctx context.Context, backup *entities.Backup, path string, archivePath string, replica *valueobjects.ReplicaLocation, cold *valueobjects.ColdLocation, targetHost *entities.Host, targetPath string, options valueobjects.RestoreOptions) (workerDto.RestorePreviewResult, error)
+	// DiskUsage returns the usage of the filesystem holding path, the backup
+	// root of the worker when empty.
+	DiskUsage(ctx context.Context, path string) (workerDto.DiskUsageResult, error)
+	// ScanOrphans lists what lies under the storage roots that none of the
+	// referenced directories holds.
+	ScanOrphans(ctx context.Context, roots []string, referenced []string) (workerDto.OrphanScanResult, error)
 }
@@ -0,0 +1,147 @@
+package valueobjects
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCatalogFilter = errors.New("invalid catalog filter")
+
+const (
+	DefaultCatalogPageSize = 100
+	MaxCatalogPageSize     = 1000
+)
+
+// CatalogFilter selects entries of the file catalog. Glob is matched against
+// the file name like `find -name`; Regex is matched against the path inside
+// the snapshot. A nil BackupIDs matches every backup. Page is 1-based.
+type CatalogFilter struct {
+	BackupIDs      []BackupID
+	Glob           string
+	Regex          string
+	ModifiedAfter  *time.Time
+	ModifiedBefore *time.Time
+	MinSize        *int64
+	MaxSize        *int64
+	Page           int
+	PageSize       int
+
+	globRe  *regexp.Regexp
+	regexRe *regexp.Regexp
+}
+
+// Normalize validates the patterns and fills in the paging defaults. Only a
+// normalized filter can be matched in memory.
+func (f CatalogFilter) Normalize() (CatalogFilter, error) {
+	if f.Glob != "" {
+		if _, err := path.Match(f.Glob, ""); err != nil {
+			return f, fmt.Errorf("%w: bad glob %q", ErrInvalidCatalogFilter, f.Glob)
+		}
+		f.globRe = regexp.MustCompile(GlobToRegex(f.Glob))
+	}
+	if f.Regex != "" {
+		re, err := regexp.Compile(f.Regex)
+		if err != nil {
+			return f, fmt.Errorf("%w: bad regex: %v", ErrInvalidCatalogFilter, err)
+		}
+		f.regexRe = re
+	}
+	if f.MinSize != nil && f.MaxSize != nil && *f.MinSize > *f.MaxSize {
+		return f, fmt.Errorf("%w: min size exceeds max size", ErrInvalidCatalogFilter)
+	}
+	if f.ModifiedAfter != nil && f.ModifiedBefore != nil && f.ModifiedAfter.After(*f.ModifiedBefore) {
+		return f, fmt.Errorf("%w: date range is reversed", ErrInvalidCatalogFilter)
+	}
+
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	if f.PageSize < 1 {
+		f.PageSize = DefaultCatalogPageSize
+	}
+	if f.PageSize > MaxCatalogPageSize {
+		f.PageSize = MaxCatalogPageSize
+	}
+	return f, nil
+}
+
+// Offset is the number of matches skipped before the current page.
+func (f CatalogFilter) Offset() int {
+	return (f.Page - 1) * f.PageSize
+}
+
+// Matches reports whether a file passes the filter.
+func (f CatalogFilter) Matches(backupID BackupID, filePath string, size int64, modTime time.Time) bool {
+	if f.BackupIDs != nil {
+		found := false
+		for _, id := range f.BackupIDs {
+			if id.Equals(backupID) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.globRe != nil && !f.globRe.MatchString(path.Base(filePath)) {
+		return false
+	}
+	if f.regexRe != nil && !f.regexRe.MatchString(filePath) {
+		return false
+	}
+	if f.ModifiedAfter != nil && modTime.Before(*f.ModifiedAfter) {
+		return false
+	}
+	if f.ModifiedBefore != nil && modTime.After(*f.ModifiedBefore) {
+		return false
+	}
+	if f.MinSize != nil && size < *f.MinSize {
+		return false
+	}
+	if f.MaxSize != nil && size > *f.MaxSize {
+		return false
+	}
+	return true
+}
+
+// GlobToRegex translates a shell glob into an anchored regular expression
+// that both Go and PostgreSQL understand.
+func GlobToRegex(glob string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+			}
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
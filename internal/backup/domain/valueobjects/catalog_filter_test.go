@@ -0,0 +1,86 @@
+package valueobjects
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGlobToRegex(t *testing.T) {
+	tests := []struct {
+		glob    string
+		match   []string
+		noMatch []string
+	}{
+		{"*.conf", []string{"nginx.conf", ".conf"}, []string{"nginx.conf.bak", "nginxconf"}},
+		{"file?.txt", []string{"file1.txt"}, []string{"file10.txt", "file.txt"}},
+		{"[a-c]*", []string{"apple", "cherry"}, []string{"date"}},
+		{"[!a-c]*", []string{"date"}, []string{"apple"}},
+		{"a+b(1).txt", []string{"a+b(1).txt"}, []string{"aab1.txt"}},
+		{`\*.txt`, []string{"*.txt"}, []string{"a.txt"}},
+		{"[abc", []string{"[abc"}, []string{"a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.glob, func(t *testing.T) {
+			re := regexp.MustCompile(GlobToRegex(tt.glob))
+			for _, name := range tt.match {
+				assert.True(t, re.MatchString(name), name)
+			}
+			for _, name := range tt.noMatch {
+				assert.False(t, re.MatchString(name), name)
+			}
+		})
+	}
+}
+
+func TestCatalogFilter_Normalize(t *testing.T) {
+	f, err := CatalogFilter{}.Normalize()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, f.Page)
+	assert.Equal(t, DefaultCatalogPageSize, f.PageSize)
+	assert.Equal(t, 0, f.Offset())
+
+	f, err = CatalogFilter{Page: 3, PageSize: 5000}.Normalize()
+	assert.NoError(t, err)
+	assert.Equal(t, MaxCatalogPageSize, f.PageSize)
+	assert.Equal(t, 2*MaxCatalogPageSize, f.Offset())
+
+	_, err = CatalogFilter{Regex: "("}.Normalize()
+	assert.ErrorIs(t, err, ErrInvalidCatalogFilter)
+
+	_, err = CatalogFilter{Glob: "[a-"}.Normalize()
+	assert.ErrorIs(t, err, ErrInvalidCatalogFilter)
+
+	minSize, maxSize := int64(10), int64(5)
+	_, err = CatalogFilter{MinSize: &minSize, MaxSize: &maxSize}.Normalize()
+	assert.ErrorIs(t, err, ErrInvalidCatalogFilter)
+}
+
+func TestCatalogFilter_Matches(t *testing.T) {
+	backupID := NewBackupID()
+	other := NewBackupID()
+	modTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	after := modTime.Add(-time.Hour)
+	before := modTime.Add(time.Hour)
+	minSize := int64(100)
+
+	f, err := CatalogFilter{
+		BackupIDs:      []BackupID{backupID},
+		Glob:           "*.conf",
+		Regex:          "^etc/",
+		ModifiedAfter:  &after,
+		ModifiedBefore: &before,
+		MinSize:        &minSize,
+	}.Normalize()
+	assert.NoError(t, err)
+
+	assert.True(t, f.Matches(backupID, "etc/nginx/nginx.conf", 200, modTime))
+	assert.False(t, f.Matches(other, "etc/nginx/nginx.conf", 200, modTime))
+	assert.False(t, f.Matches(backupID, "etc/nginx/nginx.conf.bak", 200, modTime))
+	assert.False(t, f.Matches(backupID, "srv/nginx.conf", 200, modTime))
+	assert.False(t, f.Matches(backupID, "etc/nginx/nginx.conf", 50, modTime))
+	assert.False(t, f.Matches(backupID, "etc/nginx/nginx.conf", 200, before.Add(time.Second)))
+}
@@ -0,0 +1,80 @@
+package valueobjects
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+var ErrInvalidContentQuery = errors.New("invalid content query")
+
+const (
+	// MinContentQueryLength is the shortest query the trigram index can
+	// answer without scanning every file.
+	MinContentQueryLength = 3
+
+	DefaultContentPageSize = 20
+	MaxContentPageSize     = 100
+)
+
+// ContentFilter selects files of the content index whose text contains
+// Query, ignoring case. A nil BackupIDs matches every backup. Page is
+// 1-based.
+type ContentFilter struct {
+	Query     string
+	BackupIDs []BackupID
+	Page      int
+	PageSize  int
+}
+
+// Normalize validates the query and fills in the paging defaults.
+func (f ContentFilter) Normalize() (ContentFilter, error) {
+	if utf8.RuneCountInString(strings.TrimSpace(f.Query)) < MinContentQueryLength {
+		return f, fmt.Errorf("%w: the query needs at least %d characters", ErrInvalidContentQuery, MinContentQueryLength)
+	}
+	if strings.ContainsAny(f.Query, "\n\x00") {
+		return f, fmt.Errorf("%w: the query must be a single line", ErrInvalidContentQuery)
+	}
+
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	if f.PageSize < 1 {
+		f.PageSize = DefaultContentPageSize
+	}
+	if f.PageSize > MaxContentPageSize {
+		f.PageSize = MaxContentPageSize
+	}
+	return f, nil
+}
+
+// Offset is the number of matches skipped before the current page.
+func (f ContentFilter) Offset() int {
+	return (f.Page - 1) * f.PageSize
+}
+
+// MatchesBackup reports whether files of the backup are searched.
+func (f ContentFilter) MatchesBackup(id BackupID) bool {
+	if f.BackupIDs == nil {
+		return true
+	}
+	for _, b := range f.BackupIDs {
+		if b.Equals(id) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesContent reports whether content contains the query, ignoring case.
+func (f ContentFilter) MatchesContent(content string) bool {
+	return strings.Contains(strings.ToLower(content), strings.ToLower(f.Query))
+}
+
+// LikePattern returns the query as an ILIKE pattern matching it anywhere,
+// with the LIKE wildcards escaped.
+func (f ContentFilter) LikePattern() string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(f.Query)
+	return "%" + escaped + "%"
+}
@@ -0,0 +1,32 @@
+package valueobjects
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentFilter_Normalize(t *testing.T) {
+	for _, query := range []string{"", "ab", "  ab  ", "two\nlines", "nul\x00byte"} {
+		_, err := ContentFilter{Query: query}.Normalize()
+		assert.ErrorIs(t, err, ErrInvalidContentQuery, query)
+	}
+
+	f, err := ContentFilter{Query: "listen", PageSize: 1000}.Normalize()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, f.Page)
+	assert.Equal(t, MaxContentPageSize, f.PageSize)
+	assert.Equal(t, 0, f.Offset())
+
+	f, err = ContentFilter{Query: "listen", Page: 3}.Normalize()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultContentPageSize, f.PageSize)
+	assert.Equal(t, 2*DefaultContentPageSize, f.Offset())
+}
+
+func TestContentFilter_LikePattern(t *testing.T) {
+	f := ContentFilter{Query: `100%_done\`}
+	assert.Equal(t, `%100\%\_done\\%`, f.LikePattern())
+	assert.True(t, f.MatchesContent("status: 100%_DONE\\ ok"))
+	assert.False(t, f.MatchesContent("100 done"))
+}
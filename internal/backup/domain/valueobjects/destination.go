@@ -0,0 +1,26 @@
+package valueobjects
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidDestination is returned for a backup destination that does not
+// name a directory of its own below the directory of its host.
+var ErrInvalidDestination = errors.New("invalid backup destination")
+
+// ValidateDestination checks that a destination names a directory below the
+// directory of its host: it may not be empty, nor hold "." or "..".
+func ValidateDestination(destination string) error {
+	trimmed := strings.Trim(destination, "/")
+	if trimmed == "" {
+		return fmt.Errorf("%w: it is empty", ErrInvalidDestination)
+	}
+	for _, part := range strings.Split(trimmed, "/") {
+		if part == "." || part == ".." {
+			return fmt.Errorf("%w: %q may not contain . or ..", ErrInvalidDestination, destination)
+		}
+	}
+	return nil
+}
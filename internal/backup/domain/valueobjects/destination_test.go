@@ -0,0 +1,16 @@
+package valueobjects
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateDestination(t *testing.T) {
+	for _, valid := range []string{"app", "/app", "web/app/", "app.v2"} {
+		assert.NoError(t, ValidateDestination(valid), valid)
+	}
+	for _, invalid := range []string{"", "/", ".", "..", "app/..", "./app", "a/../b"} {
+		assert.ErrorIs(t, ValidateDestination(invalid), ErrInvalidDestination, invalid)
+	}
+}
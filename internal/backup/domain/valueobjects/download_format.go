@@ -0,0 +1,37 @@
+package valueobjects
+
+import "errors"
+
+var ErrInvalidDownloadFormat = errors.New("invalid download format: use tar.gz or zip")
+
+// DownloadFormat is the archive format a restore download is served in.
+type DownloadFormat string
+
+const (
+	DownloadFormatTarGz DownloadFormat = "tar.gz"
+	DownloadFormatZip   DownloadFormat = "zip"
+)
+
+// ParseDownloadFormat parses a download format, defaulting to tar.gz.
+func ParseDownloadFormat(value string) (DownloadFormat, error) {
+	switch value {
+	case "", string(DownloadFormatTarGz), "tgz":
+		return DownloadFormatTarGz, nil
+	case string(DownloadFormatZip):
+		return DownloadFormatZip, nil
+	}
+	return "", ErrInvalidDownloadFormat
+}
+
+// Extension returns the file name extension of the format, without the dot.
+func (f DownloadFormat) Extension() string {
+	return string(f)
+}
+
+// ContentType returns the media type the format is served with.
+func (f DownloadFormat) ContentType() string {
+	if f == DownloadFormatZip {
+		return "application/zip"
+	}
+	return "application/gzip"
+}
@@ -0,0 +1,124 @@
+package valueobjects
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+var (
+	ErrInvalidGrowthModel = errors.New("growth model must be linear or seasonal")
+	ErrInvalidHistoryDays = errors.New("size history must cover at least one day")
+)
+
+// GrowthModel is how the growth of backups is projected forward.
+type GrowthModel string
+
+const (
+	// GrowthModelLinear fits a straight line through the daily totals.
+	GrowthModelLinear GrowthModel = "linear"
+	// GrowthModelSeasonal repeats the average growth of each day of the week,
+	// for backups that grow in weekly cycles.
+	GrowthModelSeasonal GrowthModel = "seasonal"
+)
+
+// MaxForecastDays is how far ahead growth is projected. A root that does not
+// fill within it is reported as not filling.
+const MaxForecastDays = 3650
+
+// seasonalMinDays is the history the seasonal model needs: two of each day
+// of the week. With less it falls back to the linear model.
+const seasonalMinDays = 15
+
+// ParseGrowthModel parses a growth model, linear when empty.
+func ParseGrowthModel(s string) (GrowthModel, error) {
+	switch GrowthModel(s) {
+	case "", GrowthModelLinear:
+		return GrowthModelLinear, nil
+	case GrowthModelSeasonal:
+		return GrowthModelSeasonal, nil
+	}
+	return "", ErrInvalidGrowthModel
+}
+
+// GrowthForecast is the projected growth of the data on a backup root.
+// DaysUntilFull is nil when the data does not grow or would not fill the free
+// space within MaxForecastDays.
+type GrowthForecast struct {
+	Model         GrowthModel
+	GrowthPerDay  float64
+	DaysUntilFull *int
+}
+
+// ForecastGrowth projects daily totals forward until they have grown by free
+// bytes. The totals are one per consecutive day, oldest first, the first one
+// taken on start.
+func ForecastGrowth(daily []int64, start time.Time, free int64, model GrowthModel) GrowthForecast {
+	if model == GrowthModelSeasonal && len(daily) >= seasonalMinDays {
+		return forecastSeasonal(daily, start, free)
+	}
+	return forecastLinear(daily, free)
+}
+
+func forecastLinear(daily []int64, free int64) GrowthForecast {
+	forecast := GrowthForecast{Model: GrowthModelLinear}
+	if len(daily) < 2 {
+		return forecast
+	}
+
+	// Least squares slope of the totals over the day number.
+	n := float64(len(daily))
+	var sumX, sumY, sumXY, sumXX float64
+	for i, total := range daily {
+		x, y := float64(i), float64(total)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	forecast.GrowthPerDay = (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+
+	if forecast.GrowthPerDay <= 0 {
+		return forecast
+	}
+	days := int(math.Ceil(float64(max(free, 0)) / forecast.GrowthPerDay))
+	if days <= MaxForecastDays {
+		forecast.DaysUntilFull = &days
+	}
+	return forecast
+}
+
+func forecastSeasonal(daily []int64, start time.Time, free int64) GrowthForecast {
+	forecast := GrowthForecast{Model: GrowthModelSeasonal}
+
+	var sums [7]float64
+	var counts [7]int
+	for i := 1; i < len(daily); i++ {
+		weekday := start.AddDate(0, 0, i).Weekday()
+		sums[weekday] += float64(daily[i] - daily[i-1])
+		counts[weekday]++
+	}
+	var perWeekday [7]float64
+	var week float64
+	for d := range perWeekday {
+		if counts[d] > 0 {
+			perWeekday[d] = sums[d] / float64(counts[d])
+		}
+		week += perWeekday[d]
+	}
+	forecast.GrowthPerDay = week / 7
+
+	if week <= 0 {
+		return forecast
+	}
+	last := start.AddDate(0, 0, len(daily)-1)
+	var grown float64
+	for days := 0; days <= MaxForecastDays; days++ {
+		if grown >= float64(free) {
+			forecast.DaysUntilFull = &days
+			return forecast
+		}
+		grown += perWeekday[last.AddDate(0, 0, days+1).Weekday()]
+	}
+	return forecast
+}
@@ -0,0 +1,62 @@
+package valueobjects
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGrowthModel(t *testing.T) {
+	model, err := ParseGrowthModel("")
+	assert.NoError(t, err)
+	assert.Equal(t, GrowthModelLinear, model)
+
+	model, err = ParseGrowthModel("seasonal")
+	assert.NoError(t, err)
+	assert.Equal(t, GrowthModelSeasonal, model)
+
+	_, err = ParseGrowthModel("exponential")
+	assert.ErrorIs(t, err, ErrInvalidGrowthModel)
+}
+
+func TestForecastGrowth_Linear(t *testing.T) {
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	forecast := ForecastGrowth([]int64{1000, 1100, 1200, 1300}, start, 1000, GrowthModelLinear)
+	assert.Equal(t, GrowthModelLinear, forecast.Model)
+	assert.InDelta(t, 100, forecast.GrowthPerDay, 0.001)
+	require.NotNil(t, forecast.DaysUntilFull)
+	assert.Equal(t, 10, *forecast.DaysUntilFull)
+
+	shrinking := ForecastGrowth([]int64{1300, 1200, 1100}, start, 1000, GrowthModelLinear)
+	assert.Nil(t, shrinking.DaysUntilFull)
+
+	slow := ForecastGrowth([]int64{1000, 1001}, start, 1<<40, GrowthModelLinear)
+	assert.Nil(t, slow.DaysUntilFull, "beyond the forecast horizon")
+
+	assert.Nil(t, ForecastGrowth([]int64{1000}, start, 1000, GrowthModelLinear).DaysUntilFull)
+}
+
+func TestForecastGrowth_Seasonal(t *testing.T) {
+	// Starts on a Monday; only the Saturday full runs grow by 700.
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	daily := make([]int64, 21)
+	for i := 1; i < len(daily); i++ {
+		daily[i] = daily[i-1]
+		if start.AddDate(0, 0, i).Weekday() == time.Saturday {
+			daily[i] += 700
+		}
+	}
+
+	forecast := ForecastGrowth(daily, start, 1400, GrowthModelSeasonal)
+	assert.Equal(t, GrowthModelSeasonal, forecast.Model)
+	assert.InDelta(t, 100, forecast.GrowthPerDay, 0.001)
+	require.NotNil(t, forecast.DaysUntilFull)
+	// The history ends on a Sunday: full after the second Saturday from now.
+	assert.Equal(t, 13, *forecast.DaysUntilFull)
+
+	short := ForecastGrowth(daily[:7], start, 1400, GrowthModelSeasonal)
+	assert.Equal(t, GrowthModelLinear, short.Model, "falls back without two weeks of history")
+}
@@ -0,0 +1,71 @@
+package valueobjects
+
+import "errors"
+
+var (
+	ErrInvalidQuota  = errors.New("quota limits cannot be negative")
+	ErrQuotaExceeded = errors.New("storage quota exceeded")
+)
+
+// Quota caps the space taken by the backups of a host or by a single backup.
+// Bytes caps what they store on disk and SnapshotBytes what a single run may
+// add. Zero leaves either unlimited.
+type Quota struct {
+	Bytes         int64 `json:"bytes"`
+	SnapshotBytes int64 `json:"snapshot_bytes"`
+}
+
+func NewQuota(bytes, snapshotBytes int64) (Quota, error) {
+	if bytes < 0 || snapshotBytes < 0 {
+		return Quota{}, ErrInvalidQuota
+	}
+	return Quota{Bytes: bytes, SnapshotBytes: snapshotBytes}, nil
+}
+
+// IsZero reports whether the quota sets no limit at all.
+func (q Quota) IsZero() bool {
+	return q.Bytes == 0 && q.SnapshotBytes == 0
+}
+
+// QuotaScope is what a quota is set on.
+type QuotaScope string
+
+const (
+	QuotaScopeHost   QuotaScope = "host"
+	QuotaScopeBackup QuotaScope = "backup"
+)
+
+// QuotaBudget is how many bytes a backup run may add before it breaks a
+// quota, and the quota that limits it most. Scope is empty when no quota
+// applies. A budget of zero or less means the quota is already used up.
+type QuotaBudget struct {
+	Bytes int64      `json:"bytes"`
+	Scope QuotaScope `json:"scope,omitempty"`
+}
+
+// Limited reports whether any quota applies.
+func (b QuotaBudget) Limited() bool {
+	return b.Scope != ""
+}
+
+// Exhausted reports whether a quota is used up before the run starts.
+func (b QuotaBudget) Exhausted() bool {
+	return b.Limited() && b.Bytes <= 0
+}
+
+// Tighten narrows the budget to what a quota leaves with stored bytes
+// already under it.
+func (b QuotaBudget) Tighten(q Quota, stored int64, scope QuotaScope) QuotaBudget {
+	limit := func(bytes int64) {
+		if !b.Limited() || bytes < b.Bytes {
+			b = QuotaBudget{Bytes: bytes, Scope: scope}
+		}
+	}
+	if q.Bytes > 0 {
+		limit(q.Bytes - stored)
+	}
+	if q.SnapshotBytes > 0 {
+		limit(q.SnapshotBytes)
+	}
+	return b
+}
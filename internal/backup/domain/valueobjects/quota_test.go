@@ -0,0 +1,35 @@
+package valueobjects
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewQuota(t *testing.T) {
+	quota, err := NewQuota(1000, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, Quota{Bytes: 1000, SnapshotBytes: 100}, quota)
+	assert.False(t, quota.IsZero())
+
+	_, err = NewQuota(-1, 0)
+	assert.ErrorIs(t, err, ErrInvalidQuota)
+	assert.True(t, Quota{}.IsZero())
+}
+
+func TestQuotaBudget_Tighten(t *testing.T) {
+	budget := QuotaBudget{}.Tighten(Quota{}, 500, QuotaScopeBackup)
+	assert.False(t, budget.Limited())
+	assert.False(t, budget.Exhausted())
+
+	budget = budget.Tighten(Quota{Bytes: 1000}, 700, QuotaScopeBackup)
+	assert.Equal(t, QuotaBudget{Bytes: 300, Scope: QuotaScopeBackup}, budget)
+
+	// The snapshot budget is tighter than what the host quota leaves.
+	budget = budget.Tighten(Quota{Bytes: 5000, SnapshotBytes: 200}, 1000, QuotaScopeHost)
+	assert.Equal(t, QuotaBudget{Bytes: 200, Scope: QuotaScopeHost}, budget)
+
+	budget = budget.Tighten(Quota{Bytes: 1000}, 1200, QuotaScopeHost)
+	assert.Equal(t, int64(-200), budget.Bytes)
+	assert.True(t, budget.Exhausted())
+}
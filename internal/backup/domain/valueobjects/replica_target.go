@@ -0,0 +1,141 @@
+package valueobjects
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// ErrInvalidReplicaTarget is returned for a replica target missing a
+// required setting.
+var ErrInvalidReplicaTarget = errors.New("invalid replica target")
+
+// ErrNoReplicaTarget is returned when no enabled replica target covers a
+// backup.
+var ErrNoReplicaTarget = errors.New("no enabled replica target covers the backup")
+
+// ReplicaTargetKind is the kind of storage a replica target copies
+// snapshots to.
+type ReplicaTargetKind string
+
+const (
+	// ReplicaTargetS3 stores snapshots in an S3-compatible bucket.
+	ReplicaTargetS3 ReplicaTargetKind = "s3"
+	// ReplicaTargetRsync mirrors snapshots to a directory of a remote host
+	// over SSH.
+	ReplicaTargetRsync ReplicaTargetKind = "rsync"
+)
+
+// ParseReplicaTargetKind parses the kind of a replica target.
+func ParseReplicaTargetKind(value string) (ReplicaTargetKind, error) {
+	switch ReplicaTargetKind(value) {
+	case ReplicaTargetS3, ReplicaTargetRsync:
+		return ReplicaTargetKind(value), nil
+	}
+	return "", fmt.Errorf("%w: unknown kind %q", ErrInvalidReplicaTarget, value)
+}
+
+// S3Config locates the bucket of an S3 replica target. Endpoint is the base
+// URL of the service, such as https://s3.eu-west-1.amazonaws.com or
+// http://minio:9000; PathStyle addresses the bucket in the path, which MinIO
+// needs. Prefix is prepended to every object key.
+type S3Config struct {
+	Endpoint  string `json:"endpoint"`
+	Region    string `json:"region,omitempty"`
+	Bucket    string `json:"bucket"`
+	Prefix    string `json:"prefix,omitempty"`
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+	PathStyle bool   `json:"path_style,omitempty"`
+}
+
+func (c S3Config) Validate() error {
+	endpoint, err := url.Parse(c.Endpoint)
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return fmt.Errorf("%w: endpoint must be an http or https URL", ErrInvalidReplicaTarget)
+	}
+	if c.Bucket == "" {
+		return fmt.Errorf("%w: bucket is required", ErrInvalidReplicaTarget)
+	}
+	if c.AccessKey == "" || c.SecretKey == "" {
+		return fmt.Errorf("%w: access key and secret key are required", ErrInvalidReplicaTarget)
+	}
+	return nil
+}
+
+// KeyPrefix returns the prefix of the keys of a backup in the bucket.
+func (c S3Config) KeyPrefix(backupID string) string {
+	prefix := strings.Trim(c.Prefix, "/")
+	if prefix == "" {
+		return backupID + "/"
+	}
+	return prefix + "/" + backupID + "/"
+}
+
+// RsyncConfig locates the directory of an rsync replica target. The worker
+// logs in as User with its own SSH key, like it does on backed up hosts.
+type RsyncConfig struct {
+	Host string `json:"host"`
+	User string `json:"user"`
+	Port int    `json:"port,omitempty"`
+	Path string `json:"path"`
+}
+
+func (c RsyncConfig) Validate() error {
+	if c.Host == "" || c.User == "" {
+		return fmt.Errorf("%w: host and user are required", ErrInvalidReplicaTarget)
+	}
+	if c.Port < 0 || c.Port > 65535 {
+		return fmt.Errorf("%w: invalid port %d", ErrInvalidReplicaTarget, c.Port)
+	}
+	if !strings.HasPrefix(c.Path, "/") {
+		return fmt.Errorf("%w: path must be absolute", ErrInvalidReplicaTarget)
+	}
+	return nil
+}
+
+// BackupDir returns the directory of a backup on the target.
+func (c RsyncConfig) BackupDir(backupID string) string {
+	return path.Join(c.Path, backupID)
+}
+
+// ReplicaPolicy is how snapshots are copied to a replica target.
+// BandwidthLimit caps the upload rate in KiB/s, 0 for no limit. Retention is
+// how many snapshots of an incremental backup the target keeps, apart from
+// the retention of the backup itself; 0 keeps every snapshot copied.
+type ReplicaPolicy struct {
+	BandwidthLimit int `json:"bandwidth_limit,omitempty"`
+	Retention      int `json:"retention,omitempty"`
+}
+
+func (p ReplicaPolicy) Validate() error {
+	if p.BandwidthLimit < 0 {
+		return fmt.Errorf("%w: bandwidth limit cannot be negative", ErrInvalidReplicaTarget)
+	}
+	if p.Retention < 0 {
+		return fmt.Errorf("%w: retention cannot be negative", ErrInvalidReplicaTarget)
+	}
+	return nil
+}
+
+// ReplicaEndpoint is what a worker needs to reach a replica target.
+type ReplicaEndpoint struct {
+	TargetID       string            `json:"target_id"`
+	Kind           ReplicaTargetKind `json:"kind"`
+	S3             *S3Config         `json:"s3,omitempty"`
+	Rsync          *RsyncConfig      `json:"rsync,omitempty"`
+	BandwidthLimit int               `json:"bandwidth_limit,omitempty"`
+}
+
+// ReplicaLocation is where a restore finds its source on a replica target
+// when the local copy is missing. Path is the source inside the replicated
+// Snapshot, relative to the directory holding the artifacts of the backup:
+// its root, or the parent of the archive of a non-incremental encrypted
+// backup.
+type ReplicaLocation struct {
+	ReplicaEndpoint
+	Snapshot string `json:"snapshot"`
+	Path     string `json:"path"`
+}
@@ -0,0 +1,66 @@
+package valueobjects
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrInvalidConflictPolicy = errors.New("invalid conflict policy: use overwrite, skip, newer or rename")
+
+// ErrInvalidRestoreOptions is returned for options the kind of restore
+// requested does not support.
+var ErrInvalidRestoreOptions = errors.New("invalid restore options")
+
+// ConflictPolicy decides what a restore does with a file that already exists
+// at the target.
+type ConflictPolicy string
+
+const (
+	// ConflictOverwrite replaces the existing file.
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictSkip keeps the existing file.
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictNewer keeps the existing file when it is newer than the
+	// restored one.
+	ConflictNewer ConflictPolicy = "newer"
+	// ConflictRename moves the existing file aside with a suffix.
+	ConflictRename ConflictPolicy = "rename"
+)
+
+// ParseConflictPolicy parses a conflict policy, defaulting to overwrite.
+func ParseConflictPolicy(value string) (ConflictPolicy, error) {
+	switch ConflictPolicy(value) {
+	case "":
+		return ConflictOverwrite, nil
+	case ConflictOverwrite, ConflictSkip, ConflictNewer, ConflictRename:
+		return ConflictPolicy(value), nil
+	}
+	return "", ErrInvalidConflictPolicy
+}
+
+// RestoreOptions controls how a restore treats the target.
+type RestoreOptions struct {
+	Conflict ConflictPolicy `json:"conflict,omitempty"`
+	// Delete mirrors the restored path: files at the target that are not in
+	// the backup are removed, or renamed under ConflictRename.
+	Delete bool `json:"delete,omitempty"`
+	// PreserveOwnership restores the owner and group of each file, which
+	// needs root at the target. Owners are mapped by name unless NumericIDs
+	// is set; NumericIDs implies PreserveOwnership. Remote restores keep
+	// owners and groups by name unless NoOwnership is set, which leaves them
+	// to the target.
+	PreserveOwnership bool `json:"preserve_ownership,omitempty"`
+	NumericIDs        bool `json:"numeric_ids,omitempty"`
+	NoOwnership       bool `json:"no_ownership,omitempty"`
+	// PreserveTimes keeps the modification times of the restored files at a
+	// remote target, which otherwise get the time they were restored at.
+	PreserveTimes bool `json:"preserve_times,omitempty"`
+	// DryRun reports the changes the restore would make without making them.
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// RestoreRenameSuffix is the suffix ConflictRename gives the files a restore
+// started at t moves aside.
+func RestoreRenameSuffix(t time.Time) string {
+	return ".pre-restore-" + t.UTC().Format(SnapshotTimeFormat)
+}
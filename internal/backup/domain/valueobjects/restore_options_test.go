@@ -0,0 +1,30 @@
+package valueobjects
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseConflictPolicy(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected ConflictPolicy
+		err      error
+	}{
+		{"", ConflictOverwrite, nil},
+		{"overwrite", ConflictOverwrite, nil},
+		{"skip", ConflictSkip, nil},
+		{"newer", ConflictNewer, nil},
+		{"rename", ConflictRename, nil},
+		{"merge", "", ErrInvalidConflictPolicy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			policy, err := ParseConflictPolicy(tt.value)
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.expected, policy)
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package valueobjects
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+)
+
+// SnapshotTimeFormat is the name layout of incremental snapshot directories.
+const SnapshotTimeFormat = "2006-01-02_15-04-05"
+
+// ParseSnapshotTime returns the creation time encoded in a snapshot name.
+// Workers name snapshots in their local time, the zone the server shares.
+func ParseSnapshotTime(name string) (time.Time, bool) {
+	t, err := time.ParseInLocation(SnapshotTimeFormat, name, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// EncryptedSnapshotSuffix is appended to snapshots packed by the encryption workflow.
+const EncryptedSnapshotSuffix = ".tar.gz.enc"
+
+// SnapshotNameFromArtifact returns the snapshot an on-disk entry belongs to:
+// either a snapshot directory or the encrypted archive of one.
+func SnapshotNameFromArtifact(entry string, isDir bool) (string, bool) {
+	name := entry
+	if !isDir {
+		if !strings.HasSuffix(entry, EncryptedSnapshotSuffix) {
+			return "", false
+		}
+		name = strings.TrimSuffix(entry, EncryptedSnapshotSuffix)
+	}
+	if _, ok := ParseSnapshotTime(name); !ok {
+		return "", false
+	}
+	return name, true
+}
+
+// RetentionRule identifies the rule of a policy that kept a snapshot.
+type RetentionRule string
+
+const (
+	RetentionRuleLast    RetentionRule = "last"
+	RetentionRuleDaily   RetentionRule = "daily"
+	RetentionRuleWeekly  RetentionRule = "weekly"
+	RetentionRuleMonthly RetentionRule = "monthly"
+	RetentionRuleYearly  RetentionRule = "yearly"
+	// RetentionRuleLatest protects the snapshot the 'latest' link points to.
+	RetentionRuleLatest RetentionRule = "latest"
+	// RetentionRulePinned protects snapshots pinned by a user.
+	RetentionRulePinned RetentionRule = "pinned"
+	// RetentionRuleLegalHold protects everything while a legal hold is active.
+	RetentionRuleLegalHold RetentionRule = "legal_hold"
+)
+
+var ErrInvalidRetentionPolicy = errors.New("invalid retention policy: counts cannot be negative")
+
+// RetentionPolicy is a grandfather-father-son retention policy.
+// KeepLast keeps the newest N snapshots; the other counts keep the newest
+// snapshot of each of the last N days, ISO weeks, months and years.
+// A snapshot is kept as soon as any rule selects it. The zero policy keeps
+// everything.
+type RetentionPolicy struct {
+	KeepLast    int `json:"keep_last"`
+	KeepDaily   int `json:"keep_daily"`
+	KeepWeekly  int `json:"keep_weekly"`
+	KeepMonthly int `json:"keep_monthly"`
+	KeepYearly  int `json:"keep_yearly"`
+}
+
+func NewRetentionPolicy(keepLast, keepDaily, keepWeekly, keepMonthly, keepYearly int) (RetentionPolicy, error) {
+	if keepLast < 0 || keepDaily < 0 || keepWeekly < 0 || keepMonthly < 0 || keepYearly < 0 {
+		return RetentionPolicy{}, ErrInvalidRetentionPolicy
+	}
+	return RetentionPolicy{
+		KeepLast:    keepLast,
+		KeepDaily:   keepDaily,
+		KeepWeekly:  keepWeekly,
+		KeepMonthly: keepMonthly,
+		KeepYearly:  keepYearly,
+	}, nil
+}
+
+// IsZero reports whether the policy has no rules, i.e. nothing is ever purged.
+func (p RetentionPolicy) IsZero() bool {
+	return p == RetentionPolicy{}
+}
+
+func (p RetentionPolicy) Equals(other shared.ValueObject) bool {
+	otherPolicy, ok := other.(RetentionPolicy)
+	return ok && p == otherPolicy
+}
+
+func (p RetentionPolicy) String() string {
+	return fmt.Sprintf("last=%d daily=%d weekly=%d monthly=%d yearly=%d",
+		p.KeepLast, p.KeepDaily, p.KeepWeekly, p.KeepMonthly, p.KeepYearly)
+}
+
+// RetentionCandidate is a snapshot considered by a retention policy.
+// A zero Time means the creation time is unknown; such snapshots can only be
+// kept by the "last" rule. Latest marks the snapshot the 'latest' link points
+// to and Pinned a snapshot pinned by a user; neither is ever purged.
+type RetentionCandidate struct {
+	Name   string
+	Time   time.Time
+	Latest bool
+	Pinned bool
+}
+
+// RetainedSnapshot is a snapshot kept by a policy along with the rules that kept it.
+type RetainedSnapshot struct {
+	Name  string          `json:"name"`
+	Rules []RetentionRule `json:"rules"`
+}
+
+// RetentionPlan is the outcome of applying a policy. Both lists are ordered
+// oldest first.
+type RetentionPlan struct {
+	Keep  []RetainedSnapshot `json:"keep"`
+	Purge []string           `json:"purge"`
+}
+
+type retentionBucket struct {
+	rule  RetentionRule
+	count int
+	key   func(time.Time) string
+}
+
+// Plan decides which candidates to keep and which to purge.
+// Candidates must be ordered oldest first.
+func (p RetentionPolicy) Plan(candidates []RetentionCandidate) RetentionPlan {
+	plan := RetentionPlan{Keep: []RetainedSnapshot{}, Purge: []string{}}
+	rules := make([][]RetentionRule, len(candidates))
+
+	if p.IsZero() {
+		for _, c := range candidates {
+			kept := RetainedSnapshot{Name: c.Name, Rules: []RetentionRule{}}
+			if c.Latest {
+				kept.Rules = append(kept.Rules, RetentionRuleLatest)
+			}
+			if c.Pinned {
+				kept.Rules = append(kept.Rules, RetentionRulePinned)
+			}
+			plan.Keep = append(plan.Keep, kept)
+		}
+		return plan
+	}
+
+	for i, kept := len(candidates)-1, 0; i >= 0 && kept < p.KeepLast; i, kept = i-1, kept+1 {
+		rules[i] = append(rules[i], RetentionRuleLast)
+	}
+
+	buckets := []retentionBucket{
+		{RetentionRuleDaily, p.KeepDaily, func(t time.Time) string { return t.Format("2006-01-02") }},
+		{RetentionRuleWeekly, p.KeepWeekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%04d-W%02d", year, week)
+		}},
+		{RetentionRuleMonthly, p.KeepMonthly, func(t time.Time) string { return t.Format("2006-01") }},
+		{RetentionRuleYearly, p.KeepYearly, func(t time.Time) string { return t.Format("2006") }},
+	}
+
+	// Walking newest first keeps the most recent snapshot of every period.
+	for _, bucket := range buckets {
+		lastKey := ""
+		kept := 0
+		for i := len(candidates) - 1; i >= 0 && kept < bucket.count; i-- {
+			if candidates[i].Time.IsZero() {
+				continue
+			}
+			key := bucket.key(candidates[i].Time)
+			if key == lastKey {
+				continue
+			}
+			lastKey = key
+			kept++
+			rules[i] = append(rules[i], bucket.rule)
+		}
+	}
+
+	for i, c := range candidates {
+		if c.Latest {
+			rules[i] = append(rules[i], RetentionRuleLatest)
+		}
+		if c.Pinned {
+			rules[i] = append(rules[i], RetentionRulePinned)
+		}
+		if len(rules[i]) == 0 {
+			plan.Purge = append(plan.Purge, c.Name)
+			continue
+		}
+		plan.Keep = append(plan.Keep, RetainedSnapshot{Name: c.Name, Rules: rules[i]})
+	}
+	return plan
+}
+
+// PlanSnapshots applies the policy to snapshot names (oldest first), reading
+// each snapshot's time from its name. latest is the snapshot the 'latest' link
+// points to, or empty if there is none; pinned snapshots are always kept.
+func (p RetentionPolicy) PlanSnapshots(names []string, latest string, pinned []string) RetentionPlan {
+	isPinned := make(map[string]bool, len(pinned))
+	for _, name := range pinned {
+		isPinned[name] = true
+	}
+
+	candidates := make([]RetentionCandidate, 0, len(names))
+	for _, name := range names {
+		t, _ := ParseSnapshotTime(name)
+		candidates = append(candidates, RetentionCandidate{
+			Name:   name,
+			Time:   t,
+			Latest: latest != "" && name == latest,
+			Pinned: isPinned[name],
+		})
+	}
+	return p.Plan(candidates)
+}
+
+// Hold returns the plan a legal hold turns this one into: nothing is purged
+// and the snapshots that would have been are kept by the legal hold rule.
+func (plan RetentionPlan) Hold() RetentionPlan {
+	held := RetentionPlan{Keep: make([]RetainedSnapshot, 0, len(plan.Keep)+len(plan.Purge)), Purge: []string{}}
+
+	// Both lists are oldest first; merge them by snapshot time.
+	keep, purge := plan.Keep, plan.Purge
+	for len(keep) > 0 || len(purge) > 0 {
+		if len(purge) == 0 || (len(keep) > 0 && !snapshotBefore(purge[0], keep[0].Name)) {
+			held.Keep = append(held.Keep, keep[0])
+			keep = keep[1:]
+			continue
+		}
+		held.Keep = append(held.Keep, RetainedSnapshot{Name: purge[0], Rules: []RetentionRule{RetentionRuleLegalHold}})
+		purge = purge[1:]
+	}
+	return held
+}
+
+func snapshotBefore(a, b string) bool {
+	ta, okA := ParseSnapshotTime(a)
+	tb, okB := ParseSnapshotTime(b)
+	if okA && okB {
+		return ta.Before(tb)
+	}
+	return a < b
+}
@@ -0,0 +1,258 @@
+package valueobjects
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRetentionPolicy(t *testing.T) {
+	policy, err := NewRetentionPolicy(3, 7, 4, 12, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, RetentionPolicy{KeepLast: 3, KeepDaily: 7, KeepWeekly: 4, KeepMonthly: 12, KeepYearly: 2}, policy)
+
+	_, err = NewRetentionPolicy(1, -1, 0, 0, 0)
+	assert.ErrorIs(t, err, ErrInvalidRetentionPolicy)
+}
+
+func TestRetentionPolicy_IsZero(t *testing.T) {
+	assert.True(t, RetentionPolicy{}.IsZero())
+	assert.False(t, RetentionPolicy{KeepYearly: 1}.IsZero())
+}
+
+func TestParseSnapshotTime(t *testing.T) {
+	ts, ok := ParseSnapshotTime("2024-03-01_02-30-00")
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 3, 1, 2, 30, 0, 0, time.Local), ts)
+
+	_, ok = ParseSnapshotTime("latest")
+	assert.False(t, ok)
+}
+
+func TestSnapshotNameFromArtifact(t *testing.T) {
+	tests := []struct {
+		entry    string
+		isDir    bool
+		expected string
+		ok       bool
+	}{
+		{"2024-03-01_02-30-00", true, "2024-03-01_02-30-00", true},
+		{"2024-03-01_02-30-00.tar.gz.enc", false, "2024-03-01_02-30-00", true},
+		{"2024-03-01_02-30-00", false, "", false},
+		{"2024-03-01_02-30-00.tar.gz.enc", true, "", false},
+		{"latest.tar.gz.enc", false, "", false},
+		{".partial-2024-03-01_02-30-00", true, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.entry, func(t *testing.T) {
+			name, ok := SnapshotNameFromArtifact(tt.entry, tt.isDir)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, name)
+		})
+	}
+}
+
+// dailySnapshots returns one snapshot per day at 02:00, oldest first.
+func dailySnapshots(from time.Time, days int) []string {
+	names := make([]string, 0, days)
+	for i := 0; i < days; i++ {
+		names = append(names, from.AddDate(0, 0, i).Add(2*time.Hour).Format(SnapshotTimeFormat))
+	}
+	return names
+}
+
+func keptNames(plan RetentionPlan) []string {
+	names := make([]string, 0, len(plan.Keep))
+	for _, k := range plan.Keep {
+		names = append(names, k.Name)
+	}
+	return names
+}
+
+func TestRetentionPolicy_PlanSnapshots(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   RetentionPolicy
+		backups  []string
+		expected []string // kept, oldest first
+	}{
+		{
+			name:     "No snapshots",
+			policy:   RetentionPolicy{KeepLast: 3},
+			backups:  []string{},
+			expected: []string{},
+		},
+		{
+			name:     "Zero policy keeps everything",
+			policy:   RetentionPolicy{},
+			backups:  []string{"2024-01-01_00-00-00", "2024-01-02_00-00-00"},
+			expected: []string{"2024-01-01_00-00-00", "2024-01-02_00-00-00"},
+		},
+		{
+			name:     "Keep last uses order only",
+			policy:   RetentionPolicy{KeepLast: 2},
+			backups:  []string{"a", "b", "c", "d"},
+			expected: []string{"c", "d"},
+		},
+		{
+			name:   "Keep daily keeps newest snapshot of each day",
+			policy: RetentionPolicy{KeepDaily: 2},
+			backups: []string{
+				"2024-01-01_01-00-00",
+				"2024-01-02_01-00-00",
+				"2024-01-02_13-00-00",
+				"2024-01-03_01-00-00",
+				"2024-01-03_13-00-00",
+			},
+			expected: []string{"2024-01-02_13-00-00", "2024-01-03_13-00-00"},
+		},
+		{
+			name:   "Keep weekly uses ISO weeks",
+			policy: RetentionPolicy{KeepWeekly: 2},
+			// 2024-01-07 is a Sunday, 2024-01-08 starts ISO week 2.
+			backups: []string{
+				"2024-01-01_00-00-00",
+				"2024-01-07_00-00-00",
+				"2024-01-08_00-00-00",
+				"2024-01-14_00-00-00",
+				"2024-01-15_00-00-00",
+			},
+			expected: []string{"2024-01-14_00-00-00", "2024-01-15_00-00-00"},
+		},
+		{
+			name:   "Weekly bucket spanning a year boundary",
+			policy: RetentionPolicy{KeepWeekly: 2},
+			// 2024-12-30 and 2025-01-05 both belong to ISO week 2025-W01.
+			backups: []string{
+				"2024-12-29_00-00-00",
+				"2024-12-30_00-00-00",
+				"2025-01-05_00-00-00",
+			},
+			expected: []string{"2024-12-29_00-00-00", "2025-01-05_00-00-00"},
+		},
+		{
+			name:   "Keep monthly and yearly",
+			policy: RetentionPolicy{KeepMonthly: 2, KeepYearly: 2},
+			backups: []string{
+				"2021-06-01_00-00-00",
+				"2022-03-01_00-00-00",
+				"2022-12-31_00-00-00",
+				"2023-11-15_00-00-00",
+				"2023-11-30_00-00-00",
+				"2023-12-01_00-00-00",
+			},
+			expected: []string{
+				"2022-12-31_00-00-00",
+				"2023-11-30_00-00-00",
+				"2023-12-01_00-00-00",
+			},
+		},
+		{
+			name:     "Rule count larger than available periods keeps all periods",
+			policy:   RetentionPolicy{KeepDaily: 30},
+			backups:  dailySnapshots(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 5),
+			expected: dailySnapshots(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 5),
+		},
+		{
+			name:     "Unparseable names are only kept by the last rule",
+			policy:   RetentionPolicy{KeepLast: 1, KeepDaily: 5},
+			backups:  []string{"2024-01-01_00-00-00", "old", "2024-01-02_00-00-00", "newest"},
+			expected: []string{"2024-01-01_00-00-00", "2024-01-02_00-00-00", "newest"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := tt.policy.PlanSnapshots(tt.backups, "", nil)
+			assert.Equal(t, tt.expected, keptNames(plan))
+			assert.Equal(t, len(tt.backups), len(plan.Keep)+len(plan.Purge))
+		})
+	}
+}
+
+func TestRetentionPolicy_PlanReportsRules(t *testing.T) {
+	// 90 daily snapshots from 2024-01-01 to 2024-03-30.
+	backups := dailySnapshots(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 90)
+	policy := RetentionPolicy{KeepLast: 2, KeepDaily: 3, KeepWeekly: 2, KeepMonthly: 3}
+
+	plan := policy.PlanSnapshots(backups, "", nil)
+
+	rules := make(map[string][]RetentionRule)
+	for _, k := range plan.Keep {
+		rules[k.Name] = k.Rules
+	}
+
+	assert.Equal(t, map[string][]RetentionRule{
+		// Newest snapshot: every rule selects it.
+		"2024-03-30_02-00-00": {RetentionRuleLast, RetentionRuleDaily, RetentionRuleWeekly, RetentionRuleMonthly},
+		"2024-03-29_02-00-00": {RetentionRuleLast, RetentionRuleDaily},
+		"2024-03-28_02-00-00": {RetentionRuleDaily},
+		// Sunday closing ISO week 12.
+		"2024-03-24_02-00-00": {RetentionRuleWeekly},
+		// Last day of February and January.
+		"2024-02-29_02-00-00": {RetentionRuleMonthly},
+		"2024-01-31_02-00-00": {RetentionRuleMonthly},
+	}, rules)
+
+	assert.Len(t, plan.Purge, 84)
+	assert.Equal(t, "2024-01-01_02-00-00", plan.Purge[0])
+	assert.NotContains(t, plan.Purge, "2024-03-30_02-00-00")
+}
+
+func TestRetentionPolicy_PlanZeroPolicyReportsNoRules(t *testing.T) {
+	plan := RetentionPolicy{}.PlanSnapshots([]string{"2024-01-01_00-00-00"}, "", nil)
+	assert.Equal(t, []RetainedSnapshot{{Name: "2024-01-01_00-00-00", Rules: []RetentionRule{}}}, plan.Keep)
+	assert.Empty(t, plan.Purge)
+}
+
+func TestRetentionPolicy_PlanNeverPurgesLatest(t *testing.T) {
+	backups := []string{"2024-01-01_00-00-00", "2024-01-02_00-00-00", "2024-01-03_00-00-00"}
+
+	t.Run("latest outside the policy is kept", func(t *testing.T) {
+		plan := RetentionPolicy{KeepLast: 1}.PlanSnapshots(backups, "2024-01-02_00-00-00", nil)
+		assert.Equal(t, []RetainedSnapshot{
+			{Name: "2024-01-02_00-00-00", Rules: []RetentionRule{RetentionRuleLatest}},
+			{Name: "2024-01-03_00-00-00", Rules: []RetentionRule{RetentionRuleLast}},
+		}, plan.Keep)
+		assert.Equal(t, []string{"2024-01-01_00-00-00"}, plan.Purge)
+	})
+
+	t.Run("latest kept by a rule reports both", func(t *testing.T) {
+		plan := RetentionPolicy{KeepLast: 1}.PlanSnapshots(backups, "2024-01-03_00-00-00", nil)
+		assert.Equal(t, []RetentionRule{RetentionRuleLast, RetentionRuleLatest}, plan.Keep[0].Rules)
+		assert.Len(t, plan.Purge, 2)
+	})
+
+	t.Run("zero policy marks latest", func(t *testing.T) {
+		plan := RetentionPolicy{}.PlanSnapshots(backups, "2024-01-03_00-00-00", nil)
+		assert.Equal(t, []RetentionRule{RetentionRuleLatest}, plan.Keep[2].Rules)
+	})
+}
+
+func TestRetentionPolicy_PlanNeverPurgesPinned(t *testing.T) {
+	backups := []string{"2024-01-01_00-00-00", "2024-01-02_00-00-00", "2024-01-03_00-00-00"}
+
+	plan := RetentionPolicy{KeepLast: 1}.PlanSnapshots(backups, "", []string{"2024-01-01_00-00-00", "2023-01-01_00-00-00"})
+	assert.Equal(t, []RetainedSnapshot{
+		{Name: "2024-01-01_00-00-00", Rules: []RetentionRule{RetentionRulePinned}},
+		{Name: "2024-01-03_00-00-00", Rules: []RetentionRule{RetentionRuleLast}},
+	}, plan.Keep)
+	assert.Equal(t, []string{"2024-01-02_00-00-00"}, plan.Purge)
+}
+
+func TestRetentionPlan_Hold(t *testing.T) {
+	backups := []string{"2024-01-01_00-00-00", "2024-01-02_00-00-00", "2024-01-03_00-00-00", "2024-01-04_00-00-00"}
+	plan := RetentionPolicy{KeepLast: 1}.PlanSnapshots(backups, "", []string{"2024-01-02_00-00-00"})
+
+	held := plan.Hold()
+
+	assert.Equal(t, []RetainedSnapshot{
+		{Name: "2024-01-01_00-00-00", Rules: []RetentionRule{RetentionRuleLegalHold}},
+		{Name: "2024-01-02_00-00-00", Rules: []RetentionRule{RetentionRulePinned}},
+		{Name: "2024-01-03_00-00-00", Rules: []RetentionRule{RetentionRuleLegalHold}},
+		{Name: "2024-01-04_00-00-00", Rules: []RetentionRule{RetentionRuleLast}},
+	}, held.Keep)
+	assert.Empty(t, held.Purge)
+}
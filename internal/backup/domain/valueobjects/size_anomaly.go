@@ -0,0 +1,135 @@
+package valueobjects
+
+import (
+	"errors"
+	"sort"
+)
+
+var ErrInvalidAnomalyRatio = errors.New("anomaly ratio must be between 0 and 1")
+
+// AnomalyKind is what looked wrong about a backup run.
+type AnomalyKind string
+
+const (
+	// AnomalyShrink is a snapshot much smaller than the ones before it, as
+	// left by a broken mount or mass deletion.
+	AnomalyShrink AnomalyKind = "shrink"
+	// AnomalyChurn is a run that changed far more files than usual, as left
+	// by ransomware encrypting them.
+	AnomalyChurn AnomalyKind = "churn"
+)
+
+// AnomalyBaselineRuns is how many previous runs a run is compared against.
+const AnomalyBaselineRuns = 7
+
+// anomalyMinBaselineRuns is the history a check needs before it reports
+// anything, so the first runs of a backup do not raise alerts.
+const anomalyMinBaselineRuns = 3
+
+// churnBaselineFactor is how many times its usual share of changed files a
+// run must change, so backups that always rewrite most of their files (such
+// as database dumps) are not reported on every run.
+const churnBaselineFactor = 2
+
+// FileChanges is how many regular files a backup run found and how many of
+// them it had to transfer.
+type FileChanges struct {
+	Files   int64
+	Changed int64
+}
+
+// Ratio is the share of files that changed, 0 when there are none.
+func (c FileChanges) Ratio() float64 {
+	if c.Files <= 0 {
+		return 0
+	}
+	return float64(c.Changed) / float64(c.Files)
+}
+
+// RunSample is what a backup run is judged by: the apparent size of its
+// snapshot and the files it changed.
+type RunSample struct {
+	Size    int64
+	Changes FileChanges
+}
+
+// AnomalyThresholds are the ratios past which a run is reported. Shrink is the
+// share of its usual size a snapshot must lose and Churn the share of files a
+// run must change. Zero disables the check.
+type AnomalyThresholds struct {
+	Shrink float64
+	Churn  float64
+}
+
+// NewAnomalyThresholds validates the thresholds.
+func NewAnomalyThresholds(shrink, churn float64) (AnomalyThresholds, error) {
+	if shrink < 0 || shrink > 1 || churn < 0 || churn > 1 {
+		return AnomalyThresholds{}, ErrInvalidAnomalyRatio
+	}
+	return AnomalyThresholds{Shrink: shrink, Churn: churn}, nil
+}
+
+// SizeAnomaly is a run that stood out from its baseline. Ratio is the share of
+// the baseline size the snapshot lost for a shrink and the share of files the
+// run changed for churn. Baseline is what the run was compared against: the
+// median snapshot size in bytes for a shrink, the median share of changed
+// files for churn.
+type SizeAnomaly struct {
+	Kind     AnomalyKind
+	Ratio    float64
+	Baseline float64
+}
+
+// DetectSizeAnomalies compares a run with the runs before it, oldest first,
+// of which the last AnomalyBaselineRuns form the baseline.
+func DetectSizeAnomalies(history []RunSample, run RunSample, thresholds AnomalyThresholds) []SizeAnomaly {
+	if len(history) > AnomalyBaselineRuns {
+		history = history[len(history)-AnomalyBaselineRuns:]
+	}
+
+	var anomalies []SizeAnomaly
+	if thresholds.Shrink > 0 {
+		sizes := make([]float64, 0, len(history))
+		for _, h := range history {
+			sizes = append(sizes, float64(h.Size))
+		}
+		if baseline, ok := median(sizes); ok && baseline > 0 {
+			lost := 1 - float64(run.Size)/baseline
+			if lost >= thresholds.Shrink {
+				anomalies = append(anomalies, SizeAnomaly{Kind: AnomalyShrink, Ratio: lost, Baseline: baseline})
+			}
+		}
+	}
+
+	if thresholds.Churn > 0 && run.Changes.Files > 0 {
+		// Runs from before file counts were reported carry none.
+		ratios := make([]float64, 0, len(history))
+		for _, h := range history {
+			if h.Changes.Files > 0 {
+				ratios = append(ratios, h.Changes.Ratio())
+			}
+		}
+		if baseline, ok := median(ratios); ok {
+			ratio := run.Changes.Ratio()
+			if ratio >= thresholds.Churn && ratio >= churnBaselineFactor*baseline {
+				anomalies = append(anomalies, SizeAnomaly{Kind: AnomalyChurn, Ratio: ratio, Baseline: baseline})
+			}
+		}
+	}
+	return anomalies
+}
+
+// median returns the median of values, false when there are too few of them
+// to form a baseline.
+func median(values []float64) (float64, bool) {
+	if len(values) < anomalyMinBaselineRuns {
+		return 0, false
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2, true
+	}
+	return sorted[mid], true
+}
@@ -0,0 +1,77 @@
+package valueobjects
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func steadyRuns(n int) []RunSample {
+	runs := make([]RunSample, n)
+	for i := range runs {
+		runs[i] = RunSample{Size: 1000, Changes: FileChanges{Files: 100, Changed: 5}}
+	}
+	return runs
+}
+
+func TestNewAnomalyThresholds(t *testing.T) {
+	thresholds, err := NewAnomalyThresholds(0.5, 0.8)
+	require.NoError(t, err)
+	assert.Equal(t, AnomalyThresholds{Shrink: 0.5, Churn: 0.8}, thresholds)
+
+	_, err = NewAnomalyThresholds(1.5, 0.8)
+	assert.ErrorIs(t, err, ErrInvalidAnomalyRatio)
+	_, err = NewAnomalyThresholds(0.5, -0.1)
+	assert.ErrorIs(t, err, ErrInvalidAnomalyRatio)
+}
+
+func TestDetectSizeAnomalies_Shrink(t *testing.T) {
+	thresholds := AnomalyThresholds{Shrink: 0.5, Churn: 0.8}
+
+	anomalies := DetectSizeAnomalies(steadyRuns(5), RunSample{Size: 100, Changes: FileChanges{Files: 10, Changed: 0}}, thresholds)
+	require.Len(t, anomalies, 1)
+	assert.Equal(t, AnomalyShrink, anomalies[0].Kind)
+	assert.InDelta(t, 0.9, anomalies[0].Ratio, 0.001)
+	assert.Equal(t, float64(1000), anomalies[0].Baseline)
+
+	// A smaller drop is normal churn.
+	assert.Empty(t, DetectSizeAnomalies(steadyRuns(5), RunSample{Size: 700, Changes: FileChanges{Files: 100, Changed: 5}}, thresholds))
+}
+
+func TestDetectSizeAnomalies_Churn(t *testing.T) {
+	thresholds := AnomalyThresholds{Shrink: 0.5, Churn: 0.8}
+
+	anomalies := DetectSizeAnomalies(steadyRuns(5), RunSample{Size: 1050, Changes: FileChanges{Files: 100, Changed: 90}}, thresholds)
+	require.Len(t, anomalies, 1)
+	assert.Equal(t, AnomalyChurn, anomalies[0].Kind)
+	assert.InDelta(t, 0.9, anomalies[0].Ratio, 0.001)
+	assert.InDelta(t, 0.05, anomalies[0].Baseline, 0.001)
+
+	// A backup that always rewrites its files is not reported.
+	dumps := make([]RunSample, 5)
+	for i := range dumps {
+		dumps[i] = RunSample{Size: 1000, Changes: FileChanges{Files: 2, Changed: 2}}
+	}
+	assert.Empty(t, DetectSizeAnomalies(dumps, RunSample{Size: 1000, Changes: FileChanges{Files: 2, Changed: 2}}, thresholds))
+}
+
+func TestDetectSizeAnomalies_NeedsBaseline(t *testing.T) {
+	thresholds := AnomalyThresholds{Shrink: 0.5, Churn: 0.8}
+	run := RunSample{Size: 0, Changes: FileChanges{Files: 100, Changed: 100}}
+
+	assert.Empty(t, DetectSizeAnomalies(steadyRuns(2), run, thresholds))
+	assert.Empty(t, DetectSizeAnomalies(steadyRuns(5), run, AnomalyThresholds{}))
+	assert.Len(t, DetectSizeAnomalies(steadyRuns(3), run, thresholds), 2)
+}
+
+func TestDetectSizeAnomalies_UsesLatestRuns(t *testing.T) {
+	// The backup used to be larger; only the last runs form the baseline.
+	history := make([]RunSample, 0, 10)
+	for i := 0; i < 5; i++ {
+		history = append(history, RunSample{Size: 10000})
+	}
+	history = append(history, steadyRuns(AnomalyBaselineRuns)...)
+
+	assert.Empty(t, DetectSizeAnomalies(history, RunSample{Size: 1000}, AnomalyThresholds{Shrink: 0.5}))
+}
@@ -0,0 +1,117 @@
+package valueobjects
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidSnapshotSelector = errors.New("invalid snapshot selector: use a snapshot name, 'latest' or a point in time")
+	ErrSnapshotNotFound        = errors.New("snapshot not found")
+	ErrNotIncremental          = errors.New("backup is not incremental and has no snapshots")
+	ErrEncryptedSnapshot       = errors.New("snapshot is only stored as an encrypted archive: select the whole snapshot")
+)
+
+// SnapshotLatest selects the newest snapshot of a backup.
+const SnapshotLatest = "latest"
+
+// asOfLayouts are the point in time formats accepted by a selector, tried in order.
+var asOfLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// SnapshotSelector picks one snapshot of an incremental backup: an exact
+// snapshot name, the latest one, or the newest one taken at or before a point
+// in time ("as of"). The zero selector selects nothing, i.e. the backup root.
+type SnapshotSelector struct {
+	name   string
+	latest bool
+	asOf   time.Time
+}
+
+// ParseSnapshotSelector parses a snapshot name, "latest", or a point in time
+// such as "2024-03-01", "2024-03-01 14:00" or RFC3339, optionally prefixed
+// with "as of". Points in time without a zone are read in the local zone,
+// the one workers write snapshot names in.
+func ParseSnapshotSelector(value string) (SnapshotSelector, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return SnapshotSelector{}, nil
+	}
+	if value == SnapshotLatest {
+		return SnapshotSelector{latest: true}, nil
+	}
+	if _, ok := ParseSnapshotTime(value); ok {
+		return SnapshotSelector{name: value}, nil
+	}
+
+	asOf := strings.TrimSpace(strings.TrimPrefix(strings.ToLower(value), "as of"))
+	for _, layout := range asOfLayouts {
+		t, err := time.ParseInLocation(layout, strings.ToUpper(asOf), time.Local)
+		if err != nil {
+			continue
+		}
+		// A bare date means the end of that day.
+		if layout == "2006-01-02" {
+			t = t.Add(24*time.Hour - time.Second)
+		}
+		return SnapshotSelector{asOf: t}, nil
+	}
+	return SnapshotSelector{}, ErrInvalidSnapshotSelector
+}
+
+// IsZero reports whether the selector selects nothing.
+func (s SnapshotSelector) IsZero() bool {
+	return s == SnapshotSelector{}
+}
+
+// Resolve returns the snapshot the selector picks among names.
+func (s SnapshotSelector) Resolve(names []string) (string, error) {
+	sorted := make([]string, 0, len(names))
+	for _, name := range names {
+		if _, ok := ParseSnapshotTime(name); ok {
+			sorted = append(sorted, name)
+		}
+	}
+	sort.Strings(sorted)
+
+	switch {
+	case s.name != "":
+		for _, name := range sorted {
+			if name == s.name {
+				return name, nil
+			}
+		}
+	case s.latest:
+		if len(sorted) > 0 {
+			return sorted[len(sorted)-1], nil
+		}
+	case !s.asOf.IsZero():
+		for i := len(sorted) - 1; i >= 0; i-- {
+			t, _ := ParseSnapshotTime(sorted[i])
+			if !t.After(s.asOf) {
+				return sorted[i], nil
+			}
+		}
+	}
+	return "", ErrSnapshotNotFound
+}
+
+func (s SnapshotSelector) String() string {
+	switch {
+	case s.name != "":
+		return s.name
+	case s.latest:
+		return SnapshotLatest
+	case !s.asOf.IsZero():
+		return "as of " + s.asOf.Format(time.RFC3339)
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+package valueobjects
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotSelector_Resolve(t *testing.T) {
+	snapshots := []string{
+		"2024-03-02_02-00-00",
+		"2024-03-01_02-00-00",
+		"latest",
+		"2024-03-03_02-00-00",
+	}
+
+	tests := []struct {
+		selector string
+		expected string
+		err      error
+	}{
+		{"2024-03-02_02-00-00", "2024-03-02_02-00-00", nil},
+		{"2024-03-04_02-00-00", "", ErrSnapshotNotFound},
+		{"latest", "2024-03-03_02-00-00", nil},
+		{"2024-03-02", "2024-03-02_02-00-00", nil},
+		{"as of 2024-03-02 01:00", "2024-03-01_02-00-00", nil},
+		{"2024-03-03T02:00:00Z", "2024-03-03_02-00-00", nil},
+		{"2024-03-03T03:00:00+02:00", "2024-03-02_02-00-00", nil},
+		{"2024-02-28", "", ErrSnapshotNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			selector, err := ParseSnapshotSelector(tt.selector)
+			assert.NoError(t, err)
+
+			name, err := selector.Resolve(snapshots)
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.expected, name)
+		})
+	}
+}
+
+func TestParseSnapshotSelector(t *testing.T) {
+	selector, err := ParseSnapshotSelector("")
+	assert.NoError(t, err)
+	assert.True(t, selector.IsZero())
+
+	_, err = ParseSnapshotSelector("yesterday")
+	assert.ErrorIs(t, err, ErrInvalidSnapshotSelector)
+
+	selector, err = ParseSnapshotSelector("As Of 2024-03-02")
+	assert.NoError(t, err)
+	assert.Equal(t, "as of 2024-03-02T23:59:59Z", selector.String())
+}
+
+func TestSnapshotSelector_LatestWithoutSnapshots(t *testing.T) {
+	selector, _ := ParseSnapshotSelector("latest")
+	_, err := selector.Resolve(nil)
+	assert.ErrorIs(t, err, ErrSnapshotNotFound)
+}
@@ -0,0 +1,12 @@
+package valueobjects
+
+// SpaceUsage is the space a backup takes on its worker, in bytes. Size is the
+// apparent size of the latest snapshot, Unique what the latest run added that
+// no other snapshot shares, and Stored what the whole backup takes on disk
+// with hard-linked files counted once. Incremental runs add Unique to Stored;
+// purges and tiering measure it again.
+type SpaceUsage struct {
+	Size   int64
+	Unique int64
+	Stored int64
+}
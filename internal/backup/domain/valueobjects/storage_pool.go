@@ -0,0 +1,36 @@
+package valueobjects
+
+import "errors"
+
+// DefaultStorageRoot is where the worker keeps the backups that are in no
+// storage pool, the backup root it was always given.
+const DefaultStorageRoot = "/mnt/backups"
+
+// ErrInvalidStoragePool is returned for a storage pool missing a required
+// setting.
+var ErrInvalidStoragePool = errors.New("invalid storage pool")
+
+// ErrStoragePoolInUse is returned when changing the root of, or deleting, a
+// storage pool that backups or hosts are assigned to.
+var ErrStoragePoolInUse = errors.New("storage pool is in use")
+
+// ErrSameStoragePool is returned when migrating a backup to the pool it is
+// already in.
+var ErrSameStoragePool = errors.New("backup is already in the storage pool")
+
+// ErrBackupRunning is returned when moving the data of a backup, or deleting
+// it, while it runs.
+var ErrBackupRunning = errors.New("backup is running")
+
+// ErrBackupMigrating is returned when running, migrating or deleting a
+// backup while its data is being moved to another storage pool.
+var ErrBackupMigrating = errors.New("backup is being migrated")
+
+// ErrMigrationTarget is returned when migrating a backup to a directory
+// another backup keeps its data in.
+var ErrMigrationTarget = errors.New("migration target is in use")
+
+// WorkerStateDir is the directory under each storage root where the worker
+// keeps what it needs from one run to the next, such as the file hashes it
+// carries forward when cataloging. It never holds backup data.
+const WorkerStateDir = ".justbackup"
@@ -0,0 +1,17 @@
+package valueobjects
+
+import "errors"
+
+// ErrNotOrphaned is returned when reclaiming or adopting a path that lies
+// outside every storage root or that a backup still keeps its data in.
+var ErrNotOrphaned = errors.New("path is not orphaned data")
+
+// ErrInvalidAdoption is returned when orphaned data cannot become the data
+// of a backup, because it lies outside the storage of the host of the backup
+// or is not the kind of data the backup keeps.
+var ErrInvalidAdoption = errors.New("orphaned data cannot be adopted by the backup")
+
+// ErrSharedData is returned when deleting the data of a backup whose
+// directory is not its own alone: it overlaps the data of another backup or
+// is the directory of its host.
+var ErrSharedData = errors.New("backup data is shared")
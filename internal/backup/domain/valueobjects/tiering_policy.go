@@ -0,0 +1,99 @@
+package valueobjects
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidTieringPolicy is returned for a tiering policy missing a
+// required setting.
+var ErrInvalidTieringPolicy = errors.New("invalid tiering policy")
+
+// ColdArchiveSuffix is appended to a snapshot compressed into a cold storage
+// pool without encryption. Encrypted ones use EncryptedSnapshotSuffix.
+const ColdArchiveSuffix = ".tar.gz"
+
+// TierKind is where a tiering policy moves old snapshots to.
+type TierKind string
+
+const (
+	// TierPool archives snapshots into a cold storage pool.
+	TierPool TierKind = "pool"
+	// TierReplica keeps snapshots only on a replica target that holds them.
+	TierReplica TierKind = "replica"
+)
+
+// TieringPolicy moves the snapshots of an incremental backup older than
+// AfterDays off the storage of the backup, into an archive in the storage
+// pool StoragePoolID, encrypted when Encrypt is set, or to the replica target
+// ReplicaTargetID, which must hold them already. The latest snapshot always
+// stays.
+type TieringPolicy struct {
+	AfterDays       int    `json:"after_days"`
+	StoragePoolID   string `json:"storage_pool_id,omitempty"`
+	ReplicaTargetID string `json:"replica_target_id,omitempty"`
+	Encrypt         bool   `json:"encrypt,omitempty"`
+}
+
+func (p TieringPolicy) Validate() error {
+	if p.AfterDays < 1 {
+		return fmt.Errorf("%w: after_days must be at least 1", ErrInvalidTieringPolicy)
+	}
+	if (p.StoragePoolID == "") == (p.ReplicaTargetID == "") {
+		return fmt.Errorf("%w: either a storage pool or a replica target is required", ErrInvalidTieringPolicy)
+	}
+	// Replica targets keep snapshots in their own format.
+	if p.Encrypt && p.ReplicaTargetID != "" {
+		return fmt.Errorf("%w: encryption only applies to storage pools", ErrInvalidTieringPolicy)
+	}
+	return nil
+}
+
+func (p TieringPolicy) Kind() TierKind {
+	if p.ReplicaTargetID != "" {
+		return TierReplica
+	}
+	return TierPool
+}
+
+// Due reports whether a snapshot is old enough to be moved at now.
+func (p TieringPolicy) Due(snapshot string, now time.Time) bool {
+	t, ok := ParseSnapshotTime(snapshot)
+	return ok && t.Before(now.AddDate(0, 0, -p.AfterDays))
+}
+
+// ColdLocation is where the archive of a snapshot moved to a cold storage
+// pool lies on the worker. Path is what a restore reads inside the snapshot,
+// "." for all of it.
+type ColdLocation struct {
+	Snapshot  string `json:"snapshot"`
+	Archive   string `json:"archive"`
+	Encrypted bool   `json:"encrypted,omitempty"`
+	Path      string `json:"path,omitempty"`
+}
+
+// ColdArchiveName names the archive of a snapshot in a cold storage pool.
+func ColdArchiveName(snapshot string, encrypted bool) string {
+	if encrypted {
+		return snapshot + EncryptedSnapshotSuffix
+	}
+	return snapshot + ColdArchiveSuffix
+}
+
+// SnapshotNameFromColdArchive returns the snapshot a file in a cold storage
+// pool is the archive of.
+func SnapshotNameFromColdArchive(file string) (string, bool) {
+	if name, ok := SnapshotNameFromArtifact(file, false); ok {
+		return name, true
+	}
+	name, ok := strings.CutSuffix(file, ColdArchiveSuffix)
+	if !ok {
+		return "", false
+	}
+	if _, ok := ParseSnapshotTime(name); !ok {
+		return "", false
+	}
+	return name, true
+}
@@ -0,0 +1,50 @@
+package valueobjects
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTieringPolicy_Validate(t *testing.T) {
+	assert.NoError(t, TieringPolicy{AfterDays: 30, StoragePoolID: "p1", Encrypt: true}.Validate())
+	assert.NoError(t, TieringPolicy{AfterDays: 30, ReplicaTargetID: "t1"}.Validate())
+
+	assert.ErrorIs(t, TieringPolicy{StoragePoolID: "p1"}.Validate(), ErrInvalidTieringPolicy)
+	assert.ErrorIs(t, TieringPolicy{AfterDays: 30}.Validate(), ErrInvalidTieringPolicy)
+	assert.ErrorIs(t, TieringPolicy{AfterDays: 30, StoragePoolID: "p1", ReplicaTargetID: "t1"}.Validate(), ErrInvalidTieringPolicy)
+	assert.ErrorIs(t, TieringPolicy{AfterDays: 30, ReplicaTargetID: "t1", Encrypt: true}.Validate(), ErrInvalidTieringPolicy)
+}
+
+func TestTieringPolicy_Due(t *testing.T) {
+	policy := TieringPolicy{AfterDays: 30, StoragePoolID: "p1"}
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	assert.True(t, policy.Due("2024-02-29_02-00-00", now))
+	assert.False(t, policy.Due("2024-03-02_02-00-00", now))
+	assert.False(t, policy.Due("latest", now))
+}
+
+func TestSnapshotNameFromColdArchive(t *testing.T) {
+	tests := []struct {
+		file     string
+		expected string
+		ok       bool
+	}{
+		{"2024-03-01_02-30-00.tar.gz", "2024-03-01_02-30-00", true},
+		{"2024-03-01_02-30-00.tar.gz.enc", "2024-03-01_02-30-00", true},
+		{"2024-03-01_02-30-00.tar.gz.enc.idx", "", false},
+		{"2024-03-01_02-30-00", "", false},
+		{"latest.tar.gz", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			name, ok := SnapshotNameFromColdArchive(tt.file)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, name)
+		})
+	}
+	assert.Equal(t, "2024-03-01_02-30-00.tar.gz", ColdArchiveName("2024-03-01_02-30-00", false))
+}
@@ -51,7 +51,7 @@ func NewBackupRepositoryMemory() *BackupRepositoryMemory {
 		nil,
 		true,
 		false,
-		"1.2GB",
+		valueobjects.SpaceUsage{Size: 1288490188, Unique: 1288490188, Stored: 1288490188},
 		0,
 		false,
 	)
@@ -71,7 +71,7 @@ func NewBackupRepositoryMemory() *BackupRepositoryMemory {
 		[]string{"tmp/*"},
 		true,
 		true,
-		"",
+		valueobjects.SpaceUsage{},
 		4,
 		false,
 	)
@@ -94,7 +94,7 @@ func NewBackupRepositoryMemory() *BackupRepositoryMemory {
 		nil,
 		true,
 		false,
-		"",
+		valueobjects.SpaceUsage{},
 		0,
 		false,
 	)
@@ -182,22 +182,18 @@ func (r *BackupRepositoryMemory) GetStats(ctx context.Context) (*entities.Backup
 		Total: len(r.backups),
 	}
 
-	var totalSize int64
 	for _, b := range r.backups {
+		stats.TotalSize += b.Usage().Stored
 		switch b.Status() {
 		case valueobjects.BackupStatusPending:
 			stats.Pending++
 		case valueobjects.BackupStatusCompleted:
 			stats.Completed++
-			s, _ := shared.ParseSize(b.Size())
-			totalSize += s
 		case valueobjects.BackupStatusFailed:
 			stats.Failed++
 		}
 	}
 
-	stats.TotalSize = shared.FormatSize(totalSize)
-
 	return stats, nil
 }
 
@@ -0,0 +1,197 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+)
+
+type catalogKey struct {
+	backupID string
+	snapshot string
+	path     string
+}
+
+type FileCatalogRepositoryMemory struct {
+	entries  map[catalogKey]*entities.CatalogEntry
+	contents map[contentKey]*entities.FileContent
+	mu       sync.Mutex
+}
+
+func NewFileCatalogRepositoryMemory() *FileCatalogRepositoryMemory {
+	return &FileCatalogRepositoryMemory{
+		entries:  make(map[catalogKey]*entities.CatalogEntry),
+		contents: make(map[contentKey]*entities.FileContent),
+	}
+}
+
+func (r *FileCatalogRepositoryMemory) SaveBatch(ctx context.Context, entries []*entities.CatalogEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range entries {
+		r.entries[catalogKey{e.BackupID.String(), e.Snapshot, e.Path}] = e
+	}
+	return nil
+}
+
+func (r *FileCatalogRepositoryMemory) DeleteSnapshot(ctx context.Context, backupID valueobjects.BackupID, snapshot string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key := range r.entries {
+		if key.backupID == backupID.String() && key.snapshot == snapshot {
+			delete(r.entries, key)
+		}
+	}
+	return nil
+}
+
+func (r *FileCatalogRepositoryMemory) Search(ctx context.Context, filter valueobjects.CatalogFilter) ([]*entities.CatalogEntry, int, error) {
+	filter, err := filter.Normalize()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	r.mu.Lock()
+	matches := make([]*entities.CatalogEntry, 0)
+	for _, e := range r.entries {
+		if filter.Matches(e.BackupID, e.Path, e.Size, e.ModTime) {
+			matches = append(matches, e)
+		}
+	}
+	r.mu.Unlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Snapshot != b.Snapshot {
+			return a.Snapshot > b.Snapshot
+		}
+		return a.BackupID.String() < b.BackupID.String()
+	})
+
+	total := len(matches)
+	start := filter.Offset()
+	if start > total {
+		start = total
+	}
+	end := start + filter.PageSize
+	if end > total {
+		end = total
+	}
+	return matches[start:end], total, nil
+}
+
+type contentKey struct {
+	backupID string
+	hash     string
+}
+
+func (r *FileCatalogRepositoryMemory) SaveContents(ctx context.Context, contents []*entities.FileContent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, c := range contents {
+		key := contentKey{c.BackupID.String(), c.Hash}
+		if _, ok := r.contents[key]; !ok {
+			r.contents[key] = c
+		}
+	}
+	return nil
+}
+
+func (r *FileCatalogRepositoryMemory) PruneContents(ctx context.Context, backupID valueobjects.BackupID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	referenced := make(map[string]bool)
+	for key, e := range r.entries {
+		if key.backupID == backupID.String() {
+			referenced[e.Hash] = true
+		}
+	}
+	for key := range r.contents {
+		if key.backupID == backupID.String() && !referenced[key.hash] {
+			delete(r.contents, key)
+		}
+	}
+	return nil
+}
+
+func (r *FileCatalogRepositoryMemory) DeleteContents(ctx context.Context, backupID valueobjects.BackupID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key := range r.contents {
+		if key.backupID == backupID.String() {
+			delete(r.contents, key)
+		}
+	}
+	return nil
+}
+
+func (r *FileCatalogRepositoryMemory) SearchContents(ctx context.Context, filter valueobjects.ContentFilter) ([]*entities.ContentMatch, int, error) {
+	filter, err := filter.Normalize()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	type versionKey struct {
+		backupID string
+		path     string
+		hash     string
+	}
+
+	r.mu.Lock()
+	versions := make(map[versionKey]*entities.ContentMatch)
+	for _, e := range r.entries {
+		if !filter.MatchesBackup(e.BackupID) {
+			continue
+		}
+		content, ok := r.contents[contentKey{e.BackupID.String(), e.Hash}]
+		if !ok || !filter.MatchesContent(content.Content) {
+			continue
+		}
+		key := versionKey{e.BackupID.String(), e.Path, e.Hash}
+		match, ok := versions[key]
+		if !ok {
+			match = &entities.ContentMatch{BackupID: e.BackupID, Path: e.Path, Hash: e.Hash, Content: content.Content}
+			versions[key] = match
+		}
+		match.Snapshots = append(match.Snapshots, e.Snapshot)
+	}
+	r.mu.Unlock()
+
+	matches := make([]*entities.ContentMatch, 0, len(versions))
+	for _, m := range versions {
+		sort.Sort(sort.Reverse(sort.StringSlice(m.Snapshots)))
+		matches = append(matches, m)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.BackupID.String() != b.BackupID.String() {
+			return a.BackupID.String() < b.BackupID.String()
+		}
+		return a.Snapshots[0] > b.Snapshots[0]
+	})
+
+	total := len(matches)
+	start := filter.Offset()
+	if start > total {
+		start = total
+	}
+	end := start + filter.PageSize
+	if end > total {
+		end = total
+	}
+	return matches[start:end], total, nil
+}
@@ -0,0 +1,45 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+)
+
+type LegalHoldEventRepositoryMemory struct {
+	events []*entities.LegalHoldEvent
+	mu     sync.Mutex
+}
+
+func NewLegalHoldEventRepositoryMemory() *LegalHoldEventRepositoryMemory {
+	return &LegalHoldEventRepositoryMemory{
+		events: []*entities.LegalHoldEvent{},
+	}
+}
+
+func (r *LegalHoldEventRepositoryMemory) Save(ctx context.Context, event *entities.LegalHoldEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	r.events = append(r.events, event)
+	return nil
+}
+
+// FindByTarget returns the events of a host or backup, newest first.
+func (r *LegalHoldEventRepositoryMemory) FindByTarget(ctx context.Context, scope entities.LegalHoldScope, targetID string) ([]*entities.LegalHoldEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]*entities.LegalHoldEvent, 0)
+	for i := len(r.events) - 1; i >= 0; i-- {
+		e := r.events[i]
+		if e.Scope == scope && e.TargetID == targetID {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+)
+
+type ReplicaTargetRepositoryMemory struct {
+	mu      sync.RWMutex
+	targets map[uuid.UUID]*entities.ReplicaTarget
+}
+
+func NewReplicaTargetRepositoryMemory() *ReplicaTargetRepositoryMemory {
+	return &ReplicaTargetRepositoryMemory{
+		targets: make(map[uuid.UUID]*entities.ReplicaTarget),
+	}
+}
+
+func (r *ReplicaTargetRepositoryMemory) Save(ctx context.Context, target *entities.ReplicaTarget) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.targets[target.ID()] = target
+	return nil
+}
+
+func (r *ReplicaTargetRepositoryMemory) FindByID(ctx context.Context, id uuid.UUID) (*entities.ReplicaTarget, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	target, ok := r.targets[id]
+	if !ok {
+		return nil, shared.ErrNotFound
+	}
+	return target, nil
+}
+
+func (r *ReplicaTargetRepositoryMemory) FindAll(ctx context.Context) ([]*entities.ReplicaTarget, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	targets := make([]*entities.ReplicaTarget, 0, len(r.targets))
+	for _, target := range r.targets {
+		targets = append(targets, target)
+	}
+	sort.Slice(targets, func(i, k int) bool { return targets[i].CreatedAt().Before(targets[k].CreatedAt()) })
+	return targets, nil
+}
+
+func (r *ReplicaTargetRepositoryMemory) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.targets[id]; !ok {
+		return shared.ErrNotFound
+	}
+	delete(r.targets, id)
+	return nil
+}
+
+type replicaSnapshotKey struct {
+	targetID uuid.UUID
+	backupID valueobjects.BackupID
+	snapshot string
+}
+
+type ReplicaSnapshotRepositoryMemory struct {
+	mu        sync.RWMutex
+	snapshots map[replicaSnapshotKey]*entities.ReplicaSnapshot
+}
+
+func NewReplicaSnapshotRepositoryMemory() *ReplicaSnapshotRepositoryMemory {
+	return &ReplicaSnapshotRepositoryMemory{
+		snapshots: make(map[replicaSnapshotKey]*entities.ReplicaSnapshot),
+	}
+}
+
+func (r *ReplicaSnapshotRepositoryMemory) Save(ctx context.Context, snapshot *entities.ReplicaSnapshot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.snapshots[replicaSnapshotKey{snapshot.TargetID, snapshot.BackupID, snapshot.Snapshot}] = snapshot
+	return nil
+}
+
+func (r *ReplicaSnapshotRepositoryMemory) FindByBackupID(ctx context.Context, backupID valueobjects.BackupID) ([]*entities.ReplicaSnapshot, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	snapshots := make([]*entities.ReplicaSnapshot, 0)
+	for key, snapshot := range r.snapshots {
+		if key.backupID == backupID {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	sort.Slice(snapshots, func(i, k int) bool {
+		if snapshots[i].TargetID != snapshots[k].TargetID {
+			return snapshots[i].TargetID.String() < snapshots[k].TargetID.String()
+		}
+		return snapshots[i].Snapshot < snapshots[k].Snapshot
+	})
+	return snapshots, nil
+}
+
+func (r *ReplicaSnapshotRepositoryMemory) Delete(ctx context.Context, targetID uuid.UUID, backupID valueobjects.BackupID, snapshot string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.snapshots, replicaSnapshotKey{targetID, backupID, snapshot})
+	return nil
+}
+
+func (r *ReplicaSnapshotRepositoryMemory) DeleteByTarget(ctx context.Context, targetID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key := range r.snapshots {
+		if key.targetID == targetID {
+			delete(r.snapshots, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+)
+
+type ReplicationJobRepositoryMemory struct {
+	mu   sync.RWMutex
+	jobs map[uuid.UUID]*entities.ReplicationJob
+}
+
+func NewReplicationJobRepositoryMemory() *ReplicationJobRepositoryMemory {
+	return &ReplicationJobRepositoryMemory{
+		jobs: make(map[uuid.UUID]*entities.ReplicationJob),
+	}
+}
+
+func (r *ReplicationJobRepositoryMemory) Save(ctx context.Context, job *entities.ReplicationJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[job.ID()] = job
+	return nil
+}
+
+func (r *ReplicationJobRepositoryMemory) FindByID(ctx context.Context, id uuid.UUID) (*entities.ReplicationJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, shared.ErrNotFound
+	}
+	return job, nil
+}
+
+func (r *ReplicationJobRepositoryMemory) FindAll(ctx context.Context) ([]*entities.ReplicationJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	jobs := make([]*entities.ReplicationJob, 0, len(r.jobs))
+	for _, job := range r.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].CreatedAt().Before(jobs[k].CreatedAt()) })
+	return jobs, nil
+}
+
+func (r *ReplicationJobRepositoryMemory) FindDue(ctx context.Context, now time.Time) ([]*entities.ReplicationJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var due []*entities.ReplicationJob
+	for _, job := range r.jobs {
+		if job.Enabled() && job.NextRunAt() != nil && !job.NextRunAt().After(now) {
+			due = append(due, job)
+		}
+	}
+	return due, nil
+}
+
+func (r *ReplicationJobRepositoryMemory) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.jobs[id]; !ok {
+		return shared.ErrNotFound
+	}
+	delete(r.jobs, id)
+	return nil
+}
+
+type ReplicationRunRepositoryMemory struct {
+	mu   sync.RWMutex
+	runs []*entities.ReplicationRun
+}
+
+func NewReplicationRunRepositoryMemory() *ReplicationRunRepositoryMemory {
+	return &ReplicationRunRepositoryMemory{
+		runs: []*entities.ReplicationRun{},
+	}
+}
+
+func (r *ReplicationRunRepositoryMemory) Save(ctx context.Context, run *entities.ReplicationRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.runs {
+		if existing.ID == run.ID {
+			r.runs[i] = run
+			return nil
+		}
+	}
+	r.runs = append(r.runs, run)
+	return nil
+}
+
+func (r *ReplicationRunRepositoryMemory) FindByTaskID(ctx context.Context, taskID string) (*entities.ReplicationRun, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, run := range r.runs {
+		if taskID != "" && run.TaskID == taskID {
+			return run, nil
+		}
+	}
+	return nil, shared.ErrNotFound
+}
+
+func (r *ReplicationRunRepositoryMemory) FindByJobID(ctx context.Context, jobID uuid.UUID, limit int) ([]*entities.ReplicationRun, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	runs := make([]*entities.ReplicationRun, 0)
+	for i := len(r.runs) - 1; i >= 0 && (limit <= 0 || len(runs) < limit); i-- {
+		if r.runs[i].JobID == jobID {
+			runs = append(runs, r.runs[i])
+		}
+	}
+	return runs, nil
+}
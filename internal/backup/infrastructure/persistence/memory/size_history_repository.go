@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+)
+
+type SizeHistoryRepositoryMemory struct {
+	mu      sync.RWMutex
+	samples []*entities.SizeSample
+}
+
+func NewSizeHistoryRepositoryMemory() *SizeHistoryRepositoryMemory {
+	return &SizeHistoryRepositoryMemory{}
+}
+
+func (r *SizeHistoryRepositoryMemory) Save(ctx context.Context, sample *entities.SizeSample) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.samples = append(r.samples, sample)
+	return nil
+}
+
+func (r *SizeHistoryRepositoryMemory) FindByBackupID(ctx context.Context, backupID valueobjects.BackupID, since time.Time) ([]*entities.SizeSample, error) {
+	return r.find(since, func(s *entities.SizeSample) bool { return s.BackupID == backupID }), nil
+}
+
+func (r *SizeHistoryRepositoryMemory) FindByHostID(ctx context.Context, hostID entities.HostID, since time.Time) ([]*entities.SizeSample, error) {
+	return r.find(since, func(s *entities.SizeSample) bool { return s.HostID == hostID }), nil
+}
+
+func (r *SizeHistoryRepositoryMemory) FindSince(ctx context.Context, since time.Time) ([]*entities.SizeSample, error) {
+	return r.find(since, func(*entities.SizeSample) bool { return true }), nil
+}
+
+func (r *SizeHistoryRepositoryMemory) find(since time.Time, match func(*entities.SizeSample) bool) []*entities.SizeSample {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	samples := make([]*entities.SizeSample, 0)
+	for _, s := range r.samples {
+		if !s.SampledAt.Before(since) && match(s) {
+			samples = append(samples, s)
+		}
+	}
+	sort.SliceStable(samples, func(i, k int) bool { return samples[i].SampledAt.Before(samples[k].SampledAt) })
+	return samples
+}
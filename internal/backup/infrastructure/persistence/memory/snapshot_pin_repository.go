@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+)
+
+type SnapshotPinRepositoryMemory struct {
+	pins []*entities.SnapshotPin
+	mu   sync.Mutex
+}
+
+func NewSnapshotPinRepositoryMemory() *SnapshotPinRepositoryMemory {
+	return &SnapshotPinRepositoryMemory{
+		pins: []*entities.SnapshotPin{},
+	}
+}
+
+func (r *SnapshotPinRepositoryMemory) Save(ctx context.Context, pin *entities.SnapshotPin) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, p := range r.pins {
+		if p.BackupID.Equals(pin.BackupID) && p.Snapshot == pin.Snapshot {
+			r.pins[i] = pin
+			return nil
+		}
+	}
+	r.pins = append(r.pins, pin)
+	return nil
+}
+
+func (r *SnapshotPinRepositoryMemory) FindByBackupID(ctx context.Context, backupID valueobjects.BackupID) ([]*entities.SnapshotPin, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]*entities.SnapshotPin, 0)
+	for _, p := range r.pins {
+		if p.BackupID.Equals(backupID) {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+func (r *SnapshotPinRepositoryMemory) Delete(ctx context.Context, backupID valueobjects.BackupID, snapshot string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, p := range r.pins {
+		if p.BackupID.Equals(backupID) && p.Snapshot == snapshot {
+			r.pins = append(r.pins[:i], r.pins[i+1:]...)
+			return nil
+		}
+	}
+	return shared.ErrNotFound
+}
@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+)
+
+type StoragePoolRepositoryMemory struct {
+	mu    sync.RWMutex
+	pools map[uuid.UUID]*entities.StoragePool
+}
+
+func NewStoragePoolRepositoryMemory() *StoragePoolRepositoryMemory {
+	return &StoragePoolRepositoryMemory{
+		pools: make(map[uuid.UUID]*entities.StoragePool),
+	}
+}
+
+func (r *StoragePoolRepositoryMemory) Save(ctx context.Context, pool *entities.StoragePool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pools[pool.ID()] = pool
+	return nil
+}
+
+func (r *StoragePoolRepositoryMemory) FindByID(ctx context.Context, id uuid.UUID) (*entities.StoragePool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	pool, ok := r.pools[id]
+	if !ok {
+		return nil, shared.ErrNotFound
+	}
+	return pool, nil
+}
+
+func (r *StoragePoolRepositoryMemory) FindAll(ctx context.Context) ([]*entities.StoragePool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	pools := make([]*entities.StoragePool, 0, len(r.pools))
+	for _, pool := range r.pools {
+		pools = append(pools, pool)
+	}
+	sort.Slice(pools, func(i, k int) bool { return pools[i].Name() < pools[k].Name() })
+	return pools, nil
+}
+
+func (r *StoragePoolRepositoryMemory) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.pools[id]; !ok {
+		return shared.ErrNotFound
+	}
+	delete(r.pools, id)
+	return nil
+}
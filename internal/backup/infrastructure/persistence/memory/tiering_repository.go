@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+)
+
+type TieringPolicyRepositoryMemory struct {
+	mu       sync.RWMutex
+	policies map[string]valueobjects.TieringPolicy
+}
+
+func NewTieringPolicyRepositoryMemory() *TieringPolicyRepositoryMemory {
+	return &TieringPolicyRepositoryMemory{
+		policies: make(map[string]valueobjects.TieringPolicy),
+	}
+}
+
+func (r *TieringPolicyRepositoryMemory) Save(ctx context.Context, backupID valueobjects.BackupID, policy valueobjects.TieringPolicy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.policies[backupID.String()] = policy
+	return nil
+}
+
+func (r *TieringPolicyRepositoryMemory) FindByBackupID(ctx context.Context, backupID valueobjects.BackupID) (*valueobjects.TieringPolicy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	policy, ok := r.policies[backupID.String()]
+	if !ok {
+		return nil, shared.ErrNotFound
+	}
+	return &policy, nil
+}
+
+func (r *TieringPolicyRepositoryMemory) FindAll(ctx context.Context) (map[string]valueobjects.TieringPolicy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	policies := make(map[string]valueobjects.TieringPolicy, len(r.policies))
+	for id, policy := range r.policies {
+		policies[id] = policy
+	}
+	return policies, nil
+}
+
+func (r *TieringPolicyRepositoryMemory) Delete(ctx context.Context, backupID valueobjects.BackupID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.policies[backupID.String()]; !ok {
+		return shared.ErrNotFound
+	}
+	delete(r.policies, backupID.String())
+	return nil
+}
+
+type archivedSnapshotKey struct {
+	backupID valueobjects.BackupID
+	snapshot string
+}
+
+type ArchivedSnapshotRepositoryMemory struct {
+	mu        sync.RWMutex
+	snapshots map[archivedSnapshotKey]*entities.ArchivedSnapshot
+}
+
+func NewArchivedSnapshotRepositoryMemory() *ArchivedSnapshotRepositoryMemory {
+	return &ArchivedSnapshotRepositoryMemory{
+		snapshots: make(map[archivedSnapshotKey]*entities.ArchivedSnapshot),
+	}
+}
+
+func (r *ArchivedSnapshotRepositoryMemory) Save(ctx context.Context, snapshot *entities.ArchivedSnapshot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.snapshots[archivedSnapshotKey{snapshot.BackupID, snapshot.Snapshot}] = snapshot
+	return nil
+}
+
+func (r *ArchivedSnapshotRepositoryMemory) FindByBackupID(ctx context.Context, backupID valueobjects.BackupID) ([]*entities.ArchivedSnapshot, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	snapshots := make([]*entities.ArchivedSnapshot, 0)
+	for key, snapshot := range r.snapshots {
+		if key.backupID == backupID {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	sort.Slice(snapshots, func(i, k int) bool { return snapshots[i].Snapshot < snapshots[k].Snapshot })
+	return snapshots, nil
+}
+
+func (r *ArchivedSnapshotRepositoryMemory) Delete(ctx context.Context, backupID valueobjects.BackupID, snapshot string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.snapshots, archivedSnapshotKey{backupID, snapshot})
+	return nil
+}
@@ -29,8 +29,8 @@ func NewBackupRepositoryPostgres(db *sql.DB, encryptionService crypto.Encryption
 
 func (r *BackupRepositoryPostgres) Save(ctx context.Context, backup *entities.Backup) error {
 	query := `
-		INSERT INTO backups (id, host_id, path, destination, status, schedule, created_at, updated_at, last_run, next_run_at, excludes, enabled, incremental, size, retention, encrypted)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		INSERT INTO backups (id, host_id, path, destination, status, schedule, created_at, updated_at, last_run, next_run_at, excludes, enabled, incremental, size_bytes, unique_bytes, stored_bytes, retention, encrypted, keep_daily, keep_weekly, keep_monthly, keep_yearly, legal_hold, content_index, storage_pool_id, quota_bytes, quota_snapshot_bytes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28)
 		ON CONFLICT (id) DO UPDATE SET
 			host_id = EXCLUDED.host_id,
 			path = EXCLUDED.path,
@@ -43,9 +43,21 @@ func (r *BackupRepositoryPostgres) Save(ctx context.Context, backup *entities.Ba
 			excludes = EXCLUDED.excludes,
 			enabled = EXCLUDED.enabled,
 			incremental = EXCLUDED.incremental,
-			size = EXCLUDED.size,
+			size_bytes = EXCLUDED.size_bytes,
+			unique_bytes = EXCLUDED.unique_bytes,
+			stored_bytes = EXCLUDED.stored_bytes,
 			retention = EXCLUDED.retention,
-			encrypted = EXCLUDED.encrypted
+			encrypted = EXCLUDED.encrypted,
+			keep_daily = EXCLUDED.keep_daily,
+			keep_weekly = EXCLUDED.keep_weekly,
+			keep_monthly = EXCLUDED.keep_monthly,
+			keep_yearly = EXCLUDED.keep_yearly,
+			legal_hold = EXCLUDED.legal_hold,
+			content_index = EXCLUDED.content_index,
+			storage_pool_id = EXCLUDED.storage_pool_id,
+			quota_bytes = EXCLUDED.quota_bytes,
+			quota_snapshot_bytes = EXCLUDED.quota_snapshot_bytes,
+			migrating = EXCLUDED.migrating
 	`
 
 	var lastRun *time.Time
@@ -68,9 +80,21 @@ func (r *BackupRepositoryPostgres) Save(ctx context.Context, backup *entities.Ba
 		pq.Array(backup.Excludes()),
 		backup.Enabled(),
 		backup.Incremental(),
-		backup.Size(),
+		backup.Usage().Size,
+		backup.Usage().Unique,
+		backup.Usage().Stored,
 		backup.Retention(),
 		backup.Encrypted(),
+		backup.RetentionPolicy().KeepDaily,
+		backup.RetentionPolicy().KeepWeekly,
+		backup.RetentionPolicy().KeepMonthly,
+		backup.RetentionPolicy().KeepYearly,
+		backup.LegalHold(),
+		backup.ContentIndex(),
+		nullString(backup.StoragePoolID()),
+		backup.Quota().Bytes,
+		backup.Quota().SnapshotBytes,
+		backup.Migrating(),
 	)
 	if err != nil {
 		return err
@@ -128,7 +152,7 @@ func (r *BackupRepositoryPostgres) saveHooks(ctx context.Context, backup *entiti
 
 func (r *BackupRepositoryPostgres) FindByID(ctx context.Context, id valueobjects.BackupID) (*entities.Backup, error) {
 	query := `
-		SELECT id, host_id, path, destination, status, schedule, created_at, updated_at, last_run, next_run_at, excludes, enabled, incremental, size, retention, encrypted
+		SELECT id, host_id, path, destination, status, schedule, created_at, updated_at, last_run, next_run_at, excludes, enabled, incremental, size_bytes, unique_bytes, stored_bytes, retention, encrypted, keep_daily, keep_weekly, keep_monthly, keep_yearly, legal_hold, content_index, storage_pool_id, quota_bytes, quota_snapshot_bytes, migrating
 		FROM backups WHERE id = $1
 	`
 	backup, err := r.scanBackup(r.db.QueryRowContext(ctx, query, id.String()))
@@ -191,7 +215,7 @@ func (r *BackupRepositoryPostgres) loadHooks(ctx context.Context, backup *entiti
 
 func (r *BackupRepositoryPostgres) FindByHostID(ctx context.Context, hostID entities.HostID) ([]*entities.Backup, error) {
 	query := `
-		SELECT id, host_id, path, destination, status, schedule, created_at, updated_at, last_run, next_run_at, excludes, enabled, incremental, size, retention, encrypted
+		SELECT id, host_id, path, destination, status, schedule, created_at, updated_at, last_run, next_run_at, excludes, enabled, incremental, size_bytes, unique_bytes, stored_bytes, retention, encrypted, keep_daily, keep_weekly, keep_monthly, keep_yearly, legal_hold, content_index, storage_pool_id, quota_bytes, quota_snapshot_bytes, migrating
 		FROM backups WHERE host_id = $1
 	`
 	rows, err := r.db.QueryContext(ctx, query, hostID.String())
@@ -214,7 +238,7 @@ func (r *BackupRepositoryPostgres) FindByHostID(ctx context.Context, hostID enti
 
 func (r *BackupRepositoryPostgres) FindAll(ctx context.Context) ([]*entities.Backup, error) {
 	query := `
-		SELECT id, host_id, path, destination, status, schedule, created_at, updated_at, last_run, next_run_at, excludes, enabled, incremental, size, retention, encrypted
+		SELECT id, host_id, path, destination, status, schedule, created_at, updated_at, last_run, next_run_at, excludes, enabled, incremental, size_bytes, unique_bytes, stored_bytes, retention, encrypted, keep_daily, keep_weekly, keep_monthly, keep_yearly, legal_hold, content_index, storage_pool_id, quota_bytes, quota_snapshot_bytes, migrating
 		FROM backups
 	`
 	rows, err := r.db.QueryContext(ctx, query)
@@ -237,7 +261,7 @@ func (r *BackupRepositoryPostgres) FindAll(ctx context.Context) ([]*entities.Bac
 
 func (r *BackupRepositoryPostgres) FindDueBackups(ctx context.Context) ([]*entities.Backup, error) {
 	query := `
-		SELECT id, host_id, path, destination, status, schedule, created_at, updated_at, last_run, next_run_at, excludes, enabled, incremental, size, retention, encrypted
+		SELECT id, host_id, path, destination, status, schedule, created_at, updated_at, last_run, next_run_at, excludes, enabled, incremental, size_bytes, unique_bytes, stored_bytes, retention, encrypted, keep_daily, keep_weekly, keep_monthly, keep_yearly, legal_hold, content_index, storage_pool_id, quota_bytes, quota_snapshot_bytes, migrating
 		FROM backups
 		WHERE enabled = TRUE AND next_run_at <= NOW()
 	`
@@ -284,7 +308,8 @@ func (r *BackupRepositoryPostgres) GetStats(ctx context.Context) (*entities.Back
 			COUNT(*) AS total,
 			COUNT(*) FILTER (WHERE status = 'pending') AS pending,
 			COUNT(*) FILTER (WHERE status = 'completed') AS completed,
-			COUNT(*) FILTER (WHERE status = 'failed') AS failed
+			COUNT(*) FILTER (WHERE status = 'failed') AS failed,
+			COALESCE(SUM(stored_bytes), 0) AS total_size
 		FROM backups
 	`
 
@@ -294,30 +319,12 @@ func (r *BackupRepositoryPostgres) GetStats(ctx context.Context) (*entities.Back
 		&stats.Pending,
 		&stats.Completed,
 		&stats.Failed,
+		&stats.TotalSize,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	sizeQuery := `SELECT size FROM backups WHERE status = 'completed' AND size IS NOT NULL`
-	rows, err := r.db.QueryContext(ctx, sizeQuery)
-	if err != nil {
-		return nil, err
-	}
-	defer func() { _ = rows.Close() }()
-
-	var totalSize int64
-	for rows.Next() {
-		var sizeStr string
-		if err := rows.Scan(&sizeStr); err != nil {
-			continue
-		}
-		s, _ := shared.ParseSize(sizeStr)
-		totalSize += s
-	}
-
-	stats.TotalSize = shared.FormatSize(totalSize)
-
 	return stats, nil
 }
 
@@ -351,11 +358,14 @@ func (r *BackupRepositoryPostgres) scanBackup(row *sql.Row) (*entities.Backup, e
 	var createdAt, updatedAt time.Time
 	var lastRun, nextRunAt *time.Time
 	var excludes []string
-	var enabled, incremental, encrypted bool
-	var size sql.NullString
+	var enabled, incremental, encrypted, legalHold, contentIndex, migrating bool
+	var storagePool sql.NullString
+	var quota valueobjects.Quota
+	var usage valueobjects.SpaceUsage
 	var retention sql.NullInt64
+	var policy valueobjects.RetentionPolicy
 
-	err := row.Scan(&idStr, &hostIDStr, &path, &destination, &statusStr, &scheduleCron, &createdAt, &updatedAt, &lastRun, &nextRunAt, pq.Array(&excludes), &enabled, &incremental, &size, &retention, &encrypted)
+	err := row.Scan(&idStr, &hostIDStr, &path, &destination, &statusStr, &scheduleCron, &createdAt, &updatedAt, &lastRun, &nextRunAt, pq.Array(&excludes), &enabled, &incremental, &usage.Size, &usage.Unique, &usage.Stored, &retention, &encrypted, &policy.KeepDaily, &policy.KeepWeekly, &policy.KeepMonthly, &policy.KeepYearly, &legalHold, &contentIndex, &storagePool, &quota.Bytes, &quota.SnapshotBytes, &migrating)
 	if err == sql.ErrNoRows {
 		return nil, shared.ErrNotFound
 	}
@@ -363,7 +373,7 @@ func (r *BackupRepositoryPostgres) scanBackup(row *sql.Row) (*entities.Backup, e
 		return nil, err
 	}
 
-	return r.mapToEntity(idStr, hostIDStr, path, destination, statusStr, scheduleCron, createdAt, updatedAt, lastRun, nextRunAt, excludes, enabled, incremental, size.String, int(retention.Int64), encrypted)
+	return r.mapToEntity(idStr, hostIDStr, path, destination, statusStr, scheduleCron, createdAt, updatedAt, lastRun, nextRunAt, excludes, enabled, incremental, usage, policy, int(retention.Int64), encrypted, legalHold, contentIndex, storagePool.String, quota, migrating)
 }
 
 func (r *BackupRepositoryPostgres) scanBackups(rows *sql.Rows) ([]*entities.Backup, error) {
@@ -373,15 +383,18 @@ func (r *BackupRepositoryPostgres) scanBackups(rows *sql.Rows) ([]*entities.Back
 		var createdAt, updatedAt time.Time
 		var lastRun, nextRunAt *time.Time
 		var excludes []string
-		var enabled, incremental, encrypted bool
-		var size sql.NullString
+		var enabled, incremental, encrypted, legalHold, contentIndex, migrating bool
+		var storagePool sql.NullString
+		var quota valueobjects.Quota
+		var usage valueobjects.SpaceUsage
 		var retention sql.NullInt64
+		var policy valueobjects.RetentionPolicy
 
-		if err := rows.Scan(&idStr, &hostIDStr, &path, &destination, &statusStr, &scheduleCron, &createdAt, &updatedAt, &lastRun, &nextRunAt, pq.Array(&excludes), &enabled, &incremental, &size, &retention, &encrypted); err != nil {
+		if err := rows.Scan(&idStr, &hostIDStr, &path, &destination, &statusStr, &scheduleCron, &createdAt, &updatedAt, &lastRun, &nextRunAt, pq.Array(&excludes), &enabled, &incremental, &usage.Size, &usage.Unique, &usage.Stored, &retention, &encrypted, &policy.KeepDaily, &policy.KeepWeekly, &policy.KeepMonthly, &policy.KeepYearly, &legalHold, &contentIndex, &storagePool, &quota.Bytes, &quota.SnapshotBytes, &migrating); err != nil {
 			return nil, err
 		}
 
-		backup, err := r.mapToEntity(idStr, hostIDStr, path, destination, statusStr, scheduleCron, createdAt, updatedAt, lastRun, nextRunAt, excludes, enabled, incremental, size.String, int(retention.Int64), encrypted)
+		backup, err := r.mapToEntity(idStr, hostIDStr, path, destination, statusStr, scheduleCron, createdAt, updatedAt, lastRun, nextRunAt, excludes, enabled, incremental, usage, policy, int(retention.Int64), encrypted, legalHold, contentIndex, storagePool.String, quota, migrating)
 		if err != nil {
 			return nil, err
 		}
@@ -390,7 +403,7 @@ func (r *BackupRepositoryPostgres) scanBackups(rows *sql.Rows) ([]*entities.Back
 	return backups, nil
 }
 
-func (r *BackupRepositoryPostgres) mapToEntity(idStr, hostIDStr, path, destination, statusStr, scheduleCron string, createdAt, updatedAt time.Time, lastRun, nextRunAt *time.Time, excludes []string, enabled, incremental bool, size string, retention int, encrypted bool) (*entities.Backup, error) {
+func (r *BackupRepositoryPostgres) mapToEntity(idStr, hostIDStr, path, destination, statusStr, scheduleCron string, createdAt, updatedAt time.Time, lastRun, nextRunAt *time.Time, excludes []string, enabled, incremental bool, usage valueobjects.SpaceUsage, policy valueobjects.RetentionPolicy, retention int, encrypted, legalHold, contentIndex bool, storagePool string, quota valueobjects.Quota, migrating bool) (*entities.Backup, error) {
 	bid, err := valueobjects.NewBackupIDFromString(idStr)
 	if err != nil {
 		return nil, err
@@ -405,7 +418,7 @@ func (r *BackupRepositoryPostgres) mapToEntity(idStr, hostIDStr, path, destinati
 		schedule.LastRun = *lastRun
 	}
 
-	return entities.RestoreBackup(
+	backup := entities.RestoreBackup(
 		bid,
 		hid,
 		path,
@@ -418,8 +431,21 @@ func (r *BackupRepositoryPostgres) mapToEntity(idStr, hostIDStr, path, destinati
 		excludes,
 		enabled,
 		incremental,
-		size,
+		usage,
 		retention,
 		encrypted,
-	), nil
+	)
+	policy.KeepLast = retention
+	backup.SetRetentionPolicy(policy)
+	backup.SetLegalHold(legalHold)
+	backup.SetContentIndex(contentIndex)
+	backup.SetStoragePool(storagePool)
+	backup.SetQuota(quota)
+	backup.SetMigrating(migrating)
+	return backup, nil
+}
+
+// nullString stores an empty optional reference as NULL.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
 }
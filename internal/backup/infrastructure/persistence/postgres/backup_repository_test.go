@@ -2,6 +2,7 @@ package postgres_test
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"testing"
@@ -65,7 +66,7 @@ func TestBackupRepositoryPostgres_Save_HookErrors(t *testing.T) {
 		[]string{},
 		true,
 		false,
-		"",
+		valueobjects.SpaceUsage{},
 		0,
 		false,
 	)
@@ -109,10 +110,11 @@ func TestBackupRepositoryPostgres_LoadHooks_Errors(t *testing.T) {
 		rows := sqlmock.NewRows([]string{
 			"id", "host_id", "path", "destination", "status", "schedule",
 			"created_at", "updated_at", "last_run", "next_run_at", "excludes",
-			"enabled", "incremental", "size", "retention", "encrypted",
+			"enabled", "incremental", "size_bytes", "unique_bytes", "stored_bytes", "retention", "encrypted",
+			"keep_daily", "keep_weekly", "keep_monthly", "keep_yearly", "legal_hold", "content_index", "storage_pool_id", "quota_bytes", "quota_snapshot_bytes", "migrating",
 		}).AddRow(
 			backupID.String(), entities.NewHostID().String(), "/src", "/dst", "pending", "0 0 * * *",
-			time.Now(), time.Now(), nil, nil, "{}", true, false, nil, 0, false,
+			time.Now(), time.Now(), nil, nil, "{}", true, false, 0, 0, 0, 0, false, 0, 0, 0, 0, false, false, nil, 0, 0, false,
 		)
 		mockDB.ExpectQuery("SELECT .* FROM backups WHERE id =").WillReturnRows(rows)
 
@@ -134,10 +136,11 @@ func TestBackupRepositoryPostgres_LoadHooks_Errors(t *testing.T) {
 		rows := sqlmock.NewRows([]string{
 			"id", "host_id", "path", "destination", "status", "schedule",
 			"created_at", "updated_at", "last_run", "next_run_at", "excludes",
-			"enabled", "incremental", "size", "retention", "encrypted",
+			"enabled", "incremental", "size_bytes", "unique_bytes", "stored_bytes", "retention", "encrypted",
+			"keep_daily", "keep_weekly", "keep_monthly", "keep_yearly", "legal_hold", "content_index", "storage_pool_id", "quota_bytes", "quota_snapshot_bytes", "migrating",
 		}).AddRow(
 			backupID.String(), entities.NewHostID().String(), "/src", "/dst", "pending", "0 0 * * *",
-			time.Now(), time.Now(), nil, nil, "{}", true, false, nil, 0, false,
+			time.Now(), time.Now(), nil, nil, "{}", true, false, 0, 0, 0, 0, false, 0, 0, 0, 0, false, false, nil, 0, 0, false,
 		)
 		mockDB.ExpectQuery("SELECT .* FROM backups WHERE id =").WillReturnRows(rows)
 
@@ -179,10 +182,11 @@ func TestBackupRepositoryPostgres_Save_Success(t *testing.T) {
 		[]string{"*.tmp"},
 		true,
 		false,
-		"1GB",
+		valueobjects.SpaceUsage{Size: 1073741824, Unique: 1048576, Stored: 1073741824},
 		5,
 		true,
 	)
+	backup.SetRetentionPolicy(valueobjects.RetentionPolicy{KeepLast: 5, KeepDaily: 7, KeepWeekly: 4})
 
 	// Add a hook
 	hook := entities.BackupHook{
@@ -211,9 +215,21 @@ func TestBackupRepositoryPostgres_Save_Success(t *testing.T) {
 			sqlmock.AnyArg(), // Excludes (pq.Array)
 			backup.Enabled(),
 			backup.Incremental(),
-			backup.Size(),
+			backup.Usage().Size,
+			backup.Usage().Unique,
+			backup.Usage().Stored,
 			backup.Retention(),
 			backup.Encrypted(),
+			7, // KeepDaily
+			4, // KeepWeekly
+			0, // KeepMonthly
+			0, // KeepYearly
+			false,
+			false,
+			sql.NullString{}, // StoragePoolID
+			int64(0),         // QuotaBytes
+			int64(0),         // QuotaSnapshotBytes
+			false,            // Migrating
 		).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -261,15 +277,17 @@ func TestBackupRepositoryPostgres_FindByID_Success(t *testing.T) {
 
 	backupID := valueobjects.NewBackupID()
 	hostID := entities.NewHostID()
+	poolID := uuid.New()
 
 	// Backup Row Mock
 	rows := sqlmock.NewRows([]string{
 		"id", "host_id", "path", "destination", "status", "schedule",
 		"created_at", "updated_at", "last_run", "next_run_at", "excludes",
-		"enabled", "incremental", "size", "retention", "encrypted",
+		"enabled", "incremental", "size_bytes", "unique_bytes", "stored_bytes", "retention", "encrypted",
+		"keep_daily", "keep_weekly", "keep_monthly", "keep_yearly", "legal_hold", "content_index", "storage_pool_id", "quota_bytes", "quota_snapshot_bytes", "migrating",
 	}).AddRow(
 		backupID.String(), hostID.String(), "/src", "/dst", "pending", "0 0 * * *",
-		time.Now(), time.Now(), nil, nil, "{*.log}", true, false, "500MB", 3, false,
+		time.Now(), time.Now(), nil, nil, "{*.log}", true, false, 524288000, 1048576, 734003200, 3, false, 7, 4, 12, 0, true, true, poolID.String(), 10737418240, 1073741824, true,
 	)
 	mockDB.ExpectQuery("SELECT .* FROM backups WHERE id =").
 		WithArgs(backupID.String()).
@@ -303,6 +321,11 @@ func TestBackupRepositoryPostgres_FindByID_Success(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, backup)
 	assert.Equal(t, backupID.String(), backup.ID().String())
+	assert.Equal(t, valueobjects.RetentionPolicy{KeepLast: 3, KeepDaily: 7, KeepWeekly: 4, KeepMonthly: 12}, backup.RetentionPolicy())
+	assert.Equal(t, poolID.String(), backup.StoragePoolID())
+	assert.Equal(t, valueobjects.Quota{Bytes: 10737418240, SnapshotBytes: 1073741824}, backup.Quota())
+	assert.True(t, backup.Migrating())
+	assert.Equal(t, valueobjects.SpaceUsage{Size: 524288000, Unique: 1048576, Stored: 734003200}, backup.Usage())
 	assert.Len(t, backup.Hooks(), 1)
 	assert.Equal(t, "decrypted_value", backup.Hooks()[0].Params["key"])
 
@@ -347,16 +370,10 @@ func TestBackupRepositoryPostgres_GetStats(t *testing.T) {
 	repo := postgres.NewBackupRepositoryPostgres(db, nil)
 
 	// Count query mock
-	rows := sqlmock.NewRows([]string{"total", "pending", "completed", "failed"}).
-		AddRow(10, 2, 5, 3)
+	rows := sqlmock.NewRows([]string{"total", "pending", "completed", "failed", "total_size"}).
+		AddRow(10, 2, 5, 3, 1572864000)
 	mockDB.ExpectQuery("SELECT .* FROM backups").WillReturnRows(rows)
 
-	// Size query mock
-	sizeRows := sqlmock.NewRows([]string{"size"}).
-		AddRow("1 GB").
-		AddRow("500 MB")
-	mockDB.ExpectQuery("SELECT size FROM backups").WillReturnRows(sizeRows)
-
 	stats, err := repo.GetStats(context.Background())
 	assert.NoError(t, err)
 	assert.NotNil(t, stats)
@@ -364,5 +381,5 @@ func TestBackupRepositoryPostgres_GetStats(t *testing.T) {
 	assert.Equal(t, 2, stats.Pending)
 	assert.Equal(t, 5, stats.Completed)
 	assert.Equal(t, 3, stats.Failed)
-	assert.NotEmpty(t, stats.TotalSize)
+	assert.Equal(t, int64(1572864000), stats.TotalSize)
 }
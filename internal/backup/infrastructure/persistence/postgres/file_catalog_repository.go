@@ -0,0 +1,294 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+)
+
+// catalogInsertChunk bounds the rows per INSERT statement, keeping the number
+// of bind parameters well below the PostgreSQL limit.
+const catalogInsertChunk = 500
+
+type FileCatalogRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewFileCatalogRepositoryPostgres(db *sql.DB) *FileCatalogRepositoryPostgres {
+	return &FileCatalogRepositoryPostgres{db: db}
+}
+
+func (r *FileCatalogRepositoryPostgres) SaveBatch(ctx context.Context, entries []*entities.CatalogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for start := 0; start < len(entries); start += catalogInsertChunk {
+		end := start + catalogInsertChunk
+		if end > len(entries) {
+			end = len(entries)
+		}
+		chunk := entries[start:end]
+
+		values := make([]string, 0, len(chunk))
+		args := make([]interface{}, 0, len(chunk)*7)
+		for i, e := range chunk {
+			n := i * 7
+			values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6, n+7))
+			args = append(args, e.BackupID.String(), e.Snapshot, e.Path, e.Name(), e.Size, e.ModTime, e.Hash)
+		}
+
+		query := `
+			INSERT INTO file_catalog (backup_id, snapshot, path, name, size, mod_time, hash)
+			VALUES ` + strings.Join(values, ", ") + `
+			ON CONFLICT (backup_id, snapshot, path) DO UPDATE SET
+				name = EXCLUDED.name,
+				size = EXCLUDED.size,
+				mod_time = EXCLUDED.mod_time,
+				hash = EXCLUDED.hash
+		`
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("failed to save catalog entries: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit catalog entries: %w", err)
+	}
+	return nil
+}
+
+func (r *FileCatalogRepositoryPostgres) DeleteSnapshot(ctx context.Context, backupID valueobjects.BackupID, snapshot string) error {
+	query := `DELETE FROM file_catalog WHERE backup_id = $1 AND snapshot = $2`
+	if _, err := r.db.ExecContext(ctx, query, backupID.String(), snapshot); err != nil {
+		return fmt.Errorf("failed to delete catalog snapshot: %w", err)
+	}
+	return nil
+}
+
+func (r *FileCatalogRepositoryPostgres) Search(ctx context.Context, filter valueobjects.CatalogFilter) ([]*entities.CatalogEntry, int, error) {
+	filter, err := filter.Normalize()
+	if err != nil {
+		return nil, 0, err
+	}
+	if filter.BackupIDs != nil && len(filter.BackupIDs) == 0 {
+		return []*entities.CatalogEntry{}, 0, nil
+	}
+
+	where, args := catalogWhere(filter)
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM file_catalog` + where
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count catalog entries: %w", err)
+	}
+
+	query := fmt.Sprintf(`SELECT backup_id, snapshot, path, size, mod_time, hash FROM file_catalog%s ORDER BY path, snapshot DESC, backup_id LIMIT $%d OFFSET $%d`,
+		where, len(args)+1, len(args)+2)
+	rows, err := r.db.QueryContext(ctx, query, append(args, filter.PageSize, filter.Offset())...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query catalog entries: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	entries := make([]*entities.CatalogEntry, 0)
+	for rows.Next() {
+		var e entities.CatalogEntry
+		var backupIDStr string
+		if err := rows.Scan(&backupIDStr, &e.Snapshot, &e.Path, &e.Size, &e.ModTime, &e.Hash); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan catalog entry: %w", err)
+		}
+		bid, err := valueobjects.NewBackupIDFromString(backupIDStr)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to parse backup ID: %w", err)
+		}
+		e.BackupID = bid
+		entries = append(entries, &e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating catalog entries: %w", err)
+	}
+
+	return entries, total, nil
+}
+
+// catalogWhere builds the WHERE clause of a catalog search and its arguments.
+// The glob and regex filters are served by the trigram indexes on name and
+// path.
+func catalogWhere(filter valueobjects.CatalogFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.BackupIDs != nil {
+		ids := make([]string, 0, len(filter.BackupIDs))
+		for _, id := range filter.BackupIDs {
+			ids = append(ids, id.String())
+		}
+		add("backup_id = ANY($%d::uuid[])", pq.Array(ids))
+	}
+	if filter.Glob != "" {
+		add("name ~ $%d", valueobjects.GlobToRegex(filter.Glob))
+	}
+	if filter.Regex != "" {
+		add("path ~ $%d", filter.Regex)
+	}
+	if filter.ModifiedAfter != nil {
+		add("mod_time >= $%d", *filter.ModifiedAfter)
+	}
+	if filter.ModifiedBefore != nil {
+		add("mod_time <= $%d", *filter.ModifiedBefore)
+	}
+	if filter.MinSize != nil {
+		add("size >= $%d", *filter.MinSize)
+	}
+	if filter.MaxSize != nil {
+		add("size <= $%d", *filter.MaxSize)
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func (r *FileCatalogRepositoryPostgres) SaveContents(ctx context.Context, contents []*entities.FileContent) error {
+	if len(contents) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for start := 0; start < len(contents); start += catalogInsertChunk {
+		end := start + catalogInsertChunk
+		if end > len(contents) {
+			end = len(contents)
+		}
+		chunk := contents[start:end]
+
+		values := make([]string, 0, len(chunk))
+		args := make([]interface{}, 0, len(chunk)*3)
+		for i, c := range chunk {
+			n := i * 3
+			values = append(values, fmt.Sprintf("($%d, $%d, $%d)", n+1, n+2, n+3))
+			args = append(args, c.BackupID.String(), c.Hash, c.Content)
+		}
+
+		query := `
+			INSERT INTO file_contents (backup_id, hash, content)
+			VALUES ` + strings.Join(values, ", ") + `
+			ON CONFLICT (backup_id, hash) DO NOTHING
+		`
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("failed to save file contents: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit file contents: %w", err)
+	}
+	return nil
+}
+
+func (r *FileCatalogRepositoryPostgres) PruneContents(ctx context.Context, backupID valueobjects.BackupID) error {
+	query := `
+		DELETE FROM file_contents fc
+		WHERE fc.backup_id = $1
+		  AND NOT EXISTS (
+			SELECT 1 FROM file_catalog c WHERE c.backup_id = fc.backup_id AND c.hash = fc.hash
+		  )
+	`
+	if _, err := r.db.ExecContext(ctx, query, backupID.String()); err != nil {
+		return fmt.Errorf("failed to prune file contents: %w", err)
+	}
+	return nil
+}
+
+func (r *FileCatalogRepositoryPostgres) DeleteContents(ctx context.Context, backupID valueobjects.BackupID) error {
+	query := `DELETE FROM file_contents WHERE backup_id = $1`
+	if _, err := r.db.ExecContext(ctx, query, backupID.String()); err != nil {
+		return fmt.Errorf("failed to delete file contents: %w", err)
+	}
+	return nil
+}
+
+func (r *FileCatalogRepositoryPostgres) SearchContents(ctx context.Context, filter valueobjects.ContentFilter) ([]*entities.ContentMatch, int, error) {
+	filter, err := filter.Normalize()
+	if err != nil {
+		return nil, 0, err
+	}
+	if filter.BackupIDs != nil && len(filter.BackupIDs) == 0 {
+		return []*entities.ContentMatch{}, 0, nil
+	}
+
+	// The ILIKE on file_contents is answered by the trigram index; the
+	// catalog then supplies the paths and snapshots holding each version.
+	where := ` WHERE fc.content ILIKE $1`
+	args := []interface{}{filter.LikePattern()}
+	if filter.BackupIDs != nil {
+		ids := make([]string, 0, len(filter.BackupIDs))
+		for _, id := range filter.BackupIDs {
+			ids = append(ids, id.String())
+		}
+		args = append(args, pq.Array(ids))
+		where += fmt.Sprintf(` AND fc.backup_id = ANY($%d::uuid[])`, len(args))
+	}
+	from := ` FROM file_contents fc JOIN file_catalog c ON c.backup_id = fc.backup_id AND c.hash = fc.hash` + where
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM (SELECT 1` + from + ` GROUP BY fc.backup_id, fc.hash, c.path) versions`
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count content matches: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT fc.backup_id, c.path, fc.hash, array_agg(c.snapshot ORDER BY c.snapshot DESC), fc.content%s
+		GROUP BY fc.backup_id, fc.hash, c.path
+		ORDER BY c.path, fc.backup_id, max(c.snapshot) DESC
+		LIMIT $%d OFFSET $%d`, from, len(args)+1, len(args)+2)
+	rows, err := r.db.QueryContext(ctx, query, append(args, filter.PageSize, filter.Offset())...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query content matches: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	matches := make([]*entities.ContentMatch, 0)
+	for rows.Next() {
+		var m entities.ContentMatch
+		var backupIDStr string
+		if err := rows.Scan(&backupIDStr, &m.Path, &m.Hash, pq.Array(&m.Snapshots), &m.Content); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan content match: %w", err)
+		}
+		bid, err := valueobjects.NewBackupIDFromString(backupIDStr)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to parse backup ID: %w", err)
+		}
+		m.BackupID = bid
+		matches = append(matches, &m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating content matches: %w", err)
+	}
+
+	return matches, total, nil
+}
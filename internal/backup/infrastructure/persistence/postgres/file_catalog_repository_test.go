@@ -0,0 +1,113 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/backup/infrastructure/persistence/postgres"
+)
+
+func TestFileCatalogRepositoryPostgres_SaveBatch(t *testing.T) {
+	db, mockDB, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	repo := postgres.NewFileCatalogRepositoryPostgres(db)
+	backupID := valueobjects.NewBackupID()
+	modTime := time.Now()
+	entries := []*entities.CatalogEntry{
+		{BackupID: backupID, Snapshot: "2024-01-01_00-00-00", Path: "etc/hosts", Size: 10, ModTime: modTime, Hash: "aa"},
+		{BackupID: backupID, Snapshot: "2024-01-01_00-00-00", Path: "etc/nginx/nginx.conf", Size: 20, ModTime: modTime, Hash: "bb"},
+	}
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectExec(`INSERT INTO file_catalog \(backup_id, snapshot, path, name, size, mod_time, hash\)\s+VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7\), \(\$8, \$9, \$10, \$11, \$12, \$13, \$14\)\s+ON CONFLICT`).
+		WithArgs(
+			backupID.String(), "2024-01-01_00-00-00", "etc/hosts", "hosts", int64(10), modTime, "aa",
+			backupID.String(), "2024-01-01_00-00-00", "etc/nginx/nginx.conf", "nginx.conf", int64(20), modTime, "bb",
+		).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mockDB.ExpectCommit()
+
+	assert.NoError(t, repo.SaveBatch(context.Background(), entries))
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestFileCatalogRepositoryPostgres_DeleteSnapshot(t *testing.T) {
+	db, mockDB, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	repo := postgres.NewFileCatalogRepositoryPostgres(db)
+	backupID := valueobjects.NewBackupID()
+
+	mockDB.ExpectExec(`DELETE FROM file_catalog WHERE backup_id = \$1 AND snapshot = \$2`).
+		WithArgs(backupID.String(), "2024-01-01_00-00-00").
+		WillReturnResult(sqlmock.NewResult(0, 12))
+
+	assert.NoError(t, repo.DeleteSnapshot(context.Background(), backupID, "2024-01-01_00-00-00"))
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestFileCatalogRepositoryPostgres_Search(t *testing.T) {
+	db, mockDB, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	repo := postgres.NewFileCatalogRepositoryPostgres(db)
+	backupID := valueobjects.NewBackupID()
+	after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	minSize := int64(5)
+	modTime := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	t.Run("applies filters and paging", func(t *testing.T) {
+		filter := valueobjects.CatalogFilter{
+			BackupIDs:     []valueobjects.BackupID{backupID},
+			Glob:          "*.conf",
+			ModifiedAfter: &after,
+			MinSize:       &minSize,
+			Page:          2,
+			PageSize:      10,
+		}
+		where := `WHERE backup_id = ANY\(\$1::uuid\[\]\) AND name ~ \$2 AND mod_time >= \$3 AND size >= \$4`
+
+		mockDB.ExpectQuery(`SELECT COUNT\(\*\) FROM file_catalog `+where).
+			WithArgs(pq.Array([]string{backupID.String()}), `^.*\.conf$`, after, minSize).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(11))
+		mockDB.ExpectQuery(`SELECT backup_id, snapshot, path, size, mod_time, hash FROM file_catalog `+where+` ORDER BY path, snapshot DESC, backup_id LIMIT \$5 OFFSET \$6`).
+			WithArgs(pq.Array([]string{backupID.String()}), `^.*\.conf$`, after, minSize, 10, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"backup_id", "snapshot", "path", "size", "mod_time", "hash"}).
+				AddRow(backupID.String(), "2024-01-02_00-00-00", "etc/nginx/nginx.conf", 20, modTime, "bb"))
+
+		entries, total, err := repo.Search(context.Background(), filter)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 11, total)
+		assert.Len(t, entries, 1)
+		assert.Equal(t, "etc/nginx/nginx.conf", entries[0].Path)
+		assert.True(t, entries[0].BackupID.Equals(backupID))
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("empty backup set matches nothing", func(t *testing.T) {
+		entries, total, err := repo.Search(context.Background(), valueobjects.CatalogFilter{BackupIDs: []valueobjects.BackupID{}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, total)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("invalid regex", func(t *testing.T) {
+		_, _, err := repo.Search(context.Background(), valueobjects.CatalogFilter{Regex: "("})
+
+		assert.ErrorIs(t, err, valueobjects.ErrInvalidCatalogFilter)
+	})
+}
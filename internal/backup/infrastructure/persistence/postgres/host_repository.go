@@ -8,6 +8,7 @@ import (
 
 	"github.com/lib/pq"
 	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
 	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
 )
 
@@ -23,15 +24,19 @@ func NewHostRepositoryPostgres(db *sql.DB) *HostRepositoryPostgres {
 
 func (r *HostRepositoryPostgres) Save(ctx context.Context, host *entities.Host) error {
 	query := `
-		INSERT INTO hosts (id, name, hostname, "user", port, host_path, is_workstation, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO hosts (id, name, hostname, "user", port, host_path, is_workstation, created_at, legal_hold, storage_pool_id, quota_bytes, quota_snapshot_bytes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		ON CONFLICT (id) DO UPDATE SET
 			name = EXCLUDED.name,
 			hostname = EXCLUDED.hostname,
 			"user" = EXCLUDED."user",
 			port = EXCLUDED.port,
 			host_path = EXCLUDED.host_path,
-			is_workstation = EXCLUDED.is_workstation
+			is_workstation = EXCLUDED.is_workstation,
+			legal_hold = EXCLUDED.legal_hold,
+			storage_pool_id = EXCLUDED.storage_pool_id,
+			quota_bytes = EXCLUDED.quota_bytes,
+			quota_snapshot_bytes = EXCLUDED.quota_snapshot_bytes
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
@@ -43,21 +48,27 @@ func (r *HostRepositoryPostgres) Save(ctx context.Context, host *entities.Host)
 		host.Path(),
 		host.IsWorkstation(),
 		host.CreatedAt(),
+		host.LegalHold(),
+		nullString(host.StoragePoolID()),
+		host.Quota().Bytes,
+		host.Quota().SnapshotBytes,
 	)
 	return err
 }
 
 func (r *HostRepositoryPostgres) Get(ctx context.Context, id entities.HostID) (*entities.Host, error) {
-	query := `SELECT id, name, hostname, "user", port, host_path, is_workstation, created_at FROM hosts WHERE id = $1`
+	query := `SELECT id, name, hostname, "user", port, host_path, is_workstation, created_at, legal_hold, storage_pool_id, quota_bytes, quota_snapshot_bytes FROM hosts WHERE id = $1`
 
 	var hostIDStr string
 	var name, hostname, user, path string
 	var port int
-	var isWorkstation bool
+	var isWorkstation, legalHold bool
 	var createdAt time.Time
+	var storagePool sql.NullString
+	var quota valueobjects.Quota
 
 	err := r.db.QueryRowContext(ctx, query, id.String()).Scan(
-		&hostIDStr, &name, &hostname, &user, &port, &path, &isWorkstation, &createdAt,
+		&hostIDStr, &name, &hostname, &user, &port, &path, &isWorkstation, &createdAt, &legalHold, &storagePool, &quota.Bytes, &quota.SnapshotBytes,
 	)
 
 	if err == sql.ErrNoRows {
@@ -72,7 +83,11 @@ func (r *HostRepositoryPostgres) Get(ctx context.Context, id entities.HostID) (*
 	if err != nil {
 		return nil, fmt.Errorf("database corruption: invalid host id %s: %w", hostIDStr, err)
 	}
-	return entities.RestoreHost(hid, name, hostname, user, port, path, isWorkstation, createdAt), nil
+	host := entities.RestoreHost(hid, name, hostname, user, port, path, isWorkstation, createdAt)
+	host.SetLegalHold(legalHold)
+	host.SetStoragePool(storagePool.String)
+	host.SetQuota(quota)
+	return host, nil
 }
 
 func (r *HostRepositoryPostgres) GetByIDs(ctx context.Context, ids []entities.HostID) ([]*entities.Host, error) {
@@ -80,7 +95,7 @@ func (r *HostRepositoryPostgres) GetByIDs(ctx context.Context, ids []entities.Ho
 		return []*entities.Host{}, nil
 	}
 
-	query := `SELECT id, name, hostname, "user", port, host_path, is_workstation, created_at FROM hosts WHERE id = ANY($1)`
+	query := `SELECT id, name, hostname, "user", port, host_path, is_workstation, created_at, legal_hold, storage_pool_id, quota_bytes, quota_snapshot_bytes FROM hosts WHERE id = ANY($1)`
 
 	// Convert IDs to string slice for postgres array
 	idStrings := make([]string, len(ids))
@@ -99,10 +114,12 @@ func (r *HostRepositoryPostgres) GetByIDs(ctx context.Context, ids []entities.Ho
 		var hostIDStr string
 		var name, hostname, user, path string
 		var port int
-		var isWorkstation bool
+		var isWorkstation, legalHold bool
 		var createdAt time.Time
+		var storagePool sql.NullString
+		var quota valueobjects.Quota
 
-		if err := rows.Scan(&hostIDStr, &name, &hostname, &user, &port, &path, &isWorkstation, &createdAt); err != nil {
+		if err := rows.Scan(&hostIDStr, &name, &hostname, &user, &port, &path, &isWorkstation, &createdAt, &legalHold, &storagePool, &quota.Bytes, &quota.SnapshotBytes); err != nil {
 			return nil, err
 		}
 
@@ -110,14 +127,18 @@ func (r *HostRepositoryPostgres) GetByIDs(ctx context.Context, ids []entities.Ho
 		if err != nil {
 			return nil, fmt.Errorf("database corruption: invalid host id %s: %w", hostIDStr, err)
 		}
-		hosts = append(hosts, entities.RestoreHost(hid, name, hostname, user, port, path, isWorkstation, createdAt))
+		host := entities.RestoreHost(hid, name, hostname, user, port, path, isWorkstation, createdAt)
+		host.SetLegalHold(legalHold)
+		host.SetStoragePool(storagePool.String)
+		host.SetQuota(quota)
+		hosts = append(hosts, host)
 	}
 
 	return hosts, nil
 }
 
 func (r *HostRepositoryPostgres) List(ctx context.Context) ([]*entities.Host, error) {
-	query := `SELECT id, name, hostname, "user", port, host_path, is_workstation, created_at FROM hosts`
+	query := `SELECT id, name, hostname, "user", port, host_path, is_workstation, created_at, legal_hold, storage_pool_id, quota_bytes, quota_snapshot_bytes FROM hosts`
 
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
@@ -130,10 +151,12 @@ func (r *HostRepositoryPostgres) List(ctx context.Context) ([]*entities.Host, er
 		var hostIDStr string
 		var name, hostname, user, path string
 		var port int
-		var isWorkstation bool
+		var isWorkstation, legalHold bool
 		var createdAt time.Time
+		var storagePool sql.NullString
+		var quota valueobjects.Quota
 
-		if err := rows.Scan(&hostIDStr, &name, &hostname, &user, &port, &path, &isWorkstation, &createdAt); err != nil {
+		if err := rows.Scan(&hostIDStr, &name, &hostname, &user, &port, &path, &isWorkstation, &createdAt, &legalHold, &storagePool, &quota.Bytes, &quota.SnapshotBytes); err != nil {
 			return nil, err
 		}
 
@@ -141,7 +164,11 @@ func (r *HostRepositoryPostgres) List(ctx context.Context) ([]*entities.Host, er
 		if err != nil {
 			return nil, fmt.Errorf("database corruption: invalid host id %s: %w", hostIDStr, err)
 		}
-		hosts = append(hosts, entities.RestoreHost(hid, name, hostname, user, port, path, isWorkstation, createdAt))
+		host := entities.RestoreHost(hid, name, hostname, user, port, path, isWorkstation, createdAt)
+		host.SetLegalHold(legalHold)
+		host.SetStoragePool(storagePool.String)
+		host.SetQuota(quota)
+		hosts = append(hosts, host)
 	}
 
 	return hosts, nil
@@ -150,7 +177,7 @@ func (r *HostRepositoryPostgres) List(ctx context.Context) ([]*entities.Host, er
 func (r *HostRepositoryPostgres) Update(ctx context.Context, host *entities.Host) error {
 	query := `
 		UPDATE hosts
-		SET name = $2, hostname = $3, "user" = $4, port = $5, host_path = $6, is_workstation = $7
+		SET name = $2, hostname = $3, "user" = $4, port = $5, host_path = $6, is_workstation = $7, legal_hold = $8, storage_pool_id = $9, quota_bytes = $10, quota_snapshot_bytes = $11
 		WHERE id = $1
 	`
 
@@ -162,6 +189,10 @@ func (r *HostRepositoryPostgres) Update(ctx context.Context, host *entities.Host
 		host.Port(),
 		host.Path(),
 		host.IsWorkstation(),
+		host.LegalHold(),
+		nullString(host.StoragePoolID()),
+		host.Quota().Bytes,
+		host.Quota().SnapshotBytes,
 	)
 	if err != nil {
 		return err
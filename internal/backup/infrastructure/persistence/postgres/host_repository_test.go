@@ -36,7 +36,7 @@ func TestHostRepositoryPostgres_Save(t *testing.T) {
 			time.Now(),
 		)
 
-		mockDB.ExpectExec(`INSERT INTO hosts \(id, name, hostname, "user", port, host_path, is_workstation, created_at\)`).
+		mockDB.ExpectExec(`INSERT INTO hosts \(id, name, hostname, "user", port, host_path, is_workstation, created_at, legal_hold, storage_pool_id, quota_bytes, quota_snapshot_bytes\)`).
 			WithArgs(
 				hostID.String(),
 				"test-host",
@@ -46,6 +46,10 @@ func TestHostRepositoryPostgres_Save(t *testing.T) {
 				"/backup/path",
 				false,
 				sqlmock.AnyArg(), // created_at
+				false,            // legal_hold
+				sql.NullString{}, // storage_pool_id
+				int64(0),         // quota_bytes
+				int64(0),         // quota_snapshot_bytes
 			).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -76,6 +80,10 @@ func TestHostRepositoryPostgres_Save(t *testing.T) {
 				"/updated/path",
 				true,
 				sqlmock.AnyArg(), // created_at
+				false,            // legal_hold
+				sql.NullString{}, // storage_pool_id
+				int64(0),         // quota_bytes
+				int64(0),         // quota_snapshot_bytes
 			).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -106,6 +114,10 @@ func TestHostRepositoryPostgres_Save(t *testing.T) {
 				"/error/path",
 				false,
 				sqlmock.AnyArg(),
+				false,
+				sql.NullString{},
+				int64(0),
+				int64(0),
 			).
 			WillReturnError(sql.ErrConnDone)
 
@@ -127,7 +139,7 @@ func TestHostRepositoryPostgres_Get(t *testing.T) {
 		createdAt := time.Now()
 
 		rows := sqlmock.NewRows([]string{
-			"id", "name", "hostname", "user", "port", "host_path", "is_workstation", "created_at",
+			"id", "name", "hostname", "user", "port", "host_path", "is_workstation", "created_at", "legal_hold", "storage_pool_id", "quota_bytes", "quota_snapshot_bytes",
 		}).AddRow(
 			hostID.String(),
 			"test-host",
@@ -137,9 +149,13 @@ func TestHostRepositoryPostgres_Get(t *testing.T) {
 			"/backup/path",
 			false,
 			createdAt,
+			false,
+			nil,
+			int64(0),
+			int64(0),
 		)
 
-		mockDB.ExpectQuery(`SELECT id, name, hostname, "user", port, host_path, is_workstation, created_at FROM hosts WHERE id =`).
+		mockDB.ExpectQuery(`SELECT id, name, hostname, "user", port, host_path, is_workstation, created_at, legal_hold, storage_pool_id, quota_bytes, quota_snapshot_bytes FROM hosts WHERE id =`).
 			WithArgs(hostID.String()).
 			WillReturnRows(rows)
 
@@ -159,7 +175,7 @@ func TestHostRepositoryPostgres_Get(t *testing.T) {
 	t.Run("not found", func(t *testing.T) {
 		hostID := entities.NewHostID()
 
-		mockDB.ExpectQuery(`SELECT id, name, hostname, "user", port, host_path, is_workstation, created_at FROM hosts WHERE id =`).
+		mockDB.ExpectQuery(`SELECT id, name, hostname, "user", port, host_path, is_workstation, created_at, legal_hold, storage_pool_id, quota_bytes, quota_snapshot_bytes FROM hosts WHERE id =`).
 			WithArgs(hostID.String()).
 			WillReturnError(sql.ErrNoRows)
 
@@ -172,7 +188,7 @@ func TestHostRepositoryPostgres_Get(t *testing.T) {
 	t.Run("database error", func(t *testing.T) {
 		hostID := entities.NewHostID()
 
-		mockDB.ExpectQuery(`SELECT id, name, hostname, "user", port, host_path, is_workstation, created_at FROM hosts WHERE id =`).
+		mockDB.ExpectQuery(`SELECT id, name, hostname, "user", port, host_path, is_workstation, created_at, legal_hold, storage_pool_id, quota_bytes, quota_snapshot_bytes FROM hosts WHERE id =`).
 			WithArgs(hostID.String()).
 			WillReturnError(sql.ErrConnDone)
 
@@ -196,7 +212,7 @@ func TestHostRepositoryPostgres_GetByIDs(t *testing.T) {
 		createdAt := time.Now()
 
 		rows := sqlmock.NewRows([]string{
-			"id", "name", "hostname", "user", "port", "host_path", "is_workstation", "created_at",
+			"id", "name", "hostname", "user", "port", "host_path", "is_workstation", "created_at", "legal_hold", "storage_pool_id", "quota_bytes", "quota_snapshot_bytes",
 		}).AddRow(
 			hostID1.String(),
 			"host-1",
@@ -206,6 +222,10 @@ func TestHostRepositoryPostgres_GetByIDs(t *testing.T) {
 			"/path1",
 			false,
 			createdAt,
+			false,
+			nil,
+			int64(0),
+			int64(0),
 		).AddRow(
 			hostID2.String(),
 			"host-2",
@@ -215,9 +235,13 @@ func TestHostRepositoryPostgres_GetByIDs(t *testing.T) {
 			"/path2",
 			true,
 			createdAt,
+			true,
+			nil,
+			int64(0),
+			int64(0),
 		)
 
-		mockDB.ExpectQuery(`SELECT id, name, hostname, "user", port, host_path, is_workstation, created_at FROM hosts WHERE id = ANY\(\$1\)`).
+		mockDB.ExpectQuery(`SELECT id, name, hostname, "user", port, host_path, is_workstation, created_at, legal_hold, storage_pool_id, quota_bytes, quota_snapshot_bytes FROM hosts WHERE id = ANY\(\$1\)`).
 			WithArgs(pq.Array([]string{hostID1.String(), hostID2.String()})).
 			WillReturnRows(rows)
 
@@ -240,7 +264,7 @@ func TestHostRepositoryPostgres_GetByIDs(t *testing.T) {
 	t.Run("database error", func(t *testing.T) {
 		hostID := entities.NewHostID()
 
-		mockDB.ExpectQuery(`SELECT id, name, hostname, "user", port, host_path, is_workstation, created_at FROM hosts WHERE id = ANY\(\$1\)`).
+		mockDB.ExpectQuery(`SELECT id, name, hostname, "user", port, host_path, is_workstation, created_at, legal_hold, storage_pool_id, quota_bytes, quota_snapshot_bytes FROM hosts WHERE id = ANY\(\$1\)`).
 			WithArgs(pq.Array([]string{hostID.String()})).
 			WillReturnError(sql.ErrConnDone)
 
@@ -264,7 +288,7 @@ func TestHostRepositoryPostgres_List(t *testing.T) {
 		createdAt := time.Now()
 
 		rows := sqlmock.NewRows([]string{
-			"id", "name", "hostname", "user", "port", "host_path", "is_workstation", "created_at",
+			"id", "name", "hostname", "user", "port", "host_path", "is_workstation", "created_at", "legal_hold", "storage_pool_id", "quota_bytes", "quota_snapshot_bytes",
 		}).AddRow(
 			hostID1.String(),
 			"list-host-1",
@@ -274,6 +298,10 @@ func TestHostRepositoryPostgres_List(t *testing.T) {
 			"/listpath1",
 			false,
 			createdAt,
+			false,
+			nil,
+			int64(0),
+			int64(0),
 		).AddRow(
 			hostID2.String(),
 			"list-host-2",
@@ -283,9 +311,13 @@ func TestHostRepositoryPostgres_List(t *testing.T) {
 			"/listpath2",
 			true,
 			createdAt,
+			false,
+			nil,
+			int64(0),
+			int64(0),
 		)
 
-		mockDB.ExpectQuery(`SELECT id, name, hostname, "user", port, host_path, is_workstation, created_at FROM hosts`).
+		mockDB.ExpectQuery(`SELECT id, name, hostname, "user", port, host_path, is_workstation, created_at, legal_hold, storage_pool_id, quota_bytes, quota_snapshot_bytes FROM hosts`).
 			WillReturnRows(rows)
 
 		hosts, err := repo.List(context.Background())
@@ -298,10 +330,10 @@ func TestHostRepositoryPostgres_List(t *testing.T) {
 
 	t.Run("success with empty result", func(t *testing.T) {
 		rows := sqlmock.NewRows([]string{
-			"id", "name", "hostname", "user", "port", "host_path", "is_workstation", "created_at",
+			"id", "name", "hostname", "user", "port", "host_path", "is_workstation", "created_at", "legal_hold", "storage_pool_id", "quota_bytes", "quota_snapshot_bytes",
 		})
 
-		mockDB.ExpectQuery(`SELECT id, name, hostname, "user", port, host_path, is_workstation, created_at FROM hosts`).
+		mockDB.ExpectQuery(`SELECT id, name, hostname, "user", port, host_path, is_workstation, created_at, legal_hold, storage_pool_id, quota_bytes, quota_snapshot_bytes FROM hosts`).
 			WillReturnRows(rows)
 
 		hosts, err := repo.List(context.Background())
@@ -310,7 +342,7 @@ func TestHostRepositoryPostgres_List(t *testing.T) {
 	})
 
 	t.Run("database error", func(t *testing.T) {
-		mockDB.ExpectQuery(`SELECT id, name, hostname, "user", port, host_path, is_workstation, created_at FROM hosts`).
+		mockDB.ExpectQuery(`SELECT id, name, hostname, "user", port, host_path, is_workstation, created_at, legal_hold, storage_pool_id, quota_bytes, quota_snapshot_bytes FROM hosts`).
 			WillReturnError(sql.ErrConnDone)
 
 		hosts, err := repo.List(context.Background())
@@ -340,7 +372,7 @@ func TestHostRepositoryPostgres_Update(t *testing.T) {
 			time.Now(),
 		)
 
-		mockDB.ExpectExec(`UPDATE hosts SET name = \$2, hostname = \$3, "user" = \$4, port = \$5, host_path = \$6, is_workstation = \$7 WHERE id = \$1`).
+		mockDB.ExpectExec(`UPDATE hosts SET name = \$2, hostname = \$3, "user" = \$4, port = \$5, host_path = \$6, is_workstation = \$7, legal_hold = \$8, storage_pool_id = \$9, quota_bytes = \$10, quota_snapshot_bytes = \$11 WHERE id = \$1`).
 			WithArgs(
 				hostID.String(),
 				"updated-host",
@@ -349,6 +381,10 @@ func TestHostRepositoryPostgres_Update(t *testing.T) {
 				2222,
 				"/updated/path",
 				true,
+				false,
+				sql.NullString{},
+				int64(0),
+				int64(0),
 			).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -369,7 +405,7 @@ func TestHostRepositoryPostgres_Update(t *testing.T) {
 			time.Now(),
 		)
 
-		mockDB.ExpectExec(`UPDATE hosts SET name = \$2, hostname = \$3, "user" = \$4, port = \$5, host_path = \$6, is_workstation = \$7 WHERE id = \$1`).
+		mockDB.ExpectExec(`UPDATE hosts SET name = \$2, hostname = \$3, "user" = \$4, port = \$5, host_path = \$6, is_workstation = \$7, legal_hold = \$8, storage_pool_id = \$9, quota_bytes = \$10, quota_snapshot_bytes = \$11 WHERE id = \$1`).
 			WithArgs(
 				hostID.String(),
 				"not-found-host",
@@ -378,6 +414,10 @@ func TestHostRepositoryPostgres_Update(t *testing.T) {
 				22,
 				"/notfound/path",
 				false,
+				false,
+				sql.NullString{},
+				int64(0),
+				int64(0),
 			).
 			WillReturnResult(sqlmock.NewResult(0, 0))
 
@@ -399,7 +439,7 @@ func TestHostRepositoryPostgres_Update(t *testing.T) {
 			time.Now(),
 		)
 
-		mockDB.ExpectExec(`UPDATE hosts SET name = \$2, hostname = \$3, "user" = \$4, port = \$5, host_path = \$6, is_workstation = \$7 WHERE id = \$1`).
+		mockDB.ExpectExec(`UPDATE hosts SET name = \$2, hostname = \$3, "user" = \$4, port = \$5, host_path = \$6, is_workstation = \$7, legal_hold = \$8, storage_pool_id = \$9, quota_bytes = \$10, quota_snapshot_bytes = \$11 WHERE id = \$1`).
 			WithArgs(
 				hostID.String(),
 				"error-host",
@@ -408,6 +448,10 @@ func TestHostRepositoryPostgres_Update(t *testing.T) {
 				22,
 				"/error/path",
 				false,
+				false,
+				sql.NullString{},
+				int64(0),
+				int64(0),
 			).
 			WillReturnError(sql.ErrConnDone)
 
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+)
+
+type LegalHoldEventRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewLegalHoldEventRepositoryPostgres(db *sql.DB) *LegalHoldEventRepositoryPostgres {
+	return &LegalHoldEventRepositoryPostgres{db: db}
+}
+
+func (r *LegalHoldEventRepositoryPostgres) Save(ctx context.Context, event *entities.LegalHoldEvent) error {
+	query := `INSERT INTO legal_hold_events (scope, target_id, enabled, reason, user_id, occurred_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	err := r.db.QueryRowContext(ctx, query, string(event.Scope), event.TargetID, event.Enabled, event.Reason, event.UserID, event.OccurredAt).Scan(&event.ID)
+	if err != nil {
+		return fmt.Errorf("failed to save legal hold event: %w", err)
+	}
+	return nil
+}
+
+func (r *LegalHoldEventRepositoryPostgres) FindByTarget(ctx context.Context, scope entities.LegalHoldScope, targetID string) ([]*entities.LegalHoldEvent, error) {
+	query := `SELECT id, scope, target_id, enabled, reason, user_id, occurred_at FROM legal_hold_events WHERE scope = $1 AND target_id = $2 ORDER BY occurred_at DESC`
+	rows, err := r.db.QueryContext(ctx, query, string(scope), targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query legal hold events: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	events := make([]*entities.LegalHoldEvent, 0)
+	for rows.Next() {
+		var e entities.LegalHoldEvent
+		var scopeStr string
+		var userID sql.NullInt64
+		if err := rows.Scan(&e.ID, &scopeStr, &e.TargetID, &e.Enabled, &e.Reason, &userID, &e.OccurredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan legal hold event: %w", err)
+		}
+		e.Scope = entities.LegalHoldScope(scopeStr)
+		if userID.Valid {
+			id := int(userID.Int64)
+			e.UserID = &id
+		}
+		events = append(events, &e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating legal hold events: %w", err)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,231 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/crypto"
+)
+
+const replicaTargetColumns = `id, name, kind, config, bandwidth_limit, retention, backup_ids, enabled, created_at, updated_at`
+
+// replicaTargetConfig is the connection settings of a target, stored
+// encrypted since they hold credentials.
+type replicaTargetConfig struct {
+	S3    valueobjects.S3Config    `json:"s3"`
+	Rsync valueobjects.RsyncConfig `json:"rsync"`
+}
+
+type ReplicaTargetRepositoryPostgres struct {
+	db                *sql.DB
+	encryptionService crypto.EncryptionService
+}
+
+func NewReplicaTargetRepositoryPostgres(db *sql.DB, encryptionService crypto.EncryptionService) *ReplicaTargetRepositoryPostgres {
+	return &ReplicaTargetRepositoryPostgres{db: db, encryptionService: encryptionService}
+}
+
+func (r *ReplicaTargetRepositoryPostgres) Save(ctx context.Context, target *entities.ReplicaTarget) error {
+	configJSON, err := json.Marshal(replicaTargetConfig{S3: target.S3(), Rsync: target.Rsync()})
+	if err != nil {
+		return fmt.Errorf("failed to marshal replica target config: %w", err)
+	}
+	config, err := r.encryptionService.Encrypt(configJSON)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt replica target config: %w", err)
+	}
+
+	backupIDs := make([]string, 0, len(target.BackupIDs()))
+	for _, id := range target.BackupIDs() {
+		backupIDs = append(backupIDs, id.String())
+	}
+
+	query := `
+		INSERT INTO replica_targets (` + replicaTargetColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			kind = EXCLUDED.kind,
+			config = EXCLUDED.config,
+			bandwidth_limit = EXCLUDED.bandwidth_limit,
+			retention = EXCLUDED.retention,
+			backup_ids = EXCLUDED.backup_ids,
+			enabled = EXCLUDED.enabled,
+			updated_at = EXCLUDED.updated_at
+	`
+	_, err = r.db.ExecContext(ctx, query,
+		target.ID(),
+		target.Name(),
+		string(target.Kind()),
+		config,
+		target.Policy().BandwidthLimit,
+		target.Policy().Retention,
+		pq.Array(backupIDs),
+		target.Enabled(),
+		target.CreatedAt(),
+		target.UpdatedAt(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save replica target: %w", err)
+	}
+	return nil
+}
+
+func (r *ReplicaTargetRepositoryPostgres) FindByID(ctx context.Context, id uuid.UUID) (*entities.ReplicaTarget, error) {
+	query := `SELECT ` + replicaTargetColumns + ` FROM replica_targets WHERE id = $1`
+	target, err := r.scan(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, shared.ErrNotFound
+	}
+	return target, err
+}
+
+func (r *ReplicaTargetRepositoryPostgres) FindAll(ctx context.Context) ([]*entities.ReplicaTarget, error) {
+	query := `SELECT ` + replicaTargetColumns + ` FROM replica_targets ORDER BY created_at`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query replica targets: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	targets := make([]*entities.ReplicaTarget, 0)
+	for rows.Next() {
+		target, err := r.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating replica targets: %w", err)
+	}
+	return targets, nil
+}
+
+func (r *ReplicaTargetRepositoryPostgres) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM replica_targets WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete replica target: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return shared.ErrNotFound
+	}
+	return nil
+}
+
+func (r *ReplicaTargetRepositoryPostgres) scan(row rowScanner) (*entities.ReplicaTarget, error) {
+	var (
+		id                   uuid.UUID
+		name, kind           string
+		encryptedConfig      []byte
+		policy               valueobjects.ReplicaPolicy
+		backupIDStrs         []string
+		enabled              bool
+		createdAt, updatedAt time.Time
+	)
+	if err := row.Scan(&id, &name, &kind, &encryptedConfig, &policy.BandwidthLimit, &policy.Retention, pq.Array(&backupIDStrs), &enabled, &createdAt, &updatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan replica target: %w", err)
+	}
+
+	configJSON, err := r.encryptionService.Decrypt(encryptedConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt config of replica target %s: %w", name, err)
+	}
+	var config replicaTargetConfig
+	if err := json.Unmarshal(configJSON, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config of replica target %s: %w", name, err)
+	}
+
+	backupIDs := make([]valueobjects.BackupID, 0, len(backupIDStrs))
+	for _, s := range backupIDStrs {
+		backupID, err := valueobjects.NewBackupIDFromString(s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse backup ID: %w", err)
+		}
+		backupIDs = append(backupIDs, backupID)
+	}
+
+	return entities.RestoreReplicaTarget(id, name, valueobjects.ReplicaTargetKind(kind), config.S3, config.Rsync, policy, backupIDs, enabled, createdAt, updatedAt), nil
+}
+
+type ReplicaSnapshotRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewReplicaSnapshotRepositoryPostgres(db *sql.DB) *ReplicaSnapshotRepositoryPostgres {
+	return &ReplicaSnapshotRepositoryPostgres{db: db}
+}
+
+func (r *ReplicaSnapshotRepositoryPostgres) Save(ctx context.Context, snapshot *entities.ReplicaSnapshot) error {
+	query := `
+		INSERT INTO replica_snapshots (target_id, backup_id, snapshot, files, bytes, uploaded_bytes, replicated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (target_id, backup_id, snapshot) DO UPDATE SET
+			files = EXCLUDED.files,
+			bytes = EXCLUDED.bytes,
+			uploaded_bytes = EXCLUDED.uploaded_bytes,
+			replicated_at = EXCLUDED.replicated_at
+	`
+	_, err := r.db.ExecContext(ctx, query, snapshot.TargetID, snapshot.BackupID.String(), snapshot.Snapshot, snapshot.Files, snapshot.Bytes, snapshot.UploadedBytes, snapshot.ReplicatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save replica snapshot: %w", err)
+	}
+	return nil
+}
+
+func (r *ReplicaSnapshotRepositoryPostgres) FindByBackupID(ctx context.Context, backupID valueobjects.BackupID) ([]*entities.ReplicaSnapshot, error) {
+	query := `
+		SELECT target_id, snapshot, files, bytes, uploaded_bytes, replicated_at
+		FROM replica_snapshots WHERE backup_id = $1
+		ORDER BY target_id, snapshot
+	`
+	rows, err := r.db.QueryContext(ctx, query, backupID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query replica snapshots: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	snapshots := make([]*entities.ReplicaSnapshot, 0)
+	for rows.Next() {
+		s := &entities.ReplicaSnapshot{BackupID: backupID}
+		if err := rows.Scan(&s.TargetID, &s.Snapshot, &s.Files, &s.Bytes, &s.UploadedBytes, &s.ReplicatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan replica snapshot: %w", err)
+		}
+		snapshots = append(snapshots, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating replica snapshots: %w", err)
+	}
+	return snapshots, nil
+}
+
+func (r *ReplicaSnapshotRepositoryPostgres) Delete(ctx context.Context, targetID uuid.UUID, backupID valueobjects.BackupID, snapshot string) error {
+	query := `DELETE FROM replica_snapshots WHERE target_id = $1 AND backup_id = $2 AND snapshot = $3`
+	if _, err := r.db.ExecContext(ctx, query, targetID, backupID.String(), snapshot); err != nil {
+		return fmt.Errorf("failed to delete replica snapshot: %w", err)
+	}
+	return nil
+}
+
+func (r *ReplicaSnapshotRepositoryPostgres) DeleteByTarget(ctx context.Context, targetID uuid.UUID) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM replica_snapshots WHERE target_id = $1`, targetID); err != nil {
+		return fmt.Errorf("failed to delete replica snapshots: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+)
+
+const replicationJobColumns = `id, name, backup_id, path, snapshot, target_host_id, target_path, schedule, options, enabled, status, current_task_id, started_at, last_run_at, next_run_at, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+type ReplicationJobRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewReplicationJobRepositoryPostgres(db *sql.DB) *ReplicationJobRepositoryPostgres {
+	return &ReplicationJobRepositoryPostgres{db: db}
+}
+
+func (r *ReplicationJobRepositoryPostgres) Save(ctx context.Context, job *entities.ReplicationJob) error {
+	options, err := json.Marshal(job.Options())
+	if err != nil {
+		return fmt.Errorf("failed to marshal replication options: %w", err)
+	}
+
+	query := `
+		INSERT INTO replication_jobs (` + replicationJobColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			path = EXCLUDED.path,
+			snapshot = EXCLUDED.snapshot,
+			target_host_id = EXCLUDED.target_host_id,
+			target_path = EXCLUDED.target_path,
+			schedule = EXCLUDED.schedule,
+			options = EXCLUDED.options,
+			enabled = EXCLUDED.enabled,
+			status = EXCLUDED.status,
+			current_task_id = EXCLUDED.current_task_id,
+			started_at = EXCLUDED.started_at,
+			last_run_at = EXCLUDED.last_run_at,
+			next_run_at = EXCLUDED.next_run_at,
+			updated_at = EXCLUDED.updated_at
+	`
+	_, err = r.db.ExecContext(ctx, query,
+		job.ID(),
+		job.Name(),
+		job.BackupID().String(),
+		job.Path(),
+		job.Snapshot(),
+		job.TargetHostID().String(),
+		job.TargetPath(),
+		job.Schedule(),
+		options,
+		job.Enabled(),
+		job.Status().String(),
+		job.CurrentTaskID(),
+		job.StartedAt(),
+		job.LastRunAt(),
+		job.NextRunAt(),
+		job.CreatedAt(),
+		job.UpdatedAt(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save replication job: %w", err)
+	}
+	return nil
+}
+
+func (r *ReplicationJobRepositoryPostgres) FindByID(ctx context.Context, id uuid.UUID) (*entities.ReplicationJob, error) {
+	query := `SELECT ` + replicationJobColumns + ` FROM replication_jobs WHERE id = $1`
+	job, err := scanReplicationJob(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, shared.ErrNotFound
+	}
+	return job, err
+}
+
+func (r *ReplicationJobRepositoryPostgres) FindAll(ctx context.Context) ([]*entities.ReplicationJob, error) {
+	query := `SELECT ` + replicationJobColumns + ` FROM replication_jobs ORDER BY created_at`
+	return r.query(ctx, query)
+}
+
+func (r *ReplicationJobRepositoryPostgres) FindDue(ctx context.Context, now time.Time) ([]*entities.ReplicationJob, error) {
+	query := `SELECT ` + replicationJobColumns + ` FROM replication_jobs WHERE enabled = TRUE AND next_run_at <= $1`
+	return r.query(ctx, query, now)
+}
+
+func (r *ReplicationJobRepositoryPostgres) query(ctx context.Context, query string, args ...interface{}) ([]*entities.ReplicationJob, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query replication jobs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	jobs := make([]*entities.ReplicationJob, 0)
+	for rows.Next() {
+		job, err := scanReplicationJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating replication jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+func (r *ReplicationJobRepositoryPostgres) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM replication_jobs WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete replication job: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return shared.ErrNotFound
+	}
+	return nil
+}
+
+func scanReplicationJob(row rowScanner) (*entities.ReplicationJob, error) {
+	var (
+		id                                               uuid.UUID
+		name, backupIDStr, path, snapshot, targetHostStr string
+		targetPath, schedule, statusStr, currentTaskID   string
+		optionsJSON                                      []byte
+		enabled                                          bool
+		startedAt, lastRunAt, nextRunAt                  *time.Time
+		createdAt, updatedAt                             time.Time
+	)
+	if err := row.Scan(&id, &name, &backupIDStr, &path, &snapshot, &targetHostStr, &targetPath, &schedule, &optionsJSON, &enabled, &statusStr, &currentTaskID, &startedAt, &lastRunAt, &nextRunAt, &createdAt, &updatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan replication job: %w", err)
+	}
+
+	backupID, err := valueobjects.NewBackupIDFromString(backupIDStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse backup ID: %w", err)
+	}
+	targetHostID, err := entities.NewHostIDFromString(targetHostStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse host ID: %w", err)
+	}
+	status, err := valueobjects.NewBackupStatus(statusStr)
+	if err != nil {
+		return nil, err
+	}
+	var options valueobjects.RestoreOptions
+	if err := json.Unmarshal(optionsJSON, &options); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal replication options: %w", err)
+	}
+
+	return entities.RestoreReplicationJob(id, name, backupID, path, snapshot, targetHostID, targetPath, schedule, options, enabled, status, currentTaskID, startedAt, lastRunAt, nextRunAt, createdAt, updatedAt), nil
+}
+
+type ReplicationRunRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewReplicationRunRepositoryPostgres(db *sql.DB) *ReplicationRunRepositoryPostgres {
+	return &ReplicationRunRepositoryPostgres{db: db}
+}
+
+func (r *ReplicationRunRepositoryPostgres) Save(ctx context.Context, run *entities.ReplicationRun) error {
+	query := `
+		INSERT INTO replication_runs (id, job_id, task_id, trigger, status, message, started_at, finished_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (id) DO UPDATE SET
+			status = EXCLUDED.status,
+			message = EXCLUDED.message,
+			finished_at = EXCLUDED.finished_at
+	`
+	_, err := r.db.ExecContext(ctx, query, run.ID, run.JobID, run.TaskID, run.Trigger, run.Status.String(), run.Message, run.StartedAt, run.FinishedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save replication run: %w", err)
+	}
+	return nil
+}
+
+func (r *ReplicationRunRepositoryPostgres) FindByTaskID(ctx context.Context, taskID string) (*entities.ReplicationRun, error) {
+	if taskID == "" {
+		return nil, shared.ErrNotFound
+	}
+	query := `SELECT id, job_id, task_id, trigger, status, message, started_at, finished_at FROM replication_runs WHERE task_id = $1`
+	run, err := scanReplicationRun(r.db.QueryRowContext(ctx, query, taskID))
+	if err == sql.ErrNoRows {
+		return nil, shared.ErrNotFound
+	}
+	return run, err
+}
+
+func (r *ReplicationRunRepositoryPostgres) FindByJobID(ctx context.Context, jobID uuid.UUID, limit int) ([]*entities.ReplicationRun, error) {
+	query := `SELECT id, job_id, task_id, trigger, status, message, started_at, finished_at FROM replication_runs WHERE job_id = $1 ORDER BY started_at DESC LIMIT $2`
+	rows, err := r.db.QueryContext(ctx, query, jobID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query replication runs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	runs := make([]*entities.ReplicationRun, 0)
+	for rows.Next() {
+		run, err := scanReplicationRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating replication runs: %w", err)
+	}
+	return runs, nil
+}
+
+func scanReplicationRun(row rowScanner) (*entities.ReplicationRun, error) {
+	var run entities.ReplicationRun
+	var statusStr string
+	if err := row.Scan(&run.ID, &run.JobID, &run.TaskID, &run.Trigger, &statusStr, &run.Message, &run.StartedAt, &run.FinishedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan replication run: %w", err)
+	}
+	run.Status = valueobjects.BackupStatus(statusStr)
+	return &run, nil
+}
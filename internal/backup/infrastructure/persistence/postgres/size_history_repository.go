@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+)
+
+type SizeHistoryRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewSizeHistoryRepositoryPostgres(db *sql.DB) *SizeHistoryRepositoryPostgres {
+	return &SizeHistoryRepositoryPostgres{db: db}
+}
+
+func (r *SizeHistoryRepositoryPostgres) Save(ctx context.Context, sample *entities.SizeSample) error {
+	query := `
+		INSERT INTO backup_size_samples (backup_id, host_id, size_bytes, unique_bytes, stored_bytes, files, changed_files, sampled_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		sample.BackupID.String(),
+		sample.HostID.String(),
+		sample.Usage.Size,
+		sample.Usage.Unique,
+		sample.Usage.Stored,
+		sample.Changes.Files,
+		sample.Changes.Changed,
+		sample.SampledAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save size sample: %w", err)
+	}
+	return nil
+}
+
+func (r *SizeHistoryRepositoryPostgres) FindByBackupID(ctx context.Context, backupID valueobjects.BackupID, since time.Time) ([]*entities.SizeSample, error) {
+	return r.find(ctx, `WHERE backup_id = $1 AND sampled_at >= $2`, backupID.String(), since)
+}
+
+func (r *SizeHistoryRepositoryPostgres) FindByHostID(ctx context.Context, hostID entities.HostID, since time.Time) ([]*entities.SizeSample, error) {
+	return r.find(ctx, `WHERE host_id = $1 AND sampled_at >= $2`, hostID.String(), since)
+}
+
+func (r *SizeHistoryRepositoryPostgres) FindSince(ctx context.Context, since time.Time) ([]*entities.SizeSample, error) {
+	return r.find(ctx, `WHERE sampled_at >= $1`, since)
+}
+
+func (r *SizeHistoryRepositoryPostgres) find(ctx context.Context, where string, args ...interface{}) ([]*entities.SizeSample, error) {
+	query := `
+		SELECT backup_id, host_id, size_bytes, unique_bytes, stored_bytes, files, changed_files, sampled_at
+		FROM backup_size_samples ` + where + `
+		ORDER BY sampled_at
+	`
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query size samples: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	samples := make([]*entities.SizeSample, 0)
+	for rows.Next() {
+		var backupID, hostID string
+		s := &entities.SizeSample{}
+		if err := rows.Scan(&backupID, &hostID, &s.Usage.Size, &s.Usage.Unique, &s.Usage.Stored, &s.Changes.Files, &s.Changes.Changed, &s.SampledAt); err != nil {
+			return nil, fmt.Errorf("failed to scan size sample: %w", err)
+		}
+		if s.BackupID, err = valueobjects.NewBackupIDFromString(backupID); err != nil {
+			return nil, err
+		}
+		if s.HostID, err = entities.NewHostIDFromString(hostID); err != nil {
+			return nil, err
+		}
+		samples = append(samples, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating size samples: %w", err)
+	}
+	return samples, nil
+}
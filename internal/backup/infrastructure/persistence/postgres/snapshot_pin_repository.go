@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+)
+
+type SnapshotPinRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewSnapshotPinRepositoryPostgres(db *sql.DB) *SnapshotPinRepositoryPostgres {
+	return &SnapshotPinRepositoryPostgres{db: db}
+}
+
+func (r *SnapshotPinRepositoryPostgres) Save(ctx context.Context, pin *entities.SnapshotPin) error {
+	query := `
+		INSERT INTO snapshot_pins (backup_id, snapshot, label, note, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (backup_id, snapshot) DO UPDATE SET
+			label = EXCLUDED.label,
+			note = EXCLUDED.note,
+			expires_at = EXCLUDED.expires_at
+	`
+	_, err := r.db.ExecContext(ctx, query, pin.BackupID.String(), pin.Snapshot, pin.Label, pin.Note, pin.ExpiresAt, pin.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save snapshot pin: %w", err)
+	}
+	return nil
+}
+
+func (r *SnapshotPinRepositoryPostgres) FindByBackupID(ctx context.Context, backupID valueobjects.BackupID) ([]*entities.SnapshotPin, error) {
+	query := `SELECT backup_id, snapshot, label, note, expires_at, created_at FROM snapshot_pins WHERE backup_id = $1 ORDER BY snapshot`
+	rows, err := r.db.QueryContext(ctx, query, backupID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query snapshot pins: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	pins := make([]*entities.SnapshotPin, 0)
+	for rows.Next() {
+		var p entities.SnapshotPin
+		var backupIDStr string
+		if err := rows.Scan(&backupIDStr, &p.Snapshot, &p.Label, &p.Note, &p.ExpiresAt, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan snapshot pin: %w", err)
+		}
+		bid, err := valueobjects.NewBackupIDFromString(backupIDStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse backup ID: %w", err)
+		}
+		p.BackupID = bid
+		pins = append(pins, &p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating snapshot pins: %w", err)
+	}
+
+	return pins, nil
+}
+
+func (r *SnapshotPinRepositoryPostgres) Delete(ctx context.Context, backupID valueobjects.BackupID, snapshot string) error {
+	query := `DELETE FROM snapshot_pins WHERE backup_id = $1 AND snapshot = $2`
+	result, err := r.db.ExecContext(ctx, query, backupID.String(), snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to delete snapshot pin: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return shared.ErrNotFound
+	}
+	return nil
+}
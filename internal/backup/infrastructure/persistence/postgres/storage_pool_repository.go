@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+)
+
+const storagePoolColumns = `id, name, root_path, capacity, labels, created_at, updated_at`
+
+type StoragePoolRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewStoragePoolRepositoryPostgres(db *sql.DB) *StoragePoolRepositoryPostgres {
+	return &StoragePoolRepositoryPostgres{db: db}
+}
+
+func (r *StoragePoolRepositoryPostgres) Save(ctx context.Context, pool *entities.StoragePool) error {
+	query := `
+		INSERT INTO storage_pools (` + storagePoolColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			root_path = EXCLUDED.root_path,
+			capacity = EXCLUDED.capacity,
+			labels = EXCLUDED.labels,
+			updated_at = EXCLUDED.updated_at
+	`
+	labels := pool.Labels()
+	if labels == nil {
+		labels = []string{}
+	}
+	_, err := r.db.ExecContext(ctx, query,
+		pool.ID(),
+		pool.Name(),
+		pool.RootPath(),
+		pool.Capacity(),
+		pq.Array(labels),
+		pool.CreatedAt(),
+		pool.UpdatedAt(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save storage pool: %w", err)
+	}
+	return nil
+}
+
+func (r *StoragePoolRepositoryPostgres) FindByID(ctx context.Context, id uuid.UUID) (*entities.StoragePool, error) {
+	query := `SELECT ` + storagePoolColumns + ` FROM storage_pools WHERE id = $1`
+	pool, err := r.scan(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, shared.ErrNotFound
+	}
+	return pool, err
+}
+
+func (r *StoragePoolRepositoryPostgres) FindAll(ctx context.Context) ([]*entities.StoragePool, error) {
+	query := `SELECT ` + storagePoolColumns + ` FROM storage_pools ORDER BY name`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query storage pools: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	pools := make([]*entities.StoragePool, 0)
+	for rows.Next() {
+		pool, err := r.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		pools = append(pools, pool)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating storage pools: %w", err)
+	}
+	return pools, nil
+}
+
+func (r *StoragePoolRepositoryPostgres) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM storage_pools WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete storage pool: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return shared.ErrNotFound
+	}
+	return nil
+}
+
+func (r *StoragePoolRepositoryPostgres) scan(row rowScanner) (*entities.StoragePool, error) {
+	var (
+		id                   uuid.UUID
+		name, rootPath       string
+		capacity             int64
+		labels               []string
+		createdAt, updatedAt time.Time
+	)
+	if err := row.Scan(&id, &name, &rootPath, &capacity, pq.Array(&labels), &createdAt, &updatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan storage pool: %w", err)
+	}
+	return entities.RestoreStoragePool(id, name, rootPath, capacity, labels, createdAt, updatedAt), nil
+}
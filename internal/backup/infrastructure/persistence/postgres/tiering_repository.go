@@ -0,0 +1,169 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+)
+
+type TieringPolicyRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewTieringPolicyRepositoryPostgres(db *sql.DB) *TieringPolicyRepositoryPostgres {
+	return &TieringPolicyRepositoryPostgres{db: db}
+}
+
+func (r *TieringPolicyRepositoryPostgres) Save(ctx context.Context, backupID valueobjects.BackupID, policy valueobjects.TieringPolicy) error {
+	query := `
+		INSERT INTO tiering_policies (backup_id, after_days, storage_pool_id, replica_target_id, encrypt, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (backup_id) DO UPDATE SET
+			after_days = EXCLUDED.after_days,
+			storage_pool_id = EXCLUDED.storage_pool_id,
+			replica_target_id = EXCLUDED.replica_target_id,
+			encrypt = EXCLUDED.encrypt,
+			updated_at = EXCLUDED.updated_at
+	`
+	_, err := r.db.ExecContext(ctx, query, backupID.String(), policy.AfterDays, nullString(policy.StoragePoolID), nullString(policy.ReplicaTargetID), policy.Encrypt)
+	if err != nil {
+		return fmt.Errorf("failed to save tiering policy: %w", err)
+	}
+	return nil
+}
+
+func (r *TieringPolicyRepositoryPostgres) FindByBackupID(ctx context.Context, backupID valueobjects.BackupID) (*valueobjects.TieringPolicy, error) {
+	query := `SELECT after_days, storage_pool_id, replica_target_id, encrypt FROM tiering_policies WHERE backup_id = $1`
+	var policy valueobjects.TieringPolicy
+	var poolID, targetID sql.NullString
+	err := r.db.QueryRowContext(ctx, query, backupID.String()).Scan(&policy.AfterDays, &poolID, &targetID, &policy.Encrypt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, shared.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tiering policy: %w", err)
+	}
+	policy.StoragePoolID, policy.ReplicaTargetID = poolID.String, targetID.String
+	return &policy, nil
+}
+
+func (r *TieringPolicyRepositoryPostgres) FindAll(ctx context.Context) (map[string]valueobjects.TieringPolicy, error) {
+	query := `SELECT backup_id, after_days, storage_pool_id, replica_target_id, encrypt FROM tiering_policies`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tiering policies: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	policies := make(map[string]valueobjects.TieringPolicy)
+	for rows.Next() {
+		var backupID string
+		var policy valueobjects.TieringPolicy
+		var poolID, targetID sql.NullString
+		if err := rows.Scan(&backupID, &policy.AfterDays, &poolID, &targetID, &policy.Encrypt); err != nil {
+			return nil, fmt.Errorf("failed to scan tiering policy: %w", err)
+		}
+		policy.StoragePoolID, policy.ReplicaTargetID = poolID.String, targetID.String
+		policies[backupID] = policy
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tiering policies: %w", err)
+	}
+	return policies, nil
+}
+
+func (r *TieringPolicyRepositoryPostgres) Delete(ctx context.Context, backupID valueobjects.BackupID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM tiering_policies WHERE backup_id = $1`, backupID.String())
+	if err != nil {
+		return fmt.Errorf("failed to delete tiering policy: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return shared.ErrNotFound
+	}
+	return nil
+}
+
+type ArchivedSnapshotRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewArchivedSnapshotRepositoryPostgres(db *sql.DB) *ArchivedSnapshotRepositoryPostgres {
+	return &ArchivedSnapshotRepositoryPostgres{db: db}
+}
+
+func (r *ArchivedSnapshotRepositoryPostgres) Save(ctx context.Context, snapshot *entities.ArchivedSnapshot) error {
+	query := `
+		INSERT INTO archived_snapshots (backup_id, snapshot, tier, storage_pool_id, replica_target_id, archive, size, encrypted, archived_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (backup_id, snapshot) DO UPDATE SET
+			tier = EXCLUDED.tier,
+			storage_pool_id = EXCLUDED.storage_pool_id,
+			replica_target_id = EXCLUDED.replica_target_id,
+			archive = EXCLUDED.archive,
+			size = EXCLUDED.size,
+			encrypted = EXCLUDED.encrypted,
+			archived_at = EXCLUDED.archived_at
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		snapshot.BackupID.String(),
+		snapshot.Snapshot,
+		string(snapshot.Tier),
+		nullString(snapshot.StoragePoolID),
+		nullString(snapshot.ReplicaTargetID),
+		snapshot.Archive,
+		snapshot.Size,
+		snapshot.Encrypted,
+		snapshot.ArchivedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save archived snapshot: %w", err)
+	}
+	return nil
+}
+
+func (r *ArchivedSnapshotRepositoryPostgres) FindByBackupID(ctx context.Context, backupID valueobjects.BackupID) ([]*entities.ArchivedSnapshot, error) {
+	query := `
+		SELECT snapshot, tier, storage_pool_id, replica_target_id, archive, size, encrypted, archived_at
+		FROM archived_snapshots WHERE backup_id = $1
+		ORDER BY snapshot
+	`
+	rows, err := r.db.QueryContext(ctx, query, backupID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query archived snapshots: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	snapshots := make([]*entities.ArchivedSnapshot, 0)
+	for rows.Next() {
+		s := &entities.ArchivedSnapshot{BackupID: backupID}
+		var tier string
+		var poolID, targetID sql.NullString
+		if err := rows.Scan(&s.Snapshot, &tier, &poolID, &targetID, &s.Archive, &s.Size, &s.Encrypted, &s.ArchivedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan archived snapshot: %w", err)
+		}
+		s.Tier = valueobjects.TierKind(tier)
+		s.StoragePoolID, s.ReplicaTargetID = poolID.String, targetID.String
+		snapshots = append(snapshots, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating archived snapshots: %w", err)
+	}
+	return snapshots, nil
+}
+
+func (r *ArchivedSnapshotRepositoryPostgres) Delete(ctx context.Context, backupID valueobjects.BackupID, snapshot string) error {
+	query := `DELETE FROM archived_snapshots WHERE backup_id = $1 AND snapshot = $2`
+	if _, err := r.db.ExecContext(ctx, query, backupID.String(), snapshot); err != nil {
+		return fmt.Errorf("failed to delete archived snapshot: %w", err)
+	}
+	return nil
+}
@@ -2,13 +2,20 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"mime"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
 
 	"github.com/rrbarrero/justbackup/internal/backup/application"
 	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
 )
 
 type BackupHandler struct {
@@ -18,6 +25,7 @@ type BackupHandler struct {
 	restoreService   *application.BackupRestoreService
 	taskService      *application.BackupTaskService
 	hookService      *application.BackupHookService
+	storedData       *application.StoredDataService
 }
 
 func NewBackupHandler(
@@ -27,6 +35,7 @@ func NewBackupHandler(
 	restoreService *application.BackupRestoreService,
 	taskService *application.BackupTaskService,
 	hookService *application.BackupHookService,
+	storedData *application.StoredDataService,
 ) *BackupHandler {
 	return &BackupHandler{
 		lifecycleService: lifecycleService,
@@ -35,6 +44,7 @@ func NewBackupHandler(
 		restoreService:   restoreService,
 		taskService:      taskService,
 		hookService:      hookService,
+		storedData:       storedData,
 	}
 }
 
@@ -48,8 +58,11 @@ func (h *BackupHandler) RegisterRoutes(mux *http.ServeMux, middleware func(http.
 	mux.HandleFunc("DELETE /backups/{id}", middleware(h.Delete))
 	mux.HandleFunc("POST /backups/{id}/run", middleware(h.Run))
 	mux.HandleFunc("POST /hosts/{id}/run", middleware(h.RunHostBackups))
+	mux.HandleFunc("POST /backups/{id}/catalog", middleware(h.RebuildCatalog))
 	mux.HandleFunc("GET /files/search", middleware(h.SearchFiles))
+	mux.HandleFunc("GET /files/grep", middleware(h.GrepFiles))
 	mux.HandleFunc("POST /backups/{id}/restore", middleware(h.Restore))
+	mux.HandleFunc("GET /backups/{id}/download", middleware(h.Download))
 	mux.HandleFunc("GET /backups/{id}/files", middleware(h.ListFiles))
 
 	// Backup Hook Routes
@@ -66,6 +79,7 @@ func (h *BackupHandler) RegisterRoutes(mux *http.ServeMux, middleware func(http.
 // @Param   id     path    string     true  "Backup ID"
 // @Success 200 {object} map[string]string
 // @Failure 401 {string} string "Unauthorized"
+// @Failure 409 {string} string "Backup is being migrated"
 // @Failure 500 {string} string "Internal Server Error"
 // @Security BasicAuth
 // @Router /backups/{id}/run [post]
@@ -73,7 +87,11 @@ func (h *BackupHandler) Run(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	taskID, err := h.lifecycleService.RunBackup(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		status := http.StatusInternalServerError
+		if errors.Is(err, valueobjects.ErrBackupMigrating) {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
 		return
 	}
 
@@ -83,6 +101,35 @@ func (h *BackupHandler) Run(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// @Summary Rebuild the file catalog of a backup
+// @Description Catalog the files of every plain snapshot of a backup again, e.g. for snapshots taken before the catalog existed
+// @Tags backups
+// @Accept  json
+// @Produce  json
+// @Param   id     path    string     true  "Backup ID"
+// @Success 202 {object} map[string]string
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Backup not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /backups/{id}/catalog [post]
+func (h *BackupHandler) RebuildCatalog(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	taskID, err := h.lifecycleService.RebuildCatalog(r.Context(), id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, shared.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(map[string]string{"task_id": taskID})
+}
+
 // @Summary Run all backups for a host
 // @Description Trigger all backup executions for a specific host immediately
 // @Tags hosts
@@ -109,19 +156,36 @@ func (h *BackupHandler) RunHostBackups(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Delete a backup
-// @Description Delete a backup configuration
+// @Description Delete a backup configuration. With with_data its snapshots are removed from the worker too, on its storage and in cold storage pools
 // @Tags backups
 // @Accept  json
 // @Produce  json
-// @Param   id     path    string     true  "Backup ID"
+// @Param   id         path    string     true   "Backup ID"
+// @Param   with_data  query   bool       false  "Also delete the stored data"
 // @Success 204 "No Content"
+// @Success 202 {object} dto.DeleteDataResponse
 // @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Backup not found"
+// @Failure 409 {string} string "Blocked by legal hold or running"
 // @Failure 500 {string} string "Internal Server Error"
 // @Security BasicAuth
 // @Router /backups/{id} [delete]
 func (h *BackupHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if withData(r) {
+		deleteWithData(w, r, h.storedData, (*application.StoredDataService).DeleteBackup)
+		return
+	}
+
 	id := r.PathValue("id")
 	if err := h.lifecycleService.DeleteBackup(r.Context(), id); err != nil {
+		if errors.Is(err, shared.ErrNotFound) {
+			http.Error(w, "Backup not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, entities.ErrLegalHold) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -156,6 +220,10 @@ func (h *BackupHandler) Create(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, errorMessage, http.StatusBadRequest)
 			return
 		}
+		if errors.Is(err, valueobjects.ErrInvalidRetentionPolicy) || errors.Is(err, valueobjects.ErrInvalidDestination) {
+			http.Error(w, errorMessage, http.StatusBadRequest)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -198,6 +266,10 @@ func (h *BackupHandler) Update(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, errorMessage, http.StatusBadRequest)
 			return
 		}
+		if errors.Is(err, valueobjects.ErrInvalidRetentionPolicy) || errors.Is(err, valueobjects.ErrInvalidDestination) {
+			http.Error(w, errorMessage, http.StatusBadRequest)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -372,26 +444,106 @@ func (h *BackupHandler) DeleteErrors(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Search files in backups
-// @Description Search for files in all backups using a POSIX pattern
+// @Description Search the file catalog of all backups. Every filter is optional; results are paginated.
 // @Tags backups
 // @Accept  json
 // @Produce  json
-// @Param   pattern     query    string     true  "POSIX pattern (e.g. *.txt)"
-// @Success 200 {array} dto.FileSearchResult
+// @Param   pattern     query    string     false  "Glob matched against the file name (e.g. *.txt)"
+// @Param   regex     query    string     false  "Regular expression matched against the path inside the snapshot"
+// @Param   host_id     query    string     false  "Only files of this host's backups"
+// @Param   backup_id     query    string     false  "Only files of this backup"
+// @Param   modified_after     query    string     false  "Modified at or after this date (RFC 3339 or YYYY-MM-DD)"
+// @Param   modified_before     query    string     false  "Modified at or before this date (RFC 3339 or YYYY-MM-DD)"
+// @Param   min_size     query    string     false  "Minimum size (e.g. 10MB)"
+// @Param   max_size     query    string     false  "Maximum size (e.g. 1GB)"
+// @Param   page     query    int     false  "Page number, starting at 1"
+// @Param   page_size     query    int     false  "Results per page (default 100, max 1000)"
+// @Success 200 {object} dto.FileSearchResponse
+// @Failure 400 {string} string "Invalid filter"
 // @Failure 401 {string} string "Unauthorized"
 // @Failure 500 {string} string "Internal Server Error"
 // @Security BasicAuth
 // @Router /files/search [get]
 func (h *BackupHandler) SearchFiles(w http.ResponseWriter, r *http.Request) {
-	pattern := r.URL.Query().Get("pattern")
-	if pattern == "" {
-		http.Error(w, "Pattern is required", http.StatusBadRequest)
+	query := r.URL.Query()
+	req := dto.FileSearchRequest{
+		Pattern:        query.Get("pattern"),
+		Regex:          query.Get("regex"),
+		HostID:         query.Get("host_id"),
+		BackupID:       query.Get("backup_id"),
+		ModifiedAfter:  query.Get("modified_after"),
+		ModifiedBefore: query.Get("modified_before"),
+		MinSize:        query.Get("min_size"),
+		MaxSize:        query.Get("max_size"),
+	}
+
+	var err error
+	if req.Page, err = queryInt(query.Get("page")); err != nil {
+		http.Error(w, "Invalid page", http.StatusBadRequest)
+		return
+	}
+	if req.PageSize, err = queryInt(query.Get("page_size")); err != nil {
+		http.Error(w, "Invalid page_size", http.StatusBadRequest)
 		return
 	}
 
-	results, err := h.searchService.SearchFiles(r.Context(), pattern)
+	results, err := h.searchService.SearchFiles(r.Context(), req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		status := http.StatusInternalServerError
+		if errors.Is(err, valueobjects.ErrInvalidCatalogFilter) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(results); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// @Summary Search file contents in backups
+// @Description Search the content index for text files containing q, ignoring case. Only backups with content_index enabled are indexed, and only their text files up to the worker's size limit. Each result is one version of a file with the snapshots holding it and its matching lines.
+// @Tags backups
+// @Accept  json
+// @Produce  json
+// @Param   q     query    string     true  "Text to look for (at least 3 characters)"
+// @Param   host_id     query    string     false  "Only files of this host's backups"
+// @Param   backup_id     query    string     false  "Only files of this backup"
+// @Param   page     query    int     false  "Page number, starting at 1"
+// @Param   page_size     query    int     false  "Results per page (default 20, max 100)"
+// @Success 200 {object} dto.FileGrepResponse
+// @Failure 400 {string} string "Invalid query"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /files/grep [get]
+func (h *BackupHandler) GrepFiles(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	req := dto.FileGrepRequest{
+		Query:    query.Get("q"),
+		HostID:   query.Get("host_id"),
+		BackupID: query.Get("backup_id"),
+	}
+
+	var err error
+	if req.Page, err = queryInt(query.Get("page")); err != nil {
+		http.Error(w, "Invalid page", http.StatusBadRequest)
+		return
+	}
+	if req.PageSize, err = queryInt(query.Get("page_size")); err != nil {
+		http.Error(w, "Invalid page_size", http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.searchService.GrepFiles(r.Context(), req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, valueobjects.ErrInvalidContentQuery) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
 		return
 	}
 
@@ -401,16 +553,26 @@ func (h *BackupHandler) SearchFiles(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// queryInt parses an optional integer query parameter; empty means zero.
+func queryInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
 // @Summary Restore files
-// @Description Trigger a restoration process
+// @Description Trigger a restoration process. A remote restore with dry_run set is not started; the changes it would make at the target are returned instead.
 // @Tags backups
 // @Accept  json
 // @Produce  json
 // @Param   id     path    string     true  "Backup ID"
 // @Param   restore     body    dto.RestoreRequest     true  "Restore Configuration"
 // @Success 202 {object} map[string]string
-// @Failure 400 {string} string "Invalid request body"
+// @Success 200 {object} dto.RestorePreviewResponse
+// @Failure 400 {string} string "Invalid request body, snapshot selector or restore options"
 // @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Backup or snapshot not found"
 // @Failure 500 {string} string "Internal Server Error"
 // @Security BasicAuth
 // @Router /backups/{id}/restore [post]
@@ -424,9 +586,20 @@ func (h *BackupHandler) Restore(w http.ResponseWriter, r *http.Request) {
 	}
 	req.BackupID = id
 
+	if req.DryRun {
+		preview, err := h.restoreService.Preview(r.Context(), req)
+		if err != nil {
+			http.Error(w, err.Error(), restoreErrorStatus(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(preview)
+		return
+	}
+
 	taskID, err := h.restoreService.Restore(r.Context(), req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), restoreErrorStatus(err))
 		return
 	}
 
@@ -435,6 +608,58 @@ func (h *BackupHandler) Restore(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(map[string]string{"task_id": taskID})
 }
 
+// @Summary Download files
+// @Description Download a path of a backup as an archive streamed through the server, without the worker connecting to the client. The first request waits until the worker has streamed the whole archive; it is then kept for a while and served with range requests, so interrupted downloads can be resumed.
+// @Tags backups
+// @Produce  application/gzip
+// @Produce  application/zip
+// @Param   id     path    string     true  "Backup ID"
+// @Param   path   query   string     false "Path inside the backup"
+// @Param   snapshot   query   string     false "Snapshot selector: snapshot name, 'latest' or a point in time (e.g. 2024-03-01 14:00)"
+// @Param   format   query   string     false "Archive format: tar.gz (default) or zip"
+// @Param   Range   header   string     false "Byte range to resume from, e.g. bytes=1048576-"
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Failure 400 {string} string "Invalid snapshot selector or format"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Backup or snapshot not found"
+// @Failure 416 {string} string "Range not satisfiable"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /backups/{id}/download [get]
+func (h *BackupHandler) Download(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	req := dto.DownloadRequest{
+		BackupID: r.PathValue("id"),
+		Path:     query.Get("path"),
+		Snapshot: query.Get("snapshot"),
+		Format:   query.Get("format"),
+	}
+
+	download, err := h.restoreService.Download(r.Context(), req)
+	if err != nil {
+		status := snapshotErrorStatus(err)
+		if errors.Is(err, valueobjects.ErrInvalidDownloadFormat) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	file, err := os.Open(download.FilePath)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer func() { _ = file.Close() }()
+
+	w.Header().Set("Content-Type", download.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": download.FileName}))
+	w.Header().Set("ETag", `"`+download.ETag+`"`)
+	// ServeContent answers Range and If-Range requests against the ETag.
+	http.ServeContent(w, r, download.FileName, download.ModTime, file)
+}
+
 // @Summary List files in a backup
 // @Description Get a list of files and directories for a specific backup
 // @Tags backups
@@ -442,19 +667,22 @@ func (h *BackupHandler) Restore(w http.ResponseWriter, r *http.Request) {
 // @Produce  json
 // @Param   id     path    string     true  "Backup ID"
 // @Param   path   query   string     false "Subpath to list"
+// @Param   snapshot   query   string     false "Snapshot selector: snapshot name, 'latest' or a point in time (e.g. 2024-03-01 14:00)"
 // @Success 200 {array} dto.BackupFileResponse
+// @Failure 400 {string} string "Invalid snapshot selector"
 // @Failure 401 {string} string "Unauthorized"
-// @Failure 404 {string} string "Backup not found"
+// @Failure 404 {string} string "Backup or snapshot not found"
 // @Failure 500 {string} string "Internal Server Error"
 // @Security BasicAuth
 // @Router /backups/{id}/files [get]
 func (h *BackupHandler) ListFiles(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	path := r.URL.Query().Get("path")
+	snapshot := r.URL.Query().Get("snapshot")
 
-	files, err := h.searchService.ListFiles(r.Context(), id, path)
+	files, err := h.searchService.ListFiles(r.Context(), id, path, snapshot)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), snapshotErrorStatus(err))
 		return
 	}
 
@@ -464,6 +692,27 @@ func (h *BackupHandler) ListFiles(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// snapshotErrorStatus maps errors of requests that select a snapshot to an HTTP status.
+func snapshotErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, shared.ErrNotFound), errors.Is(err, valueobjects.ErrSnapshotNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, valueobjects.ErrInvalidSnapshotSelector),
+		errors.Is(err, valueobjects.ErrNotIncremental),
+		errors.Is(err, valueobjects.ErrEncryptedSnapshot):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func restoreErrorStatus(err error) int {
+	if errors.Is(err, valueobjects.ErrInvalidConflictPolicy) || errors.Is(err, valueobjects.ErrInvalidRestoreOptions) {
+		return http.StatusBadRequest
+	}
+	return snapshotErrorStatus(err)
+}
+
 func (h *BackupHandler) CreateHook(w http.ResponseWriter, r *http.Request) {
 	backupID := r.PathValue("id")
 	var req dto.CreateHookRequest
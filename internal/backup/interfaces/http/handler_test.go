@@ -6,12 +6,16 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/rrbarrero/justbackup/internal/backup/application"
 	"github.com/rrbarrero/justbackup/internal/backup/application/assembler"
 	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
 	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/interfaces"
 	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
 	"github.com/rrbarrero/justbackup/internal/backup/infrastructure/persistence/memory"
 	backupHttp "github.com/rrbarrero/justbackup/internal/backup/interfaces/http"
@@ -30,8 +34,8 @@ func (m *MockTaskPublisher) Publish(ctx context.Context, backup *entities.Backup
 	return args.Error(0)
 }
 
-func (m *MockTaskPublisher) PublishSearchTask(ctx context.Context, pattern string) (string, error) {
-	args := m.Called(ctx, pattern)
+func (m *MockTaskPublisher) PublishCatalogTask(ctx context.Context, backup *entities.Backup, snapshot string) (string, error) {
+	args := m.Called(ctx, backup, snapshot)
 	return args.String(0), args.Error(1)
 }
 
@@ -40,8 +44,13 @@ func (m *MockTaskPublisher) PublishMeasureTask(ctx context.Context, hostID strin
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockTaskPublisher) PublishRestoreTask(ctx context.Context, backup *entities.Backup, path string, restoreAddr string, restoreToken string) (string, error) {
-	args := m.Called(ctx, backup, path, restoreAddr, restoreToken)
+func (m *MockTaskPublisher) PublishRestoreTask(ctx context.Context, backup *entities.Backup, path string, archivePath string, replica *valueobjects.ReplicaLocation, cold *valueobjects.ColdLocation, restoreAddr string, restoreToken string, restoreFingerprint string) (string, error) {
+	args := m.Called(ctx, backup, path, archivePath, replica, cold, restoreAddr, restoreToken, restoreFingerprint)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTaskPublisher) PublishListArchiveTask(ctx context.Context, backupID string, archive string, archivePath string) (string, error) {
+	args := m.Called(ctx, backupID, archive, archivePath)
 	return args.String(0), args.Error(1)
 }
 
@@ -50,8 +59,58 @@ func (m *MockTaskPublisher) PublishListFilesTask(ctx context.Context, path strin
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockTaskPublisher) PublishRemoteRestoreTask(ctx context.Context, backup *entities.Backup, path string, targetHost *entities.Host, targetPath string) (string, error) {
-	args := m.Called(ctx, backup, path, targetHost, targetPath)
+func (m *MockTaskPublisher) PublishListSnapshotsTask(ctx context.Context, path string) (string, error) {
+	args := m.Called(ctx, path)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTaskPublisher) PublishFileVersionsTask(ctx context.Context, path string, filePath string) (string, error) {
+	args := m.Called(ctx, path, filePath)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTaskPublisher) PublishDiffSnapshotsTask(ctx context.Context, path string, from string, to string) (string, error) {
+	args := m.Called(ctx, path, from, to)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTaskPublisher) PublishRemoteRestoreTask(ctx context.Context, backup *entities.Backup, path string, archivePath string, replica *valueobjects.ReplicaLocation, cold *valueobjects.ColdLocation, targetHost *entities.Host, targetPath string, options valueobjects.RestoreOptions, taskID string) (string, error) {
+	args := m.Called(ctx, backup, path, archivePath, replica, cold, targetHost, targetPath, options, taskID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTaskPublisher) PublishDownloadTask(ctx context.Context, backup *entities.Backup, path string, archivePath string, replica *valueobjects.ReplicaLocation, cold *valueobjects.ColdLocation, format valueobjects.DownloadFormat, stream string) (string, error) {
+	args := m.Called(ctx, backup, path, archivePath, replica, cold, format, stream)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTaskPublisher) PublishReplicateTask(ctx context.Context, backup *entities.Backup, endpoint valueobjects.ReplicaEndpoint, replicated []string, retention int) (string, error) {
+	args := m.Called(ctx, backup, endpoint, replicated, retention)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTaskPublisher) PublishDiskUsageTask(ctx context.Context, path string) (string, error) {
+	args := m.Called(ctx, path)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTaskPublisher) PublishMigrateStorageTask(ctx context.Context, backup *entities.Backup, root string, poolID string) (string, error) {
+	args := m.Called(ctx, backup, root, poolID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTaskPublisher) PublishTierTask(ctx context.Context, backup *entities.Backup, policy valueobjects.TieringPolicy, coldRoot string, replicated []string) (string, error) {
+	args := m.Called(ctx, backup, policy, coldRoot, replicated)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTaskPublisher) PublishDeleteDataTask(ctx context.Context, backupID string, root string, path string, cold []valueobjects.ColdLocation) (string, error) {
+	args := m.Called(ctx, backupID, root, path, cold)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTaskPublisher) PublishScanOrphansTask(ctx context.Context, roots []string, referenced []string) (string, error) {
+	args := m.Called(ctx, roots, referenced)
 	return args.String(0), args.Error(1)
 }
 
@@ -70,16 +129,46 @@ type MockWorkerQueryBus struct {
 	mock.Mock
 }
 
-func (m *MockWorkerQueryBus) SearchFiles(ctx context.Context, pattern string) (workerDto.SearchFilesResult, error) {
-	args := m.Called(ctx, pattern)
-	return args.Get(0).(workerDto.SearchFilesResult), args.Error(1)
-}
-
 func (m *MockWorkerQueryBus) ListFiles(ctx context.Context, path string) (workerDto.ListFilesResult, error) {
 	args := m.Called(ctx, path)
 	return args.Get(0).(workerDto.ListFilesResult), args.Error(1)
 }
 
+func (m *MockWorkerQueryBus) ListArchive(ctx context.Context, backupID string, archive string, archivePath string) (workerDto.ListFilesResult, error) {
+	args := m.Called(ctx, backupID, archive, archivePath)
+	return args.Get(0).(workerDto.ListFilesResult), args.Error(1)
+}
+
+func (m *MockWorkerQueryBus) ListSnapshots(ctx context.Context, path string) (workerDto.ListSnapshotsResult, error) {
+	args := m.Called(ctx, path)
+	return args.Get(0).(workerDto.ListSnapshotsResult), args.Error(1)
+}
+
+func (m *MockWorkerQueryBus) FileVersions(ctx context.Context, path string, filePath string) (workerDto.FileVersionsResult, error) {
+	args := m.Called(ctx, path, filePath)
+	return args.Get(0).(workerDto.FileVersionsResult), args.Error(1)
+}
+
+func (m *MockWorkerQueryBus) DiffSnapshots(ctx context.Context, path string, from string, to string) (workerDto.SnapshotDiffResult, error) {
+	args := m.Called(ctx, path, from, to)
+	return args.Get(0).(workerDto.SnapshotDiffResult), args.Error(1)
+}
+
+func (m *MockWorkerQueryBus) PreviewRemoteRestore(ctx context.Context, backup *entities.Backup, path string, archivePath string, replica *valueobjects.ReplicaLocation, cold *valueobjects.ColdLocation, targetHost *entities.Host, targetPath string, options valueobjects.RestoreOptions) (workerDto.RestorePreviewResult, error) {
+	args := m.Called(ctx, backup, path, archivePath, replica, cold, targetHost, targetPath, options)
+	return args.Get(0).(workerDto.RestorePreviewResult), args.Error(1)
+}
+
+func (m *MockWorkerQueryBus) DiskUsage(ctx context.Context, path string) (workerDto.DiskUsageResult, error) {
+	args := m.Called(ctx, path)
+	return args.Get(0).(workerDto.DiskUsageResult), args.Error(1)
+}
+
+func (m *MockWorkerQueryBus) ScanOrphans(ctx context.Context, roots []string, referenced []string) (workerDto.OrphanScanResult, error) {
+	args := m.Called(ctx, roots, referenced)
+	return args.Get(0).(workerDto.OrphanScanResult), args.Error(1)
+}
+
 // MockBackupErrorRepository is a mock of BackupErrorRepository
 type MockBackupErrorRepository struct {
 	mock.Mock
@@ -110,10 +199,10 @@ func setupBackupHandler() (*backupHttp.BackupHandler, *memory.BackupRepositoryMe
 
 	hostService := application.NewHostService(hostRepo, backupRepo)
 
-	lifecycleService := application.NewBackupLifecycleService(backupRepo, hostService, publisher, backupAssembler)
+	lifecycleService := application.NewBackupLifecycleService(backupRepo, memory.NewFileCatalogRepositoryMemory(), hostService, publisher, backupAssembler, nil)
 	queryService := application.NewBackupQueryService(backupRepo, hostService, backupErrorRepo, backupAssembler)
-	searchService := application.NewBackupSearchService(backupRepo, hostService, queryBus, backupAssembler)
-	restoreService := application.NewBackupRestoreService(backupRepo, hostService, publisher)
+	searchService := application.NewBackupSearchService(backupRepo, memory.NewFileCatalogRepositoryMemory(), hostService, queryBus, backupAssembler, nil)
+	restoreService := application.NewBackupRestoreService(backupRepo, hostService, publisher, queryBus, nil, nil, nil, nil)
 	taskService := application.NewBackupTaskService(publisher, resultStore)
 	hookService := application.NewBackupHookService(backupRepo, backupAssembler)
 
@@ -124,6 +213,7 @@ func setupBackupHandler() (*backupHttp.BackupHandler, *memory.BackupRepositoryMe
 		restoreService,
 		taskService,
 		hookService,
+		nil,
 	)
 
 	return handler, backupRepo, hostRepo, publisher, resultStore, queryBus
@@ -164,6 +254,45 @@ func TestCreateBackup(t *testing.T) {
 	assert.True(t, resp.Incremental) // Check incremental flag
 }
 
+func TestCreateBackup_RetentionPolicy(t *testing.T) {
+	handler, _, hostRepo, _, _, _ := setupBackupHandler()
+
+	host := entities.NewHost("Test Host", "test.example.com", "user", 22, "path", false)
+	_ = hostRepo.Save(context.Background(), host)
+
+	newRequest := func(policy dto.RetentionPolicyDTO) *http.Request {
+		body, _ := json.Marshal(dto.CreateBackupRequest{
+			HostID:          host.ID().String(),
+			Path:            "/source",
+			Destination:     "/dest",
+			Schedule:        "0 0 * * *",
+			Incremental:     true,
+			Retention:       3,
+			RetentionPolicy: &policy,
+		})
+		req, _ := http.NewRequest("POST", "/backups", bytes.NewBuffer(body))
+		return req
+	}
+
+	t.Run("stores the policy", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.Create(rr, newRequest(dto.RetentionPolicyDTO{KeepDaily: 7, KeepWeekly: 4, KeepMonthly: 12}))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var resp dto.BackupResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, 3, resp.Retention)
+		assert.Equal(t, dto.RetentionPolicyDTO{KeepDaily: 7, KeepWeekly: 4, KeepMonthly: 12}, resp.RetentionPolicy)
+	})
+
+	t.Run("rejects negative counts", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.Create(rr, newRequest(dto.RetentionPolicyDTO{KeepDaily: -1}))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
 func TestListBackups(t *testing.T) {
 	handler, backupRepo, hostRepo, _, _, _ := setupBackupHandler()
 
@@ -261,6 +390,28 @@ func TestDeleteBackup(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestDeleteBackup_LegalHold(t *testing.T) {
+	handler, backupRepo, hostRepo, _, _, _ := setupBackupHandler()
+
+	host := entities.NewHost("Test Host", "test.example.com", "user", 22, "path", false)
+	host.SetLegalHold(true)
+	_ = hostRepo.Save(context.Background(), host)
+
+	backup, err := entities.NewBackup(host.ID(), "/source", "/dest", entities.NewBackupSchedule("0 0 * * *"), []string{}, false, 0, false)
+	assert.NoError(t, err)
+	_ = backupRepo.Save(context.Background(), backup)
+
+	req, _ := http.NewRequest("DELETE", "/backups/"+backup.ID().String(), nil)
+	req.SetPathValue("id", backup.ID().String())
+	rr := httptest.NewRecorder()
+
+	handler.Delete(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	_, err = backupRepo.FindByID(context.Background(), backup.ID())
+	assert.NoError(t, err)
+}
+
 func TestRunBackup(t *testing.T) {
 	handler, backupRepo, hostRepo, publisher, _, _ := setupBackupHandler()
 
@@ -383,3 +534,151 @@ func TestRunHostBackups(t *testing.T) {
 
 	publisher.AssertExpectations(t)
 }
+
+func TestRebuildCatalog(t *testing.T) {
+	handler, backupRepo, hostRepo, publisher, _, _ := setupBackupHandler()
+
+	host := entities.NewHost("Test Host", "test.example.com", "user", 22, "path", false)
+	_ = hostRepo.Save(context.Background(), host)
+	backup, err := entities.NewBackup(host.ID(), "/source", "/dest", entities.NewBackupSchedule("0 0 * * *"), []string{}, true, 5, false)
+	assert.NoError(t, err)
+	_ = backupRepo.Save(context.Background(), backup)
+
+	publisher.On("PublishCatalogTask", mock.Anything, mock.Anything, "").Return("catalog-task", nil)
+
+	req, _ := http.NewRequest("POST", "/backups/"+backup.ID().String()+"/catalog", nil)
+	req.SetPathValue("id", backup.ID().String())
+	rr := httptest.NewRecorder()
+
+	handler.RebuildCatalog(rr, req)
+
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	var resp map[string]string
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "catalog-task", resp["task_id"])
+	publisher.AssertExpectations(t)
+}
+
+func TestSearchFiles(t *testing.T) {
+	handler, _, _, _, _, _ := setupBackupHandler()
+
+	t.Run("returns an empty page", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/files/search?pattern=*.txt&page=2", nil)
+		rr := httptest.NewRecorder()
+
+		handler.SearchFiles(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp dto.FileSearchResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, 0, resp.Total)
+		assert.Equal(t, 2, resp.Page)
+		assert.Empty(t, resp.Results)
+	})
+
+	t.Run("rejects invalid filters", func(t *testing.T) {
+		for _, query := range []string{"regex=(", "min_size=ten", "page=x"} {
+			req, _ := http.NewRequest("GET", "/files/search?"+query, nil)
+			rr := httptest.NewRecorder()
+
+			handler.SearchFiles(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+	})
+}
+
+func TestGrepFiles(t *testing.T) {
+	handler, _, _, _, _, _ := setupBackupHandler()
+
+	t.Run("returns an empty page", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/files/grep?q=api_key", nil)
+		rr := httptest.NewRecorder()
+
+		handler.GrepFiles(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp dto.FileGrepResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, 0, resp.Total)
+		assert.Equal(t, 1, resp.Page)
+		assert.Empty(t, resp.Results)
+	})
+
+	t.Run("rejects invalid queries", func(t *testing.T) {
+		for _, query := range []string{"", "q=ab", "q=api_key&backup_id=nope", "q=api_key&page_size=x"} {
+			req, _ := http.NewRequest("GET", "/files/grep?"+query, nil)
+			rr := httptest.NewRecorder()
+
+			handler.GrepFiles(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+	})
+}
+
+// fakeDownloadStager stages a fixed archive after starting the worker task.
+type fakeDownloadStager struct {
+	dir     string
+	content []byte
+}
+
+func (s *fakeDownloadStager) Stage(ctx context.Context, key string, start func(ctx context.Context, stream string) error) (*interfaces.StagedDownload, error) {
+	if err := start(ctx, "download_stream:test"); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(s.dir, key+".download")
+	if err := os.WriteFile(path, s.content, 0600); err != nil {
+		return nil, err
+	}
+	return &interfaces.StagedDownload{Path: path, Size: int64(len(s.content)), ModTime: time.Now()}, nil
+}
+
+func TestDownload(t *testing.T) {
+	backupRepo := memory.NewBackupRepositoryMemoryEmpty()
+	hostRepo := memory.NewHostRepositoryMemory()
+	publisher := new(MockTaskPublisher)
+	stager := &fakeDownloadStager{dir: t.TempDir(), content: []byte("0123456789")}
+	hostService := application.NewHostService(hostRepo, backupRepo)
+	restoreService := application.NewBackupRestoreService(backupRepo, hostService, publisher, new(MockWorkerQueryBus), stager, nil, nil, nil)
+	handler := backupHttp.NewBackupHandler(nil, nil, nil, restoreService, nil, nil, nil)
+
+	host := entities.NewHost("Test Host", "test.example.com", "user", 22, "path", false)
+	_ = hostRepo.Save(context.Background(), host)
+	backup, err := entities.NewBackup(host.ID(), "/etc", "etc", entities.NewBackupSchedule("0 0 * * *"), []string{}, false, 0, false)
+	assert.NoError(t, err)
+	_ = backupRepo.Save(context.Background(), backup)
+
+	publisher.On("PublishDownloadTask", mock.Anything, mock.Anything, "/mnt/backups/path/etc/nginx", "", mock.Anything, mock.Anything, valueobjects.DownloadFormatZip, "download_stream:test").Return("task-1", nil)
+
+	download := func(query string, headers map[string]string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/backups/"+backup.ID().String()+"/download?"+query, nil)
+		req.SetPathValue("id", backup.ID().String())
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rr := httptest.NewRecorder()
+		handler.Download(rr, req)
+		return rr
+	}
+
+	rr := download("path=/nginx&format=zip", nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "0123456789", rr.Body.String())
+	assert.Equal(t, "application/zip", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename=nginx.zip`, rr.Header().Get("Content-Disposition"))
+	etag := rr.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	rr = download("path=/nginx&format=zip", map[string]string{"Range": "bytes=4-", "If-Range": etag})
+	assert.Equal(t, http.StatusPartialContent, rr.Code)
+	assert.Equal(t, "456789", rr.Body.String())
+	assert.Equal(t, "bytes 4-9/10", rr.Header().Get("Content-Range"))
+
+	rr = download("path=/nginx&format=zip", map[string]string{"Range": "bytes=4-", "If-Range": `"stale"`})
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "0123456789", rr.Body.String())
+
+	rr = download("path=/nginx&format=rar", nil)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -2,20 +2,25 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 
 	"github.com/rrbarrero/justbackup/internal/backup/application"
 	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
 )
 
 type HostHandler struct {
-	service *application.HostService
+	service    *application.HostService
+	storedData *application.StoredDataService
 }
 
-func NewHostHandler(service *application.HostService) *HostHandler {
+func NewHostHandler(service *application.HostService, storedData *application.StoredDataService) *HostHandler {
 	return &HostHandler{
-		service: service,
+		service:    service,
+		storedData: storedData,
 	}
 }
 
@@ -140,19 +145,36 @@ func (h *HostHandler) Update(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Delete a host
-// @Description Delete a host configuration
+// @Description Delete a host configuration and its backups. With with_data the snapshots of its backups are removed from the worker too
 // @Tags hosts
 // @Accept  json
 // @Produce  json
-// @Param   id     path    string     true  "Host ID"
+// @Param   id         path    string     true   "Host ID"
+// @Param   with_data  query   bool       false  "Also delete the stored data of its backups"
 // @Success 204 "No Content"
+// @Success 202 {object} dto.DeleteDataResponse
 // @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Host not found"
+// @Failure 409 {string} string "Blocked by legal hold or a running backup"
 // @Failure 500 {string} string "Internal Server Error"
 // @Security BasicAuth
 // @Router /hosts/{id} [delete]
 func (h *HostHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if withData(r) {
+		deleteWithData(w, r, h.storedData, (*application.StoredDataService).DeleteHost)
+		return
+	}
+
 	id := r.PathValue("id")
 	if err := h.service.DeleteHost(r.Context(), id); err != nil {
+		if errors.Is(err, shared.ErrNotFound) {
+			http.Error(w, "Host not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, entities.ErrLegalHold) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
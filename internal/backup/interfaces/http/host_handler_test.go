@@ -20,7 +20,7 @@ func setupHostHandler() (*backupHttp.HostHandler, *memory.HostRepositoryMemory)
 	hostRepo := memory.NewHostRepositoryMemory()
 	backupRepo := memory.NewBackupRepositoryMemory()
 	service := application.NewHostService(hostRepo, backupRepo)
-	handler := backupHttp.NewHostHandler(service)
+	handler := backupHttp.NewHostHandler(service, nil)
 	return handler, hostRepo
 }
 
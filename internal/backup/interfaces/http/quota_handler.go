@@ -0,0 +1,95 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application"
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+)
+
+type QuotaHandler struct {
+	service *application.QuotaService
+}
+
+func NewQuotaHandler(service *application.QuotaService) *QuotaHandler {
+	return &QuotaHandler{
+		service: service,
+	}
+}
+
+func (h *QuotaHandler) RegisterRoutes(mux *http.ServeMux, middleware func(http.HandlerFunc) http.HandlerFunc) {
+	mux.HandleFunc("PUT /backups/{id}/quota", middleware(h.SetBackupQuota))
+	mux.HandleFunc("PUT /hosts/{id}/quota", middleware(h.SetHostQuota))
+}
+
+// @Summary Set backup quota
+// @Description Cap the bytes a backup stores on disk and the bytes a single run may add. A run that would break the quota fails and retention is applied early. Zero lifts a limit
+// @Tags backups
+// @Accept  json
+// @Produce  json
+// @Param   id     path    string             true  "Backup ID"
+// @Param   quota  body    dto.QuotaRequest   true  "Quota"
+// @Success 200 {object} dto.QuotaResponse
+// @Success 204 "Quota lifted"
+// @Failure 400 {string} string "Invalid request body"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Backup not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /backups/{id}/quota [put]
+func (h *QuotaHandler) SetBackupQuota(w http.ResponseWriter, r *http.Request) {
+	h.setQuota(w, r, h.service.SetBackupQuota)
+}
+
+// @Summary Set host quota
+// @Description Cap the bytes all backups of a host store on disk together and the bytes a single run of any of them may add. A run that would break the quota fails and retention is applied early to the backups of the host. Zero lifts a limit
+// @Tags hosts
+// @Accept  json
+// @Produce  json
+// @Param   id     path    string             true  "Host ID"
+// @Param   quota  body    dto.QuotaRequest   true  "Quota"
+// @Success 200 {object} dto.QuotaResponse
+// @Success 204 "Quota lifted"
+// @Failure 400 {string} string "Invalid request body"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Host not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /hosts/{id}/quota [put]
+func (h *QuotaHandler) SetHostQuota(w http.ResponseWriter, r *http.Request) {
+	h.setQuota(w, r, h.service.SetHostQuota)
+}
+
+type quotaSetter func(ctx context.Context, id string, req dto.QuotaRequest) (*dto.QuotaResponse, error)
+
+func (h *QuotaHandler) setQuota(w http.ResponseWriter, r *http.Request, set quotaSetter) {
+	var req dto.QuotaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := set(r.Context(), r.PathValue("id"), req)
+	if err != nil {
+		http.Error(w, err.Error(), quotaErrorStatus(err))
+		return
+	}
+	if resp == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusOK, resp)
+}
+
+func quotaErrorStatus(err error) int {
+	if errors.Is(err, shared.ErrInvalidID) || errors.Is(err, valueobjects.ErrInvalidQuota) {
+		return http.StatusBadRequest
+	}
+	return snapshotErrorStatus(err)
+}
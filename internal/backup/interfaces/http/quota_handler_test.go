@@ -0,0 +1,66 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application"
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/backup/infrastructure/persistence/memory"
+	backupHttp "github.com/rrbarrero/justbackup/internal/backup/interfaces/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotaHandler_SetHostQuota(t *testing.T) {
+	backupRepo := memory.NewBackupRepositoryMemoryEmpty()
+	hostRepo := memory.NewHostRepositoryMemory()
+	handler := backupHttp.NewQuotaHandler(application.NewQuotaService(backupRepo, hostRepo))
+
+	host := entities.NewHost("Test Host", "test.example.com", "user", 22, "path", false)
+	_ = hostRepo.Save(context.Background(), host)
+	backup, err := entities.NewBackup(host.ID(), "/source", "/dest", entities.NewBackupSchedule("0 0 * * *"), nil, true, 1, false)
+	assert.NoError(t, err)
+	backup.SetUsage(valueobjects.SpaceUsage{Size: 500, Stored: 700})
+	_ = backupRepo.Save(context.Background(), backup)
+
+	put := func(id, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("PUT", "/hosts/"+id+"/quota", bytes.NewBufferString(body))
+		req.SetPathValue("id", id)
+		rr := httptest.NewRecorder()
+		handler.SetHostQuota(rr, req)
+		return rr
+	}
+
+	t.Run("success", func(t *testing.T) {
+		rr := put(host.ID().String(), `{"bytes":1000,"snapshot_bytes":200}`)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp dto.QuotaResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, dto.QuotaResponse{Bytes: 1000, SnapshotBytes: 200, Used: 700}, resp)
+	})
+
+	t.Run("lifted", func(t *testing.T) {
+		rr := put(host.ID().String(), `{"bytes":0}`)
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		rr := put(host.ID().String(), `{"bytes":-1}`)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		rr := put(entities.NewHost("Other", "other", "user", 22, "other", false).ID().String(), `{"bytes":1000}`)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
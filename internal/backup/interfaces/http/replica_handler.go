@@ -0,0 +1,203 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application"
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+)
+
+type ReplicaHandler struct {
+	service *application.ReplicaService
+}
+
+func NewReplicaHandler(service *application.ReplicaService) *ReplicaHandler {
+	return &ReplicaHandler{
+		service: service,
+	}
+}
+
+func (h *ReplicaHandler) RegisterRoutes(mux *http.ServeMux, middleware func(http.HandlerFunc) http.HandlerFunc) {
+	mux.HandleFunc("GET /replica-targets", middleware(h.List))
+	mux.HandleFunc("POST /replica-targets", middleware(h.Create))
+	mux.HandleFunc("GET /replica-targets/{id}", middleware(h.Get))
+	mux.HandleFunc("PUT /replica-targets/{id}", middleware(h.Update))
+	mux.HandleFunc("DELETE /replica-targets/{id}", middleware(h.Delete))
+	mux.HandleFunc("POST /backups/{id}/replicate", middleware(h.Replicate))
+	mux.HandleFunc("GET /backups/{id}/replicas", middleware(h.Replicas))
+}
+
+// @Summary List replica targets
+// @Description List the offsite targets the snapshots of backups are copied to after each successful run. Secret keys are never returned
+// @Tags replicas
+// @Produce  json
+// @Success 200 {array} dto.ReplicaTargetResponse
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /replica-targets [get]
+func (h *ReplicaHandler) List(w http.ResponseWriter, r *http.Request) {
+	targets, err := h.service.ListTargets(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), replicaErrorStatus(err))
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusOK, targets)
+}
+
+// @Summary Create a replica target
+// @Description Create an offsite target, an S3-compatible bucket or a directory of an SSH host, that the snapshots of the listed backups, or of every backup, are copied to after each successful run
+// @Tags replicas
+// @Accept  json
+// @Produce  json
+// @Param   target body    dto.ReplicaTargetRequest  true  "Replica target"
+// @Success 201 {object} dto.ReplicaTargetResponse
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Backup not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /replica-targets [post]
+func (h *ReplicaHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req dto.ReplicaTargetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	target, err := h.service.CreateTarget(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), replicaErrorStatus(err))
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusCreated, target)
+}
+
+// @Summary Get a replica target
+// @Tags replicas
+// @Produce  json
+// @Param   id     path    string     true  "Replica target ID"
+// @Success 200 {object} dto.ReplicaTargetResponse
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Replica target not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /replica-targets/{id} [get]
+func (h *ReplicaHandler) Get(w http.ResponseWriter, r *http.Request) {
+	target, err := h.service.GetTarget(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), replicaErrorStatus(err))
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusOK, target)
+}
+
+// @Summary Update a replica target
+// @Description Replace the definition of a replica target. An empty secret key keeps the current one
+// @Tags replicas
+// @Accept  json
+// @Produce  json
+// @Param   id     path    string     true  "Replica target ID"
+// @Param   target body    dto.ReplicaTargetRequest  true  "Replica target"
+// @Success 200 {object} dto.ReplicaTargetResponse
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Replica target or backup not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /replica-targets/{id} [put]
+func (h *ReplicaHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req dto.ReplicaTargetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	target, err := h.service.UpdateTarget(r.Context(), r.PathValue("id"), req)
+	if err != nil {
+		http.Error(w, err.Error(), replicaErrorStatus(err))
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusOK, target)
+}
+
+// @Summary Delete a replica target
+// @Description Delete a replica target and the record of the snapshots it holds. The data already copied to it is left in place
+// @Tags replicas
+// @Param   id     path    string     true  "Replica target ID"
+// @Success 204 "No Content"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Replica target not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /replica-targets/{id} [delete]
+func (h *ReplicaHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteTarget(r.Context(), r.PathValue("id")); err != nil {
+		http.Error(w, err.Error(), replicaErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Replicate a backup now
+// @Description Copy the snapshots of a backup its replica targets do not hold yet, outside of a backup run
+// @Tags replicas
+// @Produce  json
+// @Param   id     path    string     true  "Backup ID"
+// @Success 202 {object} dto.ReplicateResponse
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Backup not found"
+// @Failure 409 {string} string "No enabled replica target covers the backup"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /backups/{id}/replicate [post]
+func (h *ReplicaHandler) Replicate(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.Sync(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), replicaErrorStatus(err))
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusAccepted, resp)
+}
+
+// @Summary List the replicas of a backup
+// @Description List the state of a backup on each replica target covering it: the snapshots held, the last one copied and how long the target has lagged behind the last run
+// @Tags replicas
+// @Produce  json
+// @Param   id     path    string     true  "Backup ID"
+// @Success 200 {array} dto.BackupReplicaResponse
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Backup not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /backups/{id}/replicas [get]
+func (h *ReplicaHandler) Replicas(w http.ResponseWriter, r *http.Request) {
+	replicas, err := h.service.ListBackupReplicas(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), replicaErrorStatus(err))
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusOK, replicas)
+}
+
+func replicaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, valueobjects.ErrNoReplicaTarget):
+		return http.StatusConflict
+	case errors.Is(err, shared.ErrInvalidID),
+		errors.Is(err, valueobjects.ErrInvalidReplicaTarget):
+		return http.StatusBadRequest
+	}
+	return snapshotErrorStatus(err)
+}
@@ -0,0 +1,215 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application"
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+)
+
+type ReplicationHandler struct {
+	service *application.ReplicationService
+}
+
+func NewReplicationHandler(service *application.ReplicationService) *ReplicationHandler {
+	return &ReplicationHandler{
+		service: service,
+	}
+}
+
+func (h *ReplicationHandler) RegisterRoutes(mux *http.ServeMux, middleware func(http.HandlerFunc) http.HandlerFunc) {
+	mux.HandleFunc("GET /replications", middleware(h.List))
+	mux.HandleFunc("POST /replications", middleware(h.Create))
+	mux.HandleFunc("GET /replications/{id}", middleware(h.Get))
+	mux.HandleFunc("PUT /replications/{id}", middleware(h.Update))
+	mux.HandleFunc("DELETE /replications/{id}", middleware(h.Delete))
+	mux.HandleFunc("POST /replications/{id}/run", middleware(h.Run))
+	mux.HandleFunc("GET /replications/{id}/runs", middleware(h.Runs))
+}
+
+// @Summary List replication jobs
+// @Description List the replication jobs, which restore a backup to a target host on a schedule
+// @Tags replications
+// @Produce  json
+// @Success 200 {array} dto.ReplicationJobResponse
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /replications [get]
+func (h *ReplicationHandler) List(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.service.ListJobs(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), replicationErrorStatus(err))
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusOK, jobs)
+}
+
+// @Summary Create a replication job
+// @Description Create a job that restores a path and snapshot of a backup to a target host on a cron schedule, through the same remote restore as `restore --remote`. Incremental backups replicate the latest snapshot unless another selector is given
+// @Tags replications
+// @Accept  json
+// @Produce  json
+// @Param   job    body    dto.ReplicationJobRequest  true  "Replication job"
+// @Success 201 {object} dto.ReplicationJobResponse
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Backup or target host not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /replications [post]
+func (h *ReplicationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req dto.ReplicationJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.service.CreateJob(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), replicationErrorStatus(err))
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusCreated, job)
+}
+
+// @Summary Get a replication job
+// @Tags replications
+// @Produce  json
+// @Param   id     path    string     true  "Replication job ID"
+// @Success 200 {object} dto.ReplicationJobResponse
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Replication job not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /replications/{id} [get]
+func (h *ReplicationHandler) Get(w http.ResponseWriter, r *http.Request) {
+	job, err := h.service.GetJob(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), replicationErrorStatus(err))
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusOK, job)
+}
+
+// @Summary Update a replication job
+// @Description Replace the definition of a replication job and reschedule it. The backup it replicates cannot change
+// @Tags replications
+// @Accept  json
+// @Produce  json
+// @Param   id     path    string     true  "Replication job ID"
+// @Param   job    body    dto.ReplicationJobRequest  true  "Replication job"
+// @Success 200 {object} dto.ReplicationJobResponse
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Replication job or target host not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /replications/{id} [put]
+func (h *ReplicationHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req dto.ReplicationJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.service.UpdateJob(r.Context(), r.PathValue("id"), req)
+	if err != nil {
+		http.Error(w, err.Error(), replicationErrorStatus(err))
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusOK, job)
+}
+
+// @Summary Delete a replication job
+// @Description Delete a replication job and its run history. The data already replicated to the target is left in place
+// @Tags replications
+// @Param   id     path    string     true  "Replication job ID"
+// @Success 204 "No Content"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Replication job not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /replications/{id} [delete]
+func (h *ReplicationHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteJob(r.Context(), r.PathValue("id")); err != nil {
+		http.Error(w, err.Error(), replicationErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Run a replication job now
+// @Description Start a run of a replication job outside its schedule. A job runs once at a time
+// @Tags replications
+// @Produce  json
+// @Param   id     path    string     true  "Replication job ID"
+// @Success 202 {object} dto.ReplicationRunResponse
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Replication job not found"
+// @Failure 409 {string} string "Replication job is already running"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /replications/{id}/run [post]
+func (h *ReplicationHandler) Run(w http.ResponseWriter, r *http.Request) {
+	run, err := h.service.RunJob(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), replicationErrorStatus(err))
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusAccepted, run)
+}
+
+// @Summary List replication runs
+// @Description List the latest runs of a replication job, newest first
+// @Tags replications
+// @Produce  json
+// @Param   id     path    string     true  "Replication job ID"
+// @Success 200 {array} dto.ReplicationRunResponse
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Replication job not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /replications/{id}/runs [get]
+func (h *ReplicationHandler) Runs(w http.ResponseWriter, r *http.Request) {
+	runs, err := h.service.ListRuns(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), replicationErrorStatus(err))
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusOK, runs)
+}
+
+func writeReplicationJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+func replicationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, entities.ErrReplicationRunning):
+		return http.StatusConflict
+	case errors.Is(err, shared.ErrInvalidID),
+		errors.Is(err, entities.ErrInvalidReplicationJob),
+		errors.Is(err, valueobjects.ErrInvalidConflictPolicy),
+		errors.Is(err, valueobjects.ErrInvalidRestoreOptions):
+		return http.StatusBadRequest
+	}
+	return snapshotErrorStatus(err)
+}
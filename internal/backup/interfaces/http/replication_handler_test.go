@@ -0,0 +1,97 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application"
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/infrastructure/persistence/memory"
+	backupHttp "github.com/rrbarrero/justbackup/internal/backup/interfaces/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestReplicationHandler(t *testing.T) {
+	backupRepo := memory.NewBackupRepositoryMemoryEmpty()
+	hostRepo := memory.NewHostRepositoryMemory()
+	publisher := new(MockTaskPublisher)
+	hostService := application.NewHostService(hostRepo, backupRepo)
+	restoreService := application.NewBackupRestoreService(backupRepo, hostService, publisher, new(MockWorkerQueryBus), nil, nil, nil, nil)
+	service := application.NewReplicationService(memory.NewReplicationJobRepositoryMemory(), memory.NewReplicationRunRepositoryMemory(), backupRepo, hostService, restoreService)
+
+	mux := http.NewServeMux()
+	backupHttp.NewReplicationHandler(service).RegisterRoutes(mux, func(h http.HandlerFunc) http.HandlerFunc { return h })
+
+	host := entities.NewHost("Test Host", "test.example.com", "user", 22, "path", false)
+	_ = hostRepo.Save(context.Background(), host)
+	backup, err := entities.NewBackup(host.ID(), "/source", "dest", entities.NewBackupSchedule("0 0 * * *"), nil, false, 1, false)
+	assert.NoError(t, err)
+	_ = backupRepo.Save(context.Background(), backup)
+
+	serve := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		var payload bytes.Buffer
+		if body != nil {
+			_ = json.NewEncoder(&payload).Encode(body)
+		}
+		req, _ := http.NewRequest(method, path, &payload)
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		return rr
+	}
+
+	request := dto.ReplicationJobRequest{
+		Name:         "staging",
+		BackupID:     backup.ID().String(),
+		TargetHostID: host.ID().String(),
+		TargetPath:   "/srv/staging",
+		Schedule:     "0 3 * * *",
+	}
+
+	t.Run("invalid conflict policy", func(t *testing.T) {
+		invalid := request
+		invalid.Conflict = "merge"
+		rr := serve("POST", "/replications", invalid)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	rr := serve("POST", "/replications", request)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var job dto.ReplicationJobResponse
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&job))
+	assert.Equal(t, "overwrite", job.Conflict)
+
+	t.Run("run locks the job", func(t *testing.T) {
+		publisher.On("PublishRemoteRestoreTask", mock.Anything, mock.Anything, "/mnt/backups/path/dest", "", mock.Anything, mock.Anything, mock.Anything, "/srv/staging", mock.Anything, mock.Anything).Return("task-1", nil).Once()
+
+		rr := serve("POST", "/replications/"+job.ID+"/run", nil)
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+
+		rr = serve("POST", "/replications/"+job.ID+"/run", nil)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+
+		rr = serve("GET", "/replications/"+job.ID+"/runs", nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var runs []dto.ReplicationRunResponse
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&runs))
+		assert.Len(t, runs, 1)
+		assert.NotEmpty(t, runs[0].TaskID)
+		assert.Equal(t, "running", runs[0].Status)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		rr := serve("DELETE", "/replications/"+job.ID, nil)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+
+		rr = serve("GET", "/replications/"+job.ID, nil)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = serve("GET", "/replications/not-an-id", nil)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -0,0 +1,284 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application"
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/auth"
+)
+
+type RetentionHandler struct {
+	service *application.BackupRetentionService
+}
+
+func NewRetentionHandler(service *application.BackupRetentionService) *RetentionHandler {
+	return &RetentionHandler{
+		service: service,
+	}
+}
+
+func (h *RetentionHandler) RegisterRoutes(mux *http.ServeMux, middleware func(http.HandlerFunc) http.HandlerFunc) {
+	mux.HandleFunc("GET /backups/{id}/retention/preview", middleware(h.Preview))
+	mux.HandleFunc("GET /backups/{id}/pins", middleware(h.ListPins))
+	mux.HandleFunc("POST /backups/{id}/pins", middleware(h.Pin))
+	mux.HandleFunc("DELETE /backups/{id}/pins/{snapshot}", middleware(h.Unpin))
+	mux.HandleFunc("PUT /backups/{id}/legal-hold", middleware(h.SetBackupLegalHold))
+	mux.HandleFunc("GET /backups/{id}/legal-hold/events", middleware(h.ListBackupLegalHoldEvents))
+	mux.HandleFunc("PUT /hosts/{id}/legal-hold", middleware(h.SetHostLegalHold))
+	mux.HandleFunc("GET /hosts/{id}/legal-hold/events", middleware(h.ListHostLegalHoldEvents))
+}
+
+// @Summary Preview retention
+// @Description Dry-run of the backup's retention policy: lists the snapshots that would be kept (and by which rule) and the ones that would be purged
+// @Tags backups
+// @Accept  json
+// @Produce  json
+// @Param   id     path    string     true  "Backup ID"
+// @Success 200 {object} dto.RetentionPreviewResponse
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Backup not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /backups/{id}/retention/preview [get]
+func (h *RetentionHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	preview, err := h.service.PreviewRetention(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, shared.ErrNotFound) {
+			http.Error(w, "Backup not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(preview); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// @Summary List snapshot pins
+// @Description List the pinned snapshots of a backup, including expired pins
+// @Tags backups
+// @Accept  json
+// @Produce  json
+// @Param   id     path    string     true  "Backup ID"
+// @Success 200 {array} dto.SnapshotPinResponse
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Backup not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /backups/{id}/pins [get]
+func (h *RetentionHandler) ListPins(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	pins, err := h.service.ListPins(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, shared.ErrNotFound) {
+			http.Error(w, "Backup not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(pins); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// @Summary Pin a snapshot
+// @Description Exempt a snapshot from retention, optionally until an expiry date. Pinning a pinned snapshot replaces its label, note and expiry
+// @Tags backups
+// @Accept  json
+// @Produce  json
+// @Param   id     path    string     true  "Backup ID"
+// @Param   pin    body    dto.PinSnapshotRequest  true  "Pin"
+// @Success 201 {object} dto.SnapshotPinResponse
+// @Failure 400 {string} string "Invalid request body"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Backup not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /backups/{id}/pins [post]
+func (h *RetentionHandler) Pin(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var req dto.PinSnapshotRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	pin, err := h.service.PinSnapshot(r.Context(), id, req)
+	if err != nil {
+		if errors.Is(err, shared.ErrNotFound) {
+			http.Error(w, "Backup not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, entities.ErrInvalidSnapshotName) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(pin); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// @Summary Unpin a snapshot
+// @Description Remove the pin of a snapshot so retention applies to it again
+// @Tags backups
+// @Accept  json
+// @Produce  json
+// @Param   id        path    string     true  "Backup ID"
+// @Param   snapshot  path    string     true  "Snapshot name"
+// @Success 204 "No Content"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Pin not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /backups/{id}/pins/{snapshot} [delete]
+func (h *RetentionHandler) Unpin(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	snapshot := r.PathValue("snapshot")
+
+	if err := h.service.UnpinSnapshot(r.Context(), id, snapshot); err != nil {
+		if errors.Is(err, shared.ErrNotFound) {
+			http.Error(w, "Pin not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Set backup legal hold
+// @Description Place or release a legal hold on a backup. While held, the backup is never purged or deleted. Every change is audited
+// @Tags backups
+// @Accept  json
+// @Produce  json
+// @Param   id    path    string                 true  "Backup ID"
+// @Param   hold  body    dto.LegalHoldRequest   true  "Legal hold"
+// @Success 200 {object} dto.LegalHoldEventResponse
+// @Failure 400 {string} string "Invalid request body"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Backup not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /backups/{id}/legal-hold [put]
+func (h *RetentionHandler) SetBackupLegalHold(w http.ResponseWriter, r *http.Request) {
+	h.setLegalHold(w, r, "Backup not found", h.service.SetBackupLegalHold)
+}
+
+// @Summary Set host legal hold
+// @Description Place or release a legal hold covering every backup of a host. Every change is audited
+// @Tags hosts
+// @Accept  json
+// @Produce  json
+// @Param   id    path    string                 true  "Host ID"
+// @Param   hold  body    dto.LegalHoldRequest   true  "Legal hold"
+// @Success 200 {object} dto.LegalHoldEventResponse
+// @Failure 400 {string} string "Invalid request body"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Host not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /hosts/{id}/legal-hold [put]
+func (h *RetentionHandler) SetHostLegalHold(w http.ResponseWriter, r *http.Request) {
+	h.setLegalHold(w, r, "Host not found", h.service.SetHostLegalHold)
+}
+
+type legalHoldSetter func(ctx context.Context, id string, req dto.LegalHoldRequest, userID *int) (*dto.LegalHoldEventResponse, error)
+
+func (h *RetentionHandler) setLegalHold(w http.ResponseWriter, r *http.Request, notFound string, set legalHoldSetter) {
+	id := r.PathValue("id")
+	var req dto.LegalHoldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var userID *int
+	if uid, ok := auth.GetUserIDFromContext(r.Context()); ok {
+		userID = &uid
+	}
+
+	event, err := set(r.Context(), id, req, userID)
+	if err != nil {
+		if errors.Is(err, shared.ErrNotFound) {
+			http.Error(w, notFound, http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, entities.ErrLegalHoldReasonRequired) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(event); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// @Summary List backup legal hold events
+// @Description Audit trail of the legal holds placed on and released from a backup, newest first
+// @Tags backups
+// @Accept  json
+// @Produce  json
+// @Param   id     path    string     true  "Backup ID"
+// @Success 200 {array} dto.LegalHoldEventResponse
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /backups/{id}/legal-hold/events [get]
+func (h *RetentionHandler) ListBackupLegalHoldEvents(w http.ResponseWriter, r *http.Request) {
+	h.listLegalHoldEvents(w, r, entities.LegalHoldScopeBackup)
+}
+
+// @Summary List host legal hold events
+// @Description Audit trail of the legal holds placed on and released from a host, newest first
+// @Tags hosts
+// @Accept  json
+// @Produce  json
+// @Param   id     path    string     true  "Host ID"
+// @Success 200 {array} dto.LegalHoldEventResponse
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /hosts/{id}/legal-hold/events [get]
+func (h *RetentionHandler) ListHostLegalHoldEvents(w http.ResponseWriter, r *http.Request) {
+	h.listLegalHoldEvents(w, r, entities.LegalHoldScopeHost)
+}
+
+func (h *RetentionHandler) listLegalHoldEvents(w http.ResponseWriter, r *http.Request, scope entities.LegalHoldScope) {
+	events, err := h.service.ListLegalHoldEvents(r.Context(), scope, r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(events); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
@@ -0,0 +1,199 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application"
+	"github.com/rrbarrero/justbackup/internal/backup/application/assembler"
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/backup/infrastructure/persistence/memory"
+	backupHttp "github.com/rrbarrero/justbackup/internal/backup/interfaces/http"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/auth"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRetentionHandler_Preview(t *testing.T) {
+	backupRepo := memory.NewBackupRepositoryMemoryEmpty()
+	hostRepo := memory.NewHostRepositoryMemory()
+	queryBus := new(MockWorkerQueryBus)
+	service := application.NewBackupRetentionService(backupRepo, hostRepo, memory.NewSnapshotPinRepositoryMemory(), memory.NewLegalHoldEventRepositoryMemory(), queryBus, assembler.NewBackupAssembler(), nil)
+	handler := backupHttp.NewRetentionHandler(service)
+
+	host := entities.NewHost("Test Host", "test.example.com", "user", 22, "path", false)
+	_ = hostRepo.Save(context.Background(), host)
+
+	backup, err := entities.NewBackup(host.ID(), "/source", "/dest", entities.NewBackupSchedule("0 0 * * *"), nil, true, 1, false)
+	assert.NoError(t, err)
+	_ = backupRepo.Save(context.Background(), backup)
+
+	queryBus.On("ListFiles", mock.Anything, "/mnt/backups/path/dest").Return(workerDto.ListFilesResult{
+		Files: []workerDto.FileListItem{
+			{Name: "2024-01-01_00-00-00", IsDir: true},
+			{Name: "2024-01-02_00-00-00", IsDir: true},
+			{Name: "latest", IsDir: true},
+		},
+	}, nil)
+
+	t.Run("success", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/backups/"+backup.ID().String()+"/retention/preview", nil)
+		req.SetPathValue("id", backup.ID().String())
+		rr := httptest.NewRecorder()
+
+		handler.Preview(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp dto.RetentionPreviewResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, []dto.RetainedSnapshotDTO{{Name: "2024-01-02_00-00-00", Rules: []string{"last", "latest"}}}, resp.Keep)
+		assert.Equal(t, []string{"2024-01-01_00-00-00"}, resp.Purge)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		id := valueobjects.NewBackupID().String()
+		req, _ := http.NewRequest("GET", "/backups/"+id+"/retention/preview", nil)
+		req.SetPathValue("id", id)
+		rr := httptest.NewRecorder()
+
+		handler.Preview(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestRetentionHandler_Pins(t *testing.T) {
+	backupRepo := memory.NewBackupRepositoryMemoryEmpty()
+	hostRepo := memory.NewHostRepositoryMemory()
+	service := application.NewBackupRetentionService(backupRepo, hostRepo, memory.NewSnapshotPinRepositoryMemory(), memory.NewLegalHoldEventRepositoryMemory(), new(MockWorkerQueryBus), assembler.NewBackupAssembler(), nil)
+	handler := backupHttp.NewRetentionHandler(service)
+
+	host := entities.NewHost("Test Host", "test.example.com", "user", 22, "path", false)
+	_ = hostRepo.Save(context.Background(), host)
+	backup, err := entities.NewBackup(host.ID(), "/source", "/dest", entities.NewBackupSchedule("0 0 * * *"), nil, true, 1, false)
+	assert.NoError(t, err)
+	_ = backupRepo.Save(context.Background(), backup)
+	id := backup.ID().String()
+
+	t.Run("pin", func(t *testing.T) {
+		body, _ := json.Marshal(dto.PinSnapshotRequest{Snapshot: "2024-01-01_00-00-00", Label: "quarter-end"})
+		req, _ := http.NewRequest("POST", "/backups/"+id+"/pins", bytes.NewBuffer(body))
+		req.SetPathValue("id", id)
+		rr := httptest.NewRecorder()
+
+		handler.Pin(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var resp dto.SnapshotPinResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, "quarter-end", resp.Label)
+		assert.True(t, resp.Active)
+	})
+
+	t.Run("pin invalid snapshot", func(t *testing.T) {
+		body, _ := json.Marshal(dto.PinSnapshotRequest{Snapshot: "latest"})
+		req, _ := http.NewRequest("POST", "/backups/"+id+"/pins", bytes.NewBuffer(body))
+		req.SetPathValue("id", id)
+		rr := httptest.NewRecorder()
+
+		handler.Pin(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/backups/"+id+"/pins", nil)
+		req.SetPathValue("id", id)
+		rr := httptest.NewRecorder()
+
+		handler.ListPins(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp []dto.SnapshotPinResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Len(t, resp, 1)
+	})
+
+	t.Run("unpin", func(t *testing.T) {
+		for _, expected := range []int{http.StatusNoContent, http.StatusNotFound} {
+			req, _ := http.NewRequest("DELETE", "/backups/"+id+"/pins/2024-01-01_00-00-00", nil)
+			req.SetPathValue("id", id)
+			req.SetPathValue("snapshot", "2024-01-01_00-00-00")
+			rr := httptest.NewRecorder()
+
+			handler.Unpin(rr, req)
+
+			assert.Equal(t, expected, rr.Code)
+		}
+	})
+}
+
+func TestRetentionHandler_LegalHold(t *testing.T) {
+	backupRepo := memory.NewBackupRepositoryMemoryEmpty()
+	hostRepo := memory.NewHostRepositoryMemory()
+	service := application.NewBackupRetentionService(backupRepo, hostRepo, memory.NewSnapshotPinRepositoryMemory(), memory.NewLegalHoldEventRepositoryMemory(), new(MockWorkerQueryBus), assembler.NewBackupAssembler(), nil)
+	handler := backupHttp.NewRetentionHandler(service)
+
+	host := entities.NewHost("Test Host", "test.example.com", "user", 22, "path", false)
+	_ = hostRepo.Save(context.Background(), host)
+	hostID := host.ID().String()
+
+	t.Run("reason is required", func(t *testing.T) {
+		body, _ := json.Marshal(dto.LegalHoldRequest{Enabled: true})
+		req, _ := http.NewRequest("PUT", "/hosts/"+hostID+"/legal-hold", bytes.NewBuffer(body))
+		req.SetPathValue("id", hostID)
+		rr := httptest.NewRecorder()
+
+		handler.SetHostLegalHold(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("place hold records the actor", func(t *testing.T) {
+		body, _ := json.Marshal(dto.LegalHoldRequest{Enabled: true, Reason: "litigation"})
+		req, _ := http.NewRequest("PUT", "/hosts/"+hostID+"/legal-hold", bytes.NewBuffer(body))
+		req = req.WithContext(context.WithValue(req.Context(), auth.UserIDKey, 42))
+		req.SetPathValue("id", hostID)
+		rr := httptest.NewRecorder()
+
+		handler.SetHostLegalHold(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		stored, _ := hostRepo.Get(context.Background(), host.ID())
+		assert.True(t, stored.LegalHold())
+	})
+
+	t.Run("events", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/hosts/"+hostID+"/legal-hold/events", nil)
+		req.SetPathValue("id", hostID)
+		rr := httptest.NewRecorder()
+
+		handler.ListHostLegalHoldEvents(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp []dto.LegalHoldEventResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Len(t, resp, 1)
+		assert.Equal(t, "litigation", resp[0].Reason)
+		assert.Equal(t, 42, *resp[0].UserID)
+	})
+
+	t.Run("unknown backup", func(t *testing.T) {
+		id := valueobjects.NewBackupID().String()
+		body, _ := json.Marshal(dto.LegalHoldRequest{Enabled: false})
+		req, _ := http.NewRequest("PUT", "/backups/"+id+"/legal-hold", bytes.NewBuffer(body))
+		req.SetPathValue("id", id)
+		rr := httptest.NewRecorder()
+
+		handler.SetBackupLegalHold(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
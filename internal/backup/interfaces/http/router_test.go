@@ -6,32 +6,34 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/rrbarrero/justbackup/internal/backup/application"
 	"github.com/rrbarrero/justbackup/internal/backup/application/assembler"
 	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
 	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
 	"github.com/rrbarrero/justbackup/internal/backup/infrastructure/persistence/memory"
 	backupHttp "github.com/rrbarrero/justbackup/internal/backup/interfaces/http"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
-func TestRestoreRoute(t *testing.T) {
+func newRestoreRouter(t *testing.T, publisher *MockTaskPublisher, queryBus *MockWorkerQueryBus) (*http.ServeMux, *entities.Backup) {
 	backupRepo := memory.NewBackupRepositoryMemoryEmpty()
 	backupErrorRepo := memory.NewBackupErrorRepositoryMemory()
 	hostRepo := memory.NewHostRepositoryMemory()
-	publisher := new(MockTaskPublisher)
 	resultStore := new(MockResultStore)
 
 	backupAssembler := assembler.NewBackupAssembler()
 	hostService := application.NewHostService(hostRepo, backupRepo)
 
-	lifecycleService := application.NewBackupLifecycleService(backupRepo, hostService, publisher, backupAssembler)
+	lifecycleService := application.NewBackupLifecycleService(backupRepo, memory.NewFileCatalogRepositoryMemory(), hostService, publisher, backupAssembler, nil)
 	queryService := application.NewBackupQueryService(backupRepo, hostService, backupErrorRepo, backupAssembler)
-	searchService := application.NewBackupSearchService(backupRepo, hostService, nil, backupAssembler)
-	restoreService := application.NewBackupRestoreService(backupRepo, hostService, publisher)
+	searchService := application.NewBackupSearchService(backupRepo, memory.NewFileCatalogRepositoryMemory(), hostService, nil, backupAssembler, nil)
+	restoreService := application.NewBackupRestoreService(backupRepo, hostService, publisher, queryBus, nil, nil, nil, nil)
 	taskService := application.NewBackupTaskService(publisher, resultStore)
 	hookService := application.NewBackupHookService(backupRepo, backupAssembler)
 
@@ -42,6 +44,7 @@ func TestRestoreRoute(t *testing.T) {
 		restoreService,
 		taskService,
 		hookService,
+		nil,
 	)
 
 	mux := http.NewServeMux()
@@ -54,11 +57,19 @@ func TestRestoreRoute(t *testing.T) {
 	assert.NoError(t, err)
 	_ = backupRepo.Save(context.TODO(), backup)
 
+	return mux, backup
+}
+
+func TestRestoreRoute(t *testing.T) {
+	publisher := new(MockTaskPublisher)
+	mux, backup := newRestoreRouter(t, publisher, nil)
+
 	reqBody := dto.RestoreRequest{
-		Path:         "/some/path",
-		RestoreType:  "local",
-		RestoreAddr:  "1.2.3.4:5678",
-		RestoreToken: "secret",
+		Path:               "/some/path",
+		RestoreType:        "local",
+		RestoreAddr:        "1.2.3.4:5678",
+		RestoreToken:       "secret",
+		RestoreFingerprint: "ab12",
 	}
 	body, _ := json.Marshal(reqBody)
 
@@ -66,10 +77,34 @@ func TestRestoreRoute(t *testing.T) {
 	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(body))
 
 	expectedPath := "/mnt/backups/path/dest/some/path"
-	publisher.On("PublishRestoreTask", mock.Anything, mock.Anything, expectedPath, reqBody.RestoreAddr, reqBody.RestoreToken).Return("task-123", nil)
+	publisher.On("PublishRestoreTask", mock.Anything, mock.Anything, expectedPath, "", mock.Anything, mock.Anything, reqBody.RestoreAddr, reqBody.RestoreToken, reqBody.RestoreFingerprint).Return("task-123", nil)
 
 	rr := httptest.NewRecorder()
 	mux.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusAccepted, rr.Code, "Route should match and return 202")
 }
+
+func TestRestoreRouteDryRun(t *testing.T) {
+	queryBus := new(MockWorkerQueryBus)
+	mux, backup := newRestoreRouter(t, new(MockTaskPublisher), queryBus)
+
+	options := valueobjects.RestoreOptions{Conflict: valueobjects.ConflictNewer, Delete: true, DryRun: true}
+	queryBus.On("PreviewRemoteRestore", mock.Anything, mock.Anything, "/mnt/backups/path/dest/etc", "", mock.Anything, mock.Anything, mock.Anything, "/srv/restore", options).
+		Return(workerDto.RestorePreviewResult{Changes: []string{"*deleting   etc/stale.conf"}}, nil)
+
+	url := "/backups/" + backup.ID().String() + "/restore"
+	body := `{"path":"etc","restore_type":"remote","target_path":"/srv/restore","conflict":"newer","delete":true,"dry_run":true}`
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("POST", url, strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var preview dto.RestorePreviewResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &preview))
+	assert.Equal(t, []string{"*deleting   etc/stale.conf"}, preview.Changes)
+
+	body = `{"path":"etc","restore_type":"remote","target_path":"/srv/restore","conflict":"merge"}`
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("POST", url, strings.NewReader(body)))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -0,0 +1,128 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+)
+
+// defaultHistoryDays is how far back size history goes without ?days.
+const defaultHistoryDays = 30
+
+type SizeHistoryHandler struct {
+	service *application.SizeHistoryService
+}
+
+func NewSizeHistoryHandler(service *application.SizeHistoryService) *SizeHistoryHandler {
+	return &SizeHistoryHandler{
+		service: service,
+	}
+}
+
+func (h *SizeHistoryHandler) RegisterRoutes(mux *http.ServeMux, middleware func(http.HandlerFunc) http.HandlerFunc) {
+	mux.HandleFunc("GET /backups/{id}/size-history", middleware(h.BackupHistory))
+	mux.HandleFunc("GET /hosts/{id}/size-history", middleware(h.HostHistory))
+	mux.HandleFunc("GET /capacity/forecast", middleware(h.Forecast))
+}
+
+// @Summary Get the size history of a backup
+// @Description Get the space usage the backup reported at the end of each run over the last days, and the space it took on disk at the end of each day
+// @Tags size-history
+// @Produce  json
+// @Param   id     path    string     true  "Backup ID"
+// @Param   days   query   int        false "Days of history (default 30)"
+// @Success 200 {object} dto.SizeHistoryResponse
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Backup not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /backups/{id}/size-history [get]
+func (h *SizeHistoryHandler) BackupHistory(w http.ResponseWriter, r *http.Request) {
+	days, ok := historyDays(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.BackupHistory(r.Context(), r.PathValue("id"), days)
+	if err != nil {
+		http.Error(w, err.Error(), sizeHistoryErrorStatus(err))
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusOK, resp)
+}
+
+// @Summary Get the size history of a host
+// @Description Get the space usage the backups of a host reported at the end of each run over the last days, and the space they took on disk together at the end of each day
+// @Tags size-history
+// @Produce  json
+// @Param   id     path    string     true  "Host ID"
+// @Param   days   query   int        false "Days of history (default 30)"
+// @Success 200 {object} dto.SizeHistoryResponse
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Host not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /hosts/{id}/size-history [get]
+func (h *SizeHistoryHandler) HostHistory(w http.ResponseWriter, r *http.Request) {
+	days, ok := historyDays(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.HostHistory(r.Context(), r.PathValue("id"), days)
+	if err != nil {
+		http.Error(w, err.Error(), sizeHistoryErrorStatus(err))
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusOK, resp)
+}
+
+// @Summary Forecast backup capacity
+// @Description Project when the default backup root and every storage pool holding backups fill, from the growth of their backups over the last 90 days and the free space the worker reports. The seasonal model repeats the growth of each day of the week and needs two weeks of history, falling back to the linear model before that
+// @Tags size-history
+// @Produce  json
+// @Param   model  query   string     false "Growth model: linear (default) or seasonal"
+// @Success 200 {object} dto.CapacityForecastResponse
+// @Failure 400 {string} string "Invalid growth model"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /capacity/forecast [get]
+func (h *SizeHistoryHandler) Forecast(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.Forecast(r.Context(), r.URL.Query().Get("model"))
+	if err != nil {
+		http.Error(w, err.Error(), sizeHistoryErrorStatus(err))
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusOK, resp)
+}
+
+func historyDays(w http.ResponseWriter, r *http.Request) (int, bool) {
+	days, err := queryInt(r.URL.Query().Get("days"))
+	if err != nil {
+		http.Error(w, "Invalid days", http.StatusBadRequest)
+		return 0, false
+	}
+	if days == 0 {
+		days = defaultHistoryDays
+	}
+	return days, true
+}
+
+func sizeHistoryErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, shared.ErrInvalidID),
+		errors.Is(err, valueobjects.ErrInvalidGrowthModel),
+		errors.Is(err, valueobjects.ErrInvalidHistoryDays):
+		return http.StatusBadRequest
+	}
+	return snapshotErrorStatus(err)
+}
@@ -0,0 +1,123 @@
+package http
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application"
+)
+
+type SnapshotHandler struct {
+	service *application.BackupSnapshotService
+}
+
+func NewSnapshotHandler(service *application.BackupSnapshotService) *SnapshotHandler {
+	return &SnapshotHandler{
+		service: service,
+	}
+}
+
+func (h *SnapshotHandler) RegisterRoutes(mux *http.ServeMux, middleware func(http.HandlerFunc) http.HandlerFunc) {
+	mux.HandleFunc("GET /backups/{id}/snapshots", middleware(h.List))
+	mux.HandleFunc("GET /backups/{id}/versions", middleware(h.Versions))
+	mux.HandleFunc("GET /backups/{id}/diff", middleware(h.Diff))
+}
+
+// @Summary List snapshots
+// @Description List the snapshots of an incremental backup, oldest first, with their size, file count and pinned state. Encrypted snapshots report the archive size and no file count
+// @Tags backups
+// @Accept  json
+// @Produce  json
+// @Param   id     path    string     true  "Backup ID"
+// @Success 200 {array} dto.SnapshotResponse
+// @Failure 400 {string} string "Backup is not incremental"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Backup not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /backups/{id}/snapshots [get]
+func (h *SnapshotHandler) List(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	snapshots, err := h.service.ListSnapshots(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), snapshotErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(snapshots); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// @Summary List file versions
+// @Description List the distinct versions of a file across the snapshots of an incremental backup, oldest first. Copies hard-linked between snapshots are reported once. Snapshots stored only as encrypted archives cannot be inspected and are listed separately
+// @Tags backups
+// @Accept  json
+// @Produce  json
+// @Param   id     path    string     true  "Backup ID"
+// @Param   path   query   string     true  "Path of the file inside the backup"
+// @Success 200 {object} dto.FileVersionsResponse
+// @Failure 400 {string} string "Missing path or backup is not incremental"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Backup not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /backups/{id}/versions [get]
+func (h *SnapshotHandler) Versions(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	path := r.URL.Query().Get("path")
+	if strings.Trim(path, "/") == "" {
+		http.Error(w, "path is required", http.StatusBadRequest)
+		return
+	}
+
+	versions, err := h.service.ListVersions(r.Context(), id, path)
+	if err != nil {
+		http.Error(w, err.Error(), snapshotErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(versions); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// @Summary Diff two snapshots
+// @Description Compare two snapshots of an incremental backup and list the files added, removed and modified between them with their size deltas. Both selectors accept a snapshot name, "latest" or a point in time
+// @Tags backups
+// @Accept  json
+// @Produce  json
+// @Param   id     path    string     true   "Backup ID"
+// @Param   from   query   string     true   "Older snapshot"
+// @Param   to     query   string     false  "Newer snapshot (defaults to latest)"
+// @Success 200 {object} dto.SnapshotDiffResponse
+// @Failure 400 {string} string "Invalid selector, encrypted snapshot or backup is not incremental"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Backup or snapshot not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /backups/{id}/diff [get]
+func (h *SnapshotHandler) Diff(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	from := r.URL.Query().Get("from")
+	if from == "" {
+		http.Error(w, "from is required", http.StatusBadRequest)
+		return
+	}
+
+	diff, err := h.service.Diff(r.Context(), id, from, r.URL.Query().Get("to"))
+	if err != nil {
+		http.Error(w, err.Error(), snapshotErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(diff); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
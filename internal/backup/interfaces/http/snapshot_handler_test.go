@@ -0,0 +1,178 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application"
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/backup/infrastructure/persistence/memory"
+	backupHttp "github.com/rrbarrero/justbackup/internal/backup/interfaces/http"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSnapshotHandler_List(t *testing.T) {
+	backupRepo := memory.NewBackupRepositoryMemoryEmpty()
+	hostRepo := memory.NewHostRepositoryMemory()
+	pinRepo := memory.NewSnapshotPinRepositoryMemory()
+	queryBus := new(MockWorkerQueryBus)
+	hostService := application.NewHostService(hostRepo, backupRepo)
+	handler := backupHttp.NewSnapshotHandler(application.NewBackupSnapshotService(backupRepo, hostService, pinRepo, queryBus, nil, nil))
+
+	host := entities.NewHost("Test Host", "test.example.com", "user", 22, "path", false)
+	_ = hostRepo.Save(context.Background(), host)
+
+	incremental, err := entities.NewBackup(host.ID(), "/source", "/dest", entities.NewBackupSchedule("0 0 * * *"), nil, true, 1, false)
+	assert.NoError(t, err)
+	_ = backupRepo.Save(context.Background(), incremental)
+
+	mirror, err := entities.NewBackup(host.ID(), "/source", "/mirror", entities.NewBackupSchedule("0 0 * * *"), nil, false, 1, false)
+	assert.NoError(t, err)
+	_ = backupRepo.Save(context.Background(), mirror)
+
+	files := int64(3)
+	queryBus.On("ListSnapshots", mock.Anything, "/mnt/backups/path/dest").Return(workerDto.ListSnapshotsResult{
+		Snapshots: []workerDto.SnapshotInfo{
+			{Name: "2024-01-01_00-00-00", Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Size: 100, FileCount: &files, Latest: true},
+		},
+	}, nil)
+	_ = pinRepo.Save(context.Background(), &entities.SnapshotPin{BackupID: incremental.ID(), Snapshot: "2024-01-01_00-00-00"})
+
+	list := func(id string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/backups/"+id+"/snapshots", nil)
+		req.SetPathValue("id", id)
+		rr := httptest.NewRecorder()
+		handler.List(rr, req)
+		return rr
+	}
+
+	t.Run("success", func(t *testing.T) {
+		rr := list(incremental.ID().String())
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp []dto.SnapshotResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Len(t, resp, 1)
+		assert.Equal(t, "2024-01-01_00-00-00", resp[0].Name)
+		assert.Equal(t, int64(3), *resp[0].FileCount)
+		assert.True(t, resp[0].Pinned)
+		assert.True(t, resp[0].Latest)
+	})
+
+	t.Run("plain mirror", func(t *testing.T) {
+		rr := list(mirror.ID().String())
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		rr := list(valueobjects.NewBackupID().String())
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestSnapshotHandler_Versions(t *testing.T) {
+	backupRepo := memory.NewBackupRepositoryMemoryEmpty()
+	hostRepo := memory.NewHostRepositoryMemory()
+	queryBus := new(MockWorkerQueryBus)
+	hostService := application.NewHostService(hostRepo, backupRepo)
+	handler := backupHttp.NewSnapshotHandler(application.NewBackupSnapshotService(backupRepo, hostService, memory.NewSnapshotPinRepositoryMemory(), queryBus, nil, nil))
+
+	host := entities.NewHost("Test Host", "test.example.com", "user", 22, "path", false)
+	_ = hostRepo.Save(context.Background(), host)
+	backup, err := entities.NewBackup(host.ID(), "/source", "/dest", entities.NewBackupSchedule("0 0 * * *"), nil, true, 1, false)
+	assert.NoError(t, err)
+	_ = backupRepo.Save(context.Background(), backup)
+
+	queryBus.On("FileVersions", mock.Anything, "/mnt/backups/path/dest", "etc/hosts").Return(workerDto.FileVersionsResult{
+		Versions: []workerDto.FileVersion{{Hash: "abc", Size: 5, Snapshots: []string{"2024-01-01_00-00-00"}}},
+	}, nil)
+
+	versions := func(query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/backups/"+backup.ID().String()+"/versions"+query, nil)
+		req.SetPathValue("id", backup.ID().String())
+		rr := httptest.NewRecorder()
+		handler.Versions(rr, req)
+		return rr
+	}
+
+	t.Run("success", func(t *testing.T) {
+		rr := versions("?path=etc/hosts")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp dto.FileVersionsResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, "etc/hosts", resp.Path)
+		assert.Len(t, resp.Versions, 1)
+		assert.Equal(t, "abc", resp.Versions[0].Hash)
+	})
+
+	t.Run("missing path", func(t *testing.T) {
+		rr := versions("")
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestSnapshotHandler_Diff(t *testing.T) {
+	backupRepo := memory.NewBackupRepositoryMemoryEmpty()
+	hostRepo := memory.NewHostRepositoryMemory()
+	queryBus := new(MockWorkerQueryBus)
+	hostService := application.NewHostService(hostRepo, backupRepo)
+	handler := backupHttp.NewSnapshotHandler(application.NewBackupSnapshotService(backupRepo, hostService, memory.NewSnapshotPinRepositoryMemory(), queryBus, nil, nil))
+
+	host := entities.NewHost("Test Host", "test.example.com", "user", 22, "path", false)
+	_ = hostRepo.Save(context.Background(), host)
+	backup, err := entities.NewBackup(host.ID(), "/source", "/dest", entities.NewBackupSchedule("0 0 * * *"), nil, true, 1, false)
+	assert.NoError(t, err)
+	_ = backupRepo.Save(context.Background(), backup)
+
+	queryBus.On("ListFiles", mock.Anything, "/mnt/backups/path/dest").Return(workerDto.ListFilesResult{Files: []workerDto.FileListItem{
+		{Name: "2024-01-01_00-00-00", IsDir: true},
+		{Name: "2024-01-02_00-00-00", IsDir: true},
+	}}, nil)
+	queryBus.On("DiffSnapshots", mock.Anything, "/mnt/backups/path/dest", "2024-01-01_00-00-00", "2024-01-02_00-00-00").Return(workerDto.SnapshotDiffResult{
+		Removed:   []workerDto.DiffEntry{{Path: "old.log", Size: 5, SizeDelta: -5}},
+		SizeDelta: -5,
+	}, nil)
+
+	diff := func(query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/backups/"+backup.ID().String()+"/diff"+query, nil)
+		req.SetPathValue("id", backup.ID().String())
+		rr := httptest.NewRecorder()
+		handler.Diff(rr, req)
+		return rr
+	}
+
+	t.Run("success", func(t *testing.T) {
+		rr := diff("?from=2024-01-01_00-00-00")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp dto.SnapshotDiffResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, "2024-01-02_00-00-00", resp.To)
+		assert.Equal(t, "old.log", resp.Removed[0].Path)
+		assert.Equal(t, int64(-5), resp.SizeDelta)
+	})
+
+	t.Run("missing from", func(t *testing.T) {
+		rr := diff("")
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("unknown snapshot", func(t *testing.T) {
+		rr := diff("?from=2023-01-01")
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -0,0 +1,226 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application"
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+)
+
+type StoragePoolHandler struct {
+	service *application.StoragePoolService
+}
+
+func NewStoragePoolHandler(service *application.StoragePoolService) *StoragePoolHandler {
+	return &StoragePoolHandler{
+		service: service,
+	}
+}
+
+func (h *StoragePoolHandler) RegisterRoutes(mux *http.ServeMux, middleware func(http.HandlerFunc) http.HandlerFunc) {
+	mux.HandleFunc("GET /storage-pools", middleware(h.List))
+	mux.HandleFunc("POST /storage-pools", middleware(h.Create))
+	mux.HandleFunc("GET /storage-pools/{id}", middleware(h.Get))
+	mux.HandleFunc("PUT /storage-pools/{id}", middleware(h.Update))
+	mux.HandleFunc("DELETE /storage-pools/{id}", middleware(h.Delete))
+	mux.HandleFunc("PUT /hosts/{id}/storage-pool", middleware(h.AssignHost))
+	mux.HandleFunc("POST /backups/{id}/migrate", middleware(h.Migrate))
+}
+
+// @Summary List storage pools
+// @Description List the storage pools the worker keeps backups in, with how many backups and hosts use each and how full each is
+// @Tags storage-pools
+// @Produce  json
+// @Success 200 {array} dto.StoragePoolResponse
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /storage-pools [get]
+func (h *StoragePoolHandler) List(w http.ResponseWriter, r *http.Request) {
+	pools, err := h.service.ListPools(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), storagePoolErrorStatus(err))
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusOK, pools)
+}
+
+// @Summary Create a storage pool
+// @Description Create a storage pool, a directory of the worker with its own capacity and labels that new backups can be placed in
+// @Tags storage-pools
+// @Accept  json
+// @Produce  json
+// @Param   pool body    dto.StoragePoolRequest  true  "Storage pool"
+// @Success 201 {object} dto.StoragePoolResponse
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /storage-pools [post]
+func (h *StoragePoolHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req dto.StoragePoolRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	pool, err := h.service.CreatePool(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), storagePoolErrorStatus(err))
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusCreated, pool)
+}
+
+// @Summary Get a storage pool
+// @Tags storage-pools
+// @Produce  json
+// @Param   id     path    string     true  "Storage pool ID"
+// @Success 200 {object} dto.StoragePoolResponse
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Storage pool not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /storage-pools/{id} [get]
+func (h *StoragePoolHandler) Get(w http.ResponseWriter, r *http.Request) {
+	pool, err := h.service.GetPool(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), storagePoolErrorStatus(err))
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusOK, pool)
+}
+
+// @Summary Update a storage pool
+// @Description Replace the definition of a storage pool. Its root cannot change while backups are in it
+// @Tags storage-pools
+// @Accept  json
+// @Produce  json
+// @Param   id     path    string     true  "Storage pool ID"
+// @Param   pool body    dto.StoragePoolRequest  true  "Storage pool"
+// @Success 200 {object} dto.StoragePoolResponse
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Storage pool not found"
+// @Failure 409 {string} string "Storage pool in use"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /storage-pools/{id} [put]
+func (h *StoragePoolHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req dto.StoragePoolRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	pool, err := h.service.UpdatePool(r.Context(), r.PathValue("id"), req)
+	if err != nil {
+		http.Error(w, err.Error(), storagePoolErrorStatus(err))
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusOK, pool)
+}
+
+// @Summary Delete a storage pool
+// @Description Delete a storage pool no backup or host is assigned to. Its directory is left in place
+// @Tags storage-pools
+// @Param   id     path    string     true  "Storage pool ID"
+// @Success 204 "No Content"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Storage pool not found"
+// @Failure 409 {string} string "Storage pool in use"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /storage-pools/{id} [delete]
+func (h *StoragePoolHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeletePool(r.Context(), r.PathValue("id")); err != nil {
+		http.Error(w, err.Error(), storagePoolErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Assign a host to a storage pool
+// @Description Make new backups of a host go in a storage pool, or be placed by free space when the pool ID is empty. Existing backups stay where they are
+// @Tags storage-pools
+// @Accept  json
+// @Produce  json
+// @Param   id     path    string     true  "Host ID"
+// @Param   pool body    dto.AssignStoragePoolRequest  true  "Storage pool"
+// @Success 200 {object} dto.HostResponse
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Host or storage pool not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /hosts/{id}/storage-pool [put]
+func (h *StoragePoolHandler) AssignHost(w http.ResponseWriter, r *http.Request) {
+	var req dto.AssignStoragePoolRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	host, err := h.service.AssignHost(r.Context(), r.PathValue("id"), req)
+	if err != nil {
+		http.Error(w, err.Error(), storagePoolErrorStatus(err))
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusOK, host)
+}
+
+// @Summary Migrate a backup to another storage pool
+// @Description Move the data of a backup to a storage pool, or to the default backup root when the pool ID is empty. The backup switches to the pool and its file catalog is rebuilt once the worker is done
+// @Tags storage-pools
+// @Accept  json
+// @Produce  json
+// @Param   id     path    string     true  "Backup ID"
+// @Param   pool body    dto.AssignStoragePoolRequest  true  "Storage pool"
+// @Success 202 {object} dto.MigrateStorageResponse
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Backup or storage pool not found"
+// @Failure 409 {string} string "Backup already in the pool or running"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /backups/{id}/migrate [post]
+func (h *StoragePoolHandler) Migrate(w http.ResponseWriter, r *http.Request) {
+	var req dto.AssignStoragePoolRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.service.Migrate(r.Context(), r.PathValue("id"), req)
+	if err != nil {
+		http.Error(w, err.Error(), storagePoolErrorStatus(err))
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusAccepted, resp)
+}
+
+func storagePoolErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, valueobjects.ErrStoragePoolInUse),
+		errors.Is(err, valueobjects.ErrSameStoragePool),
+		errors.Is(err, valueobjects.ErrBackupRunning),
+		errors.Is(err, valueobjects.ErrBackupMigrating),
+		errors.Is(err, valueobjects.ErrMigrationTarget):
+		return http.StatusConflict
+	case errors.Is(err, shared.ErrInvalidID),
+		errors.Is(err, valueobjects.ErrInvalidStoragePool):
+		return http.StatusBadRequest
+	}
+	return snapshotErrorStatus(err)
+}
@@ -0,0 +1,148 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application"
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+)
+
+type StoredDataHandler struct {
+	service *application.StoredDataService
+}
+
+func NewStoredDataHandler(service *application.StoredDataService) *StoredDataHandler {
+	return &StoredDataHandler{
+		service: service,
+	}
+}
+
+func (h *StoredDataHandler) RegisterRoutes(mux *http.ServeMux, middleware func(http.HandlerFunc) http.HandlerFunc) {
+	mux.HandleFunc("GET /orphans", middleware(h.Scan))
+	mux.HandleFunc("POST /orphans/reclaim", middleware(h.Reclaim))
+	mux.HandleFunc("POST /orphans/adopt", middleware(h.Adopt))
+}
+
+// @Summary Scan for orphaned data
+// @Description List what lies under the storage roots that no backup references, such as the data of deleted backups or of a destination that changed, with its size on disk
+// @Tags storage
+// @Produce  json
+// @Success 200 {object} dto.OrphanedDataResponse
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /orphans [get]
+func (h *StoredDataHandler) Scan(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.Scan(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), storedDataErrorStatus(err))
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusOK, resp)
+}
+
+// @Summary Reclaim orphaned data
+// @Description Delete an orphaned entry under a storage root for good. Paths a backup keeps its data in are refused
+// @Tags storage
+// @Accept  json
+// @Produce  json
+// @Param   request  body    dto.ReclaimOrphanRequest  true  "Orphaned path"
+// @Success 202 {object} dto.ReclaimOrphanResponse
+// @Failure 400 {string} string "Not orphaned data"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /orphans/reclaim [post]
+func (h *StoredDataHandler) Reclaim(w http.ResponseWriter, r *http.Request) {
+	var req dto.ReclaimOrphanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.service.Reclaim(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), storedDataErrorStatus(err))
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusAccepted, resp)
+}
+
+// @Summary Adopt orphaned data
+// @Description Point a backup at an orphaned entry on the storage of its host, such as the directory it kept its data in before its destination changed
+// @Tags storage
+// @Accept  json
+// @Param   request  body    dto.AdoptOrphanRequest  true  "Orphaned path and backup"
+// @Success 204 "No Content"
+// @Failure 400 {string} string "Not orphaned data or not adoptable by the backup"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Backup not found"
+// @Failure 409 {string} string "Backup is running"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /orphans/adopt [post]
+func (h *StoredDataHandler) Adopt(w http.ResponseWriter, r *http.Request) {
+	var req dto.AdoptOrphanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Adopt(r.Context(), req); err != nil {
+		http.Error(w, err.Error(), storedDataErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// dataDeleter is StoredDataService.DeleteBackup or DeleteHost.
+type dataDeleter func(service *application.StoredDataService, ctx context.Context, id string) (*dto.DeleteDataResponse, error)
+
+// withData reports whether a delete request asks for the stored data to go
+// too.
+func withData(r *http.Request) bool {
+	enabled, _ := strconv.ParseBool(r.URL.Query().Get("with_data"))
+	return enabled
+}
+
+// deleteWithData serves a delete of a backup or host that also removes its
+// stored data, answering with the worker tasks doing so.
+func deleteWithData(w http.ResponseWriter, r *http.Request, service *application.StoredDataService, del dataDeleter) {
+	if service == nil {
+		http.Error(w, "Deleting stored data is not available", http.StatusNotImplemented)
+		return
+	}
+
+	resp, err := del(service, r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), storedDataErrorStatus(err))
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusAccepted, resp)
+}
+
+func storedDataErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, entities.ErrLegalHold),
+		errors.Is(err, valueobjects.ErrBackupRunning),
+		errors.Is(err, valueobjects.ErrBackupMigrating),
+		errors.Is(err, valueobjects.ErrSharedData):
+		return http.StatusConflict
+	case errors.Is(err, shared.ErrInvalidID),
+		errors.Is(err, valueobjects.ErrNotOrphaned),
+		errors.Is(err, valueobjects.ErrInvalidAdoption):
+		return http.StatusBadRequest
+	}
+	return snapshotErrorStatus(err)
+}
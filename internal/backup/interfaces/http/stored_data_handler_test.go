@@ -0,0 +1,115 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application"
+	"github.com/rrbarrero/justbackup/internal/backup/application/assembler"
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/backup/infrastructure/persistence/memory"
+	backupHttp "github.com/rrbarrero/justbackup/internal/backup/interfaces/http"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestStoredDataHandler(t *testing.T) {
+	backupRepo := memory.NewBackupRepositoryMemoryEmpty()
+	hostRepo := memory.NewHostRepositoryMemory()
+	publisher := new(MockTaskPublisher)
+	queryBus := new(MockWorkerQueryBus)
+	hostService := application.NewHostService(hostRepo, backupRepo)
+	lifecycle := application.NewBackupLifecycleService(backupRepo, memory.NewFileCatalogRepositoryMemory(), hostService, publisher, assembler.NewBackupAssembler(), nil)
+	service := application.NewStoredDataService(backupRepo, hostRepo, publisher, queryBus, nil, nil, lifecycle, hostService, nil)
+	handler := backupHttp.NewStoredDataHandler(service)
+	backupHandler := backupHttp.NewBackupHandler(lifecycle, nil, nil, nil, nil, nil, service)
+
+	host := entities.NewHost("Test Host", "test.example.com", "user", 22, "path", false)
+	_ = hostRepo.Save(context.Background(), host)
+	kept, err := entities.NewBackup(host.ID(), "/source", "/kept", entities.NewBackupSchedule("0 0 * * *"), nil, true, 1, false)
+	assert.NoError(t, err)
+	_ = backupRepo.Save(context.Background(), kept)
+	deleted, err := entities.NewBackup(host.ID(), "/source", "/dest", entities.NewBackupSchedule("0 0 * * *"), nil, true, 1, false)
+	assert.NoError(t, err)
+	_ = backupRepo.Save(context.Background(), deleted)
+
+	t.Run("delete backup with data", func(t *testing.T) {
+		id := deleted.ID().String()
+		publisher.On("PublishDeleteDataTask", mock.Anything, id, "/mnt/backups", "/mnt/backups/path/dest", []valueobjects.ColdLocation(nil)).Return("task-1", nil).Once()
+		req, _ := http.NewRequest("DELETE", "/backups/"+id+"?with_data=true", nil)
+		req.SetPathValue("id", id)
+		rr := httptest.NewRecorder()
+		backupHandler.Delete(rr, req)
+
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		var resp dto.DeleteDataResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, []string{"task-1"}, resp.TaskIDs)
+		publisher.AssertExpectations(t)
+	})
+
+	t.Run("delete host with data unavailable", func(t *testing.T) {
+		req, _ := http.NewRequest("DELETE", "/hosts/"+host.ID().String()+"?with_data=1", nil)
+		req.SetPathValue("id", host.ID().String())
+		rr := httptest.NewRecorder()
+		backupHttp.NewHostHandler(hostService, nil).Delete(rr, req)
+
+		assert.Equal(t, http.StatusNotImplemented, rr.Code)
+		_, err := hostRepo.Get(context.Background(), host.ID())
+		assert.NoError(t, err, "the host is kept")
+	})
+
+	t.Run("scan", func(t *testing.T) {
+		queryBus.On("ScanOrphans", mock.Anything, []string{"/mnt/backups"}, []string{"/mnt/backups/path/kept"}).Return(workerDto.OrphanScanResult{
+			Orphans: []workerDto.OrphanEntry{{Root: "/mnt/backups", Path: "/mnt/backups/path/dest", IsDir: true, Size: 42}},
+		}, nil).Once()
+		req, _ := http.NewRequest("GET", "/orphans", nil)
+		rr := httptest.NewRecorder()
+		handler.Scan(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp dto.OrphanedDataResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Len(t, resp.Orphans, 1)
+		assert.Equal(t, int64(42), resp.TotalSize)
+	})
+
+	post := func(route string, serve http.HandlerFunc, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", route, bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		serve(rr, req)
+		return rr
+	}
+
+	t.Run("reclaim referenced", func(t *testing.T) {
+		rr := post("/orphans/reclaim", handler.Reclaim, `{"path":"/mnt/backups/path/kept"}`)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("reclaim", func(t *testing.T) {
+		publisher.On("PublishDeleteDataTask", mock.Anything, "", "/mnt/backups", "/mnt/backups/path/dest", []valueobjects.ColdLocation(nil)).Return("task-2", nil).Once()
+		rr := post("/orphans/reclaim", handler.Reclaim, `{"path":"/mnt/backups/path/dest"}`)
+
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		var resp dto.ReclaimOrphanResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, "task-2", resp.TaskID)
+	})
+
+	t.Run("adopt", func(t *testing.T) {
+		rr := post("/orphans/adopt", handler.Adopt, `{"path":"/mnt/backups/path/dest","backup_id":"`+kept.ID().String()+`"}`)
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		stored, err := backupRepo.FindByID(context.Background(), kept.ID())
+		assert.NoError(t, err)
+		assert.Equal(t, "dest", stored.Destination())
+	})
+}
@@ -0,0 +1,130 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application"
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+)
+
+type TieringHandler struct {
+	service *application.TieringService
+}
+
+func NewTieringHandler(service *application.TieringService) *TieringHandler {
+	return &TieringHandler{
+		service: service,
+	}
+}
+
+func (h *TieringHandler) RegisterRoutes(mux *http.ServeMux, middleware func(http.HandlerFunc) http.HandlerFunc) {
+	mux.HandleFunc("GET /backups/{id}/tiering", middleware(h.Get))
+	mux.HandleFunc("PUT /backups/{id}/tiering", middleware(h.Set))
+	mux.HandleFunc("DELETE /backups/{id}/tiering", middleware(h.Delete))
+	mux.HandleFunc("POST /backups/{id}/tier", middleware(h.Tier))
+}
+
+// @Summary Get the tiering of a backup
+// @Description Get the tiering policy of a backup, null if it has none, and the snapshots moved off its storage that can still be restored
+// @Tags tiering
+// @Produce  json
+// @Param   id     path    string     true  "Backup ID"
+// @Success 200 {object} dto.TieringResponse
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Backup not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /backups/{id}/tiering [get]
+func (h *TieringHandler) Get(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.GetTiering(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), tieringErrorStatus(err))
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusOK, resp)
+}
+
+// @Summary Set the tiering policy of a backup
+// @Description Move the snapshots of an incremental backup older than after_days into compressed archives in a cold storage pool, optionally encrypted, or off its storage once a replica target holds them. The latest snapshot always stays
+// @Tags tiering
+// @Accept  json
+// @Produce  json
+// @Param   id     path    string     true  "Backup ID"
+// @Param   policy body    dto.TieringPolicyRequest  true  "Tiering policy"
+// @Success 200 {object} dto.TieringResponse
+// @Failure 400 {string} string "Invalid request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Backup, storage pool or replica target not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /backups/{id}/tiering [put]
+func (h *TieringHandler) Set(w http.ResponseWriter, r *http.Request) {
+	var req dto.TieringPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.service.SetPolicy(r.Context(), r.PathValue("id"), req)
+	if err != nil {
+		http.Error(w, err.Error(), tieringErrorStatus(err))
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusOK, resp)
+}
+
+// @Summary Delete the tiering policy of a backup
+// @Description Stop tiering a backup. Snapshots already moved stay where they are and can still be restored
+// @Tags tiering
+// @Param   id     path    string     true  "Backup ID"
+// @Success 204 "No Content"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Tiering policy not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /backups/{id}/tiering [delete]
+func (h *TieringHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeletePolicy(r.Context(), r.PathValue("id")); err != nil {
+		http.Error(w, err.Error(), tieringErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Tier a backup now
+// @Description Move the snapshots of a backup its tiering policy says are due now, instead of at the scheduled run
+// @Tags tiering
+// @Produce  json
+// @Param   id     path    string     true  "Backup ID"
+// @Success 202 {object} dto.TierResponse
+// @Failure 400 {string} string "Invalid tiering policy"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Backup or tiering policy not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Security BasicAuth
+// @Router /backups/{id}/tier [post]
+func (h *TieringHandler) Tier(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.Tier(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), tieringErrorStatus(err))
+		return
+	}
+
+	writeReplicationJSON(w, http.StatusAccepted, resp)
+}
+
+func tieringErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, shared.ErrInvalidID),
+		errors.Is(err, valueobjects.ErrInvalidTieringPolicy):
+		return http.StatusBadRequest
+	}
+	return snapshotErrorStatus(err)
+}
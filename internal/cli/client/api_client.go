@@ -11,8 +11,9 @@ import (
 )
 
 type Client struct {
-	config *config.Config
-	http   *http.Client
+	config   *config.Config
+	http     *http.Client
+	download *http.Client
 }
 
 func NewClient(cfg *config.Config) *Client {
@@ -25,6 +26,9 @@ func NewClient(cfg *config.Config) *Client {
 			Timeout:   35 * time.Second,
 			Transport: tr,
 		},
+		// Downloads have no overall timeout: the server answers once the
+		// worker has streamed the whole archive, and the body can be large.
+		download: &http.Client{Transport: tr},
 	}
 }
 
@@ -84,3 +88,89 @@ func (c *Client) Post(path string, body io.Reader) ([]byte, error) {
 
 	return resBody, nil
 }
+
+func (c *Client) Put(path string, body io.Reader) ([]byte, error) {
+	url := fmt.Sprintf("%s%s", c.config.URL, path)
+	req, err := http.NewRequest("PUT", url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	resBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("API error: %s - %s", resp.Status, string(resBody))
+	}
+
+	return resBody, nil
+}
+
+func (c *Client) Delete(path string) ([]byte, error) {
+	url := fmt.Sprintf("%s%s", c.config.URL, path)
+	req, err := http.NewRequest("DELETE", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	resBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("API error: %s - %s", resp.Status, string(resBody))
+	}
+
+	return resBody, nil
+}
+
+// Download requests path for streaming. With offset > 0 it asks for the rest
+// of the file from offset, sending etag as If-Range so the server sends the
+// whole file again if it changed. The caller closes the response body.
+func (c *Client) Download(path string, offset int64, etag string) (*http.Response, error) {
+	url := fmt.Sprintf("%s%s", c.config.URL, path)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", etag)
+	}
+
+	resp, err := c.download.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		defer func() { _ = resp.Body.Close() }()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API error: %s - %s", resp.Status, string(body))
+	}
+
+	return resp, nil
+}
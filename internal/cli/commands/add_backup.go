@@ -21,6 +21,8 @@ func AddBackupCommand() {
 	schedule := addCmd.String("schedule", "0 0 * * *", "Cron schedule (default: daily at midnight)")
 	excludes := addCmd.String("excludes", "", "Comma-separated list of exclude patterns")
 	incremental := addCmd.Bool("incremental", true, "Whether the backup is incremental")
+	pool := addCmd.String("pool", "", "ID of the storage pool (default: placed by free space)")
+	poolLabels := addCmd.String("pool-labels", "", "Comma-separated labels the storage pool must carry")
 
 	if len(os.Args) < 2 {
 		printAddBackupUsage()
@@ -43,6 +45,11 @@ func AddBackupCommand() {
 		excludeList = strings.Split(*excludes, ",")
 	}
 
+	var labelList []string
+	if *poolLabels != "" {
+		labelList = strings.Split(*poolLabels, ",")
+	}
+
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		fmt.Printf("Error loading config: %v\nRun 'justbackup config' to configure the CLI.\n", err)
@@ -52,12 +59,14 @@ func AddBackupCommand() {
 	apiClient := client.NewClient(cfg)
 
 	req := dto.CreateBackupRequest{
-		HostID:      *hostID,
-		Path:        *path,
-		Destination: *destination,
-		Schedule:    *schedule,
-		Excludes:    excludeList,
-		Incremental: *incremental,
+		HostID:        *hostID,
+		Path:          *path,
+		Destination:   *destination,
+		Schedule:      *schedule,
+		Excludes:      excludeList,
+		Incremental:   *incremental,
+		StoragePoolID: *pool,
+		PoolLabels:    labelList,
 	}
 
 	body, err := json.Marshal(req)
@@ -85,4 +94,6 @@ func printAddBackupUsage() {
 	fmt.Println("  --schedule <cron> Cron schedule expression (default: '0 0 * * *')")
 	fmt.Println("  --excludes <p1,p2> Comma-separated exclude patterns")
 	fmt.Println("  --incremental      Enable incremental backups (default: true)")
+	fmt.Println("  --pool <id>        Storage pool of the backup (default: placed by free space)")
+	fmt.Println("  --pool-labels <l1,l2> Labels the storage pool must carry")
 }
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
 
 	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
 	"github.com/rrbarrero/justbackup/internal/cli/client"
@@ -70,3 +72,37 @@ func (s *apiServiceImpl) RequestRestore(backupID string, req dto.RestoreRequest)
 
 	return resp.TaskID, nil
 }
+
+func (s *apiServiceImpl) PreviewRestore(backupID string, req dto.RestoreRequest) (*dto.RestorePreviewResponse, error) {
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("/backups/%s/restore", backupID)
+	data, err := s.client.Post(endpoint, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("error requesting restore preview: %w", err)
+	}
+
+	var preview dto.RestorePreviewResponse
+	if err := json.Unmarshal(data, &preview); err != nil {
+		return nil, fmt.Errorf("failed to parse restore preview: %w", err)
+	}
+
+	return &preview, nil
+}
+
+func (s *apiServiceImpl) DownloadBackup(backupID string, query url.Values, offset int64, etag string) (*Download, error) {
+	endpoint := fmt.Sprintf("/backups/%s/download?%s", backupID, query.Encode())
+	resp, err := s.client.Download(endpoint, offset, etag)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading backup: %w", err)
+	}
+
+	return &Download{
+		Body:    resp.Body,
+		Partial: resp.StatusCode == http.StatusPartialContent,
+		ETag:    resp.Header.Get("ETag"),
+	}, nil
+}
@@ -3,12 +3,14 @@ package commands
 import (
 	"bytes"
 	"io"
+	"net/url"
 	"os"
 	"strings"
 	"testing"
 
 	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
 	"github.com/rrbarrero/justbackup/internal/cli/config"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/restorestream"
 )
 
 func withTempHome(t *testing.T) string {
@@ -90,6 +92,8 @@ type MockAPIService struct {
 	GetSSHKeyFunc      func() (string, error)
 	RegisterHostFunc   func(req dto.CreateHostRequest) error
 	RequestRestoreFunc func(backupID string, req dto.RestoreRequest) (string, error)
+	PreviewRestoreFunc func(backupID string, req dto.RestoreRequest) (*dto.RestorePreviewResponse, error)
+	DownloadBackupFunc func(backupID string, query url.Values, offset int64, etag string) (*Download, error)
 }
 
 func (m *MockAPIService) GetSSHKey() (string, error) {
@@ -113,11 +117,25 @@ func (m *MockAPIService) RequestRestore(backupID string, req dto.RestoreRequest)
 	return "task-123", nil
 }
 
+func (m *MockAPIService) PreviewRestore(backupID string, req dto.RestoreRequest) (*dto.RestorePreviewResponse, error) {
+	if m.PreviewRestoreFunc != nil {
+		return m.PreviewRestoreFunc(backupID, req)
+	}
+	return &dto.RestorePreviewResponse{Changes: []string{}}, nil
+}
+
+func (m *MockAPIService) DownloadBackup(backupID string, query url.Values, offset int64, etag string) (*Download, error) {
+	if m.DownloadBackupFunc != nil {
+		return m.DownloadBackupFunc(backupID, query, offset, etag)
+	}
+	return &Download{Body: io.NopCloser(strings.NewReader("")), ETag: `"etag"`}, nil
+}
+
 type MockNetService struct {
 	GetLocalIPFunc        func() string
 	ListenTCPFunc         func() (string, int, io.Closer, error)
-	AcceptAndValidateFunc func(listener io.Closer, token string) (io.ReadCloser, error)
-	ExtractTarGzFunc      func(r io.Reader, dest string) error
+	AcceptAndValidateFunc func(listener io.Closer, session *restorestream.Session, offset int64) (io.ReadCloser, error)
+	ExtractTarGzFunc      func(r io.Reader, dest string, options TargetOptions) ([]string, error)
 }
 
 func (m *MockNetService) GetLocalIP() string {
@@ -134,18 +152,18 @@ func (m *MockNetService) ListenTCP() (string, int, io.Closer, error) {
 	return "127.0.0.1", 8080, &mockCloser{}, nil
 }
 
-func (m *MockNetService) AcceptAndValidate(listener io.Closer, token string) (io.ReadCloser, error) {
+func (m *MockNetService) AcceptAndValidate(listener io.Closer, session *restorestream.Session, offset int64) (io.ReadCloser, error) {
 	if m.AcceptAndValidateFunc != nil {
-		return m.AcceptAndValidateFunc(listener, token)
+		return m.AcceptAndValidateFunc(listener, session, offset)
 	}
 	return io.NopCloser(strings.NewReader("")), nil
 }
 
-func (m *MockNetService) ExtractTarGz(r io.Reader, dest string) error {
+func (m *MockNetService) ExtractTarGz(r io.Reader, dest string, options TargetOptions) ([]string, error) {
 	if m.ExtractTarGzFunc != nil {
-		return m.ExtractTarGzFunc(r, dest)
+		return m.ExtractTarGzFunc(r, dest, options)
 	}
-	return nil
+	return nil, nil
 }
 
 type mockCloser struct{}
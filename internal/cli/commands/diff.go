@@ -0,0 +1,145 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/rrbarrero/justbackup/internal/cli/client"
+	"github.com/rrbarrero/justbackup/internal/cli/config"
+)
+
+type DiffEntry struct {
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`
+	SizeDelta int64  `json:"size_delta"`
+}
+
+type SnapshotDiff struct {
+	From      string      `json:"from"`
+	To        string      `json:"to"`
+	Added     []DiffEntry `json:"added"`
+	Removed   []DiffEntry `json:"removed"`
+	Modified  []DiffEntry `json:"modified"`
+	SizeDelta int64       `json:"size_delta"`
+}
+
+// DiffCommand prints what changed between two snapshots of a backup, as a
+// tree or as JSON.
+func DiffCommand() {
+	diffCmd := flag.NewFlagSet("diff", flag.ExitOnError)
+	from := diffCmd.String("from", "", "Older snapshot: a snapshot name or a point in time (required)")
+	to := diffCmd.String("to", "", "Newer snapshot (default: latest)")
+	asJSON := diffCmd.Bool("json", false, "Print the raw JSON result")
+
+	if len(os.Args) < 3 {
+		fmt.Println("Usage: justbackup diff <backup-id> --from <snapshot|time> [--to <snapshot|time>] [--json]")
+		return
+	}
+
+	backupID := os.Args[2]
+	if err := diffCmd.Parse(os.Args[3:]); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *from == "" {
+		fmt.Println("Error: --from is required")
+		return
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return
+	}
+
+	query := url.Values{}
+	query.Set("from", *from)
+	if *to != "" {
+		query.Set("to", *to)
+	}
+
+	apiClient := client.NewClient(cfg)
+	data, err := apiClient.Get(fmt.Sprintf("/backups/%s/diff?%s", backupID, query.Encode()))
+	if err != nil {
+		fmt.Printf("Error fetching diff: %v\n", err)
+		return
+	}
+
+	if *asJSON {
+		var out bytes.Buffer
+		if err := json.Indent(&out, data, "", "  "); err != nil {
+			fmt.Printf("Error parsing response: %v\n", err)
+			return
+		}
+		fmt.Println(out.String())
+		return
+	}
+
+	var diff SnapshotDiff
+	if err := json.Unmarshal(data, &diff); err != nil {
+		fmt.Printf("Error parsing response: %v\n", err)
+		return
+	}
+
+	printDiffTree(diff)
+}
+
+type diffLine struct {
+	marker string
+	entry  DiffEntry
+}
+
+func printDiffTree(diff SnapshotDiff) {
+	fmt.Printf("Changes from %s to %s\n", diff.From, diff.To)
+
+	var lines []diffLine
+	for _, e := range diff.Added {
+		lines = append(lines, diffLine{"+", e})
+	}
+	for _, e := range diff.Removed {
+		lines = append(lines, diffLine{"-", e})
+	}
+	for _, e := range diff.Modified {
+		lines = append(lines, diffLine{"~", e})
+	}
+
+	if len(lines) == 0 {
+		fmt.Println("No changes.")
+		return
+	}
+
+	sort.Slice(lines, func(i, j int) bool { return lines[i].entry.Path < lines[j].entry.Path })
+
+	var printed []string
+	for _, line := range lines {
+		parts := strings.Split(line.entry.Path, "/")
+		dirs := parts[:len(parts)-1]
+
+		common := 0
+		for common < len(dirs) && common < len(printed) && dirs[common] == printed[common] {
+			common++
+		}
+		for i := common; i < len(dirs); i++ {
+			fmt.Printf("%s%s/\n", strings.Repeat("  ", i), dirs[i])
+		}
+		printed = dirs
+
+		fmt.Printf("%s%s %s (%s)\n", strings.Repeat("  ", len(dirs)), line.marker, parts[len(parts)-1], formatSizeDelta(line.entry.SizeDelta))
+	}
+
+	fmt.Printf("\n%d added, %d removed, %d modified (%s)\n", len(diff.Added), len(diff.Removed), len(diff.Modified), formatSizeDelta(diff.SizeDelta))
+}
+
+func formatSizeDelta(delta int64) string {
+	if delta < 0 {
+		return "-" + formatSize(-delta)
+	}
+	return "+" + formatSize(delta)
+}
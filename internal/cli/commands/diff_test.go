@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testDiffResponse = `{
+	"from":"2024-01-01_02-00-00",
+	"to":"2024-01-02_02-00-00",
+	"added":[{"path":"etc/nginx/sites/new.conf","size":2048,"size_delta":2048}],
+	"removed":[{"path":"old.log","size":5,"size_delta":-5}],
+	"modified":[{"path":"etc/hosts","size":20,"size_delta":-2}],
+	"size_delta":2041
+}`
+
+func TestDiffCommandPrintsTree(t *testing.T) {
+	withTempHome(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/backups/b1/diff" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("from"); got != "as of 2024-01-01" {
+			t.Fatalf("unexpected from: %q", got)
+		}
+		if r.URL.Query().Has("to") {
+			t.Fatalf("to should default to the server side latest")
+		}
+		_, _ = w.Write([]byte(testDiffResponse))
+	}))
+	defer server.Close()
+
+	writeTestConfig(t, server.URL)
+
+	args := []string{"justbackup", "diff", "b1", "--from", "as of 2024-01-01"}
+	output := captureOutput(t, func() {
+		withArgs(t, args, DiffCommand)
+	})
+
+	expected := strings.Join([]string{
+		"Changes from 2024-01-01_02-00-00 to 2024-01-02_02-00-00",
+		"etc/",
+		"  ~ hosts (-2 B)",
+		"  nginx/",
+		"    sites/",
+		"      + new.conf (+2.0 KB)",
+		"- old.log (-5 B)",
+		"",
+		"1 added, 1 removed, 1 modified (+2.0 KB)",
+	}, "\n")
+	if strings.TrimSpace(output) != expected {
+		t.Fatalf("unexpected output:\n%s", output)
+	}
+}
+
+func TestDiffCommandPrintsJSON(t *testing.T) {
+	withTempHome(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("to"); got != "latest" {
+			t.Fatalf("unexpected to: %q", got)
+		}
+		_, _ = w.Write([]byte(testDiffResponse))
+	}))
+	defer server.Close()
+
+	writeTestConfig(t, server.URL)
+
+	args := []string{"justbackup", "diff", "b1", "--from", "2024-01-01_02-00-00", "--to", "latest", "--json"}
+	output := captureOutput(t, func() {
+		withArgs(t, args, DiffCommand)
+	})
+
+	if !strings.Contains(output, `"path": "old.log"`) || !strings.Contains(output, `"size_delta": 2041`) {
+		t.Fatalf("unexpected output: %s", output)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"net/url"
 	"os"
 	"text/tabwriter"
 
@@ -20,9 +21,10 @@ type BackupFile struct {
 func FilesCommand() {
 	filesCmd := flag.NewFlagSet("files", flag.ExitOnError)
 	path := filesCmd.String("path", "", "Subpath to list (optional)")
+	at := filesCmd.String("at", "", "Snapshot to browse: a snapshot name, 'latest' or a point in time (optional)")
 
 	if len(os.Args) < 3 {
-		fmt.Println("Usage: justbackup files <backup-id> [--path <subpath>]")
+		fmt.Println("Usage: justbackup files <backup-id> [--path <subpath>] [--at <snapshot|latest|time>]")
 		return
 	}
 
@@ -40,8 +42,15 @@ func FilesCommand() {
 
 	apiClient := client.NewClient(cfg)
 	apiUrl := fmt.Sprintf("/backups/%s/files", backupID)
+	query := url.Values{}
 	if *path != "" {
-		apiUrl = fmt.Sprintf("%s?path=%s", apiUrl, *path)
+		query.Set("path", *path)
+	}
+	if *at != "" {
+		query.Set("snapshot", *at)
+	}
+	if len(query) > 0 {
+		apiUrl = fmt.Sprintf("%s?%s", apiUrl, query.Encode())
 	}
 
 	data, err := apiClient.Get(apiUrl)
@@ -57,3 +57,29 @@ func TestFormatSize(t *testing.T) {
 		}
 	}
 }
+
+func TestFilesCommandPassesSnapshotSelector(t *testing.T) {
+	withTempHome(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("snapshot"); got != "as of 2024-03-01 12:00" {
+			t.Fatalf("unexpected snapshot: %q", got)
+		}
+		if got := r.URL.Query().Get("path"); got != "etc" {
+			t.Fatalf("unexpected path: %q", got)
+		}
+		_, _ = w.Write([]byte(`[{"name":"hosts","is_dir":false,"size":10}]`))
+	}))
+	defer server.Close()
+
+	writeTestConfig(t, server.URL)
+
+	args := []string{"justbackup", "files", "b1", "--path", "etc", "--at", "as of 2024-03-01 12:00"}
+	output := captureOutput(t, func() {
+		withArgs(t, args, FilesCommand)
+	})
+
+	if !strings.Contains(output, "hosts") {
+		t.Fatalf("unexpected output: %s", output)
+	}
+}
@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"text/tabwriter"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/cli/client"
+	"github.com/rrbarrero/justbackup/internal/cli/config"
+)
+
+// ForecastCommand shows when the backup roots are projected to fill.
+func ForecastCommand() {
+	forecastCmd := flag.NewFlagSet("forecast", flag.ExitOnError)
+	model := forecastCmd.String("model", "", "Growth model: linear (default) or seasonal")
+	if err := forecastCmd.Parse(os.Args[2:]); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return
+	}
+	apiClient := client.NewClient(cfg)
+
+	path := "/capacity/forecast"
+	if *model != "" {
+		path += "?model=" + url.QueryEscape(*model)
+	}
+	data, err := apiClient.Get(path)
+	if err != nil {
+		fmt.Printf("Error fetching forecast: %v\n", err)
+		return
+	}
+
+	var forecast dto.CapacityForecastResponse
+	if err := json.Unmarshal(data, &forecast); err != nil {
+		fmt.Printf("Error parsing response: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Based on the last %d days of backup sizes.\n", forecast.HistoryDays)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ROOT\tMODEL\tSTORED\tFREE\tGROWTH/DAY\tFULL IN")
+	for _, r := range forecast.Roots {
+		fullIn := "never"
+		if r.DaysUntilFull != nil {
+			fullIn = fmt.Sprintf("%d days (%s)", *r.DaysUntilFull, r.FullAt.Format("2006-01-02"))
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", r.Root, r.Model, formatSize(r.StoredSize), formatSize(r.Free), formatSizeDelta(r.GrowthPerDay), fullIn)
+	}
+	_ = w.Flush()
+}
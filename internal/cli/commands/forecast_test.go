@@ -0,0 +1,32 @@
+package commands
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestForecastCommand(t *testing.T) {
+	withTempHome(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/capacity/forecast" || r.URL.Query().Get("model") != "seasonal" {
+			t.Fatalf("unexpected request: %s", r.URL)
+		}
+		_, _ = w.Write([]byte(`{"history_days":90,"roots":[
+			{"root":"/mnt/backups","model":"seasonal","total":10240,"used":8192,"free":2048,"stored_size":4096,"growth_per_day":1024,"days_until_full":2,"full_at":"2024-03-12T02:00:00Z"},
+			{"root":"/mnt/cold","storage_pool_id":"p1","model":"linear","total":10240,"used":0,"free":10240,"stored_size":0,"growth_per_day":0,"days_until_full":null,"full_at":null}]}`))
+	}))
+	defer server.Close()
+
+	writeTestConfig(t, server.URL)
+
+	output := captureOutput(t, func() {
+		withArgs(t, []string{"justbackup", "forecast", "--model", "seasonal"}, ForecastCommand)
+	})
+
+	if !strings.Contains(output, "2 days (2024-03-12)") || !strings.Contains(output, "+1.0 KB") || !strings.Contains(output, "never") {
+		t.Fatalf("unexpected output: %s", output)
+	}
+}
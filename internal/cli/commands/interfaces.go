@@ -2,8 +2,10 @@ package commands
 
 import (
 	"io"
+	"net/url"
 
 	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/restorestream"
 )
 
 // SSHService defines the interface for SSH operations.
@@ -16,14 +18,26 @@ type APIService interface {
 	GetSSHKey() (string, error)
 	RegisterHost(req dto.CreateHostRequest) error
 	RequestRestore(backupID string, req dto.RestoreRequest) (string, error)
+	PreviewRestore(backupID string, req dto.RestoreRequest) (*dto.RestorePreviewResponse, error)
+	DownloadBackup(backupID string, query url.Values, offset int64, etag string) (*Download, error)
+}
+
+// Download is an archive being downloaded from the server. Partial is set
+// when the server resumed it from the requested offset.
+type Download struct {
+	Body    io.ReadCloser
+	Partial bool
+	ETag    string
 }
 
 // NetService defines the interface for network and data streaming operations.
 type NetService interface {
 	GetLocalIP() string
 	ListenTCP() (string, int, io.Closer, error)
-	AcceptAndValidate(listener io.Closer, token string) (io.ReadCloser, error)
-	ExtractTarGz(r io.Reader, dest string) error
+	AcceptAndValidate(listener io.Closer, session *restorestream.Session, offset int64) (io.ReadCloser, error)
+	// ExtractTarGz extracts the archive into dest, treating existing files as
+	// options say. A dry run writes nothing and returns the changes instead.
+	ExtractTarGz(r io.Reader, dest string, options TargetOptions) ([]string, error)
 }
 
 // OSUserRetriever defines the interface for retrieving the current OS user.
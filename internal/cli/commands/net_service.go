@@ -7,7 +7,13 @@ import (
 	"io"
 	"net"
 	"os"
+	"os/user"
 	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/restorestream"
 )
 
 type netServiceImpl struct{}
@@ -40,7 +46,7 @@ func (s *netServiceImpl) ListenTCP() (string, int, io.Closer, error) {
 	return addr.IP.String(), addr.Port, listener, nil
 }
 
-func (s *netServiceImpl) AcceptAndValidate(listener io.Closer, token string) (io.ReadCloser, error) {
+func (s *netServiceImpl) AcceptAndValidate(listener io.Closer, session *restorestream.Session, offset int64) (io.ReadCloser, error) {
 	tcpListener, ok := listener.(net.Listener)
 	if !ok {
 		return nil, fmt.Errorf("invalid listener type")
@@ -51,30 +57,24 @@ func (s *netServiceImpl) AcceptAndValidate(listener io.Closer, token string) (io
 		return nil, err
 	}
 
-	// Validate Token
-	receivedToken := make([]byte, len(token))
-	_, err = io.ReadFull(conn, receivedToken)
+	stream, err := session.Accept(conn, offset)
 	if err != nil {
 		_ = conn.Close()
-		return nil, fmt.Errorf("error reading token: %w", err)
+		return nil, fmt.Errorf("worker connection rejected: %w", err)
 	}
-
-	if string(receivedToken) != token {
-		_ = conn.Close()
-		return nil, fmt.Errorf("invalid token received from worker")
-	}
-
-	return conn, nil
+	return stream, nil
 }
 
-func (s *netServiceImpl) ExtractTarGz(r io.Reader, dest string) error {
+func (s *netServiceImpl) ExtractTarGz(r io.Reader, dest string, options TargetOptions) ([]string, error) {
 	gzr, err := gzip.NewReader(r)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer func() { _ = gzr.Close() }()
 
 	tr := tar.NewReader(gzr)
+	renameSuffix := valueobjects.RestoreRenameSuffix(time.Now())
+	var changes []string
 
 	for {
 		header, err := tr.Next()
@@ -82,30 +82,114 @@ func (s *netServiceImpl) ExtractTarGz(r io.Reader, dest string) error {
 			break
 		}
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		target := filepath.Join(dest, header.Name)
 
 		switch header.Typeflag {
 		case tar.TypeDir:
+			if options.DryRun {
+				if _, err := os.Lstat(target); os.IsNotExist(err) {
+					changes = append(changes, "create   "+header.Name)
+				}
+				continue
+			}
 			if err := os.MkdirAll(target, 0755); err != nil {
-				return err
+				return nil, err
+			}
+			if err := restoreOwnership(target, header, options); err != nil {
+				return nil, err
 			}
 		case tar.TypeReg:
-			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
-				return err
+			action := conflictAction(target, header, valueobjects.ConflictPolicy(options.Conflict))
+			if options.DryRun {
+				changes = append(changes, fmt.Sprintf("%-8s %s", action, header.Name))
+				continue
+			}
+			if action == "skip" {
+				continue
 			}
-			f, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR, os.FileMode(header.Mode))
-			if err != nil {
-				return err
+			if action == "rename" {
+				if err := os.Rename(target, target+renameSuffix); err != nil {
+					return nil, err
+				}
+			}
+			if err := extractFile(tr, target, header); err != nil {
+				return nil, err
+			}
+			if err := restoreOwnership(target, header, options); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return changes, nil
+}
+
+// conflictAction decides what extracting header does to target: create,
+// overwrite, skip or rename the file already there.
+func conflictAction(target string, header *tar.Header, policy valueobjects.ConflictPolicy) string {
+	info, err := os.Lstat(target)
+	if err != nil {
+		return "create"
+	}
+	switch policy {
+	case valueobjects.ConflictSkip:
+		return "skip"
+	case valueobjects.ConflictNewer:
+		if info.ModTime().After(header.ModTime) {
+			return "skip"
+		}
+	case valueobjects.ConflictRename:
+		return "rename"
+	}
+	return "overwrite"
+}
+
+func extractFile(r io.Reader, target string, header *tar.Header) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	// An overwritten file keeps its mode unless it is set again.
+	if err := os.Chmod(target, header.FileInfo().Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Chtimes(target, header.ModTime, header.ModTime)
+}
+
+// restoreOwnership gives target the owner and group recorded in header, by
+// name unless numeric IDs are asked for or the name is unknown here.
+func restoreOwnership(target string, header *tar.Header, options TargetOptions) error {
+	if !options.PreserveOwnership && !options.NumericIDs {
+		return nil
+	}
+
+	uid, gid := header.Uid, header.Gid
+	if !options.NumericIDs {
+		if u, err := user.Lookup(header.Uname); err == nil {
+			if id, err := strconv.Atoi(u.Uid); err == nil {
+				uid = id
 			}
-			if _, err := io.Copy(f, tr); err != nil {
-				_ = f.Close()
-				return err
+		}
+		if g, err := user.LookupGroup(header.Gname); err == nil {
+			if id, err := strconv.Atoi(g.Gid); err == nil {
+				gid = id
 			}
-			_ = f.Close()
 		}
 	}
+	if err := os.Lchown(target, uid, gid); err != nil {
+		return fmt.Errorf("failed to restore ownership of %s (restoring ownership needs root): %w", target, err)
+	}
 	return nil
 }
@@ -6,7 +6,9 @@ import (
 	"compress/gzip"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNetService_GetLocalIP(t *testing.T) {
@@ -58,7 +60,7 @@ func TestNetService_ExtractTarGz(t *testing.T) {
 	_ = gz.Close()
 
 	dest := t.TempDir()
-	if err := svc.ExtractTarGz(buf, dest); err != nil {
+	if _, err := svc.ExtractTarGz(buf, dest, TargetOptions{}); err != nil {
 		t.Fatalf("extract failed: %v", err)
 	}
 
@@ -70,3 +72,100 @@ func TestNetService_ExtractTarGz(t *testing.T) {
 		t.Errorf("expected %s, got %s", content, got)
 	}
 }
+
+func TestNetService_ExtractTarGzConflictPolicies(t *testing.T) {
+	restored := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	archive := func() *bytes.Buffer {
+		buf := &bytes.Buffer{}
+		gz := gzip.NewWriter(buf)
+		tw := tar.NewWriter(gz)
+		for _, name := range []string{"old.txt", "new.txt", "missing.txt"} {
+			_ = tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: 8, ModTime: restored})
+			_, _ = tw.Write([]byte("restored"))
+		}
+		_ = tw.Close()
+		_ = gz.Close()
+		return buf
+	}
+	setup := func(t *testing.T) string {
+		dest := t.TempDir()
+		for name, mtime := range map[string]time.Time{"old.txt": restored.Add(-time.Hour), "new.txt": restored.Add(time.Hour)} {
+			path := filepath.Join(dest, name)
+			if err := os.WriteFile(path, []byte("existing file"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(path, mtime, mtime); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return dest
+	}
+	read := func(t *testing.T, dest, name string) string {
+		data, err := os.ReadFile(filepath.Join(dest, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	svc := NewNetService()
+
+	t.Run("overwrite", func(t *testing.T) {
+		dest := setup(t)
+		if _, err := svc.ExtractTarGz(archive(), dest, TargetOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		if read(t, dest, "old.txt") != "restored" || read(t, dest, "new.txt") != "restored" {
+			t.Error("expected existing files to be overwritten")
+		}
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		dest := setup(t)
+		if _, err := svc.ExtractTarGz(archive(), dest, TargetOptions{Conflict: "skip"}); err != nil {
+			t.Fatal(err)
+		}
+		if read(t, dest, "old.txt") != "existing file" || read(t, dest, "missing.txt") != "restored" {
+			t.Error("expected existing files to be kept and missing ones restored")
+		}
+	})
+
+	t.Run("newer", func(t *testing.T) {
+		dest := setup(t)
+		if _, err := svc.ExtractTarGz(archive(), dest, TargetOptions{Conflict: "newer"}); err != nil {
+			t.Fatal(err)
+		}
+		if read(t, dest, "old.txt") != "restored" || read(t, dest, "new.txt") != "existing file" {
+			t.Error("expected only files older than the restored ones to be overwritten")
+		}
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		dest := setup(t)
+		if _, err := svc.ExtractTarGz(archive(), dest, TargetOptions{Conflict: "rename"}); err != nil {
+			t.Fatal(err)
+		}
+		moved, _ := filepath.Glob(filepath.Join(dest, "old.txt.pre-restore-*"))
+		if read(t, dest, "old.txt") != "restored" || len(moved) != 1 || read(t, dest, filepath.Base(moved[0])) != "existing file" {
+			t.Errorf("expected the existing file to be moved aside, found %v", moved)
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		dest := setup(t)
+		changes, err := svc.ExtractTarGz(archive(), dest, TargetOptions{Conflict: "newer", DryRun: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []string{"overwrite old.txt", "skip     new.txt", "create   missing.txt"}
+		if strings.Join(changes, "\n") != strings.Join(expected, "\n") {
+			t.Errorf("expected %q, got %q", expected, changes)
+		}
+		if read(t, dest, "old.txt") != "existing file" {
+			t.Error("expected a dry run not to write anything")
+		}
+		if _, err := os.Stat(filepath.Join(dest, "missing.txt")); !os.IsNotExist(err) {
+			t.Error("expected a dry run not to create files")
+		}
+	})
+}
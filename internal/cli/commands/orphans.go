@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/cli/client"
+	"github.com/rrbarrero/justbackup/internal/cli/config"
+)
+
+// OrphansCommand lists the data under the storage roots that no backup
+// references, or reclaims or adopts one entry.
+func OrphansCommand() {
+	orphansCmd := flag.NewFlagSet("orphans", flag.ExitOnError)
+	reclaim := orphansCmd.String("reclaim", "", "Delete this orphaned path for good")
+	adopt := orphansCmd.String("adopt", "", "Make this orphaned path the data of the backup given by --backup")
+	backupID := orphansCmd.String("backup", "", "Backup adopting the path")
+	if err := orphansCmd.Parse(os.Args[2:]); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		os.Exit(1)
+	}
+	if *adopt != "" && *backupID == "" {
+		fmt.Println("Error: --adopt requires --backup <id>")
+		return
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return
+	}
+	apiClient := client.NewClient(cfg)
+
+	switch {
+	case *reclaim != "":
+		reclaimOrphan(apiClient, *reclaim)
+	case *adopt != "":
+		adoptOrphan(apiClient, *adopt, *backupID)
+	default:
+		listOrphans(apiClient)
+	}
+}
+
+func listOrphans(apiClient *client.Client) {
+	data, err := apiClient.Get("/orphans")
+	if err != nil {
+		fmt.Printf("Error scanning for orphaned data: %v\n", err)
+		return
+	}
+
+	var scan dto.OrphanedDataResponse
+	if err := json.Unmarshal(data, &scan); err != nil {
+		fmt.Printf("Error parsing response: %v\n", err)
+		return
+	}
+
+	for _, root := range scan.Missing {
+		fmt.Printf("Warning: storage root %s was not found on the worker.\n", root)
+	}
+	if len(scan.Orphans) == 0 {
+		fmt.Println("No orphaned data found.")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "PATH\tSIZE\tMODIFIED")
+	for _, o := range scan.Orphans {
+		path := o.Path
+		if o.IsDir {
+			path += "/"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", path, formatSize(o.Size), o.ModTime.Format("2006-01-02 15:04"))
+	}
+	_ = w.Flush()
+	fmt.Printf("\n%d orphaned entries, %s in total.\n", len(scan.Orphans), formatSize(scan.TotalSize))
+}
+
+func reclaimOrphan(apiClient *client.Client, path string) {
+	body, err := json.Marshal(dto.ReclaimOrphanRequest{Path: path})
+	if err != nil {
+		fmt.Printf("Error marshaling request: %v\n", err)
+		return
+	}
+
+	data, err := apiClient.Post("/orphans/reclaim", bytes.NewBuffer(body))
+	if err != nil {
+		fmt.Printf("Error reclaiming %s: %v\n", path, err)
+		return
+	}
+
+	var resp dto.ReclaimOrphanResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		fmt.Printf("Error parsing response: %v\n", err)
+		return
+	}
+	fmt.Printf("Deleting %s (task %s).\n", path, resp.TaskID)
+}
+
+func adoptOrphan(apiClient *client.Client, path string, backupID string) {
+	body, err := json.Marshal(dto.AdoptOrphanRequest{Path: path, BackupID: backupID})
+	if err != nil {
+		fmt.Printf("Error marshaling request: %v\n", err)
+		return
+	}
+
+	if _, err := apiClient.Post("/orphans/adopt", bytes.NewBuffer(body)); err != nil {
+		fmt.Printf("Error adopting %s: %v\n", path, err)
+		return
+	}
+	fmt.Printf("Backup %s now keeps its data in %s.\n", backupID, path)
+}
@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+)
+
+func TestOrphansCommandListsOrphans(t *testing.T) {
+	withTempHome(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/orphans" || r.Method != http.MethodGet {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"orphans":[{"root":"/mnt/backups","path":"/mnt/backups/web/old","is_dir":true,"size":2048,"mod_time":"2024-03-01T10:00:00Z"}],"total_size":2048,"missing":["/mnt/ssd"]}`))
+	}))
+	defer server.Close()
+
+	writeTestConfig(t, server.URL)
+
+	output := captureOutput(t, func() {
+		withArgs(t, []string{"justbackup", "orphans"}, OrphansCommand)
+	})
+
+	if !strings.Contains(output, "/mnt/backups/web/old/") || !strings.Contains(output, "1 orphaned entries, 2.0 KB in total.") {
+		t.Fatalf("unexpected output: %s", output)
+	}
+	if !strings.Contains(output, "storage root /mnt/ssd was not found") {
+		t.Fatalf("missing root not reported: %s", output)
+	}
+}
+
+func TestOrphansCommandReclaims(t *testing.T) {
+	withTempHome(t)
+
+	var received dto.ReclaimOrphanRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/orphans/reclaim" || r.Method != http.MethodPost {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"task_id":"t1"}`))
+	}))
+	defer server.Close()
+
+	writeTestConfig(t, server.URL)
+
+	output := captureOutput(t, func() {
+		withArgs(t, []string{"justbackup", "orphans", "--reclaim", "/mnt/backups/web/old"}, OrphansCommand)
+	})
+
+	if received.Path != "/mnt/backups/web/old" {
+		t.Fatalf("unexpected request body: %+v", received)
+	}
+	if !strings.Contains(output, "Deleting /mnt/backups/web/old (task t1).") {
+		t.Fatalf("unexpected output: %s", output)
+	}
+}
+
+func TestOrphansCommandAdoptRequiresBackup(t *testing.T) {
+	output := captureOutput(t, func() {
+		withArgs(t, []string{"justbackup", "orphans", "--adopt", "/mnt/backups/web/old"}, OrphansCommand)
+	})
+
+	if !strings.Contains(output, "--adopt requires --backup") {
+		t.Fatalf("unexpected output: %s", output)
+	}
+}
@@ -0,0 +1,162 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/cli/client"
+	"github.com/rrbarrero/justbackup/internal/cli/config"
+)
+
+type SnapshotPin struct {
+	Snapshot  string     `json:"snapshot"`
+	Label     string     `json:"label,omitempty"`
+	Note      string     `json:"note,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Active    bool       `json:"active,omitempty"`
+}
+
+// PinCommand pins a snapshot so retention never purges it, or lists the pins
+// of a backup when no snapshot is given.
+func PinCommand() {
+	pinCmd := flag.NewFlagSet("pin", flag.ExitOnError)
+	label := pinCmd.String("label", "", "Short label for the pin (optional)")
+	note := pinCmd.String("note", "", "Free-form note (optional)")
+	expires := pinCmd.String("expires", "", "Expiry: YYYY-MM-DD, RFC3339, <N>d or a duration like 72h (optional)")
+
+	if len(os.Args) < 3 {
+		fmt.Println("Usage: justbackup pin <backup-id> [<snapshot>] [--label <label>] [--note <note>] [--expires <when>]")
+		return
+	}
+
+	backupID := os.Args[2]
+	args := os.Args[3:]
+	snapshot := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		snapshot = args[0]
+		args = args[1:]
+	}
+	if err := pinCmd.Parse(args); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return
+	}
+	apiClient := client.NewClient(cfg)
+
+	if snapshot == "" {
+		listPins(apiClient, backupID)
+		return
+	}
+
+	pin := SnapshotPin{Snapshot: snapshot, Label: *label, Note: *note}
+	if *expires != "" {
+		expiresAt, err := parseExpiry(*expires, time.Now())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		pin.ExpiresAt = &expiresAt
+	}
+
+	body, err := json.Marshal(pin)
+	if err != nil {
+		fmt.Printf("Error encoding request: %v\n", err)
+		return
+	}
+
+	if _, err := apiClient.Post(fmt.Sprintf("/backups/%s/pins", backupID), bytes.NewReader(body)); err != nil {
+		fmt.Printf("Error pinning snapshot: %v\n", err)
+		return
+	}
+
+	if pin.ExpiresAt != nil {
+		fmt.Printf("Snapshot %s pinned until %s.\n", snapshot, pin.ExpiresAt.Format(time.RFC3339))
+		return
+	}
+	fmt.Printf("Snapshot %s pinned.\n", snapshot)
+}
+
+// UnpinCommand removes the pin of a snapshot.
+func UnpinCommand() {
+	if len(os.Args) < 4 {
+		fmt.Println("Usage: justbackup unpin <backup-id> <snapshot>")
+		return
+	}
+	backupID, snapshot := os.Args[2], os.Args[3]
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return
+	}
+	apiClient := client.NewClient(cfg)
+
+	if _, err := apiClient.Delete(fmt.Sprintf("/backups/%s/pins/%s", backupID, url.PathEscape(snapshot))); err != nil {
+		fmt.Printf("Error unpinning snapshot: %v\n", err)
+		return
+	}
+	fmt.Printf("Snapshot %s unpinned.\n", snapshot)
+}
+
+func listPins(apiClient *client.Client, backupID string) {
+	data, err := apiClient.Get(fmt.Sprintf("/backups/%s/pins", backupID))
+	if err != nil {
+		fmt.Printf("Error fetching pins: %v\n", err)
+		return
+	}
+
+	var pins []SnapshotPin
+	if err := json.Unmarshal(data, &pins); err != nil {
+		fmt.Printf("Error parsing response: %v\n", err)
+		return
+	}
+
+	if len(pins) == 0 {
+		fmt.Println("No pinned snapshots.")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "SNAPSHOT\tLABEL\tEXPIRES\tACTIVE\tNOTE")
+	for _, p := range pins {
+		expires := "never"
+		if p.ExpiresAt != nil {
+			expires = p.ExpiresAt.Format("2006-01-02 15:04")
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\n", p.Snapshot, p.Label, expires, p.Active, p.Note)
+	}
+	_ = w.Flush()
+}
+
+// parseExpiry accepts an absolute date (YYYY-MM-DD or RFC3339) or a duration
+// relative to now ("30d" or anything time.ParseDuration understands).
+func parseExpiry(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return now.AddDate(0, 0, n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return now.Add(d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid expiry %q: use YYYY-MM-DD, RFC3339, <N>d or a duration like 72h", value)
+}
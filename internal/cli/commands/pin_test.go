@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPinCommandPinsSnapshot(t *testing.T) {
+	withTempHome(t)
+
+	var received SnapshotPin
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/backups/b1/pins" || r.Method != http.MethodPost {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	writeTestConfig(t, server.URL)
+
+	args := []string{"justbackup", "pin", "b1", "2024-01-01_00-00-00", "--label", "audit", "--expires", "2030-01-01"}
+	output := captureOutput(t, func() {
+		withArgs(t, args, PinCommand)
+	})
+
+	if !strings.Contains(output, "Snapshot 2024-01-01_00-00-00 pinned until 2030-01-01") {
+		t.Fatalf("unexpected output: %s", output)
+	}
+	if received.Snapshot != "2024-01-01_00-00-00" || received.Label != "audit" || received.ExpiresAt == nil {
+		t.Fatalf("unexpected request body: %+v", received)
+	}
+}
+
+func TestPinCommandListsPins(t *testing.T) {
+	withTempHome(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/backups/b1/pins" || r.Method != http.MethodGet {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`[{"snapshot":"2024-01-01_00-00-00","label":"audit","active":true}]`))
+	}))
+	defer server.Close()
+
+	writeTestConfig(t, server.URL)
+
+	output := captureOutput(t, func() {
+		withArgs(t, []string{"justbackup", "pin", "b1"}, PinCommand)
+	})
+
+	if !strings.Contains(output, "SNAPSHOT") || !strings.Contains(output, "2024-01-01_00-00-00") || !strings.Contains(output, "never") {
+		t.Fatalf("unexpected output: %s", output)
+	}
+}
+
+func TestUnpinCommand(t *testing.T) {
+	withTempHome(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/backups/b1/pins/2024-01-01_00-00-00" || r.Method != http.MethodDelete {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	writeTestConfig(t, server.URL)
+
+	output := captureOutput(t, func() {
+		withArgs(t, []string{"justbackup", "unpin", "b1", "2024-01-01_00-00-00"}, UnpinCommand)
+	})
+
+	if !strings.Contains(output, "Snapshot 2024-01-01_00-00-00 unpinned.") {
+		t.Fatalf("unexpected output: %s", output)
+	}
+}
+
+func TestParseExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value    string
+		expected time.Time
+	}{
+		{"2024-02-01T00:00:00Z", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"30d", now.AddDate(0, 0, 30)},
+		{"72h", now.Add(72 * time.Hour)},
+	}
+	for _, tt := range tests {
+		got, err := parseExpiry(tt.value, now)
+		if err != nil || !got.Equal(tt.expected) {
+			t.Fatalf("parseExpiry(%q) = %v, %v; want %v", tt.value, got, err, tt.expected)
+		}
+	}
+
+	for _, invalid := range []string{"tomorrow", "-3d", "0d"} {
+		if _, err := parseExpiry(invalid, now); err == nil {
+			t.Fatalf("expected error for %q", invalid)
+		}
+	}
+}
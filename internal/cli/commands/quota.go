@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/cli/client"
+	"github.com/rrbarrero/justbackup/internal/cli/config"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+)
+
+const quotaUsage = "Usage: justbackup quota host|backup <id> --bytes <size> [--per-run <size>]"
+
+// QuotaCommand sets the storage quota of a host or a backup. Sizes accept
+// units like 50GB; zero lifts a limit.
+func QuotaCommand() {
+	quotaCmd := flag.NewFlagSet("quota", flag.ExitOnError)
+	limit := quotaCmd.String("bytes", "0", "Most the backups may store on disk, e.g. 50GB (0: unlimited)")
+	perRun := quotaCmd.String("per-run", "0", "Most a single run may add, e.g. 5GB (0: unlimited)")
+
+	if len(os.Args) < 4 || (os.Args[2] != "host" && os.Args[2] != "backup") {
+		fmt.Println(quotaUsage)
+		return
+	}
+	scope, id := os.Args[2], os.Args[3]
+	if err := quotaCmd.Parse(os.Args[4:]); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		os.Exit(1)
+	}
+
+	var req dto.QuotaRequest
+	var err error
+	if req.Bytes, err = shared.ParseSize(*limit); err != nil {
+		fmt.Printf("Error: invalid --bytes: %v\n", err)
+		return
+	}
+	if req.SnapshotBytes, err = shared.ParseSize(*perRun); err != nil {
+		fmt.Printf("Error: invalid --per-run: %v\n", err)
+		return
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\nRun 'justbackup config' to configure the CLI.\n", err)
+		return
+	}
+	apiClient := client.NewClient(cfg)
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		fmt.Printf("Error marshaling request: %v\n", err)
+		return
+	}
+
+	data, err := apiClient.Put(fmt.Sprintf("/%ss/%s/quota", scope, id), bytes.NewBuffer(body))
+	if err != nil {
+		fmt.Printf("Error setting quota: %v\n", err)
+		return
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		fmt.Printf("Quota of %s %s lifted.\n", scope, id)
+		return
+	}
+
+	var quota dto.QuotaResponse
+	if err := json.Unmarshal(data, &quota); err != nil {
+		fmt.Printf("Error parsing response: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Quota of %s %s set.\n", scope, id)
+	if quota.Bytes > 0 {
+		fmt.Printf("  Stored:  %s of %s\n", formatSize(quota.Used), formatSize(quota.Bytes))
+	}
+	if quota.SnapshotBytes > 0 {
+		fmt.Printf("  Per run: %s\n", formatSize(quota.SnapshotBytes))
+	}
+	if quota.Exceeded {
+		fmt.Println("  The quota is already used up: the next run will fail until retention frees space.")
+	}
+}
@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+)
+
+func TestQuotaCommandSetsHostQuota(t *testing.T) {
+	withTempHome(t)
+
+	var received dto.QuotaRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/hosts/h1/quota" || r.Method != http.MethodPut {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"bytes":2147483648,"snapshot_bytes":0,"used":3221225472,"exceeded":true}`))
+	}))
+	defer server.Close()
+
+	writeTestConfig(t, server.URL)
+
+	output := captureOutput(t, func() {
+		withArgs(t, []string{"justbackup", "quota", "host", "h1", "--bytes", "2GB"}, QuotaCommand)
+	})
+
+	if received.Bytes != 2*1024*1024*1024 || received.SnapshotBytes != 0 {
+		t.Fatalf("unexpected request body: %+v", received)
+	}
+	if !strings.Contains(output, "3.0 GB of 2.0 GB") || !strings.Contains(output, "already used up") {
+		t.Fatalf("unexpected output: %s", output)
+	}
+}
+
+func TestQuotaCommandLiftsBackupQuota(t *testing.T) {
+	withTempHome(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/backups/b1/quota" || r.Method != http.MethodPut {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	writeTestConfig(t, server.URL)
+
+	output := captureOutput(t, func() {
+		withArgs(t, []string{"justbackup", "quota", "backup", "b1"}, QuotaCommand)
+	})
+
+	if !strings.Contains(output, "Quota of backup b1 lifted.") {
+		t.Fatalf("unexpected output: %s", output)
+	}
+}
@@ -0,0 +1,247 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/cli/client"
+	"github.com/rrbarrero/justbackup/internal/cli/config"
+)
+
+// ReplicasCommand lists the replica targets, or the snapshots of a backup
+// they hold when its ID is given. --sync copies what they miss now.
+func ReplicasCommand() {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return
+	}
+	apiClient := client.NewClient(cfg)
+
+	if len(os.Args) < 3 || strings.HasPrefix(os.Args[2], "-") {
+		listReplicaTargets(apiClient)
+		return
+	}
+	backupID := os.Args[2]
+
+	replicasCmd := flag.NewFlagSet("replicas", flag.ExitOnError)
+	sync := replicasCmd.Bool("sync", false, "Copy the snapshots the targets do not hold yet now")
+	if err := replicasCmd.Parse(os.Args[3:]); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *sync {
+		data, err := apiClient.Post(fmt.Sprintf("/backups/%s/replicate", backupID), nil)
+		if err != nil {
+			fmt.Printf("Error starting replication: %v\n", err)
+			return
+		}
+		var resp dto.ReplicateResponse
+		if err := json.Unmarshal(data, &resp); err != nil {
+			fmt.Printf("Error parsing response: %v\n", err)
+			return
+		}
+		fmt.Printf("Copying to %d replica targets (tasks %s).\n", len(resp.TaskIDs), strings.Join(resp.TaskIDs, ", "))
+		return
+	}
+
+	data, err := apiClient.Get(fmt.Sprintf("/backups/%s/replicas", backupID))
+	if err != nil {
+		fmt.Printf("Error fetching replicas: %v\n", err)
+		return
+	}
+
+	var replicas []dto.BackupReplicaResponse
+	if err := json.Unmarshal(data, &replicas); err != nil {
+		fmt.Printf("Error parsing response: %v\n", err)
+		return
+	}
+
+	if len(replicas) == 0 {
+		fmt.Println("No replica target covers the backup.")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "TARGET\tKIND\tSNAPSHOTS\tLATEST\tREPLICATED\tLAG")
+	for _, r := range replicas {
+		latest, replicated := "-", "never"
+		if r.LatestSnapshot != "" {
+			latest = r.LatestSnapshot
+		}
+		if r.LastReplicatedAt != nil {
+			replicated = r.LastReplicatedAt.Local().Format("2006-01-02 15:04")
+		}
+		lag := "in sync"
+		if !r.InSync {
+			lag = (time.Duration(r.LagSeconds) * time.Second).String()
+		}
+		if !r.Enabled {
+			lag += " (disabled)"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n", r.TargetName, r.Kind, len(r.Snapshots), latest, replicated, lag)
+	}
+	_ = w.Flush()
+
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "TARGET\tSNAPSHOT\tFILES\tSIZE\tUPLOADED\tREPLICATED")
+	for _, r := range replicas {
+		for _, s := range r.Snapshots {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n", r.TargetName, s.Snapshot, s.Files, formatSize(s.Bytes), formatSize(s.UploadedBytes), s.ReplicatedAt.Local().Format("2006-01-02 15:04"))
+		}
+	}
+	_ = w.Flush()
+}
+
+func listReplicaTargets(apiClient *client.Client) {
+	data, err := apiClient.Get("/replica-targets")
+	if err != nil {
+		fmt.Printf("Error fetching replica targets: %v\n", err)
+		return
+	}
+
+	var targets []dto.ReplicaTargetResponse
+	if err := json.Unmarshal(data, &targets); err != nil {
+		fmt.Printf("Error parsing response: %v\n", err)
+		return
+	}
+
+	if len(targets) == 0 {
+		fmt.Println("No replica targets.")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tNAME\tKIND\tLOCATION\tBWLIMIT\tRETENTION\tBACKUPS\tENABLED")
+	for _, t := range targets {
+		location := "-"
+		if t.S3 != nil {
+			location = strings.TrimSuffix(t.S3.Endpoint, "/") + "/" + t.S3.Bucket
+			if t.S3.Prefix != "" {
+				location += "/" + strings.Trim(t.S3.Prefix, "/")
+			}
+		}
+		if t.Rsync != nil {
+			location = fmt.Sprintf("%s@%s:%s", t.Rsync.User, t.Rsync.Host, t.Rsync.Path)
+		}
+		bwlimit, retention := "-", "all"
+		if t.BandwidthLimit > 0 {
+			bwlimit = fmt.Sprintf("%d KiB/s", t.BandwidthLimit)
+		}
+		if t.Retention > 0 {
+			retention = fmt.Sprintf("%d", t.Retention)
+		}
+		backups := "all"
+		if len(t.BackupIDs) > 0 {
+			backups = strings.Join(t.BackupIDs, ",")
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%t\n", t.ID, t.Name, t.Kind, location, bwlimit, retention, backups, t.Enabled)
+	}
+	_ = w.Flush()
+}
+
+// AddReplicaTargetCommand creates a target the snapshots of backups are
+// copied to after each successful run: an S3-compatible bucket, or a
+// directory of a remote host reached over SSH with rsync.
+func AddReplicaTargetCommand() {
+	addCmd := flag.NewFlagSet("add-replica-target", flag.ExitOnError)
+	name := addCmd.String("name", "", "Name of the target (required)")
+	kind := addCmd.String("kind", "s3", "Kind of target: s3 or rsync")
+	endpoint := addCmd.String("endpoint", "", "s3: URL of the S3 service, e.g. http://minio:9000 (required)")
+	bucket := addCmd.String("bucket", "", "s3: Bucket (required)")
+	region := addCmd.String("region", "", "s3: Region (default: us-east-1)")
+	prefix := addCmd.String("prefix", "", "s3: Prefix of the object keys")
+	accessKey := addCmd.String("access-key", "", "s3: Access key (required)")
+	secretKey := addCmd.String("secret-key", "", "s3: Secret key (required)")
+	pathStyle := addCmd.Bool("path-style", false, "s3: Address the bucket in the path, as MinIO needs")
+	host := addCmd.String("host", "", "rsync: Remote host (required)")
+	user := addCmd.String("user", "", "rsync: SSH user (required)")
+	port := addCmd.Int("port", 22, "rsync: SSH port")
+	remotePath := addCmd.String("path", "", "rsync: Absolute directory on the host (required)")
+	bwlimit := addCmd.Int("bwlimit", 0, "Upload rate limit in KiB/s (default: no limit)")
+	retention := addCmd.Int("retention", 0, "Snapshots of incremental backups kept on the target (default: all)")
+	backups := addCmd.String("backups", "", "Comma-separated IDs of the backups to copy (default: all)")
+
+	if err := addCmd.Parse(os.Args[2:]); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		os.Exit(1)
+	}
+
+	req := dto.ReplicaTargetRequest{
+		Name:           *name,
+		Kind:           *kind,
+		BandwidthLimit: *bwlimit,
+		Retention:      *retention,
+	}
+	switch *kind {
+	case "s3":
+		if *name == "" || *endpoint == "" || *bucket == "" || *accessKey == "" || *secretKey == "" {
+			fmt.Println("Error: --name, --endpoint, --bucket, --access-key and --secret-key are required.")
+			fmt.Println("Usage: justbackup add-replica-target --name <name> --endpoint <url> --bucket <bucket> --access-key <key> --secret-key <secret> [--region --prefix --path-style --bwlimit <KiB/s> --retention <n> --backups <id,...>]")
+			os.Exit(1)
+		}
+		req.S3 = &dto.ReplicaS3Config{
+			Endpoint:  *endpoint,
+			Region:    *region,
+			Bucket:    *bucket,
+			Prefix:    *prefix,
+			AccessKey: *accessKey,
+			SecretKey: *secretKey,
+			PathStyle: *pathStyle,
+		}
+	case "rsync":
+		if *name == "" || *host == "" || *user == "" || *remotePath == "" {
+			fmt.Println("Error: --name, --host, --user and --path are required.")
+			fmt.Println("Usage: justbackup add-replica-target --kind rsync --name <name> --host <host> --user <user> --path <dir> [--port --bwlimit <KiB/s> --retention <n> --backups <id,...>]")
+			os.Exit(1)
+		}
+		req.Rsync = &dto.ReplicaRsyncConfig{
+			Host: *host,
+			User: *user,
+			Port: *port,
+			Path: *remotePath,
+		}
+	default:
+		fmt.Printf("Error: unknown kind %q, expected s3 or rsync.\n", *kind)
+		os.Exit(1)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\nRun 'justbackup config' to configure the CLI.\n", err)
+		return
+	}
+	apiClient := client.NewClient(cfg)
+
+	if *backups != "" {
+		req.BackupIDs = strings.Split(*backups, ",")
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		fmt.Printf("Error marshaling request: %v\n", err)
+		return
+	}
+
+	data, err := apiClient.Post("/replica-targets", bytes.NewBuffer(body))
+	if err != nil {
+		fmt.Printf("Error creating replica target: %v\n", err)
+		return
+	}
+
+	var target dto.ReplicaTargetResponse
+	if err := json.Unmarshal(data, &target); err != nil {
+		fmt.Printf("Error parsing response: %v\n", err)
+		return
+	}
+	fmt.Printf("Replica target %s created.\n", target.ID)
+}
@@ -0,0 +1,30 @@
+package commands
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReplicasCommandListsReplicatedSnapshots(t *testing.T) {
+	withTempHome(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/backups/b1/replicas" || r.Method != http.MethodGet {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`[{"target_id":"t1","target_name":"minio","kind":"s3","enabled":true,"latest_snapshot":"2024-06-01_03-00-00","in_sync":false,"lag_seconds":5400,"snapshots":[{"snapshot":"2024-06-01_03-00-00","files":12,"bytes":2048,"uploaded_bytes":1024,"replicated_at":"2024-06-01T03:05:00Z"}]}]`))
+	}))
+	defer server.Close()
+
+	writeTestConfig(t, server.URL)
+
+	output := captureOutput(t, func() {
+		withArgs(t, []string{"justbackup", "replicas", "b1"}, ReplicasCommand)
+	})
+
+	if !strings.Contains(output, "minio") || !strings.Contains(output, "2024-06-01_03-00-00") || !strings.Contains(output, "1h30m0s") {
+		t.Fatalf("unexpected output: %s", output)
+	}
+}
@@ -0,0 +1,214 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/cli/client"
+	"github.com/rrbarrero/justbackup/internal/cli/config"
+)
+
+// ReplicationsCommand lists the replication jobs, or the latest runs of one
+// job when its ID is given.
+func ReplicationsCommand() {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return
+	}
+	apiClient := client.NewClient(cfg)
+
+	if len(os.Args) > 2 {
+		listReplicationRuns(apiClient, os.Args[2])
+		return
+	}
+
+	data, err := apiClient.Get("/replications")
+	if err != nil {
+		fmt.Printf("Error fetching replication jobs: %v\n", err)
+		return
+	}
+
+	var jobs []dto.ReplicationJobResponse
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		fmt.Printf("Error parsing response: %v\n", err)
+		return
+	}
+
+	if len(jobs) == 0 {
+		fmt.Println("No replication jobs.")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tNAME\tBACKUP\tSNAPSHOT\tTARGET\tSCHEDULE\tSTATUS\tNEXT RUN")
+	for _, j := range jobs {
+		next := "disabled"
+		if j.NextRunAt != nil {
+			next = j.NextRunAt.Local().Format("2006-01-02 15:04")
+		}
+		snapshot := j.Snapshot
+		if snapshot == "" {
+			snapshot = "-"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s:%s\t%s\t%s\t%s\n", j.ID, j.Name, j.BackupID, snapshot, j.TargetHostID, j.TargetPath, j.Schedule, j.Status, next)
+	}
+	_ = w.Flush()
+}
+
+func listReplicationRuns(apiClient *client.Client, jobID string) {
+	data, err := apiClient.Get(fmt.Sprintf("/replications/%s/runs", jobID))
+	if err != nil {
+		fmt.Printf("Error fetching replication runs: %v\n", err)
+		return
+	}
+
+	var runs []dto.ReplicationRunResponse
+	if err := json.Unmarshal(data, &runs); err != nil {
+		fmt.Printf("Error parsing response: %v\n", err)
+		return
+	}
+
+	if len(runs) == 0 {
+		fmt.Println("The job has not run yet.")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "STARTED\tTRIGGER\tSTATUS\tDURATION\tMESSAGE")
+	for _, r := range runs {
+		duration := "-"
+		if r.FinishedAt != nil {
+			duration = r.FinishedAt.Sub(r.StartedAt).Round(time.Second).String()
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.StartedAt.Local().Format("2006-01-02 15:04:05"), r.Trigger, r.Status, duration, r.Message)
+	}
+	_ = w.Flush()
+}
+
+// AddReplicationCommand creates a job that restores a backup to a target
+// host on a schedule.
+func AddReplicationCommand() {
+	addCmd := flag.NewFlagSet("add-replication", flag.ExitOnError)
+	name := addCmd.String("name", "", "Name of the job (required)")
+	toHost := addCmd.String("to-host", "", "ID of the target host (required)")
+	toPath := addCmd.String("to-path", "", "Path at the target host (required)")
+	schedule := addCmd.String("schedule", "0 3 * * *", "Cron schedule (default: daily at 03:00)")
+	path := addCmd.String("path", "", "Path inside the backup (default: the whole backup)")
+	at := addCmd.String("at", "", "Snapshot: name, 'latest' or a point in time (default: latest)")
+	conflict := addCmd.String("conflict", "", "Existing files at the target: overwrite (default), skip, newer or rename")
+	deleteExtra := addCmd.Bool("delete", false, "Remove files at the target that are not in the backup")
+	preserveOwner := addCmd.Bool("preserve-owner", false, "Restore owners and groups by name (needs root at the target)")
+	numericIDs := addCmd.Bool("numeric-ids", false, "Restore owners and groups by numeric ID (needs root at the target)")
+	noOwner := addCmd.Bool("no-owner", false, "Leave owners and groups to the target instead of restoring them by name")
+	preserveTimes := addCmd.Bool("preserve-times", false, "Keep the modification times of the restored files")
+
+	if len(os.Args) < 3 || strings.HasPrefix(os.Args[2], "-") {
+		printAddReplicationUsage()
+		os.Exit(1)
+	}
+	backupID := os.Args[2]
+
+	if err := addCmd.Parse(os.Args[3:]); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *name == "" || *toHost == "" || *toPath == "" {
+		fmt.Println("Error: --name, --to-host and --to-path are required.")
+		printAddReplicationUsage()
+		os.Exit(1)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\nRun 'justbackup config' to configure the CLI.\n", err)
+		return
+	}
+	apiClient := client.NewClient(cfg)
+
+	req := dto.ReplicationJobRequest{
+		Name:              *name,
+		BackupID:          backupID,
+		Path:              *path,
+		Snapshot:          *at,
+		TargetHostID:      *toHost,
+		TargetPath:        *toPath,
+		Schedule:          *schedule,
+		Conflict:          *conflict,
+		Delete:            *deleteExtra,
+		PreserveOwnership: *preserveOwner,
+		NumericIDs:        *numericIDs,
+		NoOwnership:       *noOwner,
+		PreserveTimes:     *preserveTimes,
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		fmt.Printf("Error marshaling request: %v\n", err)
+		return
+	}
+
+	data, err := apiClient.Post("/replications", bytes.NewBuffer(body))
+	if err != nil {
+		fmt.Printf("Error creating replication job: %v\n", err)
+		return
+	}
+
+	var job dto.ReplicationJobResponse
+	if err := json.Unmarshal(data, &job); err != nil {
+		fmt.Printf("Error parsing response: %v\n", err)
+		return
+	}
+	fmt.Printf("Replication job %s created.\n", job.ID)
+}
+
+func printAddReplicationUsage() {
+	fmt.Println("Usage: justbackup add-replication <backup-id> --name <name> --to-host <host-id> --to-path <path> [options]")
+	fmt.Println("Options:")
+	fmt.Println("  --schedule <cron>     Cron schedule (default: daily at 03:00)")
+	fmt.Println("  --path <path>         Path inside the backup (default: the whole backup)")
+	fmt.Println("  --at <snapshot>       Snapshot name, 'latest' or a point in time (default: latest)")
+	fmt.Println("  --conflict <policy>   overwrite (default), skip, newer or rename")
+	fmt.Println("  --delete              Remove files at the target that are not in the backup")
+	fmt.Println("  --preserve-owner      Restore owners and groups by name")
+	fmt.Println("  --numeric-ids         Restore owners and groups by numeric ID")
+	fmt.Println("  --no-owner            Leave owners and groups to the target")
+	fmt.Println("  --preserve-times      Keep the modification times of the restored files")
+}
+
+// ReplicateCommand runs a replication job now.
+func ReplicateCommand() {
+	if len(os.Args) < 3 {
+		fmt.Println("Usage: justbackup replicate <job-id>")
+		return
+	}
+	jobID := os.Args[2]
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return
+	}
+	apiClient := client.NewClient(cfg)
+
+	data, err := apiClient.Post(fmt.Sprintf("/replications/%s/run", jobID), nil)
+	if err != nil {
+		fmt.Printf("Error starting replication: %v\n", err)
+		return
+	}
+
+	var run dto.ReplicationRunResponse
+	if err := json.Unmarshal(data, &run); err != nil {
+		fmt.Printf("Error parsing response: %v\n", err)
+		return
+	}
+	fmt.Printf("Replication started (task %s). Check its outcome with 'justbackup replications %s'.\n", run.TaskID, jobID)
+}
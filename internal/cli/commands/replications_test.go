@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+)
+
+func TestAddReplicationCommand(t *testing.T) {
+	withTempHome(t)
+
+	var received dto.ReplicationJobRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/replications" || r.Method != http.MethodPost {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"j1"}`))
+	}))
+	defer server.Close()
+
+	writeTestConfig(t, server.URL)
+
+	args := []string{"justbackup", "add-replication", "b1", "--name", "staging", "--to-host", "h2", "--to-path", "/srv/app", "--conflict", "newer", "--delete"}
+	output := captureOutput(t, func() {
+		withArgs(t, args, AddReplicationCommand)
+	})
+
+	if !strings.Contains(output, "Replication job j1 created.") {
+		t.Fatalf("unexpected output: %s", output)
+	}
+	if received.BackupID != "b1" || received.TargetHostID != "h2" || received.TargetPath != "/srv/app" ||
+		received.Schedule != "0 3 * * *" || received.Conflict != "newer" || !received.Delete {
+		t.Fatalf("unexpected request body: %+v", received)
+	}
+}
+
+func TestReplicationsCommandListsRuns(t *testing.T) {
+	withTempHome(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/replications/j1/runs" || r.Method != http.MethodGet {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`[{"id":"r1","job_id":"j1","trigger":"schedule","status":"failed","message":"rsync failed","started_at":"2024-06-01T03:00:00Z","finished_at":"2024-06-01T03:01:30Z"}]`))
+	}))
+	defer server.Close()
+
+	writeTestConfig(t, server.URL)
+
+	output := captureOutput(t, func() {
+		withArgs(t, []string{"justbackup", "replications", "j1"}, ReplicationsCommand)
+	})
+
+	if !strings.Contains(output, "schedule") || !strings.Contains(output, "1m30s") || !strings.Contains(output, "rsync failed") {
+		t.Fatalf("unexpected output: %s", output)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
 	"github.com/rrbarrero/justbackup/internal/cli/client"
 	"github.com/rrbarrero/justbackup/internal/cli/config"
 )
@@ -13,11 +14,15 @@ type restoreOptions struct {
 	backupID     string
 	isLocal      bool
 	isRemote     bool
+	isDownload   bool
+	format       string
 	remotePath   string
 	localDest    string
 	targetHostID string
 	targetPath   string
 	addr         string
+	snapshot     string
+	target       TargetOptions
 }
 
 func RestoreCommand() {
@@ -42,6 +47,11 @@ func RestoreCommand() {
 		executeRemoteRestore(svc, opts)
 		return
 	}
+
+	if opts.isDownload {
+		executeDownloadRestore(svc, opts)
+		return
+	}
 }
 
 func parseRestoreFlags() (*restoreOptions, error) {
@@ -50,11 +60,21 @@ func parseRestoreFlags() (*restoreOptions, error) {
 
 	fs.BoolVar(&opts.isLocal, "local", false, "")
 	fs.BoolVar(&opts.isRemote, "remote", false, "")
+	fs.BoolVar(&opts.isDownload, "download", false, "")
+	fs.StringVar(&opts.format, "format", "", "")
 	fs.StringVar(&opts.remotePath, "path", "", "")
 	fs.StringVar(&opts.localDest, "dest", ".", "")
 	fs.StringVar(&opts.targetHostID, "to-host", "", "")
 	fs.StringVar(&opts.targetPath, "to-path", "", "")
 	fs.StringVar(&opts.addr, "addr", "", "")
+	fs.StringVar(&opts.snapshot, "at", "", "")
+	fs.StringVar(&opts.target.Conflict, "conflict", "", "")
+	fs.BoolVar(&opts.target.Delete, "delete", false, "")
+	fs.BoolVar(&opts.target.PreserveOwnership, "preserve-owner", false, "")
+	fs.BoolVar(&opts.target.NumericIDs, "numeric-ids", false, "")
+	fs.BoolVar(&opts.target.NoOwnership, "no-owner", false, "")
+	fs.BoolVar(&opts.target.PreserveTimes, "preserve-times", false, "")
+	fs.BoolVar(&opts.target.DryRun, "dry-run", false, "")
 
 	if len(os.Args) < 3 {
 		printRestoreUsage()
@@ -72,13 +92,35 @@ func parseRestoreFlags() (*restoreOptions, error) {
 		return nil, fmt.Errorf("--path is required")
 	}
 
-	if opts.isLocal && opts.isRemote {
-		return nil, fmt.Errorf("--local and --remote are mutually exclusive")
+	modes := 0
+	for _, set := range []bool{opts.isLocal, opts.isRemote, opts.isDownload} {
+		if set {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return nil, fmt.Errorf("--local, --remote and --download are mutually exclusive")
 	}
 
-	if !opts.isLocal && !opts.isRemote {
+	if modes == 0 {
 		printRestoreUsage()
-		return nil, fmt.Errorf("either --local or --remote must be specified")
+		return nil, fmt.Errorf("one of --local, --remote or --download must be specified")
+	}
+
+	if _, err := valueobjects.ParseConflictPolicy(opts.target.Conflict); err != nil {
+		return nil, err
+	}
+	if opts.isDownload && opts.target != (TargetOptions{}) {
+		return nil, fmt.Errorf("--conflict, --delete, --preserve-owner, --numeric-ids, --no-owner, --preserve-times and --dry-run apply to --local and --remote")
+	}
+	if opts.target.Delete && !opts.isRemote {
+		return nil, fmt.Errorf("--delete is only supported with --remote")
+	}
+	if (opts.target.NoOwnership || opts.target.PreserveTimes) && !opts.isRemote {
+		return nil, fmt.Errorf("--no-owner and --preserve-times are only supported with --remote")
+	}
+	if opts.target.NoOwnership && (opts.target.PreserveOwnership || opts.target.NumericIDs) {
+		return nil, fmt.Errorf("--no-owner excludes --preserve-owner and --numeric-ids")
 	}
 
 	return opts, nil
@@ -100,11 +142,16 @@ func executeLocalRestore(svc *RestoreService, opts *restoreOptions) {
 		Path:       opts.remotePath,
 		LocalDest:  opts.localDest,
 		CustomAddr: opts.addr,
+		Snapshot:   opts.snapshot,
+		Options:    opts.target,
 	}
 	if err := svc.ExecuteLocal(params); err != nil {
 		fmt.Printf("Local restoration failed: %v\n", err)
 		return
 	}
+	if opts.target.DryRun {
+		return
+	}
 	fmt.Printf("Restoration completed successfully to %s\n", opts.localDest)
 }
 
@@ -118,7 +165,26 @@ func executeRemoteRestore(svc *RestoreService, opts *restoreOptions) {
 		Path:         opts.remotePath,
 		TargetHostID: opts.targetHostID,
 		TargetPath:   opts.targetPath,
+		Snapshot:     opts.snapshot,
+		Options:      opts.target,
 	}
+
+	if opts.target.DryRun {
+		preview, err := svc.PreviewRemote(params)
+		if err != nil {
+			fmt.Printf("Remote restore preview failed: %v\n", err)
+			return
+		}
+		fmt.Printf("Dry run: %d changes, nothing was written\n", len(preview.Changes))
+		for _, change := range preview.Changes {
+			fmt.Println(change)
+		}
+		if preview.Truncated {
+			fmt.Println("... (more changes not shown)")
+		}
+		return
+	}
+
 	taskID, err := svc.ExecuteRemote(params)
 	if err != nil {
 		fmt.Printf("Remote restoration failed: %v\n", err)
@@ -129,15 +195,53 @@ func executeRemoteRestore(svc *RestoreService, opts *restoreOptions) {
 	fmt.Println("The worker will now rsync the files to the destination host.")
 }
 
+func executeDownloadRestore(svc *RestoreService, opts *restoreOptions) {
+	params := DownloadParams{
+		BackupID:  opts.backupID,
+		Path:      opts.remotePath,
+		LocalDest: opts.localDest,
+		Snapshot:  opts.snapshot,
+		Format:    opts.format,
+	}
+	target, err := svc.ExecuteDownload(params)
+	if err != nil {
+		fmt.Printf("Download failed: %v\n", err)
+		return
+	}
+	fmt.Printf("Download completed successfully to %s\n", target)
+}
+
 func printRestoreUsage() {
 	fmt.Println("Usage: justbackup restore <backup-id> [options]")
 	fmt.Println("Options for Local Restore:")
 	fmt.Println("  --local              Restore to the local machine")
 	fmt.Println("  --path <path>        Path inside the backup (required)")
 	fmt.Println("  --dest <dir>         Local destination directory (default: .)")
+	fmt.Println("\nOptions for Download (through the server, no inbound connection needed):")
+	fmt.Println("  --download           Download an archive of the path into --dest, resuming")
+	fmt.Println("                       an interrupted download when run again")
+	fmt.Println("  --path <path>        Path inside the backup (required)")
+	fmt.Println("  --dest <dir>         Local destination directory (default: .)")
+	fmt.Println("  --format <format>    tar.gz (default) or zip")
 	fmt.Println("\nOptions for Remote Restore:")
 	fmt.Println("  --remote             Restore to a remote host")
 	fmt.Println("  --path <path>        Path inside the backup (required)")
 	fmt.Println("  --to-path <path>     Target path on the remote host (required)")
 	fmt.Println("  --to-host <id>       Target host ID (optional, defaults to original host)")
+	fmt.Println("  --delete             Remove files under --to-path that are not in the backup")
+	fmt.Println("  --no-owner           Leave owners and groups to the target instead of restoring")
+	fmt.Println("                       them by name")
+	fmt.Println("  --preserve-times     Keep the modification times of the restored files")
+	fmt.Println("\nCommon Options:")
+	fmt.Println("  --at <snapshot>      Snapshot to restore from: a snapshot name, 'latest' or a")
+	fmt.Println("                       point in time such as \"as of 2024-03-01 12:00\" (incremental backups)")
+	fmt.Println("\nTarget Options (--local and --remote):")
+	fmt.Println("  --conflict <policy>  What to do with existing files: overwrite (default), skip,")
+	fmt.Println("                       newer (keep them when newer) or rename (move them aside")
+	fmt.Println("                       with a .pre-restore-<time> suffix)")
+	fmt.Println("  --preserve-owner     Restore owners and groups by name (needs root at the target;")
+	fmt.Println("                       remote restores do so by default)")
+	fmt.Println("  --numeric-ids        Restore owners and groups by numeric ID")
+	fmt.Println("  --dry-run            List the changes the restore would make without writing")
+	fmt.Println("                       anything (a local dry run still receives the data)")
 }
@@ -1,12 +1,21 @@
 package commands
 
 import (
-	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/restorestream"
 )
 
 type RestoreService struct {
@@ -21,23 +30,55 @@ func NewRestoreService(api APIService, net NetService) *RestoreService {
 	}
 }
 
+// TargetOptions control how a restore treats what already exists at the
+// target. The worker applies them to remote restores through rsync and the
+// CLI applies them itself to local restores.
+type TargetOptions struct {
+	Conflict          string // overwrite (default), skip, newer or rename
+	Delete            bool   // remote only: remove files not in the backup
+	PreserveOwnership bool
+	NumericIDs        bool
+	NoOwnership       bool // remote only: leave owners and groups to the target
+	PreserveTimes     bool // remote only: keep file modification times
+	DryRun            bool
+}
+
 type RemoteRestoreParams struct {
 	BackupID     string
 	Path         string
 	TargetHostID string
 	TargetPath   string
+	Snapshot     string
+	Options      TargetOptions
 }
 
 func (s *RestoreService) ExecuteRemote(params RemoteRestoreParams) (string, error) {
-	req := dto.RestoreRequest{
-		BackupID:     params.BackupID,
-		Path:         params.Path,
-		RestoreType:  "remote",
-		TargetHostID: params.TargetHostID,
-		TargetPath:   params.TargetPath,
-	}
+	return s.apiService.RequestRestore(params.BackupID, remoteRestoreRequest(params))
+}
+
+// PreviewRemote returns the changes a remote restore would make at the
+// target, without making them.
+func (s *RestoreService) PreviewRemote(params RemoteRestoreParams) (*dto.RestorePreviewResponse, error) {
+	req := remoteRestoreRequest(params)
+	req.DryRun = true
+	return s.apiService.PreviewRestore(params.BackupID, req)
+}
 
-	return s.apiService.RequestRestore(params.BackupID, req)
+func remoteRestoreRequest(params RemoteRestoreParams) dto.RestoreRequest {
+	return dto.RestoreRequest{
+		BackupID:          params.BackupID,
+		Path:              params.Path,
+		RestoreType:       "remote",
+		TargetHostID:      params.TargetHostID,
+		TargetPath:        params.TargetPath,
+		Snapshot:          params.Snapshot,
+		Conflict:          params.Options.Conflict,
+		Delete:            params.Options.Delete,
+		PreserveOwnership: params.Options.PreserveOwnership,
+		NumericIDs:        params.Options.NumericIDs,
+		NoOwnership:       params.Options.NoOwnership,
+		PreserveTimes:     params.Options.PreserveTimes,
+	}
 }
 
 type LocalRestoreParams struct {
@@ -45,11 +86,24 @@ type LocalRestoreParams struct {
 	Path       string
 	LocalDest  string
 	CustomAddr string
+	Snapshot   string
+	Options    TargetOptions
 }
 
+// maxLocalRestoreAttempts bounds how many times an interrupted local restore
+// is requested again before giving up.
+const maxLocalRestoreAttempts = 5
+
+// ExecuteLocal has the worker stream the path to this machine and extracts it
+// into LocalDest. The stream is received into a .part file first, so an
+// interrupted transfer is resumed where it stopped, both by the retries here
+// and by running the command again.
 func (s *RestoreService) ExecuteLocal(params LocalRestoreParams) error {
-	// 1. Generate Token
-	token := s.generateRandomToken(16)
+	// 1. Generate the token and certificate the worker is told to expect
+	session, err := restorestream.NewSession()
+	if err != nil {
+		return err
+	}
 
 	// 2. Start Listener
 	_, port, listener, err := s.netService.ListenTCP()
@@ -67,44 +121,194 @@ func (s *RestoreService) ExecuteLocal(params LocalRestoreParams) error {
 	}
 
 	fmt.Printf("Listening for worker connection on %s\n", restoreAddr)
-	fmt.Printf("Authentication token: %s\n", token)
+	fmt.Printf("Certificate fingerprint: %s\n", session.Fingerprint)
+
+	if err := os.MkdirAll(params.LocalDest, 0755); err != nil {
+		return err
+	}
+	part := filepath.Join(params.LocalDest, localRestorePartName(params))
 
-	// 4. Send request to Backend
+	// 4. Request the restore and receive the stream, resuming after interruptions
 	req := dto.RestoreRequest{
-		BackupID:     params.BackupID,
-		Path:         params.Path,
-		RestoreType:  "local",
-		RestoreAddr:  restoreAddr,
-		RestoreToken: token,
+		BackupID:           params.BackupID,
+		Path:               params.Path,
+		RestoreType:        "local",
+		RestoreAddr:        restoreAddr,
+		RestoreToken:       session.Token,
+		RestoreFingerprint: session.Fingerprint,
+		Snapshot:           params.Snapshot,
 	}
 
-	fmt.Println("Requesting restore from server...")
-	_, err = s.apiService.RequestRestore(params.BackupID, req)
+	var summary *restorestream.Summary
+	for attempt := 1; ; attempt++ {
+		var offset int64
+		if info, err := os.Stat(part); err == nil && info.Size() > 0 {
+			offset = info.Size()
+			fmt.Printf("Resuming at %s\n", formatSize(offset))
+		}
+
+		fmt.Println("Requesting restore from server...")
+		if _, err := s.apiService.RequestRestore(params.BackupID, req); err != nil {
+			return err
+		}
+
+		fmt.Println("Waiting for worker to connect and send data...")
+		summary, err = s.receiveLocal(listener, session, part, offset)
+		if err == nil {
+			break
+		}
+
+		var remote *restorestream.RemoteError
+		if errors.As(err, &remote) {
+			_ = os.Remove(part)
+			return err
+		}
+		if attempt == maxLocalRestoreAttempts {
+			return fmt.Errorf("restore interrupted, run the command again to resume: %w", err)
+		}
+		fmt.Printf("Transfer interrupted (%v), retrying...\n", err)
+	}
+
+	// 5. Verify the whole stream and extract it
+	f, err := os.Open(part)
 	if err != nil {
 		return err
 	}
+	defer func() { _ = f.Close() }()
 
-	fmt.Println("Waiting for worker to connect and send data...")
-
-	// 5. Accept Connection and stream data
-	stream, err := s.netService.AcceptAndValidate(listener, token)
+	if err := summary.Verify(f); err != nil {
+		_ = os.Remove(part)
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	changes, err := s.netService.ExtractTarGz(f, params.LocalDest, params.Options)
 	if err != nil {
 		return err
 	}
+	if params.Options.DryRun {
+		fmt.Printf("Dry run: %d changes, nothing was written\n", len(changes))
+		for _, change := range changes {
+			fmt.Println(change)
+		}
+	}
+	return os.Remove(part)
+}
+
+// receiveLocal accepts the worker connection and appends the stream to part.
+func (s *RestoreService) receiveLocal(listener io.Closer, session *restorestream.Session, part string, offset int64) (*restorestream.Summary, error) {
+	stream, err := s.netService.AcceptAndValidate(listener, session, offset)
+	if err != nil {
+		return nil, err
+	}
 	defer func() { _ = stream.Close() }()
 
-	fmt.Println("Token validated. Receiving data...")
+	f, err := os.OpenFile(part, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	fmt.Println("Worker authenticated. Receiving data...")
+	start := time.Now()
+	received := int64(0)
+	summary, err := restorestream.Receive(stream, f, func(n int) {
+		received += int64(n)
+		rate := float64(received) / max(time.Since(start).Seconds(), 0.001)
+		fmt.Printf("\rReceived %s (%s/s)   ", formatSize(offset+received), formatSize(int64(rate)))
+	})
+	if received > 0 {
+		fmt.Println()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return summary, f.Close()
+}
 
-	// 6. Extract data
-	return s.netService.ExtractTarGz(stream, params.LocalDest)
+// localRestorePartName names the file a local restore is received into, so
+// the same restore finds it again when run after an interruption.
+func localRestorePartName(params LocalRestoreParams) string {
+	sum := sha256.Sum256([]byte(params.BackupID + "\x00" + params.Path + "\x00" + params.Snapshot))
+	return ".justbackup-restore-" + hex.EncodeToString(sum[:8]) + ".part"
 }
 
-func (s *RestoreService) generateRandomToken(n int) string {
-	b := make([]byte, n)
-	if _, err := rand.Read(b); err != nil {
-		return "fallback-token-123"
+type DownloadParams struct {
+	BackupID  string
+	Path      string
+	LocalDest string
+	Snapshot  string
+	Format    string
+}
+
+// ExecuteDownload downloads an archive of the path through the server into
+// LocalDest and returns where it was saved. An interrupted download is kept
+// as a .part file and resumed by the next call with the same parameters.
+func (s *RestoreService) ExecuteDownload(params DownloadParams) (string, error) {
+	format := params.Format
+	if format == "" {
+		format = "tar.gz"
+	}
+	name := path.Base(path.Clean("/" + params.Path))
+	if name == "/" {
+		name = params.BackupID
+	}
+	target := filepath.Join(params.LocalDest, name+"."+format)
+	part := target + ".part"
+	etagFile := part + ".etag"
+
+	var offset int64
+	var etag string
+	if info, err := os.Stat(part); err == nil {
+		if data, err := os.ReadFile(etagFile); err == nil {
+			offset, etag = info.Size(), strings.TrimSpace(string(data))
+		}
+	}
+
+	query := url.Values{}
+	query.Set("path", params.Path)
+	query.Set("format", format)
+	if params.Snapshot != "" {
+		query.Set("snapshot", params.Snapshot)
+	}
+
+	fmt.Println("Requesting download from server (the worker streams the archive to it first)...")
+	download, err := s.apiService.DownloadBackup(params.BackupID, query, offset, etag)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = download.Body.Close() }()
+
+	if err := os.MkdirAll(params.LocalDest, 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(etagFile, []byte(download.ETag), 0644); err != nil {
+		return "", err
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if download.Partial {
+		fmt.Printf("Resuming download at %d bytes\n", offset)
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	f, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, download.Body); err != nil {
+		_ = f.Close()
+		return "", fmt.Errorf("download interrupted, run the command again to resume: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(part, target); err != nil {
+		return "", err
 	}
-	return hex.EncodeToString(b)
+	_ = os.Remove(etagFile)
+	return target, nil
 }
 
 func (s *RestoreService) containsPort(sAddr string) bool {
@@ -1,12 +1,17 @@
 package commands
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/restorestream"
 )
 
 func TestRestoreService_ExecuteRemote(t *testing.T) {
@@ -25,6 +30,7 @@ func TestRestoreService_ExecuteRemote(t *testing.T) {
 		Path:         "/var/log",
 		TargetHostID: "h1",
 		TargetPath:   "/tmp",
+		Snapshot:     "as of 2024-03-01",
 	}
 
 	taskID, err := svc.ExecuteRemote(params)
@@ -35,11 +41,52 @@ func TestRestoreService_ExecuteRemote(t *testing.T) {
 		t.Fatalf("unexpected taskID: %s", taskID)
 	}
 
-	if capturedReq.RestoreType != "remote" || capturedReq.TargetPath != "/tmp" || capturedReq.TargetHostID != "h1" {
+	if capturedReq.RestoreType != "remote" || capturedReq.TargetPath != "/tmp" || capturedReq.TargetHostID != "h1" || capturedReq.Snapshot != "as of 2024-03-01" {
 		t.Errorf("unexpected request parameters: %+v", capturedReq)
 	}
 }
 
+func TestRestoreService_PreviewRemote(t *testing.T) {
+	var capturedReq dto.RestoreRequest
+	apiMock := &MockAPIService{
+		PreviewRestoreFunc: func(backupID string, req dto.RestoreRequest) (*dto.RestorePreviewResponse, error) {
+			capturedReq = req
+			return &dto.RestorePreviewResponse{Changes: []string{">f+++++++++ log/syslog"}}, nil
+		},
+	}
+	svc := NewRestoreService(apiMock, &MockNetService{})
+
+	preview, err := svc.PreviewRemote(RemoteRestoreParams{
+		BackupID:   "b1",
+		Path:       "/var/log",
+		TargetPath: "/tmp",
+		Options:    TargetOptions{Conflict: "rename", Delete: true, NumericIDs: true},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(preview.Changes) != 1 {
+		t.Errorf("unexpected preview: %+v", preview)
+	}
+	if !capturedReq.DryRun || capturedReq.Conflict != "rename" || !capturedReq.Delete || !capturedReq.NumericIDs {
+		t.Errorf("unexpected request parameters: %+v", capturedReq)
+	}
+}
+
+// framedStream frames content as a worker resuming at offset would send it.
+func framedStream(t *testing.T, content []byte, offset int64) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := restorestream.NewWriter(&buf, offset)
+	if _, err := w.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
 func TestRestoreService_ExecuteLocal(t *testing.T) {
 	var capturedReq dto.RestoreRequest
 	apiMock := &MockAPIService{
@@ -49,22 +96,29 @@ func TestRestoreService_ExecuteLocal(t *testing.T) {
 		},
 	}
 
-	var capturedToken string
+	var capturedSession *restorestream.Session
+	var extracted string
 	netMock := &MockNetService{
 		ListenTCPFunc: func() (string, int, io.Closer, error) {
 			return "127.0.0.1", 9999, &mockCloser{}, nil
 		},
-		AcceptAndValidateFunc: func(listener io.Closer, token string) (io.ReadCloser, error) {
-			capturedToken = token
-			return io.NopCloser(strings.NewReader("")), nil
+		AcceptAndValidateFunc: func(listener io.Closer, session *restorestream.Session, offset int64) (io.ReadCloser, error) {
+			capturedSession = session
+			return io.NopCloser(bytes.NewReader(framedStream(t, []byte("archive"), offset))), nil
+		},
+		ExtractTarGzFunc: func(r io.Reader, dest string, options TargetOptions) ([]string, error) {
+			data, err := io.ReadAll(r)
+			extracted = string(data)
+			return nil, err
 		},
 	}
 	svc := NewRestoreService(apiMock, netMock)
 
+	dest := t.TempDir()
 	params := LocalRestoreParams{
 		BackupID:  "b1",
 		Path:      "/var/log",
-		LocalDest: "/tmp/restore",
+		LocalDest: dest,
 	}
 
 	err := svc.ExecuteLocal(params)
@@ -76,12 +130,90 @@ func TestRestoreService_ExecuteLocal(t *testing.T) {
 		t.Errorf("unexpected request parameters: %+v", capturedReq)
 	}
 
-	if capturedReq.RestoreToken == "" {
-		t.Error("expected token to be generated and sent")
+	if capturedReq.RestoreToken == "" || capturedReq.RestoreFingerprint == "" {
+		t.Error("expected token and certificate fingerprint to be generated and sent")
 	}
 
-	if capturedToken != capturedReq.RestoreToken {
-		t.Errorf("token mismatch: accepted %s, but sent %s", capturedToken, capturedReq.RestoreToken)
+	if capturedSession.Token != capturedReq.RestoreToken || capturedSession.Fingerprint != capturedReq.RestoreFingerprint {
+		t.Errorf("session mismatch: accepted %+v, but sent %+v", capturedSession, capturedReq)
+	}
+	if extracted != "archive" {
+		t.Errorf("expected the received stream to be extracted, got %q", extracted)
+	}
+	if entries, _ := os.ReadDir(dest); len(entries) != 0 {
+		t.Errorf("expected the part file to be removed, found %v", entries)
+	}
+}
+
+func TestRestoreService_ExecuteLocalResumes(t *testing.T) {
+	content := make([]byte, 300<<10)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+
+	requests := 0
+	apiMock := &MockAPIService{
+		RequestRestoreFunc: func(backupID string, req dto.RestoreRequest) (string, error) {
+			requests++
+			return "", nil
+		},
+	}
+
+	var offsets []int64
+	var extracted []byte
+	netMock := &MockNetService{
+		AcceptAndValidateFunc: func(listener io.Closer, session *restorestream.Session, offset int64) (io.ReadCloser, error) {
+			offsets = append(offsets, offset)
+			stream := framedStream(t, content, offset)
+			if len(offsets) == 1 {
+				// Drop the connection in the middle of the second frame.
+				stream = stream[:(256<<10)+100]
+			}
+			return io.NopCloser(bytes.NewReader(stream)), nil
+		},
+		ExtractTarGzFunc: func(r io.Reader, dest string, options TargetOptions) ([]string, error) {
+			var err error
+			extracted, err = io.ReadAll(r)
+			return nil, err
+		},
+	}
+	svc := NewRestoreService(apiMock, netMock)
+
+	if err := svc.ExecuteLocal(LocalRestoreParams{BackupID: "b1", Path: "/var/log", LocalDest: t.TempDir()}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if requests != 2 || len(offsets) != 2 || offsets[0] != 0 || offsets[1] != 256<<10 {
+		t.Errorf("expected a second request resuming after the first frame, got %d requests at %v", requests, offsets)
+	}
+	if !bytes.Equal(extracted, content) {
+		t.Errorf("resumed stream does not match the original (%d of %d bytes)", len(extracted), len(content))
+	}
+}
+
+func TestRestoreService_ExecuteLocalWorkerError(t *testing.T) {
+	requests := 0
+	apiMock := &MockAPIService{
+		RequestRestoreFunc: func(backupID string, req dto.RestoreRequest) (string, error) {
+			requests++
+			return "", nil
+		},
+	}
+	netMock := &MockNetService{
+		AcceptAndValidateFunc: func(listener io.Closer, session *restorestream.Session, offset int64) (io.ReadCloser, error) {
+			var buf bytes.Buffer
+			_ = restorestream.NewWriter(&buf, offset).Fail(fmt.Errorf("path not found"))
+			return io.NopCloser(&buf), nil
+		},
+	}
+	svc := NewRestoreService(apiMock, netMock)
+
+	err := svc.ExecuteLocal(LocalRestoreParams{BackupID: "b1", LocalDest: t.TempDir()})
+	if err == nil || !strings.Contains(err.Error(), "path not found") {
+		t.Errorf("expected the worker error, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected worker errors not to be retried, got %d requests", requests)
 	}
 }
 
@@ -103,6 +235,7 @@ func TestRestoreService_ExecuteLocal_CustomAddr(t *testing.T) {
 	params := LocalRestoreParams{
 		BackupID:   "b1",
 		CustomAddr: "192.168.1.50",
+		LocalDest:  t.TempDir(),
 	}
 
 	_ = svc.ExecuteLocal(params)
@@ -120,8 +253,69 @@ func TestRestoreService_ErrorPropagation(t *testing.T) {
 	netMock := &MockNetService{}
 	svc := NewRestoreService(apiMock, netMock)
 
-	err := svc.ExecuteLocal(LocalRestoreParams{BackupID: "b1"})
+	err := svc.ExecuteLocal(LocalRestoreParams{BackupID: "b1", LocalDest: t.TempDir()})
 	if err == nil || !strings.Contains(err.Error(), "api error") {
 		t.Errorf("expected api error, got %v", err)
 	}
 }
+
+func TestRestoreService_ExecuteDownloadResumes(t *testing.T) {
+	dest := t.TempDir()
+	content := "0123456789"
+
+	var offsets []int64
+	var etags []string
+	interrupted := true
+	apiMock := &MockAPIService{
+		DownloadBackupFunc: func(backupID string, query url.Values, offset int64, etag string) (*Download, error) {
+			offsets = append(offsets, offset)
+			etags = append(etags, etag)
+			if query.Get("path") != "/etc/nginx" || query.Get("format") != "zip" {
+				t.Errorf("unexpected query: %v", query)
+			}
+			if interrupted {
+				return &Download{Body: io.NopCloser(&failingReader{data: content[:4]}), ETag: `"v1"`}, nil
+			}
+			return &Download{Body: io.NopCloser(strings.NewReader(content[offset:])), Partial: true, ETag: `"v1"`}, nil
+		},
+	}
+	svc := NewRestoreService(apiMock, &MockNetService{})
+	params := DownloadParams{BackupID: "b1", Path: "/etc/nginx", LocalDest: dest, Format: "zip"}
+
+	if _, err := svc.ExecuteDownload(params); err == nil {
+		t.Fatal("expected the interrupted download to fail")
+	}
+
+	interrupted = false
+	target, err := svc.ExecuteDownload(params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if target != filepath.Join(dest, "nginx.zip") {
+		t.Errorf("unexpected target: %s", target)
+	}
+	if data, _ := os.ReadFile(target); string(data) != content {
+		t.Errorf("unexpected content: %q", data)
+	}
+	if offsets[0] != 0 || offsets[1] != 4 || etags[1] != `"v1"` {
+		t.Errorf("unexpected resume: offsets %v etags %v", offsets, etags)
+	}
+	if _, err := os.Stat(target + ".part.etag"); !os.IsNotExist(err) {
+		t.Error("expected the resume state to be removed")
+	}
+}
+
+// failingReader returns data and then fails, like a dropped connection.
+type failingReader struct {
+	data string
+	done bool
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.done {
+		return 0, fmt.Errorf("connection reset")
+	}
+	r.done = true
+	return copy(p, r.data), nil
+}
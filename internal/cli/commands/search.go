@@ -2,18 +2,33 @@ package commands
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"net/url"
 	"os"
+	"strconv"
+	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/rrbarrero/justbackup/internal/cli/client"
 	"github.com/rrbarrero/justbackup/internal/cli/config"
 )
 
 type FileSearchResult struct {
-	Path   string         `json:"path"`
-	Backup BackupResponse `json:"backup"`
+	Path     string         `json:"path"`
+	Snapshot string         `json:"snapshot"`
+	Size     int64          `json:"size"`
+	ModTime  time.Time      `json:"mod_time"`
+	Hash     string         `json:"hash"`
+	Backup   BackupResponse `json:"backup"`
+}
+
+type FileSearchResponse struct {
+	Results  []FileSearchResult `json:"results"`
+	Total    int                `json:"total"`
+	Page     int                `json:"page"`
+	PageSize int                `json:"page_size"`
 }
 
 type BackupResponse struct {
@@ -23,7 +38,57 @@ type BackupResponse struct {
 	Status      string `json:"status"`
 }
 
-func SearchCommand(pattern string) {
+// SearchCommand searches the file catalog of all backups. The glob pattern is
+// optional when other filters are given.
+func SearchCommand() {
+	searchCmd := flag.NewFlagSet("search", flag.ExitOnError)
+	regex := searchCmd.String("regex", "", "Regular expression matched against the path inside the snapshot")
+	host := searchCmd.String("host", "", "Only files of this host's backups (host ID)")
+	backup := searchCmd.String("backup", "", "Only files of this backup (backup ID)")
+	after := searchCmd.String("after", "", "Modified at or after this date (YYYY-MM-DD or RFC 3339)")
+	before := searchCmd.String("before", "", "Modified at or before this date (YYYY-MM-DD or RFC 3339)")
+	minSize := searchCmd.String("min-size", "", "Minimum file size (e.g. 10MB)")
+	maxSize := searchCmd.String("max-size", "", "Maximum file size (e.g. 1GB)")
+	page := searchCmd.Int("page", 1, "Page of results to show")
+	pageSize := searchCmd.Int("page-size", 0, "Results per page (default: server default)")
+
+	args := os.Args[2:]
+	pattern := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		pattern = args[0]
+		args = args[1:]
+	}
+	if err := searchCmd.Parse(args); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		os.Exit(1)
+	}
+
+	query := url.Values{}
+	for key, value := range map[string]string{
+		"pattern":         pattern,
+		"regex":           *regex,
+		"host_id":         *host,
+		"backup_id":       *backup,
+		"modified_after":  *after,
+		"modified_before": *before,
+		"min_size":        *minSize,
+		"max_size":        *maxSize,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	if len(query) == 0 {
+		fmt.Println("Usage: justbackup search [pattern] [--regex <re>] [--host <id>] [--backup <id>] [--after <date>] [--before <date>] [--min-size <size>] [--max-size <size>] [--page <n>] [--page-size <n>]")
+		return
+	}
+	if *page > 1 {
+		query.Set("page", strconv.Itoa(*page))
+	}
+	if *pageSize > 0 {
+		query.Set("page_size", strconv.Itoa(*pageSize))
+	}
+
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		fmt.Printf("Error loading config: %v\nRun 'justbackup config' to configure the CLI.\n", err)
@@ -32,33 +97,42 @@ func SearchCommand(pattern string) {
 
 	apiClient := client.NewClient(cfg)
 
-	path := fmt.Sprintf("/files/search?pattern=%s", url.QueryEscape(pattern))
-	data, err := apiClient.Get(path)
+	data, err := apiClient.Get("/files/search?" + query.Encode())
 	if err != nil {
 		fmt.Printf("Error searching files: %v\n", err)
 		return
 	}
 
-	var results []FileSearchResult
-	if err := json.Unmarshal(data, &results); err != nil {
+	var resp FileSearchResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
 		fmt.Printf("Error parsing response: %v\n", err)
 		return
 	}
 
-	if len(results) == 0 {
-		fmt.Printf("No files found matching pattern: %s\n", pattern)
+	if len(resp.Results) == 0 {
+		fmt.Println("No files found.")
 		return
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	_, _ = fmt.Fprintln(w, "FILE PATH\tHOST\tDESTINATION\tID")
-	for _, res := range results {
-		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+	_, _ = fmt.Fprintln(w, "FILE PATH\tSNAPSHOT\tSIZE\tMODIFIED\tHOST\tDESTINATION\tID")
+	for _, res := range resp.Results {
+		snapshot := res.Snapshot
+		if snapshot == "" {
+			snapshot = "-"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
 			res.Path,
+			snapshot,
+			formatSize(res.Size),
+			res.ModTime.Local().Format("2006-01-02 15:04"),
 			res.Backup.HostName,
 			res.Backup.Destination,
 			res.Backup.ID,
 		)
 	}
 	_ = w.Flush()
+
+	pages := (resp.Total + resp.PageSize - 1) / resp.PageSize
+	fmt.Printf("\nPage %d of %d (%d files)\n", resp.Page, pages, resp.Total)
 }
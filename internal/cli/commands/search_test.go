@@ -14,19 +14,24 @@ func TestSearchCommand(t *testing.T) {
 		if r.URL.Path != "/files/search" {
 			t.Fatalf("unexpected path: %s", r.URL.Path)
 		}
-		if r.URL.Query().Get("pattern") != "foo bar" {
-			t.Fatalf("unexpected pattern: %s", r.URL.Query().Get("pattern"))
+		query := r.URL.Query()
+		if query.Get("pattern") != "foo bar" {
+			t.Fatalf("unexpected pattern: %s", query.Get("pattern"))
 		}
-		_, _ = w.Write([]byte(`[
-			{"path":"/etc/hosts","backup":{"id":"b1","host_name":"srv","destination":"dest","status":"ok"}}
-		]`))
+		if query.Get("min_size") != "1MB" || query.Get("modified_after") != "2024-01-01" || query.Get("page") != "2" {
+			t.Fatalf("unexpected filters: %s", r.URL.RawQuery)
+		}
+		_, _ = w.Write([]byte(`{"results":[
+			{"path":"etc/hosts","snapshot":"2024-01-02_10-00-00","size":2048,"mod_time":"2024-01-02T10:00:00Z","backup":{"id":"b1","host_name":"srv","destination":"dest","status":"ok"}}
+		],"total":3,"page":2,"page_size":2}`))
 	}))
 	defer server.Close()
 
 	writeTestConfig(t, server.URL)
 
-	output := captureOutput(t, func() {
-		SearchCommand("foo bar")
+	var output string
+	withArgs(t, []string{"justbackup", "search", "foo bar", "--min-size", "1MB", "--after", "2024-01-01", "--page", "2"}, func() {
+		output = captureOutput(t, SearchCommand)
 	})
 
 	lines := strings.Split(strings.TrimSpace(output), "\n")
@@ -34,11 +39,14 @@ func TestSearchCommand(t *testing.T) {
 		t.Fatalf("unexpected output: %s", output)
 	}
 	header := lines[0]
-	if !strings.Contains(header, "FILE PATH") || !strings.Contains(header, "DESTINATION") {
+	if !strings.Contains(header, "FILE PATH") || !strings.Contains(header, "SNAPSHOT") || !strings.Contains(header, "DESTINATION") {
 		t.Fatalf("missing header fields: %s", header)
 	}
 	row := lines[1]
-	if !strings.Contains(row, "/etc/hosts") || !strings.Contains(row, "srv") || !strings.Contains(row, "b1") {
+	if !strings.Contains(row, "etc/hosts") || !strings.Contains(row, "2024-01-02_10-00-00") || !strings.Contains(row, "2.0 KB") || !strings.Contains(row, "b1") {
 		t.Fatalf("missing result row: %s", row)
 	}
+	if !strings.Contains(output, "Page 2 of 2 (3 files)") {
+		t.Fatalf("missing page footer: %s", output)
+	}
 }
@@ -0,0 +1,153 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/cli/client"
+	"github.com/rrbarrero/justbackup/internal/cli/config"
+)
+
+// StoragePoolsCommand lists the storage pools with how full they are.
+func StoragePoolsCommand() {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return
+	}
+	apiClient := client.NewClient(cfg)
+
+	data, err := apiClient.Get("/storage-pools")
+	if err != nil {
+		fmt.Printf("Error fetching storage pools: %v\n", err)
+		return
+	}
+
+	var pools []dto.StoragePoolResponse
+	if err := json.Unmarshal(data, &pools); err != nil {
+		fmt.Printf("Error parsing response: %v\n", err)
+		return
+	}
+
+	if len(pools) == 0 {
+		fmt.Println("No storage pools, backups are kept in the default backup root.")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tNAME\tROOT\tCAPACITY\tAVAILABLE\tLABELS\tBACKUPS\tHOSTS")
+	for _, p := range pools {
+		capacity, available, labels := "disk", "?", "-"
+		if p.Capacity > 0 {
+			capacity = formatSize(p.Capacity)
+		}
+		if p.Usage != nil {
+			available = formatSize(p.Usage.Available)
+		}
+		if len(p.Labels) > 0 {
+			labels = strings.Join(p.Labels, ",")
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%d\n", p.ID, p.Name, p.RootPath, capacity, available, labels, p.Backups, p.Hosts)
+	}
+	_ = w.Flush()
+}
+
+// AddStoragePoolCommand creates a storage pool new backups can be placed in.
+func AddStoragePoolCommand() {
+	addCmd := flag.NewFlagSet("add-storage-pool", flag.ExitOnError)
+	name := addCmd.String("name", "", "Name of the pool (required)")
+	root := addCmd.String("root", "", "Directory of the pool as the worker sees it (required)")
+	capacity := addCmd.Int64("capacity", 0, "Bytes the pool may fill (default: its whole filesystem)")
+	labels := addCmd.String("labels", "", "Comma-separated labels, e.g. ssd,local")
+
+	if err := addCmd.Parse(os.Args[2:]); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *name == "" || *root == "" {
+		fmt.Println("Error: --name and --root are required.")
+		fmt.Println("Usage: justbackup add-storage-pool --name <name> --root <dir> [--capacity <bytes> --labels <l1,l2>]")
+		os.Exit(1)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\nRun 'justbackup config' to configure the CLI.\n", err)
+		return
+	}
+	apiClient := client.NewClient(cfg)
+
+	req := dto.StoragePoolRequest{Name: *name, RootPath: *root, Capacity: *capacity}
+	if *labels != "" {
+		req.Labels = strings.Split(*labels, ",")
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		fmt.Printf("Error marshaling request: %v\n", err)
+		return
+	}
+
+	data, err := apiClient.Post("/storage-pools", bytes.NewBuffer(body))
+	if err != nil {
+		fmt.Printf("Error creating storage pool: %v\n", err)
+		return
+	}
+
+	var pool dto.StoragePoolResponse
+	if err := json.Unmarshal(data, &pool); err != nil {
+		fmt.Printf("Error parsing response: %v\n", err)
+		return
+	}
+	fmt.Printf("Storage pool %s created.\n", pool.ID)
+}
+
+// MigrateBackupCommand moves the data of a backup to another storage pool.
+func MigrateBackupCommand() {
+	if len(os.Args) < 3 || strings.HasPrefix(os.Args[2], "-") {
+		fmt.Println("Error: Backup ID is required")
+		fmt.Println("Usage: justbackup migrate-backup <backup-id> [--pool <id>]")
+		os.Exit(1)
+	}
+	backupID := os.Args[2]
+
+	migrateCmd := flag.NewFlagSet("migrate-backup", flag.ExitOnError)
+	pool := migrateCmd.String("pool", "", "ID of the target pool (default: the default backup root)")
+	if err := migrateCmd.Parse(os.Args[3:]); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\nRun 'justbackup config' to configure the CLI.\n", err)
+		return
+	}
+	apiClient := client.NewClient(cfg)
+
+	body, err := json.Marshal(dto.AssignStoragePoolRequest{StoragePoolID: *pool})
+	if err != nil {
+		fmt.Printf("Error marshaling request: %v\n", err)
+		return
+	}
+
+	data, err := apiClient.Post(fmt.Sprintf("/backups/%s/migrate", backupID), bytes.NewBuffer(body))
+	if err != nil {
+		fmt.Printf("Error starting migration: %v\n", err)
+		return
+	}
+
+	var resp dto.MigrateStorageResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		fmt.Printf("Error parsing response: %v\n", err)
+		return
+	}
+	fmt.Printf("Migrating backup %s (task %s).\n", backupID, resp.TaskID)
+}
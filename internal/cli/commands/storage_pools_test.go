@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStoragePoolsCommandListsPools(t *testing.T) {
+	withTempHome(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/storage-pools" || r.Method != http.MethodGet {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`[{"id":"p1","name":"ssd","root_path":"/mnt/ssd","capacity":0,"labels":["ssd","local"],"backups":3,"hosts":1,"usage":{"total":4096,"free":2048,"used":2048,"available":2048}}]`))
+	}))
+	defer server.Close()
+
+	writeTestConfig(t, server.URL)
+
+	output := captureOutput(t, func() {
+		withArgs(t, []string{"justbackup", "storage-pools"}, StoragePoolsCommand)
+	})
+
+	if !strings.Contains(output, "/mnt/ssd") || !strings.Contains(output, "ssd,local") || !strings.Contains(output, "2.0 KB") {
+		t.Fatalf("unexpected output: %s", output)
+	}
+}
+
+func TestMigrateBackupCommandPostsTargetPool(t *testing.T) {
+	withTempHome(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/backups/b1/migrate" || r.Method != http.MethodPost {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), `"storage_pool_id":"p2"`) {
+			t.Fatalf("unexpected body: %s", body)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"task_id":"task-9"}`))
+	}))
+	defer server.Close()
+
+	writeTestConfig(t, server.URL)
+
+	output := captureOutput(t, func() {
+		withArgs(t, []string{"justbackup", "migrate-backup", "b1", "--pool", "p2"}, MigrateBackupCommand)
+	})
+
+	if !strings.Contains(output, "task-9") {
+		t.Fatalf("unexpected output: %s", output)
+	}
+}
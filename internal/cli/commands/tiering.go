@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rrbarrero/justbackup/internal/backup/application/dto"
+	"github.com/rrbarrero/justbackup/internal/cli/client"
+	"github.com/rrbarrero/justbackup/internal/cli/config"
+)
+
+// TieringCommand shows the tiering policy of a backup and its archived
+// snapshots, sets or removes the policy, or tiers the backup now.
+func TieringCommand() {
+	if len(os.Args) < 3 || strings.HasPrefix(os.Args[2], "-") {
+		fmt.Println("Error: Backup ID is required")
+		fmt.Println("Usage: justbackup tiering <backup-id> [--after-days <n> (--pool <id> [--encrypt] | --replica <id>)] [--off] [--now]")
+		os.Exit(1)
+	}
+	backupID := os.Args[2]
+
+	tieringCmd := flag.NewFlagSet("tiering", flag.ExitOnError)
+	afterDays := tieringCmd.Int("after-days", 0, "Move snapshots older than this many days")
+	pool := tieringCmd.String("pool", "", "ID of the cold storage pool to archive them into")
+	replica := tieringCmd.String("replica", "", "ID of the replica target to keep them on instead")
+	encrypt := tieringCmd.Bool("encrypt", false, "Encrypt the archives in the storage pool")
+	off := tieringCmd.Bool("off", false, "Remove the tiering policy")
+	now := tieringCmd.Bool("now", false, "Move the due snapshots now")
+	if err := tieringCmd.Parse(os.Args[3:]); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\nRun 'justbackup config' to configure the CLI.\n", err)
+		return
+	}
+	apiClient := client.NewClient(cfg)
+	path := fmt.Sprintf("/backups/%s/tiering", backupID)
+
+	switch {
+	case *off:
+		if _, err := apiClient.Delete(path); err != nil {
+			fmt.Printf("Error removing tiering policy: %v\n", err)
+			return
+		}
+		fmt.Printf("Tiering policy of backup %s removed. Archived snapshots stay where they are.\n", backupID)
+		return
+	case *afterDays > 0:
+		req := dto.TieringPolicyRequest{AfterDays: *afterDays, StoragePoolID: *pool, ReplicaTargetID: *replica, Encrypt: *encrypt}
+		body, err := json.Marshal(req)
+		if err != nil {
+			fmt.Printf("Error marshaling request: %v\n", err)
+			return
+		}
+		if _, err := apiClient.Put(path, bytes.NewBuffer(body)); err != nil {
+			fmt.Printf("Error setting tiering policy: %v\n", err)
+			return
+		}
+		fmt.Printf("Tiering policy of backup %s set.\n", backupID)
+	}
+
+	if *now {
+		data, err := apiClient.Post(fmt.Sprintf("/backups/%s/tier", backupID), nil)
+		if err != nil {
+			fmt.Printf("Error starting tiering: %v\n", err)
+			return
+		}
+		var resp dto.TierResponse
+		if err := json.Unmarshal(data, &resp); err != nil {
+			fmt.Printf("Error parsing response: %v\n", err)
+			return
+		}
+		fmt.Printf("Tiering backup %s (task %s).\n", backupID, resp.TaskID)
+		return
+	}
+	if *afterDays > 0 {
+		return
+	}
+
+	data, err := apiClient.Get(path)
+	if err != nil {
+		fmt.Printf("Error fetching tiering: %v\n", err)
+		return
+	}
+	var tiering dto.TieringResponse
+	if err := json.Unmarshal(data, &tiering); err != nil {
+		fmt.Printf("Error parsing response: %v\n", err)
+		return
+	}
+	printTiering(tiering)
+}
+
+func printTiering(tiering dto.TieringResponse) {
+	if p := tiering.Policy; p == nil {
+		fmt.Println("No tiering policy.")
+	} else if p.ReplicaTargetID != "" {
+		fmt.Printf("Snapshots older than %d days are kept only on replica target %s.\n", p.AfterDays, p.ReplicaTargetID)
+	} else {
+		encrypted := ""
+		if p.Encrypt {
+			encrypted = " encrypted"
+		}
+		fmt.Printf("Snapshots older than %d days are archived%s into storage pool %s.\n", p.AfterDays, encrypted, p.StoragePoolID)
+	}
+
+	if len(tiering.Archived) == 0 {
+		fmt.Println("No archived snapshots.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "SNAPSHOT\tTIER\tWHERE\tSIZE\tENCRYPTED\tARCHIVED AT")
+	for _, a := range tiering.Archived {
+		where := a.StoragePoolID
+		if a.Tier == "replica" {
+			where = a.ReplicaTargetID
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\t%s\n", a.Snapshot, a.Tier, where, formatSize(a.Size), a.Encrypted, a.ArchivedAt.Format("2006-01-02 15:04"))
+	}
+	_ = w.Flush()
+}
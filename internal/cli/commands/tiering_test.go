@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTieringCommandShowsArchivedSnapshots(t *testing.T) {
+	withTempHome(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/backups/b1/tiering" || r.Method != http.MethodGet {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"backup_id":"b1","policy":{"after_days":30,"storage_pool_id":"p1","encrypt":true},"archived":[{"snapshot":"2024-01-01_00-00-00","tier":"pool","storage_pool_id":"p1","size":2048,"encrypted":true,"archived_at":"2024-02-01T05:39:00Z"}]}`))
+	}))
+	defer server.Close()
+
+	writeTestConfig(t, server.URL)
+
+	output := captureOutput(t, func() {
+		withArgs(t, []string{"justbackup", "tiering", "b1"}, TieringCommand)
+	})
+
+	if !strings.Contains(output, "older than 30 days are archived encrypted into storage pool p1") || !strings.Contains(output, "2024-01-01_00-00-00") || !strings.Contains(output, "2.0 KB") {
+		t.Fatalf("unexpected output: %s", output)
+	}
+}
+
+func TestTieringCommandSetsPolicy(t *testing.T) {
+	withTempHome(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/backups/b1/tiering" || r.Method != http.MethodPut {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), `"after_days":90`) || !strings.Contains(string(body), `"replica_target_id":"t1"`) {
+			t.Fatalf("unexpected body: %s", body)
+		}
+		_, _ = w.Write([]byte(`{"backup_id":"b1","policy":{"after_days":90,"replica_target_id":"t1"},"archived":[]}`))
+	}))
+	defer server.Close()
+
+	writeTestConfig(t, server.URL)
+
+	output := captureOutput(t, func() {
+		withArgs(t, []string{"justbackup", "tiering", "b1", "--after-days", "90", "--replica", "t1"}, TieringCommand)
+	})
+
+	if !strings.Contains(output, "Tiering policy of backup b1 set.") {
+		t.Fatalf("unexpected output: %s", output)
+	}
+}
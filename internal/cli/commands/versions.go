@@ -0,0 +1,151 @@
+package commands
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/cli/client"
+	"github.com/rrbarrero/justbackup/internal/cli/config"
+)
+
+type FileVersion struct {
+	Hash      string    `json:"hash"`
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"mod_time"`
+	Snapshots []string  `json:"snapshots"`
+}
+
+type FileVersions struct {
+	Path               string        `json:"path"`
+	Versions           []FileVersion `json:"versions"`
+	EncryptedSnapshots []string      `json:"encrypted_snapshots,omitempty"`
+}
+
+// VersionsCommand lists every stored version of a file, or restores one of
+// them locally with --restore.
+func VersionsCommand() {
+	versionsCmd := flag.NewFlagSet("versions", flag.ExitOnError)
+	path := versionsCmd.String("path", "", "Path of the file inside the backup (required)")
+	restore := versionsCmd.Int("restore", 0, "Number of the version to restore (optional)")
+	dest := versionsCmd.String("dest", ".", "Local destination directory for --restore")
+	addr := versionsCmd.String("addr", "", "Address the worker connects back to for --restore (optional)")
+
+	if len(os.Args) < 3 {
+		fmt.Println("Usage: justbackup versions <backup-id> --path <file> [--restore <n> [--dest <dir>]]")
+		return
+	}
+
+	backupID := os.Args[2]
+	if err := versionsCmd.Parse(os.Args[3:]); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *path == "" {
+		fmt.Println("Error: --path is required")
+		return
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return
+	}
+
+	apiClient := client.NewClient(cfg)
+	data, err := apiClient.Get(fmt.Sprintf("/backups/%s/versions?path=%s", backupID, url.QueryEscape(*path)))
+	if err != nil {
+		fmt.Printf("Error fetching versions: %v\n", err)
+		return
+	}
+
+	var versions FileVersions
+	if err := json.Unmarshal(data, &versions); err != nil {
+		fmt.Printf("Error parsing response: %v\n", err)
+		return
+	}
+
+	if *restore == 0 {
+		printVersions(versions)
+		return
+	}
+
+	snapshot, err := versionSnapshot(versions, *restore)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	svc := NewRestoreService(NewAPIService(apiClient), NewNetService())
+	params := LocalRestoreParams{
+		BackupID:   backupID,
+		Path:       *path,
+		LocalDest:  *dest,
+		CustomAddr: *addr,
+		Snapshot:   snapshot,
+	}
+	if err := svc.ExecuteLocal(params); err != nil {
+		fmt.Printf("Local restoration failed: %v\n", err)
+		return
+	}
+	fmt.Printf("Version %d restored from snapshot %s to %s\n", *restore, snapshot, *dest)
+}
+
+func printVersions(versions FileVersions) {
+	if len(versions.Versions) == 0 {
+		fmt.Printf("No versions of %s found.\n", versions.Path)
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		_, _ = fmt.Fprintln(w, "#\tMODIFIED\tSIZE\tHASH\tSNAPSHOTS")
+		for i, v := range versions.Versions {
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n",
+				i+1,
+				v.ModTime.Local().Format("2006-01-02 15:04:05"),
+				formatSize(v.Size),
+				shortHash(v.Hash),
+				snapshotRange(v.Snapshots),
+			)
+		}
+		_ = w.Flush()
+	}
+
+	if len(versions.EncryptedSnapshots) > 0 {
+		fmt.Printf("%d encrypted snapshots could not be inspected.\n", len(versions.EncryptedSnapshots))
+	}
+}
+
+// versionSnapshot picks the snapshot to restore a version from. Every
+// snapshot holding a version has the same content, so the newest is used.
+func versionSnapshot(versions FileVersions, n int) (string, error) {
+	if n < 1 || n > len(versions.Versions) {
+		return "", fmt.Errorf("version %d does not exist (found %d)", n, len(versions.Versions))
+	}
+	snapshots := versions.Versions[n-1].Snapshots
+	if len(snapshots) == 0 {
+		return "", fmt.Errorf("version %d is not held by any snapshot", n)
+	}
+	return snapshots[len(snapshots)-1], nil
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
+func snapshotRange(snapshots []string) string {
+	switch len(snapshots) {
+	case 0:
+		return "-"
+	case 1:
+		return snapshots[0]
+	default:
+		return fmt.Sprintf("%s .. %s (%d)", snapshots[0], snapshots[len(snapshots)-1], len(snapshots))
+	}
+}
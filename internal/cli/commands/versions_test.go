@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestVersionsCommandListsVersions(t *testing.T) {
+	withTempHome(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/backups/b1/versions" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("path"); got != "/etc/nginx/nginx.conf" {
+			t.Fatalf("unexpected file path: %q", got)
+		}
+		_, _ = w.Write([]byte(`{
+			"path":"/etc/nginx/nginx.conf",
+			"versions":[
+				{"hash":"0123456789abcdef","size":2048,"mod_time":"2024-01-01T08:00:00Z","snapshots":["2024-01-01_02-00-00","2024-01-02_02-00-00"]},
+				{"hash":"fedcba9876543210","size":100,"mod_time":"2024-01-03T08:00:00Z","snapshots":["2024-01-03_02-00-00"]}
+			],
+			"encrypted_snapshots":["2024-01-04_02-00-00"]
+		}`))
+	}))
+	defer server.Close()
+
+	writeTestConfig(t, server.URL)
+
+	args := []string{"justbackup", "versions", "b1", "--path", "/etc/nginx/nginx.conf"}
+	output := captureOutput(t, func() {
+		withArgs(t, args, VersionsCommand)
+	})
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("unexpected output: %s", output)
+	}
+	if !strings.Contains(lines[1], "0123456789ab") || !strings.Contains(lines[1], "2.0 KB") ||
+		!strings.Contains(lines[1], "2024-01-01_02-00-00 .. 2024-01-02_02-00-00 (2)") {
+		t.Fatalf("unexpected first version row: %s", lines[1])
+	}
+	if !strings.Contains(lines[2], "fedcba987654") || !strings.Contains(lines[2], "2024-01-03_02-00-00") {
+		t.Fatalf("unexpected second version row: %s", lines[2])
+	}
+	if !strings.Contains(lines[3], "1 encrypted snapshots") {
+		t.Fatalf("missing encrypted note: %s", lines[3])
+	}
+}
+
+func TestVersionSnapshot(t *testing.T) {
+	versions := FileVersions{Versions: []FileVersion{
+		{Snapshots: []string{"2024-01-01_02-00-00", "2024-01-02_02-00-00"}},
+		{Snapshots: []string{"2024-01-03_02-00-00"}},
+	}}
+
+	snapshot, err := versionSnapshot(versions, 1)
+	if err != nil || snapshot != "2024-01-02_02-00-00" {
+		t.Fatalf("unexpected snapshot %q (%v)", snapshot, err)
+	}
+	if _, err := versionSnapshot(versions, 3); err == nil {
+		t.Fatalf("expected error for missing version")
+	}
+	if _, err := versionSnapshot(versions, 0); err == nil {
+		t.Fatalf("expected error for version 0")
+	}
+}
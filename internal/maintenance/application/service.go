@@ -7,29 +7,62 @@ import (
 
 	backupEntities "github.com/rrbarrero/justbackup/internal/backup/domain/entities"
 	backupInterfaces "github.com/rrbarrero/justbackup/internal/backup/domain/interfaces"
+	backupValueobjects "github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
 	"github.com/rrbarrero/justbackup/internal/maintenance/domain/entities"
 	"github.com/rrbarrero/justbackup/internal/maintenance/domain/interfaces"
 )
 
 type MaintenanceTaskPublisher interface {
-	PublishPurgeTask(ctx context.Context, backup *backupEntities.Backup) error
+	PublishPurgeTask(ctx context.Context, backup *backupEntities.Backup, pinned []string, cold []backupValueobjects.ColdLocation) error
+}
+
+// Tierer moves old snapshots off the storage of their backups and knows
+// where the ones archived in cold storage pools lie.
+type Tierer interface {
+	TierAll(ctx context.Context) error
+	ColdArchives(ctx context.Context, backup *backupEntities.Backup) ([]backupValueobjects.ColdLocation, error)
+}
+
+// CapacityChecker warns about backup roots projected to fill soon.
+type CapacityChecker interface {
+	CheckCapacity(ctx context.Context) error
+}
+
+// OrphanScanner looks for data under the storage roots no backup references.
+type OrphanScanner interface {
+	CheckOrphans(ctx context.Context) error
 }
 
 type MaintenanceService struct {
 	repo       interfaces.MaintenanceTaskRepository
 	backupRepo backupInterfaces.BackupRepository
+	hostRepo   backupInterfaces.HostRepository
+	pinRepo    backupInterfaces.SnapshotPinRepository
 	publisher  MaintenanceTaskPublisher
+	tierer     Tierer
+	capacity   CapacityChecker
+	orphans    OrphanScanner
 }
 
 func NewMaintenanceService(
 	repo interfaces.MaintenanceTaskRepository,
 	backupRepo backupInterfaces.BackupRepository,
+	hostRepo backupInterfaces.HostRepository,
+	pinRepo backupInterfaces.SnapshotPinRepository,
 	publisher MaintenanceTaskPublisher,
+	tierer Tierer,
+	capacity CapacityChecker,
+	orphans OrphanScanner,
 ) *MaintenanceService {
 	return &MaintenanceService{
 		repo:       repo,
 		backupRepo: backupRepo,
+		hostRepo:   hostRepo,
+		pinRepo:    pinRepo,
 		publisher:  publisher,
+		tierer:     tierer,
+		capacity:   capacity,
+		orphans:    orphans,
 	}
 }
 
@@ -63,29 +96,84 @@ func (s *MaintenanceService) ProcessDueTasks(ctx context.Context) error {
 func (s *MaintenanceService) executeTask(ctx context.Context, task *entities.MaintenanceTask) error {
 	switch task.Type() {
 	case entities.MaintenanceTaskTypePurge:
-		return s.purgeIncrementalBackups(ctx)
+		return s.purgeBackups(ctx)
+	case entities.MaintenanceTaskTypeTier:
+		if s.tierer == nil {
+			return nil
+		}
+		return s.tierer.TierAll(ctx)
+	case entities.MaintenanceTaskTypeForecast:
+		if s.capacity == nil {
+			return nil
+		}
+		return s.capacity.CheckCapacity(ctx)
+	case entities.MaintenanceTaskTypeOrphans:
+		if s.orphans == nil {
+			return nil
+		}
+		return s.orphans.CheckOrphans(ctx)
 	default:
 		log.Printf("Unknown maintenance task type: %s", task.Type())
 		return nil
 	}
 }
 
-func (s *MaintenanceService) purgeIncrementalBackups(ctx context.Context) error {
+func (s *MaintenanceService) purgeBackups(ctx context.Context) error {
 	backups, err := s.backupRepo.FindAll(ctx)
 	if err != nil {
 		return err
 	}
+	s.ApplyRetention(ctx, backups)
+	return nil
+}
 
+// ApplyRetention queues a purge of the given backups under their retention
+// policies and returns how many were queued. The scheduled purge task covers
+// every backup; a backup that hit its storage quota is purged right away.
+func (s *MaintenanceService) ApplyRetention(ctx context.Context, backups []*backupEntities.Backup) int {
+	queued := 0
+	hosts := make(map[backupEntities.HostID]*backupEntities.Host)
 	for _, backup := range backups {
-		if !backup.Incremental() || backup.Retention() <= 0 {
+		if backup.RetentionPolicy().IsZero() {
 			continue
 		}
 
-		log.Printf("Queueing purge task for backup: %s (retention: %d)", backup.ID(), backup.Retention())
-		if err := s.publisher.PublishPurgeTask(ctx, backup); err != nil {
+		host, ok := hosts[backup.HostID()]
+		if !ok {
+			var err error
+			host, err = s.hostRepo.Get(ctx, backup.HostID())
+			if err != nil {
+				log.Printf("Failed to load host of backup %s: %v", backup.ID(), err)
+				continue
+			}
+			hosts[backup.HostID()] = host
+		}
+		if backupEntities.UnderLegalHold(backup, host) {
+			log.Printf("Skipping purge of backup %s: under legal hold", backup.ID())
+			continue
+		}
+
+		pins, err := s.pinRepo.FindByBackupID(ctx, backup.ID())
+		if err != nil {
+			log.Printf("Failed to load pins of backup %s: %v", backup.ID(), err)
+			continue
+		}
+
+		var cold []backupValueobjects.ColdLocation
+		if s.tierer != nil {
+			if cold, err = s.tierer.ColdArchives(ctx, backup); err != nil {
+				log.Printf("Failed to load cold archives of backup %s: %v", backup.ID(), err)
+				continue
+			}
+		}
+
+		log.Printf("Queueing purge task for backup: %s (retention: %s)", backup.ID(), backup.RetentionPolicy())
+		if err := s.publisher.PublishPurgeTask(ctx, backup, backupEntities.ActivePinnedSnapshots(pins, time.Now()), cold); err != nil {
 			log.Printf("Failed to publish purge task for backup %s: %v", backup.ID(), err)
+			continue
 		}
+		queued++
 	}
 
-	return nil
+	return queued
 }
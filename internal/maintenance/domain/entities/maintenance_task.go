@@ -11,7 +11,10 @@ import (
 type MaintenanceTaskType string
 
 const (
-	MaintenanceTaskTypePurge MaintenanceTaskType = "purge"
+	MaintenanceTaskTypePurge    MaintenanceTaskType = "purge"
+	MaintenanceTaskTypeTier     MaintenanceTaskType = "tier"
+	MaintenanceTaskTypeForecast MaintenanceTaskType = "forecast"
+	MaintenanceTaskTypeOrphans  MaintenanceTaskType = "orphan_scan"
 )
 
 type MaintenanceTask struct {
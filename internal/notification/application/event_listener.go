@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/rrbarrero/justbackup/internal/backup/domain/events"
 	"github.com/rrbarrero/justbackup/internal/notification/domain/valueobjects"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
 	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/event"
 )
 
@@ -40,6 +42,54 @@ func (l *NotificationEventListener) Start(ctx context.Context) {
 		}
 		return l.handleBackupCompleted(ctx, event)
 	})
+
+	l.eventBus.Subscribe(ctx, events.ReplicationFailedEvent, func(data []byte) error {
+		var event events.ReplicationFailed
+		if err := json.Unmarshal(data, &event); err != nil {
+			return fmt.Errorf("failed to unmarshal ReplicationFailed event: %w", err)
+		}
+		return l.handleReplicationFailed(ctx, event)
+	})
+
+	l.eventBus.Subscribe(ctx, events.ReplicationCompletedEvent, func(data []byte) error {
+		var event events.ReplicationCompleted
+		if err := json.Unmarshal(data, &event); err != nil {
+			return fmt.Errorf("failed to unmarshal ReplicationCompleted event: %w", err)
+		}
+		return l.handleReplicationCompleted(ctx, event)
+	})
+
+	l.eventBus.Subscribe(ctx, events.ReplicaFailedEvent, func(data []byte) error {
+		var event events.ReplicaFailed
+		if err := json.Unmarshal(data, &event); err != nil {
+			return fmt.Errorf("failed to unmarshal ReplicaFailed event: %w", err)
+		}
+		return l.handleReplicaFailed(ctx, event)
+	})
+
+	l.eventBus.Subscribe(ctx, events.CapacityWarningEvent, func(data []byte) error {
+		var event events.CapacityWarning
+		if err := json.Unmarshal(data, &event); err != nil {
+			return fmt.Errorf("failed to unmarshal CapacityWarning event: %w", err)
+		}
+		return l.handleCapacityWarning(ctx, event)
+	})
+
+	l.eventBus.Subscribe(ctx, events.BackupAnomalyEvent, func(data []byte) error {
+		var event events.BackupAnomaly
+		if err := json.Unmarshal(data, &event); err != nil {
+			return fmt.Errorf("failed to unmarshal BackupAnomaly event: %w", err)
+		}
+		return l.handleBackupAnomaly(ctx, event)
+	})
+
+	l.eventBus.Subscribe(ctx, events.OrphanedDataFoundEvent, func(data []byte) error {
+		var event events.OrphanedDataFound
+		if err := json.Unmarshal(data, &event); err != nil {
+			return fmt.Errorf("failed to unmarshal OrphanedDataFound event: %w", err)
+		}
+		return l.handleOrphanedDataFound(ctx, event)
+	})
 }
 
 func (l *NotificationEventListener) handleBackupFailed(ctx context.Context, event events.BackupFailed) error {
@@ -54,7 +104,61 @@ func (l *NotificationEventListener) handleBackupFailed(ctx context.Context, even
 func (l *NotificationEventListener) handleBackupCompleted(ctx context.Context, event events.BackupCompleted) error {
 	// Handle successful backup notifications.
 	title := "Backup Completed"
-	message := fmt.Sprintf("Backup %s for host '%s' (Source: %s) completed successfully. Size: %s", event.BackupID, event.HostName, event.SourcePath, event.Size)
+	message := fmt.Sprintf("Backup %s for host '%s' (Source: %s) completed successfully. Size: %s (%s new)", event.BackupID, event.HostName, event.SourcePath, shared.FormatSize(event.Size), shared.FormatSize(event.UniqueSize))
 
 	return l.service.Notify(ctx, title, message, valueobjects.Info)
 }
+
+func (l *NotificationEventListener) handleReplicationFailed(ctx context.Context, event events.ReplicationFailed) error {
+	title := "Replication Failed"
+	message := fmt.Sprintf("Replication '%s' of backup %s to %s:%s failed: %s", event.JobName, event.BackupID, event.TargetHost, event.TargetPath, event.ErrorMessage)
+
+	return l.service.Notify(ctx, title, message, valueobjects.Error)
+}
+
+func (l *NotificationEventListener) handleReplicationCompleted(ctx context.Context, event events.ReplicationCompleted) error {
+	title := "Replication Completed"
+	message := fmt.Sprintf("Replication '%s' of backup %s to %s:%s completed successfully.", event.JobName, event.BackupID, event.TargetHost, event.TargetPath)
+
+	return l.service.Notify(ctx, title, message, valueobjects.Info)
+}
+
+func (l *NotificationEventListener) handleReplicaFailed(ctx context.Context, event events.ReplicaFailed) error {
+	title := "Offsite Copy Failed"
+	message := fmt.Sprintf("Copying backup %s to replica target '%s' failed: %s", event.BackupID, event.TargetName, event.ErrorMessage)
+
+	return l.service.Notify(ctx, title, message, valueobjects.Error)
+}
+
+func (l *NotificationEventListener) handleCapacityWarning(ctx context.Context, event events.CapacityWarning) error {
+	title := "Backup Storage Filling Up"
+	message := fmt.Sprintf("Backup root %s is projected to fill in %d days (around %s): %s free, growing %s a day", event.Root, event.DaysUntilFull, event.FullAt.Format("2006-01-02"), shared.FormatSize(event.Free), shared.FormatSize(event.GrowthPerDay))
+
+	return l.service.Notify(ctx, title, message, valueobjects.Warning)
+}
+
+func (l *NotificationEventListener) handleBackupAnomaly(ctx context.Context, event events.BackupAnomaly) error {
+	title := "Backup Anomaly Detected"
+	var detail string
+	switch event.Kind {
+	case "shrink":
+		detail = fmt.Sprintf("the snapshot shrank by %.0f%% to %s from a usual %s, which may be a broken mount or mass deletion", event.Ratio*100, shared.FormatSize(event.Size), shared.FormatSize(int64(event.Baseline)))
+	case "churn":
+		detail = fmt.Sprintf("%d of %d files (%.0f%%) changed against a usual %.0f%%, which may be ransomware encrypting them", event.ChangedFiles, event.Files, event.Ratio*100, event.Baseline*100)
+	default:
+		detail = event.Kind
+	}
+	message := fmt.Sprintf("Backup %s for host '%s' (Source: %s): %s.", event.BackupID, event.HostName, event.SourcePath, detail)
+	if event.Held {
+		message += " The backup was put under legal hold so its older snapshots are not purged; release the hold once it has been checked."
+	}
+
+	return l.service.Notify(ctx, title, message, valueobjects.Error)
+}
+
+func (l *NotificationEventListener) handleOrphanedDataFound(ctx context.Context, event events.OrphanedDataFound) error {
+	title := "Orphaned Backup Data Found"
+	message := fmt.Sprintf("%d entries no backup references take %s under %s. Review them with `justbackup orphans` to reclaim the space or adopt them back into a backup.", event.Count, shared.FormatSize(event.Size), strings.Join(event.Roots, ", "))
+
+	return l.service.Notify(ctx, title, message, valueobjects.Warning)
+}
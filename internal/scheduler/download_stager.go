@@ -0,0 +1,176 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/interfaces"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+)
+
+// downloadIdleTimeout bounds the wait for the next frame of a download. It is
+// long because the task may sit in the queue behind running backups.
+const downloadIdleTimeout = time.Hour
+
+// RedisDownloadStager stages the archives workers stream through Redis lists
+// as files in a local directory, and keeps them for ttl after they complete.
+type RedisDownloadStager struct {
+	client *redis.Client
+	dir    string
+	ttl    time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*stagedEntry
+}
+
+type stagedEntry struct {
+	done     chan struct{}
+	download *interfaces.StagedDownload
+	err      error
+	staged   time.Time
+}
+
+func NewRedisDownloadStager(client *redis.Client, dir string, ttl time.Duration) (*RedisDownloadStager, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create download directory: %w", err)
+	}
+
+	// Files staged before a restart are not tracked anymore.
+	for _, pattern := range []string{"*.download", "*.part"} {
+		stale, _ := filepath.Glob(filepath.Join(dir, pattern))
+		for _, file := range stale {
+			if err := os.Remove(file); err != nil {
+				log.Printf("WARNING: Failed to remove stale download %s: %v", file, err)
+			}
+		}
+	}
+
+	return &RedisDownloadStager{
+		client:  client,
+		dir:     dir,
+		ttl:     ttl,
+		entries: make(map[string]*stagedEntry),
+	}, nil
+}
+
+func (s *RedisDownloadStager) Stage(ctx context.Context, key string, start func(ctx context.Context, stream string) error) (*interfaces.StagedDownload, error) {
+	s.mu.Lock()
+	s.sweep(time.Now())
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &stagedEntry{done: make(chan struct{})}
+		s.entries[key] = entry
+		go s.receive(key, entry, start)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-entry.done:
+		return entry.download, entry.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// receive stages one download. It runs detached from the request that
+// started it, so a client that disconnects can come back for the archive.
+func (s *RedisDownloadStager) receive(key string, entry *stagedEntry, start func(ctx context.Context, stream string) error) {
+	download, err := s.stage(key, start)
+
+	s.mu.Lock()
+	entry.download, entry.err, entry.staged = download, err, time.Now()
+	if err != nil {
+		log.Printf("Failed to stage download %s: %v", key, err)
+		delete(s.entries, key)
+	}
+	s.mu.Unlock()
+	close(entry.done)
+}
+
+func (s *RedisDownloadStager) stage(key string, start func(ctx context.Context, stream string) error) (*interfaces.StagedDownload, error) {
+	ctx := context.Background()
+	stream := "download_stream:" + uuid.New().String()
+	defer func() { _ = s.client.Del(ctx, stream).Err() }()
+
+	if err := start(ctx, stream); err != nil {
+		return nil, err
+	}
+
+	part := filepath.Join(s.dir, key+".part")
+	f, err := os.Create(part)
+	if err != nil {
+		return nil, err
+	}
+	err = s.copyStream(ctx, stream, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(part)
+		return nil, err
+	}
+
+	staged := filepath.Join(s.dir, key+".download")
+	if err := os.Rename(part, staged); err != nil {
+		_ = os.Remove(part)
+		return nil, err
+	}
+	info, err := os.Stat(staged)
+	if err != nil {
+		return nil, err
+	}
+	return &interfaces.StagedDownload{Path: staged, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// copyStream writes the frames of stream to w until the end frame.
+func (s *RedisDownloadStager) copyStream(ctx context.Context, stream string, w io.Writer) error {
+	for {
+		result, err := s.client.BLPop(ctx, downloadIdleTimeout, stream).Result()
+		if err == redis.Nil {
+			return fmt.Errorf("timeout waiting for the worker to stream the download (%s)", downloadIdleTimeout)
+		}
+		if err != nil {
+			return err
+		}
+
+		// result[0] is the stream name, result[1] the frame
+		frame := result[1]
+		if frame == "" {
+			return fmt.Errorf("empty frame in download stream")
+		}
+		switch frame[0] {
+		case workerDto.DownloadFrameData:
+			if _, err := io.WriteString(w, frame[1:]); err != nil {
+				return err
+			}
+		case workerDto.DownloadFrameEnd:
+			return nil
+		case workerDto.DownloadFrameError:
+			return fmt.Errorf("worker error: %s", frame[1:])
+		default:
+			return fmt.Errorf("unknown frame %q in download stream", frame[0])
+		}
+	}
+}
+
+// sweep forgets downloads staged more than ttl ago and removes their files.
+// Requests still serving one keep reading it from their open file.
+func (s *RedisDownloadStager) sweep(now time.Time) {
+	for key, entry := range s.entries {
+		if entry.download == nil || now.Sub(entry.staged) < s.ttl {
+			continue
+		}
+		if err := os.Remove(entry.download.Path); err != nil && !os.IsNotExist(err) {
+			log.Printf("WARNING: Failed to remove staged download %s: %v", entry.download.Path, err)
+		}
+		delete(s.entries, key)
+	}
+}
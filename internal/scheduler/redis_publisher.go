@@ -10,23 +10,63 @@ import (
 	"github.com/redis/go-redis/v9"
 	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
 	"github.com/rrbarrero/justbackup/internal/backup/domain/interfaces"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
 	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
 )
 
 type RedisPublisher struct {
-	client   *redis.Client
-	queue    string
-	hostRepo interfaces.HostRepository
+	client     *redis.Client
+	queue      string
+	hostRepo   interfaces.HostRepository
+	poolRepo   interfaces.StoragePoolRepository
+	backupRepo interfaces.BackupRepository
 }
 
-func NewRedisPublisher(client *redis.Client, queue string, hostRepo interfaces.HostRepository) *RedisPublisher {
+func NewRedisPublisher(client *redis.Client, queue string, hostRepo interfaces.HostRepository, poolRepo interfaces.StoragePoolRepository, backupRepo interfaces.BackupRepository) *RedisPublisher {
 	return &RedisPublisher{
-		client:   client,
-		queue:    queue,
-		hostRepo: hostRepo,
+		client:     client,
+		queue:      queue,
+		hostRepo:   hostRepo,
+		poolRepo:   poolRepo,
+		backupRepo: backupRepo,
 	}
 }
 
+// backupRoot returns the root of the storage pool a backup is in, empty for
+// the backup root of the worker.
+func (p *RedisPublisher) backupRoot(ctx context.Context, backup *entities.Backup) (string, error) {
+	if backup.StoragePoolID() == "" || p.poolRepo == nil {
+		return "", nil
+	}
+	id, err := uuid.Parse(backup.StoragePoolID())
+	if err != nil {
+		return "", fmt.Errorf("invalid storage pool of backup %s: %w", backup.ID(), err)
+	}
+	pool, err := p.poolRepo.FindByID(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("failed to get storage pool: %w", err)
+	}
+	return pool.RootPath(), nil
+}
+
+// quotaBudget returns what the next run of a backup may add under the quotas
+// of the backup and its host, nil when neither has one.
+func (p *RedisPublisher) quotaBudget(ctx context.Context, backup *entities.Backup, host *entities.Host) (*valueobjects.QuotaBudget, error) {
+	if backup.Quota().IsZero() && host.Quota().IsZero() {
+		return nil, nil
+	}
+	var hostStored int64
+	if !host.Quota().IsZero() && p.backupRepo != nil {
+		backups, err := p.backupRepo.FindByHostID(ctx, host.ID())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get backups of host: %w", err)
+		}
+		hostStored = entities.HostStoredBytes(backups)
+	}
+	budget := entities.RunQuotaBudget(backup, host, hostStored)
+	return &budget, nil
+}
+
 func (p *RedisPublisher) Publish(ctx context.Context, backup *entities.Backup) error {
 	host, err := p.hostRepo.Get(ctx, backup.HostID())
 	if err != nil {
@@ -34,6 +74,12 @@ func (p *RedisPublisher) Publish(ctx context.Context, backup *entities.Backup) e
 	}
 
 	task := p.createWorkerTask(backup, host)
+	if task.BackupRoot, err = p.backupRoot(ctx, backup); err != nil {
+		return err
+	}
+	if task.Quota, err = p.quotaBudget(ctx, backup, host); err != nil {
+		return err
+	}
 
 	data, err := json.Marshal(task)
 	if err != nil {
@@ -83,28 +129,43 @@ func (p *RedisPublisher) PublishMeasureTask(ctx context.Context, hostID string,
 	return taskID, nil
 }
 
-func (p *RedisPublisher) PublishSearchTask(ctx context.Context, pattern string) (string, error) {
-	taskID := uuid.New().String()
+func (p *RedisPublisher) PublishCatalogTask(ctx context.Context, backup *entities.Backup, snapshot string) (string, error) {
+	host, err := p.hostRepo.Get(ctx, backup.HostID())
+	if err != nil {
+		return "", fmt.Errorf("failed to get host: %w", err)
+	}
+	root, err := p.backupRoot(ctx, backup)
+	if err != nil {
+		return "", err
+	}
 
+	taskID := uuid.New().String()
 	task := workerDto.WorkerTask{
-		Type:          workerDto.TaskTypeSearchFiles,
-		TaskID:        taskID,
-		SearchPattern: pattern,
+		Type:            workerDto.TaskTypeCatalog,
+		TaskID:          taskID,
+		BackupID:        backup.ID().String(),
+		Destination:     backup.Destination(),
+		HostPath:        host.Path(),
+		BackupRoot:      root,
+		Incremental:     backup.Incremental(),
+		Encrypted:       backup.Encrypted(),
+		ContentIndex:    backup.ContentIndex(),
+		CatalogSnapshot: snapshot,
 	}
 
 	data, err := json.Marshal(task)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal search task: %w", err)
+		return "", fmt.Errorf("failed to marshal catalog task: %w", err)
 	}
 
 	if err := p.client.RPush(ctx, p.queue, data).Err(); err != nil {
-		return "", err
+		return "", fmt.Errorf("failed to publish catalog task to redis: %w", err)
 	}
 
 	return taskID, nil
 }
 
-func (p *RedisPublisher) PublishRestoreTask(ctx context.Context, backup *entities.Backup, path string, restoreAddr string, restoreToken string) (string, error) {
+func (p *RedisPublisher) PublishRestoreTask(ctx context.Context, backup *entities.Backup, path string, archivePath string, replica *valueobjects.ReplicaLocation, cold *valueobjects.ColdLocation, restoreAddr string, restoreToken string, restoreFingerprint string) (string, error) {
 	taskID := uuid.New().String()
 
 	host, err := p.hostRepo.Get(ctx, backup.HostID())
@@ -113,17 +174,21 @@ func (p *RedisPublisher) PublishRestoreTask(ctx context.Context, backup *entitie
 	}
 
 	task := workerDto.WorkerTask{
-		Type:         workerDto.TaskTypeRestoreLocal,
-		TaskID:       taskID,
-		BackupID:     backup.ID().String(),
-		JobID:        uuid.New().String(),
-		Host:         host.Hostname(),
-		User:         host.User(),
-		Port:         host.Port(),
-		Path:         path,
-		RestoreAddr:  restoreAddr,
-		RestoreToken: restoreToken,
-		Encrypted:    backup.Encrypted(),
+		Type:               workerDto.TaskTypeRestoreLocal,
+		TaskID:             taskID,
+		BackupID:           backup.ID().String(),
+		JobID:              uuid.New().String(),
+		Host:               host.Hostname(),
+		User:               host.User(),
+		Port:               host.Port(),
+		Path:               path,
+		ArchivePath:        archivePath,
+		RestoreAddr:        restoreAddr,
+		RestoreToken:       restoreToken,
+		Encrypted:          backup.Encrypted(),
+		RestoreFingerprint: restoreFingerprint,
+		ReplicaSource:      replica,
+		ColdSource:         cold,
 	}
 
 	data, err := json.Marshal(task)
@@ -159,23 +224,121 @@ func (p *RedisPublisher) PublishListFilesTask(ctx context.Context, path string)
 	return taskID, nil
 }
 
-func (p *RedisPublisher) PublishRemoteRestoreTask(ctx context.Context, backup *entities.Backup, path string, targetHost *entities.Host, targetPath string) (string, error) {
+func (p *RedisPublisher) PublishListArchiveTask(ctx context.Context, backupID string, archive string, archivePath string) (string, error) {
+	taskID := uuid.New().String()
+
+	task := workerDto.WorkerTask{
+		Type:        workerDto.TaskTypeListArchive,
+		TaskID:      taskID,
+		BackupID:    backupID,
+		Path:        archive,
+		ArchivePath: archivePath,
+		Encrypted:   true,
+	}
+
+	data, err := json.Marshal(task)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal list archive task: %w", err)
+	}
+
+	if err := p.client.RPush(ctx, p.queue, data).Err(); err != nil {
+		return "", fmt.Errorf("failed to publish list archive task to redis: %w", err)
+	}
+
+	return taskID, nil
+}
+
+func (p *RedisPublisher) PublishListSnapshotsTask(ctx context.Context, path string) (string, error) {
+	taskID := uuid.New().String()
+
+	task := workerDto.WorkerTask{
+		Type:   workerDto.TaskTypeListSnapshots,
+		TaskID: taskID,
+		Path:   path,
+	}
+
+	data, err := json.Marshal(task)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal list snapshots task: %w", err)
+	}
+
+	if err := p.client.RPush(ctx, p.queue, data).Err(); err != nil {
+		return "", fmt.Errorf("failed to publish list snapshots task to redis: %w", err)
+	}
+
+	return taskID, nil
+}
+
+func (p *RedisPublisher) PublishFileVersionsTask(ctx context.Context, path string, filePath string) (string, error) {
 	taskID := uuid.New().String()
 
+	task := workerDto.WorkerTask{
+		Type:     workerDto.TaskTypeFileVersions,
+		TaskID:   taskID,
+		Path:     path,
+		FilePath: filePath,
+	}
+
+	data, err := json.Marshal(task)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal file versions task: %w", err)
+	}
+
+	if err := p.client.RPush(ctx, p.queue, data).Err(); err != nil {
+		return "", fmt.Errorf("failed to publish file versions task to redis: %w", err)
+	}
+
+	return taskID, nil
+}
+
+func (p *RedisPublisher) PublishDiffSnapshotsTask(ctx context.Context, path string, from string, to string) (string, error) {
+	taskID := uuid.New().String()
+
+	task := workerDto.WorkerTask{
+		Type:         workerDto.TaskTypeDiffSnapshots,
+		TaskID:       taskID,
+		Path:         path,
+		FromSnapshot: from,
+		ToSnapshot:   to,
+	}
+
+	data, err := json.Marshal(task)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal diff snapshots task: %w", err)
+	}
+
+	if err := p.client.RPush(ctx, p.queue, data).Err(); err != nil {
+		return "", fmt.Errorf("failed to publish diff snapshots task to redis: %w", err)
+	}
+
+	return taskID, nil
+}
+
+// PublishRemoteRestoreTask asks a worker to restore path to a host. The task
+// is published under taskID, or a new ID when it is empty.
+func (p *RedisPublisher) PublishRemoteRestoreTask(ctx context.Context, backup *entities.Backup, path string, archivePath string, replica *valueobjects.ReplicaLocation, cold *valueobjects.ColdLocation, targetHost *entities.Host, targetPath string, options valueobjects.RestoreOptions, taskID string) (string, error) {
+	if taskID == "" {
+		taskID = uuid.New().String()
+	}
+
 	// Get host where backup is stored (the physical files)
 	// The worker must have access to the backup storage path.
 
 	task := workerDto.WorkerTask{
-		Type:       workerDto.TaskTypeRestoreRemote,
-		TaskID:     taskID,
-		BackupID:   backup.ID().String(),
-		JobID:      uuid.New().String(),
-		Path:       path, // This should be the path in the worker filesystem
-		TargetHost: targetHost.Hostname(),
-		TargetUser: targetHost.User(),
-		TargetPort: targetHost.Port(),
-		TargetPath: targetPath,
-		Encrypted:  backup.Encrypted(),
+		Type:           workerDto.TaskTypeRestoreRemote,
+		TaskID:         taskID,
+		BackupID:       backup.ID().String(),
+		JobID:          uuid.New().String(),
+		Path:           path, // This should be the path in the worker filesystem
+		ArchivePath:    archivePath,
+		TargetHost:     targetHost.Hostname(),
+		TargetUser:     targetHost.User(),
+		TargetPort:     targetHost.Port(),
+		TargetPath:     targetPath,
+		Encrypted:      backup.Encrypted(),
+		RestoreOptions: &options,
+		ReplicaSource:  replica,
+		ColdSource:     cold,
 	}
 
 	data, err := json.Marshal(task)
@@ -190,24 +353,265 @@ func (p *RedisPublisher) PublishRemoteRestoreTask(ctx context.Context, backup *e
 	return taskID, nil
 }
 
-func (p *RedisPublisher) PublishPurgeTask(ctx context.Context, backup *entities.Backup) error {
+func (p *RedisPublisher) PublishDownloadTask(ctx context.Context, backup *entities.Backup, path string, archivePath string, replica *valueobjects.ReplicaLocation, cold *valueobjects.ColdLocation, format valueobjects.DownloadFormat, stream string) (string, error) {
+	taskID := uuid.New().String()
+
+	task := workerDto.WorkerTask{
+		Type:           workerDto.TaskTypeRestoreDownload,
+		TaskID:         taskID,
+		BackupID:       backup.ID().String(),
+		JobID:          uuid.New().String(),
+		Path:           path,
+		ArchivePath:    archivePath,
+		Encrypted:      backup.Encrypted(),
+		DownloadFormat: string(format),
+		DownloadStream: stream,
+		ReplicaSource:  replica,
+		ColdSource:     cold,
+	}
+
+	data, err := json.Marshal(task)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal download task: %w", err)
+	}
+
+	if err := p.client.RPush(ctx, p.queue, data).Err(); err != nil {
+		return "", fmt.Errorf("failed to publish download task to redis: %w", err)
+	}
+
+	return taskID, nil
+}
+
+// PublishReplicateTask asks a worker to copy the snapshots of a backup that
+// are not in replicated to a replica target, keeping retention snapshots
+// there when it is not 0.
+func (p *RedisPublisher) PublishReplicateTask(ctx context.Context, backup *entities.Backup, endpoint valueobjects.ReplicaEndpoint, replicated []string, retention int) (string, error) {
+	host, err := p.hostRepo.Get(ctx, backup.HostID())
+	if err != nil {
+		return "", fmt.Errorf("failed to get host: %w", err)
+	}
+	root, err := p.backupRoot(ctx, backup)
+	if err != nil {
+		return "", err
+	}
+
+	taskID := uuid.New().String()
+	task := workerDto.WorkerTask{
+		Type:                workerDto.TaskTypeReplicate,
+		TaskID:              taskID,
+		BackupID:            backup.ID().String(),
+		JobID:               uuid.New().String(),
+		Destination:         backup.Destination(),
+		HostPath:            host.Path(),
+		BackupRoot:          root,
+		Incremental:         backup.Incremental(),
+		Encrypted:           backup.Encrypted(),
+		Replica:             &endpoint,
+		ReplicatedSnapshots: replicated,
+		ReplicaRetention:    retention,
+	}
+
+	data, err := json.Marshal(task)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal replicate task: %w", err)
+	}
+
+	if err := p.client.RPush(ctx, p.queue, data).Err(); err != nil {
+		return "", fmt.Errorf("failed to publish replicate task to redis: %w", err)
+	}
+
+	return taskID, nil
+}
+
+// PublishDiskUsageTask asks a worker how full the filesystem holding path
+// is, its backup root when path is empty. The answer comes on the sync
+// response channel.
+func (p *RedisPublisher) PublishDiskUsageTask(ctx context.Context, path string) (string, error) {
+	taskID := uuid.New().String()
+
+	task := workerDto.WorkerTask{
+		Type:   workerDto.TaskTypeGetDiskUsage,
+		TaskID: taskID,
+		Path:   path,
+	}
+
+	data, err := json.Marshal(task)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal disk usage task: %w", err)
+	}
+
+	if err := p.client.RPush(ctx, p.queue, data).Err(); err != nil {
+		return "", fmt.Errorf("failed to publish disk usage task to redis: %w", err)
+	}
+
+	return taskID, nil
+}
+
+// PublishMigrateStorageTask asks a worker to move the data of a backup to
+// root, the root of the storage pool poolID or the backup root of the worker
+// when both are empty.
+func (p *RedisPublisher) PublishMigrateStorageTask(ctx context.Context, backup *entities.Backup, root string, poolID string) (string, error) {
+	host, err := p.hostRepo.Get(ctx, backup.HostID())
+	if err != nil {
+		return "", fmt.Errorf("failed to get host: %w", err)
+	}
+	current, err := p.backupRoot(ctx, backup)
+	if err != nil {
+		return "", err
+	}
+
+	taskID := uuid.New().String()
+	task := workerDto.WorkerTask{
+		Type:          workerDto.TaskTypeMigrateStorage,
+		TaskID:        taskID,
+		BackupID:      backup.ID().String(),
+		JobID:         uuid.New().String(),
+		Destination:   backup.Destination(),
+		HostPath:      host.Path(),
+		BackupRoot:    current,
+		Incremental:   backup.Incremental(),
+		Encrypted:     backup.Encrypted(),
+		MigrateRoot:   root,
+		StoragePoolID: poolID,
+	}
+
+	data, err := json.Marshal(task)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal migrate storage task: %w", err)
+	}
+
+	if err := p.client.RPush(ctx, p.queue, data).Err(); err != nil {
+		return "", fmt.Errorf("failed to publish migrate storage task to redis: %w", err)
+	}
+
+	return taskID, nil
+}
+
+// PublishTierTask asks a worker to move the snapshots of a backup older than
+// its tiering policy allows into coldRoot, the root of the cold storage pool
+// of the policy, or off its storage when its replica target holds them, as
+// listed in replicated.
+func (p *RedisPublisher) PublishTierTask(ctx context.Context, backup *entities.Backup, policy valueobjects.TieringPolicy, coldRoot string, replicated []string) (string, error) {
+	host, err := p.hostRepo.Get(ctx, backup.HostID())
+	if err != nil {
+		return "", fmt.Errorf("failed to get host: %w", err)
+	}
+	root, err := p.backupRoot(ctx, backup)
+	if err != nil {
+		return "", err
+	}
+
+	taskID := uuid.New().String()
+	task := workerDto.WorkerTask{
+		Type:                workerDto.TaskTypeTier,
+		TaskID:              taskID,
+		BackupID:            backup.ID().String(),
+		JobID:               uuid.New().String(),
+		Destination:         backup.Destination(),
+		HostPath:            host.Path(),
+		BackupRoot:          root,
+		Incremental:         backup.Incremental(),
+		Encrypted:           backup.Encrypted(),
+		TieringPolicy:       &policy,
+		ColdRoot:            coldRoot,
+		ReplicatedSnapshots: replicated,
+	}
+
+	data, err := json.Marshal(task)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal tier task: %w", err)
+	}
+
+	if err := p.client.RPush(ctx, p.queue, data).Err(); err != nil {
+		return "", fmt.Errorf("failed to publish tier task to redis: %w", err)
+	}
+
+	return taskID, nil
+}
+
+// PublishDeleteDataTask asks a worker to remove path under root for good,
+// with the archives of the backup in cold storage pools. It serves both the
+// data of a deleted backup and orphaned data, where backupID is empty.
+func (p *RedisPublisher) PublishDeleteDataTask(ctx context.Context, backupID string, root string, path string, cold []valueobjects.ColdLocation) (string, error) {
+	taskID := uuid.New().String()
+	task := workerDto.WorkerTask{
+		Type:         workerDto.TaskTypeDeleteData,
+		TaskID:       taskID,
+		BackupID:     backupID,
+		JobID:        uuid.New().String(),
+		Path:         path,
+		BackupRoot:   root,
+		ColdArchives: cold,
+	}
+
+	data, err := json.Marshal(task)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal delete data task: %w", err)
+	}
+
+	if err := p.client.RPush(ctx, p.queue, data).Err(); err != nil {
+		return "", fmt.Errorf("failed to publish delete data task to redis: %w", err)
+	}
+
+	return taskID, nil
+}
+
+// PublishScanOrphansTask asks a worker what lies under roots that none of
+// the referenced directories holds. The answer comes on the sync response
+// channel.
+func (p *RedisPublisher) PublishScanOrphansTask(ctx context.Context, roots []string, referenced []string) (string, error) {
+	taskID := uuid.New().String()
+	task := workerDto.WorkerTask{
+		Type:           workerDto.TaskTypeScanOrphans,
+		TaskID:         taskID,
+		ScanRoots:      roots,
+		ReferencedDirs: referenced,
+	}
+
+	data, err := json.Marshal(task)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal scan orphans task: %w", err)
+	}
+
+	if err := p.client.RPush(ctx, p.queue, data).Err(); err != nil {
+		return "", fmt.Errorf("failed to publish scan orphans task to redis: %w", err)
+	}
+
+	return taskID, nil
+}
+
+// PublishPurgeTask asks a worker to apply the retention policy of a backup,
+// to its snapshots and to those archived in cold storage pools.
+func (p *RedisPublisher) PublishPurgeTask(ctx context.Context, backup *entities.Backup, pinned []string, cold []valueobjects.ColdLocation) error {
 	host, err := p.hostRepo.Get(ctx, backup.HostID())
 	if err != nil {
 		return fmt.Errorf("failed to get host: %w", err)
 	}
+	root, err := p.backupRoot(ctx, backup)
+	if err != nil {
+		return err
+	}
 
+	policy := backup.RetentionPolicy()
 	task := workerDto.WorkerTask{
-		Type:        workerDto.TaskTypePurge,
-		TaskID:      uuid.New().String(),
-		JobID:       uuid.New().String(),
-		Host:        host.Hostname(),
-		User:        host.User(),
-		Port:        host.Port(),
-		Path:        backup.Path(),
-		Destination: backup.Destination(),
-		HostPath:    host.Path(),
-		Incremental: backup.Incremental(),
-		Retention:   backup.Retention(),
+		Type:            workerDto.TaskTypePurge,
+		TaskID:          uuid.New().String(),
+		BackupID:        backup.ID().String(),
+		JobID:           uuid.New().String(),
+		Host:            host.Hostname(),
+		User:            host.User(),
+		Port:            host.Port(),
+		Path:            backup.Path(),
+		Destination:     backup.Destination(),
+		HostPath:        host.Path(),
+		BackupRoot:      root,
+		Incremental:     backup.Incremental(),
+		Retention:       backup.Retention(),
+		Encrypted:       backup.Encrypted(),
+		RetentionPolicy: &policy,
+		PinnedSnapshots: pinned,
+		LegalHold:       entities.UnderLegalHold(backup, host),
+		ColdArchives:    cold,
 	}
 
 	data, err := json.Marshal(task)
@@ -234,19 +638,20 @@ func (p *RedisPublisher) createWorkerTask(backup *entities.Backup, host *entitie
 	}
 
 	return workerDto.WorkerTask{
-		Type:        workerDto.TaskTypeBackup,
-		TaskID:      backup.ID().String(),
-		JobID:       uuid.New().String(),
-		Host:        host.Hostname(),
-		User:        host.User(),
-		Port:        host.Port(),
-		Path:        backup.Path(),
-		Destination: backup.Destination(),
-		Excludes:    backup.Excludes(),
-		HostPath:    host.Path(),
-		Incremental: backup.Incremental(),
-		Retention:   backup.Retention(),
-		Encrypted:   backup.Encrypted(),
-		Hooks:       hooks,
+		Type:         workerDto.TaskTypeBackup,
+		TaskID:       backup.ID().String(),
+		JobID:        uuid.New().String(),
+		Host:         host.Hostname(),
+		User:         host.User(),
+		Port:         host.Port(),
+		Path:         backup.Path(),
+		Destination:  backup.Destination(),
+		Excludes:     backup.Excludes(),
+		HostPath:     host.Path(),
+		Incremental:  backup.Incremental(),
+		Retention:    backup.Retention(),
+		Encrypted:    backup.Encrypted(),
+		ContentIndex: backup.ContentIndex(),
+		Hooks:        hooks,
 	}
 }
@@ -1,13 +1,16 @@
 package scheduler
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
 	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/backup/infrastructure/persistence/memory"
 	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRedisPublisher_createWorkerTask(t *testing.T) {
@@ -29,7 +32,7 @@ func TestRedisPublisher_createWorkerTask(t *testing.T) {
 		[]string{"*.tmp"},
 		true,
 		false,
-		"",
+		valueobjects.SpaceUsage{},
 		0,
 		false,
 	)
@@ -48,3 +51,29 @@ func TestRedisPublisher_createWorkerTask(t *testing.T) {
 	assert.Equal(t, []string{"*.tmp"}, task.Excludes)
 	assert.Equal(t, "host/path", task.HostPath)
 }
+
+func TestRedisPublisher_quotaBudget(t *testing.T) {
+	ctx := context.Background()
+	backupRepo := memory.NewBackupRepositoryMemoryEmpty()
+	host := entities.NewHost("Test Host", "example.com", "user", 22, "host/path", false)
+	backup, err := entities.NewBackup(host.ID(), "/srv", "srv", entities.NewBackupSchedule("@daily"), nil, true, 5, false)
+	require.NoError(t, err)
+	backup.SetUsage(valueobjects.SpaceUsage{Stored: 300})
+	other, err := entities.NewBackup(host.ID(), "/etc", "etc", entities.NewBackupSchedule("@daily"), nil, true, 5, false)
+	require.NoError(t, err)
+	other.SetUsage(valueobjects.SpaceUsage{Stored: 600})
+	require.NoError(t, backupRepo.Save(ctx, backup))
+	require.NoError(t, backupRepo.Save(ctx, other))
+
+	publisher := &RedisPublisher{backupRepo: backupRepo}
+
+	budget, err := publisher.quotaBudget(ctx, backup, host)
+	require.NoError(t, err)
+	assert.Nil(t, budget, "no budget without quotas")
+
+	host.SetQuota(valueobjects.Quota{Bytes: 1000})
+	budget, err = publisher.quotaBudget(ctx, backup, host)
+	require.NoError(t, err)
+	require.NotNil(t, budget)
+	assert.Equal(t, valueobjects.QuotaBudget{Bytes: 100, Scope: valueobjects.QuotaScopeHost}, *budget)
+}
@@ -13,30 +13,49 @@ import (
 	"github.com/rrbarrero/justbackup/internal/backup/domain/events"
 	"github.com/rrbarrero/justbackup/internal/backup/domain/interfaces"
 	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	maintApp "github.com/rrbarrero/justbackup/internal/maintenance/application"
 	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/event"
 	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/websocket"
 	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
 )
 
 type ResultConsumer struct {
-	client          *redis.Client
-	queue           string
-	backupRepo      interfaces.BackupRepository
-	hostService     *application.HostService
-	backupErrorRepo interfaces.BackupErrorRepository
-	hub             *websocket.Hub
-	eventBus        *event.RedisEventBus
+	client             *redis.Client
+	queue              string
+	backupRepo         interfaces.BackupRepository
+	hostService        *application.HostService
+	backupErrorRepo    interfaces.BackupErrorRepository
+	catalogRepo        interfaces.FileCatalogRepository
+	publisher          interfaces.TaskPublisher
+	replicationService *application.ReplicationService
+	replicaService     *application.ReplicaService
+	storagePoolService *application.StoragePoolService
+	tieringService     *application.TieringService
+	sizeHistoryService *application.SizeHistoryService
+	anomalyService     *application.AnomalyService
+	maintService       *maintApp.MaintenanceService
+	hub                *websocket.Hub
+	eventBus           *event.RedisEventBus
 }
 
-func NewResultConsumer(client *redis.Client, queue string, backupRepo interfaces.BackupRepository, hostService *application.HostService, backupErrorRepo interfaces.BackupErrorRepository, hub *websocket.Hub, eventBus *event.RedisEventBus) *ResultConsumer {
+func NewResultConsumer(client *redis.Client, queue string, backupRepo interfaces.BackupRepository, hostService *application.HostService, backupErrorRepo interfaces.BackupErrorRepository, catalogRepo interfaces.FileCatalogRepository, publisher interfaces.TaskPublisher, replicationService *application.ReplicationService, replicaService *application.ReplicaService, storagePoolService *application.StoragePoolService, tieringService *application.TieringService, sizeHistoryService *application.SizeHistoryService, anomalyService *application.AnomalyService, maintService *maintApp.MaintenanceService, hub *websocket.Hub, eventBus *event.RedisEventBus) *ResultConsumer {
 	return &ResultConsumer{
-		client:          client,
-		queue:           queue,
-		backupRepo:      backupRepo,
-		hostService:     hostService,
-		backupErrorRepo: backupErrorRepo,
-		hub:             hub,
-		eventBus:        eventBus,
+		client:             client,
+		queue:              queue,
+		backupRepo:         backupRepo,
+		hostService:        hostService,
+		backupErrorRepo:    backupErrorRepo,
+		catalogRepo:        catalogRepo,
+		publisher:          publisher,
+		replicationService: replicationService,
+		replicaService:     replicaService,
+		storagePoolService: storagePoolService,
+		tieringService:     tieringService,
+		sizeHistoryService: sizeHistoryService,
+		anomalyService:     anomalyService,
+		maintService:       maintService,
+		hub:                hub,
+		eventBus:           eventBus,
 	}
 }
 
@@ -77,7 +96,37 @@ func (c *ResultConsumer) processResult(ctx context.Context, result workerDto.Wor
 	switch result.Type {
 	case workerDto.TaskTypeBackup:
 		return c.processBackupResult(ctx, result)
-	case workerDto.TaskTypeMeasureSize, workerDto.TaskTypeRestoreRemote, workerDto.TaskTypeRestoreLocal, workerDto.TaskTypeListFiles, workerDto.TaskTypePurge:
+	case workerDto.TaskTypeCatalog:
+		return c.processCatalogResult(ctx, result)
+	case workerDto.TaskTypePurge:
+		if err := c.pruneCatalog(ctx, result); err != nil {
+			log.Printf("Failed to prune file catalog for task %s: %v", result.TaskID, err)
+		}
+		if err := c.processPurgeUsage(ctx, result); err != nil {
+			log.Printf("Failed to record space usage for task %s: %v", result.TaskID, err)
+		}
+		return c.processGenericResult(ctx, result)
+	case workerDto.TaskTypeRestoreRemote:
+		if err := c.processReplicationResult(ctx, result); err != nil {
+			log.Printf("Failed to record replication run for task %s: %v", result.TaskID, err)
+		}
+		return c.processGenericResult(ctx, result)
+	case workerDto.TaskTypeReplicate:
+		if err := c.processReplicaResult(ctx, result); err != nil {
+			log.Printf("Failed to record replicated snapshots for task %s: %v", result.TaskID, err)
+		}
+		return c.processGenericResult(ctx, result)
+	case workerDto.TaskTypeMigrateStorage:
+		if err := c.processMigrationResult(ctx, result); err != nil {
+			log.Printf("Failed to record storage migration for task %s: %v", result.TaskID, err)
+		}
+		return c.processGenericResult(ctx, result)
+	case workerDto.TaskTypeTier:
+		if err := c.processTierResult(ctx, result); err != nil {
+			log.Printf("Failed to record tiered snapshots for task %s: %v", result.TaskID, err)
+		}
+		return c.processGenericResult(ctx, result)
+	case workerDto.TaskTypeMeasureSize, workerDto.TaskTypeRestoreLocal, workerDto.TaskTypeRestoreDownload, workerDto.TaskTypeListFiles, workerDto.TaskTypeDeleteData:
 		// These types only need to be stored in Redis for the requester to pick up,
 		// or they were already handled by another mechanism.
 		// Storing them avoids the "unknown result" error.
@@ -98,6 +147,131 @@ func (c *ResultConsumer) processGenericResult(ctx context.Context, result worker
 	return c.client.Set(ctx, key, data, 10*time.Minute).Err()
 }
 
+// processCatalogResult stores one batch of the file catalog of a snapshot,
+// and the file text it carries in the content index. The first batch
+// replaces what was cataloged for the snapshot before.
+func (c *ResultConsumer) processCatalogResult(ctx context.Context, result workerDto.WorkerResult) error {
+	var batch workerDto.CatalogBatch
+	if err := decodeResultData(result, &batch); err != nil {
+		return err
+	}
+
+	backupID, err := valueobjects.NewBackupIDFromString(batch.BackupID)
+	if err != nil {
+		return fmt.Errorf("invalid backup ID: %w", err)
+	}
+
+	if batch.Sequence == 0 {
+		if err := c.catalogRepo.DeleteSnapshot(ctx, backupID, batch.Snapshot); err != nil {
+			return err
+		}
+	}
+
+	entries := make([]*entities.CatalogEntry, 0, len(batch.Files))
+	var contents []*entities.FileContent
+	for _, f := range batch.Files {
+		entries = append(entries, &entities.CatalogEntry{
+			BackupID: backupID,
+			Snapshot: batch.Snapshot,
+			Path:     f.Path,
+			Size:     f.Size,
+			ModTime:  f.ModTime,
+			Hash:     f.Hash,
+		})
+		if f.Content != "" {
+			contents = append(contents, &entities.FileContent{BackupID: backupID, Hash: f.Hash, Content: f.Content})
+		}
+	}
+	if err := c.catalogRepo.SaveBatch(ctx, entries); err != nil {
+		return err
+	}
+	if err := c.catalogRepo.SaveContents(ctx, contents); err != nil {
+		return err
+	}
+
+	// Text of versions the snapshot no longer holds can go once it is
+	// fully cataloged again.
+	if batch.Final {
+		return c.catalogRepo.PruneContents(ctx, backupID)
+	}
+	return nil
+}
+
+// pruneCatalog drops the catalog of the snapshots a purge removed, and the
+// indexed text only they held, and forgets those that were archived.
+func (c *ResultConsumer) pruneCatalog(ctx context.Context, result workerDto.WorkerResult) error {
+	if result.Data == nil {
+		return nil
+	}
+	var report workerDto.PurgeResult
+	if err := decodeResultData(result, &report); err != nil {
+		return err
+	}
+	if report.BackupID == "" || len(report.Purged) == 0 {
+		return nil
+	}
+
+	backupID, err := valueobjects.NewBackupIDFromString(report.BackupID)
+	if err != nil {
+		return fmt.Errorf("invalid backup ID: %w", err)
+	}
+	if c.tieringService != nil {
+		if err := c.tieringService.ForgetSnapshots(ctx, report.BackupID, report.Purged); err != nil {
+			return err
+		}
+	}
+	for _, snapshot := range report.Purged {
+		if err := c.catalogRepo.DeleteSnapshot(ctx, backupID, snapshot); err != nil {
+			return err
+		}
+	}
+	return c.catalogRepo.PruneContents(ctx, backupID)
+}
+
+// processPurgeUsage records the stored size a purge measured.
+func (c *ResultConsumer) processPurgeUsage(ctx context.Context, result workerDto.WorkerResult) error {
+	if result.Data == nil {
+		return nil
+	}
+	var report workerDto.PurgeResult
+	if err := decodeResultData(result, &report); err != nil {
+		return err
+	}
+	return c.recordStoredSize(ctx, report.BackupID, report.StoredSize)
+}
+
+// recordStoredSize replaces the stored size of a backup with one measured by
+// a scheduled task. Backup runs only add what their snapshot holds to it.
+func (c *ResultConsumer) recordStoredSize(ctx context.Context, id string, stored *int64) error {
+	if id == "" || stored == nil {
+		return nil
+	}
+	backupID, err := valueobjects.NewBackupIDFromString(id)
+	if err != nil {
+		return fmt.Errorf("invalid backup ID: %w", err)
+	}
+	backup, err := c.backupRepo.FindByID(ctx, backupID)
+	if err != nil {
+		return err
+	}
+	usage := backup.Usage()
+	usage.Stored = *stored
+	backup.SetUsage(usage)
+	return c.backupRepo.Save(ctx, backup)
+}
+
+// decodeResultData converts the generic Data of a result into out.
+func decodeResultData(result workerDto.WorkerResult, out interface{}) error {
+	data, err := json.Marshal(result.Data)
+	if err != nil {
+		return fmt.Errorf("failed to re-marshal result data: %w", err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to unmarshal %s result: %w", result.Type, err)
+	}
+	return nil
+}
+
 func (c *ResultConsumer) processBackupResult(ctx context.Context, result workerDto.WorkerResult) error {
 	backupID, err := valueobjects.NewBackupIDFromString(result.TaskID)
 	if err != nil {
@@ -117,19 +291,24 @@ func (c *ResultConsumer) processBackupResult(ctx context.Context, result workerD
 		log.Printf("Failed to fetch host info for backup %s: %v", backup.ID(), err)
 	}
 
+	var usage workerDto.BackupResult
+	measured := false
 	if result.Status == "completed" {
 		if err := backup.Complete(); err != nil {
 			log.Printf("Failed to complete backup %s: %v", backup.ID(), err)
 		}
-		size := ""
-		if dataMap, ok := result.Data.(map[string]interface{}); ok {
-			if s, ok := dataMap["size"].(string); ok {
-				backup.SetSize(s)
-				size = s
+		if err := decodeResultData(result, &usage); err != nil {
+			log.Printf("Failed to read space usage of backup %s: %v", backup.ID(), err)
+		} else {
+			stored := usage.StoredSize
+			if backup.Incremental() {
+				stored = backup.Usage().Stored + usage.AddedSize
 			}
+			backup.SetUsage(valueobjects.SpaceUsage{Size: usage.Size, Unique: usage.UniqueSize, Stored: stored})
+			measured = true
 		}
 		// Publish BackupCompleted event
-		event := events.NewBackupCompleted(backup.ID().String(), backup.HostID().String(), hostName, backup.Path(), size)
+		event := events.NewBackupCompleted(backup.ID().String(), backup.HostID().String(), hostName, backup.Path(), usage.Size, usage.UniqueSize)
 		if err := c.eventBus.Publish(ctx, event); err != nil {
 			log.Printf("Failed to publish BackupCompleted event: %v", err)
 		}
@@ -151,6 +330,39 @@ func (c *ResultConsumer) processBackupResult(ctx context.Context, result workerD
 		return fmt.Errorf("failed to save backup: %w", err)
 	}
 
+	if result.Status != "completed" && result.Data != nil {
+		var quota workerDto.QuotaExceededResult
+		if err := decodeResultData(result, &quota); err == nil && quota.Scope != "" {
+			c.retainEarly(ctx, backup, valueobjects.QuotaScope(quota.Scope))
+		}
+	}
+
+	changes := valueobjects.FileChanges{Files: usage.Files, Changed: usage.ChangedFiles}
+	// A run without a usage report would look like a snapshot shrunk to nothing.
+	if measured && c.anomalyService != nil {
+		if _, err := c.anomalyService.Check(ctx, backup, hostName, changes); err != nil {
+			log.Printf("Failed to check backup %s for anomalies: %v", backup.ID(), err)
+		}
+	}
+
+	if result.Status == "completed" && c.sizeHistoryService != nil {
+		if err := c.sizeHistoryService.Record(ctx, backup, changes); err != nil {
+			log.Printf("Failed to record size history of backup %s: %v", backup.ID(), err)
+		}
+	}
+
+	if result.Status == "completed" && c.replicaService != nil {
+		c.replicaService.OnBackupCompleted(ctx, backup)
+	}
+
+	// The new snapshot is cataloged by a task of its own; search only
+	// misses it until that has run.
+	if result.Status == "completed" && c.publisher != nil {
+		if _, err := c.publisher.PublishCatalogTask(ctx, backup, usage.Snapshot); err != nil {
+			log.Printf("Failed to publish catalog task for backup %s: %v", backup.ID(), err)
+		}
+	}
+
 	// Broadcast result
 	msg := map[string]string{
 		"type":      "backup_completed",
@@ -168,3 +380,177 @@ func (c *ResultConsumer) processBackupResult(ctx context.Context, result workerD
 
 	return nil
 }
+
+// retainEarly applies retention right away to the backups under a quota a
+// run has broken, so the next run finds room again.
+func (c *ResultConsumer) retainEarly(ctx context.Context, backup *entities.Backup, scope valueobjects.QuotaScope) {
+	if c.maintService == nil {
+		return
+	}
+	backups := []*entities.Backup{backup}
+	if scope == valueobjects.QuotaScopeHost {
+		hostBackups, err := c.backupRepo.FindByHostID(ctx, backup.HostID())
+		if err != nil {
+			log.Printf("Failed to load backups of host %s for early retention: %v", backup.HostID(), err)
+			return
+		}
+		backups = hostBackups
+	}
+	queued := c.maintService.ApplyRetention(ctx, backups)
+	log.Printf("Backup %s broke its %s quota, queued early purge of %d backup(s)", backup.ID(), scope, queued)
+}
+
+// processReplicationResult finishes the replication run a remote restore
+// belongs to, if any, and notifies about its outcome like a backup run.
+func (c *ResultConsumer) processReplicationResult(ctx context.Context, result workerDto.WorkerResult) error {
+	if c.replicationService == nil {
+		return nil
+	}
+	succeeded := result.Status == "completed"
+	job, err := c.replicationService.RecordResult(ctx, result.TaskID, succeeded, result.Message)
+	if err != nil || job == nil {
+		return err
+	}
+
+	targetHost := "Unknown"
+	hostResp, err := c.hostService.GetHost(ctx, job.TargetHostID().String())
+	if err == nil {
+		targetHost = hostResp.Name
+	} else {
+		log.Printf("Failed to fetch target host info for replication job %s: %v", job.ID(), err)
+	}
+
+	msg := map[string]string{
+		"type":    "replication_completed",
+		"job_id":  job.ID().String(),
+		"task_id": result.TaskID,
+		"status":  result.Status,
+	}
+	if succeeded {
+		event := events.NewReplicationCompleted(job.ID().String(), job.Name(), job.BackupID().String(), targetHost, job.TargetPath())
+		if err := c.eventBus.Publish(ctx, event); err != nil {
+			log.Printf("Failed to publish ReplicationCompleted event: %v", err)
+		}
+	} else {
+		msg["type"] = "replication_failed"
+		event := events.NewReplicationFailed(job.ID().String(), job.Name(), job.BackupID().String(), targetHost, job.TargetPath(), result.Message)
+		if err := c.eventBus.Publish(ctx, event); err != nil {
+			log.Printf("Failed to publish ReplicationFailed event: %v", err)
+		}
+	}
+
+	data, err := json.Marshal(msg)
+	if err == nil {
+		c.hub.Broadcast(data)
+	}
+	return nil
+}
+
+// processReplicaResult records the snapshots a replicate task copied and
+// notifies when it failed.
+func (c *ResultConsumer) processReplicaResult(ctx context.Context, result workerDto.WorkerResult) error {
+	if c.replicaService == nil || result.Data == nil {
+		return nil
+	}
+	var report workerDto.ReplicateResult
+	if err := decodeResultData(result, &report); err != nil {
+		return err
+	}
+	target, err := c.replicaService.RecordResult(ctx, report)
+	if err != nil {
+		return err
+	}
+
+	msg := map[string]string{
+		"type":      "replica_completed",
+		"target_id": report.TargetID,
+		"backup_id": report.BackupID,
+		"task_id":   result.TaskID,
+		"status":    result.Status,
+	}
+	if result.Status != "completed" {
+		msg["type"] = "replica_failed"
+		event := events.NewReplicaFailed(report.TargetID, target.Name(), report.BackupID, result.Message)
+		if err := c.eventBus.Publish(ctx, event); err != nil {
+			log.Printf("Failed to publish ReplicaFailed event: %v", err)
+		}
+	}
+
+	data, err := json.Marshal(msg)
+	if err == nil {
+		c.hub.Broadcast(data)
+	}
+	return nil
+}
+
+// processMigrationResult switches a backup to the storage pool its data was
+// moved to, and notifies about the outcome of the move.
+func (c *ResultConsumer) processMigrationResult(ctx context.Context, result workerDto.WorkerResult) error {
+	if c.storagePoolService == nil || result.Data == nil {
+		return nil
+	}
+	var report workerDto.MigrateStorageResult
+	if err := decodeResultData(result, &report); err != nil {
+		return err
+	}
+	if result.Status == "completed" {
+		if _, err := c.storagePoolService.RecordMigration(ctx, report); err != nil {
+			return err
+		}
+	} else if err := c.storagePoolService.RecordFailedMigration(ctx, report); err != nil {
+		return err
+	}
+
+	msg := map[string]string{
+		"type":            "storage_migrated",
+		"backup_id":       report.BackupID,
+		"storage_pool_id": report.StoragePoolID,
+		"task_id":         result.TaskID,
+		"status":          result.Status,
+	}
+	if result.Status != "completed" {
+		msg["type"] = "storage_migration_failed"
+		msg["message"] = result.Message
+	}
+
+	data, err := json.Marshal(msg)
+	if err == nil {
+		c.hub.Broadcast(data)
+	}
+	return nil
+}
+
+// processTierResult records the snapshots a tier task moved off the storage
+// of a backup, and notifies about the outcome.
+func (c *ResultConsumer) processTierResult(ctx context.Context, result workerDto.WorkerResult) error {
+	if c.tieringService == nil || result.Data == nil {
+		return nil
+	}
+	var report workerDto.TierResult
+	if err := decodeResultData(result, &report); err != nil {
+		return err
+	}
+	if err := c.tieringService.RecordResult(ctx, report); err != nil {
+		return err
+	}
+	if err := c.recordStoredSize(ctx, report.BackupID, report.StoredSize); err != nil {
+		log.Printf("Failed to record space usage of backup %s: %v", report.BackupID, err)
+	}
+
+	msg := map[string]string{
+		"type":      "snapshots_tiered",
+		"backup_id": report.BackupID,
+		"task_id":   result.TaskID,
+		"status":    result.Status,
+	}
+	if result.Status != "completed" {
+		msg["type"] = "tiering_failed"
+		msg["message"] = result.Message
+	}
+
+	data, err := json.Marshal(msg)
+	if err == nil {
+		c.hub.Broadcast(data)
+	}
+	return nil
+}
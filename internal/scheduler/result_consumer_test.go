@@ -3,10 +3,22 @@ package scheduler
 import (
 	"context"
 	"encoding/json"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/redis/go-redis/v9"
+	"github.com/rrbarrero/justbackup/internal/backup/application"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/interfaces"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/backup/infrastructure/persistence/memory"
+	maintApp "github.com/rrbarrero/justbackup/internal/maintenance/application"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/event"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/websocket"
 	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestProcessResult_UnknownType(t *testing.T) {
@@ -55,8 +67,199 @@ func TestWorkerResultSerialization(t *testing.T) {
 }
 
 func TestNewResultConsumer(t *testing.T) {
-	consumer := NewResultConsumer(nil, "test_queue", nil, nil, nil, nil, nil)
+	consumer := NewResultConsumer(nil, "test_queue", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 
 	assert.NotNil(t, consumer)
 	assert.Equal(t, "test_queue", consumer.queue)
 }
+
+func TestProcessResult_Catalog(t *testing.T) {
+	ctx := context.Background()
+	catalogRepo := memory.NewFileCatalogRepositoryMemory()
+	consumer := &ResultConsumer{catalogRepo: catalogRepo}
+	backupID := valueobjects.NewBackupID()
+	modTime := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	batch := func(snapshot string, sequence int, paths ...string) workerDto.WorkerResult {
+		files := make([]workerDto.CatalogFile, 0, len(paths))
+		for _, p := range paths {
+			files = append(files, workerDto.CatalogFile{Path: p, Size: 1, ModTime: modTime, Hash: "h"})
+		}
+		// Round-trip through JSON like a result read from Redis.
+		data, _ := json.Marshal(workerDto.WorkerResult{
+			Type:   workerDto.TaskTypeCatalog,
+			TaskID: backupID.String(),
+			Status: "completed",
+			Data:   workerDto.CatalogBatch{BackupID: backupID.String(), Snapshot: snapshot, Sequence: sequence, Files: files},
+		})
+		var result workerDto.WorkerResult
+		_ = json.Unmarshal(data, &result)
+		return result
+	}
+	paths := func() []string {
+		entries, _, err := catalogRepo.Search(ctx, valueobjects.CatalogFilter{})
+		assert.NoError(t, err)
+		var out []string
+		for _, e := range entries {
+			out = append(out, e.Snapshot+":"+e.Path)
+		}
+		return out
+	}
+
+	assert.NoError(t, consumer.processResult(ctx, batch("2024-01-01_10-00-00", 0, "a", "b")))
+	assert.NoError(t, consumer.processResult(ctx, batch("2024-01-01_10-00-00", 1, "c")))
+	assert.NoError(t, consumer.processResult(ctx, batch("2024-01-02_10-00-00", 0, "a")))
+	assert.Equal(t, []string{"2024-01-02_10-00-00:a", "2024-01-01_10-00-00:a", "2024-01-01_10-00-00:b", "2024-01-01_10-00-00:c"}, paths())
+
+	t.Run("the first batch replaces the snapshot", func(t *testing.T) {
+		assert.NoError(t, consumer.processResult(ctx, batch("2024-01-01_10-00-00", 0, "d")))
+		assert.Equal(t, []string{"2024-01-02_10-00-00:a", "2024-01-01_10-00-00:d"}, paths())
+	})
+
+	t.Run("a purge prunes the removed snapshots", func(t *testing.T) {
+		err := consumer.pruneCatalog(ctx, workerDto.WorkerResult{
+			Type: workerDto.TaskTypePurge,
+			Data: workerDto.PurgeResult{BackupID: backupID.String(), Purged: []string{"2024-01-01_10-00-00"}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"2024-01-02_10-00-00:a"}, paths())
+	})
+}
+
+func TestProcessResult_CatalogContent(t *testing.T) {
+	ctx := context.Background()
+	catalogRepo := memory.NewFileCatalogRepositoryMemory()
+	consumer := &ResultConsumer{catalogRepo: catalogRepo}
+	backupID := valueobjects.NewBackupID()
+
+	batch := func(snapshot string, content string) workerDto.WorkerResult {
+		return workerDto.WorkerResult{
+			Type: workerDto.TaskTypeCatalog,
+			Data: workerDto.CatalogBatch{BackupID: backupID.String(), Snapshot: snapshot, Final: true, Files: []workerDto.CatalogFile{
+				{Path: "etc/app.conf", Hash: content + "-hash", Content: content},
+			}},
+		}
+	}
+	grep := func(query string) []string {
+		matches, _, err := catalogRepo.SearchContents(ctx, valueobjects.ContentFilter{Query: query})
+		assert.NoError(t, err)
+		var out []string
+		for _, m := range matches {
+			out = append(out, strings.Join(m.Snapshots, ",")+":"+m.Path)
+		}
+		return out
+	}
+
+	assert.NoError(t, consumer.processResult(ctx, batch("2024-01-01_10-00-00", "api_key=old")))
+	assert.NoError(t, consumer.processResult(ctx, batch("2024-01-02_10-00-00", "api_key=new")))
+	assert.Equal(t, []string{"2024-01-02_10-00-00:etc/app.conf", "2024-01-01_10-00-00:etc/app.conf"}, grep("API_KEY"))
+
+	err := consumer.pruneCatalog(ctx, workerDto.WorkerResult{
+		Type: workerDto.TaskTypePurge,
+		Data: workerDto.PurgeResult{BackupID: backupID.String(), Purged: []string{"2024-01-01_10-00-00"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2024-01-02_10-00-00:etc/app.conf"}, grep("api_key"))
+	assert.Empty(t, grep("=old"))
+}
+
+type recordingPurgePublisher struct {
+	purged []string
+}
+
+func (p *recordingPurgePublisher) PublishPurgeTask(_ context.Context, backup *entities.Backup, _ []string, _ []valueobjects.ColdLocation) error {
+	p.purged = append(p.purged, backup.Path())
+	return nil
+}
+
+func TestRetainEarly(t *testing.T) {
+	ctx := context.Background()
+	backupRepo := memory.NewBackupRepositoryMemoryEmpty()
+	hostRepo := memory.NewHostRepositoryMemory()
+	host := entities.NewHost("Test Host", "example.com", "user", 22, "host/path", false)
+	require.NoError(t, hostRepo.Save(ctx, host))
+	backup, err := entities.NewBackup(host.ID(), "/srv", "srv", entities.NewBackupSchedule("@daily"), nil, true, 5, false)
+	require.NoError(t, err)
+	other, err := entities.NewBackup(host.ID(), "/etc", "etc", entities.NewBackupSchedule("@daily"), nil, true, 5, false)
+	require.NoError(t, err)
+	require.NoError(t, backupRepo.Save(ctx, backup))
+	require.NoError(t, backupRepo.Save(ctx, other))
+
+	publisher := &recordingPurgePublisher{}
+	maintService := maintApp.NewMaintenanceService(nil, backupRepo, hostRepo, memory.NewSnapshotPinRepositoryMemory(), publisher, nil, nil, nil)
+	consumer := &ResultConsumer{backupRepo: backupRepo, maintService: maintService}
+
+	consumer.retainEarly(ctx, backup, valueobjects.QuotaScopeBackup)
+	assert.Equal(t, []string{"/srv"}, publisher.purged)
+
+	publisher.purged = nil
+	consumer.retainEarly(ctx, backup, valueobjects.QuotaScopeHost)
+	assert.ElementsMatch(t, []string{"/srv", "/etc"}, publisher.purged)
+}
+
+func TestProcessPurgeUsage(t *testing.T) {
+	ctx := context.Background()
+	backupRepo := memory.NewBackupRepositoryMemoryEmpty()
+	backup, err := entities.NewBackup(entities.NewHostID(), "/srv", "srv", entities.NewBackupSchedule("@daily"), nil, true, 5, false)
+	require.NoError(t, err)
+	backup.SetUsage(valueobjects.SpaceUsage{Size: 400, Unique: 100, Stored: 1500})
+	require.NoError(t, backupRepo.Save(ctx, backup))
+	consumer := &ResultConsumer{backupRepo: backupRepo}
+
+	// A purge that did not measure leaves the stored size alone.
+	unmeasured := workerDto.WorkerResult{Type: workerDto.TaskTypePurge, Data: workerDto.PurgeResult{BackupID: backup.ID().String()}}
+	require.NoError(t, consumer.processPurgeUsage(ctx, unmeasured))
+	saved, err := backupRepo.FindByID(ctx, backup.ID())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1500), saved.Usage().Stored)
+
+	stored := int64(900)
+	measured := workerDto.WorkerResult{Type: workerDto.TaskTypePurge, Data: workerDto.PurgeResult{BackupID: backup.ID().String(), StoredSize: &stored}}
+	require.NoError(t, consumer.processPurgeUsage(ctx, measured))
+	saved, err = backupRepo.FindByID(ctx, backup.ID())
+	require.NoError(t, err)
+	assert.Equal(t, valueobjects.SpaceUsage{Size: 400, Unique: 100, Stored: 900}, saved.Usage())
+}
+
+type recordingCatalogPublisher struct {
+	interfaces.TaskPublisher
+	snapshots []string
+}
+
+func (p *recordingCatalogPublisher) PublishCatalogTask(_ context.Context, _ *entities.Backup, snapshot string) (string, error) {
+	p.snapshots = append(p.snapshots, snapshot)
+	return "catalog-1", nil
+}
+
+func TestProcessBackupResult_QueuesCatalog(t *testing.T) {
+	ctx := context.Background()
+	backupRepo := memory.NewBackupRepositoryMemoryEmpty()
+	hostRepo := memory.NewHostRepositoryMemory()
+	host := entities.NewHost("Test Host", "example.com", "user", 22, "host/path", false)
+	require.NoError(t, hostRepo.Save(ctx, host))
+	backup, err := entities.NewBackup(host.ID(), "/srv", "srv", entities.NewBackupSchedule("@daily"), nil, true, 5, false)
+	require.NoError(t, err)
+	require.NoError(t, backupRepo.Save(ctx, backup))
+
+	// Nothing listens for the events; publishing them only fails.
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 10 * time.Millisecond})
+	defer client.Close()
+	publisher := &recordingCatalogPublisher{}
+	consumer := &ResultConsumer{
+		backupRepo:  backupRepo,
+		hostService: application.NewHostService(hostRepo, backupRepo),
+		publisher:   publisher,
+		hub:         websocket.NewHub(),
+		eventBus:    event.NewRedisEventBus(client),
+	}
+
+	completed := workerDto.WorkerResult{Type: workerDto.TaskTypeBackup, TaskID: backup.ID().String(), Status: "completed", Data: workerDto.BackupResult{Snapshot: "2026-10-18_120000"}}
+	require.NoError(t, consumer.processBackupResult(ctx, completed))
+	assert.Equal(t, []string{"2026-10-18_120000"}, publisher.snapshots)
+
+	// A failed run took no snapshot to catalog.
+	failed := workerDto.WorkerResult{Type: workerDto.TaskTypeBackup, TaskID: backup.ID().String(), Status: "failed", Message: "rsync failed"}
+	consumer.backupErrorRepo = memory.NewBackupErrorRepositoryMemory()
+	require.NoError(t, consumer.processBackupResult(ctx, failed))
+	assert.Equal(t, []string{"2026-10-18_120000"}, publisher.snapshots)
+}
@@ -5,23 +5,26 @@ import (
 	"log"
 	"time"
 
+	"github.com/rrbarrero/justbackup/internal/backup/application"
 	"github.com/rrbarrero/justbackup/internal/backup/domain/interfaces"
 	maintApp "github.com/rrbarrero/justbackup/internal/maintenance/application"
 )
 
 type Scheduler struct {
-	repo         interfaces.BackupRepository
-	maintService *maintApp.MaintenanceService
-	publisher    *RedisPublisher
-	interval     time.Duration
+	repo               interfaces.BackupRepository
+	maintService       *maintApp.MaintenanceService
+	replicationService *application.ReplicationService
+	publisher          *RedisPublisher
+	interval           time.Duration
 }
 
-func NewScheduler(repo interfaces.BackupRepository, maintService *maintApp.MaintenanceService, publisher *RedisPublisher, interval time.Duration) *Scheduler {
+func NewScheduler(repo interfaces.BackupRepository, maintService *maintApp.MaintenanceService, replicationService *application.ReplicationService, publisher *RedisPublisher, interval time.Duration) *Scheduler {
 	return &Scheduler{
-		repo:         repo,
-		maintService: maintService,
-		publisher:    publisher,
-		interval:     interval,
+		repo:               repo,
+		maintService:       maintService,
+		replicationService: replicationService,
+		publisher:          publisher,
+		interval:           interval,
 	}
 }
 
@@ -45,6 +48,11 @@ func (s *Scheduler) Start(ctx context.Context) {
 					log.Printf("Error processing due maintenance tasks: %v", err)
 				}
 			}
+			if s.replicationService != nil {
+				if err := s.replicationService.ProcessDueJobs(ctx); err != nil {
+					log.Printf("Error processing due replication jobs: %v", err)
+				}
+			}
 		}
 	}
 }
@@ -56,6 +64,10 @@ func (s *Scheduler) processDueBackups(ctx context.Context) error {
 	}
 
 	for _, backup := range backups {
+		if backup.Migrating() {
+			// Run once its data is in place; it stays due until then.
+			continue
+		}
 		log.Printf("Processing due backup: %s", backup.ID())
 
 		// Publish to Redis
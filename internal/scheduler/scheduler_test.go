@@ -15,7 +15,7 @@ func TestScheduler_ProcessDueBackups(t *testing.T) {
 	redisPublisher := &RedisPublisher{} // We'll need a mock or test instance
 
 	// Create a scheduler
-	_ = NewScheduler(backupRepo, nil, redisPublisher, 1*time.Minute)
+	_ = NewScheduler(backupRepo, nil, nil, redisPublisher, 1*time.Minute)
 
 	// This test would require a real Redis connection and the Backup entity
 	// to have SetNextRunAt method. Skipping for now.
@@ -27,7 +27,7 @@ func TestScheduler_Start_Context_Cancellation(t *testing.T) {
 	// Setup
 	backupRepo := memory.NewBackupRepositoryMemoryEmpty()
 	redisPublisher := &RedisPublisher{} // Mock
-	scheduler := NewScheduler(backupRepo, nil, redisPublisher, 100*time.Millisecond)
+	scheduler := NewScheduler(backupRepo, nil, nil, redisPublisher, 100*time.Millisecond)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
 	defer cancel()
@@ -53,7 +53,7 @@ func TestNewScheduler(t *testing.T) {
 	redisPublisher := &RedisPublisher{}
 	interval := 1 * time.Minute
 
-	scheduler := NewScheduler(backupRepo, nil, redisPublisher, interval)
+	scheduler := NewScheduler(backupRepo, nil, nil, redisPublisher, interval)
 
 	assert.NotNil(t, scheduler)
 	assert.Equal(t, interval, scheduler.interval)
@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/entities"
 	"github.com/rrbarrero/justbackup/internal/backup/domain/interfaces"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
 	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
 )
 
@@ -23,17 +25,17 @@ func NewRedisWorkerQueryBus(client *redis.Client, publisher interfaces.TaskPubli
 	}
 }
 
-func (b *RedisWorkerQueryBus) SearchFiles(ctx context.Context, pattern string) (workerDto.SearchFilesResult, error) {
+func (b *RedisWorkerQueryBus) ListFiles(ctx context.Context, path string) (workerDto.ListFilesResult, error) {
 	pubsub := b.client.Subscribe(ctx, "worker_sync_responses")
 	defer func() { _ = pubsub.Close() }()
 
 	if _, err := pubsub.ReceiveTimeout(ctx, 2*time.Second); err != nil {
-		return workerDto.SearchFilesResult{}, fmt.Errorf("failed to subscribe to worker responses: %w", err)
+		return workerDto.ListFilesResult{}, fmt.Errorf("failed to subscribe to worker responses: %w", err)
 	}
 
-	taskID, err := b.publisher.PublishSearchTask(ctx, pattern)
+	taskID, err := b.publisher.PublishListFilesTask(ctx, path)
 	if err != nil {
-		return workerDto.SearchFilesResult{}, err
+		return workerDto.ListFilesResult{}, err
 	}
 
 	ch := pubsub.Channel()
@@ -49,31 +51,31 @@ func (b *RedisWorkerQueryBus) SearchFiles(ctx context.Context, pattern string) (
 
 			if result.TaskID == taskID {
 				if result.Status == "failed" {
-					return workerDto.SearchFilesResult{}, fmt.Errorf("worker error: %s", result.Message)
+					return workerDto.ListFilesResult{}, fmt.Errorf("worker error: %s", result.Message)
 				}
 
 				// Improvement (Tech Debt #3): Use structured parsing
 				dataJSON, err := json.Marshal(result.Data)
 				if err != nil {
-					return workerDto.SearchFilesResult{}, fmt.Errorf("failed to re-marshal result data: %w", err)
+					return workerDto.ListFilesResult{}, fmt.Errorf("failed to re-marshal result data: %w", err)
 				}
 
-				var searchResult workerDto.SearchFilesResult
-				if err := json.Unmarshal(dataJSON, &searchResult); err != nil {
-					return workerDto.SearchFilesResult{}, fmt.Errorf("failed to unmarshal search results: %w", err)
+				var listResult workerDto.ListFilesResult
+				if err := json.Unmarshal(dataJSON, &listResult); err != nil {
+					return workerDto.ListFilesResult{}, fmt.Errorf("failed to unmarshal list results: %w", err)
 				}
 
-				return searchResult, nil
+				return listResult, nil
 			}
 		case <-timeout:
-			return workerDto.SearchFilesResult{}, fmt.Errorf("timeout waiting for worker search response (30s)")
+			return workerDto.ListFilesResult{}, fmt.Errorf("timeout waiting for worker list response (30s)")
 		case <-ctx.Done():
-			return workerDto.SearchFilesResult{}, ctx.Err()
+			return workerDto.ListFilesResult{}, ctx.Err()
 		}
 	}
 }
 
-func (b *RedisWorkerQueryBus) ListFiles(ctx context.Context, path string) (workerDto.ListFilesResult, error) {
+func (b *RedisWorkerQueryBus) ListArchive(ctx context.Context, backupID string, archive string, archivePath string) (workerDto.ListFilesResult, error) {
 	pubsub := b.client.Subscribe(ctx, "worker_sync_responses")
 	defer func() { _ = pubsub.Close() }()
 
@@ -81,7 +83,7 @@ func (b *RedisWorkerQueryBus) ListFiles(ctx context.Context, path string) (worke
 		return workerDto.ListFilesResult{}, fmt.Errorf("failed to subscribe to worker responses: %w", err)
 	}
 
-	taskID, err := b.publisher.PublishListFilesTask(ctx, path)
+	taskID, err := b.publisher.PublishListArchiveTask(ctx, backupID, archive, archivePath)
 	if err != nil {
 		return workerDto.ListFilesResult{}, err
 	}
@@ -102,7 +104,6 @@ func (b *RedisWorkerQueryBus) ListFiles(ctx context.Context, path string) (worke
 					return workerDto.ListFilesResult{}, fmt.Errorf("worker error: %s", result.Message)
 				}
 
-				// Improvement (Tech Debt #3): Use structured parsing
 				dataJSON, err := json.Marshal(result.Data)
 				if err != nil {
 					return workerDto.ListFilesResult{}, fmt.Errorf("failed to re-marshal result data: %w", err)
@@ -110,15 +111,321 @@ func (b *RedisWorkerQueryBus) ListFiles(ctx context.Context, path string) (worke
 
 				var listResult workerDto.ListFilesResult
 				if err := json.Unmarshal(dataJSON, &listResult); err != nil {
-					return workerDto.ListFilesResult{}, fmt.Errorf("failed to unmarshal list results: %w", err)
+					return workerDto.ListFilesResult{}, fmt.Errorf("failed to unmarshal archive list results: %w", err)
 				}
 
 				return listResult, nil
 			}
 		case <-timeout:
-			return workerDto.ListFilesResult{}, fmt.Errorf("timeout waiting for worker list response (30s)")
+			return workerDto.ListFilesResult{}, fmt.Errorf("timeout waiting for worker archive list response (30s)")
 		case <-ctx.Done():
 			return workerDto.ListFilesResult{}, ctx.Err()
 		}
 	}
 }
+
+func (b *RedisWorkerQueryBus) ListSnapshots(ctx context.Context, path string) (workerDto.ListSnapshotsResult, error) {
+	pubsub := b.client.Subscribe(ctx, "worker_sync_responses")
+	defer func() { _ = pubsub.Close() }()
+
+	if _, err := pubsub.ReceiveTimeout(ctx, 2*time.Second); err != nil {
+		return workerDto.ListSnapshotsResult{}, fmt.Errorf("failed to subscribe to worker responses: %w", err)
+	}
+
+	taskID, err := b.publisher.PublishListSnapshotsTask(ctx, path)
+	if err != nil {
+		return workerDto.ListSnapshotsResult{}, err
+	}
+
+	ch := pubsub.Channel()
+	// Snapshots are walked to count their files, which takes longer than a listing.
+	timeout := time.After(120 * time.Second)
+
+	for {
+		select {
+		case msg := <-ch:
+			var result workerDto.WorkerResult
+			if err := json.Unmarshal([]byte(msg.Payload), &result); err != nil {
+				continue
+			}
+
+			if result.TaskID == taskID {
+				if result.Status == "failed" {
+					return workerDto.ListSnapshotsResult{}, fmt.Errorf("worker error: %s", result.Message)
+				}
+
+				dataJSON, err := json.Marshal(result.Data)
+				if err != nil {
+					return workerDto.ListSnapshotsResult{}, fmt.Errorf("failed to re-marshal result data: %w", err)
+				}
+
+				var snapshotsResult workerDto.ListSnapshotsResult
+				if err := json.Unmarshal(dataJSON, &snapshotsResult); err != nil {
+					return workerDto.ListSnapshotsResult{}, fmt.Errorf("failed to unmarshal snapshot results: %w", err)
+				}
+
+				return snapshotsResult, nil
+			}
+		case <-timeout:
+			return workerDto.ListSnapshotsResult{}, fmt.Errorf("timeout waiting for worker snapshot response (120s)")
+		case <-ctx.Done():
+			return workerDto.ListSnapshotsResult{}, ctx.Err()
+		}
+	}
+}
+
+func (b *RedisWorkerQueryBus) FileVersions(ctx context.Context, path string, filePath string) (workerDto.FileVersionsResult, error) {
+	pubsub := b.client.Subscribe(ctx, "worker_sync_responses")
+	defer func() { _ = pubsub.Close() }()
+
+	if _, err := pubsub.ReceiveTimeout(ctx, 2*time.Second); err != nil {
+		return workerDto.FileVersionsResult{}, fmt.Errorf("failed to subscribe to worker responses: %w", err)
+	}
+
+	taskID, err := b.publisher.PublishFileVersionsTask(ctx, path, filePath)
+	if err != nil {
+		return workerDto.FileVersionsResult{}, err
+	}
+
+	ch := pubsub.Channel()
+	// Every distinct copy of the file is hashed.
+	timeout := time.After(120 * time.Second)
+
+	for {
+		select {
+		case msg := <-ch:
+			var result workerDto.WorkerResult
+			if err := json.Unmarshal([]byte(msg.Payload), &result); err != nil {
+				continue
+			}
+
+			if result.TaskID == taskID {
+				if result.Status == "failed" {
+					return workerDto.FileVersionsResult{}, fmt.Errorf("worker error: %s", result.Message)
+				}
+
+				dataJSON, err := json.Marshal(result.Data)
+				if err != nil {
+					return workerDto.FileVersionsResult{}, fmt.Errorf("failed to re-marshal result data: %w", err)
+				}
+
+				var versionsResult workerDto.FileVersionsResult
+				if err := json.Unmarshal(dataJSON, &versionsResult); err != nil {
+					return workerDto.FileVersionsResult{}, fmt.Errorf("failed to unmarshal file versions results: %w", err)
+				}
+
+				return versionsResult, nil
+			}
+		case <-timeout:
+			return workerDto.FileVersionsResult{}, fmt.Errorf("timeout waiting for worker file versions response (120s)")
+		case <-ctx.Done():
+			return workerDto.FileVersionsResult{}, ctx.Err()
+		}
+	}
+}
+
+func (b *RedisWorkerQueryBus) DiffSnapshots(ctx context.Context, path string, from string, to string) (workerDto.SnapshotDiffResult, error) {
+	pubsub := b.client.Subscribe(ctx, "worker_sync_responses")
+	defer func() { _ = pubsub.Close() }()
+
+	if _, err := pubsub.ReceiveTimeout(ctx, 2*time.Second); err != nil {
+		return workerDto.SnapshotDiffResult{}, fmt.Errorf("failed to subscribe to worker responses: %w", err)
+	}
+
+	taskID, err := b.publisher.PublishDiffSnapshotsTask(ctx, path, from, to)
+	if err != nil {
+		return workerDto.SnapshotDiffResult{}, err
+	}
+
+	ch := pubsub.Channel()
+	// Both snapshots are walked in full.
+	timeout := time.After(120 * time.Second)
+
+	for {
+		select {
+		case msg := <-ch:
+			var result workerDto.WorkerResult
+			if err := json.Unmarshal([]byte(msg.Payload), &result); err != nil {
+				continue
+			}
+
+			if result.TaskID == taskID {
+				if result.Status == "failed" {
+					return workerDto.SnapshotDiffResult{}, fmt.Errorf("worker error: %s", result.Message)
+				}
+
+				dataJSON, err := json.Marshal(result.Data)
+				if err != nil {
+					return workerDto.SnapshotDiffResult{}, fmt.Errorf("failed to re-marshal result data: %w", err)
+				}
+
+				var diffResult workerDto.SnapshotDiffResult
+				if err := json.Unmarshal(dataJSON, &diffResult); err != nil {
+					return workerDto.SnapshotDiffResult{}, fmt.Errorf("failed to unmarshal diff results: %w", err)
+				}
+
+				return diffResult, nil
+			}
+		case <-timeout:
+			return workerDto.SnapshotDiffResult{}, fmt.Errorf("timeout waiting for worker diff response (120s)")
+		case <-ctx.Done():
+			return workerDto.SnapshotDiffResult{}, ctx.Err()
+		}
+	}
+}
+
+// PreviewRemoteRestore runs a remote restore as a dry run and returns the
+// changes it would make at the target.
+func (b *RedisWorkerQueryBus) PreviewRemoteRestore(ctx context.Context, backup *entities.Backup, path string, archivePath string, replica *valueobjects.ReplicaLocation, cold *valueobjects.ColdLocation, targetHost *entities.Host, targetPath string, options valueobjects.RestoreOptions) (workerDto.RestorePreviewResult, error) {
+	pubsub := b.client.Subscribe(ctx, "worker_sync_responses")
+	defer func() { _ = pubsub.Close() }()
+
+	if _, err := pubsub.ReceiveTimeout(ctx, 2*time.Second); err != nil {
+		return workerDto.RestorePreviewResult{}, fmt.Errorf("failed to subscribe to worker responses: %w", err)
+	}
+
+	options.DryRun = true
+	taskID, err := b.publisher.PublishRemoteRestoreTask(ctx, backup, path, archivePath, replica, cold, targetHost, targetPath, options, "")
+	if err != nil {
+		return workerDto.RestorePreviewResult{}, err
+	}
+
+	ch := pubsub.Channel()
+	// The whole restored tree is compared with the target, and an encrypted
+	// source is unpacked first.
+	timeout := time.After(300 * time.Second)
+
+	for {
+		select {
+		case msg := <-ch:
+			var result workerDto.WorkerResult
+			if err := json.Unmarshal([]byte(msg.Payload), &result); err != nil {
+				continue
+			}
+
+			if result.TaskID == taskID {
+				if result.Status == "failed" {
+					return workerDto.RestorePreviewResult{}, fmt.Errorf("worker error: %s", result.Message)
+				}
+
+				dataJSON, err := json.Marshal(result.Data)
+				if err != nil {
+					return workerDto.RestorePreviewResult{}, fmt.Errorf("failed to re-marshal result data: %w", err)
+				}
+
+				var preview workerDto.RestorePreviewResult
+				if err := json.Unmarshal(dataJSON, &preview); err != nil {
+					return workerDto.RestorePreviewResult{}, fmt.Errorf("failed to unmarshal restore preview: %w", err)
+				}
+
+				return preview, nil
+			}
+		case <-timeout:
+			return workerDto.RestorePreviewResult{}, fmt.Errorf("timeout waiting for worker restore preview (300s)")
+		case <-ctx.Done():
+			return workerDto.RestorePreviewResult{}, ctx.Err()
+		}
+	}
+}
+
+// DiskUsage asks a worker how full the filesystem holding path is.
+func (b *RedisWorkerQueryBus) DiskUsage(ctx context.Context, path string) (workerDto.DiskUsageResult, error) {
+	pubsub := b.client.Subscribe(ctx, "worker_sync_responses")
+	defer func() { _ = pubsub.Close() }()
+
+	if _, err := pubsub.ReceiveTimeout(ctx, 2*time.Second); err != nil {
+		return workerDto.DiskUsageResult{}, fmt.Errorf("failed to subscribe to worker responses: %w", err)
+	}
+
+	taskID, err := b.publisher.PublishDiskUsageTask(ctx, path)
+	if err != nil {
+		return workerDto.DiskUsageResult{}, err
+	}
+
+	ch := pubsub.Channel()
+	timeout := time.After(10 * time.Second)
+
+	for {
+		select {
+		case msg := <-ch:
+			var result workerDto.WorkerResult
+			if err := json.Unmarshal([]byte(msg.Payload), &result); err != nil {
+				continue
+			}
+
+			if result.TaskID == taskID {
+				if result.Status == "failed" {
+					return workerDto.DiskUsageResult{}, fmt.Errorf("worker error: %s", result.Message)
+				}
+
+				dataJSON, err := json.Marshal(result.Data)
+				if err != nil {
+					return workerDto.DiskUsageResult{}, fmt.Errorf("failed to re-marshal result data: %w", err)
+				}
+
+				var usage workerDto.DiskUsageResult
+				if err := json.Unmarshal(dataJSON, &usage); err != nil {
+					return workerDto.DiskUsageResult{}, fmt.Errorf("failed to unmarshal disk usage: %w", err)
+				}
+
+				return usage, nil
+			}
+		case <-timeout:
+			return workerDto.DiskUsageResult{}, fmt.Errorf("timeout waiting for worker disk usage response (10s)")
+		case <-ctx.Done():
+			return workerDto.DiskUsageResult{}, ctx.Err()
+		}
+	}
+}
+
+// ScanOrphans asks a worker what lies under the storage roots that none of
+// the referenced directories holds. Measuring orphans walks them whole, so it
+// waits longer than the other queries.
+func (b *RedisWorkerQueryBus) ScanOrphans(ctx context.Context, roots []string, referenced []string) (workerDto.OrphanScanResult, error) {
+	pubsub := b.client.Subscribe(ctx, "worker_sync_responses")
+	defer func() { _ = pubsub.Close() }()
+
+	if _, err := pubsub.ReceiveTimeout(ctx, 2*time.Second); err != nil {
+		return workerDto.OrphanScanResult{}, fmt.Errorf("failed to subscribe to worker responses: %w", err)
+	}
+
+	taskID, err := b.publisher.PublishScanOrphansTask(ctx, roots, referenced)
+	if err != nil {
+		return workerDto.OrphanScanResult{}, err
+	}
+
+	ch := pubsub.Channel()
+	timeout := time.After(300 * time.Second)
+
+	for {
+		select {
+		case msg := <-ch:
+			var result workerDto.WorkerResult
+			if err := json.Unmarshal([]byte(msg.Payload), &result); err != nil {
+				continue
+			}
+
+			if result.TaskID == taskID {
+				if result.Status == "failed" {
+					return workerDto.OrphanScanResult{}, fmt.Errorf("worker error: %s", result.Message)
+				}
+
+				dataJSON, err := json.Marshal(result.Data)
+				if err != nil {
+					return workerDto.OrphanScanResult{}, fmt.Errorf("failed to re-marshal result data: %w", err)
+				}
+
+				var scan workerDto.OrphanScanResult
+				if err := json.Unmarshal(dataJSON, &scan); err != nil {
+					return workerDto.OrphanScanResult{}, fmt.Errorf("failed to unmarshal orphan scan: %w", err)
+				}
+
+				return scan, nil
+			}
+		case <-timeout:
+			return workerDto.OrphanScanResult{}, fmt.Errorf("timeout waiting for worker orphan scan response (300s)")
+		case <-ctx.Done():
+			return workerDto.OrphanScanResult{}, ctx.Err()
+		}
+	}
+}
@@ -3,7 +3,12 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
+
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
 )
 
 // ServerConfig holds configuration for the server
@@ -14,7 +19,17 @@ type ServerConfig struct {
 	RedisPort         string
 	CORSAllowedOrigin string
 	EncryptionKey     string
-	ServerPort        string // Added for better server configuration
+	ServerPort        string        // Added for better server configuration
+	DownloadDir       string        // Where archives streamed by workers are staged for download
+	DownloadTTL       time.Duration // How long a staged download is kept
+	// Warn when a backup root is projected to fill within this many days; 0 disables it
+	CapacityWarningDays int
+	// Alert when a snapshot loses this share of its usual size; 0 disables it
+	AnomalyShrinkRatio float64
+	// Alert when a run changes this share of its files; 0 disables it
+	AnomalyChurnRatio float64
+	// Put a backup under legal hold when an anomaly is detected
+	AnomalyAutoHold bool
 }
 
 // WorkerConfig holds configuration for the worker
@@ -26,6 +41,9 @@ type WorkerConfig struct {
 	ContainerBackupRoot string
 	EncryptionKey       string
 	BackendURL          string
+	BackendToken        string        // API token the worker deregisters itself with
+	ShutdownGracePeriod time.Duration // How long an in-flight task may run after SIGTERM
+	ContentIndexMaxSize int64         // Larger files are left out of the content index
 }
 
 // ConfigService provides methods to access configuration
@@ -51,13 +69,58 @@ func (cs *ConfigService) LoadServerConfig() (*ServerConfig, error) {
 	}
 
 	config := &ServerConfig{
-		Environment:       env,
-		JWTSecret:         os.Getenv("JWT_SECRET"),
-		RedisHost:         os.Getenv("REDIS_HOST"),
-		RedisPort:         os.Getenv("REDIS_PORT"),
-		CORSAllowedOrigin: os.Getenv("CORS_ALLOWED_ORIGIN"),
-		EncryptionKey:     os.Getenv("ENCRYPTION_KEY"),
-		ServerPort:        getEnv("SERVER_PORT", "8080"), // Default to 8080
+		Environment:         env,
+		JWTSecret:           os.Getenv("JWT_SECRET"),
+		RedisHost:           os.Getenv("REDIS_HOST"),
+		RedisPort:           os.Getenv("REDIS_PORT"),
+		CORSAllowedOrigin:   os.Getenv("CORS_ALLOWED_ORIGIN"),
+		EncryptionKey:       os.Getenv("ENCRYPTION_KEY"),
+		ServerPort:          getEnv("SERVER_PORT", "8080"), // Default to 8080
+		DownloadDir:         getEnv("DOWNLOAD_DIR", filepath.Join(os.TempDir(), "justbackup-downloads")),
+		DownloadTTL:         time.Hour,
+		CapacityWarningDays: 30,
+		AnomalyShrinkRatio:  0.5,
+		AnomalyChurnRatio:   0.8,
+	}
+
+	if raw := os.Getenv("DOWNLOAD_TTL"); raw != "" {
+		ttl, err := time.ParseDuration(raw)
+		if err != nil || ttl <= 0 {
+			return nil, fmt.Errorf("invalid DOWNLOAD_TTL %q: expected a duration such as 30m or 2h", raw)
+		}
+		config.DownloadTTL = ttl
+	}
+
+	if raw := os.Getenv("CAPACITY_WARNING_DAYS"); raw != "" {
+		days, err := strconv.Atoi(raw)
+		if err != nil || days < 0 {
+			return nil, fmt.Errorf("invalid CAPACITY_WARNING_DAYS %q: expected a number of days, 0 to disable", raw)
+		}
+		config.CapacityWarningDays = days
+	}
+
+	if raw := os.Getenv("ANOMALY_SHRINK_RATIO"); raw != "" {
+		ratio, err := strconv.ParseFloat(raw, 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			return nil, fmt.Errorf("invalid ANOMALY_SHRINK_RATIO %q: expected a ratio between 0 and 1, 0 to disable", raw)
+		}
+		config.AnomalyShrinkRatio = ratio
+	}
+
+	if raw := os.Getenv("ANOMALY_CHURN_RATIO"); raw != "" {
+		ratio, err := strconv.ParseFloat(raw, 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			return nil, fmt.Errorf("invalid ANOMALY_CHURN_RATIO %q: expected a ratio between 0 and 1, 0 to disable", raw)
+		}
+		config.AnomalyChurnRatio = ratio
+	}
+
+	if raw := os.Getenv("ANOMALY_AUTO_HOLD"); raw != "" {
+		hold, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ANOMALY_AUTO_HOLD %q: expected true or false", raw)
+		}
+		config.AnomalyAutoHold = hold
 	}
 
 	// Only validate in production mode
@@ -113,6 +176,8 @@ func (cs *ConfigService) LoadWorkerConfig() (*WorkerConfig, error) {
 	}
 
 	CONTAINER_BACKUP_ROOT := "/mnt/backups"
+	DEFAULT_SHUTDOWN_GRACE_PERIOD := 5 * time.Minute
+	DEFAULT_CONTENT_INDEX_MAX_SIZE := int64(1 << 20)
 
 	env := os.Getenv("ENVIRONMENT")
 	if env == "" {
@@ -127,6 +192,25 @@ func (cs *ConfigService) LoadWorkerConfig() (*WorkerConfig, error) {
 		ContainerBackupRoot: CONTAINER_BACKUP_ROOT,
 		EncryptionKey:       os.Getenv("ENCRYPTION_KEY"),
 		BackendURL:          os.Getenv("BACKEND_INTERNAL_URL"),
+		BackendToken:        os.Getenv("WORKER_API_TOKEN"),
+		ShutdownGracePeriod: DEFAULT_SHUTDOWN_GRACE_PERIOD,
+		ContentIndexMaxSize: DEFAULT_CONTENT_INDEX_MAX_SIZE,
+	}
+
+	if raw := os.Getenv("SHUTDOWN_GRACE_PERIOD"); raw != "" {
+		grace, err := time.ParseDuration(raw)
+		if err != nil || grace < 0 {
+			return nil, fmt.Errorf("invalid SHUTDOWN_GRACE_PERIOD %q: expected a duration such as 30s or 5m", raw)
+		}
+		config.ShutdownGracePeriod = grace
+	}
+
+	if raw := os.Getenv("CONTENT_INDEX_MAX_FILE_SIZE"); raw != "" {
+		size, err := shared.ParseSize(raw)
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("invalid CONTENT_INDEX_MAX_FILE_SIZE %q: expected a size such as 512KB or 1MB", raw)
+		}
+		config.ContentIndexMaxSize = size
 	}
 
 	// Only validate in production mode
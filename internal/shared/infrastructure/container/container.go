@@ -64,15 +64,19 @@ func (c *Container) Initialize() error {
 	c.webSocketHub = webSocketHub
 
 	// Initialize services with proper Redis components
-	redisPublisher := scheduler.NewRedisPublisher(c.redisClient, "backup_tasks", repos.Host)
+	redisPublisher := scheduler.NewRedisPublisher(c.redisClient, "backup_tasks", repos.Host, repos.StoragePool, repos.Backup)
 	resultStore := scheduler.NewRedisResultStore(c.redisClient)
 	workerQueryBus := scheduler.NewRedisWorkerQueryBus(c.redisClient, redisPublisher)
-	services := c.initializeServices(repos, redisPublisher, resultStore, workerQueryBus, cfg)
+	downloadStager, err := scheduler.NewRedisDownloadStager(c.redisClient, cfg.DownloadDir, cfg.DownloadTTL)
+	if err != nil {
+		return fmt.Errorf("failed to initialize download staging: %w", err)
+	}
+	services := c.initializeServices(repos, redisPublisher, resultStore, workerQueryBus, downloadStager, cfg)
 
 	// Initialize scheduler components
-	c.backupScheduler = scheduler.NewScheduler(repos.Backup, services.Maintenance, redisPublisher, 1*time.Minute) // Use 1 minute as default
+	c.backupScheduler = scheduler.NewScheduler(repos.Backup, services.Maintenance, services.Replication, redisPublisher, 1*time.Minute) // Use 1 minute as default
 
-	c.resultConsumer = scheduler.NewResultConsumer(c.redisClient, "backup_results", repos.Backup, services.Host, repos.BackupError, webSocketHub, c.eventBus)
+	c.resultConsumer = scheduler.NewResultConsumer(c.redisClient, "backup_results", repos.Backup, services.Host, repos.BackupError, repos.FileCatalog, redisPublisher, services.Replication, services.Replica, services.StoragePool, services.Tiering, services.SizeHistory, services.Anomaly, services.Maintenance, webSocketHub, c.eventBus)
 
 	// Initialize notification listener
 	c.notificationListener = notifApp.NewNotificationEventListener(services.Notification, c.eventBus)
@@ -20,8 +20,18 @@ func (c *Container) initializeHandlers(services *Services, repos *Repositories,
 			services.BackupRestore,
 			services.BackupTask,
 			services.BackupHook,
+			services.StoredData,
 		),
-		Host:         backupHttp.NewHostHandler(services.Host),
+		Host:         backupHttp.NewHostHandler(services.Host, services.StoredData),
+		Retention:    backupHttp.NewRetentionHandler(services.BackupRetention),
+		Snapshot:     backupHttp.NewSnapshotHandler(services.BackupSnapshot),
+		Replication:  backupHttp.NewReplicationHandler(services.Replication),
+		Replica:      backupHttp.NewReplicaHandler(services.Replica),
+		StoragePool:  backupHttp.NewStoragePoolHandler(services.StoragePool),
+		Tiering:      backupHttp.NewTieringHandler(services.Tiering),
+		SizeHistory:  backupHttp.NewSizeHistoryHandler(services.SizeHistory),
+		Quota:        backupHttp.NewQuotaHandler(services.Quota),
+		StoredData:   backupHttp.NewStoredDataHandler(services.StoredData),
 		Settings:     backupHttp.NewSettingsHandler(),
 		User:         userHttp.NewUserHandler(services.User, services.JWT),
 		Auth:         authHttp.NewAuthHandler(services.Auth),
@@ -33,6 +33,17 @@ func (c *Container) initializeRepositories(cfg *config.ServerConfig, env string)
 		repos.User = userMem.NewUserRepositoryMemory()
 		repos.AuthToken = authMem.NewAuthTokenRepositoryMemory()
 		repos.BackupError = memory.NewBackupErrorRepositoryMemory()
+		repos.SnapshotPin = memory.NewSnapshotPinRepositoryMemory()
+		repos.LegalHold = memory.NewLegalHoldEventRepositoryMemory()
+		repos.FileCatalog = memory.NewFileCatalogRepositoryMemory()
+		repos.Replication = memory.NewReplicationJobRepositoryMemory()
+		repos.ReplicationRun = memory.NewReplicationRunRepositoryMemory()
+		repos.ReplicaTarget = memory.NewReplicaTargetRepositoryMemory()
+		repos.ReplicaSnapshot = memory.NewReplicaSnapshotRepositoryMemory()
+		repos.StoragePool = memory.NewStoragePoolRepositoryMemory()
+		repos.TieringPolicy = memory.NewTieringPolicyRepositoryMemory()
+		repos.Archived = memory.NewArchivedSnapshotRepositoryMemory()
+		repos.SizeHistory = memory.NewSizeHistoryRepositoryMemory()
 		repos.Notification = notifMem.NewNotificationRepositoryMemory()
 		repos.WorkerStats = workerStatsMem.NewWorkerStatsRepositoryMemory()
 
@@ -93,6 +104,17 @@ func (c *Container) initializeRepositories(cfg *config.ServerConfig, env string)
 		repos.User = userPostgres.NewUserRepositoryPostgres(conn)
 		repos.AuthToken = authPostgres.NewAuthTokenRepositoryPostgres(conn)
 		repos.BackupError = postgres.NewBackupErrorRepositoryPostgres(conn)
+		repos.SnapshotPin = postgres.NewSnapshotPinRepositoryPostgres(conn)
+		repos.LegalHold = postgres.NewLegalHoldEventRepositoryPostgres(conn)
+		repos.FileCatalog = postgres.NewFileCatalogRepositoryPostgres(conn)
+		repos.Replication = postgres.NewReplicationJobRepositoryPostgres(conn)
+		repos.ReplicationRun = postgres.NewReplicationRunRepositoryPostgres(conn)
+		repos.ReplicaTarget = postgres.NewReplicaTargetRepositoryPostgres(conn, encryptionService)
+		repos.ReplicaSnapshot = postgres.NewReplicaSnapshotRepositoryPostgres(conn)
+		repos.StoragePool = postgres.NewStoragePoolRepositoryPostgres(conn)
+		repos.TieringPolicy = postgres.NewTieringPolicyRepositoryPostgres(conn)
+		repos.Archived = postgres.NewArchivedSnapshotRepositoryPostgres(conn)
+		repos.SizeHistory = postgres.NewSizeHistoryRepositoryPostgres(conn)
 		repos.Maintenance = maintPostgres.NewMaintenanceRepositoryPostgres(conn)
 		repos.Notification = notifPostgres.NewNotificationRepositoryPostgres(conn, encryptionService)
 		repos.WorkerStats = workerStatsMem.NewWorkerStatsRepositoryMemory()
@@ -47,6 +47,15 @@ func (c *Container) setupRouter(handlers *Handlers, services *Services, hub *web
 	// Register Routes
 	handlers.Backup.RegisterRoutes(apiMux, protected)
 	handlers.Host.RegisterRoutes(apiMux, protected)
+	handlers.Retention.RegisterRoutes(apiMux, protected)
+	handlers.Snapshot.RegisterRoutes(apiMux, protected)
+	handlers.Replication.RegisterRoutes(apiMux, protected)
+	handlers.Replica.RegisterRoutes(apiMux, protected)
+	handlers.StoragePool.RegisterRoutes(apiMux, protected)
+	handlers.Tiering.RegisterRoutes(apiMux, protected)
+	handlers.SizeHistory.RegisterRoutes(apiMux, protected)
+	handlers.Quota.RegisterRoutes(apiMux, protected)
+	handlers.StoredData.RegisterRoutes(apiMux, protected)
 	handlers.Settings.RegisterRoutes(apiMux, protected)
 	handlers.Auth.RegisterRoutes(apiMux, protected)
 	handlers.Notification.RegisterRoutes(apiMux, protected)
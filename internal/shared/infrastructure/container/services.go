@@ -5,6 +5,7 @@ import (
 	"github.com/rrbarrero/justbackup/internal/backup/application"
 	"github.com/rrbarrero/justbackup/internal/backup/application/assembler"
 	"github.com/rrbarrero/justbackup/internal/backup/domain/interfaces"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
 	maintApp "github.com/rrbarrero/justbackup/internal/maintenance/application"
 	notifApp "github.com/rrbarrero/justbackup/internal/notification/application"
 	"github.com/rrbarrero/justbackup/internal/scheduler"
@@ -15,24 +16,43 @@ import (
 )
 
 // initializeServices initializes all application services
-func (c *Container) initializeServices(repos *Repositories, redisPublisher *scheduler.RedisPublisher, resultStore *scheduler.RedisResultStore, workerQueryBus interfaces.WorkerQueryBus, cfg *config.ServerConfig) *Services {
+func (c *Container) initializeServices(repos *Repositories, redisPublisher *scheduler.RedisPublisher, resultStore *scheduler.RedisResultStore, workerQueryBus interfaces.WorkerQueryBus, downloadStager interfaces.DownloadStager, cfg *config.ServerConfig) *Services {
 	hostService := application.NewHostService(repos.Host, repos.Backup)
 	backupAssembler := assembler.NewBackupAssembler()
+	replicaService := application.NewReplicaService(repos.ReplicaTarget, repos.ReplicaSnapshot, repos.Backup, redisPublisher)
+	storagePoolService := application.NewStoragePoolService(repos.StoragePool, repos.Backup, repos.Host, redisPublisher, workerQueryBus)
+	tieringService := application.NewTieringService(repos.TieringPolicy, repos.Archived, repos.Backup, repos.Host, repos.ReplicaTarget, redisPublisher, storagePoolService, replicaService)
+	sizeHistoryService := application.NewSizeHistoryService(repos.SizeHistory, repos.Backup, repos.Host, workerQueryBus, storagePoolService, c.eventBus, cfg.CapacityWarningDays)
+	retentionService := application.NewBackupRetentionService(repos.Backup, repos.Host, repos.SnapshotPin, repos.LegalHold, workerQueryBus, backupAssembler, storagePoolService)
+	anomalyThresholds := valueobjects.AnomalyThresholds{Shrink: cfg.AnomalyShrinkRatio, Churn: cfg.AnomalyChurnRatio}
+	restoreService := application.NewBackupRestoreService(repos.Backup, hostService, redisPublisher, workerQueryBus, downloadStager, replicaService, storagePoolService, tieringService)
+	lifecycleService := application.NewBackupLifecycleService(repos.Backup, repos.FileCatalog, hostService, redisPublisher, backupAssembler, storagePoolService)
+	storedDataService := application.NewStoredDataService(repos.Backup, repos.Host, redisPublisher, workerQueryBus, storagePoolService, tieringService, lifecycleService, hostService, c.eventBus)
 
 	return &Services{
 		Host:            hostService,
-		BackupLifecycle: application.NewBackupLifecycleService(repos.Backup, hostService, redisPublisher, backupAssembler),
+		BackupLifecycle: lifecycleService,
 		BackupQuery:     application.NewBackupQueryService(repos.Backup, hostService, repos.BackupError, backupAssembler),
-		BackupSearch:    application.NewBackupSearchService(repos.Backup, hostService, workerQueryBus, backupAssembler),
-		BackupRestore:   application.NewBackupRestoreService(repos.Backup, hostService, redisPublisher),
+		BackupSearch:    application.NewBackupSearchService(repos.Backup, repos.FileCatalog, hostService, workerQueryBus, backupAssembler, storagePoolService),
+		BackupRestore:   restoreService,
 		BackupTask:      application.NewBackupTaskService(redisPublisher, resultStore),
 		BackupHook:      application.NewBackupHookService(repos.Backup, backupAssembler),
+		BackupSnapshot:  application.NewBackupSnapshotService(repos.Backup, hostService, repos.SnapshotPin, workerQueryBus, storagePoolService, tieringService),
+		BackupRetention: retentionService,
+		Replication:     application.NewReplicationService(repos.Replication, repos.ReplicationRun, repos.Backup, hostService, restoreService),
+		Replica:         replicaService,
+		StoragePool:     storagePoolService,
+		Tiering:         tieringService,
+		SizeHistory:     sizeHistoryService,
+		Anomaly:         application.NewAnomalyService(repos.SizeHistory, retentionService, c.eventBus, anomalyThresholds, cfg.AnomalyAutoHold),
+		Quota:           application.NewQuotaService(repos.Backup, repos.Host),
+		StoredData:      storedDataService,
 		BackupAssembler: backupAssembler,
 		User:            userApp.NewUserService(repos.User),
 		Auth:            authApp.NewAuthService(repos.AuthToken),
 		Notification:    notifApp.NewNotificationService(repos.Notification),
 		Dashboard:       application.NewDashboardService(repos.Backup, repos.Host, workerStatsApp.NewWorkerStatsService(repos.WorkerStats, c.webSocketHub)),
-		Maintenance:     maintApp.NewMaintenanceService(repos.Maintenance, repos.Backup, redisPublisher),
+		Maintenance:     maintApp.NewMaintenanceService(repos.Maintenance, repos.Backup, repos.Host, repos.SnapshotPin, redisPublisher, tieringService, sizeHistoryService, storedDataService),
 		JWT:             auth.NewJWTService(cfg.JWTSecret, "justbackup"),
 		WorkerStats:     workerStatsApp.NewWorkerStatsService(repos.WorkerStats, c.webSocketHub),
 	}
@@ -33,14 +33,25 @@ import (
 
 // Repositories holds all repository implementations
 type Repositories struct {
-	Backup       interfaces.BackupRepository
-	Host         interfaces.HostRepository
-	User         userInterfaces.UserRepository
-	AuthToken    authInterfaces.AuthTokenRepository
-	BackupError  interfaces.BackupErrorRepository
-	Notification notifInterfaces.NotificationRepository
-	Maintenance  maintInterfaces.MaintenanceTaskRepository
-	WorkerStats  workerStatsInterfaces.WorkerStatsRepository
+	Backup          interfaces.BackupRepository
+	Host            interfaces.HostRepository
+	User            userInterfaces.UserRepository
+	AuthToken       authInterfaces.AuthTokenRepository
+	BackupError     interfaces.BackupErrorRepository
+	SnapshotPin     interfaces.SnapshotPinRepository
+	LegalHold       interfaces.LegalHoldEventRepository
+	FileCatalog     interfaces.FileCatalogRepository
+	Replication     interfaces.ReplicationJobRepository
+	ReplicationRun  interfaces.ReplicationRunRepository
+	ReplicaTarget   interfaces.ReplicaTargetRepository
+	ReplicaSnapshot interfaces.ReplicaSnapshotRepository
+	StoragePool     interfaces.StoragePoolRepository
+	TieringPolicy   interfaces.TieringPolicyRepository
+	Archived        interfaces.ArchivedSnapshotRepository
+	SizeHistory     interfaces.SizeHistoryRepository
+	Notification    notifInterfaces.NotificationRepository
+	Maintenance     maintInterfaces.MaintenanceTaskRepository
+	WorkerStats     workerStatsInterfaces.WorkerStatsRepository
 }
 
 // Services holds all application services
@@ -52,6 +63,16 @@ type Services struct {
 	BackupRestore   *application.BackupRestoreService
 	BackupTask      *application.BackupTaskService
 	BackupHook      *application.BackupHookService
+	BackupRetention *application.BackupRetentionService
+	BackupSnapshot  *application.BackupSnapshotService
+	Replication     *application.ReplicationService
+	Replica         *application.ReplicaService
+	StoragePool     *application.StoragePoolService
+	Tiering         *application.TieringService
+	SizeHistory     *application.SizeHistoryService
+	Anomaly         *application.AnomalyService
+	Quota           *application.QuotaService
+	StoredData      *application.StoredDataService
 	BackupAssembler *assembler.BackupAssembler
 	User            *userApp.UserService
 	Auth            *authApp.AuthService
@@ -66,6 +87,15 @@ type Services struct {
 type Handlers struct {
 	Backup       *backupHttp.BackupHandler
 	Host         *backupHttp.HostHandler
+	Retention    *backupHttp.RetentionHandler
+	Snapshot     *backupHttp.SnapshotHandler
+	Replication  *backupHttp.ReplicationHandler
+	Replica      *backupHttp.ReplicaHandler
+	StoragePool  *backupHttp.StoragePoolHandler
+	Tiering      *backupHttp.TieringHandler
+	SizeHistory  *backupHttp.SizeHistoryHandler
+	Quota        *backupHttp.QuotaHandler
+	StoredData   *backupHttp.StoredDataHandler
 	Settings     *backupHttp.SettingsHandler
 	User         *userHttp.UserHandler
 	Auth         *authHttp.AuthHandler
@@ -0,0 +1,250 @@
+package crypto
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ArchiveIndexSuffix is appended to the path of an encrypted archive to name
+// its index sidecar.
+const ArchiveIndexSuffix = ".idx"
+
+var (
+	ErrNoArchiveIndex      = errors.New("archive has no index")
+	ErrNotFoundInArchive   = errors.New("path not found in archive")
+	errArchiveIndexVersion = errors.New("unsupported archive index version")
+)
+
+// Version 1 indexes predate ArchiveBlock and can only be read together with
+// the whole archive.
+const archiveIndexVersion = 2
+
+// ArchiveIndexEntry describes one member of a tar archive. Offset is where
+// its header starts in the uncompressed tar stream.
+type ArchiveIndexEntry struct {
+	Name    string    `json:"name"`
+	IsDir   bool      `json:"is_dir,omitempty"`
+	Size    int64     `json:"size"`
+	Mode    int64     `json:"mode"`
+	ModTime time.Time `json:"mod_time"`
+	Hash    string    `json:"hash,omitempty"`
+	Offset  int64     `json:"offset"`
+}
+
+// ArchiveBlock is a gzip member of an archive: Offset is where it starts in
+// the compressed file and TarOffset where its data starts in the tar stream.
+type ArchiveBlock struct {
+	Offset    int64 `json:"offset"`
+	TarOffset int64 `json:"tar_offset"`
+}
+
+// ArchiveIndex lists the members of an archive in archive order, and the
+// gzip blocks the archive can be read from. It is stored encrypted with the
+// archive key so it leaks nothing the archive does not.
+type ArchiveIndex struct {
+	Version int                 `json:"version"`
+	Entries []ArchiveIndexEntry `json:"entries"`
+	Blocks  []ArchiveBlock      `json:"blocks,omitempty"`
+}
+
+// ArchiveIndexPath returns where the index of an archive is stored. Links
+// such as latest.tar.gz.enc are resolved first, since only the archive they
+// point to has a sidecar.
+func ArchiveIndexPath(archive string) string {
+	if resolved, err := filepath.EvalSymlinks(archive); err == nil {
+		archive = resolved
+	}
+	return archive + ArchiveIndexSuffix
+}
+
+// WriteArchiveIndex encrypts index with key and writes it next to archive.
+func WriteArchiveIndex(index *ArchiveIndex, archive string, key []byte) error {
+	index.Version = archiveIndexVersion
+	data, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("failed to marshal archive index: %w", err)
+	}
+	sealed, err := encryptBytes(data, key)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt archive index: %w", err)
+	}
+
+	target := archive + ArchiveIndexSuffix
+	tmp := target + ".tmp"
+	if err := os.WriteFile(tmp, sealed, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, target)
+}
+
+// ReadArchiveIndex loads and decrypts the index of archive. Archives written
+// before indexes existed return ErrNoArchiveIndex.
+func ReadArchiveIndex(archive string, key []byte) (*ArchiveIndex, error) {
+	sealed, err := os.ReadFile(ArchiveIndexPath(archive))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoArchiveIndex
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, err := decryptBytes(sealed, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt archive index: %w", err)
+	}
+
+	var index ArchiveIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse archive index: %w", err)
+	}
+	if index.Version < 1 || index.Version > archiveIndexVersion {
+		return nil, fmt.Errorf("%w: %d", errArchiveIndexVersion, index.Version)
+	}
+	return &index, nil
+}
+
+// cleanArchivePath normalises a path inside an archive; the root is "".
+func cleanArchivePath(p string) string {
+	p = strings.Trim(path.Clean("/"+p), "/")
+	if p == "." {
+		return ""
+	}
+	return p
+}
+
+// within reports whether name is dir itself or lies below it.
+func within(name, dir string) bool {
+	return dir == "" || name == dir || strings.HasPrefix(name, dir+"/")
+}
+
+// List returns the members directly inside dir, sorted by name.
+func (idx *ArchiveIndex) List(dir string) ([]ArchiveIndexEntry, error) {
+	dir = cleanArchivePath(dir)
+	found := dir == ""
+	var children []ArchiveIndexEntry
+	for _, e := range idx.Entries {
+		name := cleanArchivePath(e.Name)
+		if name == "" {
+			continue
+		}
+		if name == dir {
+			if !e.IsDir {
+				return nil, fmt.Errorf("%w: %s is not a directory", ErrNotFoundInArchive, dir)
+			}
+			found = true
+			continue
+		}
+		if cleanArchivePath(path.Dir(name)) == dir {
+			children = append(children, e)
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrNotFoundInArchive, dir)
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].Name < children[j].Name })
+	return children, nil
+}
+
+// Subtree returns p and every member below it, in archive order.
+func (idx *ArchiveIndex) Subtree(p string) ([]ArchiveIndexEntry, error) {
+	p = cleanArchivePath(p)
+	var entries []ArchiveIndexEntry
+	for _, e := range idx.Entries {
+		name := cleanArchivePath(e.Name)
+		if name != "" && within(name, p) {
+			entries = append(entries, e)
+		}
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFoundInArchive, p)
+	}
+	return entries, nil
+}
+
+// ExtractSubtree reads the members of the subtree at p from a tar.gz stream,
+// using the index to skip everything before it and to stop right after it.
+// The whole stream is still decompressed up to that point: gzip cannot seek.
+func ExtractSubtree(r io.Reader, index *ArchiveIndex, p string, handle func(header *tar.Header, content io.Reader) error) error {
+	entries, err := index.Subtree(p)
+	if err != nil {
+		return err
+	}
+	return extractEntries(r, 0, entries, cleanArchivePath(p), handle)
+}
+
+// ExtractEncryptedSubtree is ExtractSubtree for an archive encrypted with
+// EncryptFile. Only the chunks holding the gzip blocks of the subtree are
+// decrypted; archives written before chunking or block indexes existed are
+// decrypted whole.
+func ExtractEncryptedSubtree(archive string, key []byte, index *ArchiveIndex, p string, handle func(header *tar.Header, content io.Reader) error) error {
+	entries, err := index.Subtree(p)
+	if err != nil {
+		return err
+	}
+	p = cleanArchivePath(p)
+
+	block := index.blockFor(entries[0].Offset)
+	r, err := OpenDecrypted(archive, key, block.Offset)
+	if errors.Is(err, ErrNotSeekable) {
+		block = ArchiveBlock{}
+		r, err = OpenDecrypted(archive, key, 0)
+	}
+	if err != nil {
+		return err
+	}
+	defer func() { _ = r.Close() }()
+
+	return extractEntries(r, block.TarOffset, entries, p, handle)
+}
+
+// blockFor returns the last gzip block starting at or before tar offset.
+// Indexes without blocks have a single implicit block at the start.
+func (idx *ArchiveIndex) blockFor(offset int64) ArchiveBlock {
+	i := sort.Search(len(idx.Blocks), func(i int) bool { return idx.Blocks[i].TarOffset > offset })
+	if i == 0 {
+		return ArchiveBlock{}
+	}
+	return idx.Blocks[i-1]
+}
+
+// extractEntries reads entries, the subtree at p, from a tar.gz stream whose
+// tar data starts at tar offset start.
+func extractEntries(r io.Reader, start int64, entries []ArchiveIndexEntry, p string, handle func(header *tar.Header, content io.Reader) error) error {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = gzr.Close() }()
+
+	if _, err := io.CopyN(io.Discard, gzr, entries[0].Offset-start); err != nil {
+		return fmt.Errorf("failed to seek to %s: %w", p, err)
+	}
+
+	tr := tar.NewReader(gzr)
+	for remaining := len(entries); remaining > 0; {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if !within(cleanArchivePath(header.Name), p) {
+			continue
+		}
+		remaining--
+		if err := handle(header, tr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
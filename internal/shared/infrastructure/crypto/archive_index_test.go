@@ -0,0 +1,106 @@
+package crypto
+
+import (
+	"archive/tar"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestArchiveIndex_RoundTrip(t *testing.T) {
+	source := writeTree(t, map[string]string{
+		"a.txt":          "a",
+		"etc/hosts":      "127.0.0.1 localhost",
+		"etc/ssh/config": "Host *",
+		"var/log/syslog": "boot",
+	})
+	archive := filepath.Join(t.TempDir(), "snap.tar.gz")
+	index, err := CompressDirectoryWithIndex(source, archive)
+	if err != nil {
+		t.Fatalf("failed to compress: %v", err)
+	}
+
+	key := make([]byte, 32)
+	if err := WriteArchiveIndex(index, archive, key); err != nil {
+		t.Fatalf("failed to write index: %v", err)
+	}
+	link := filepath.Join(filepath.Dir(archive), "latest.tar.gz")
+	if err := os.Symlink(filepath.Base(archive), link); err != nil {
+		t.Fatalf("failed to link: %v", err)
+	}
+
+	read, err := ReadArchiveIndex(link, key)
+	if err != nil {
+		t.Fatalf("failed to read index through link: %v", err)
+	}
+	if len(read.Entries) != len(index.Entries) {
+		t.Fatalf("got %d entries, want %d", len(read.Entries), len(index.Entries))
+	}
+
+	children, err := read.List("/etc/")
+	if err != nil {
+		t.Fatalf("failed to list: %v", err)
+	}
+	if len(children) != 2 || children[0].Name != "etc/hosts" || children[1].Name != "etc/ssh" || !children[1].IsDir {
+		t.Errorf("unexpected listing of etc: %+v", children)
+	}
+	if children[0].Size != int64(len("127.0.0.1 localhost")) || len(children[0].Hash) != 64 {
+		t.Errorf("unexpected hosts entry: %+v", children[0])
+	}
+
+	if _, err := read.List("etc/hosts"); !errors.Is(err, ErrNotFoundInArchive) {
+		t.Errorf("listing a file: got %v, want ErrNotFoundInArchive", err)
+	}
+	if _, err := read.Subtree("missing"); !errors.Is(err, ErrNotFoundInArchive) {
+		t.Errorf("missing subtree: got %v, want ErrNotFoundInArchive", err)
+	}
+
+	f, err := os.Open(archive)
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	got := map[string]string{}
+	err = ExtractSubtree(f, read, "etc", func(header *tar.Header, content io.Reader) error {
+		data, err := io.ReadAll(content)
+		got[header.Name] = string(data)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("failed to extract: %v", err)
+	}
+	want := map[string]string{"etc": "", "etc/hosts": "127.0.0.1 localhost", "etc/ssh": "", "etc/ssh/config": "Host *"}
+	if len(got) != len(want) {
+		t.Fatalf("got members %v, want %v", got, want)
+	}
+	for name, content := range want {
+		if got[name] != content {
+			t.Errorf("member %s: got %q, want %q", name, got[name], content)
+		}
+	}
+}
+
+func TestReadArchiveIndex_Missing(t *testing.T) {
+	_, err := ReadArchiveIndex(filepath.Join(t.TempDir(), "old.tar.gz.enc"), make([]byte, 32))
+	if !errors.Is(err, ErrNoArchiveIndex) {
+		t.Errorf("got %v, want ErrNoArchiveIndex", err)
+	}
+}
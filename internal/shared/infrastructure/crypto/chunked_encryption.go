@@ -0,0 +1,250 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Files written by EncryptFile are split into chunks sealed independently
+// with AES-GCM, so any byte range can be decrypted without reading what comes
+// before it. The layout is
+//
+//	magic | chunk size (uint32) | base nonce | sealed chunk 0 | sealed chunk 1 | ...
+//
+// Every chunk holds chunkSize plaintext bytes except the last one, which
+// holds fewer and may be empty. Chunk i is sealed with the base nonce XOR i,
+// and its index and a final flag are authenticated, so chunks cannot be
+// reordered, dropped or truncated unnoticed.
+var chunkedMagic = []byte("JBCHUNK1")
+
+const defaultChunkSize = 1 << 20
+
+// ErrNotSeekable is returned for files written with the older whole-file
+// encryption, which can only be decrypted in one piece.
+var ErrNotSeekable = errors.New("file uses whole-file encryption and cannot be read partially")
+
+type chunkedHeader struct {
+	chunkSize int64
+	nonce     []byte
+	raw       []byte
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(base []byte, index uint64) []byte {
+	nonce := make([]byte, len(base))
+	copy(nonce, base)
+	tail := nonce[len(nonce)-8:]
+	binary.BigEndian.PutUint64(tail, binary.BigEndian.Uint64(tail)^index)
+	return nonce
+}
+
+func chunkAAD(header []byte, index uint64, final bool) []byte {
+	aad := make([]byte, len(header)+9)
+	copy(aad, header)
+	binary.BigEndian.PutUint64(aad[len(header):], index)
+	if final {
+		aad[len(aad)-1] = 1
+	}
+	return aad
+}
+
+// encryptChunked seals everything read from r into w using the chunked layout.
+func encryptChunked(w io.Writer, r io.Reader, key []byte, chunkSize int) error {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	header := make([]byte, 0, len(chunkedMagic)+4+len(nonce))
+	header = append(header, chunkedMagic...)
+	header = binary.BigEndian.AppendUint32(header, uint32(chunkSize))
+	header = append(header, nonce...)
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	plain := make([]byte, chunkSize)
+	sealed := make([]byte, 0, chunkSize+gcm.Overhead())
+	for index := uint64(0); ; index++ {
+		n, err := io.ReadFull(r, plain)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		final := n < chunkSize
+		sealed = gcm.Seal(sealed[:0], chunkNonce(nonce, index), plain[:n], chunkAAD(header, index, final))
+		if _, err := w.Write(sealed); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+	}
+}
+
+// readChunkedHeader parses the header at the start of r. Files without the
+// chunked magic return ErrNotSeekable.
+func readChunkedHeader(r io.ReaderAt, nonceSize int) (*chunkedHeader, error) {
+	raw := make([]byte, len(chunkedMagic)+4+nonceSize)
+	if _, err := r.ReadAt(raw, 0); err != nil {
+		if err == io.EOF {
+			return nil, ErrNotSeekable
+		}
+		return nil, err
+	}
+	if !bytes.Equal(raw[:len(chunkedMagic)], chunkedMagic) {
+		return nil, ErrNotSeekable
+	}
+	chunkSize := int64(binary.BigEndian.Uint32(raw[len(chunkedMagic):]))
+	if chunkSize == 0 {
+		return nil, errors.New("malformed chunked file: zero chunk size")
+	}
+	return &chunkedHeader{
+		chunkSize: chunkSize,
+		nonce:     raw[len(chunkedMagic)+4:],
+		raw:       raw,
+	}, nil
+}
+
+// chunkReader decrypts a chunked file on demand, starting at a plaintext
+// offset.
+type chunkReader struct {
+	r       io.ReaderAt
+	gcm     cipher.AEAD
+	header  *chunkedHeader
+	chunks  uint64
+	lastLen int64
+	index   uint64
+	buf     []byte
+	pending []byte
+}
+
+// NewDecryptingReader returns the plaintext of a file written by EncryptFile
+// from offset onwards, decrypting only the chunks it reaches. size is the
+// size of the encrypted file.
+func NewDecryptingReader(r io.ReaderAt, size int64, key []byte, offset int64) (io.Reader, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	header, err := readChunkedHeader(r, gcm.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+
+	sealedSize := header.chunkSize + int64(gcm.Overhead())
+	body := size - int64(len(header.raw))
+	lastLen := body % sealedSize
+	if body < int64(gcm.Overhead()) || lastLen < int64(gcm.Overhead()) {
+		return nil, errors.New("malformed chunked file: truncated")
+	}
+	cr := &chunkReader{
+		r:       r,
+		gcm:     gcm,
+		header:  header,
+		chunks:  uint64(body/sealedSize) + 1,
+		lastLen: lastLen,
+		index:   uint64(offset / header.chunkSize),
+		buf:     make([]byte, sealedSize),
+	}
+	if cr.index >= cr.chunks {
+		return nil, fmt.Errorf("offset %d is past the end of the file", offset)
+	}
+	if err := cr.fill(); err != nil {
+		return nil, err
+	}
+	skip := offset % header.chunkSize
+	if skip > int64(len(cr.pending)) {
+		return nil, fmt.Errorf("offset %d is past the end of the file", offset)
+	}
+	cr.pending = cr.pending[skip:]
+	return cr, nil
+}
+
+// fill decrypts the chunk at cr.index into cr.pending.
+func (cr *chunkReader) fill() error {
+	final := cr.index == cr.chunks-1
+	sealedSize := cr.header.chunkSize + int64(cr.gcm.Overhead())
+	n := sealedSize
+	if final {
+		n = cr.lastLen
+	}
+	buf := cr.buf[:n]
+	if _, err := cr.r.ReadAt(buf, int64(len(cr.header.raw))+int64(cr.index)*sealedSize); err != nil && err != io.EOF {
+		return err
+	}
+	plain, err := cr.gcm.Open(buf[:0], chunkNonce(cr.header.nonce, cr.index), buf, chunkAAD(cr.header.raw, cr.index, final))
+	if err != nil {
+		return fmt.Errorf("chunk %d: %w", cr.index, err)
+	}
+	cr.pending = plain
+	cr.index++
+	return nil
+}
+
+func (cr *chunkReader) Read(p []byte) (int, error) {
+	for len(cr.pending) == 0 {
+		if cr.index >= cr.chunks {
+			return 0, io.EOF
+		}
+		if err := cr.fill(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, cr.pending)
+	cr.pending = cr.pending[n:]
+	return n, nil
+}
+
+// OpenDecrypted opens an encrypted file for reading from offset. Whole-file
+// encrypted files are decrypted in memory and only accept offset 0.
+func OpenDecrypted(source string, key []byte, offset int64) (io.ReadCloser, error) {
+	f, err := os.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	r, err := NewDecryptingReader(f, info.Size(), key, offset)
+	if errors.Is(err, ErrNotSeekable) && offset == 0 {
+		data, readErr := io.ReadAll(f)
+		_ = f.Close()
+		if readErr != nil {
+			return nil, readErr
+		}
+		plaintext, err := decryptBytes(data, key)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(bytes.NewReader(plaintext)), nil
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{r, f}, nil
+}
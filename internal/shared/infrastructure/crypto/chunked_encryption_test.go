@@ -0,0 +1,183 @@
+package crypto
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func randomBytes(t *testing.T, n int) []byte {
+	t.Helper()
+	data := make([]byte, n)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatalf("failed to generate data: %v", err)
+	}
+	return data
+}
+
+func sealChunked(t *testing.T, plaintext []byte, key []byte, chunkSize int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := encryptChunked(&buf, bytes.NewReader(plaintext), key, chunkSize); err != nil {
+		t.Fatalf("failed to encrypt: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestChunkedEncryption_ReadFromOffset(t *testing.T) {
+	key := randomBytes(t, 32)
+	for _, size := range []int{0, 1, 63, 64, 65, 640, 1000} {
+		plaintext := randomBytes(t, size)
+		sealed := sealChunked(t, plaintext, key, 64)
+
+		for _, offset := range []int{0, size / 3, size / 2, size} {
+			r, err := NewDecryptingReader(bytes.NewReader(sealed), int64(len(sealed)), key, int64(offset))
+			if err != nil {
+				t.Fatalf("size %d offset %d: failed to open: %v", size, offset, err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("size %d offset %d: failed to read: %v", size, offset, err)
+			}
+			if !bytes.Equal(got, plaintext[offset:]) {
+				t.Errorf("size %d offset %d: plaintext mismatch", size, offset)
+			}
+		}
+	}
+}
+
+func TestChunkedEncryption_DetectsTampering(t *testing.T) {
+	key := randomBytes(t, 32)
+	sealed := sealChunked(t, randomBytes(t, 200), key, 64)
+	sealedChunk := 64 + 16
+
+	readAll := func(data []byte) error {
+		r, err := NewDecryptingReader(bytes.NewReader(data), int64(len(data)), key, 0)
+		if err != nil {
+			return err
+		}
+		_, err = io.ReadAll(r)
+		return err
+	}
+
+	flipped := append([]byte(nil), sealed...)
+	flipped[len(flipped)-1] ^= 1
+	if readAll(flipped) == nil {
+		t.Error("expected an error for a modified chunk")
+	}
+
+	header := len(chunkedMagic) + 4 + 12
+	truncated := sealed[:header+3*sealedChunk]
+	if readAll(truncated) == nil {
+		t.Error("expected an error for a file truncated at a chunk boundary")
+	}
+
+	swapped := append([]byte(nil), sealed...)
+	copy(swapped[header:], sealed[header+sealedChunk:header+2*sealedChunk])
+	copy(swapped[header+sealedChunk:], sealed[header:header+sealedChunk])
+	if readAll(swapped) == nil {
+		t.Error("expected an error for reordered chunks")
+	}
+
+	if readAll(sealed) != nil {
+		t.Error("expected the untouched file to decrypt")
+	}
+}
+
+func TestDecryptFile_WholeFileEncryption(t *testing.T) {
+	key := randomBytes(t, 32)
+	plaintext := randomBytes(t, 300)
+	sealed, err := encryptBytes(plaintext, key)
+	if err != nil {
+		t.Fatalf("failed to encrypt: %v", err)
+	}
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "old.tar.gz.enc")
+	if err := os.WriteFile(source, sealed, 0644); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+
+	got, err := DecryptFileToMemory(source, key)
+	if err != nil {
+		t.Fatalf("failed to decrypt: %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Error("plaintext mismatch")
+	}
+
+	if _, err := OpenDecrypted(source, key, 10); !errors.Is(err, ErrNotSeekable) {
+		t.Errorf("got %v, want ErrNotSeekable", err)
+	}
+}
+
+func TestExtractEncryptedSubtree(t *testing.T) {
+	source := t.TempDir()
+	want := map[string][]byte{}
+	for _, name := range []string{"a/1.bin", "a/2.bin", "b/1.bin", "b/2.bin", "c/1.bin"} {
+		data := randomBytes(t, archiveBlockSize/2+100)
+		want[name] = data
+		path := filepath.Join(source, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	dir := t.TempDir()
+	tarPath := filepath.Join(dir, "snap.tar.gz")
+	index, err := CompressDirectoryWithIndex(source, tarPath)
+	if err != nil {
+		t.Fatalf("failed to compress: %v", err)
+	}
+	if len(index.Blocks) < 2 {
+		t.Fatalf("expected several gzip blocks, got %d", len(index.Blocks))
+	}
+
+	key := randomBytes(t, 32)
+	encPath := tarPath + ".enc"
+	if err := EncryptFile(tarPath, encPath, key); err != nil {
+		t.Fatalf("failed to encrypt: %v", err)
+	}
+
+	// The whole archive still decrypts to a regular tar.gz.
+	if err := DecryptFile(encPath, filepath.Join(dir, "out.tar.gz"), key); err != nil {
+		t.Fatalf("failed to decrypt: %v", err)
+	}
+	if err := DecompressTarGz(filepath.Join(dir, "out.tar.gz"), filepath.Join(dir, "out")); err != nil {
+		t.Fatalf("failed to decompress: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "out", "c", "1.bin")); !bytes.Equal(got, want["c/1.bin"]) {
+		t.Error("c/1.bin mismatch after full decryption")
+	}
+
+	for _, p := range []string{"b", "c/1.bin", "a/1.bin"} {
+		got := map[string][]byte{}
+		err := ExtractEncryptedSubtree(encPath, key, index, p, func(header *tar.Header, content io.Reader) error {
+			data, err := io.ReadAll(content)
+			got[header.Name] = data
+			return err
+		})
+		if err != nil {
+			t.Fatalf("%s: failed to extract: %v", p, err)
+		}
+		for name, data := range want {
+			if !within(name, p) {
+				if _, ok := got[name]; ok {
+					t.Errorf("%s: unexpected member %s", p, name)
+				}
+				continue
+			}
+			if !bytes.Equal(got[name], data) {
+				t.Errorf("%s: member %s mismatch", p, name)
+			}
+		}
+	}
+}
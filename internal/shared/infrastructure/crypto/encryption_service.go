@@ -7,6 +7,7 @@ import (
 	"crypto/cipher"
 	"crypto/rand"
 	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -86,6 +87,8 @@ func DeriveKey(masterKey string, backupID string) ([]byte, error) {
 	return key, nil
 }
 
+// EncryptFile encrypts source to target in independently sealed chunks (see
+// NewDecryptingReader), streaming rather than loading the file into memory.
 func EncryptFile(source string, target string, key []byte) error {
 	inFile, err := os.Open(source)
 	if err != nil {
@@ -107,78 +110,93 @@ func EncryptFile(source string, target string, key []byte) error {
 		}
 	}()
 
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return err
-	}
+	return encryptChunked(outFile, inFile, key, defaultChunkSize)
+}
 
-	gcm, err := cipher.NewGCM(block)
+// DecryptFile decrypts a file written by EncryptFile, or by the whole-file
+// encryption used before archives were chunked, to target.
+func DecryptFile(source string, target string, key []byte) error {
+	r, err := OpenDecrypted(source, key, 0)
 	if err != nil {
 		return err
 	}
+	defer func() { _ = r.Close() }()
 
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return err
-	}
-
-	if _, err := outFile.Write(nonce); err != nil {
+	outFile, err := os.Create(target)
+	if err != nil {
 		return err
 	}
-
-	plaintext, err := io.ReadAll(inFile)
-	if err != nil {
+	if _, err := io.Copy(outFile, r); err != nil {
+		_ = outFile.Close()
 		return err
 	}
-
-	ciphertext := gcm.Seal(nil, nonce, plaintext, nil)
-	_, err = outFile.Write(ciphertext)
-	return err
+	return outFile.Close()
 }
 
-func DecryptFile(source string, target string, key []byte) error {
-	inFile, err := os.Open(source)
+// DecryptFileToMemory decrypts a file like DecryptFile without touching the
+// disk.
+func DecryptFileToMemory(source string, key []byte) ([]byte, error) {
+	r, err := OpenDecrypted(source, key, 0)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer func() {
-		if err := inFile.Close(); err != nil {
-			log.Printf("WARNING: Failed to close input file %s: %v", source, err)
-		}
-	}()
+	defer func() { _ = r.Close() }()
+	return io.ReadAll(r)
+}
 
+// decryptBytes opens a nonce-prefixed AES-GCM ciphertext.
+func decryptBytes(data []byte, key []byte) ([]byte, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	gcm, err := cipher.NewGCM(block)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(inFile, nonce); err != nil {
-		return err
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("malformed ciphertext")
 	}
 
-	ciphertext, err := io.ReadAll(inFile)
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+// encryptBytes seals plaintext with AES-GCM, prefixing the random nonce.
+func encryptBytes(plaintext []byte, key []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	gcm, err := cipher.NewGCM(block)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return os.WriteFile(target, plaintext, 0644)
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
 }
 
 func CompressDirectory(source string, target string) error {
+	_, err := CompressDirectoryWithIndex(source, target)
+	return err
+}
+
+// CompressDirectoryWithIndex packs source into a tar.gz at target and returns
+// an index of the members, recording where each one starts in the tar stream.
+// The gzip stream is restarted every archiveBlockSize bytes, at a member
+// boundary, so reading can start at any block (see ExtractEncryptedSubtree).
+func CompressDirectoryWithIndex(source string, target string) (*ArchiveIndex, error) {
 	tarFile, err := os.Create(target)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer func() {
 		if err := tarFile.Close(); err != nil {
@@ -186,13 +204,13 @@ func CompressDirectory(source string, target string) error {
 		}
 	}()
 
-	gw := gzip.NewWriter(tarFile)
-	defer func() { _ = gw.Close() }()
+	compressed := &countingWriter{w: tarFile}
+	blocks := &gzipBlockWriter{out: compressed, gw: gzip.NewWriter(compressed)}
+	counter := &countingWriter{w: blocks}
+	tw := tar.NewWriter(counter)
 
-	tw := tar.NewWriter(gw)
-	defer func() { _ = tw.Close() }()
-
-	return filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+	index := &ArchiveIndex{Entries: []ArchiveIndexEntry{}, Blocks: []ArchiveBlock{{}}}
+	err = filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -208,21 +226,90 @@ func CompressDirectory(source string, target string) error {
 		}
 		header.Name = relPath
 
+		// The writer pads the previous member when the next header is
+		// written, so flush it to know where this header starts.
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		if counter.n-index.Blocks[len(index.Blocks)-1].TarOffset >= archiveBlockSize {
+			if err := blocks.restart(); err != nil {
+				return err
+			}
+			index.Blocks = append(index.Blocks, ArchiveBlock{Offset: compressed.n, TarOffset: counter.n})
+		}
+		entry := ArchiveIndexEntry{
+			Name:    filepath.ToSlash(relPath),
+			IsDir:   info.IsDir(),
+			Size:    header.Size,
+			Mode:    header.Mode,
+			ModTime: info.ModTime().UTC(),
+			Offset:  counter.n,
+		}
+
 		if err := tw.WriteHeader(header); err != nil {
 			return err
 		}
 
-		if !info.IsDir() {
+		if info.Mode().IsRegular() {
 			file, err := os.Open(path)
 			if err != nil {
 				return err
 			}
 			defer func() { _ = file.Close() }()
-			_, err = io.Copy(tw, file)
-			return err
+			hash := sha256.New()
+			if _, err := io.Copy(io.MultiWriter(tw, hash), file); err != nil {
+				return err
+			}
+			entry.Hash = hex.EncodeToString(hash.Sum(nil))
 		}
+		index.Entries = append(index.Entries, entry)
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := blocks.gw.Close(); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// archiveBlockSize is how much uncompressed tar data goes into one gzip
+// member before CompressDirectoryWithIndex starts the next one.
+const archiveBlockSize = 1 << 20
+
+// gzipBlockWriter compresses into a series of concatenated gzip members,
+// which gzip readers treat as one stream.
+type gzipBlockWriter struct {
+	out io.Writer
+	gw  *gzip.Writer
+}
+
+func (b *gzipBlockWriter) Write(p []byte) (int, error) {
+	return b.gw.Write(p)
+}
+
+// restart ends the current gzip member and starts a new one.
+func (b *gzipBlockWriter) restart() error {
+	if err := b.gw.Close(); err != nil {
+		return err
+	}
+	b.gw = gzip.NewWriter(b.out)
+	return nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
 }
 
 func DecompressTarGz(source string, targetDir string) error {
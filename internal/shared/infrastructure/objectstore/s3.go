@@ -0,0 +1,428 @@
+// Package objectstore talks to S3-compatible object storage (AWS S3, MinIO,
+// and the like) over the plain REST API, signing requests with AWS
+// Signature Version 4.
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned for an object that does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// EmptyPayloadHash is the SHA-256 of an empty body.
+const EmptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+const (
+	// maxSinglePut is the largest object S3 accepts in one PUT.
+	maxSinglePut = 5 << 30
+	// defaultPartSize is the part size of multipart uploads; with the limit
+	// of 10000 parts it allows objects up to 2.5 TiB.
+	defaultPartSize = 256 << 20
+)
+
+// Config locates a bucket. Endpoint is the base URL of the service, for
+// example https://s3.eu-west-1.amazonaws.com or http://minio:9000. PathStyle
+// addresses the bucket in the path instead of the host name, which MinIO
+// and most self-hosted services need. BandwidthLimit caps the rate request
+// bodies are sent at, in bytes per second, 0 for no limit.
+type Config struct {
+	Endpoint       string
+	Region         string
+	Bucket         string
+	AccessKey      string
+	SecretKey      string
+	PathStyle      bool
+	BandwidthLimit int64
+}
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// S3Client reads and writes the objects of one bucket.
+type S3Client struct {
+	cfg        Config
+	endpoint   *url.URL
+	httpClient *http.Client
+	now        func() time.Time
+	partSize   int64
+	singlePut  int64
+	limiter    *rateLimiter
+}
+
+func NewS3Client(cfg Config) (*S3Client, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("bucket is required")
+	}
+	endpoint, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("invalid endpoint %q", cfg.Endpoint)
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	client := &S3Client{
+		cfg:        cfg,
+		endpoint:   endpoint,
+		httpClient: &http.Client{},
+		now:        time.Now,
+		partSize:   defaultPartSize,
+		singlePut:  maxSinglePut,
+	}
+	if cfg.BandwidthLimit > 0 {
+		client.limiter = newRateLimiter(cfg.BandwidthLimit)
+	}
+	return client, nil
+}
+
+// Head returns the metadata of an object, or ErrNotFound.
+func (c *S3Client) Head(ctx context.Context, key string) (ObjectInfo, error) {
+	resp, err := c.do(ctx, http.MethodHead, key, nil, nil, EmptyPayloadHash, 0)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	_ = resp.Body.Close()
+
+	info := ObjectInfo{Key: key, Size: resp.ContentLength}
+	if modified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.LastModified = modified
+	}
+	return info, nil
+}
+
+// Get opens an object for reading, or returns ErrNotFound.
+func (c *S3Client) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, http.MethodGet, key, nil, nil, EmptyPayloadHash, 0)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Put stores data under key.
+func (c *S3Client) Put(ctx context.Context, key string, data []byte) error {
+	sum := sha256.Sum256(data)
+	resp, err := c.do(ctx, http.MethodPut, key, nil, bytes.NewReader(data), hex.EncodeToString(sum[:]), int64(len(data)))
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// PutFile uploads the file at path under key. payloadHash is the hex SHA-256
+// of the file, which the service checks the upload against. Files too large
+// for one request are uploaded in parts.
+func (c *S3Client) PutFile(ctx context.Context, key string, path string, payloadHash string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() > c.singlePut {
+		return c.putMultipart(ctx, key, f, info.Size())
+	}
+
+	resp, err := c.do(ctx, http.MethodPut, key, nil, f, payloadHash, info.Size())
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Delete removes an object. Deleting a missing object is not an error.
+func (c *S3Client) Delete(ctx context.Context, key string) error {
+	resp, err := c.do(ctx, http.MethodDelete, key, nil, nil, EmptyPayloadHash, 0)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// List calls fn for every object whose key starts with prefix, in key order.
+func (c *S3Client) List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := c.do(ctx, http.MethodGet, "", query, nil, EmptyPayloadHash, 0)
+		if err != nil {
+			return err
+		}
+
+		var page struct {
+			Contents []struct {
+				Key          string
+				Size         int64
+				LastModified time.Time
+			}
+			IsTruncated           bool
+			NextContinuationToken string
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		_ = resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to decode object list: %w", err)
+		}
+
+		for _, o := range page.Contents {
+			if err := fn(ObjectInfo{Key: o.Key, Size: o.Size, LastModified: o.LastModified}); err != nil {
+				return err
+			}
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// putMultipart uploads f in parts, hashing each part before sending it. An
+// upload that fails is aborted so its parts do not linger in the bucket.
+func (c *S3Client) putMultipart(ctx context.Context, key string, f *os.File, size int64) error {
+	resp, err := c.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil, EmptyPayloadHash, 0)
+	if err != nil {
+		return err
+	}
+	var initiated struct {
+		UploadID string `xml:"UploadId"`
+	}
+	err = xml.NewDecoder(resp.Body).Decode(&initiated)
+	_ = resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to start multipart upload: %w", err)
+	}
+
+	if err := c.uploadParts(ctx, key, initiated.UploadID, f, size); err != nil {
+		abort, abortErr := c.do(context.Background(), http.MethodDelete, key, url.Values{"uploadId": {initiated.UploadID}}, nil, EmptyPayloadHash, 0)
+		if abortErr == nil {
+			_ = abort.Body.Close()
+		}
+		return err
+	}
+	return nil
+}
+
+type completedPart struct {
+	PartNumber int
+	ETag       string
+}
+
+func (c *S3Client) uploadParts(ctx context.Context, key string, uploadID string, f *os.File, size int64) error {
+	var parts []completedPart
+	for offset, number := int64(0), 1; offset < size; offset, number = offset+c.partSize, number+1 {
+		length := min(c.partSize, size-offset)
+
+		h := sha256.New()
+		if _, err := io.Copy(h, io.NewSectionReader(f, offset, length)); err != nil {
+			return err
+		}
+
+		query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}
+		resp, err := c.do(ctx, http.MethodPut, key, query, io.NewSectionReader(f, offset, length), hex.EncodeToString(h.Sum(nil)), length)
+		if err != nil {
+			return fmt.Errorf("failed to upload part %d: %w", number, err)
+		}
+		_ = resp.Body.Close()
+		parts = append(parts, completedPart{PartNumber: number, ETag: resp.Header.Get("ETag")})
+	}
+
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(body)
+	resp, err := c.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, bytes.NewReader(body), hex.EncodeToString(sum[:]), int64(len(body)))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// The service can report a failed completion in a 200 response.
+	var result struct {
+		XMLName xml.Name
+		Message string
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err == nil && result.XMLName.Local == "Error" {
+		return fmt.Errorf("failed to complete multipart upload: %s", result.Message)
+	}
+	return nil
+}
+
+// do sends a signed request for key, or for the bucket when key is empty.
+// Responses other than 2xx are turned into errors.
+func (c *S3Client) do(ctx context.Context, method string, key string, query url.Values, body io.Reader, payloadHash string, size int64) (*http.Response, error) {
+	if body != nil && size == 0 {
+		// An empty body of unknown type would be sent chunked.
+		body = http.NoBody
+	} else if body != nil && c.limiter != nil {
+		body = &throttledReader{ctx: ctx, r: body, limiter: c.limiter}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.objectURL(key, query), body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	c.sign(req, payloadHash)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	var apiErr struct {
+		Code    string
+		Message string
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if xml.Unmarshal(msg, &apiErr) == nil && apiErr.Code != "" {
+		return nil, fmt.Errorf("%s %s: %s: %s", method, key, apiErr.Code, apiErr.Message)
+	}
+	return nil, fmt.Errorf("%s %s: unexpected status %s", method, key, resp.Status)
+}
+
+func (c *S3Client) objectURL(key string, query url.Values) string {
+	u := *c.endpoint
+	objectPath := "/" + key
+	if c.cfg.PathStyle {
+		objectPath = "/" + c.cfg.Bucket + objectPath
+	} else {
+		u.Host = c.cfg.Bucket + "." + u.Host
+	}
+	u.Path = c.endpoint.Path + objectPath
+	u.RawPath = uriEncode(c.endpoint.Path+objectPath, false)
+	u.RawQuery = canonicalQuery(query)
+	return u.String()
+}
+
+// sign adds the AWS Signature Version 4 authorization to req. The host and
+// every header already set on req are signed.
+func (c *S3Client) sign(req *http.Request, payloadHash string) {
+	now := c.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path, false),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + c.cfg.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+c.cfg.SecretKey), date)
+	key = hmacSHA256(key, c.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.cfg.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes query sorted by key the way Signature Version 4
+// expects, which differs from url.Values.Encode in how spaces are escaped.
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything but the unreserved characters, and
+// slashes unless encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case 'A' <= ch && ch <= 'Z', 'a' <= ch && ch <= 'z', '0' <= ch && ch <= '9',
+			ch == '-', ch == '_', ch == '.', ch == '~':
+			b.WriteByte(ch)
+		case ch == '/' && !encodeSlash:
+			b.WriteByte(ch)
+		default:
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
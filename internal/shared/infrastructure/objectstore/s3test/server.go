@@ -0,0 +1,243 @@
+// Package s3test provides an in-memory S3-compatible server for tests. It
+// serves a single bucket in path style and implements the small part of the
+// API the object store client uses.
+package s3test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	Bucket    = "backups"
+	AccessKey = "test-access-key"
+	SecretKey = "test-secret-key"
+)
+
+// pageSize is the number of keys in one page of a listing, small so that
+// tests exercise pagination.
+const pageSize = 100
+
+type object struct {
+	data     []byte
+	modified time.Time
+}
+
+// Server is a fake S3 bucket.
+type Server struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	objects map[string]object
+	uploads map[string]map[int][]byte
+	puts    int
+}
+
+// NewServer starts a server that is closed when the test ends.
+func NewServer(t *testing.T) *Server {
+	t.Helper()
+	s := &Server{objects: make(map[string]object), uploads: make(map[string]map[int][]byte)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Puts returns the number of objects uploaded so far.
+func (s *Server) Puts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.puts
+}
+
+// Object returns the content stored under key.
+func (s *Server) Object(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.objects[key]
+	return o.data, ok
+}
+
+// Keys returns the stored keys in order.
+func (s *Server) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.objects))
+	for k := range s.objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Delete removes an object behind the client's back.
+func (s *Server) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, key)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential="+AccessKey+"/") {
+		writeError(w, http.StatusForbidden, "AccessDenied")
+		return
+	}
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+Bucket)
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	key = strings.TrimPrefix(key, "/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := r.URL.Query()
+	switch {
+	case key == "" && r.Method == http.MethodGet:
+		s.list(w, query.Get("prefix"), query.Get("continuation-token"))
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		id := strconv.Itoa(len(s.uploads) + 1)
+		s.uploads[id] = make(map[int][]byte)
+		writeXML(w, struct {
+			XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+			UploadID string   `xml:"UploadId"`
+		}{UploadID: id})
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		s.complete(w, r, key, query.Get("uploadId"))
+	case r.Method == http.MethodPut:
+		data, ok := readPayload(w, r)
+		if !ok {
+			return
+		}
+		if query.Has("uploadId") {
+			number, _ := strconv.Atoi(query.Get("partNumber"))
+			s.uploads[query.Get("uploadId")][number] = data
+			w.Header().Set("ETag", fmt.Sprintf("%q", "part-"+query.Get("partNumber")))
+			return
+		}
+		s.objects[key] = object{data: data, modified: time.Now().UTC()}
+		s.puts++
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		o, ok := s.objects[key]
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(o.data)))
+		w.Header().Set("Last-Modified", o.modified.Format(http.TimeFormat))
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(o.data)
+		}
+	case r.Method == http.MethodDelete:
+		if query.Has("uploadId") {
+			delete(s.uploads, query.Get("uploadId"))
+		} else {
+			delete(s.objects, key)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+// readPayload reads the body of an upload and checks it against its signed
+// hash, as S3 does.
+func readPayload(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "IncompleteBody")
+		return nil, false
+	}
+	sum := sha256.Sum256(data)
+	if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+		writeError(w, http.StatusBadRequest, "XAmzContentSHA256Mismatch")
+		return nil, false
+	}
+	return data, true
+}
+
+func (s *Server) list(w http.ResponseWriter, prefix string, after string) {
+	keys := make([]string, 0, len(s.objects))
+	for k := range s.objects {
+		if strings.HasPrefix(k, prefix) && k > after {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	type content struct {
+		Key          string
+		Size         int
+		LastModified string
+	}
+	page := struct {
+		XMLName               xml.Name `xml:"ListBucketResult"`
+		Contents              []content
+		IsTruncated           bool
+		NextContinuationToken string `xml:",omitempty"`
+	}{}
+	if len(keys) > pageSize {
+		keys = keys[:pageSize]
+		page.IsTruncated = true
+		page.NextContinuationToken = keys[len(keys)-1]
+	}
+	for _, k := range keys {
+		o := s.objects[k]
+		page.Contents = append(page.Contents, content{Key: k, Size: len(o.data), LastModified: o.modified.Format(time.RFC3339)})
+	}
+	writeXML(w, page)
+}
+
+func (s *Server) complete(w http.ResponseWriter, r *http.Request, key string, uploadID string) {
+	if _, ok := readPayload(w, r); !ok {
+		return
+	}
+	parts, ok := s.uploads[uploadID]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+	numbers := make([]int, 0, len(parts))
+	for n := range parts {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+
+	var data []byte
+	for _, n := range numbers {
+		data = append(data, parts[n]...)
+	}
+	delete(s.uploads, uploadID)
+	s.objects[key] = object{data: data, modified: time.Now().UTC()}
+	s.puts++
+	writeXML(w, struct {
+		XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+		Key     string
+	}{Key: key})
+}
+
+func writeXML(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_ = xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string
+		Message string
+	}{Code: code, Message: code})
+}
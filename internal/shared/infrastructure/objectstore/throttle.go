@@ -0,0 +1,82 @@
+package objectstore
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// throttleChunk is the most a throttled read returns at once, so the rate
+// stays even within a large body.
+const throttleChunk = 32 << 10
+
+// rateLimiter spaces out the bytes sent by every request of a client so
+// they average rate bytes per second. After an idle spell it starts over
+// rather than letting the unused time through as a burst.
+type rateLimiter struct {
+	rate  int64
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+
+	mu    sync.Mutex
+	start time.Time
+	sent  int64
+}
+
+func newRateLimiter(rate int64) *rateLimiter {
+	return &rateLimiter{rate: rate, now: time.Now, sleep: sleepContext}
+}
+
+// wait accounts for n bytes sent and blocks until the rate allows them.
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := l.now()
+	if l.start.IsZero() || now.Sub(l.start) > l.due()+time.Second {
+		l.start, l.sent = now, 0
+	}
+	l.sent += int64(n)
+	delay := l.due() - now.Sub(l.start)
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	return l.sleep(ctx, delay)
+}
+
+// due is how long the bytes sent so far take at the rate.
+func (l *rateLimiter) due() time.Duration {
+	return time.Duration(l.sent * int64(time.Second) / l.rate)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// throttledReader reads a request body no faster than its limiter allows.
+type throttledReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rateLimiter
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if len(p) > throttleChunk {
+		p = p[:throttleChunk]
+	}
+	n, err := t.r.Read(p)
+	if n > 0 {
+		if waitErr := t.limiter.wait(t.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,201 @@
+package restorestream
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+// A frame is
+//
+//	kind | payload length (uint32) | payload | CRC-32C of everything before it
+//
+// Data frames carry the stream, the end frame its total size and SHA-256,
+// and an error frame the reason the worker gave up.
+const (
+	frameData  byte = 'd'
+	frameEnd   byte = 'e'
+	frameError byte = 'x'
+
+	maxFrameSize = 256 << 10
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrCorrupt is returned for a frame whose checksum does not match. The
+// transfer can be resumed from the data received before it.
+var ErrCorrupt = errors.New("corrupt frame in restore stream")
+
+// RemoteError is an error the worker reported instead of finishing the
+// stream. Resuming does not help.
+type RemoteError struct {
+	Message string
+}
+
+func (e *RemoteError) Error() string {
+	return "worker error: " + e.Message
+}
+
+// Summary describes a complete stream.
+type Summary struct {
+	Size int64
+	Sum  [sha256.Size]byte
+}
+
+// Writer frames a stream for the CLI. Bytes before the offset the CLI asked
+// for are hashed but not sent, as the CLI already holds them.
+type Writer struct {
+	w      io.Writer
+	skip   int64
+	size   int64
+	hash   hash.Hash
+	buf    []byte
+	closed bool
+}
+
+func NewWriter(w io.Writer, offset int64) *Writer {
+	return &Writer{
+		w:    w,
+		skip: offset,
+		hash: sha256.New(),
+		buf:  make([]byte, 0, maxFrameSize),
+	}
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	written := len(p)
+	w.hash.Write(p)
+	w.size += int64(len(p))
+
+	if w.skip > 0 {
+		n := min(int64(len(p)), w.skip)
+		w.skip -= n
+		p = p[n:]
+	}
+	for len(p) > 0 {
+		n := min(maxFrameSize-len(w.buf), len(p))
+		w.buf = append(w.buf, p[:n]...)
+		p = p[n:]
+		if len(w.buf) == maxFrameSize {
+			if err := w.flush(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return written, nil
+}
+
+func (w *Writer) flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	err := writeFrame(w.w, frameData, w.buf)
+	w.buf = w.buf[:0]
+	return err
+}
+
+// Close sends what is left and the end frame.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.skip > 0 {
+		err := errors.New("the stream is shorter than the offset to resume at: the backup changed, restore again from the start")
+		_ = w.Fail(err)
+		return err
+	}
+	if err := w.flush(); err != nil {
+		return err
+	}
+	end := binary.BigEndian.AppendUint64(nil, uint64(w.size))
+	end = w.hash.Sum(end)
+	return writeFrame(w.w, frameEnd, end)
+}
+
+// Fail tells the CLI the stream cannot be completed.
+func (w *Writer) Fail(reason error) error {
+	w.closed = true
+	msg := reason.Error()
+	if len(msg) > maxFrameSize {
+		msg = msg[:maxFrameSize]
+	}
+	return writeFrame(w.w, frameError, []byte(msg))
+}
+
+func writeFrame(w io.Writer, kind byte, payload []byte) error {
+	header := make([]byte, 5, 5+len(payload)+4)
+	header[0] = kind
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+	frame := append(header, payload...)
+	frame = binary.BigEndian.AppendUint32(frame, crc32.Checksum(frame, crcTable))
+	_, err := w.Write(frame)
+	return err
+}
+
+// Receive copies the data frames of r to dst until the end frame, calling
+// progress with the size of each frame written. Only frames whose checksum
+// matches reach dst, so after an error dst holds a prefix of the stream to
+// resume from. The summary covers the whole stream, including what came
+// before a resume.
+func Receive(r io.Reader, dst io.Writer, progress func(n int)) (*Summary, error) {
+	header := make([]byte, 5)
+	buf := make([]byte, 0, maxFrameSize+4)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return nil, fmt.Errorf("stream interrupted: %w", err)
+		}
+		length := binary.BigEndian.Uint32(header[1:])
+		if length > maxFrameSize {
+			return nil, ErrCorrupt
+		}
+		buf = buf[:length+4]
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, fmt.Errorf("stream interrupted: %w", err)
+		}
+		payload := buf[:length]
+		crc := crc32.Update(crc32.Checksum(header, crcTable), crcTable, payload)
+		if crc != binary.BigEndian.Uint32(buf[length:]) {
+			return nil, ErrCorrupt
+		}
+
+		switch header[0] {
+		case frameData:
+			if _, err := dst.Write(payload); err != nil {
+				return nil, err
+			}
+			if progress != nil {
+				progress(len(payload))
+			}
+		case frameEnd:
+			if len(payload) != 8+sha256.Size {
+				return nil, ErrCorrupt
+			}
+			summary := &Summary{Size: int64(binary.BigEndian.Uint64(payload))}
+			copy(summary.Sum[:], payload[8:])
+			return summary, nil
+		case frameError:
+			return nil, &RemoteError{Message: string(payload)}
+		default:
+			return nil, ErrCorrupt
+		}
+	}
+}
+
+// Verify checks that r, the whole received stream, matches the summary.
+func (s *Summary) Verify(r io.Reader) error {
+	h := sha256.New()
+	n, err := io.Copy(h, r)
+	if err != nil {
+		return err
+	}
+	if n != s.Size || !bytes.Equal(h.Sum(nil), s.Sum[:]) {
+		return fmt.Errorf("the received stream does not match the one the worker sent: the backup may have changed since an interrupted transfer")
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package restorestream
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net"
+	"testing"
+)
+
+func randomStream(t *testing.T, n int) []byte {
+	t.Helper()
+	data := make([]byte, n)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatalf("failed to generate data: %v", err)
+	}
+	return data
+}
+
+func TestFrames_RoundTripAndResume(t *testing.T) {
+	data := randomStream(t, 3*maxFrameSize+123)
+
+	for _, offset := range []int64{0, 10, maxFrameSize, int64(len(data))} {
+		var wire bytes.Buffer
+		w := NewWriter(&wire, offset)
+		if _, err := w.Write(data); err != nil {
+			t.Fatalf("offset %d: write failed: %v", offset, err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("offset %d: close failed: %v", offset, err)
+		}
+
+		received := bytes.NewBuffer(append([]byte(nil), data[:offset]...))
+		progress := 0
+		summary, err := Receive(&wire, received, func(n int) { progress += n })
+		if err != nil {
+			t.Fatalf("offset %d: receive failed: %v", offset, err)
+		}
+		if progress != len(data)-int(offset) {
+			t.Errorf("offset %d: progress %d", offset, progress)
+		}
+		if err := summary.Verify(bytes.NewReader(received.Bytes())); err != nil {
+			t.Errorf("offset %d: %v", offset, err)
+		}
+	}
+}
+
+func TestFrames_Errors(t *testing.T) {
+	data := randomStream(t, 1000)
+
+	var wire bytes.Buffer
+	w := NewWriter(&wire, 0)
+	_, _ = w.Write(data)
+	_ = w.Close()
+	corrupt := wire.Bytes()
+	corrupt[10] ^= 1
+	var dst bytes.Buffer
+	if _, err := Receive(bytes.NewReader(corrupt), &dst, nil); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("got %v, want ErrCorrupt", err)
+	}
+	if dst.Len() != 0 {
+		t.Error("a corrupt frame must not reach the destination")
+	}
+
+	wire.Reset()
+	w = NewWriter(&wire, 0)
+	_, _ = w.Write(data)
+	_ = w.flush()
+	_ = w.Fail(errors.New("disk on fire"))
+	dst.Reset()
+	_, err := Receive(&wire, &dst, nil)
+	var remote *RemoteError
+	if !errors.As(err, &remote) || remote.Message != "disk on fire" {
+		t.Errorf("got %v, want the worker error", err)
+	}
+	if !bytes.Equal(dst.Bytes(), data) {
+		t.Error("frames before the error should be kept")
+	}
+
+	wire.Reset()
+	w = NewWriter(&wire, 2000)
+	_, _ = w.Write(data)
+	if err := w.Close(); err == nil {
+		t.Error("expected an error for an offset past the end of the stream")
+	}
+
+	summary := &Summary{Size: 3}
+	if err := summary.Verify(bytes.NewReader([]byte("abc"))); err == nil {
+		t.Error("expected a mismatch for the wrong hash")
+	}
+}
+
+func TestSession_Handshake(t *testing.T) {
+	session, err := NewSession()
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+
+	accept := func(offset int64) <-chan error {
+		result := make(chan error, 1)
+		go func() {
+			conn, err := listener.Accept()
+			if err != nil {
+				result <- err
+				return
+			}
+			defer func() { _ = conn.Close() }()
+			stream, err := session.Accept(conn, offset)
+			if err != nil {
+				result <- err
+				return
+			}
+			_, err = stream.Write([]byte("ok"))
+			result <- err
+		}()
+		return result
+	}
+
+	done := accept(42)
+	conn, offset, err := Dial(listener.Addr().String(), session.Fingerprint, session.Token)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	reply, _ := io.ReadAll(io.LimitReader(conn, 2))
+	_ = conn.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("accept failed: %v", err)
+	}
+	if offset != 42 || string(reply) != "ok" {
+		t.Errorf("got offset %d reply %q", offset, reply)
+	}
+
+	done = accept(0)
+	if _, _, err := Dial(listener.Addr().String(), session.Fingerprint, "wrong-token"); err == nil {
+		t.Error("expected the handshake to fail with the wrong token")
+	}
+	if err := <-done; !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("got %v, want ErrUnauthorized", err)
+	}
+
+	other, _ := NewSession()
+	done = accept(0)
+	if _, _, err := Dial(listener.Addr().String(), other.Fingerprint, session.Token); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("got %v, want ErrUnauthorized for an unexpected certificate", err)
+	}
+	<-done
+}
@@ -0,0 +1,174 @@
+// Package restorestream implements the channel a worker uses to send a
+// restore straight to the CLI.
+//
+// The CLI listens with TLS using an ephemeral self-signed certificate, and
+// passes the fingerprint of that certificate and a random token to the worker
+// along with the restore task. The worker pins the fingerprint, so it only
+// talks to that CLI, and proves it holds the token with an HMAC over keying
+// material exported from the TLS session, so the token never crosses the
+// wire and a proof cannot be replayed on another connection. The CLI then
+// sends the offset it already holds and the worker sends the rest of the
+// stream in checksummed frames.
+package restorestream
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"time"
+)
+
+const (
+	exporterLabel = "justbackup restore stream"
+	proofSize     = sha256.Size
+	// handshakeTimeout bounds the TLS handshake and the exchange of the
+	// proof and offset that follows it.
+	handshakeTimeout = 30 * time.Second
+)
+
+// ErrUnauthorized is returned when the peer fails authentication.
+var ErrUnauthorized = errors.New("restore stream peer failed authentication")
+
+// Session holds what the CLI needs to accept one restore: the token and the
+// ephemeral certificate the worker is told to expect.
+type Session struct {
+	Token       string
+	Fingerprint string
+	certificate tls.Certificate
+}
+
+// NewSession generates a random token and an ephemeral certificate.
+func NewSession() (*Session, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate restore token: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate restore key: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "justbackup restore"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create restore certificate: %w", err)
+	}
+
+	return &Session{
+		Token:       hex.EncodeToString(raw),
+		Fingerprint: fingerprint(der),
+		certificate: tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key},
+	}, nil
+}
+
+func fingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
+
+// proof binds the token to one TLS connection.
+func proof(state tls.ConnectionState, token string) ([]byte, error) {
+	material, err := state.ExportKeyingMaterial(exporterLabel, nil, 32)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, []byte(token))
+	mac.Write(material)
+	return mac.Sum(nil), nil
+}
+
+// Accept completes the handshake of a connection accepted by the CLI: it
+// checks the proof of the worker and sends the offset the stream resumes at.
+// The returned connection carries the frames of the stream.
+func (s *Session) Accept(conn net.Conn, offset int64) (net.Conn, error) {
+	tlsConn := tls.Server(conn, &tls.Config{
+		Certificates: []tls.Certificate{s.certificate},
+		MinVersion:   tls.VersionTLS13,
+	})
+	_ = tlsConn.SetDeadline(time.Now().Add(handshakeTimeout))
+	if err := tlsConn.Handshake(); err != nil {
+		return nil, fmt.Errorf("TLS handshake failed: %w", err)
+	}
+
+	expected, err := proof(tlsConn.ConnectionState(), s.Token)
+	if err != nil {
+		return nil, err
+	}
+	received := make([]byte, proofSize)
+	if _, err := io.ReadFull(tlsConn, received); err != nil {
+		return nil, fmt.Errorf("error reading proof: %w", err)
+	}
+	if !hmac.Equal(received, expected) {
+		return nil, ErrUnauthorized
+	}
+
+	if err := binary.Write(tlsConn, binary.BigEndian, uint64(offset)); err != nil {
+		return nil, err
+	}
+	_ = tlsConn.SetDeadline(time.Time{})
+	return tlsConn, nil
+}
+
+// Dial connects the worker to the CLI at addr. It only accepts the
+// certificate with the given fingerprint, proves it holds token, and returns
+// the connection along with the offset the CLI asks the stream to resume at.
+func Dial(addr string, fingerprintHex string, token string) (net.Conn, int64, error) {
+	if fingerprintHex == "" {
+		return nil, 0, fmt.Errorf("%w: no certificate fingerprint for the CLI, which may be too old", ErrUnauthorized)
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	conn, err := tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{
+		MinVersion: tls.VersionTLS13,
+		// The certificate is self-signed and pinned below instead.
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 || !hmac.Equal([]byte(fingerprint(rawCerts[0])), []byte(fingerprintHex)) {
+				return fmt.Errorf("%w: unexpected certificate", ErrUnauthorized)
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	_ = conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	p, err := proof(conn.ConnectionState(), token)
+	if err == nil {
+		_, err = conn.Write(p)
+	}
+	var offset uint64
+	if err == nil {
+		err = binary.Read(conn, binary.BigEndian, &offset)
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, 0, fmt.Errorf("restore stream handshake failed: %w", err)
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return conn, int64(offset), nil
+}
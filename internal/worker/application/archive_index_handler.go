@@ -0,0 +1,213 @@
+package application
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/config"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/crypto"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+)
+
+// HandleListArchive lists a directory inside the encrypted archive at
+// task.Path from its index, without decrypting the archive.
+func HandleListArchive(ctx context.Context, task workerDto.WorkerTask, redisClient *redis.Client) {
+	log.Printf("Listing %q inside archive %s", task.ArchivePath, task.Path)
+
+	result := workerDto.WorkerResult{
+		Type:   workerDto.TaskTypeListArchive,
+		TaskID: task.TaskID,
+	}
+
+	listing, err := listArchive(task)
+	if err != nil {
+		log.Printf("Failed to list archive %s: %v", task.Path, err)
+		result.Status = "failed"
+		result.Message = fmt.Sprintf("Failed to list archive: %v", err)
+	} else {
+		result.Status = "completed"
+		result.Message = fmt.Sprintf("Found %d entries", len(listing.Files))
+		result.Data = listing
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("Failed to marshal result: %v", err)
+		return
+	}
+
+	channel := "worker_sync_responses"
+	if err := redisClient.Publish(ctx, channel, data).Err(); err != nil {
+		log.Printf("Failed to publish result to %s: %v", channel, err)
+	}
+}
+
+func listArchive(task workerDto.WorkerTask) (workerDto.ListFilesResult, error) {
+	key, err := archiveKey(task.BackupID)
+	if err != nil {
+		return workerDto.ListFilesResult{}, err
+	}
+
+	index, err := crypto.ReadArchiveIndex(task.Path, key)
+	if errors.Is(err, crypto.ErrNoArchiveIndex) {
+		return workerDto.ListFilesResult{Files: []workerDto.FileListItem{}, MissingIndex: true}, nil
+	}
+	if err != nil {
+		return workerDto.ListFilesResult{}, err
+	}
+
+	entries, err := index.List(task.ArchivePath)
+	if err != nil {
+		return workerDto.ListFilesResult{}, err
+	}
+
+	files := make([]workerDto.FileListItem, 0, len(entries))
+	for _, e := range entries {
+		files = append(files, workerDto.FileListItem{
+			Name:  path.Base(e.Name),
+			IsDir: e.IsDir,
+			Size:  e.Size,
+		})
+	}
+	return workerDto.ListFilesResult{Files: files}, nil
+}
+
+// archiveKey derives the key the archives of a backup are encrypted with.
+func archiveKey(backupID string) ([]byte, error) {
+	cfg, err := config.LoadWorkerConfig()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.EncryptionKey == "" {
+		return nil, fmt.Errorf("ENCRYPTION_KEY not set in worker configuration")
+	}
+	key, err := crypto.DeriveKey(cfg.EncryptionKey, backupID)
+	if err != nil {
+		return nil, fmt.Errorf("key derivation failed: %w", err)
+	}
+	return key, nil
+}
+
+// walkArchiveIndex hands the regular files of an archive index to emit in
+// batches of at most batchSize, like walkCatalog does for a directory.
+func walkArchiveIndex(index *crypto.ArchiveIndex, batchSize int, emit func(files []workerDto.CatalogFile, final bool) error) error {
+	batch := make([]workerDto.CatalogFile, 0, batchSize)
+	for _, e := range index.Entries {
+		if e.IsDir || e.Hash == "" {
+			continue
+		}
+		batch = append(batch, workerDto.CatalogFile{
+			Path:    strings.TrimPrefix(path.Clean("/"+e.Name), "/"),
+			Size:    e.Size,
+			ModTime: e.ModTime,
+			Hash:    e.Hash,
+		})
+		if len(batch) == batchSize {
+			if err := emit(batch, false); err != nil {
+				return err
+			}
+			batch = make([]workerDto.CatalogFile, 0, batchSize)
+		}
+	}
+	return emit(batch, true)
+}
+
+// readArchiveSubtree decrypts the part of the archive at task.Path holding
+// task.ArchivePath and hands the members below that path to handle, named
+// relative to its parent, the same way a plain restore names them.
+func readArchiveSubtree(task workerDto.WorkerTask, handle func(name string, header *tar.Header, content io.Reader) error) error {
+	key, err := archiveKey(task.BackupID)
+	if err != nil {
+		return err
+	}
+
+	index, err := crypto.ReadArchiveIndex(task.Path, key)
+	if err != nil {
+		return err
+	}
+	// Fail before decrypting anything if the path is not in the archive.
+	if _, err := index.Subtree(task.ArchivePath); err != nil {
+		return err
+	}
+
+	return crypto.ExtractEncryptedSubtree(task.Path, key, index, task.ArchivePath, func(header *tar.Header, content io.Reader) error {
+		return handle(subtreeMemberName(task.ArchivePath, header.Name), header, content)
+	})
+}
+
+// subtreeMemberName names a member of the subtree at p of an archive
+// relative to the parent of p.
+func subtreeMemberName(p string, member string) string {
+	parent := path.Dir(strings.Trim(path.Clean("/"+p), "/"))
+	name := strings.Trim(path.Clean("/"+member), "/")
+	if parent != "." {
+		name = strings.TrimPrefix(name, parent+"/")
+	}
+	return name
+}
+
+// streamArchiveSubtree writes the subtree at task.ArchivePath of an encrypted
+// archive to w as a tar.gz stream.
+func streamArchiveSubtree(w io.Writer, task workerDto.WorkerTask) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	err := readArchiveSubtree(task, func(name string, header *tar.Header, content io.Reader) error {
+		header.Name = name
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err := io.Copy(tw, content)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// extractArchiveSubtree unpacks the subtree at task.ArchivePath of an
+// encrypted archive into dir.
+func extractArchiveSubtree(task workerDto.WorkerTask, dir string) error {
+	return readArchiveSubtree(task, func(name string, header *tar.Header, content io.Reader) error {
+		return writeArchiveMember(dir, name, header, content)
+	})
+}
+
+// writeArchiveMember writes a directory or regular file read from an
+// archive under dir.
+func writeArchiveMember(dir string, name string, header *tar.Header, content io.Reader) error {
+	target := filepath.Join(dir, filepath.FromSlash(name))
+	switch header.Typeflag {
+	case tar.TypeDir:
+		return os.MkdirAll(target, 0755)
+	case tar.TypeReg:
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode))
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, content); err != nil {
+			_ = f.Close()
+			return err
+		}
+		return f.Close()
+	}
+	return nil
+}
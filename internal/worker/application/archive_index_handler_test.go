@@ -0,0 +1,37 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/crypto"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalkArchiveIndex(t *testing.T) {
+	index := &crypto.ArchiveIndex{Entries: []crypto.ArchiveIndexEntry{
+		{Name: ".", IsDir: true},
+		{Name: "a.txt", Size: 1, Hash: "h1"},
+		{Name: "etc", IsDir: true},
+		{Name: "etc/hosts", Size: 2, Hash: "h2"},
+		{Name: "etc/link"},
+		{Name: "etc/motd", Size: 3, Hash: "h3"},
+	}}
+
+	var batches [][]workerDto.CatalogFile
+	var finals []bool
+	err := walkArchiveIndex(index, 2, func(files []workerDto.CatalogFile, final bool) error {
+		batches = append(batches, files)
+		finals = append(finals, final)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{false, true}, finals)
+	assert.Len(t, batches[0], 2)
+	assert.Equal(t, "a.txt", batches[0][0].Path)
+	assert.Equal(t, "etc/hosts", batches[0][1].Path)
+	assert.Equal(t, "h2", batches[0][1].Hash)
+	assert.Len(t, batches[1], 1)
+	assert.Equal(t, "etc/motd", batches[1][0].Path)
+}
@@ -1,7 +1,6 @@
 package application
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -28,87 +27,147 @@ func HandleBackupTask(ctx context.Context, task workerDto.WorkerTask, redisClien
 		return
 	}
 
+	// A run whose quota is already used up does not start.
+	if err := checkQuotaBudget(task); err != nil {
+		budget := *task.Quota
+		reportQuotaExceeded(ctx, redisClient, resultQueue, task, err, workerDto.QuotaExceededResult{Scope: string(budget.Scope), Budget: budget.Bytes})
+		return
+	}
+
 	// 1. Prepare Destination
-	finalDest, err := prepareBackupDestination(task, cfg)
+	// Incremental runs write into workDest (a partial snapshot) and only
+	// become finalDest once the sync has succeeded.
+	workDest, finalDest, err := prepareBackupDestination(task, cfg)
 	if err != nil {
 		reportError(ctx, redisClient, resultQueue, task, "Failed to prepare destination", err)
 		return
 	}
 
+	// fail reports an error unless the task was aborted by a worker shutdown
+	// before its snapshot was promoted, in which case the partial snapshot is
+	// discarded and the task is left to be re-queued by the consumer.
+	fail := func(msg string, err error) {
+		if taskAborted(ctx, task, fmt.Errorf("%s: %w", msg, err)) {
+			discardPartialSnapshot(task, workDest)
+			return
+		}
+		reportError(ctx, redisClient, resultQueue, task, msg, err)
+	}
+
 	// 2. Setup Workspace (if needed)
 	taskPath, sessionTempDir, cleanupWorkspace, err := setupEphemeralWorkspace(task)
 	if cleanupWorkspace != nil {
 		defer cleanupWorkspace()
 	}
 	if err != nil {
-		reportError(ctx, redisClient, resultQueue, task, "Failed to setup ephemeral workspace", err)
+		fail("Failed to setup ephemeral workspace", err)
 		return
 	}
 
 	// 3. Pre-Backup Hooks
-	if err := executeHooks(task.Hooks, "pre", finalDest, sessionTempDir); err != nil {
-		reportError(ctx, redisClient, resultQueue, task, "Pre-backup hooks failed", err)
+	if err := executeHooks(ctx, task.Hooks, "pre", workDest, sessionTempDir); err != nil {
+		fail("Pre-backup hooks failed", err)
 		return
 	}
 
 	// 4. Execute Backup (Rsync)
-	if err := executeRsyncOperation(task, cfg, finalDest, taskPath); err != nil {
-		reportError(ctx, redisClient, resultQueue, task, "Rsync execution failed", err)
+	// Under a quota rsync is stopped as soon as the run adds more than its
+	// budget. A plain mirror keeps what was synced until then.
+	rsyncCtx, quota := startQuotaWatch(ctx, task, workDest)
+	stats, err := executeRsyncOperation(rsyncCtx, task, cfg, workDest, taskPath)
+	if quotaErr := quota.Stop(); quotaErr != nil {
+		discardPartialSnapshot(task, workDest)
+		reportQuotaExceeded(ctx, redisClient, resultQueue, task, quotaErr, quota.Result())
+		return
+	}
+	if err != nil {
+		fail("Rsync execution failed", err)
 		return
 	}
 
+	// Promote the partial snapshot now that it is complete
+	if task.Incremental {
+		baseDest := NormalizePath(task.Destination, TaskBackupRoot(task, cfg.ContainerBackupRoot), task.HostPath)
+		if err := promoteSnapshot(baseDest, workDest, finalDest); err != nil {
+			fail("Failed to promote snapshot", err)
+			return
+		}
+		// Running the task again would take a second snapshot.
+		commitTask(ctx)
+	}
+
 	// 5. Post-Processing (Encryption & Compression)
 	finalArtifactPath := finalDest
 	if task.Encrypted {
 		finalArtifactPath, err = performEncryptionWorkflow(task, finalDest, cfg)
 		if err != nil {
-			reportError(ctx, redisClient, resultQueue, task, "Encryption workflow failed", err)
+			fail("Encryption workflow failed", err)
 			return
 		}
 	}
 
 	// 6. Post-Backup Hooks
-	if err := executeHooks(task.Hooks, "post", finalDest, sessionTempDir); err != nil {
-		reportError(ctx, redisClient, resultQueue, task, "Post-backup hooks failed", err)
+	if err := executeHooks(ctx, task.Hooks, "post", finalDest, sessionTempDir); err != nil {
+		fail("Post-backup hooks failed", err)
 		return
 	}
 
-	// 7. Calculate Size & Report Success
-	size := calculateArtifactSize(finalArtifactPath)
+	// 7. Measure Space Usage & Report Success
+	usage, err := measureBackupUsage(finalArtifactPath, task.Incremental)
+	if err != nil {
+		// Sizes only feed reporting, so a failed measurement does not fail the backup.
+		log.Printf("Failed to measure space usage of %s: %v", finalArtifactPath, err)
+	}
 
-	destRelPath := NormalizePath(task.Destination, cfg.HostBackupRoot, task.HostPath)
+	// With --link-dest unchanged files are linked rather than sent, so the
+	// files rsync transferred are the ones that changed since the last run.
+	usage.Files = stats.Files
+	usage.ChangedFiles = stats.Transferred
+	usage.Path = NormalizePath(task.Destination, cfg.HostBackupRoot, task.HostPath)
 	if task.Encrypted {
-		destRelPath += ".tar.gz.enc"
+		usage.Path += ".tar.gz.enc"
+	}
+	// The server queues the catalog of the new snapshot on its own, so a
+	// slow catalog does not hold up the result.
+	if task.Incremental {
+		usage.Snapshot = filepath.Base(finalDest)
 	}
 
-	PublishResult(ctx, redisClient, resultQueue, workerDto.WorkerResult{
+	reportResult(ctx, redisClient, resultQueue, workerDto.WorkerResult{
 		Type:    workerDto.TaskTypeBackup,
 		TaskID:  task.TaskID,
 		JobID:   task.JobID,
 		Status:  "completed",
 		Message: "Backup completed successfully",
-		Data: map[string]string{
-			"path": destRelPath,
-			"size": size,
-		},
+		Data:    usage,
 	})
 }
 
 // prepareBackupDestination calculates the target directory and ensures it exists.
-func prepareBackupDestination(task workerDto.WorkerTask, cfg *config.WorkerConfig) (string, error) {
-	baseDest := NormalizePath(task.Destination, cfg.ContainerBackupRoot, task.HostPath)
-	finalDest := baseDest
+// It returns the directory rsync writes into and the final artifact directory.
+// Both are the same for plain mirrors; incremental runs write into a partial
+// snapshot that is renamed to its timestamp only on success.
+func prepareBackupDestination(task workerDto.WorkerTask, cfg *config.WorkerConfig) (string, string, error) {
+	baseDest := NormalizePath(task.Destination, TaskBackupRoot(task, cfg.ContainerBackupRoot), task.HostPath)
+
+	if !task.Incremental {
+		if err := os.MkdirAll(baseDest, 0755); err != nil {
+			return "", "", fmt.Errorf("mkdir %s failed: %w", baseDest, err)
+		}
+		return baseDest, baseDest, nil
+	}
 
-	if task.Incremental {
-		timestamp := time.Now().Format("2006-01-02_15-04-05")
-		finalDest = fmt.Sprintf("%s/%s", baseDest, timestamp)
+	timestamp := snapshotName(time.Now())
+	if err := os.MkdirAll(baseDest, 0755); err != nil {
+		return "", "", fmt.Errorf("mkdir %s failed: %w", baseDest, err)
 	}
 
-	if err := os.MkdirAll(finalDest, 0755); err != nil {
-		return "", fmt.Errorf("mkdir %s failed: %w", finalDest, err)
+	workDest, err := preparePartialSnapshot(baseDest, timestamp)
+	if err != nil {
+		return "", "", err
 	}
 
-	return finalDest, nil
+	return workDest, fmt.Sprintf("%s/%s", baseDest, timestamp), nil
 }
 
 // setupEphemeralWorkspace handles {{SESSION_TEMP_DIR}} logic.
@@ -138,13 +197,15 @@ func setupEphemeralWorkspace(task workerDto.WorkerTask) (string, string, func(),
 	return tempDir, tempDir, cleanup, nil
 }
 
-// executeRsyncOperation wraps the low-level rsync call logic.
-func executeRsyncOperation(task workerDto.WorkerTask, cfg *config.WorkerConfig, finalDest string, sourcePath string) error {
+// executeRsyncOperation wraps the low-level rsync call logic and returns what
+// rsync reported about the transfer.
+// The rsync process is killed if ctx is cancelled.
+func executeRsyncOperation(ctx context.Context, task workerDto.WorkerTask, cfg *config.WorkerConfig, workDest string, sourcePath string) (rsyncStats, error) {
 	sshKeyPath := cfg.SSHKeyPath
 
 	// Determine Link Dest for incremental
 	var useLinkDest bool
-	baseDest := NormalizePath(task.Destination, cfg.ContainerBackupRoot, task.HostPath)
+	baseDest := NormalizePath(task.Destination, TaskBackupRoot(task, cfg.ContainerBackupRoot), task.HostPath)
 	linkDest := fmt.Sprintf("%s/latest", baseDest)
 
 	if task.Incremental {
@@ -170,8 +231,8 @@ func executeRsyncOperation(task workerDto.WorkerTask, cfg *config.WorkerConfig,
 		excludeFlags = append(excludeFlags, fmt.Sprintf("--exclude=%s", exception))
 	}
 
-	args := BuildRsyncArgs(sshKeyPath, task, useLinkDest, excludeFlags, rsyncSource, finalDest)
-	cmd := exec.Command("rsync", args...)
+	args := BuildRsyncArgs(sshKeyPath, task, useLinkDest, excludeFlags, rsyncSource, workDest)
+	cmd := exec.CommandContext(ctx, "rsync", args...)
 
 	log.Printf("Executing: %s", cmd.String())
 
@@ -184,15 +245,15 @@ func executeRsyncOperation(task workerDto.WorkerTask, cfg *config.WorkerConfig,
 	}
 
 	if err != nil {
-		return handleRsyncError(err, output)
-	}
-
-	// Update Incremental Link
-	if task.Incremental {
-		updateLatestSymlink(linkDest, finalDest)
+		if ctx.Err() != nil {
+			return rsyncStats{}, fmt.Errorf("rsync interrupted: %w", ctx.Err())
+		}
+		if err := handleRsyncError(err, output); err != nil {
+			return rsyncStats{}, err
+		}
 	}
 
-	return nil
+	return parseRsyncStats(output), nil
 }
 
 // performEncryptionWorkflow handles compression, encryption, and cleanup of raw files.
@@ -209,7 +270,8 @@ func performEncryptionWorkflow(task workerDto.WorkerTask, sourceDir string, cfg
 	}
 
 	tarPath := sourceDir + ".tar.gz"
-	if err := crypto.CompressDirectory(sourceDir, tarPath); err != nil {
+	index, err := crypto.CompressDirectoryWithIndex(sourceDir, tarPath)
+	if err != nil {
 		return "", fmt.Errorf("compression failed: %w", err)
 	}
 
@@ -220,6 +282,12 @@ func performEncryptionWorkflow(task workerDto.WorkerTask, sourceDir string, cfg
 
 	log.Printf("Backup encrypted successfully: %s", encPath)
 
+	// Without its index the archive can still be restored as a whole, so a
+	// failure here does not fail the backup.
+	if err := crypto.WriteArchiveIndex(index, encPath, key); err != nil {
+		log.Printf("WARNING: Failed to write archive index for %s: %v", encPath, err)
+	}
+
 	// Clean up intermediate files
 	if err := os.RemoveAll(sourceDir); err != nil {
 		log.Printf("WARNING: Failed to remove source dir %s: %v", sourceDir, err)
@@ -230,7 +298,7 @@ func performEncryptionWorkflow(task workerDto.WorkerTask, sourceDir string, cfg
 
 	// Update symlink if incremental
 	if task.Incremental {
-		baseDest := NormalizePath(task.Destination, cfg.ContainerBackupRoot, task.HostPath)
+		baseDest := NormalizePath(task.Destination, TaskBackupRoot(task, cfg.ContainerBackupRoot), task.HostPath)
 		linkDestEnc := path.Join(baseDest, "latest.tar.gz.enc")
 		updateLatestSymlink(linkDestEnc, encPath)
 	}
@@ -238,35 +306,35 @@ func performEncryptionWorkflow(task workerDto.WorkerTask, sourceDir string, cfg
 	return encPath, nil
 }
 
-// Helper: updates the 'latest' symlink for incremental backups
-func updateLatestSymlink(linkPath, targetPath string) {
-	if err := os.Remove(linkPath); err != nil && !os.IsNotExist(err) {
-		log.Printf("WARNING: Failed to remove old symlink %s: %v", linkPath, err)
+// discardPartialSnapshot removes the partial snapshot of an interrupted
+// incremental run so it is never promoted or resumed. The 'latest' symlink is
+// left untouched because it is only repointed after a successful sync.
+// Plain mirrors are kept as-is: the next run simply resumes the sync into them.
+func discardPartialSnapshot(task workerDto.WorkerTask, finalDest string) {
+	if !task.Incremental || finalDest == "" {
+		return
 	}
-	if err := os.Symlink(filepath.Base(targetPath), linkPath); err != nil {
-		log.Printf("WARNING: Failed to update symlink %s -> %s: %v", linkPath, targetPath, err)
+	log.Printf("Removing partial snapshot %s", finalDest)
+	if err := os.RemoveAll(finalDest); err != nil {
+		log.Printf("WARNING: Failed to remove partial snapshot %s: %v", finalDest, err)
 	}
 }
 
-// calculateArtifactSize uses 'du' to measure the final output.
-func calculateArtifactSize(path string) string {
-	cmd := exec.Command("du", "-sk", path)
-	// For file use -k, for dir -sk works for both usually but let's be safe
-	// In linux du -k works for file too.
-	// Original code had check. du -sk is safer for directory summary.
-
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	if err := cmd.Run(); err != nil {
-		log.Printf("Failed to calculate size for %s: %v", path, err)
-		return "0KB"
+// Helper: updates the 'latest' symlink for incremental backups.
+// The new link is created next to the old one and renamed over it, so readers
+// never observe a missing or dangling 'latest'.
+func updateLatestSymlink(linkPath, targetPath string) {
+	tmpLink := linkPath + ".tmp"
+	if err := os.Remove(tmpLink); err != nil && !os.IsNotExist(err) {
+		log.Printf("WARNING: Failed to remove stale temp symlink %s: %v", tmpLink, err)
 	}
-
-	fields := strings.Fields(out.String())
-	if len(fields) > 0 {
-		return fields[0] + "KB"
+	if err := os.Symlink(filepath.Base(targetPath), tmpLink); err != nil {
+		log.Printf("WARNING: Failed to create symlink %s -> %s: %v", tmpLink, targetPath, err)
+		return
+	}
+	if err := os.Rename(tmpLink, linkPath); err != nil {
+		log.Printf("WARNING: Failed to update symlink %s -> %s: %v", linkPath, targetPath, err)
 	}
-	return "0KB"
 }
 
 // handleRsyncError provides semantic error messages based on exit codes.
@@ -296,7 +364,7 @@ func handleRsyncError(err error, output []byte) error {
 // reportError is a helper to log and publish failure results consistently.
 func reportError(ctx context.Context, redisClient *redis.Client, queue string, task workerDto.WorkerTask, msg string, err error) {
 	log.Printf("%s: %v", msg, err)
-	PublishResult(ctx, redisClient, queue, workerDto.WorkerResult{
+	reportResult(ctx, redisClient, queue, workerDto.WorkerResult{
 		Type:    workerDto.TaskTypeBackup,
 		TaskID:  task.TaskID,
 		JobID:   task.JobID,
@@ -309,31 +377,41 @@ func reportError(ctx context.Context, redisClient *redis.Client, queue string, t
 func BuildRsyncArgs(sshKeyPath string, task workerDto.WorkerTask, useLinkDest bool, excludeFlags []string, source, finalDest string) []string {
 	sshOpts := fmt.Sprintf("ssh -i %s -o StrictHostKeyChecking=no -p %d", sshKeyPath, task.Port)
 
-	args := []string{"-az", "--no-owner", "--no-group", "--numeric-ids", "-e", sshOpts}
+	args := []string{"-az", "--no-owner", "--no-group", "--numeric-ids", "--stats", "-e", sshOpts}
 	args = append(args, excludeFlags...)
 
 	if useLinkDest {
 		args = append(args, "--link-dest=../latest")
 	}
 
+	if task.Quota != nil && task.Quota.Bytes > 0 {
+		// A file larger than the whole quota budget could never fit
+		args = append(args, fmt.Sprintf("--max-size=%d", task.Quota.Bytes))
+	}
+
+	if task.Incremental {
+		// Keep partially transferred files so an interrupted snapshot can be resumed
+		args = append(args, "--partial-dir="+rsyncPartialDir)
+	}
+
 	args = append(args, source, finalDest)
 	return args
 }
 
 // Hook Execution Logic
-func executeHooks(hooks []workerDto.HookTask, phase string, backupDest string, sessionTempDir string) error {
+func executeHooks(ctx context.Context, hooks []workerDto.HookTask, phase string, backupDest string, sessionTempDir string) error {
 	for _, hook := range hooks {
 		if !hook.Enabled || hook.Phase != phase {
 			continue
 		}
-		if err := executeHook(hook, backupDest, sessionTempDir); err != nil {
+		if err := executeHook(ctx, hook, backupDest, sessionTempDir); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func executeHook(hook workerDto.HookTask, backupDest string, sessionTempDir string) error {
+func executeHook(ctx context.Context, hook workerDto.HookTask, backupDest string, sessionTempDir string) error {
 	pluginDir := "/app/plugins"
 	scriptPath := filepath.Join(pluginDir, hook.Name+".sh")
 
@@ -347,7 +425,7 @@ func executeHook(hook workerDto.HookTask, backupDest string, sessionTempDir stri
 	}
 
 	log.Printf("Executing hook [%s]: %s", hook.Phase, hook.Name)
-	cmd := exec.Command("bash", cleanScriptPath)
+	cmd := exec.CommandContext(ctx, "bash", cleanScriptPath)
 
 	env := os.Environ()
 	env = append(env, fmt.Sprintf("BACKUP_DEST=%s", backupDest))
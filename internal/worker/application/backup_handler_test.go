@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
 	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
 	"github.com/stretchr/testify/assert"
 )
@@ -26,6 +27,7 @@ func TestBuildRsyncArgs(t *testing.T) {
 		assert.Contains(t, args, "--no-owner")
 		assert.Contains(t, args, "--no-group")
 		assert.Contains(t, args, "--numeric-ids")
+		assert.Contains(t, args, "--stats")
 		assert.Contains(t, args, "-e")
 		assert.Contains(t, args, expectedSSHOpts)
 		assert.Equal(t, source, args[len(args)-2])
@@ -51,6 +53,24 @@ func TestBuildRsyncArgs(t *testing.T) {
 
 		assert.NotContains(t, args, "--link-dest=../latest")
 	})
+
+	t.Run("Incremental keeps partial files for resume", func(t *testing.T) {
+		incrementalTask := task
+		incrementalTask.Incremental = true
+		args := BuildRsyncArgs(sshKeyPath, incrementalTask, true, []string{}, source, finalDest)
+
+		assert.Contains(t, args, "--partial-dir=.rsync-partial")
+		assert.Equal(t, finalDest, args[len(args)-1])
+	})
+
+	t.Run("Quota skips files larger than the budget", func(t *testing.T) {
+		quotaTask := task
+		quotaTask.Quota = &valueobjects.QuotaBudget{Bytes: 5000, Scope: valueobjects.QuotaScopeHost}
+		args := BuildRsyncArgs(sshKeyPath, quotaTask, false, []string{}, source, finalDest)
+
+		assert.Contains(t, args, "--max-size=5000")
+		assert.NotContains(t, BuildRsyncArgs(sshKeyPath, task, false, []string{}, source, finalDest), "--max-size=5000")
+	})
 }
 
 func TestValidateHookPath(t *testing.T) {
@@ -1,7 +1,11 @@
 package application
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
@@ -76,3 +80,44 @@ func TestExecuteRsyncOperation_Logic(t *testing.T) {
 		assert.Contains(t, args, "/tmp/session-123/")
 	})
 }
+
+func TestDiscardPartialSnapshot(t *testing.T) {
+	t.Run("Incremental snapshot is removed", func(t *testing.T) {
+		dir := t.TempDir()
+		snapshot := filepath.Join(dir, "2024-01-01_00-00-00")
+		assert.NoError(t, os.MkdirAll(snapshot, 0755))
+
+		discardPartialSnapshot(workerDto.WorkerTask{Incremental: true}, snapshot)
+
+		_, err := os.Stat(snapshot)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("Plain mirror is kept", func(t *testing.T) {
+		dir := t.TempDir()
+
+		discardPartialSnapshot(workerDto.WorkerTask{Incremental: false}, dir)
+
+		_, err := os.Stat(dir)
+		assert.NoError(t, err)
+	})
+}
+
+func TestTaskAborted(t *testing.T) {
+	task := workerDto.WorkerTask{TaskID: "task-1", Type: workerDto.TaskTypeBackup}
+	failure := errors.New("rsync failed")
+
+	ctx, committed := WithCommitMark(context.Background())
+	ctx, cancel := context.WithCancel(ctx)
+
+	assert.False(t, taskAborted(ctx, task, failure), "a failure of a running task is reported")
+
+	cancel()
+	assert.False(t, taskAborted(ctx, task, nil), "a task that finished is not aborted")
+	assert.True(t, taskAborted(ctx, task, failure), "a failure caused by a shutdown is left to the re-queued run")
+	assert.False(t, committed())
+
+	commitTask(ctx)
+	assert.True(t, committed())
+	assert.False(t, taskAborted(ctx, task, failure), "a committed task reports its failure")
+}
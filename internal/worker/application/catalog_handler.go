@@ -0,0 +1,222 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/config"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/crypto"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+)
+
+// catalogBatchSize bounds the number of files sent in one result message.
+const catalogBatchSize = 1000
+
+// catalogBatchContent bounds the file content carried by one result message;
+// a batch is sent early once its files hold this much text.
+const catalogBatchContent = 8 << 20
+
+// HandleCatalogTask builds the file catalog of the snapshot a backup run has
+// just taken, or rebuilds that of every snapshot of the backup when the task
+// names none, for snapshots taken before the catalog existed or after it was
+// lost. Encrypted archives are cataloged from their index.
+func HandleCatalogTask(ctx context.Context, task workerDto.WorkerTask, redisClient *redis.Client, resultQueue string) {
+	cfg, err := config.LoadWorkerConfig()
+	if err != nil {
+		log.Printf("CRITICAL: Failed to load worker config: %v", err)
+		return
+	}
+
+	root := TaskBackupRoot(task, cfg.ContainerBackupRoot)
+	backupDir := NormalizePath(task.Destination, root, task.HostPath)
+	if task.CatalogSnapshot != "" {
+		log.Printf("Cataloging snapshot %s of %s", task.CatalogSnapshot, backupDir)
+	} else {
+		log.Printf("Rebuilding file catalog for %s", backupDir)
+	}
+
+	// Snapshots of a backup share most of their files, so each one is
+	// only read once.
+	hashes := loadHashCache(catalogHashesPath(root, task.BackupID))
+	defer func() {
+		if err := hashes.save(); err != nil {
+			log.Printf("Failed to keep catalog hashes of %s: %v", backupDir, err)
+		}
+	}()
+
+	if !task.Incremental {
+		if task.Encrypted {
+			err = publishArchiveCatalog(ctx, redisClient, resultQueue, task.BackupID, "", backupDir+valueobjects.EncryptedSnapshotSuffix)
+		} else {
+			err = publishSnapshotCatalog(ctx, redisClient, resultQueue, task.BackupID, "", backupDir, contentLimit(task, cfg), hashes)
+		}
+		if err != nil {
+			log.Printf("Failed to catalog %s: %v", backupDir, err)
+		}
+		return
+	}
+
+	artifacts, err := scanSnapshotArtifacts(backupDir)
+	if err != nil {
+		log.Printf("Failed to read backup dir %s: %v", backupDir, err)
+		return
+	}
+	for _, a := range artifacts {
+		if task.CatalogSnapshot != "" && a.Name != task.CatalogSnapshot {
+			continue
+		}
+		if hasSnapshotDir(a) {
+			err = publishSnapshotCatalog(ctx, redisClient, resultQueue, task.BackupID, a.Name, filepath.Join(backupDir, a.Name), contentLimit(task, cfg), hashes)
+		} else {
+			err = publishArchiveCatalog(ctx, redisClient, resultQueue, task.BackupID, a.Name, filepath.Join(backupDir, a.Name+valueobjects.EncryptedSnapshotSuffix))
+		}
+		if err != nil {
+			log.Printf("Failed to catalog snapshot %s: %v", a.Name, err)
+		}
+	}
+}
+
+// contentLimit is the largest file whose text goes into the catalog for the
+// content index, or 0 when the backup does not use the index. Encrypted
+// backups never do: the index would hold their content in the clear.
+func contentLimit(task workerDto.WorkerTask, cfg *config.WorkerConfig) int64 {
+	if !task.ContentIndex || task.Encrypted {
+		return 0
+	}
+	return cfg.ContentIndexMaxSize
+}
+
+// publishSnapshotCatalog sends the catalog of the files under dir to the
+// server in batches, with the text of files up to maxContent bytes.
+func publishSnapshotCatalog(ctx context.Context, redisClient *redis.Client, resultQueue, backupID, snapshot, dir string, maxContent int64, hashes *hashCache) error {
+	return publishCatalog(ctx, redisClient, resultQueue, backupID, snapshot, func(emit func([]workerDto.CatalogFile, bool) error) error {
+		return walkCatalog(dir, catalogBatchSize, maxContent, hashes, emit)
+	})
+}
+
+// publishArchiveCatalog sends the catalog of an encrypted archive, read from
+// its index. Archives without an index are skipped.
+func publishArchiveCatalog(ctx context.Context, redisClient *redis.Client, resultQueue, backupID, snapshot, archive string) error {
+	key, err := archiveKey(backupID)
+	if err != nil {
+		return err
+	}
+	index, err := crypto.ReadArchiveIndex(archive, key)
+	if errors.Is(err, crypto.ErrNoArchiveIndex) {
+		log.Printf("Archive %s has no index. Nothing to catalog.", archive)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return publishCatalog(ctx, redisClient, resultQueue, backupID, snapshot, func(emit func([]workerDto.CatalogFile, bool) error) error {
+		return walkArchiveIndex(index, catalogBatchSize, emit)
+	})
+}
+
+// publishCatalog publishes the batches produced by walk. At least one batch
+// is always sent so that an emptied snapshot clears its previous catalog.
+func publishCatalog(ctx context.Context, redisClient *redis.Client, resultQueue, backupID, snapshot string, walk func(emit func([]workerDto.CatalogFile, bool) error) error) error {
+	sequence := 0
+	return walk(func(files []workerDto.CatalogFile, final bool) error {
+		batch := workerDto.CatalogBatch{
+			BackupID: backupID,
+			Snapshot: snapshot,
+			Sequence: sequence,
+			Final:    final,
+			Files:    files,
+		}
+		sequence++
+		PublishResult(ctx, redisClient, resultQueue, workerDto.WorkerResult{
+			Type:    workerDto.TaskTypeCatalog,
+			TaskID:  backupID,
+			Status:  "completed",
+			Message: fmt.Sprintf("Catalog batch %d of snapshot %q", batch.Sequence, snapshot),
+			Data:    batch,
+		})
+		return ctx.Err()
+	})
+}
+
+// walkCatalog hashes every regular file under dir and hands them to emit in
+// batches of at most batchSize. Files whose inode hashes already holds
+// unchanged are not read again; hashes may be nil. Text files of at most
+// maxContent bytes carry their content; a maxContent of 0 leaves content out.
+func walkCatalog(dir string, batchSize int, maxContent int64, hashes *hashCache, emit func(files []workerDto.CatalogFile, final bool) error) error {
+	batch := make([]workerDto.CatalogFile, 0, batchSize)
+	batchContent := 0
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == rsyncPartialDir {
+			return filepath.SkipDir
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		file := workerDto.CatalogFile{
+			Path:    filepath.ToSlash(rel),
+			Size:    info.Size(),
+			ModTime: info.ModTime().UTC(),
+		}
+		if maxContent > 0 && info.Size() <= maxContent {
+			// The content index needs the text of the file either way.
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			file.Hash, _ = hashes.hash(info, func() (string, error) {
+				sum := sha256.Sum256(data)
+				return hex.EncodeToString(sum[:]), nil
+			})
+			if isText(data) {
+				file.Content = string(data)
+				batchContent += len(data)
+			}
+		} else if file.Hash, err = hashes.hash(info, func() (string, error) { return hashFile(path) }); err != nil {
+			return err
+		}
+
+		batch = append(batch, file)
+		if len(batch) == batchSize || batchContent >= catalogBatchContent {
+			if err := emit(batch, false); err != nil {
+				return err
+			}
+			batch = make([]workerDto.CatalogFile, 0, batchSize)
+			batchContent = 0
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return emit(batch, true)
+}
+
+// isText reports whether data looks like text worth indexing: non-empty
+// UTF-8 without NUL bytes.
+func isText(data []byte) bool {
+	return len(data) > 0 && utf8.Valid(data) && bytes.IndexByte(data, 0) < 0
+}
@@ -0,0 +1,120 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalkCatalog(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "etc/hosts", "etc/motd"} {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(name), 0644))
+	}
+	assert.NoError(t, os.Symlink("hosts", filepath.Join(dir, "etc", "link")))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, rsyncPartialDir), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, rsyncPartialDir, "partial"), []byte("x"), 0644))
+
+	var batches [][]workerDto.CatalogFile
+	var finals []bool
+	err := walkCatalog(dir, 2, 0, nil, func(files []workerDto.CatalogFile, final bool) error {
+		batches = append(batches, files)
+		finals = append(finals, final)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{false, true}, finals)
+	assert.Len(t, batches[0], 2)
+	assert.Len(t, batches[1], 1)
+	assert.Equal(t, "a.txt", batches[0][0].Path)
+	assert.Equal(t, "etc/hosts", batches[0][1].Path)
+	assert.Equal(t, int64(len("etc/hosts")), batches[0][1].Size)
+	assert.Len(t, batches[0][1].Hash, 64)
+	assert.Equal(t, "etc/motd", batches[1][0].Path)
+}
+
+func TestWalkCatalog_EmptyDirSendsFinalBatch(t *testing.T) {
+	calls := 0
+	err := walkCatalog(t.TempDir(), 10, 0, nil, func(files []workerDto.CatalogFile, final bool) error {
+		calls++
+		assert.Empty(t, files)
+		assert.True(t, final)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestWalkCatalog_IndexesSmallTextFiles(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "a.conf"), []byte("listen 80\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "b.bin"), []byte{0x7f, 0x00, 0x01}, 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "c.log"), []byte("a line longer than the limit\n"), 0644))
+
+	var files []workerDto.CatalogFile
+	err := walkCatalog(dir, 10, 16, nil, func(batch []workerDto.CatalogFile, final bool) error {
+		files = append(files, batch...)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, files, 3)
+	assert.Equal(t, "listen 80\n", files[0].Content)
+	assert.Empty(t, files[1].Content)
+	assert.Empty(t, files[2].Content)
+	for _, f := range files {
+		assert.Len(t, f.Hash, 64)
+	}
+}
+
+func TestWalkCatalog_CarriesHashesForward(t *testing.T) {
+	base := t.TempDir()
+	first := filepath.Join(base, "2024-01-01_00-00-00")
+	second := filepath.Join(base, "2024-01-02_00-00-00")
+	assert.NoError(t, os.MkdirAll(first, 0755))
+	assert.NoError(t, os.MkdirAll(second, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(first, "same.txt"), []byte("unchanged"), 0644))
+	assert.NoError(t, os.Link(filepath.Join(first, "same.txt"), filepath.Join(second, "same.txt")))
+	assert.NoError(t, os.WriteFile(filepath.Join(second, "new.txt"), []byte("new"), 0644))
+
+	catalog := func(dir string, hashes *hashCache) map[string]string {
+		found := make(map[string]string)
+		assert.NoError(t, walkCatalog(dir, 10, 0, hashes, func(files []workerDto.CatalogFile, final bool) error {
+			for _, f := range files {
+				found[f.Path] = f.Hash
+			}
+			return nil
+		}))
+		return found
+	}
+
+	cachePath := catalogHashesPath(base, "backup-1")
+	hashes := loadHashCache(cachePath)
+	before := catalog(first, hashes)
+	assert.NoError(t, hashes.save())
+
+	// Rewriting the linked file in place with the same size and time makes
+	// a read tell the two runs apart: the cached hash must win.
+	same := filepath.Join(second, "same.txt")
+	info, err := os.Stat(same)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(same, []byte("UNCHANGED"), 0644))
+	assert.NoError(t, os.Chtimes(same, info.ModTime(), info.ModTime()))
+
+	after := catalog(second, loadHashCache(cachePath))
+	assert.Equal(t, before["same.txt"], after["same.txt"])
+	assert.Len(t, after["new.txt"], 64)
+
+	// A changed modification time means the file is read again.
+	assert.NoError(t, os.Chtimes(same, info.ModTime(), info.ModTime().Add(time.Second)))
+	changed := catalog(second, loadHashCache(cachePath))
+	assert.NotEqual(t, before["same.txt"], changed["same.txt"])
+}
@@ -0,0 +1,116 @@
+package application
+
+import (
+	"encoding/gob"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+)
+
+// hashCache holds the hashes of the files a backup was last cataloged with,
+// by inode. A file hard-linked into a new snapshot, or left alone in a
+// mirror, keeps its inode, size and modification time, so its hash is
+// carried forward instead of reading the file again.
+type hashCache struct {
+	path string
+	prev map[inodeKey]cachedHash
+	seen map[inodeKey]cachedHash
+}
+
+type cachedHash struct {
+	Dev     uint64
+	Ino     uint64
+	Size    int64
+	ModTime int64
+	Hash    string
+}
+
+// catalogHashesPath is where the hashes of a backup are kept under the
+// storage root it is in.
+func catalogHashesPath(root string, backupID string) string {
+	return filepath.Join(root, valueobjects.WorkerStateDir, "catalog", backupID)
+}
+
+// loadHashCache reads the hashes kept at path. A missing or unreadable file
+// only means every file is hashed again.
+func loadHashCache(path string) *hashCache {
+	cache := &hashCache{path: path, prev: make(map[inodeKey]cachedHash), seen: make(map[inodeKey]cachedHash)}
+	f, err := os.Open(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Failed to read catalog hashes %s: %v", path, err)
+		}
+		return cache
+	}
+	defer func() { _ = f.Close() }()
+
+	var entries []cachedHash
+	if err := gob.NewDecoder(f).Decode(&entries); err != nil {
+		log.Printf("Failed to read catalog hashes %s: %v", path, err)
+		return cache
+	}
+	for _, e := range entries {
+		cache.prev[inodeKey{dev: e.Dev, ino: e.Ino}] = e
+	}
+	return cache
+}
+
+// hash returns the hash of the file behind info, computing it with compute
+// only when its inode is new or changed since it was last cataloged.
+func (c *hashCache) hash(info fs.FileInfo, compute func() (string, error)) (string, error) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if c == nil || !ok {
+		return compute()
+	}
+	key := inodeKey{dev: uint64(stat.Dev), ino: uint64(stat.Ino)}
+	entry := cachedHash{Dev: key.dev, Ino: key.ino, Size: info.Size(), ModTime: info.ModTime().UnixNano()}
+	for _, known := range []map[inodeKey]cachedHash{c.seen, c.prev} {
+		if cached, ok := known[key]; ok && cached.Size == entry.Size && cached.ModTime == entry.ModTime {
+			c.seen[key] = cached
+			return cached.Hash, nil
+		}
+	}
+
+	hash, err := compute()
+	if err != nil {
+		return "", err
+	}
+	entry.Hash = hash
+	c.seen[key] = entry
+	return hash, nil
+}
+
+// save keeps the hashes of the files cataloged since the cache was loaded,
+// dropping those of files no longer in any cataloged snapshot.
+func (c *hashCache) save() error {
+	if c == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	entries := make([]cachedHash, 0, len(c.seen))
+	for _, e := range c.seen {
+		entries = append(entries, e)
+	}
+
+	tmp := c.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(f).Encode(entries); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
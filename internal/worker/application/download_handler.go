@@ -0,0 +1,220 @@
+package application
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+)
+
+// HandleRestoreDownloadTask streams an archive of the restored path to the
+// server through the download stream of the task, so it can be downloaded
+// over the API instead of the worker connecting to the CLI.
+func HandleRestoreDownloadTask(ctx context.Context, task workerDto.WorkerTask, redisClient *redis.Client, resultQueue string) {
+	log.Printf("Streaming download for task %s (format: %s, encrypted: %v)", task.TaskID, task.DownloadFormat, task.Encrypted)
+
+	w := newDownloadStreamWriter(ctx, redisClient, task.DownloadStream)
+	task, cleanup, err := fetchRestoreSource(ctx, task)
+	if cleanup != nil {
+		defer cleanup()
+	}
+	if err == nil {
+		err = streamDownload(w, task)
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		w.Fail(err)
+		reportRestoreFailure(ctx, redisClient, resultQueue, task, "Download streaming failed", err)
+		return
+	}
+
+	reportRestoreSuccess(ctx, redisClient, resultQueue, task, "Download streaming completed successfully")
+}
+
+func streamDownload(w io.Writer, task workerDto.WorkerTask) error {
+	if task.DownloadFormat == string(valueobjects.DownloadFormatZip) {
+		return streamZipData(w, task)
+	}
+	return streamRestoreData(w, task)
+}
+
+// streamZipData writes the restore as a zip archive, converting the tar.gz
+// stream a local restore would send.
+func streamZipData(w io.Writer, task workerDto.WorkerTask) error {
+	pr, pw := io.Pipe()
+	defer func() { _ = pr.Close() }()
+
+	go func() {
+		pw.CloseWithError(streamRestoreData(pw, task))
+	}()
+
+	return tarGzToZip(pr, w)
+}
+
+// tarGzToZip copies the directories, regular files and symlinks of a tar.gz
+// stream into a zip archive.
+func tarGzToZip(r io.Reader, w io.Writer) error {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gr)
+	zw := zip.NewWriter(w)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		name := strings.Trim(path.Clean("/"+header.Name), "/")
+		if name == "" {
+			continue
+		}
+		fh := &zip.FileHeader{Name: name, Modified: header.ModTime, Method: zip.Deflate}
+		fh.SetMode(header.FileInfo().Mode())
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			fh.Name += "/"
+			fh.Method = zip.Store
+			if _, err := zw.CreateHeader(fh); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			fw, err := zw.CreateHeader(fh)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(fw, tr); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			fh.Method = zip.Store
+			fw, err := zw.CreateHeader(fh)
+			if err != nil {
+				return err
+			}
+			if _, err := io.WriteString(fw, header.Linkname); err != nil {
+				return err
+			}
+		}
+	}
+	return zw.Close()
+}
+
+// downloadStreamWriter writes an archive to a download stream in frames,
+// waiting whenever the server falls behind.
+type downloadStreamWriter struct {
+	ctx    context.Context
+	client *redis.Client
+	stream string
+	buf    []byte
+}
+
+func newDownloadStreamWriter(ctx context.Context, client *redis.Client, stream string) *downloadStreamWriter {
+	return &downloadStreamWriter{
+		ctx:    ctx,
+		client: client,
+		stream: stream,
+		buf:    make([]byte, 0, workerDto.DownloadFrameSize),
+	}
+}
+
+func (w *downloadStreamWriter) Write(p []byte) (int, error) {
+	written := len(p)
+	for len(p) > 0 {
+		n := min(workerDto.DownloadFrameSize-len(w.buf), len(p))
+		w.buf = append(w.buf, p[:n]...)
+		p = p[n:]
+		if len(w.buf) == workerDto.DownloadFrameSize {
+			if err := w.flush(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return written, nil
+}
+
+func (w *downloadStreamWriter) flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	if err := w.waitForRoom(); err != nil {
+		return err
+	}
+	err := w.send(workerDto.DownloadFrameData, w.buf)
+	w.buf = w.buf[:0]
+	return err
+}
+
+// Close sends what is left of the archive and marks its end.
+func (w *downloadStreamWriter) Close() error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+	return w.send(workerDto.DownloadFrameEnd, nil)
+}
+
+// Fail tells the server the archive could not be streamed.
+func (w *downloadStreamWriter) Fail(err error) {
+	if sendErr := w.send(workerDto.DownloadFrameError, []byte(err.Error())); sendErr != nil {
+		log.Printf("WARNING: Failed to report download failure on %s: %v", w.stream, sendErr)
+	}
+}
+
+// waitForRoom blocks while the server has DownloadMaxPending frames left to
+// read. It gives up once the server has read nothing for DownloadStreamTTL.
+func (w *downloadStreamWriter) waitForRoom() error {
+	deadline := time.Now().Add(workerDto.DownloadStreamTTL)
+	last := int64(-1)
+	for {
+		pending, err := w.client.LLen(w.ctx, w.stream).Result()
+		if err != nil {
+			return err
+		}
+		if pending < workerDto.DownloadMaxPending {
+			return nil
+		}
+		if pending != last {
+			last = pending
+			deadline = time.Now().Add(workerDto.DownloadStreamTTL)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("the server stopped reading the download stream")
+		}
+
+		select {
+		case <-w.ctx.Done():
+			return w.ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func (w *downloadStreamWriter) send(kind byte, data []byte) error {
+	frame := make([]byte, 0, len(data)+1)
+	frame = append(frame, kind)
+	frame = append(frame, data...)
+
+	pipe := w.client.TxPipeline()
+	pipe.RPush(w.ctx, w.stream, frame)
+	pipe.Expire(w.ctx, w.stream, workerDto.DownloadStreamTTL)
+	_, err := pipe.Exec(w.ctx)
+	return err
+}
@@ -0,0 +1,48 @@
+package application
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTarGzToZip(t *testing.T) {
+	var tgz bytes.Buffer
+	gw := gzip.NewWriter(&tgz)
+	tw := tar.NewWriter(gw)
+	modTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "nginx/", Typeflag: tar.TypeDir, Mode: 0755, ModTime: modTime}))
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "nginx/nginx.conf", Typeflag: tar.TypeReg, Mode: 0644, Size: 9, ModTime: modTime}))
+	_, err := tw.Write([]byte("listen 80"))
+	assert.NoError(t, err)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "nginx/default", Typeflag: tar.TypeSymlink, Linkname: "nginx.conf", Mode: 0777, ModTime: modTime}))
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gw.Close())
+
+	var out bytes.Buffer
+	assert.NoError(t, tarGzToZip(&tgz, &out))
+
+	zr, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	assert.NoError(t, err)
+	assert.Len(t, zr.File, 3)
+
+	assert.Equal(t, "nginx/", zr.File[0].Name)
+	assert.True(t, zr.File[0].FileInfo().IsDir())
+
+	assert.Equal(t, "nginx/nginx.conf", zr.File[1].Name)
+	assert.True(t, zr.File[1].Modified.Equal(modTime))
+	rc, err := zr.File[1].Open()
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(rc)
+	assert.Equal(t, "listen 80", string(data))
+
+	assert.Equal(t, "nginx/default", zr.File[2].Name)
+	assert.Equal(t, os.ModeSymlink, zr.File[2].Mode()&os.ModeSymlink)
+}
@@ -0,0 +1,183 @@
+package application
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+)
+
+// HandleFileVersions reports every distinct version of task.FilePath across
+// the snapshots of the incremental backup stored at task.Path.
+func HandleFileVersions(ctx context.Context, task workerDto.WorkerTask, redisClient *redis.Client) {
+	log.Printf("Collecting versions of %s in %s", task.FilePath, task.Path)
+
+	result := workerDto.WorkerResult{
+		Type:   workerDto.TaskTypeFileVersions,
+		TaskID: task.TaskID,
+	}
+
+	versions, err := collectFileVersions(task.Path, task.FilePath)
+	if err != nil {
+		log.Printf("Failed to collect versions of %s: %v", task.FilePath, err)
+		result.Status = "failed"
+		result.Message = fmt.Sprintf("Failed to collect file versions: %v", err)
+	} else {
+		result.Status = "completed"
+		result.Message = fmt.Sprintf("Found %d versions", len(versions.Versions))
+		result.Data = versions
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("Failed to marshal result: %v", err)
+		return
+	}
+
+	channel := "worker_sync_responses"
+	if err := redisClient.Publish(ctx, channel, data).Err(); err != nil {
+		log.Printf("Failed to publish result to %s: %v", channel, err)
+	}
+}
+
+type versionCandidate struct {
+	info    os.FileInfo
+	version *workerDto.FileVersion
+}
+
+// collectFileVersions walks the snapshots of backupDir oldest first and groups
+// the copies of filePath into versions. Copies hard-linked between snapshots
+// are the same inode and are only hashed once; separate copies with the same
+// content and mtime are folded into the same version.
+func collectFileVersions(backupDir, filePath string) (workerDto.FileVersionsResult, error) {
+	rel, err := cleanRelativePath(filePath)
+	if err != nil {
+		return workerDto.FileVersionsResult{}, err
+	}
+
+	artifacts, err := scanSnapshotArtifacts(backupDir)
+	if err != nil {
+		return workerDto.FileVersionsResult{}, err
+	}
+
+	result := workerDto.FileVersionsResult{Versions: []workerDto.FileVersion{}}
+	var candidates []*versionCandidate
+	var versions []*workerDto.FileVersion
+
+	for _, a := range artifacts {
+		if !hasSnapshotDir(a) {
+			result.EncryptedSnapshots = append(result.EncryptedSnapshots, a.Name)
+			continue
+		}
+
+		path, ok := snapshotFile(filepath.Join(backupDir, a.Name), rel)
+		if !ok {
+			continue
+		}
+		info, err := os.Lstat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		if c := findSameFile(candidates, info); c != nil {
+			c.version.Snapshots = append(c.version.Snapshots, a.Name)
+			continue
+		}
+
+		hash, err := hashFile(path)
+		if err != nil {
+			return workerDto.FileVersionsResult{}, err
+		}
+
+		version := findSameContent(versions, hash, info)
+		if version == nil {
+			version = &workerDto.FileVersion{Hash: hash, Size: info.Size(), ModTime: info.ModTime().UTC()}
+			versions = append(versions, version)
+		}
+		version.Snapshots = append(version.Snapshots, a.Name)
+		candidates = append(candidates, &versionCandidate{info: info, version: version})
+	}
+
+	for _, v := range versions {
+		result.Versions = append(result.Versions, *v)
+	}
+	return result, nil
+}
+
+// cleanRelativePath normalises a path inside a snapshot and rejects paths
+// that would escape it.
+func cleanRelativePath(p string) (string, error) {
+	rel := strings.TrimPrefix(filepath.Clean("/"+p), "/")
+	if rel == "" {
+		return "", fmt.Errorf("file path is required")
+	}
+	return rel, nil
+}
+
+// snapshotFile resolves rel inside snapshotDir. Symlinks come from the backed
+// up hosts, so a path whose parent directories lead out of the snapshot is
+// rejected; the file itself is not followed.
+func snapshotFile(snapshotDir, rel string) (string, bool) {
+	root, err := filepath.EvalSymlinks(snapshotDir)
+	if err != nil {
+		return "", false
+	}
+	parent, err := filepath.EvalSymlinks(filepath.Join(root, filepath.Dir(rel)))
+	if err != nil {
+		return "", false
+	}
+	if parent != root && !insideRoot(root, parent) {
+		return "", false
+	}
+	return filepath.Join(parent, filepath.Base(rel)), true
+}
+
+func hasSnapshotDir(a snapshotArtifact) bool {
+	for _, entry := range a.Entries {
+		if entry == a.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func findSameFile(candidates []*versionCandidate, info os.FileInfo) *versionCandidate {
+	for _, c := range candidates {
+		if os.SameFile(c.info, info) {
+			return c
+		}
+	}
+	return nil
+}
+
+func findSameContent(versions []*workerDto.FileVersion, hash string, info os.FileInfo) *workerDto.FileVersion {
+	for _, v := range versions {
+		if v.Hash == hash && v.Size == info.Size() && v.ModTime.Equal(info.ModTime()) {
+			return v
+		}
+	}
+	return nil
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,82 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectFileVersions(t *testing.T) {
+	dir := t.TempDir()
+	mtime := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	write := func(snapshot, content string, modTime time.Time) string {
+		path := filepath.Join(dir, snapshot, "etc", "nginx.conf")
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		assert.NoError(t, os.Chtimes(path, modTime, modTime))
+		return path
+	}
+
+	first := write("2024-01-01_00-00-00", "worker_processes 1;", mtime)
+	// Unchanged file hard-linked into the next snapshot, as rsync --link-dest does.
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "2024-01-02_00-00-00", "etc"), 0755))
+	assert.NoError(t, os.Link(first, filepath.Join(dir, "2024-01-02_00-00-00", "etc", "nginx.conf")))
+	write("2024-01-03_00-00-00", "worker_processes 4;", mtime.Add(48*time.Hour))
+	// Same content copied rather than linked still counts as the same version.
+	write("2024-01-04_00-00-00", "worker_processes 4;", mtime.Add(48*time.Hour))
+	// The file is missing from this snapshot.
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "2024-01-05_00-00-00"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "2024-01-06_00-00-00.tar.gz.enc"), []byte("encrypted"), 0644))
+
+	result, err := collectFileVersions(dir, "/etc/nginx.conf")
+
+	assert.NoError(t, err)
+	assert.Len(t, result.Versions, 2)
+	assert.Equal(t, []string{"2024-01-01_00-00-00", "2024-01-02_00-00-00"}, result.Versions[0].Snapshots)
+	assert.Equal(t, int64(19), result.Versions[0].Size)
+	assert.True(t, mtime.Equal(result.Versions[0].ModTime))
+	assert.Equal(t, []string{"2024-01-03_00-00-00", "2024-01-04_00-00-00"}, result.Versions[1].Snapshots)
+	assert.NotEqual(t, result.Versions[0].Hash, result.Versions[1].Hash)
+	assert.Len(t, result.Versions[1].Hash, 64)
+	assert.Equal(t, []string{"2024-01-06_00-00-00"}, result.EncryptedSnapshots)
+}
+
+func TestCollectFileVersions_ConfinesPathToSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "2024-01-01_00-00-00"), 0755))
+
+	result, err := collectFileVersions(dir, "../../etc/passwd")
+	assert.NoError(t, err)
+	assert.Empty(t, result.Versions)
+
+	_, err = collectFileVersions(dir, "/")
+	assert.Error(t, err)
+}
+
+func TestCollectFileVersions_DoesNotFollowSymlinksOut(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0644))
+
+	snapshot := filepath.Join(dir, "2024-01-01_00-00-00")
+	assert.NoError(t, os.MkdirAll(filepath.Join(snapshot, "etc"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(snapshot, "etc", "hosts"), []byte("127.0.0.1"), 0644))
+	// Links backed up from the host: a directory and a file pointing out of
+	// the snapshot, and a directory pointing inside it.
+	assert.NoError(t, os.Symlink(outside, filepath.Join(snapshot, "escape")))
+	assert.NoError(t, os.Symlink(filepath.Join(outside, "secret"), filepath.Join(snapshot, "etc", "secret")))
+	assert.NoError(t, os.Symlink("etc", filepath.Join(snapshot, "conf")))
+
+	for _, path := range []string{"escape/secret", "etc/secret"} {
+		result, err := collectFileVersions(dir, path)
+		assert.NoError(t, err)
+		assert.Empty(t, result.Versions, path)
+	}
+
+	result, err := collectFileVersions(dir, "conf/hosts")
+	assert.NoError(t, err)
+	assert.Len(t, result.Versions, 1)
+}
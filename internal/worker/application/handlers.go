@@ -6,6 +6,7 @@ import (
 	"log"
 	"sort"
 	"strings"
+	"sync/atomic"
 
 	"github.com/redis/go-redis/v9"
 	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
@@ -15,13 +16,54 @@ type TaskHandler interface {
 	Handle(ctx context.Context, task workerDto.WorkerTask, redisClient *redis.Client, resultQueue string)
 }
 
+type commitKey struct{}
+
+// WithCommitMark returns the context to run a task under and a function
+// reporting whether the task committed itself: got past the point where
+// running it again would repeat what it did, such as taking a snapshot. A
+// committed task must not be re-queued when it is aborted.
+func WithCommitMark(ctx context.Context) (context.Context, func() bool) {
+	committed := new(atomic.Bool)
+	return context.WithValue(ctx, commitKey{}, committed), committed.Load
+}
+
+// commitTask marks the task run under ctx as committed.
+func commitTask(ctx context.Context) {
+	if committed, ok := ctx.Value(commitKey{}).(*atomic.Bool); ok {
+		committed.Store(true)
+	}
+}
+
+// taskAborted reports whether the task run under ctx failed with err because
+// a worker shutdown aborted it, and is left to be re-queued. Such a task
+// reports no failure: the run that picks it up again reports how it went.
+func taskAborted(ctx context.Context, task workerDto.WorkerTask, err error) bool {
+	if err == nil || ctx.Err() == nil {
+		return false
+	}
+	if committed, ok := ctx.Value(commitKey{}).(*atomic.Bool); ok && committed.Load() {
+		return false
+	}
+	log.Printf("Task %s (%s) aborted, leaving it to be re-queued: %v", task.TaskID, task.Type, err)
+	return true
+}
+
+// reportResult publishes the final result of the task run under ctx, which
+// commits it.
+func reportResult(ctx context.Context, redisClient *redis.Client, resultQueue string, result workerDto.WorkerResult) {
+	commitTask(ctx)
+	PublishResult(ctx, redisClient, resultQueue, result)
+}
+
 func PublishResult(ctx context.Context, redisClient *redis.Client, resultQueue string, result workerDto.WorkerResult) {
 	data, err := json.Marshal(result)
 	if err != nil {
 		log.Printf("Failed to marshal result: %v", err)
 		return
 	}
-	if err := redisClient.RPush(ctx, resultQueue, data).Err(); err != nil {
+	// A task aborted by a shutdown past the point it can be re-queued
+	// still reports what it did.
+	if err := redisClient.RPush(context.WithoutCancel(ctx), resultQueue, data).Err(); err != nil {
 		log.Printf("Failed to publish result: %v", err)
 	}
 }
@@ -40,6 +82,15 @@ func NormalizePath(dest string, backupRoot string, hostPath string) string {
 	return root + "/" + dest
 }
 
+// TaskBackupRoot returns the root of the storage pool the backup of a task
+// is in, defaultRoot when the task names none.
+func TaskBackupRoot(task workerDto.WorkerTask, defaultRoot string) string {
+	if task.BackupRoot != "" {
+		return task.BackupRoot
+	}
+	return defaultRoot
+}
+
 // SortStrings sorts a slice of strings in ascending order using the standard library.
 func SortStrings(s []string) {
 	sort.Strings(s)
@@ -0,0 +1,177 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/config"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/crypto"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+)
+
+// HandleMigrateStorageTask moves the data of a backup from the root of its
+// storage pool to the root of another one. The server switches the backup to
+// the new pool once this reports success.
+func HandleMigrateStorageTask(ctx context.Context, task workerDto.WorkerTask, redisClient *redis.Client, resultQueue string) {
+	result := workerDto.WorkerResult{
+		Type:   workerDto.TaskTypeMigrateStorage,
+		TaskID: task.TaskID,
+		JobID:  task.JobID,
+		Status: "completed",
+		Data: workerDto.MigrateStorageResult{
+			BackupID:      task.BackupID,
+			StoragePoolID: task.StoragePoolID,
+		},
+	}
+
+	cfg, err := config.LoadWorkerConfig()
+	if err != nil {
+		log.Printf("CRITICAL: Failed to load worker config: %v", err)
+		result.Status = "failed"
+		result.Message = fmt.Sprintf("Worker configuration error: %v", err)
+		reportResult(ctx, redisClient, resultQueue, result)
+		return
+	}
+
+	from := NormalizePath(task.Destination, TaskBackupRoot(task, cfg.ContainerBackupRoot), task.HostPath)
+	targetRoot := task.MigrateRoot
+	if targetRoot == "" {
+		targetRoot = cfg.ContainerBackupRoot
+	}
+	to := NormalizePath(task.Destination, targetRoot, task.HostPath)
+	log.Printf("Migrating backup %s from %s to %s", task.BackupID, from, to)
+
+	moved, err := migrateBackupData(ctx, task.BackupID, from, to)
+	if taskAborted(ctx, task, err) {
+		// The copy left behind is picked up where it stopped.
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to migrate backup %s: %v", task.BackupID, err)
+		result.Status = "failed"
+		result.Message = fmt.Sprintf("Migration failed: %v", err)
+		reportResult(ctx, redisClient, resultQueue, result)
+		return
+	}
+
+	result.Message = fmt.Sprintf("Moved %d entries to %s", moved, to)
+	reportResult(ctx, redisClient, resultQueue, result)
+}
+
+// migrationMarkerSuffix names the file kept next to an entry while it is
+// copied to another filesystem. It holds the ID of the backup being moved,
+// so that a copy left by an interrupted migration is told apart from the
+// data of something else, and "copied" once the copy is complete.
+const migrationMarkerSuffix = ".migrating"
+
+// migrateBackupData moves the directory of a backup and the encrypted
+// archive of a mirror, with its index, next to it from one root to another,
+// returning how many of them it moved.
+func migrateBackupData(ctx context.Context, backupID string, from string, to string) (int, error) {
+	if filepath.Clean(from) == filepath.Clean(to) {
+		return 0, fmt.Errorf("backup is already in %s", to)
+	}
+
+	archive := valueobjects.EncryptedSnapshotSuffix
+	moved := 0
+	for _, suffix := range []string{"", archive, archive + crypto.ArchiveIndexSuffix} {
+		info, err := os.Lstat(from + suffix)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return moved, err
+		}
+		if err := moveBackupEntry(ctx, backupID, from+suffix, to+suffix, info.IsDir()); err != nil {
+			return moved, err
+		}
+		moved++
+	}
+	if moved == 0 {
+		return 0, fmt.Errorf("no backup data in %s", from)
+	}
+	return moved, nil
+}
+
+// moveBackupEntry renames src to dst, or copies it across filesystems
+// keeping the hard links between snapshots and then removes it. A copy left
+// by an interrupted migration of the same backup is brought up to date, or
+// only has its source removed when it was complete; anything else in the
+// way is refused rather than overwritten.
+func moveBackupEntry(ctx context.Context, backupID string, src string, dst string, isDir bool) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	marker := dst + migrationMarkerSuffix
+	owner, copied := readMigrationMarker(marker)
+
+	if _, err := os.Lstat(dst); os.IsNotExist(err) {
+		err := os.Rename(src, dst)
+		if err == nil {
+			_ = os.Remove(marker)
+		}
+		if err == nil || !errors.Is(err, syscall.EXDEV) {
+			return err
+		}
+		copied = false
+	} else if err != nil {
+		return err
+	} else if owner != backupID && !emptyDir(dst) {
+		return fmt.Errorf("%s is in use: it is not left over from migrating backup %s", dst, backupID)
+	}
+
+	if owner != backupID || !copied {
+		if err := os.WriteFile(marker, []byte(backupID+"\n"), 0644); err != nil {
+			return err
+		}
+		args := []string{"-aH", "--numeric-ids"}
+		source, dest := src, dst
+		if isDir {
+			args = append(args, "--delete")
+			source, dest = strings.TrimSuffix(src, "/")+"/", strings.TrimSuffix(dst, "/")+"/"
+		}
+		cmd := exec.CommandContext(ctx, "rsync", append(args, source, dest)...)
+		log.Printf("Executing Storage Migration: %s", cmd.String())
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("rsync failed: %w, output: %s", err, string(output))
+		}
+		// From here on src may be partly removed; it is never copied again.
+		if err := os.WriteFile(marker, []byte(backupID+"\ncopied\n"), 0644); err != nil {
+			return err
+		}
+	}
+
+	if err := os.RemoveAll(src); err != nil {
+		return err
+	}
+	return os.Remove(marker)
+}
+
+// readMigrationMarker returns the backup a migration marker belongs to and
+// whether its copy was complete, nothing when there is no marker.
+func readMigrationMarker(marker string) (string, bool) {
+	content, err := os.ReadFile(marker)
+	if err != nil {
+		return "", false
+	}
+	lines := strings.Fields(string(content))
+	if len(lines) == 0 {
+		return "", false
+	}
+	return lines[0], len(lines) > 1 && lines[1] == "copied"
+}
+
+// emptyDir reports whether path is a directory with nothing in it.
+func emptyDir(path string) bool {
+	entries, err := os.ReadDir(path)
+	return err == nil && len(entries) == 0
+}
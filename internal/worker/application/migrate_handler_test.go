@@ -0,0 +1,82 @@
+package application
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateBackupData(t *testing.T) {
+	base := t.TempDir()
+	from := filepath.Join(base, "fast", "prod", "app")
+	to := filepath.Join(base, "archive", "prod", "app")
+
+	snapshot := filepath.Join(from, "2024-01-01_000000")
+	require.NoError(t, os.MkdirAll(snapshot, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(snapshot, "data.txt"), []byte("data"), 0644))
+	require.NoError(t, os.Symlink("2024-01-01_000000", filepath.Join(from, "latest")))
+	archive := from + valueobjects.EncryptedSnapshotSuffix
+	require.NoError(t, os.WriteFile(archive, []byte("sealed"), 0644))
+	require.NoError(t, os.WriteFile(archive+crypto.ArchiveIndexSuffix, []byte("index"), 0644))
+
+	moved, err := migrateBackupData(context.Background(), "backup-1", from, to)
+	require.NoError(t, err)
+	assert.Equal(t, 3, moved)
+
+	content, err := os.ReadFile(filepath.Join(to, "latest", "data.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(content))
+	assert.FileExists(t, to+valueobjects.EncryptedSnapshotSuffix)
+	assert.FileExists(t, to+valueobjects.EncryptedSnapshotSuffix+crypto.ArchiveIndexSuffix)
+	assert.NoDirExists(t, from)
+	assert.NoFileExists(t, archive)
+
+	_, err = migrateBackupData(context.Background(), "backup-1", to, to+"/")
+	assert.Error(t, err, "source and target are the same")
+
+	_, err = migrateBackupData(context.Background(), "backup-1", from, to)
+	assert.Error(t, err, "nothing left to move")
+}
+
+func TestMoveBackupEntryRefusesDataInTheWay(t *testing.T) {
+	base := t.TempDir()
+	src := filepath.Join(base, "fast", "prod", "app")
+	dst := filepath.Join(base, "archive", "prod", "app")
+	require.NoError(t, os.MkdirAll(src, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "mine.txt"), []byte("mine"), 0644))
+	require.NoError(t, os.MkdirAll(dst, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dst, "theirs.txt"), []byte("theirs"), 0644))
+
+	err := moveBackupEntry(context.Background(), "backup-1", src, dst, true)
+	assert.Error(t, err)
+	assert.FileExists(t, filepath.Join(dst, "theirs.txt"))
+	assert.FileExists(t, filepath.Join(src, "mine.txt"))
+
+	// Left over from an interrupted migration of another backup.
+	require.NoError(t, os.WriteFile(dst+migrationMarkerSuffix, []byte("backup-2\n"), 0644))
+	err = moveBackupEntry(context.Background(), "backup-1", src, dst, true)
+	assert.Error(t, err)
+	assert.FileExists(t, filepath.Join(dst, "theirs.txt"))
+}
+
+func TestMoveBackupEntryFinishesCompleteCopy(t *testing.T) {
+	base := t.TempDir()
+	src := filepath.Join(base, "fast", "prod", "app")
+	dst := filepath.Join(base, "archive", "prod", "app")
+	// The copy was complete, and src partly removed, when the worker stopped.
+	require.NoError(t, os.MkdirAll(src, 0755))
+	require.NoError(t, os.MkdirAll(dst, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dst, "data.txt"), []byte("data"), 0644))
+	require.NoError(t, os.WriteFile(dst+migrationMarkerSuffix, []byte("backup-1\ncopied\n"), 0644))
+
+	require.NoError(t, moveBackupEntry(context.Background(), "backup-1", src, dst, true))
+	assert.FileExists(t, filepath.Join(dst, "data.txt"))
+	assert.NoDirExists(t, src)
+	assert.NoFileExists(t, dst+migrationMarkerSuffix)
+}
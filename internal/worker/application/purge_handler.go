@@ -6,20 +6,24 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/redis/go-redis/v9"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
 	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/config"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/crypto"
 	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
 )
 
 func HandlePurgeTask(ctx context.Context, task workerDto.WorkerTask, redisClient *redis.Client, resultQueue string) {
-	log.Printf("Purging backups for %s, path %s (retention: %d)", task.Host, task.Path, task.Retention)
+	policy := taskRetentionPolicy(task)
+	log.Printf("Purging backups for %s, path %s (retention: %s)", task.Host, task.Path, policy)
 
 	cfg, err := config.LoadWorkerConfig()
 	if err != nil {
 		log.Printf("CRITICAL: Failed to load worker config: %v", err)
-		PublishResult(ctx, redisClient, resultQueue, workerDto.WorkerResult{
+		reportResult(ctx, redisClient, resultQueue, workerDto.WorkerResult{
 			Type:    workerDto.TaskTypePurge,
 			TaskID:  task.TaskID,
 			JobID:   task.JobID,
@@ -29,11 +33,48 @@ func HandlePurgeTask(ctx context.Context, task workerDto.WorkerTask, redisClient
 		return
 	}
 
-	backupDir := NormalizePath(task.Destination, cfg.ContainerBackupRoot, task.HostPath)
-	entries, err := os.ReadDir(backupDir)
+	backupDir := NormalizePath(task.Destination, TaskBackupRoot(task, cfg.ContainerBackupRoot), task.HostPath)
+
+	// The server does not queue purges for held backups; this guards against
+	// tasks queued before the hold was placed.
+	if task.LegalHold {
+		log.Printf("Backup %s is under legal hold. Nothing to purge.", backupDir)
+		reportResult(ctx, redisClient, resultQueue, workerDto.WorkerResult{
+			Type:    workerDto.TaskTypePurge,
+			TaskID:  task.TaskID,
+			JobID:   task.JobID,
+			Status:  "completed",
+			Message: "Backup is under legal hold",
+		})
+		return
+	}
+
+	// A plain mirror (or its single encrypted archive) is overwritten in place
+	// by every run, so it only ever holds the latest copy. Its contents are the
+	// user's files and must never be mistaken for snapshots.
+	if !task.Incremental {
+		log.Printf("Backup %s is a plain mirror. Nothing to purge.", backupDir)
+		reportResult(ctx, redisClient, resultQueue, workerDto.WorkerResult{
+			Type:    workerDto.TaskTypePurge,
+			TaskID:  task.TaskID,
+			JobID:   task.JobID,
+			Status:  "completed",
+			Message: "Nothing to purge",
+			Data: workerDto.PurgeResult{
+				Kept: []valueobjects.RetainedSnapshot{{
+					Name:  filepath.Base(mirrorArtifactPath(task, backupDir)),
+					Rules: []valueobjects.RetentionRule{valueobjects.RetentionRuleLatest},
+				}},
+				Purged: []string{},
+			},
+		})
+		return
+	}
+
+	artifacts, err := scanSnapshotArtifacts(backupDir)
 	if err != nil {
 		log.Printf("Failed to read backup dir %s: %v", backupDir, err)
-		PublishResult(ctx, redisClient, resultQueue, workerDto.WorkerResult{
+		reportResult(ctx, redisClient, resultQueue, workerDto.WorkerResult{
 			Type:    workerDto.TaskTypePurge,
 			TaskID:  task.TaskID,
 			JobID:   task.JobID,
@@ -43,23 +84,9 @@ func HandlePurgeTask(ctx context.Context, task workerDto.WorkerTask, redisClient
 		return
 	}
 
-	var backups []string
-	for _, entry := range entries {
-		if entry.IsDir() && entry.Name() != "latest" {
-			// Basic check for timestamp format YYYY-MM-DD_HH-MM-SS
-			if len(entry.Name()) == 19 && strings.Contains(entry.Name(), "_") {
-				backups = append(backups, entry.Name())
-			}
-		}
-	}
-
-	// Sort backups ascending (oldest first)
-	// Alphabetical sort works for YYYY-MM-DD_HH-MM-SS
-	SortStrings(backups)
-
-	if len(backups) <= task.Retention {
-		log.Printf("Retention threshold not reached (%d/%d). Nothing to purge.", len(backups), task.Retention)
-		PublishResult(ctx, redisClient, resultQueue, workerDto.WorkerResult{
+	if policy.IsZero() {
+		log.Printf("No retention policy configured. Nothing to purge.")
+		reportResult(ctx, redisClient, resultQueue, workerDto.WorkerResult{
 			Type:    workerDto.TaskTypePurge,
 			TaskID:  task.TaskID,
 			JobID:   task.JobID,
@@ -69,35 +96,206 @@ func HandlePurgeTask(ctx context.Context, task workerDto.WorkerTask, redisClient
 		return
 	}
 
-	toDelete := SelectBackupsToPurge(backups, task.Retention)
-	log.Printf("Purging %d old backups", len(toDelete))
+	// Snapshots tiering moved to cold storage pools count like the others.
+	cold := coldArtifacts(artifacts, task.ColdArchives)
+	artifacts = append(artifacts, cold...)
+	sort.Slice(artifacts, func(i, k int) bool { return artifacts[i].Name < artifacts[k].Name })
 
-	var deletedCount int
-	for _, b := range toDelete {
-		path := filepath.Join(backupDir, b)
-		log.Printf("Deleting old backup: %s", path)
-		if err := os.RemoveAll(path); err != nil {
-			log.Printf("Failed to delete %s: %v", path, err)
-			continue
+	latest := latestSnapshots(backupDir)
+	pinned := make(map[string]bool, len(task.PinnedSnapshots))
+	for _, name := range task.PinnedSnapshots {
+		pinned[name] = true
+	}
+	candidates := make([]valueobjects.RetentionCandidate, 0, len(artifacts))
+	for _, a := range artifacts {
+		t, _ := valueobjects.ParseSnapshotTime(a.Name)
+		candidates = append(candidates, valueobjects.RetentionCandidate{
+			Name:   a.Name,
+			Time:   t,
+			Latest: latest[a.Name],
+			Pinned: pinned[a.Name],
+		})
+	}
+
+	plan := policy.Plan(candidates)
+	for _, kept := range plan.Keep {
+		log.Printf("Keeping %s (rules: %s)", kept.Name, joinRetentionRules(kept.Rules))
+	}
+	log.Printf("Purging %d old backups", len(plan.Purge))
+
+	report := workerDto.PurgeResult{
+		BackupID: task.BackupID,
+		Kept:     plan.Keep,
+		Purged:   []string{},
+	}
+	entries := make(map[string][]string, len(artifacts))
+	for _, a := range artifacts {
+		entries[a.Name] = a.Entries
+	}
+	for _, a := range cold {
+		entries[a.Name] = a.Entries
+	}
+	for _, b := range plan.Purge {
+		if removeSnapshotArtifacts(backupDir, entries[b]) {
+			report.Purged = append(report.Purged, b)
+		} else {
+			report.Failed = append(report.Failed, b)
 		}
-		deletedCount++
 	}
+	// Backup runs only add to the stored size, so the scheduled purge
+	// measures the whole backup again.
+	report.StoredSize = measureStoredSize(backupDir)
 
-	PublishResult(ctx, redisClient, resultQueue, workerDto.WorkerResult{
+	message := "Nothing to purge"
+	if len(plan.Purge) > 0 {
+		message = fmt.Sprintf("Successfully purged %d backups", len(report.Purged))
+	}
+
+	reportResult(ctx, redisClient, resultQueue, workerDto.WorkerResult{
 		Type:    workerDto.TaskTypePurge,
 		TaskID:  task.TaskID,
 		JobID:   task.JobID,
 		Status:  "completed",
-		Message: fmt.Sprintf("Successfully purged %d backups", deletedCount),
+		Message: message,
+		Data:    report,
 	})
 }
 
+// snapshotArtifact groups the on-disk entries of one snapshot: its directory,
+// its encrypted archive, or both when encryption was toggled between runs.
+type snapshotArtifact struct {
+	Name    string
+	Entries []string
+}
+
+// scanSnapshotArtifacts lists the completed snapshots in backupDir, oldest first.
+// Partial snapshots of failed or running backups are named ".partial-<timestamp>"
+// and skipped, as are the 'latest' links.
+func scanSnapshotArtifacts(backupDir string) ([]snapshotArtifact, error) {
+	entries, err := os.ReadDir(backupDir)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*snapshotArtifact)
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && !entry.Type().IsRegular() {
+			continue
+		}
+		name, ok := valueobjects.SnapshotNameFromArtifact(entry.Name(), entry.IsDir())
+		if !ok {
+			continue
+		}
+		if _, seen := byName[name]; !seen {
+			byName[name] = &snapshotArtifact{Name: name}
+			names = append(names, name)
+		}
+		byName[name].Entries = append(byName[name].Entries, entry.Name())
+	}
+
+	// Alphabetical sort works for YYYY-MM-DD_HH-MM-SS
+	SortStrings(names)
+	artifacts := make([]snapshotArtifact, 0, len(names))
+	for _, name := range names {
+		artifacts = append(artifacts, *byName[name])
+	}
+	return artifacts, nil
+}
+
+// coldArtifacts returns the snapshots archived in cold storage pools that
+// are not also in the backup dir, with the absolute path of their archive as
+// their only entry.
+func coldArtifacts(local []snapshotArtifact, archives []valueobjects.ColdLocation) []snapshotArtifact {
+	seen := make(map[string]bool, len(local))
+	for _, a := range local {
+		seen[a.Name] = true
+	}
+	var cold []snapshotArtifact
+	for _, archive := range archives {
+		if seen[archive.Snapshot] || !filepath.IsAbs(archive.Archive) {
+			continue
+		}
+		seen[archive.Snapshot] = true
+		cold = append(cold, snapshotArtifact{Name: archive.Snapshot, Entries: []string{archive.Archive}})
+	}
+	return cold
+}
+
+// latestSnapshots returns the snapshots the 'latest' and 'latest.tar.gz.enc'
+// links point to. They are never purged, even if the link is dangling.
+func latestSnapshots(backupDir string) map[string]bool {
+	links := map[string]bool{
+		"latest": true,
+		"latest" + valueobjects.EncryptedSnapshotSuffix: false,
+	}
+
+	latest := make(map[string]bool)
+	for link, isDir := range links {
+		target, err := os.Readlink(filepath.Join(backupDir, link))
+		if err != nil {
+			continue
+		}
+		if name, ok := valueobjects.SnapshotNameFromArtifact(filepath.Base(target), isDir); ok {
+			latest[name] = true
+		}
+	}
+	return latest
+}
+
+// removeSnapshotArtifacts deletes every entry of a snapshot and reports
+// whether all of them were removed. Entries are relative to backupDir, but
+// for the absolute paths of cold archives.
+func removeSnapshotArtifacts(backupDir string, entries []string) bool {
+	ok := true
+	for _, entry := range entries {
+		path := entry
+		if !filepath.IsAbs(entry) {
+			path = filepath.Join(backupDir, entry)
+		}
+		log.Printf("Deleting old backup: %s", path)
+		if err := os.RemoveAll(path); err != nil {
+			log.Printf("Failed to delete %s: %v", path, err)
+			ok = false
+		}
+		if strings.HasSuffix(entry, valueobjects.EncryptedSnapshotSuffix) {
+			if err := os.Remove(path + crypto.ArchiveIndexSuffix); err != nil && !os.IsNotExist(err) {
+				log.Printf("Failed to delete index of %s: %v", path, err)
+			}
+		}
+	}
+	return ok
+}
+
+// mirrorArtifactPath returns where a non-incremental backup keeps its data.
+func mirrorArtifactPath(task workerDto.WorkerTask, backupDir string) string {
+	if task.Encrypted {
+		return backupDir + valueobjects.EncryptedSnapshotSuffix
+	}
+	return backupDir
+}
+
+// taskRetentionPolicy returns the policy to apply for a purge task. Tasks
+// queued before retention policies existed only carry a keep-last count.
+func taskRetentionPolicy(task workerDto.WorkerTask) valueobjects.RetentionPolicy {
+	if task.RetentionPolicy != nil {
+		return *task.RetentionPolicy
+	}
+	return valueobjects.RetentionPolicy{KeepLast: task.Retention}
+}
+
+func joinRetentionRules(rules []valueobjects.RetentionRule) string {
+	names := make([]string, len(rules))
+	for i, r := range rules {
+		names[i] = string(r)
+	}
+	return strings.Join(names, ", ")
+}
+
 // SelectBackupsToPurge returns the list of backup directories that should be deleted
 // based on the retention policy.
 // It expects the 'backups' slice to be sorted chronologically (oldest first).
-func SelectBackupsToPurge(backups []string, retention int) []string {
-	if len(backups) <= retention {
-		return []string{}
-	}
-	return backups[:len(backups)-retention]
+// Pinned snapshots are never selected.
+func SelectBackupsToPurge(backups []string, retention int, pinned []string) []string {
+	return valueobjects.RetentionPolicy{KeepLast: retention}.PlanSnapshots(backups, "", pinned).Purge
 }
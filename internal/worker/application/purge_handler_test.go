@@ -1,8 +1,12 @@
 package application
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -11,6 +15,7 @@ func TestSelectBackupsToPurge(t *testing.T) {
 		name      string
 		backups   []string // Assumed sorted oldest first
 		retention int
+		pinned    []string
 		expected  []string
 	}{
 		{
@@ -43,12 +48,86 @@ func TestSelectBackupsToPurge(t *testing.T) {
 			retention: 3,
 			expected:  []string{"1", "2", "3", "4", "5", "6", "7"},
 		},
+		{
+			name:      "Pinned backups are never purged",
+			backups:   []string{"1", "2", "3", "4", "5"},
+			retention: 2,
+			pinned:    []string{"2", "5"},
+			expected:  []string{"1", "3"},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := SelectBackupsToPurge(tt.backups, tt.retention)
+			result := SelectBackupsToPurge(tt.backups, tt.retention, tt.pinned)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
 }
+
+func TestTaskRetentionPolicy(t *testing.T) {
+	t.Run("Legacy task only carries keep-last", func(t *testing.T) {
+		policy := taskRetentionPolicy(workerDto.WorkerTask{Retention: 4})
+		assert.Equal(t, valueobjects.RetentionPolicy{KeepLast: 4}, policy)
+	})
+
+	t.Run("Policy supersedes retention count", func(t *testing.T) {
+		policy := taskRetentionPolicy(workerDto.WorkerTask{
+			Retention:       4,
+			RetentionPolicy: &valueobjects.RetentionPolicy{KeepLast: 1, KeepDaily: 7},
+		})
+		assert.Equal(t, valueobjects.RetentionPolicy{KeepLast: 1, KeepDaily: 7}, policy)
+	})
+}
+
+func TestScanSnapshotArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "2024-01-02_00-00-00"), 0755))
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "2024-01-01_00-00-00"), 0755))
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, ".partial-2024-01-04_00-00-00"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "2024-01-01_00-00-00.tar.gz.enc"), []byte("x"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "2024-01-03_00-00-00.tar.gz.enc"), []byte("x"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0644))
+	assert.NoError(t, os.Symlink("2024-01-03_00-00-00.tar.gz.enc", filepath.Join(dir, "latest.tar.gz.enc")))
+	assert.NoError(t, os.Symlink("2024-01-02_00-00-00", filepath.Join(dir, "latest")))
+
+	artifacts, err := scanSnapshotArtifacts(dir)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []snapshotArtifact{
+		{Name: "2024-01-01_00-00-00", Entries: []string{"2024-01-01_00-00-00", "2024-01-01_00-00-00.tar.gz.enc"}},
+		{Name: "2024-01-02_00-00-00", Entries: []string{"2024-01-02_00-00-00"}},
+		{Name: "2024-01-03_00-00-00", Entries: []string{"2024-01-03_00-00-00.tar.gz.enc"}},
+	}, artifacts)
+
+	assert.Equal(t, map[string]bool{
+		"2024-01-02_00-00-00": true,
+		"2024-01-03_00-00-00": true,
+	}, latestSnapshots(dir))
+}
+
+func TestLatestSnapshots_DanglingLink(t *testing.T) {
+	// Encrypted incremental runs remove the directory 'latest' points to.
+	dir := t.TempDir()
+	assert.NoError(t, os.Symlink("2024-01-02_00-00-00", filepath.Join(dir, "latest")))
+
+	assert.Equal(t, map[string]bool{"2024-01-02_00-00-00": true}, latestSnapshots(dir))
+}
+
+func TestRemoveSnapshotArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "2024-01-01_00-00-00", "sub"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "2024-01-01_00-00-00.tar.gz.enc"), []byte("x"), 0644))
+
+	ok := removeSnapshotArtifacts(dir, []string{"2024-01-01_00-00-00", "2024-01-01_00-00-00.tar.gz.enc"})
+
+	assert.True(t, ok)
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestMirrorArtifactPath(t *testing.T) {
+	assert.Equal(t, "/mnt/backups/h/data", mirrorArtifactPath(workerDto.WorkerTask{}, "/mnt/backups/h/data"))
+	assert.Equal(t, "/mnt/backups/h/data.tar.gz.enc", mirrorArtifactPath(workerDto.WorkerTask{Encrypted: true}, "/mnt/backups/h/data"))
+}
@@ -0,0 +1,180 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	shared "github.com/rrbarrero/justbackup/internal/shared/domain"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+)
+
+// quotaCheckInterval is how long a run under a quota waits after measuring
+// what it has added before measuring again. Each measure walks the tree
+// rsync writes, so a check never starts before the previous one is over.
+var quotaCheckInterval = 30 * time.Second
+
+// quotaWatch measures what a backup run adds to its destination while rsync
+// runs, and stops rsync once that passes the quota budget of the run.
+type quotaWatch struct {
+	budget      valueobjects.QuotaBudget
+	dest        string
+	incremental bool
+	// base is the size of a plain mirror before the run, which only adds to
+	// what is already there.
+	base     int64
+	added    atomic.Int64
+	exceeded atomic.Bool
+	cancel   context.CancelFunc
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// startQuotaWatch watches dest when the task has a quota budget and returns
+// the context rsync must run under, which is cancelled once the budget is
+// passed. The watch is nil when there is no budget.
+func startQuotaWatch(ctx context.Context, task workerDto.WorkerTask, dest string) (context.Context, *quotaWatch) {
+	if task.Quota == nil || !task.Quota.Limited() {
+		return ctx, nil
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	w := &quotaWatch{
+		budget:      *task.Quota,
+		dest:        dest,
+		incremental: task.Incremental,
+		cancel:      cancel,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	if !w.incremental {
+		if size, err := measureAdded(dest, false); err == nil {
+			w.base = size
+		}
+	}
+
+	go func() {
+		defer close(w.done)
+		// The timer is only armed again once a check is over, so checks
+		// never overlap however long a walk takes.
+		timer := time.NewTimer(quotaCheckInterval)
+		defer timer.Stop()
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-runCtx.Done():
+				return
+			case <-timer.C:
+				if w.check() {
+					log.Printf("Backup %s passed its %s quota budget of %s, stopping rsync", dest, w.budget.Scope, shared.FormatSize(w.budget.Bytes))
+					return
+				}
+				timer.Reset(quotaCheckInterval)
+			}
+		}
+	}()
+	return runCtx, w
+}
+
+// check measures what the run has added and cancels it when that is over
+// budget.
+func (w *quotaWatch) check() bool {
+	size, err := measureAdded(w.dest, w.incremental)
+	if err != nil {
+		// Files rsync renames while the tree is walked; the next check
+		// catches up.
+		return false
+	}
+	added := size
+	if !w.incremental {
+		added = size - w.base
+	}
+	w.added.Store(added)
+	if added > w.budget.Bytes {
+		w.exceeded.Store(true)
+		w.cancel()
+		return true
+	}
+	return false
+}
+
+// measureAdded sums the size of the regular files under root. In a snapshot
+// only files with a single link count: the others are linked from the
+// previous snapshot and add nothing. Unlike measureTree it keeps no table of
+// inodes, so a walk costs one lstat per file and no memory per file.
+func measureAdded(root string, snapshot bool) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if snapshot {
+			if stat, ok := info.Sys().(*syscall.Stat_t); ok && uint64(stat.Nlink) > 1 {
+				return nil
+			}
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
+
+// Stop ends the watch once rsync is done and measures the destination one
+// last time. It returns a quota error when the run went over its budget.
+func (w *quotaWatch) Stop() error {
+	if w == nil {
+		return nil
+	}
+	close(w.stop)
+	<-w.done
+	if !w.exceeded.Load() {
+		w.check()
+	}
+	w.cancel()
+	if !w.exceeded.Load() {
+		return nil
+	}
+	return fmt.Errorf("%w: the run added %s, over the %s left by the %s quota", valueobjects.ErrQuotaExceeded, shared.FormatSize(w.added.Load()), shared.FormatSize(w.budget.Bytes), w.budget.Scope)
+}
+
+// Result describes the stopped run for the server.
+func (w *quotaWatch) Result() workerDto.QuotaExceededResult {
+	return workerDto.QuotaExceededResult{Scope: string(w.budget.Scope), Budget: w.budget.Bytes, Added: w.added.Load()}
+}
+
+// checkQuotaBudget fails a run whose quota is used up before it starts.
+func checkQuotaBudget(task workerDto.WorkerTask) error {
+	if task.Quota == nil || !task.Quota.Exhausted() {
+		return nil
+	}
+	return fmt.Errorf("%w: the %s quota is used up (%s over)", valueobjects.ErrQuotaExceeded, task.Quota.Scope, shared.FormatSize(-task.Quota.Bytes))
+}
+
+// reportQuotaExceeded publishes the failure of a run stopped by a quota, so
+// the server can apply retention early to make room.
+func reportQuotaExceeded(ctx context.Context, redisClient *redis.Client, queue string, task workerDto.WorkerTask, err error, result workerDto.QuotaExceededResult) {
+	log.Printf("Backup %s stopped: %v", task.TaskID, err)
+	reportResult(ctx, redisClient, queue, workerDto.WorkerResult{
+		Type:    workerDto.TaskTypeBackup,
+		TaskID:  task.TaskID,
+		JobID:   task.JobID,
+		Status:  "failed",
+		Message: err.Error(),
+		Data:    result,
+	})
+}
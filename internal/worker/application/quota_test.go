@@ -0,0 +1,72 @@
+package application
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckQuotaBudget(t *testing.T) {
+	assert.NoError(t, checkQuotaBudget(workerDto.WorkerTask{}))
+	assert.NoError(t, checkQuotaBudget(workerDto.WorkerTask{Quota: &valueobjects.QuotaBudget{Bytes: 10, Scope: valueobjects.QuotaScopeHost}}))
+
+	err := checkQuotaBudget(workerDto.WorkerTask{Quota: &valueobjects.QuotaBudget{Bytes: -2048, Scope: valueobjects.QuotaScopeHost}})
+	assert.ErrorIs(t, err, valueobjects.ErrQuotaExceeded)
+	assert.Contains(t, err.Error(), "host quota is used up")
+}
+
+func TestQuotaWatch_StopsRunOverBudget(t *testing.T) {
+	interval := quotaCheckInterval
+	quotaCheckInterval = 10 * time.Millisecond
+	t.Cleanup(func() { quotaCheckInterval = interval })
+
+	backupDir := t.TempDir()
+	previous := filepath.Join(backupDir, "2024-01-01_00-00-00")
+	snapshot := filepath.Join(backupDir, "2024-01-02_00-00-00")
+	require.NoError(t, os.MkdirAll(previous, 0755))
+	require.NoError(t, os.MkdirAll(snapshot, 0755))
+	// Linked from the previous snapshot, so it adds nothing.
+	require.NoError(t, os.WriteFile(filepath.Join(previous, "unchanged"), []byte(strings.Repeat("u", 5000)), 0644))
+	require.NoError(t, os.Link(filepath.Join(previous, "unchanged"), filepath.Join(snapshot, "unchanged")))
+
+	task := workerDto.WorkerTask{Incremental: true, Quota: &valueobjects.QuotaBudget{Bytes: 1000, Scope: valueobjects.QuotaScopeBackup}}
+	ctx, watch := startQuotaWatch(context.Background(), task, snapshot)
+	require.NotNil(t, watch)
+
+	require.NoError(t, os.WriteFile(filepath.Join(snapshot, "added"), []byte(strings.Repeat("a", 1500)), 0644))
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("the run was not stopped")
+	}
+
+	err := watch.Stop()
+	assert.ErrorIs(t, err, valueobjects.ErrQuotaExceeded)
+	assert.Equal(t, workerDto.QuotaExceededResult{Scope: "backup", Budget: 1000, Added: 1500}, watch.Result())
+}
+
+func TestQuotaWatch_MirrorWithinBudget(t *testing.T) {
+	mirror := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(mirror, "existing"), []byte(strings.Repeat("e", 5000)), 0644))
+
+	task := workerDto.WorkerTask{Quota: &valueobjects.QuotaBudget{Bytes: 1000, Scope: valueobjects.QuotaScopeHost}}
+	ctx, watch := startQuotaWatch(context.Background(), task, mirror)
+	require.NoError(t, os.WriteFile(filepath.Join(mirror, "added"), []byte(strings.Repeat("a", 800)), 0644))
+
+	assert.NoError(t, watch.Stop())
+	assert.Error(t, ctx.Err(), "the run context is released")
+
+	// Without a budget rsync runs under the caller's context.
+	plain, none := startQuotaWatch(context.Background(), workerDto.WorkerTask{}, mirror)
+	assert.Nil(t, none)
+	assert.NoError(t, none.Stop())
+	assert.NoError(t, plain.Err())
+}
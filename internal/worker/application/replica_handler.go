@@ -0,0 +1,239 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/config"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/crypto"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+)
+
+// HandleReplicateTask copies the snapshots of a backup that the replica
+// target does not hold yet, oldest first, then removes those beyond the
+// retention of the target. A non-incremental backup is copied as a whole on
+// every run, as the snapshot "latest". Content already on the target is not
+// sent again.
+func HandleReplicateTask(ctx context.Context, task workerDto.WorkerTask, redisClient *redis.Client, resultQueue string) {
+	report := workerDto.ReplicateResult{BackupID: task.BackupID, Snapshots: []workerDto.ReplicatedSnapshot{}}
+	if task.Replica != nil {
+		report.TargetID = task.Replica.TargetID
+	}
+
+	result := workerDto.WorkerResult{
+		Type:   workerDto.TaskTypeReplicate,
+		TaskID: task.TaskID,
+		JobID:  task.JobID,
+		Data:   &report,
+	}
+	err := replicate(ctx, task, &report)
+	// Snapshots already copied are reported, so the next run does not send
+	// them again.
+	if len(report.Snapshots) == 0 && taskAborted(ctx, task, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Replication of backup %s failed: %v", task.BackupID, err)
+		result.Status = "failed"
+		result.Message = fmt.Sprintf("Replication failed: %v", err)
+	} else {
+		result.Status = "completed"
+		result.Message = fmt.Sprintf("%d snapshots replicated", len(report.Snapshots))
+	}
+	reportResult(ctx, redisClient, resultQueue, result)
+}
+
+func replicate(ctx context.Context, task workerDto.WorkerTask, report *workerDto.ReplicateResult) error {
+	if task.Replica == nil {
+		return errors.New("task has no replica target")
+	}
+	cfg, err := config.LoadWorkerConfig()
+	if err != nil {
+		return err
+	}
+
+	backupDir := NormalizePath(task.Destination, TaskBackupRoot(task, cfg.ContainerBackupRoot), task.HostPath)
+	sources, err := replicaSources(task, backupDir)
+	if err != nil {
+		return err
+	}
+	store, err := newReplicaStore(*task.Replica, task.BackupID)
+	if err != nil {
+		return err
+	}
+
+	base := ""
+	for _, name := range task.ReplicatedSnapshots {
+		base = max(base, name)
+	}
+	for _, source := range sources {
+		log.Printf("Replicating snapshot %s of backup %s to target %s", source.Name, task.BackupID, task.Replica.TargetID)
+		snapshot, err := store.Push(ctx, source, base)
+		if err != nil {
+			return fmt.Errorf("snapshot %s: %w", source.Name, err)
+		}
+		log.Printf("Replicated snapshot %s: %d files, %d of %d bytes uploaded", source.Name, snapshot.Files, snapshot.UploadedBytes, snapshot.Bytes)
+		report.Snapshots = append(report.Snapshots, snapshot)
+		base = source.Name
+	}
+
+	pruned := replicaPruned(task, sources)
+	if len(pruned) == 0 {
+		return nil
+	}
+	log.Printf("Pruning snapshots %s of backup %s from target %s", strings.Join(pruned, ", "), task.BackupID, task.Replica.TargetID)
+	if err := store.Prune(ctx, pruned); err != nil {
+		return err
+	}
+	report.Pruned = pruned
+	return nil
+}
+
+// replicaRetained returns the newest retention of names, which the target
+// keeps, or nil when it keeps every snapshot.
+func replicaRetained(names []string, retention int) map[string]bool {
+	if retention <= 0 {
+		return nil
+	}
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+	kept := make(map[string]bool, retention)
+	for i := len(sorted) - 1; i >= 0 && len(kept) < retention; i-- {
+		kept[sorted[i]] = true
+	}
+	return kept
+}
+
+// replicaPruned lists the snapshots the target holds that its retention
+// drops once sources are copied.
+func replicaPruned(task workerDto.WorkerTask, sources []replicaSnapshotSource) []string {
+	if !task.Incremental {
+		return nil
+	}
+	names := append([]string(nil), task.ReplicatedSnapshots...)
+	for _, source := range sources {
+		names = append(names, source.Name)
+	}
+	kept := replicaRetained(names, task.ReplicaRetention)
+	if kept == nil {
+		return nil
+	}
+
+	var pruned []string
+	for _, name := range task.ReplicatedSnapshots {
+		if !kept[name] {
+			pruned = append(pruned, name)
+		}
+	}
+	sort.Strings(pruned)
+	return pruned
+}
+
+// replicaSources lists the snapshots of the backup to replicate: for an
+// incremental backup the finished snapshots the target does not hold and
+// would keep under its retention, for a non-incremental one the mirror or
+// its archive.
+func replicaSources(task workerDto.WorkerTask, backupDir string) ([]replicaSnapshotSource, error) {
+	if !task.Incremental {
+		if task.Encrypted {
+			root := filepath.Dir(backupDir)
+			archive := filepath.Base(backupDir) + valueobjects.EncryptedSnapshotSuffix
+			if _, err := os.Stat(filepath.Join(root, archive)); err != nil {
+				return nil, err
+			}
+			return []replicaSnapshotSource{{Name: valueobjects.SnapshotLatest, Root: root, Paths: withArchiveIndexes(root, []string{archive})}}, nil
+		}
+		if _, err := os.Stat(backupDir); err != nil {
+			return nil, err
+		}
+		return []replicaSnapshotSource{{Name: valueobjects.SnapshotLatest, Root: backupDir, Paths: []string{"."}}}, nil
+	}
+
+	artifacts, err := scanSnapshotArtifacts(backupDir)
+	if err != nil {
+		return nil, err
+	}
+	replicated := make(map[string]bool, len(task.ReplicatedSnapshots))
+	names := append([]string(nil), task.ReplicatedSnapshots...)
+	for _, name := range task.ReplicatedSnapshots {
+		replicated[name] = true
+	}
+	for _, a := range artifacts {
+		names = append(names, a.Name)
+	}
+	// Snapshots older than those the target keeps would be pruned right
+	// after the copy, and copied again on the next run.
+	kept := replicaRetained(names, task.ReplicaRetention)
+
+	var sources []replicaSnapshotSource
+	for _, a := range artifacts {
+		if replicated[a.Name] || (kept != nil && !kept[a.Name]) {
+			continue
+		}
+		sources = append(sources, replicaSnapshotSource{Name: a.Name, Root: backupDir, Paths: withArchiveIndexes(backupDir, a.Entries)})
+	}
+	return sources, nil
+}
+
+// withArchiveIndexes adds the index of each encrypted archive among entries
+// that has one.
+func withArchiveIndexes(root string, entries []string) []string {
+	paths := append([]string(nil), entries...)
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry, valueobjects.EncryptedSnapshotSuffix) {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(root, entry+crypto.ArchiveIndexSuffix)); err == nil {
+			paths = append(paths, entry+crypto.ArchiveIndexSuffix)
+		}
+	}
+	return paths
+}
+
+// pullReplicaSource pulls the source of a restore from the replica target
+// the server pointed it to when the source is missing locally, and points
+// the task at the copy. The returned cleanup removes the copy.
+func pullReplicaSource(ctx context.Context, task workerDto.WorkerTask) (workerDto.WorkerTask, func(), error) {
+	source := task.ReplicaSource
+	if source == nil {
+		return task, nil, nil
+	}
+	if _, err := os.Stat(task.Path); !errors.Is(err, fs.ErrNotExist) {
+		return task, nil, nil
+	}
+	log.Printf("%s is missing. Pulling it from snapshot %s on replica target %s", task.Path, source.Snapshot, source.TargetID)
+
+	store, err := newReplicaStore(source.ReplicaEndpoint, task.BackupID)
+	if err != nil {
+		return task, nil, err
+	}
+	tempDir, err := os.MkdirTemp("", "replica-*")
+	if err != nil {
+		return task, nil, fmt.Errorf("temp dir creation failed: %w", err)
+	}
+	cleanup := func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			log.Printf("WARNING: Failed to remove temp dir %s: %v", tempDir, err)
+		}
+	}
+
+	// The copy keeps the name of the source, which restores are named after.
+	dest := tempDir
+	if source.Path == "." {
+		dest = filepath.Join(tempDir, filepath.Base(task.Path))
+	}
+	if err := store.Pull(ctx, source.Snapshot, source.Path, dest); err != nil {
+		return task, cleanup, fmt.Errorf("failed to pull from replica: %w", err)
+	}
+	task.Path = filepath.Join(dest, filepath.FromSlash(source.Path))
+	return task, cleanup, nil
+}
@@ -0,0 +1,250 @@
+package application
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/objectstore/s3test"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func s3Endpoint(server *s3test.Server) valueobjects.ReplicaEndpoint {
+	return valueobjects.ReplicaEndpoint{
+		TargetID: "target-1",
+		Kind:     valueobjects.ReplicaTargetS3,
+		S3: &valueobjects.S3Config{
+			Endpoint:  server.URL,
+			Bucket:    s3test.Bucket,
+			Prefix:    "offsite",
+			AccessKey: s3test.AccessKey,
+			SecretKey: s3test.SecretKey,
+			PathStyle: true,
+		},
+	}
+}
+
+func writeSnapshotFile(t *testing.T, backupDir string, snapshot string, name string, content string) {
+	t.Helper()
+	p := filepath.Join(backupDir, snapshot, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+	require.NoError(t, os.WriteFile(p, []byte(content), 0640))
+}
+
+func TestS3Replica_PushUploadsChangedContentOnce(t *testing.T) {
+	server := s3test.NewServer(t)
+	backupDir := t.TempDir()
+	writeSnapshotFile(t, backupDir, "2024-01-01_00-00-00", "etc/app.conf", "port=80")
+	writeSnapshotFile(t, backupDir, "2024-01-01_00-00-00", "etc/hosts", "127.0.0.1 localhost")
+	writeSnapshotFile(t, backupDir, "2024-01-02_00-00-00", "etc/app.conf", "port=8080")
+	writeSnapshotFile(t, backupDir, "2024-01-02_00-00-00", "etc/hosts", "127.0.0.1 localhost")
+	require.NoError(t, os.Symlink("2024-01-02_00-00-00", filepath.Join(backupDir, "latest")))
+
+	task := workerDto.WorkerTask{BackupID: "backup-1", Incremental: true}
+	sources, err := replicaSources(task, backupDir)
+	require.NoError(t, err)
+	require.Len(t, sources, 2)
+
+	store, err := newReplicaStore(s3Endpoint(server), task.BackupID)
+	require.NoError(t, err)
+
+	first, err := store.Push(context.Background(), sources[0], "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), first.Files)
+	assert.Equal(t, first.Bytes, first.UploadedBytes)
+
+	second, err := store.Push(context.Background(), sources[1], sources[0].Name)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), second.Files)
+	assert.Equal(t, int64(len("port=8080")), second.UploadedBytes, "only the changed file is uploaded")
+
+	// Three distinct contents and two manifests.
+	assert.Equal(t, 5, server.Puts())
+	_, ok := server.Object("offsite/backup-1/snapshots/2024-01-02_00-00-00.json.gz")
+	assert.True(t, ok)
+
+	// A later run sees what the target already holds.
+	task.ReplicatedSnapshots = []string{sources[0].Name}
+	sources, err = replicaSources(task, backupDir)
+	require.NoError(t, err)
+	require.Len(t, sources, 1)
+	store, err = newReplicaStore(s3Endpoint(server), task.BackupID)
+	require.NoError(t, err)
+	again, err := store.Push(context.Background(), sources[0], sources[0].Name)
+	require.NoError(t, err)
+	assert.Zero(t, again.UploadedBytes)
+}
+
+func TestS3Replica_PullRestoresPath(t *testing.T) {
+	server := s3test.NewServer(t)
+	backupDir := t.TempDir()
+	writeSnapshotFile(t, backupDir, "2024-01-01_00-00-00", "etc/app.conf", "port=80")
+	writeSnapshotFile(t, backupDir, "2024-01-01_00-00-00", "var/log/app.log", "started")
+
+	store, err := newReplicaStore(s3Endpoint(server), "backup-1")
+	require.NoError(t, err)
+	sources, err := replicaSources(workerDto.WorkerTask{Incremental: true}, backupDir)
+	require.NoError(t, err)
+	_, err = store.Push(context.Background(), sources[0], "")
+	require.NoError(t, err)
+
+	dest := t.TempDir()
+	require.NoError(t, store.Pull(context.Background(), "2024-01-01_00-00-00", "2024-01-01_00-00-00/etc", dest))
+
+	data, err := os.ReadFile(filepath.Join(dest, "2024-01-01_00-00-00", "etc", "app.conf"))
+	require.NoError(t, err)
+	assert.Equal(t, "port=80", string(data))
+	info, err := os.Stat(filepath.Join(dest, "2024-01-01_00-00-00", "etc", "app.conf"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+	assert.NoFileExists(t, filepath.Join(dest, "2024-01-01_00-00-00", "var", "log", "app.log"))
+
+	err = store.Pull(context.Background(), "2024-01-01_00-00-00", "2024-01-01_00-00-00/missing", t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestPullReplicaSource(t *testing.T) {
+	server := s3test.NewServer(t)
+	backupDir := t.TempDir()
+	writeSnapshotFile(t, backupDir, "2024-01-01_00-00-00", "etc/app.conf", "port=80")
+
+	endpoint := s3Endpoint(server)
+	store, err := newReplicaStore(endpoint, "backup-1")
+	require.NoError(t, err)
+	sources, err := replicaSources(workerDto.WorkerTask{Incremental: true}, backupDir)
+	require.NoError(t, err)
+	_, err = store.Push(context.Background(), sources[0], "")
+	require.NoError(t, err)
+
+	replica := &valueobjects.ReplicaLocation{ReplicaEndpoint: endpoint, Snapshot: "2024-01-01_00-00-00", Path: "2024-01-01_00-00-00/etc"}
+
+	t.Run("local source is used when present", func(t *testing.T) {
+		task := workerDto.WorkerTask{BackupID: "backup-1", Path: filepath.Join(backupDir, "2024-01-01_00-00-00", "etc"), ReplicaSource: replica}
+		pulled, cleanup, err := pullReplicaSource(context.Background(), task)
+		require.NoError(t, err)
+		assert.Nil(t, cleanup)
+		assert.Equal(t, task.Path, pulled.Path)
+	})
+
+	t.Run("missing source is pulled from the replica", func(t *testing.T) {
+		require.NoError(t, os.RemoveAll(filepath.Join(backupDir, "2024-01-01_00-00-00")))
+		task := workerDto.WorkerTask{BackupID: "backup-1", Path: filepath.Join(backupDir, "2024-01-01_00-00-00", "etc"), ReplicaSource: replica}
+		pulled, cleanup, err := pullReplicaSource(context.Background(), task)
+		require.NoError(t, err)
+		require.NotNil(t, cleanup)
+		defer cleanup()
+
+		assert.Equal(t, "etc", filepath.Base(pulled.Path))
+		data, err := os.ReadFile(filepath.Join(pulled.Path, "app.conf"))
+		require.NoError(t, err)
+		assert.Equal(t, "port=80", string(data))
+	})
+}
+
+func TestReplicaSources_SkipSnapshotsBeyondRetention(t *testing.T) {
+	backupDir := t.TempDir()
+	for _, name := range []string{"2024-01-01_00-00-00", "2024-01-02_00-00-00", "2024-01-03_00-00-00", "2024-01-04_00-00-00"} {
+		writeSnapshotFile(t, backupDir, name, "etc/hosts", "127.0.0.1 localhost")
+	}
+
+	task := workerDto.WorkerTask{Incremental: true, ReplicatedSnapshots: []string{"2024-01-01_00-00-00"}, ReplicaRetention: 2}
+	sources, err := replicaSources(task, backupDir)
+	require.NoError(t, err)
+	require.Len(t, sources, 2)
+	assert.Equal(t, "2024-01-03_00-00-00", sources[0].Name)
+	assert.Equal(t, "2024-01-04_00-00-00", sources[1].Name)
+	assert.Equal(t, []string{"2024-01-01_00-00-00"}, replicaPruned(task, sources))
+
+	task.ReplicaRetention = 0
+	sources, err = replicaSources(task, backupDir)
+	require.NoError(t, err)
+	assert.Len(t, sources, 3)
+	assert.Empty(t, replicaPruned(task, sources))
+}
+
+func TestS3Replica_PruneDeletesUnreferencedObjects(t *testing.T) {
+	server := s3test.NewServer(t)
+	backupDir := t.TempDir()
+	writeSnapshotFile(t, backupDir, "2024-01-01_00-00-00", "etc/app.conf", "port=80")
+	writeSnapshotFile(t, backupDir, "2024-01-01_00-00-00", "etc/hosts", "127.0.0.1 localhost")
+	writeSnapshotFile(t, backupDir, "2024-01-02_00-00-00", "etc/app.conf", "port=8080")
+	writeSnapshotFile(t, backupDir, "2024-01-02_00-00-00", "etc/hosts", "127.0.0.1 localhost")
+
+	store, err := newReplicaStore(s3Endpoint(server), "backup-1")
+	require.NoError(t, err)
+	sources, err := replicaSources(workerDto.WorkerTask{Incremental: true}, backupDir)
+	require.NoError(t, err)
+	for i, source := range sources {
+		base := ""
+		if i > 0 {
+			base = sources[i-1].Name
+		}
+		_, err := store.Push(context.Background(), source, base)
+		require.NoError(t, err)
+	}
+	require.Len(t, server.Keys(), 5)
+
+	require.NoError(t, store.Prune(context.Background(), []string{"2024-01-01_00-00-00"}))
+
+	// The old manifest and the content only it used are gone.
+	assert.Len(t, server.Keys(), 3)
+	_, ok := server.Object("offsite/backup-1/snapshots/2024-01-01_00-00-00.json.gz")
+	assert.False(t, ok)
+	dest := t.TempDir()
+	require.NoError(t, store.Pull(context.Background(), "2024-01-02_00-00-00", "2024-01-02_00-00-00", dest))
+	data, err := os.ReadFile(filepath.Join(dest, "2024-01-02_00-00-00", "etc", "hosts"))
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1 localhost", string(data))
+}
+
+func TestRsyncReplica_PushArgs(t *testing.T) {
+	backupDir := t.TempDir()
+	writeSnapshotFile(t, backupDir, "2024-01-01_00-00-00", "etc/hosts", "127.0.0.1 localhost")
+	require.NoError(t, os.WriteFile(filepath.Join(backupDir, "2024-01-02_00-00-00.tar.gz.enc"), []byte("sealed"), 0600))
+
+	store := &rsyncReplica{
+		cfg:        valueobjects.RsyncConfig{Host: "vault.local", User: "backup", Port: 2222, Path: "/srv/replica"},
+		dir:        "/srv/replica/backup-1",
+		sshKeyPath: "/keys/id_ed25519",
+		bwlimit:    512,
+	}
+
+	args, err := store.pushArgs(backupDir, "2024-01-01_00-00-00", "2023-12-31_00-00-00")
+	require.NoError(t, err)
+	assert.Contains(t, args, "-aH")
+	assert.Contains(t, args, "--bwlimit=512")
+	assert.Contains(t, args, "--delete")
+	assert.Contains(t, args, "--link-dest=../2023-12-31_00-00-00")
+	assert.Contains(t, args, "--rsync-path=mkdir -p '/srv/replica/backup-1' && rsync")
+	assert.Contains(t, args, "ssh -i /keys/id_ed25519 -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null -p 2222")
+	assert.Equal(t, []string{filepath.Join(backupDir, "2024-01-01_00-00-00") + "/", "backup@vault.local:/srv/replica/backup-1/2024-01-01_00-00-00/"}, args[len(args)-2:])
+
+	// An archive is copied as a file, with nothing to link against.
+	args, err = store.pushArgs(backupDir, "2024-01-02_00-00-00.tar.gz.enc", "")
+	require.NoError(t, err)
+	assert.NotContains(t, args, "--delete")
+	assert.Equal(t, "backup@vault.local:/srv/replica/backup-1/", args[len(args)-1])
+
+	_, err = store.pushArgs(backupDir, "../escape", "")
+	assert.Error(t, err)
+}
+
+func TestParseRsyncStats(t *testing.T) {
+	output := `
+Number of files: 1,250 (reg: 1,200, dir: 50)
+Number of created files: 3 (reg: 3)
+Number of regular files transferred: 3
+Total file size: 52,428,800 bytes
+Total transferred file size: 12,288 bytes
+Literal data: 12,288 bytes
+`
+	stats := parseRsyncStats([]byte(output))
+	assert.Equal(t, rsyncStats{Files: 1200, Transferred: 3, Bytes: 52428800, UploadedBytes: 12288}, stats)
+
+	stats = parseRsyncStats([]byte("Number of files: 42\nTotal file size: 100 bytes\nTotal transferred file size: 0 bytes\n"))
+	assert.Equal(t, rsyncStats{Files: 42, Bytes: 100}, stats)
+}
@@ -0,0 +1,220 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/config"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/crypto"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+)
+
+// On an rsync target a backup is laid out under <path>/<backup> the way it
+// is in the backup root: a mirror is a copy of the backup directory, and the
+// snapshots of an incremental backup are directories hard linked against
+// the previous one, so unchanged files take no space and are not sent.
+type rsyncReplica struct {
+	cfg        valueobjects.RsyncConfig
+	dir        string
+	sshKeyPath string
+	bwlimit    int
+}
+
+func newRsyncReplica(cfg valueobjects.RsyncConfig, bwlimit int, backupID string) (*rsyncReplica, error) {
+	workerCfg, err := config.LoadWorkerConfig()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 22
+	}
+	return &rsyncReplica{cfg: cfg, dir: cfg.BackupDir(backupID), sshKeyPath: workerCfg.SSHKeyPath, bwlimit: bwlimit}, nil
+}
+
+func (r *rsyncReplica) sshOpts() string {
+	return fmt.Sprintf("ssh -i %s -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null -p %d", r.sshKeyPath, r.cfg.Port)
+}
+
+func (r *rsyncReplica) remote(p string) string {
+	return fmt.Sprintf("%s@%s:%s", r.cfg.User, r.cfg.Host, p)
+}
+
+func (r *rsyncReplica) Push(ctx context.Context, snapshot replicaSnapshotSource, base string) (workerDto.ReplicatedSnapshot, error) {
+	result := workerDto.ReplicatedSnapshot{Name: snapshot.Name}
+	for _, p := range snapshot.Paths {
+		// Only the directory of an incremental snapshot is named after it.
+		linkDest := ""
+		if p == snapshot.Name && base != "" && base != p {
+			if info, err := os.Stat(filepath.Join(snapshot.Root, base)); err == nil && info.IsDir() {
+				linkDest = base
+			}
+		}
+		args, err := r.pushArgs(snapshot.Root, p, linkDest)
+		if err != nil {
+			return result, err
+		}
+		output, err := r.run(ctx, args)
+		if err != nil {
+			return result, err
+		}
+		stats := parseRsyncStats(output)
+		result.Files += stats.Files
+		result.Bytes += stats.Bytes
+		result.UploadedBytes += stats.UploadedBytes
+	}
+	return result, nil
+}
+
+// pushArgs returns the rsync arguments copying the entry p of a snapshot
+// under root to the same place in the directory of the backup on the
+// target. Directories are synced with --delete so the copy matches; a
+// snapshot directory is hard linked against linkDest, a sibling already on
+// the target, and hard links inside it are kept. The remote directory is
+// created first.
+func (r *rsyncReplica) pushArgs(root string, p string, linkDest string) ([]string, error) {
+	if p != "." {
+		if err := validReplicaPath(p); err != nil {
+			return nil, err
+		}
+	}
+	info, err := os.Stat(filepath.Join(root, p))
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"-aH", "--numeric-ids", "--stats", "--exclude=" + rsyncPartialDir + "/"}
+	if r.bwlimit > 0 {
+		args = append(args, fmt.Sprintf("--bwlimit=%d", r.bwlimit))
+	}
+
+	source, dest, parent := filepath.Join(root, p), r.dir+"/", r.dir
+	if info.IsDir() {
+		source += "/"
+		dest = path.Join(r.dir, p) + "/"
+		parent = path.Dir(path.Join(r.dir, p))
+		args = append(args, "--delete")
+		if linkDest != "" {
+			args = append(args, "--link-dest=../"+linkDest)
+		}
+	}
+	args = append(args, "--rsync-path=mkdir -p "+shellQuote(parent)+" && rsync", "-e", r.sshOpts(), source, r.remote(dest))
+	return args, nil
+}
+
+func (r *rsyncReplica) run(ctx context.Context, args []string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "rsync", args...)
+	log.Printf("Executing Replica Sync: %s", cmd.String())
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return output, fmt.Errorf("rsync failed: %w, output: %s%s", err, string(output), stderr.String())
+	}
+	return output, nil
+}
+
+// rsyncStats is what rsync --stats reports about a transfer.
+type rsyncStats struct {
+	Files         int64
+	Transferred   int64
+	Bytes         int64
+	UploadedBytes int64
+}
+
+// parseRsyncStats reads the regular files, how many of them were sent, their
+// total size and the size of those sent from the output of rsync --stats.
+func parseRsyncStats(output []byte) rsyncStats {
+	var stats rsyncStats
+	for _, line := range strings.Split(string(output), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ": ")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Number of files":
+			// "1,234 (reg: 1,200, dir: 34)" since rsync 3.1, a plain count
+			// before.
+			if _, reg, ok := strings.Cut(value, "reg: "); ok {
+				value = reg
+			}
+			stats.Files = parseRsyncNumber(value)
+		case "Number of regular files transferred":
+			stats.Transferred = parseRsyncNumber(value)
+		case "Total file size":
+			stats.Bytes = parseRsyncNumber(value)
+		case "Total transferred file size":
+			stats.UploadedBytes = parseRsyncNumber(value)
+		}
+	}
+	return stats
+}
+
+func parseRsyncNumber(value string) int64 {
+	value, _, _ = strings.Cut(value, " ")
+	value = strings.TrimRight(strings.ReplaceAll(value, ",", ""), ",)")
+	n, _ := strconv.ParseInt(value, 10, 64)
+	return n
+}
+
+func (r *rsyncReplica) Pull(ctx context.Context, snapshot string, p string, dest string) error {
+	if p != "." {
+		if err := validReplicaPath(p); err != nil {
+			return err
+		}
+	}
+	sources := []string{r.remote(r.dir + "/./" + p)}
+	if strings.HasSuffix(p, valueobjects.EncryptedSnapshotSuffix) {
+		sources = append(sources, r.remote(r.dir+"/./"+p+crypto.ArchiveIndexSuffix))
+	}
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+
+	args := []string{"-aH", "--numeric-ids", "--relative", "--ignore-missing-args", "-e", r.sshOpts()}
+	args = append(args, sources...)
+	args = append(args, dest+"/")
+	if _, err := r.run(ctx, args); err != nil {
+		return err
+	}
+	if _, err := os.Lstat(filepath.Join(dest, filepath.FromSlash(p))); err != nil {
+		return fmt.Errorf("%s is not in replicated snapshot %s", p, snapshot)
+	}
+	return nil
+}
+
+// Prune removes snapshots from the target, with the index of an archive.
+func (r *rsyncReplica) Prune(ctx context.Context, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	command := []string{"rm", "-rf", "--"}
+	for _, name := range names {
+		if strings.Contains(name, "/") || validReplicaPath(name) != nil {
+			return fmt.Errorf("invalid snapshot name %q", name)
+		}
+		archive := path.Join(r.dir, name+valueobjects.EncryptedSnapshotSuffix)
+		command = append(command, shellQuote(path.Join(r.dir, name)), shellQuote(archive), shellQuote(archive+crypto.ArchiveIndexSuffix))
+	}
+
+	args := []string{"-i", r.sshKeyPath, "-o", "StrictHostKeyChecking=no", "-o", "UserKnownHostsFile=/dev/null", "-p", strconv.Itoa(r.cfg.Port), r.cfg.User + "@" + r.cfg.Host, strings.Join(command, " ")}
+	cmd := exec.CommandContext(ctx, "ssh", args...)
+	log.Printf("Pruning %d snapshots from replica target %s", len(names), r.cfg.Host)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("prune failed: %w, output: %s", err, string(output))
+	}
+	return nil
+}
+
+// shellQuote quotes s for the remote shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
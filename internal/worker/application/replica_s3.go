@@ -0,0 +1,379 @@
+package application
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/objectstore"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+)
+
+// On an S3 target the files of a backup are stored once per content, under
+// <prefix>/<backup>/objects/<sha256>, and each snapshot is a manifest under
+// <prefix>/<backup>/snapshots/<name>.json.gz listing its entries and the
+// objects holding their content.
+const (
+	replicaObjectsDir   = "objects/"
+	replicaSnapshotsDir = "snapshots/"
+	manifestEntryDir    = "dir"
+	manifestEntryFile   = "file"
+	manifestEntryLink   = "symlink"
+)
+
+type replicaManifest struct {
+	Snapshot  string          `json:"snapshot"`
+	CreatedAt time.Time       `json:"created_at"`
+	Entries   []manifestEntry `json:"entries"`
+}
+
+type manifestEntry struct {
+	Path    string      `json:"path"`
+	Type    string      `json:"type"`
+	Mode    fs.FileMode `json:"mode"`
+	Size    int64       `json:"size,omitempty"`
+	ModTime time.Time   `json:"mod_time"`
+	Hash    string      `json:"hash,omitempty"`
+	Target  string      `json:"target,omitempty"`
+}
+
+type s3Replica struct {
+	client *objectstore.S3Client
+	prefix string
+	// stored holds the objects known to be on the target, listed on the
+	// first push.
+	stored map[string]bool
+}
+
+// newS3Replica connects to the bucket of a target. bwlimit caps uploads in
+// KiB/s, 0 for no limit.
+func newS3Replica(cfg valueobjects.S3Config, bwlimit int, backupID string) (*s3Replica, error) {
+	client, err := objectstore.NewS3Client(objectstore.Config{
+		Endpoint:       cfg.Endpoint,
+		Region:         cfg.Region,
+		Bucket:         cfg.Bucket,
+		AccessKey:      cfg.AccessKey,
+		SecretKey:      cfg.SecretKey,
+		PathStyle:      cfg.PathStyle,
+		BandwidthLimit: int64(bwlimit) << 10,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3Replica{client: client, prefix: cfg.KeyPrefix(backupID)}, nil
+}
+
+func (r *s3Replica) objectKey(hash string) string {
+	return r.prefix + replicaObjectsDir + hash
+}
+
+func (r *s3Replica) manifestKey(snapshot string) string {
+	return r.prefix + replicaSnapshotsDir + snapshot + ".json.gz"
+}
+
+func (r *s3Replica) Push(ctx context.Context, snapshot replicaSnapshotSource, base string) (workerDto.ReplicatedSnapshot, error) {
+	result := workerDto.ReplicatedSnapshot{Name: snapshot.Name}
+
+	if r.stored == nil {
+		stored := make(map[string]bool)
+		err := r.client.List(ctx, r.prefix+replicaObjectsDir, func(o objectstore.ObjectInfo) error {
+			stored[strings.TrimPrefix(o.Key, r.prefix+replicaObjectsDir)] = true
+			return nil
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to list stored objects: %w", err)
+		}
+		r.stored = stored
+	}
+
+	known, err := r.baseEntries(ctx, base)
+	if err != nil {
+		return result, err
+	}
+
+	manifest := replicaManifest{Snapshot: snapshot.Name, CreatedAt: time.Now().UTC()}
+	for _, p := range snapshot.Paths {
+		err := filepath.WalkDir(filepath.Join(snapshot.Root, p), func(fullPath string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && d.Name() == rsyncPartialDir {
+				return filepath.SkipDir
+			}
+			rel, err := filepath.Rel(snapshot.Root, fullPath)
+			if err != nil || rel == "." {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+
+			entry := manifestEntry{
+				Path:    filepath.ToSlash(rel),
+				Mode:    info.Mode().Perm(),
+				ModTime: info.ModTime().UTC(),
+			}
+			switch {
+			case d.IsDir():
+				entry.Type = manifestEntryDir
+			case d.Type()&fs.ModeSymlink != 0:
+				entry.Type = manifestEntryLink
+				if entry.Target, err = os.Readlink(fullPath); err != nil {
+					return err
+				}
+			case d.Type().IsRegular():
+				entry.Type = manifestEntryFile
+				entry.Size = info.Size()
+				uploaded, err := r.pushFile(ctx, fullPath, &entry, known[snapshotRelative(snapshot.Name, entry.Path)])
+				if err != nil {
+					return err
+				}
+				result.Files++
+				result.Bytes += entry.Size
+				if uploaded {
+					result.UploadedBytes += entry.Size
+				}
+			default:
+				return nil
+			}
+			manifest.Entries = append(manifest.Entries, entry)
+			return nil
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if err := r.putManifest(ctx, manifest); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// pushFile uploads the content of a file unless the target has it. The hash
+// recorded for the file in the base snapshot is trusted while its size and
+// time are unchanged, the way rsync decides a file is unchanged.
+func (r *s3Replica) pushFile(ctx context.Context, fullPath string, entry *manifestEntry, previous manifestEntry) (bool, error) {
+	if previous.Type == manifestEntryFile && previous.Size == entry.Size && previous.ModTime.Equal(entry.ModTime) {
+		entry.Hash = previous.Hash
+	} else {
+		hash, err := hashFile(fullPath)
+		if err != nil {
+			return false, err
+		}
+		entry.Hash = hash
+	}
+
+	if r.stored[entry.Hash] {
+		return false, nil
+	}
+	if err := r.client.PutFile(ctx, r.objectKey(entry.Hash), fullPath, entry.Hash); err != nil {
+		return false, fmt.Errorf("failed to upload %s: %w", entry.Path, err)
+	}
+	r.stored[entry.Hash] = true
+	return true, nil
+}
+
+// baseEntries indexes the entries of the base snapshot by their path inside
+// it. A base that is gone from the target only means every file is read.
+func (r *s3Replica) baseEntries(ctx context.Context, base string) (map[string]manifestEntry, error) {
+	entries := make(map[string]manifestEntry)
+	if base == "" {
+		return entries, nil
+	}
+	manifest, err := r.getManifest(ctx, base)
+	if errors.Is(err, objectstore.ErrNotFound) {
+		log.Printf("Base snapshot %s is not on the replica target. Hashing every file.", base)
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range manifest.Entries {
+		entries[snapshotRelative(base, e.Path)] = e
+	}
+	return entries, nil
+}
+
+func (r *s3Replica) putManifest(ctx context.Context, manifest replicaManifest) error {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(gw).Encode(manifest); err != nil {
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		return err
+	}
+	if err := r.client.Put(ctx, r.manifestKey(manifest.Snapshot), buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to upload manifest of snapshot %s: %w", manifest.Snapshot, err)
+	}
+	return nil
+}
+
+func (r *s3Replica) getManifest(ctx context.Context, snapshot string) (*replicaManifest, error) {
+	body, err := r.client.Get(ctx, r.manifestKey(snapshot))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = body.Close() }()
+
+	gr, err := gzip.NewReader(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest of snapshot %s: %w", snapshot, err)
+	}
+	var manifest replicaManifest
+	if err := json.NewDecoder(gr).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest of snapshot %s: %w", snapshot, err)
+	}
+	return &manifest, nil
+}
+
+// Prune deletes the manifests of snapshots, then the objects no remaining
+// manifest refers to.
+func (r *s3Replica) Prune(ctx context.Context, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	for _, name := range names {
+		if err := r.client.Delete(ctx, r.manifestKey(name)); err != nil && !errors.Is(err, objectstore.ErrNotFound) {
+			return fmt.Errorf("failed to delete manifest of snapshot %s: %w", name, err)
+		}
+	}
+
+	referenced := make(map[string]bool)
+	err := r.client.List(ctx, r.prefix+replicaSnapshotsDir, func(o objectstore.ObjectInfo) error {
+		snapshot := strings.TrimSuffix(strings.TrimPrefix(o.Key, r.prefix+replicaSnapshotsDir), ".json.gz")
+		manifest, err := r.getManifest(ctx, snapshot)
+		if err != nil {
+			return err
+		}
+		for _, e := range manifest.Entries {
+			if e.Hash != "" {
+				referenced[e.Hash] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list remaining manifests: %w", err)
+	}
+
+	var unreferenced []string
+	err = r.client.List(ctx, r.prefix+replicaObjectsDir, func(o objectstore.ObjectInfo) error {
+		if hash := strings.TrimPrefix(o.Key, r.prefix+replicaObjectsDir); !referenced[hash] {
+			unreferenced = append(unreferenced, hash)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list stored objects: %w", err)
+	}
+	for _, hash := range unreferenced {
+		if err := r.client.Delete(ctx, r.objectKey(hash)); err != nil && !errors.Is(err, objectstore.ErrNotFound) {
+			return fmt.Errorf("failed to delete object %s: %w", hash, err)
+		}
+		delete(r.stored, hash)
+	}
+	log.Printf("Pruned %d snapshots and %d unreferenced objects from replica target", len(names), len(unreferenced))
+	return nil
+}
+
+func (r *s3Replica) Pull(ctx context.Context, snapshot string, p string, dest string) error {
+	manifest, err := r.getManifest(ctx, snapshot)
+	if errors.Is(err, objectstore.ErrNotFound) {
+		return fmt.Errorf("snapshot %s is not on the replica target", snapshot)
+	}
+	if err != nil {
+		return err
+	}
+
+	var dirs []manifestEntry
+	pulled := 0
+	for _, e := range manifest.Entries {
+		if !inReplicaPath(e.Path, p) {
+			continue
+		}
+		if err := validReplicaPath(e.Path); err != nil {
+			return err
+		}
+		target := filepath.Join(dest, filepath.FromSlash(e.Path))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+
+		switch e.Type {
+		case manifestEntryDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			dirs = append(dirs, e)
+		case manifestEntryLink:
+			if err := os.Symlink(e.Target, target); err != nil {
+				return err
+			}
+		case manifestEntryFile:
+			if err := r.pullFile(ctx, e, target); err != nil {
+				return err
+			}
+		}
+		pulled++
+	}
+	if pulled == 0 {
+		return fmt.Errorf("%s is not in replicated snapshot %s", p, snapshot)
+	}
+
+	// Directories get their mode last, so a read-only one can still be
+	// filled in.
+	for i := len(dirs) - 1; i >= 0; i-- {
+		target := filepath.Join(dest, filepath.FromSlash(dirs[i].Path))
+		if err := os.Chmod(target, dirs[i].Mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pullFile downloads the content of an entry to target and checks it
+// against the hash it is stored under.
+func (r *s3Replica) pullFile(ctx context.Context, e manifestEntry, target string) error {
+	body, err := r.client.Get(ctx, r.objectKey(e.Hash))
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", e.Path, err)
+	}
+	defer func() { _ = body.Close() }()
+
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, h), body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", e.Path, err)
+	}
+	if hex.EncodeToString(h.Sum(nil)) != e.Hash {
+		return fmt.Errorf("content of %s does not match its hash", e.Path)
+	}
+
+	if err := os.Chmod(target, e.Mode); err != nil {
+		return err
+	}
+	return os.Chtimes(target, e.ModTime, e.ModTime)
+}
@@ -0,0 +1,78 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/crypto"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+)
+
+// replicaStore is the storage of a replica target, holding copies of the
+// snapshots of one backup.
+type replicaStore interface {
+	// Push copies a snapshot to the target. base names a snapshot already
+	// there, whose record of unchanged files spares reading them again.
+	Push(ctx context.Context, snapshot replicaSnapshotSource, base string) (workerDto.ReplicatedSnapshot, error)
+	// Pull copies what lies at p inside a replicated snapshot to the same
+	// place under dest.
+	Pull(ctx context.Context, snapshot string, p string, dest string) error
+	// Prune removes snapshots from the target.
+	Prune(ctx context.Context, names []string) error
+}
+
+// replicaSnapshotSource is a snapshot to replicate: the entries at Paths
+// under Root, with their paths relative to Root kept on the target.
+type replicaSnapshotSource struct {
+	Name  string
+	Root  string
+	Paths []string
+}
+
+func newReplicaStore(endpoint valueobjects.ReplicaEndpoint, backupID string) (replicaStore, error) {
+	switch endpoint.Kind {
+	case valueobjects.ReplicaTargetS3:
+		if endpoint.S3 == nil {
+			return nil, errors.New("replica target has no S3 settings")
+		}
+		return newS3Replica(*endpoint.S3, endpoint.BandwidthLimit, backupID)
+	case valueobjects.ReplicaTargetRsync:
+		if endpoint.Rsync == nil {
+			return nil, errors.New("replica target has no rsync settings")
+		}
+		return newRsyncReplica(*endpoint.Rsync, endpoint.BandwidthLimit, backupID)
+	}
+	return nil, fmt.Errorf("unsupported replica target kind %q", endpoint.Kind)
+}
+
+// inReplicaPath reports whether the entry at entryPath of a replicated
+// snapshot is part of what lies at p: p itself, what is under it, or the
+// index of the archive at p.
+func inReplicaPath(entryPath string, p string) bool {
+	if p == "." {
+		return true
+	}
+	return entryPath == p || strings.HasPrefix(entryPath, p+"/") || entryPath == p+crypto.ArchiveIndexSuffix
+}
+
+// snapshotRelative strips the snapshot name from the path of an entry, so
+// the same file in two snapshots of an incremental backup gets the same key.
+func snapshotRelative(snapshot string, entryPath string) string {
+	if strings.HasPrefix(entryPath, snapshot+"/") || strings.HasPrefix(entryPath, snapshot+".") {
+		return strings.TrimPrefix(entryPath, snapshot)
+	}
+	return entryPath
+}
+
+// validReplicaPath rejects entry paths that would escape the directory a
+// snapshot is pulled into.
+func validReplicaPath(p string) error {
+	if p == "" || path.IsAbs(p) || path.Clean(p) != p || p == ".." || strings.HasPrefix(p, "../") {
+		return fmt.Errorf("invalid path %q in replica manifest", p)
+	}
+	return nil
+}
@@ -2,28 +2,44 @@ package application
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
-	"net"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
 	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/config"
 	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/crypto"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/restorestream"
 	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
 )
 
 // HandleRestoreLocalTask orchestrates the restoration to a local CLI consumer.
+// The stream resumes at the offset the CLI asks for, so a CLI that lost the
+// connection can request the restore again and only receive what it misses.
 func HandleRestoreLocalTask(ctx context.Context, task workerDto.WorkerTask, redisClient *redis.Client, resultQueue string) {
 	log.Printf("Restoring local for task %s (encrypted: %v)", task.TaskID, task.Encrypted)
 
-	conn, err := establishConnection(task.RestoreAddr)
+	task, cleanup, err := fetchRestoreSource(ctx, task)
+	if cleanup != nil {
+		defer cleanup()
+	}
+	if err != nil {
+		reportRestoreFailure(ctx, redisClient, resultQueue, task, "Failed to prepare source", err)
+		return
+	}
+
+	conn, offset, err := restorestream.Dial(task.RestoreAddr, task.RestoreFingerprint, task.RestoreToken)
 	if err != nil {
 		reportRestoreFailure(ctx, redisClient, resultQueue, task, "Failed to connect to CLI", err)
 		return
@@ -33,13 +49,18 @@ func HandleRestoreLocalTask(ctx context.Context, task workerDto.WorkerTask, redi
 			log.Printf("WARNING: Failed to close connection: %v", err)
 		}
 	}()
-
-	if err := authenticateConnection(conn, task.RestoreToken); err != nil {
-		log.Printf("Failed to send token: %v", err)
-		return
+	if offset > 0 {
+		log.Printf("Resuming restore stream for task %s at %d bytes", task.TaskID, offset)
 	}
 
-	if err := streamRestoreData(conn, task); err != nil {
+	stream := restorestream.NewWriter(conn, offset)
+	err = streamRestoreData(stream, task)
+	if err == nil {
+		err = stream.Close()
+	} else if failErr := stream.Fail(err); failErr != nil {
+		log.Printf("WARNING: Failed to report the error to the CLI: %v", failErr)
+	}
+	if err != nil {
 		reportRestoreFailure(ctx, redisClient, resultQueue, task, "Data streaming failed", err)
 		return
 	}
@@ -48,9 +69,32 @@ func HandleRestoreLocalTask(ctx context.Context, task workerDto.WorkerTask, redi
 }
 
 // HandleRestoreRemoteTask orchestrates the restoration to a remote host via rsync.
+// A dry run reports the changes it would make on the sync response channel.
 func HandleRestoreRemoteTask(ctx context.Context, task workerDto.WorkerTask, redisClient *redis.Client, resultQueue string) {
 	log.Printf("Restoring remote for task %s to %s@%s:%s", task.TaskID, task.TargetUser, task.TargetHost, task.TargetPath)
 
+	options := valueobjects.RestoreOptions{}
+	if task.RestoreOptions != nil {
+		options = *task.RestoreOptions
+	}
+
+	task, pullCleanup, err := fetchRestoreSource(ctx, task)
+	if pullCleanup != nil {
+		defer pullCleanup()
+	}
+	if err != nil {
+		if options.DryRun {
+			publishRestorePreview(ctx, task, redisClient, workerDto.RestorePreviewResult{}, err)
+		} else {
+			reportRestoreFailure(ctx, redisClient, resultQueue, task, "Failed to prepare source", err)
+		}
+		return
+	}
+	if options.DryRun {
+		handleRestorePreview(ctx, task, options, redisClient)
+		return
+	}
+
 	sourcePath, cleanup, err := prepareRestoreSource(task)
 	if cleanup != nil {
 		defer cleanup()
@@ -60,7 +104,7 @@ func HandleRestoreRemoteTask(ctx context.Context, task workerDto.WorkerTask, red
 		return
 	}
 
-	if err := executeRemoteRestore(task, sourcePath); err != nil {
+	if _, err := executeRemoteRestore(ctx, task, sourcePath, options); err != nil {
 		reportRestoreFailure(ctx, redisClient, resultQueue, task, "Remote restore execution failed", err)
 		return
 	}
@@ -68,17 +112,44 @@ func HandleRestoreRemoteTask(ctx context.Context, task workerDto.WorkerTask, red
 	reportRestoreSuccess(ctx, redisClient, resultQueue, task, "Remote restore completed successfully")
 }
 
-// --- Local Restore Helpers ---
-
-func establishConnection(addr string) (net.Conn, error) {
-	return net.DialTimeout("tcp", addr, 10*time.Second)
+func handleRestorePreview(ctx context.Context, task workerDto.WorkerTask, options valueobjects.RestoreOptions, redisClient *redis.Client) {
+	preview, err := previewRemoteRestore(ctx, task, options)
+	publishRestorePreview(ctx, task, redisClient, preview, err)
 }
 
-func authenticateConnection(conn net.Conn, token string) error {
-	_, err := conn.Write([]byte(token))
-	return err
+// publishRestorePreview answers a dry run with its changes, or with the error
+// that stopped it.
+func publishRestorePreview(ctx context.Context, task workerDto.WorkerTask, redisClient *redis.Client, preview workerDto.RestorePreviewResult, err error) {
+	result := workerDto.WorkerResult{
+		Type:   workerDto.TaskTypeRestoreRemote,
+		TaskID: task.TaskID,
+		JobID:  task.JobID,
+	}
+
+	if err != nil {
+		log.Printf("Failed to preview restore for task %s: %v", task.TaskID, err)
+		result.Status = "failed"
+		result.Message = fmt.Sprintf("Failed to preview restore: %v", err)
+	} else {
+		result.Status = "completed"
+		result.Message = fmt.Sprintf("%d changes", len(preview.Changes))
+		result.Data = preview
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("Failed to marshal result: %v", err)
+		return
+	}
+
+	channel := "worker_sync_responses"
+	if err := redisClient.Publish(ctx, channel, data).Err(); err != nil {
+		log.Printf("Failed to publish result to %s: %v", channel, err)
+	}
 }
 
+// --- Local Restore Helpers ---
+
 func streamRestoreData(w io.Writer, task workerDto.WorkerTask) error {
 	if task.Encrypted {
 		return streamEncryptedData(w, task)
@@ -87,6 +158,10 @@ func streamRestoreData(w io.Writer, task workerDto.WorkerTask) error {
 }
 
 func streamEncryptedData(w io.Writer, task workerDto.WorkerTask) error {
+	if task.ArchivePath != "" {
+		return streamArchiveSubtree(w, task)
+	}
+
 	cfg, err := config.LoadWorkerConfig()
 	if err != nil {
 		return err
@@ -165,6 +240,9 @@ func prepareRestoreSource(task workerDto.WorkerTask) (string, func(), error) {
 	if !task.Encrypted {
 		return task.Path, nil, nil
 	}
+	if task.ArchivePath != "" {
+		return prepareArchiveSubtreeSource(task)
+	}
 
 	cfg, err := config.LoadWorkerConfig()
 	if err != nil {
@@ -215,34 +293,134 @@ func prepareRestoreSource(task workerDto.WorkerTask) (string, func(), error) {
 	return tempDir + "/", cleanup, nil
 }
 
-func executeRemoteRestore(task workerDto.WorkerTask, sourcePath string) error {
+// prepareArchiveSubtreeSource unpacks only the requested path of an encrypted
+// archive, so rsync copies it the same way it copies a path of a plain backup.
+func prepareArchiveSubtreeSource(task workerDto.WorkerTask) (string, func(), error) {
+	tempDir, err := os.MkdirTemp("", "restore-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("temp dir creation failed: %w", err)
+	}
+	cleanup := func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			log.Printf("WARNING: Failed to remove temp dir %s: %v", tempDir, err)
+		}
+	}
+
+	if err := extractArchiveSubtree(task, tempDir); err != nil {
+		return "", cleanup, fmt.Errorf("extraction failed: %w", err)
+	}
+	return filepath.Join(tempDir, path.Base(path.Clean("/"+task.ArchivePath))), cleanup, nil
+}
+
+// maxPreviewChanges bounds the itemized changes a dry run reports.
+const maxPreviewChanges = 10000
+
+func previewRemoteRestore(ctx context.Context, task workerDto.WorkerTask, options valueobjects.RestoreOptions) (workerDto.RestorePreviewResult, error) {
+	sourcePath, cleanup, err := prepareRestoreSource(task)
+	if cleanup != nil {
+		defer cleanup()
+	}
+	if err != nil {
+		return workerDto.RestorePreviewResult{}, err
+	}
+
+	output, err := executeRemoteRestore(ctx, task, sourcePath, options)
+	if err != nil {
+		return workerDto.RestorePreviewResult{}, err
+	}
+	return parseItemizedChanges(output), nil
+}
+
+// parseItemizedChanges collects the lines rsync itemizes, skipping the ones
+// that report nothing to change.
+func parseItemizedChanges(output []byte) workerDto.RestorePreviewResult {
+	preview := workerDto.RestorePreviewResult{Changes: []string{}}
+	for _, line := range strings.Split(string(output), "\n") {
+		if line == "" || strings.HasPrefix(line, ".d          ") {
+			continue
+		}
+		if len(preview.Changes) == maxPreviewChanges {
+			preview.Truncated = true
+			break
+		}
+		preview.Changes = append(preview.Changes, line)
+	}
+	return preview
+}
+
+func executeRemoteRestore(ctx context.Context, task workerDto.WorkerTask, sourcePath string, options valueobjects.RestoreOptions) ([]byte, error) {
 	cfg, err := config.LoadWorkerConfig()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	destination := fmt.Sprintf("%s@%s:%s", task.TargetUser, task.TargetHost, task.TargetPath)
 	sshOpts := fmt.Sprintf("ssh -i %s -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null -p %d", cfg.SSHKeyPath, task.TargetPort)
 
-	// --no-t prevents permission errors on setting times for root-owned dirs like /tmp
-	args := []string{"-avz", "--no-t", "-e", sshOpts, sourcePath, destination}
-	cmd := exec.Command("rsync", args...)
+	args := remoteRestoreArgs(options, valueobjects.RestoreRenameSuffix(time.Now()))
+	args = append(args, "-e", sshOpts, sourcePath, destination)
+	cmd := exec.CommandContext(ctx, "rsync", args...)
 
 	log.Printf("Executing Remote Restore: %s", cmd.String())
-	output, err := cmd.CombinedOutput()
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
 	if err != nil {
-		return fmt.Errorf("rsync failed: %w, output: %s", err, string(output))
+		return nil, fmt.Errorf("rsync failed: %w, output: %s%s", err, string(output), stderr.String())
 	}
 
-	return nil
+	return output, nil
+}
+
+// remoteRestoreArgs returns the rsync options for a restore with options.
+// Owners and groups are kept by name unless the options leave them to the
+// target. Times are not kept, as setting them fails on root-owned
+// directories like /tmp; with PreserveTimes file times are, directory times
+// still not.
+func remoteRestoreArgs(options valueobjects.RestoreOptions, renameSuffix string) []string {
+	args := []string{"-az"}
+	if options.PreserveTimes {
+		args = append(args, "--omit-dir-times")
+	} else {
+		args = append(args, "--no-t")
+	}
+	if options.DryRun {
+		args = append(args, "--dry-run", "--itemize-changes")
+	} else {
+		args = append(args, "-v")
+	}
+
+	if options.NoOwnership {
+		args = append(args, "--no-owner", "--no-group")
+	}
+	if options.NumericIDs {
+		args = append(args, "--numeric-ids")
+	}
+
+	switch options.Conflict {
+	case valueobjects.ConflictSkip:
+		args = append(args, "--ignore-existing")
+	case valueobjects.ConflictNewer:
+		args = append(args, "--update")
+	case valueobjects.ConflictRename:
+		args = append(args, "--backup", "--suffix="+renameSuffix)
+	}
+
+	if options.Delete {
+		args = append(args, "--delete")
+	}
+	return args
 }
 
 // --- Reporting Helpers ---
 
 func reportRestoreFailure(ctx context.Context, client *redis.Client, queue string, task workerDto.WorkerTask, msg string, err error) {
+	if taskAborted(ctx, task, err) {
+		return
+	}
 	fullMsg := fmt.Sprintf("%s: %v", msg, err)
 	log.Printf("ERROR: %s", fullMsg)
-	PublishResult(ctx, client, queue, workerDto.WorkerResult{
+	reportResult(ctx, client, queue, workerDto.WorkerResult{
 		Type:    taskTypeForRestore(task),
 		TaskID:  task.TaskID,
 		JobID:   task.JobID,
@@ -253,7 +431,7 @@ func reportRestoreFailure(ctx context.Context, client *redis.Client, queue strin
 
 func reportRestoreSuccess(ctx context.Context, client *redis.Client, queue string, task workerDto.WorkerTask, msg string) {
 	log.Printf("SUCCESS: %s", msg)
-	PublishResult(ctx, client, queue, workerDto.WorkerResult{
+	reportResult(ctx, client, queue, workerDto.WorkerResult{
 		Type:    taskTypeForRestore(task),
 		TaskID:  task.TaskID,
 		JobID:   task.JobID,
@@ -263,6 +441,9 @@ func reportRestoreSuccess(ctx context.Context, client *redis.Client, queue strin
 }
 
 func taskTypeForRestore(task workerDto.WorkerTask) workerDto.TaskType {
+	if task.Type == workerDto.TaskTypeRestoreDownload {
+		return task.Type
+	}
 	if task.TargetHost != "" {
 		return workerDto.TaskTypeRestoreRemote
 	}
@@ -0,0 +1,51 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoteRestoreArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  valueobjects.RestoreOptions
+		expected []string
+	}{
+		// The default of restores before the options existed: rsync -avz --no-t.
+		{"default", valueobjects.RestoreOptions{}, []string{"-az", "--no-t", "-v"}},
+		{"skip", valueobjects.RestoreOptions{Conflict: valueobjects.ConflictSkip}, []string{"-az", "--no-t", "-v", "--ignore-existing"}},
+		{"newer", valueobjects.RestoreOptions{Conflict: valueobjects.ConflictNewer}, []string{"-az", "--no-t", "-v", "--update"}},
+		{
+			"rename mirror",
+			valueobjects.RestoreOptions{Conflict: valueobjects.ConflictRename, Delete: true},
+			[]string{"-az", "--no-t", "-v", "--backup", "--suffix=.pre-restore-2024-03-01_02-00-00", "--delete"},
+		},
+		{
+			"numeric ids dry run",
+			valueobjects.RestoreOptions{NumericIDs: true, DryRun: true},
+			[]string{"-az", "--no-t", "--dry-run", "--itemize-changes", "--numeric-ids"},
+		},
+		{
+			"no ownership, file times",
+			valueobjects.RestoreOptions{NoOwnership: true, PreserveTimes: true},
+			[]string{"-az", "--omit-dir-times", "-v", "--no-owner", "--no-group"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, remoteRestoreArgs(tt.options, ".pre-restore-2024-03-01_02-00-00"))
+		})
+	}
+}
+
+func TestParseItemizedChanges(t *testing.T) {
+	output := []byte(">f+++++++++ etc/hosts\n.d          etc/\n>f.st...... etc/nginx.conf\n*deleting   etc/stale.conf\n")
+
+	preview := parseItemizedChanges(output)
+
+	assert.Equal(t, []string{">f+++++++++ etc/hosts", ">f.st...... etc/nginx.conf", "*deleting   etc/stale.conf"}, preview.Changes)
+	assert.False(t, preview.Truncated)
+}
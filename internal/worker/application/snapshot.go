@@ -0,0 +1,105 @@
+package application
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+)
+
+// SnapshotTimeFormat is the directory name layout of incremental snapshots.
+const SnapshotTimeFormat = valueobjects.SnapshotTimeFormat
+
+// partialSnapshotPrefix marks a snapshot that is still being written.
+// It is only renamed to its final timestamp name once rsync succeeded.
+const partialSnapshotPrefix = ".partial-"
+
+// rsyncPartialDir keeps partially transferred files inside a partial snapshot
+// so an interrupted run can be resumed.
+const rsyncPartialDir = ".rsync-partial"
+
+// IsSnapshotName reports whether name is a completed incremental snapshot.
+func IsSnapshotName(name string) bool {
+	_, ok := valueobjects.ParseSnapshotTime(name)
+	return ok
+}
+
+// snapshotName returns the name of a snapshot taken at t. Names are written
+// in the local zone, the one existing snapshots were named in and the one
+// they are read back in.
+func snapshotName(t time.Time) string {
+	return t.In(time.Local).Format(SnapshotTimeFormat)
+}
+
+// partialSnapshotName returns the work directory name for a snapshot timestamp.
+func partialSnapshotName(timestamp string) string {
+	return partialSnapshotPrefix + timestamp
+}
+
+// findPartialSnapshots lists leftover partial snapshots in baseDest, oldest first.
+func findPartialSnapshots(baseDest string) ([]string, error) {
+	entries, err := os.ReadDir(baseDest)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var partials []string
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), partialSnapshotPrefix) {
+			partials = append(partials, entry.Name())
+		}
+	}
+	SortStrings(partials)
+	return partials, nil
+}
+
+// preparePartialSnapshot creates the work directory for a new snapshot.
+// If a previous run left partial snapshots behind, the newest one is reused so
+// rsync only transfers what is still missing; any older leftovers are removed.
+func preparePartialSnapshot(baseDest string, timestamp string) (string, error) {
+	workDest := filepath.Join(baseDest, partialSnapshotName(timestamp))
+
+	partials, err := findPartialSnapshots(baseDest)
+	if err != nil {
+		return "", fmt.Errorf("failed to scan for partial snapshots: %w", err)
+	}
+
+	if len(partials) > 0 {
+		for _, stale := range partials[:len(partials)-1] {
+			stalePath := filepath.Join(baseDest, stale)
+			log.Printf("Removing stale partial snapshot %s", stalePath)
+			if err := os.RemoveAll(stalePath); err != nil {
+				log.Printf("WARNING: Failed to remove stale partial snapshot %s: %v", stalePath, err)
+			}
+		}
+
+		resumed := filepath.Join(baseDest, partials[len(partials)-1])
+		log.Printf("Resuming partial snapshot %s as %s", resumed, workDest)
+		if err := os.Rename(resumed, workDest); err != nil {
+			return "", fmt.Errorf("failed to resume partial snapshot %s: %w", resumed, err)
+		}
+		return workDest, nil
+	}
+
+	if err := os.MkdirAll(workDest, 0755); err != nil {
+		return "", fmt.Errorf("mkdir %s failed: %w", workDest, err)
+	}
+	return workDest, nil
+}
+
+// promoteSnapshot atomically renames a finished partial snapshot to its final
+// name and repoints the 'latest' symlink to it.
+func promoteSnapshot(baseDest, workDest, finalDest string) error {
+	if err := os.Rename(workDest, finalDest); err != nil {
+		return fmt.Errorf("failed to promote snapshot %s: %w", workDest, err)
+	}
+	updateLatestSymlink(filepath.Join(baseDest, "latest"), finalDest)
+	return nil
+}
@@ -0,0 +1,61 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	from := filepath.Join(dir, "2024-01-01_00-00-00")
+	to := filepath.Join(dir, "2024-01-02_00-00-00")
+	mtime := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	write := func(path, content string, modTime time.Time) {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		assert.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+
+	// Unchanged and hard-linked, as rsync --link-dest leaves it.
+	write(filepath.Join(from, "etc", "hosts"), "127.0.0.1", mtime)
+	assert.NoError(t, os.MkdirAll(filepath.Join(to, "etc"), 0755))
+	assert.NoError(t, os.Link(filepath.Join(from, "etc", "hosts"), filepath.Join(to, "etc", "hosts")))
+	// Unchanged but copied.
+	write(filepath.Join(from, "etc", "motd"), "hi", mtime)
+	write(filepath.Join(to, "etc", "motd"), "hi", mtime)
+	// Modified.
+	write(filepath.Join(from, "etc", "nginx.conf"), "worker 1;", mtime)
+	write(filepath.Join(to, "etc", "nginx.conf"), "worker 1024;", mtime.Add(time.Hour))
+	// Removed and added.
+	write(filepath.Join(from, "old.log"), "12345", mtime)
+	write(filepath.Join(to, "var", "new.log"), "1234567", mtime)
+	// Symlink retargeted.
+	assert.NoError(t, os.Symlink("hosts", filepath.Join(from, "etc", "link")))
+	assert.NoError(t, os.Symlink("motd", filepath.Join(to, "etc", "link")))
+
+	diff, err := diffSnapshots(dir, "2024-01-01_00-00-00", "2024-01-02_00-00-00")
+
+	assert.NoError(t, err)
+	assert.Len(t, diff.Added, 1)
+	assert.Equal(t, "var/new.log", diff.Added[0].Path)
+	assert.Equal(t, int64(7), diff.Added[0].SizeDelta)
+	assert.Len(t, diff.Removed, 1)
+	assert.Equal(t, "old.log", diff.Removed[0].Path)
+	assert.Equal(t, int64(-5), diff.Removed[0].SizeDelta)
+	assert.Len(t, diff.Modified, 2)
+	assert.Equal(t, "etc/link", diff.Modified[0].Path)
+	assert.Equal(t, "etc/nginx.conf", diff.Modified[1].Path)
+	assert.Equal(t, int64(12), diff.Modified[1].Size)
+	assert.Equal(t, int64(3), diff.Modified[1].SizeDelta)
+	assert.Equal(t, int64(-1), diff.Modified[0].SizeDelta)
+	assert.Equal(t, int64(7-5+3-1), diff.SizeDelta)
+}
+
+func TestDiffSnapshots_RejectsInvalidSnapshot(t *testing.T) {
+	_, err := diffSnapshots(t.TempDir(), "../etc", "2024-01-02_00-00-00")
+	assert.Error(t, err)
+}
@@ -0,0 +1,107 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+)
+
+// HandleListSnapshots reports the snapshots of the incremental backup stored
+// at task.Path, oldest first, along with their size and file count.
+func HandleListSnapshots(ctx context.Context, task workerDto.WorkerTask, redisClient *redis.Client) {
+	log.Printf("Listing snapshots for path: %s", task.Path)
+
+	result := workerDto.WorkerResult{
+		Type:   workerDto.TaskTypeListSnapshots,
+		TaskID: task.TaskID,
+	}
+
+	snapshots, err := collectSnapshotInfo(task.Path)
+	if err != nil {
+		log.Printf("Failed to list snapshots in %s: %v", task.Path, err)
+		result.Status = "failed"
+		result.Message = fmt.Sprintf("Failed to list snapshots: %v", err)
+	} else {
+		result.Status = "completed"
+		result.Message = fmt.Sprintf("Found %d snapshots", len(snapshots))
+		result.Data = workerDto.ListSnapshotsResult{Snapshots: snapshots}
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("Failed to marshal result: %v", err)
+		return
+	}
+
+	channel := "worker_sync_responses"
+	if err := redisClient.Publish(ctx, channel, data).Err(); err != nil {
+		log.Printf("Failed to publish result to %s: %v", channel, err)
+	}
+}
+
+// collectSnapshotInfo measures every completed snapshot in backupDir. A
+// snapshot directory is walked for its apparent size and file count; an
+// encrypted archive only reports its own size.
+func collectSnapshotInfo(backupDir string) ([]workerDto.SnapshotInfo, error) {
+	artifacts, err := scanSnapshotArtifacts(backupDir)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := latestSnapshots(backupDir)
+	snapshots := make([]workerDto.SnapshotInfo, 0, len(artifacts))
+	for _, a := range artifacts {
+		t, _ := valueobjects.ParseSnapshotTime(a.Name)
+		info := workerDto.SnapshotInfo{Name: a.Name, Time: t, Latest: latest[a.Name]}
+
+		for _, entry := range a.Entries {
+			path := filepath.Join(backupDir, entry)
+			if entry == a.Name {
+				size, count, err := measureSnapshotDir(path)
+				if err != nil {
+					log.Printf("Failed to measure snapshot %s: %v", path, err)
+					continue
+				}
+				info.Size = size
+				info.FileCount = &count
+				continue
+			}
+
+			// Only report the archive size when there is no plain copy.
+			info.Encrypted = true
+			if fi, err := os.Stat(path); err == nil && info.FileCount == nil {
+				info.Size = fi.Size()
+			}
+		}
+		snapshots = append(snapshots, info)
+	}
+	return snapshots, nil
+}
+
+func measureSnapshotDir(dir string) (int64, int64, error) {
+	var size, count int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		count++
+		return nil
+	})
+	return size, count, err
+}
@@ -0,0 +1,44 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectSnapshotInfo(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "2024-01-01_00-00-00")
+	assert.NoError(t, os.MkdirAll(filepath.Join(first, "etc"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(first, "etc", "hosts"), []byte("12345"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(first, "motd"), []byte("123"), 0644))
+	assert.NoError(t, os.Symlink("motd", filepath.Join(first, "motd.link")))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "2024-01-02_00-00-00.tar.gz.enc"), []byte("encrypted"), 0644))
+	assert.NoError(t, os.Symlink("2024-01-02_00-00-00.tar.gz.enc", filepath.Join(dir, "latest.tar.gz.enc")))
+
+	snapshots, err := collectSnapshotInfo(dir)
+
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 2)
+
+	assert.Equal(t, "2024-01-01_00-00-00", snapshots[0].Name)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local), snapshots[0].Time)
+	assert.Equal(t, int64(8), snapshots[0].Size)
+	assert.Equal(t, int64(2), *snapshots[0].FileCount)
+	assert.False(t, snapshots[0].Encrypted)
+	assert.False(t, snapshots[0].Latest)
+
+	assert.Equal(t, "2024-01-02_00-00-00", snapshots[1].Name)
+	assert.Equal(t, int64(9), snapshots[1].Size)
+	assert.Nil(t, snapshots[1].FileCount)
+	assert.True(t, snapshots[1].Encrypted)
+	assert.True(t, snapshots[1].Latest)
+}
+
+func TestCollectSnapshotInfo_MissingDir(t *testing.T) {
+	_, err := collectSnapshotInfo(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,108 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsSnapshotName(t *testing.T) {
+	assert.True(t, IsSnapshotName("2024-01-31_23-59-00"))
+	assert.False(t, IsSnapshotName(".partial-2024-01-31_23-59-00"))
+	assert.False(t, IsSnapshotName("latest"))
+	assert.False(t, IsSnapshotName("2024-13-01_00-00-00"))
+	assert.False(t, IsSnapshotName("abcd-ef-gh_ij-kl-mn"))
+}
+
+func TestSnapshotName_SelectedOutsideUTC(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("UTC+5", 5*60*60)
+	t.Cleanup(func() { time.Local = local })
+
+	// Named by a worker before upgrading, and by this one half an hour later.
+	older := time.Date(2024, 3, 1, 14, 0, 0, 0, time.Local).Format(SnapshotTimeFormat)
+	newer := snapshotName(time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC))
+	assert.Equal(t, "2024-03-01_14-00-00", older)
+	assert.Equal(t, "2024-03-01_14-30-00", newer)
+	names := []string{older, newer}
+
+	for value, want := range map[string]string{
+		valueobjects.SnapshotLatest: newer,
+		"2024-03-01 14:10":          older,
+		"2024-03-01T09:10:00Z":      older,
+		"2024-03-01T14:30:00+05:00": newer,
+		"2024-03-01":                newer,
+	} {
+		selector, err := valueobjects.ParseSnapshotSelector(value)
+		require.NoError(t, err)
+		selected, err := selector.Resolve(names)
+		require.NoError(t, err, value)
+		assert.Equal(t, want, selected, value)
+	}
+
+	// A point in time before either snapshot was taken selects neither.
+	selector, err := valueobjects.ParseSnapshotSelector("2024-03-01 13:59")
+	require.NoError(t, err)
+	_, err = selector.Resolve(names)
+	assert.ErrorIs(t, err, valueobjects.ErrSnapshotNotFound)
+
+	// Retention keeps the snapshot the 'latest' link points to.
+	policy, err := valueobjects.NewRetentionPolicy(1, 0, 0, 0, 0)
+	require.NoError(t, err)
+	plan := policy.PlanSnapshots(names, newer, nil)
+	assert.Equal(t, []string{older}, plan.Purge)
+}
+
+func TestPreparePartialSnapshot(t *testing.T) {
+	t.Run("Creates a fresh partial snapshot", func(t *testing.T) {
+		base := t.TempDir()
+
+		workDest, err := preparePartialSnapshot(base, "2024-01-02_00-00-00")
+
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(base, ".partial-2024-01-02_00-00-00"), workDest)
+		info, err := os.Stat(workDest)
+		assert.NoError(t, err)
+		assert.True(t, info.IsDir())
+	})
+
+	t.Run("Resumes the newest leftover and removes older ones", func(t *testing.T) {
+		base := t.TempDir()
+		older := filepath.Join(base, ".partial-2024-01-01_00-00-00")
+		newer := filepath.Join(base, ".partial-2024-01-01_12-00-00")
+		assert.NoError(t, os.MkdirAll(older, 0755))
+		assert.NoError(t, os.MkdirAll(newer, 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(newer, "data.txt"), []byte("x"), 0644))
+
+		workDest, err := preparePartialSnapshot(base, "2024-01-02_00-00-00")
+
+		assert.NoError(t, err)
+		_, err = os.Stat(filepath.Join(workDest, "data.txt"))
+		assert.NoError(t, err, "resumed snapshot should keep already transferred files")
+		_, err = os.Stat(older)
+		assert.True(t, os.IsNotExist(err))
+		_, err = os.Stat(newer)
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestPromoteSnapshot(t *testing.T) {
+	base := t.TempDir()
+	workDest := filepath.Join(base, ".partial-2024-01-02_00-00-00")
+	finalDest := filepath.Join(base, "2024-01-02_00-00-00")
+	assert.NoError(t, os.MkdirAll(workDest, 0755))
+
+	err := promoteSnapshot(base, workDest, finalDest)
+
+	assert.NoError(t, err)
+	_, err = os.Stat(finalDest)
+	assert.NoError(t, err)
+	target, err := os.Readlink(filepath.Join(base, "latest"))
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-01-02_00-00-00", target)
+}
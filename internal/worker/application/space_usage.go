@@ -0,0 +1,109 @@
+package application
+
+import (
+	"io/fs"
+	"log"
+	"path/filepath"
+	"syscall"
+
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+)
+
+// inodeKey identifies a file across its hard links.
+type inodeKey struct {
+	dev uint64
+	ino uint64
+}
+
+// treeUsage is what walking a tree found. Apparent counts every link of a
+// file, Unique only files whose links are all inside the tree and Stored
+// every file once, the first time one of its links is seen.
+type treeUsage struct {
+	Apparent int64
+	Unique   int64
+	Stored   int64
+}
+
+// measureTree walks root, which may also be a single file, without following
+// symlinks so the 'latest' link is not counted twice.
+func measureTree(root string) (treeUsage, error) {
+	var usage treeUsage
+	type seenInode struct {
+		size  int64
+		links uint64
+		seen  uint64
+	}
+	inodes := make(map[inodeKey]*seenInode)
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		usage.Apparent += info.Size()
+
+		stat, ok := info.Sys().(*syscall.Stat_t)
+		if !ok || uint64(stat.Nlink) <= 1 {
+			usage.Unique += info.Size()
+			usage.Stored += info.Size()
+			return nil
+		}
+		key := inodeKey{dev: uint64(stat.Dev), ino: uint64(stat.Ino)}
+		inode, ok := inodes[key]
+		if !ok {
+			inode = &seenInode{size: info.Size(), links: uint64(stat.Nlink)}
+			inodes[key] = inode
+			usage.Stored += info.Size()
+		}
+		inode.seen++
+		return nil
+	})
+	if err != nil {
+		return treeUsage{}, err
+	}
+
+	for _, inode := range inodes {
+		if inode.seen >= inode.links {
+			usage.Unique += inode.size
+		}
+	}
+	return usage, nil
+}
+
+// measureBackupUsage measures the artifact a backup run produced. Hard links
+// from --link-dest make the apparent size of a snapshot much larger than what
+// the run added, so the unique bytes only count files no other snapshot links
+// to. A mirror is the whole backup, so it also gives the stored size. A
+// snapshot only adds its unique bytes to what the backup stored before: the
+// rest of the backup is not walked again, the scheduled purge and tiering
+// measure it once they removed snapshots.
+func measureBackupUsage(artifact string, incremental bool) (workerDto.BackupResult, error) {
+	snapshot, err := measureTree(artifact)
+	if err != nil {
+		return workerDto.BackupResult{}, err
+	}
+	result := workerDto.BackupResult{Size: snapshot.Apparent, UniqueSize: snapshot.Unique}
+	if incremental {
+		result.AddedSize = snapshot.Unique
+	} else {
+		result.StoredSize = snapshot.Stored
+	}
+	return result, nil
+}
+
+// measureStoredSize returns the space backupDir takes on disk, hard-linked
+// files counted once, or nil when it cannot be measured.
+func measureStoredSize(backupDir string) *int64 {
+	usage, err := measureTree(backupDir)
+	if err != nil {
+		log.Printf("Failed to measure space usage of %s: %v", backupDir, err)
+		return nil
+	}
+	return &usage.Stored
+}
@@ -0,0 +1,51 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeasureBackupUsage(t *testing.T) {
+	backupDir := t.TempDir()
+	older := filepath.Join(backupDir, "2024-01-01_00-00-00")
+	newer := filepath.Join(backupDir, "2024-01-02_00-00-00")
+	require.NoError(t, os.MkdirAll(older, 0755))
+	require.NoError(t, os.MkdirAll(newer, 0755))
+
+	// Unchanged by the newer run, so --link-dest hard-linked it.
+	require.NoError(t, os.WriteFile(filepath.Join(older, "unchanged"), []byte(strings.Repeat("u", 1000)), 0644))
+	require.NoError(t, os.Link(filepath.Join(older, "unchanged"), filepath.Join(newer, "unchanged")))
+	// Only in the older snapshot.
+	require.NoError(t, os.WriteFile(filepath.Join(older, "removed"), []byte(strings.Repeat("r", 300)), 0644))
+	// Added by the newer run, once with a second link inside the snapshot.
+	require.NoError(t, os.WriteFile(filepath.Join(newer, "added"), []byte(strings.Repeat("a", 200)), 0644))
+	require.NoError(t, os.Link(filepath.Join(newer, "added"), filepath.Join(newer, "added-again")))
+	require.NoError(t, os.Symlink(filepath.Base(newer), filepath.Join(backupDir, "latest")))
+
+	usage, err := measureBackupUsage(newer, true)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1400), usage.Size)
+	assert.Equal(t, int64(200), usage.UniqueSize)
+	assert.Equal(t, int64(200), usage.AddedSize)
+	assert.Zero(t, usage.StoredSize, "the rest of the backup is not walked")
+
+	mirror, err := measureBackupUsage(older, false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1300), mirror.Size)
+	assert.Equal(t, int64(1300), mirror.StoredSize)
+	assert.Zero(t, mirror.AddedSize)
+
+	stored := measureStoredSize(backupDir)
+	require.NotNil(t, stored)
+	assert.Equal(t, int64(1500), *stored)
+
+	_, err = measureBackupUsage(filepath.Join(backupDir, "missing"), true)
+	assert.Error(t, err)
+	assert.Nil(t, measureStoredSize(filepath.Join(backupDir, "missing")))
+}
@@ -0,0 +1,211 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/crypto"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+)
+
+// HandleDeleteDataTask removes for good the data of a deleted backup, or an
+// orphaned entry: task.Path with the encrypted archive of a mirror and its
+// index next to it, the archives of the backup in cold storage pools and the
+// catalog hashes the worker kept for it.
+// Directories left empty above task.Path are removed up to its storage root.
+func HandleDeleteDataTask(ctx context.Context, task workerDto.WorkerTask, redisClient *redis.Client, resultQueue string) {
+	report := workerDto.DeleteDataResult{BackupID: task.BackupID, Path: task.Path, Removed: []string{}}
+	result := workerDto.WorkerResult{
+		Type:   workerDto.TaskTypeDeleteData,
+		TaskID: task.TaskID,
+		JobID:  task.JobID,
+		Data:   &report,
+	}
+
+	log.Printf("Deleting stored data at %s", task.Path)
+	err := deleteStoredData(task.BackupRoot, task.Path, task.ColdArchives, &report)
+	if taskAborted(ctx, task, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to delete stored data at %s: %v", task.Path, err)
+		result.Status = "failed"
+		result.Message = fmt.Sprintf("Deleting stored data failed: %v", err)
+	} else {
+		result.Status = "completed"
+		result.Message = fmt.Sprintf("Removed %d entries", len(report.Removed))
+	}
+	reportResult(ctx, redisClient, resultQueue, result)
+}
+
+func deleteStoredData(root string, target string, cold []valueobjects.ColdLocation, report *workerDto.DeleteDataResult) error {
+	if root == "" || !insideRoot(root, target) {
+		return fmt.Errorf("%s is not inside the storage root %q", target, root)
+	}
+
+	var failed []string
+	remove := func(path string) {
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			return
+		}
+		if err := os.RemoveAll(path); err != nil {
+			log.Printf("Failed to delete %s: %v", path, err)
+			failed = append(failed, path)
+			return
+		}
+		report.Removed = append(report.Removed, path)
+	}
+
+	archive := valueobjects.EncryptedSnapshotSuffix
+	for _, suffix := range []string{"", archive, archive + crypto.ArchiveIndexSuffix} {
+		remove(target + suffix)
+	}
+	removeEmptyParents(root, target)
+	if report.BackupID != "" {
+		remove(catalogHashesPath(root, report.BackupID))
+	}
+
+	for _, a := range cold {
+		if !filepath.IsAbs(a.Archive) {
+			failed = append(failed, a.Archive)
+			continue
+		}
+		remove(a.Archive)
+		if strings.HasSuffix(a.Archive, archive) {
+			remove(a.Archive + crypto.ArchiveIndexSuffix)
+		}
+		// The directory of the backup in the cold pool, once it is empty.
+		_ = os.Remove(filepath.Dir(a.Archive))
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to delete %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// insideRoot reports whether path lies strictly below root.
+func insideRoot(root string, path string) bool {
+	if !filepath.IsAbs(path) {
+		return false
+	}
+	rel, err := filepath.Rel(filepath.Clean(root), filepath.Clean(path))
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// removeEmptyParents removes the directories above path that are left
+// empty, stopping below root.
+func removeEmptyParents(root string, path string) {
+	for dir := filepath.Dir(filepath.Clean(path)); insideRoot(root, dir); dir = filepath.Dir(dir) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+	}
+}
+
+// HandleScanOrphans lists what lies under the storage roots of a task that
+// none of its referenced directories holds, answering on the sync channel.
+func HandleScanOrphans(ctx context.Context, task workerDto.WorkerTask, redisClient *redis.Client) {
+	log.Printf("Scanning %d storage roots for orphaned data", len(task.ScanRoots))
+
+	result := workerDto.WorkerResult{
+		Type:   workerDto.TaskTypeScanOrphans,
+		TaskID: task.TaskID,
+	}
+
+	scan, err := scanOrphans(task.ScanRoots, task.ReferencedDirs)
+	if err != nil {
+		log.Printf("Failed to scan for orphaned data: %v", err)
+		result.Status = "failed"
+		result.Message = fmt.Sprintf("Failed to scan for orphaned data: %v", err)
+	} else {
+		result.Status = "completed"
+		result.Message = fmt.Sprintf("Found %d orphaned entries", len(scan.Orphans))
+		result.Data = scan
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("Failed to marshal result: %v", err)
+		return
+	}
+
+	channel := "worker_sync_responses"
+	if err := redisClient.Publish(ctx, channel, data).Err(); err != nil {
+		log.Printf("Failed to publish result to %s: %v", channel, err)
+	}
+}
+
+// scanOrphans walks each root down to the referenced directories. An entry
+// that is not a referenced directory, the encrypted archive of one or on the
+// way to one is orphaned, and measured as a whole. A root inside another one
+// is only scanned on its own.
+func scanOrphans(roots []string, referenced []string) (workerDto.OrphanScanResult, error) {
+	refs := make(map[string]bool, len(referenced)+len(roots))
+	ancestors := make(map[string]bool)
+	for _, r := range append(append([]string{}, referenced...), roots...) {
+		r = filepath.Clean(r)
+		refs[r] = true
+		for dir := filepath.Dir(r); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+			ancestors[dir] = true
+		}
+	}
+
+	result := workerDto.OrphanScanResult{Orphans: []workerDto.OrphanEntry{}}
+	for _, root := range roots {
+		root = filepath.Clean(root)
+		if _, err := os.Stat(root); os.IsNotExist(err) {
+			result.Missing = append(result.Missing, root)
+			continue
+		}
+		if err := collectOrphans(root, root, refs, ancestors, &result.Orphans); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func collectOrphans(root string, dir string, refs map[string]bool, ancestors map[string]bool, orphans *[]workerDto.OrphanEntry) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if refs[referencedDir(path)] || (dir == root && (e.Name() == "lost+found" || e.Name() == valueobjects.WorkerStateDir)) {
+			continue
+		}
+		if ancestors[path] && e.IsDir() {
+			if err := collectOrphans(root, path, refs, ancestors, orphans); err != nil {
+				return err
+			}
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil {
+			log.Printf("Failed to stat %s: %v", path, err)
+			continue
+		}
+		usage, err := measureTree(path)
+		if err != nil {
+			log.Printf("Failed to measure %s: %v", path, err)
+		}
+		*orphans = append(*orphans, workerDto.OrphanEntry{Root: root, Path: path, IsDir: e.IsDir(), Size: usage.Stored, ModTime: info.ModTime()})
+	}
+	return nil
+}
+
+// referencedDir maps the encrypted archive of a mirror, or its index, to the
+// directory of the backup it belongs to.
+func referencedDir(path string) string {
+	path = strings.TrimSuffix(path, crypto.ArchiveIndexSuffix)
+	return strings.TrimSuffix(path, valueobjects.EncryptedSnapshotSuffix)
+}
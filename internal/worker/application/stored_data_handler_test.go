@@ -0,0 +1,71 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteStoredData(t *testing.T) {
+	root := t.TempDir()
+	cold := t.TempDir()
+	backupDir := filepath.Join(root, "host", "docs")
+	require.NoError(t, os.MkdirAll(filepath.Join(backupDir, "2024-01-01_00-00-00"), 0755))
+	require.NoError(t, os.WriteFile(backupDir+valueobjects.EncryptedSnapshotSuffix, []byte("x"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(cold, "host", "docs"), 0755))
+	archive := filepath.Join(cold, "host", "docs", "2023-01-01_00-00-00.tar.gz")
+	require.NoError(t, os.WriteFile(archive, []byte("x"), 0644))
+	hashes := catalogHashesPath(root, "backup-1")
+	require.NoError(t, os.MkdirAll(filepath.Dir(hashes), 0755))
+	require.NoError(t, os.WriteFile(hashes, []byte("x"), 0644))
+
+	report := workerDto.DeleteDataResult{BackupID: "backup-1"}
+	err := deleteStoredData(root, backupDir, []valueobjects.ColdLocation{{Archive: archive}}, &report)
+	require.NoError(t, err)
+	assert.Len(t, report.Removed, 4)
+	assert.NoFileExists(t, hashes)
+
+	assert.NoDirExists(t, filepath.Join(root, "host"), "empty parents go too")
+	assert.DirExists(t, root, "the root stays")
+	assert.NoFileExists(t, archive)
+	assert.NoDirExists(t, filepath.Join(cold, "host", "docs"))
+}
+
+func TestDeleteStoredData_RefusesOutsideRoot(t *testing.T) {
+	root := t.TempDir()
+	for _, target := range []string{root, filepath.Dir(root), filepath.Join(root, ".."), "relative/path"} {
+		err := deleteStoredData(root, target, nil, &workerDto.DeleteDataResult{})
+		assert.Error(t, err, target)
+	}
+	assert.DirExists(t, root)
+}
+
+func TestScanOrphans(t *testing.T) {
+	root := t.TempDir()
+	pool := filepath.Join(root, "pool")
+	kept := filepath.Join(root, "host", "docs")
+	mirror := filepath.Join(root, "host", "mirror")
+	for _, dir := range []string{kept, filepath.Join(root, "host", "old"), filepath.Join(root, "gone-host", "x"), filepath.Join(pool, "leftover"), filepath.Join(root, "lost+found"), filepath.Join(root, valueobjects.WorkerStateDir, "catalog")} {
+		require.NoError(t, os.MkdirAll(dir, 0755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(root, "host", "old", "file"), []byte("12345"), 0644))
+	require.NoError(t, os.WriteFile(mirror+valueobjects.EncryptedSnapshotSuffix, []byte("x"), 0644))
+
+	result, err := scanOrphans([]string{root, pool, filepath.Join(root, "missing")}, []string{kept, mirror})
+	require.NoError(t, err)
+
+	paths := make(map[string]int64)
+	for _, orphan := range result.Orphans {
+		paths[orphan.Path] = orphan.Size
+	}
+	assert.Len(t, paths, 3)
+	assert.Contains(t, paths, filepath.Join(root, "gone-host"))
+	assert.Contains(t, paths, filepath.Join(pool, "leftover"))
+	assert.Equal(t, int64(5), paths[filepath.Join(root, "host", "old")])
+	assert.Equal(t, []string{filepath.Join(root, "missing")}, result.Missing)
+}
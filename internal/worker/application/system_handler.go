@@ -12,6 +12,7 @@ import (
 	"syscall"
 
 	"github.com/redis/go-redis/v9"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
 	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/config"
 	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
 )
@@ -23,7 +24,7 @@ func HandleMeasureSizeTask(ctx context.Context, task workerDto.WorkerTask, redis
 	cfg, err := config.LoadWorkerConfig()
 	if err != nil {
 		log.Printf("CRITICAL: Failed to load worker config: %v", err)
-		PublishResult(ctx, redisClient, resultQueue, workerDto.WorkerResult{
+		reportResult(ctx, redisClient, resultQueue, workerDto.WorkerResult{
 			Type:    workerDto.TaskTypeMeasureSize,
 			TaskID:  task.TaskID,
 			JobID:   task.JobID,
@@ -81,46 +82,42 @@ func HandleMeasureSizeTask(ctx context.Context, task workerDto.WorkerTask, redis
 		Message: "Size measured successfully",
 		Data:    map[string]string{"size": size},
 	}
-
-	data, err := json.Marshal(result)
-	if err != nil {
-		log.Printf("Failed to marshal result: %v", err)
-		return
-	}
-
-	if err := redisClient.RPush(ctx, resultQueue, data).Err(); err != nil {
-		log.Printf("Failed to publish result: %v", err)
-	}
+	reportResult(ctx, redisClient, resultQueue, result)
 }
 
 func HandleGetDiskUsage(ctx context.Context, task workerDto.WorkerTask, redisClient *redis.Client) {
-	log.Printf("Getting disk usage for backup root")
-
-	var stat syscall.Statfs_t
-	// We check the disk usage of the backup root mount point
-	// In the worker container, this is mounted at /mnt/backups
-	backupMountPoint := "/mnt/backups"
-
-	if err := syscall.Statfs(backupMountPoint, &stat); err != nil {
-		log.Printf("Failed to get disk usage: %v", err)
-		return
+	// The backup root of the worker unless the root of a storage pool is asked for
+	backupMountPoint := task.Path
+	if backupMountPoint == "" {
+		backupMountPoint = valueobjects.DefaultStorageRoot
+		if cfg, err := config.LoadWorkerConfig(); err == nil {
+			backupMountPoint = cfg.ContainerBackupRoot
+		}
 	}
-
-	// Calculate usage
-	total := stat.Blocks * uint64(stat.Bsize)
-	free := stat.Bfree * uint64(stat.Bsize)
-	used := total - free
+	log.Printf("Getting disk usage for %s", backupMountPoint)
 
 	result := workerDto.WorkerResult{
 		Type:    workerDto.TaskTypeGetDiskUsage,
 		TaskID:  task.TaskID,
 		Status:  "completed",
 		Message: "Disk usage retrieved successfully",
-		Data: map[string]string{
+	}
+
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(backupMountPoint, &stat); err != nil {
+		log.Printf("Failed to get disk usage: %v", err)
+		result.Status = "failed"
+		result.Message = fmt.Sprintf("Failed to get disk usage of %s: %v", backupMountPoint, err)
+	} else {
+		// Calculate usage
+		total := stat.Blocks * uint64(stat.Bsize)
+		free := stat.Bfree * uint64(stat.Bsize)
+		used := total - free
+		result.Data = map[string]string{
 			"total": fmt.Sprintf("%d", total),
 			"free":  fmt.Sprintf("%d", free),
 			"used":  fmt.Sprintf("%d", used),
-		},
+		}
 	}
 
 	data, err := json.Marshal(result)
@@ -138,54 +135,6 @@ func HandleGetDiskUsage(ctx context.Context, task workerDto.WorkerTask, redisCli
 	}
 }
 
-func HandleSearchFiles(ctx context.Context, task workerDto.WorkerTask, redisClient *redis.Client) {
-	log.Printf("Searching files with pattern: %s", task.SearchPattern)
-
-	backupMountPoint := "/mnt/backups"
-
-	// Ensure the search pattern is safe and within the backup mount point.
-
-	// Use find to search for files matching the pattern
-	// -name supports simple globbing like * and ?
-	cmd := exec.Command("find", backupMountPoint, "-name", task.SearchPattern)
-	var out bytes.Buffer
-	cmd.Stdout = &out
-
-	if err := cmd.Run(); err != nil {
-		log.Printf("Find command failed: %v", err)
-		// We don't return error here, just an empty list or error message in result
-	}
-
-	files := strings.Split(strings.TrimSpace(out.String()), "\n")
-	if len(files) == 1 && files[0] == "" {
-		files = []string{}
-	}
-
-	// Returning full paths as seen by the worker.
-
-	result := workerDto.WorkerResult{
-		Type:    workerDto.TaskTypeSearchFiles,
-		TaskID:  task.TaskID,
-		Status:  "completed",
-		Message: fmt.Sprintf("Found %d files", len(files)),
-		Data: map[string]interface{}{
-			"files": files,
-		},
-	}
-
-	data, err := json.Marshal(result)
-	if err != nil {
-		log.Printf("Failed to marshal result: %v", err)
-		return
-	}
-
-	// We use PubSub for synchronous-like response, same as DiskUsage
-	channel := "worker_sync_responses"
-	if err := redisClient.Publish(ctx, channel, data).Err(); err != nil {
-		log.Printf("Failed to publish result to %s: %v", channel, err)
-	}
-}
-
 func HandleListFiles(ctx context.Context, task workerDto.WorkerTask, redisClient *redis.Client) {
 	log.Printf("Listing files for path: %s", task.Path)
 
@@ -0,0 +1,356 @@
+package application
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/config"
+	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/crypto"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+)
+
+// HandleTierTask moves the snapshots of an incremental backup that are older
+// than its tiering policy allows off its storage, oldest first. A snapshot
+// directory is packed into an archive in the cold storage pool, encrypted if
+// the policy asks for it; an encrypted archive is moved there as it is. For a
+// replica tier the local copy is removed once the target holds the snapshot.
+// The latest snapshot always stays.
+func HandleTierTask(ctx context.Context, task workerDto.WorkerTask, redisClient *redis.Client, resultQueue string) {
+	report := workerDto.TierResult{BackupID: task.BackupID, Archived: []workerDto.ArchivedSnapshot{}}
+	if task.TieringPolicy != nil {
+		report.StoragePoolID = task.TieringPolicy.StoragePoolID
+		report.ReplicaTargetID = task.TieringPolicy.ReplicaTargetID
+	}
+
+	result := workerDto.WorkerResult{
+		Type:   workerDto.TaskTypeTier,
+		TaskID: task.TaskID,
+		JobID:  task.JobID,
+		Data:   &report,
+	}
+	err := tier(ctx, task, time.Now(), &report)
+	// Snapshots already moved are reported, as they are gone from where the
+	// task expects them.
+	if len(report.Archived) == 0 && taskAborted(ctx, task, err) {
+		return
+	}
+	if err == nil && len(report.Failed) > 0 {
+		err = fmt.Errorf("failed to move %s", strings.Join(report.Failed, ", "))
+	}
+	if err != nil {
+		log.Printf("Tiering of backup %s failed: %v", task.BackupID, err)
+		result.Status = "failed"
+		result.Message = fmt.Sprintf("Tiering failed: %v", err)
+	} else {
+		result.Status = "completed"
+		result.Message = fmt.Sprintf("%d snapshots archived", len(report.Archived))
+	}
+	reportResult(ctx, redisClient, resultQueue, result)
+}
+
+func tier(ctx context.Context, task workerDto.WorkerTask, now time.Time, report *workerDto.TierResult) error {
+	policy := task.TieringPolicy
+	if policy == nil {
+		return errors.New("task has no tiering policy")
+	}
+	if !task.Incremental {
+		return errors.New("only incremental backups are tiered")
+	}
+	cfg, err := config.LoadWorkerConfig()
+	if err != nil {
+		return err
+	}
+
+	backupDir := NormalizePath(task.Destination, TaskBackupRoot(task, cfg.ContainerBackupRoot), task.HostPath)
+	due, err := dueSnapshots(backupDir, *policy, now)
+	if err != nil {
+		return fmt.Errorf("failed to read backup dir: %w", err)
+	}
+	// Backup runs only add to the stored size, so it is measured again once
+	// snapshots are gone.
+	defer func() {
+		if len(report.Archived) > 0 {
+			report.StoredSize = measureStoredSize(backupDir)
+		}
+	}()
+
+	if policy.Kind() == valueobjects.TierReplica {
+		replicated := make(map[string]bool, len(task.ReplicatedSnapshots))
+		for _, name := range task.ReplicatedSnapshots {
+			replicated[name] = true
+		}
+		for _, a := range due {
+			// Left for a later run, once the target holds it.
+			if !replicated[a.Name] {
+				continue
+			}
+			size := snapshotArtifactSize(backupDir, a)
+			if !removeSnapshotArtifacts(backupDir, a.Entries) {
+				report.Failed = append(report.Failed, a.Name)
+				continue
+			}
+			report.Archived = append(report.Archived, workerDto.ArchivedSnapshot{Name: a.Name, Size: size})
+		}
+		return nil
+	}
+
+	if task.ColdRoot == "" {
+		return errors.New("task has no cold storage pool")
+	}
+	coldDir := NormalizePath(task.Destination, task.ColdRoot, task.HostPath)
+	if filepath.Clean(coldDir) == filepath.Clean(backupDir) {
+		return errors.New("the cold storage pool is the one the backup is in")
+	}
+	if err := os.MkdirAll(coldDir, 0755); err != nil {
+		return err
+	}
+	var key []byte
+	if policy.Encrypt {
+		if key, err = archiveKey(task.BackupID); err != nil {
+			return err
+		}
+	}
+
+	for _, a := range due {
+		archived, err := archiveSnapshot(ctx, task.BackupID, backupDir, coldDir, a, key)
+		if err != nil {
+			log.Printf("Failed to archive snapshot %s of %s: %v", a.Name, backupDir, err)
+			report.Failed = append(report.Failed, a.Name)
+			continue
+		}
+		log.Printf("Archived snapshot %s of %s to %s", a.Name, backupDir, filepath.Join(coldDir, archived.Archive))
+		report.Archived = append(report.Archived, archived)
+	}
+	return nil
+}
+
+// dueSnapshots returns the snapshots in backupDir old enough to be moved,
+// oldest first. The latest snapshot is never among them.
+func dueSnapshots(backupDir string, policy valueobjects.TieringPolicy, now time.Time) ([]snapshotArtifact, error) {
+	artifacts, err := scanSnapshotArtifacts(backupDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(artifacts) > 0 {
+		artifacts = artifacts[:len(artifacts)-1]
+	}
+
+	latest := latestSnapshots(backupDir)
+	var due []snapshotArtifact
+	for _, a := range artifacts {
+		if !latest[a.Name] && policy.Due(a.Name, now) {
+			due = append(due, a)
+		}
+	}
+	return due, nil
+}
+
+// archiveSnapshot moves a snapshot into coldDir and removes it from
+// backupDir. The archive is only in place once it is complete, so an
+// interrupted run leaves the snapshot where it was.
+func archiveSnapshot(ctx context.Context, backupID string, backupDir string, coldDir string, a snapshotArtifact, key []byte) (workerDto.ArchivedSnapshot, error) {
+	archived := workerDto.ArchivedSnapshot{Name: a.Name}
+
+	dir := ""
+	for _, entry := range a.Entries {
+		if !strings.HasSuffix(entry, valueobjects.EncryptedSnapshotSuffix) {
+			dir = entry
+			continue
+		}
+		// An encrypted archive is already packed; a directory left next to
+		// it when encryption was toggled is not needed.
+		archived.Archive, archived.Encrypted = entry, true
+	}
+
+	if archived.Archive != "" {
+		src, dst := filepath.Join(backupDir, archived.Archive), filepath.Join(coldDir, archived.Archive)
+		if _, err := os.Stat(src + crypto.ArchiveIndexSuffix); err == nil {
+			if err := moveBackupEntry(ctx, backupID, src+crypto.ArchiveIndexSuffix, dst+crypto.ArchiveIndexSuffix, false); err != nil {
+				return archived, err
+			}
+		}
+		if err := moveBackupEntry(ctx, backupID, src, dst, false); err != nil {
+			return archived, err
+		}
+	} else {
+		archived.Encrypted = key != nil
+		archived.Archive = valueobjects.ColdArchiveName(a.Name, archived.Encrypted)
+		if err := packSnapshot(filepath.Join(backupDir, dir), filepath.Join(coldDir, archived.Archive), key); err != nil {
+			return archived, err
+		}
+	}
+
+	if dir != "" {
+		if err := os.RemoveAll(filepath.Join(backupDir, dir)); err != nil {
+			return archived, err
+		}
+	}
+	if info, err := os.Stat(filepath.Join(coldDir, archived.Archive)); err == nil {
+		archived.Size = info.Size()
+	}
+	return archived, nil
+}
+
+// packSnapshot compresses the snapshot directory src into the archive dst,
+// encrypting it with key, along with an index, when key is set.
+func packSnapshot(src string, dst string, key []byte) error {
+	tmp := dst + ".partial"
+	defer func() { _ = os.Remove(tmp) }()
+
+	if key == nil {
+		if _, err := crypto.CompressDirectoryWithIndex(src, tmp); err != nil {
+			return fmt.Errorf("compression failed: %w", err)
+		}
+		return os.Rename(tmp, dst)
+	}
+
+	tarPath := tmp + ".tar.gz"
+	defer func() { _ = os.Remove(tarPath) }()
+	index, err := crypto.CompressDirectoryWithIndex(src, tarPath)
+	if err != nil {
+		return fmt.Errorf("compression failed: %w", err)
+	}
+	if err := crypto.EncryptFile(tarPath, tmp, key); err != nil {
+		return fmt.Errorf("encryption failed: %w", err)
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		return err
+	}
+	// Without its index the archive can still be restored as a whole.
+	if err := crypto.WriteArchiveIndex(index, dst, key); err != nil {
+		log.Printf("WARNING: Failed to write archive index for %s: %v", dst, err)
+	}
+	return nil
+}
+
+// snapshotArtifactSize returns the apparent size of a snapshot, the size of
+// its archive when it has one.
+func snapshotArtifactSize(backupDir string, a snapshotArtifact) int64 {
+	var size int64
+	for _, entry := range a.Entries {
+		p := filepath.Join(backupDir, entry)
+		if entry == a.Name {
+			if s, _, err := measureSnapshotDir(p); err == nil {
+				size = s
+			}
+			continue
+		}
+		if info, err := os.Stat(p); err == nil {
+			return info.Size()
+		}
+	}
+	return size
+}
+
+// fetchRestoreSource brings the source of a restore from where it was moved
+// to when it is missing locally: a cold storage pool or a replica target.
+func fetchRestoreSource(ctx context.Context, task workerDto.WorkerTask) (workerDto.WorkerTask, func(), error) {
+	if task.ColdSource != nil {
+		return recallColdSource(task)
+	}
+	return pullReplicaSource(ctx, task)
+}
+
+// recallColdSource reads the source of a restore back from its archive in a
+// cold storage pool when it is missing locally. What the restore reads is
+// unpacked into a temporary directory and the task pointed at it; the
+// returned cleanup removes it.
+func recallColdSource(task workerDto.WorkerTask) (workerDto.WorkerTask, func(), error) {
+	source := task.ColdSource
+	if source == nil {
+		return task, nil, nil
+	}
+	if _, err := os.Stat(task.Path); !errors.Is(err, fs.ErrNotExist) {
+		return task, nil, nil
+	}
+	log.Printf("%s is missing. Reading it from cold archive %s", task.Path, source.Archive)
+
+	tempDir, err := os.MkdirTemp("", "cold-*")
+	if err != nil {
+		return task, nil, fmt.Errorf("temp dir creation failed: %w", err)
+	}
+	cleanup := func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			log.Printf("WARNING: Failed to remove temp dir %s: %v", tempDir, err)
+		}
+	}
+
+	// The copy keeps the name of the source, which restores are named after.
+	p := strings.Trim(path.Clean("/"+source.Path), "/")
+	dest := tempDir
+	if p == "" {
+		dest = filepath.Join(tempDir, filepath.Base(task.Path))
+	}
+	write := func(name string, header *tar.Header, content io.Reader) error {
+		return writeArchiveMember(dest, name, header, content)
+	}
+	if source.Encrypted {
+		err = readArchiveSubtree(workerDto.WorkerTask{BackupID: task.BackupID, Path: source.Archive, ArchivePath: p}, write)
+	} else {
+		err = readColdArchive(source.Archive, p, write)
+	}
+	if err != nil {
+		return task, cleanup, fmt.Errorf("failed to read cold archive: %w", err)
+	}
+
+	// What was unpacked is plain files, whatever the backup stores.
+	task.Path, task.ArchivePath, task.Encrypted = dest, "", false
+	if p != "" {
+		task.Path = filepath.Join(tempDir, path.Base(p))
+	}
+	return task, cleanup, nil
+}
+
+// readColdArchive hands the members of the subtree at p of an unencrypted
+// cold archive to handle, named like readArchiveSubtree names them. Without
+// an index the archive is read from the start.
+func readColdArchive(archive string, p string, handle func(name string, header *tar.Header, content io.Reader) error) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	gzr, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = gzr.Close() }()
+
+	found := false
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		name := strings.Trim(path.Clean("/"+header.Name), "/")
+		if name == "" || (p != "" && name != p && !strings.HasPrefix(name, p+"/")) {
+			continue
+		}
+		found = true
+		if err := handle(subtreeMemberName(p, name), header, tr); err != nil {
+			return err
+		}
+	}
+	if !found {
+		return fmt.Errorf("%w: %s", crypto.ErrNotFoundInArchive, p)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package application
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+	workerDto "github.com/rrbarrero/justbackup/internal/worker/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDueSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"2024-01-01_00-00-00", "2024-01-02_00-00-00", "2024-01-20_00-00-00", "2024-01-21_00-00-00"} {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, name), 0755))
+	}
+	// Pinned as latest by the link even though a newer snapshot exists.
+	require.NoError(t, os.Symlink("2024-01-02_00-00-00", filepath.Join(dir, "latest")))
+
+	due, err := dueSnapshots(dir, valueobjects.TieringPolicy{AfterDays: 7}, time.Date(2024, 1, 25, 0, 0, 0, 0, time.UTC))
+
+	require.NoError(t, err)
+	names := make([]string, 0, len(due))
+	for _, a := range due {
+		names = append(names, a.Name)
+	}
+	assert.Equal(t, []string{"2024-01-01_00-00-00"}, names)
+}
+
+func TestArchiveSnapshotAndRecall(t *testing.T) {
+	base := t.TempDir()
+	backupDir := filepath.Join(base, "fast", "prod", "app")
+	coldDir := filepath.Join(base, "cold", "prod", "app")
+	require.NoError(t, os.MkdirAll(filepath.Join(backupDir, "2024-01-01_00-00-00", "etc", "nginx"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(backupDir, "2024-01-01_00-00-00", "etc", "nginx", "nginx.conf"), []byte("worker_processes 1;"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(backupDir, "2024-01-01_00-00-00", "etc", "hosts"), []byte("127.0.0.1 localhost"), 0644))
+	require.NoError(t, os.MkdirAll(coldDir, 0755))
+
+	artifact := snapshotArtifact{Name: "2024-01-01_00-00-00", Entries: []string{"2024-01-01_00-00-00"}}
+	archived, err := archiveSnapshot(context.Background(), "backup-1", backupDir, coldDir, artifact, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "2024-01-01_00-00-00.tar.gz", archived.Archive)
+	assert.False(t, archived.Encrypted)
+	assert.Positive(t, archived.Size)
+	assert.NoDirExists(t, filepath.Join(backupDir, "2024-01-01_00-00-00"))
+	assert.FileExists(t, filepath.Join(coldDir, archived.Archive))
+	assert.NoFileExists(t, filepath.Join(coldDir, archived.Archive+".partial"))
+
+	task := workerDto.WorkerTask{
+		Path:      filepath.Join(backupDir, "2024-01-01_00-00-00", "etc", "nginx"),
+		Encrypted: true,
+		ColdSource: &valueobjects.ColdLocation{
+			Snapshot: "2024-01-01_00-00-00",
+			Archive:  filepath.Join(coldDir, archived.Archive),
+			Path:     "etc/nginx",
+		},
+	}
+	recalled, cleanup, err := recallColdSource(task)
+	require.NoError(t, err)
+	defer cleanup()
+
+	assert.Equal(t, "nginx", filepath.Base(recalled.Path))
+	assert.False(t, recalled.Encrypted)
+	content, err := os.ReadFile(filepath.Join(recalled.Path, "nginx.conf"))
+	require.NoError(t, err)
+	assert.Equal(t, "worker_processes 1;", string(content))
+	assert.NoFileExists(t, filepath.Join(recalled.Path, "hosts"))
+
+	task.ColdSource.Path = "etc/missing"
+	_, cleanup, err = recallColdSource(task)
+	assert.Error(t, err)
+	cleanup()
+}
+
+func TestColdArtifacts(t *testing.T) {
+	local := []snapshotArtifact{{Name: "2024-01-03_00-00-00", Entries: []string{"2024-01-03_00-00-00"}}}
+	cold := coldArtifacts(local, []valueobjects.ColdLocation{
+		{Snapshot: "2024-01-01_00-00-00", Archive: "/cold/prod/app/2024-01-01_00-00-00.tar.gz"},
+		{Snapshot: "2024-01-03_00-00-00", Archive: "/cold/prod/app/2024-01-03_00-00-00.tar.gz"},
+		{Snapshot: "2024-01-02_00-00-00", Archive: "relative.tar.gz"},
+	})
+
+	assert.Equal(t, []snapshotArtifact{
+		{Name: "2024-01-01_00-00-00", Entries: []string{"/cold/prod/app/2024-01-01_00-00-00.tar.gz"}},
+	}, cold)
+}
@@ -0,0 +1,22 @@
+package dto
+
+import "time"
+
+// A download stream carries an archive from a worker to the server as a Redis
+// list of frames. The first byte of a frame says what follows it: a piece of
+// the archive, the end of the archive, or an error message.
+const (
+	DownloadFrameData  byte = 'd'
+	DownloadFrameEnd   byte = 'e'
+	DownloadFrameError byte = 'x'
+)
+
+const (
+	// DownloadFrameSize is the largest piece of archive in one frame.
+	DownloadFrameSize = 1 << 20
+	// DownloadMaxPending is how many frames a worker lets pile up before it
+	// waits for the server to catch up.
+	DownloadMaxPending = 16
+	// DownloadStreamTTL expires streams nobody reads or writes anymore.
+	DownloadStreamTTL = 10 * time.Minute
+)
@@ -0,0 +1,20 @@
+package dto
+
+// ReplicatedSnapshot reports a snapshot copied to a replica target.
+// UploadedBytes is the part of Bytes that was not on the target yet.
+type ReplicatedSnapshot struct {
+	Name          string `json:"name"`
+	Files         int64  `json:"files"`
+	Bytes         int64  `json:"bytes"`
+	UploadedBytes int64  `json:"uploaded_bytes"`
+}
+
+// ReplicateResult lists the snapshots a replicate task copied, and those it
+// removed from the target to apply its retention. A failed task still lists
+// the ones it finished before failing.
+type ReplicateResult struct {
+	TargetID  string               `json:"target_id"`
+	BackupID  string               `json:"backup_id"`
+	Snapshots []ReplicatedSnapshot `json:"snapshots"`
+	Pruned    []string             `json:"pruned,omitempty"`
+}
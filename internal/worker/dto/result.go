@@ -1,5 +1,11 @@
 package dto
 
+import (
+	"time"
+
+	"github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+)
+
 type WorkerResult struct {
 	Type    TaskType    `json:"type"`
 	TaskID  string      `json:"task_id"`
@@ -9,16 +15,151 @@ type WorkerResult struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
-type SearchFilesResult struct {
-	Files []string `json:"files"`
-}
-
 type FileListItem struct {
 	Name  string `json:"name"`
 	IsDir bool   `json:"is_dir"`
 	Size  int64  `json:"size"`
 }
 
+// ListFilesResult lists a directory. MissingIndex is set when the directory
+// is inside an encrypted archive that has no index to list it from.
 type ListFilesResult struct {
-	Files []FileListItem `json:"files"`
+	Files        []FileListItem `json:"files"`
+	MissingIndex bool           `json:"missing_index,omitempty"`
+}
+
+// PurgeResult reports what a purge kept and removed. StoredSize is the space
+// the backup takes on disk afterwards, nil when it was not measured.
+type PurgeResult struct {
+	BackupID   string                          `json:"backup_id,omitempty"`
+	Kept       []valueobjects.RetainedSnapshot `json:"kept"`
+	Purged     []string                        `json:"purged"`
+	Failed     []string                        `json:"failed,omitempty"`
+	StoredSize *int64                          `json:"stored_size,omitempty"`
+}
+
+// SnapshotInfo describes one snapshot of an incremental backup. FileCount is
+// nil when the snapshot only exists as an encrypted archive.
+type SnapshotInfo struct {
+	Name      string    `json:"name"`
+	Time      time.Time `json:"time"`
+	Size      int64     `json:"size"`
+	FileCount *int64    `json:"file_count,omitempty"`
+	Encrypted bool      `json:"encrypted"`
+	Latest    bool      `json:"latest"`
+}
+
+type ListSnapshotsResult struct {
+	Snapshots []SnapshotInfo `json:"snapshots"`
+}
+
+// FileVersion is one distinct content of a file across snapshots. Snapshots
+// lists every snapshot holding this version, oldest first.
+type FileVersion struct {
+	Hash      string    `json:"hash"`
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"mod_time"`
+	Snapshots []string  `json:"snapshots"`
+}
+
+// FileVersionsResult lists the versions of a file, oldest first.
+// EncryptedSnapshots are snapshots stored only as encrypted archives, whose
+// contents could not be inspected.
+type FileVersionsResult struct {
+	Versions           []FileVersion `json:"versions"`
+	EncryptedSnapshots []string      `json:"encrypted_snapshots,omitempty"`
+}
+
+// DiffEntry is a file that differs between two snapshots. Size is the size
+// in the newer snapshot, or in the older one for removed files.
+type DiffEntry struct {
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`
+	SizeDelta int64  `json:"size_delta"`
+}
+
+type SnapshotDiffResult struct {
+	Added     []DiffEntry `json:"added"`
+	Removed   []DiffEntry `json:"removed"`
+	Modified  []DiffEntry `json:"modified"`
+	SizeDelta int64       `json:"size_delta"`
+}
+
+// RestorePreviewResult lists the changes a dry run of a remote restore would
+// make, one rsync itemized line each. Truncated is set when there were more
+// than the worker reports.
+type RestorePreviewResult struct {
+	Changes   []string `json:"changes"`
+	Truncated bool     `json:"truncated,omitempty"`
+}
+
+type CatalogFile struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Hash    string    `json:"hash"`
+	// Content is the text of the file when the backup uses the content index
+	Content string `json:"content,omitempty"`
+}
+
+// CatalogBatch carries part of the file catalog of one snapshot. The batch
+// with Sequence 0 replaces whatever the server holds for that snapshot.
+// Snapshot is empty for plain mirrors.
+type CatalogBatch struct {
+	BackupID string        `json:"backup_id"`
+	Snapshot string        `json:"snapshot"`
+	Sequence int           `json:"sequence"`
+	Final    bool          `json:"final"`
+	Files    []CatalogFile `json:"files"`
+}
+
+// BackupResult reports a completed backup run. Size is the apparent size of
+// the new snapshot and UniqueSize the bytes only it holds. StoredSize is the
+// space a mirror takes on disk; an incremental run reports AddedSize instead,
+// the bytes its snapshot added to what the backup stored before.
+// Files is the number of regular files in the snapshot and ChangedFiles how
+// many of them the run had to transfer.
+type BackupResult struct {
+	Path         string `json:"path"`
+	Size         int64  `json:"size"`
+	UniqueSize   int64  `json:"unique_size"`
+	StoredSize   int64  `json:"stored_size"`
+	AddedSize    int64  `json:"added_size,omitempty"`
+	Files        int64  `json:"files"`
+	ChangedFiles int64  `json:"changed_files"`
+	// Snapshot is the snapshot the run took, empty for a mirror
+	Snapshot string `json:"snapshot,omitempty"`
+}
+
+// QuotaExceededResult is the data of a backup run failed by a quota. Scope is
+// the quota it broke, "host" or "backup", Budget what the run was allowed to
+// add and Added what it had added when it was stopped.
+type QuotaExceededResult struct {
+	Scope  string `json:"scope"`
+	Budget int64  `json:"budget"`
+	Added  int64  `json:"added"`
+}
+
+// DeleteDataResult reports the stored data a delete_data task removed.
+type DeleteDataResult struct {
+	BackupID string   `json:"backup_id,omitempty"`
+	Path     string   `json:"path"`
+	Removed  []string `json:"removed"`
+}
+
+// OrphanEntry is a directory or file under a storage root that no backup
+// references. Size is the space it takes on disk.
+type OrphanEntry struct {
+	Root    string    `json:"root"`
+	Path    string    `json:"path"`
+	IsDir   bool      `json:"is_dir"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// OrphanScanResult lists the orphaned entries under the scanned roots and
+// the roots the worker could not find.
+type OrphanScanResult struct {
+	Orphans []OrphanEntry `json:"orphans"`
+	Missing []string      `json:"missing,omitempty"`
 }
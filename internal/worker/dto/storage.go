@@ -0,0 +1,16 @@
+package dto
+
+// DiskUsageResult is the usage of the filesystem holding a backup root, in
+// bytes.
+type DiskUsageResult struct {
+	Total int64 `json:"total,string"`
+	Free  int64 `json:"free,string"`
+	Used  int64 `json:"used,string"`
+}
+
+// MigrateStorageResult reports a backup whose data was moved to the root of
+// another storage pool.
+type MigrateStorageResult struct {
+	BackupID      string `json:"backup_id"`
+	StoragePoolID string `json:"storage_pool_id"`
+}
@@ -1,16 +1,28 @@
 package dto
 
+import "github.com/rrbarrero/justbackup/internal/backup/domain/valueobjects"
+
 type TaskType string
 
 const (
-	TaskTypeBackup        TaskType = "backup"
-	TaskTypeMeasureSize   TaskType = "measure_size"
-	TaskTypeGetDiskUsage  TaskType = "get_disk_usage"
-	TaskTypeSearchFiles   TaskType = "search_files"
-	TaskTypeRestoreLocal  TaskType = "restore_local"
-	TaskTypeListFiles     TaskType = "list_files"
-	TaskTypeRestoreRemote TaskType = "restore_remote"
-	TaskTypePurge         TaskType = "purge"
+	TaskTypeBackup          TaskType = "backup"
+	TaskTypeMeasureSize     TaskType = "measure_size"
+	TaskTypeGetDiskUsage    TaskType = "get_disk_usage"
+	TaskTypeRestoreLocal    TaskType = "restore_local"
+	TaskTypeListFiles       TaskType = "list_files"
+	TaskTypeRestoreRemote   TaskType = "restore_remote"
+	TaskTypePurge           TaskType = "purge"
+	TaskTypeListSnapshots   TaskType = "list_snapshots"
+	TaskTypeFileVersions    TaskType = "file_versions"
+	TaskTypeDiffSnapshots   TaskType = "diff_snapshots"
+	TaskTypeCatalog         TaskType = "catalog"
+	TaskTypeListArchive     TaskType = "list_archive"
+	TaskTypeRestoreDownload TaskType = "restore_download"
+	TaskTypeReplicate       TaskType = "replicate"
+	TaskTypeMigrateStorage  TaskType = "migrate_storage"
+	TaskTypeTier            TaskType = "tier"
+	TaskTypeDeleteData      TaskType = "delete_data"
+	TaskTypeScanOrphans     TaskType = "scan_orphans"
 )
 
 type WorkerTask struct {
@@ -23,23 +35,81 @@ type WorkerTask struct {
 	Port     int      `json:"port"`
 	Path     string   `json:"path"`
 	// Backup specific
-	Destination string     `json:"destination,omitempty"`
-	Excludes    []string   `json:"excludes,omitempty"`
-	HostPath    string     `json:"host_path,omitempty"`
-	Incremental bool       `json:"incremental,omitempty"`
-	Retention   int        `json:"retention,omitempty"`
-	Encrypted   bool       `json:"encrypted,omitempty"`
-	Hooks       []HookTask `json:"hooks,omitempty"`
-	// Search specific
-	SearchPattern string `json:"search_pattern,omitempty"`
+	Destination string   `json:"destination,omitempty"`
+	Excludes    []string `json:"excludes,omitempty"`
+	HostPath    string   `json:"host_path,omitempty"`
+	// BackupRoot is the root of the storage pool the backup is in, the
+	// backup root of the worker when empty
+	BackupRoot  string `json:"backup_root,omitempty"`
+	Incremental bool   `json:"incremental,omitempty"`
+	Retention   int    `json:"retention,omitempty"`
+	// RetentionPolicy supersedes Retention when set.
+	RetentionPolicy *valueobjects.RetentionPolicy `json:"retention_policy,omitempty"`
+	Encrypted       bool                          `json:"encrypted,omitempty"`
+	Hooks           []HookTask                    `json:"hooks,omitempty"`
+	// ContentIndex asks the catalog to carry the text of small text files for
+	// the content index
+	ContentIndex bool `json:"content_index,omitempty"`
+	// Quota is how much the run may add before it breaks a quota of the
+	// backup or its host, nil when neither has one
+	Quota *valueobjects.QuotaBudget `json:"quota,omitempty"`
+	// Purge specific
+	PinnedSnapshots []string `json:"pinned_snapshots,omitempty"`
+	LegalHold       bool     `json:"legal_hold,omitempty"`
+	// ColdArchives are the snapshots tiering moved to cold storage pools,
+	// which retention applies to as well
+	ColdArchives []valueobjects.ColdLocation `json:"cold_archives,omitempty"`
+	// Encrypted archive specific: path inside the archive at Path to list or
+	// restore instead of the whole archive
+	ArchivePath string `json:"archive_path,omitempty"`
+	// Catalog specific: the snapshot to catalog, every snapshot when empty
+	CatalogSnapshot string `json:"catalog_snapshot,omitempty"`
+	// File versions specific: path of the file inside each snapshot
+	FilePath string `json:"file_path,omitempty"`
+	// Diff specific: snapshot directories under Path to compare
+	FromSnapshot string `json:"from_snapshot,omitempty"`
+	ToSnapshot   string `json:"to_snapshot,omitempty"`
 	// Restore local specific
 	RestoreAddr  string `json:"restore_addr,omitempty"`  // IP:Port of the CLI
 	RestoreToken string `json:"restore_token,omitempty"` // Auth token generated by CLI
+	// SHA-256 fingerprint of the ephemeral TLS certificate of the CLI
+	RestoreFingerprint string `json:"restore_fingerprint,omitempty"`
+	// Restore download specific: format of the archive and the stream it is
+	// written to for the server to pick up
+	DownloadFormat string `json:"download_format,omitempty"`
+	DownloadStream string `json:"download_stream,omitempty"`
 	// Restore remote specific
 	TargetHost string `json:"target_host,omitempty"`
 	TargetUser string `json:"target_user,omitempty"`
 	TargetPort int    `json:"target_port,omitempty"`
 	TargetPath string `json:"target_path,omitempty"`
+	// RestoreOptions controls how a remote restore treats the target. A dry
+	// run answers on the sync response channel instead of the result queue.
+	RestoreOptions *valueobjects.RestoreOptions `json:"restore_options,omitempty"`
+	// Restore specific: where the source can be pulled from when it is
+	// missing locally
+	ReplicaSource *valueobjects.ReplicaLocation `json:"replica_source,omitempty"`
+	// Restore specific: the archive in a cold storage pool the source is
+	// read from when it is missing locally
+	ColdSource *valueobjects.ColdLocation `json:"cold_source,omitempty"`
+	// Replicate specific: the target to copy the snapshots of the backup to,
+	// the snapshots it already holds and how many it keeps, 0 for all.
+	// ReplicatedSnapshots is also what the replica target of a tier task holds
+	Replica             *valueobjects.ReplicaEndpoint `json:"replica,omitempty"`
+	ReplicatedSnapshots []string                      `json:"replicated_snapshots,omitempty"`
+	ReplicaRetention    int                           `json:"replica_retention,omitempty"`
+	// Migrate storage specific: the root the data of the backup is moved to
+	// and the pool it belongs to, empty for the default backup root
+	MigrateRoot   string `json:"migrate_root,omitempty"`
+	StoragePoolID string `json:"storage_pool_id,omitempty"`
+	// Tier specific: the policy to apply and the root of its cold storage
+	// pool, if it has one
+	TieringPolicy *valueobjects.TieringPolicy `json:"tiering_policy,omitempty"`
+	ColdRoot      string                      `json:"cold_root,omitempty"`
+	// Orphan scan specific: the storage roots to scan and the directories
+	// backups keep in them; anything else under a root is orphaned
+	ScanRoots      []string `json:"scan_roots,omitempty"`
+	ReferencedDirs []string `json:"referenced_dirs,omitempty"`
 }
 
 type HookTask struct {
@@ -0,0 +1,24 @@
+package dto
+
+// ArchivedSnapshot reports a snapshot a tier task moved off the storage of
+// its backup. Archive is the file it was packed into in the cold storage
+// pool, empty when it was left to a replica target.
+type ArchivedSnapshot struct {
+	Name      string `json:"name"`
+	Archive   string `json:"archive,omitempty"`
+	Size      int64  `json:"size"`
+	Encrypted bool   `json:"encrypted,omitempty"`
+}
+
+// TierResult lists the snapshots a tier task moved, and those it failed to.
+// A failed task still lists the ones it finished before failing. StoredSize
+// is the space the backup takes on disk once they are gone, nil when nothing
+// was moved or it was not measured.
+type TierResult struct {
+	BackupID        string             `json:"backup_id"`
+	StoragePoolID   string             `json:"storage_pool_id,omitempty"`
+	ReplicaTargetID string             `json:"replica_target_id,omitempty"`
+	Archived        []ArchivedSnapshot `json:"archived"`
+	Failed          []string           `json:"failed,omitempty"`
+	StoredSize      *int64             `json:"stored_size,omitempty"`
+}
@@ -28,15 +28,30 @@ func NewRedisTaskConsumer(redisURL string, queueName string, resultQueue string)
 	}
 }
 
-func (c *RedisTaskConsumer) Start(ctx context.Context) {
+// Start consumes tasks until ctx is cancelled. Once cancelled, no new task is
+// taken from the queue and the in-flight task gets up to gracePeriod to finish.
+// If it is still running after that, it is aborted and pushed back to the queue.
+func (c *RedisTaskConsumer) Start(ctx context.Context, gracePeriod time.Duration) {
 	log.Printf("Worker listening on queue: %s", c.queueName)
 
 	for {
-		// BLPOP blocks until a task is available
-		result, err := c.client.BLPop(ctx, 0, c.queueName).Result()
+		if ctx.Err() != nil {
+			log.Println("Worker stopped taking new tasks")
+			return
+		}
+
+		// The pop itself is not bound to ctx so a task is never lost between
+		// Redis removing it from the list and the worker receiving it.
+		result, err := c.client.BLPop(context.Background(), 1*time.Second, c.queueName).Result()
 		if err != nil {
+			if err == redis.Nil {
+				continue
+			}
 			log.Printf("Redis BLPop error: %v", err)
-			time.Sleep(5 * time.Second)
+			select {
+			case <-ctx.Done():
+			case <-time.After(5 * time.Second):
+			}
 			continue
 		}
 
@@ -50,8 +65,61 @@ func (c *RedisTaskConsumer) Start(ctx context.Context) {
 			continue
 		}
 
-		c.processTask(ctx, task)
+		c.runTask(ctx, task, payload, gracePeriod)
+	}
+}
+
+// runTask executes a task on its own context so that a shutdown request does not
+// interrupt it immediately. The task context is only cancelled once the grace
+// period has expired, in which case the task is re-queued unless it already
+// committed to what it did.
+func (c *RedisTaskConsumer) runTask(shutdownCtx context.Context, task workerDto.WorkerTask, payload string, gracePeriod time.Duration) {
+	taskCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	taskCtx, committed := application.WithCommitMark(taskCtx)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.processTask(taskCtx, task)
+	}()
+
+	select {
+	case <-done:
+		return
+	case <-shutdownCtx.Done():
+	}
+
+	log.Printf("Shutdown requested, waiting up to %s for task %s (%s) to finish", gracePeriod, task.TaskID, task.Type)
+	select {
+	case <-done:
+		log.Printf("Task %s finished within the grace period", task.TaskID)
+		return
+	case <-time.After(gracePeriod):
+	}
+
+	log.Printf("Grace period expired, aborting task %s", task.TaskID)
+	cancel()
+	<-done
+
+	if committed() {
+		log.Printf("Task %s aborted after committing, not re-queuing it", task.TaskID)
+		return
+	}
+	c.requeue(task, payload)
+}
+
+// requeue pushes an aborted task back to the head of the queue so the next
+// available worker picks it up first.
+func (c *RedisTaskConsumer) requeue(task workerDto.WorkerTask, payload string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := c.client.LPush(ctx, c.queueName, payload).Err(); err != nil {
+		log.Printf("CRITICAL: Failed to re-queue aborted task %s: %v", task.TaskID, err)
+		return
 	}
+	log.Printf("Re-queued aborted task %s", task.TaskID)
 }
 
 func (c *RedisTaskConsumer) processTask(ctx context.Context, task workerDto.WorkerTask) {
@@ -62,16 +130,36 @@ func (c *RedisTaskConsumer) processTask(ctx context.Context, task workerDto.Work
 		application.HandleMeasureSizeTask(ctx, task, c.client, c.resultQueue)
 	case workerDto.TaskTypeGetDiskUsage:
 		application.HandleGetDiskUsage(ctx, task, c.client)
-	case workerDto.TaskTypeSearchFiles:
-		application.HandleSearchFiles(ctx, task, c.client)
 	case workerDto.TaskTypeRestoreLocal:
 		application.HandleRestoreLocalTask(ctx, task, c.client, c.resultQueue)
+	case workerDto.TaskTypeRestoreDownload:
+		application.HandleRestoreDownloadTask(ctx, task, c.client, c.resultQueue)
 	case workerDto.TaskTypeListFiles:
 		application.HandleListFiles(ctx, task, c.client)
 	case workerDto.TaskTypeRestoreRemote:
 		application.HandleRestoreRemoteTask(ctx, task, c.client, c.resultQueue)
 	case workerDto.TaskTypePurge:
 		application.HandlePurgeTask(ctx, task, c.client, c.resultQueue)
+	case workerDto.TaskTypeListSnapshots:
+		application.HandleListSnapshots(ctx, task, c.client)
+	case workerDto.TaskTypeFileVersions:
+		application.HandleFileVersions(ctx, task, c.client)
+	case workerDto.TaskTypeDiffSnapshots:
+		application.HandleDiffSnapshots(ctx, task, c.client)
+	case workerDto.TaskTypeListArchive:
+		application.HandleListArchive(ctx, task, c.client)
+	case workerDto.TaskTypeCatalog:
+		application.HandleCatalogTask(ctx, task, c.client, c.resultQueue)
+	case workerDto.TaskTypeReplicate:
+		application.HandleReplicateTask(ctx, task, c.client, c.resultQueue)
+	case workerDto.TaskTypeMigrateStorage:
+		application.HandleMigrateStorageTask(ctx, task, c.client, c.resultQueue)
+	case workerDto.TaskTypeTier:
+		application.HandleTierTask(ctx, task, c.client, c.resultQueue)
+	case workerDto.TaskTypeDeleteData:
+		application.HandleDeleteDataTask(ctx, task, c.client, c.resultQueue)
+	case workerDto.TaskTypeScanOrphans:
+		application.HandleScanOrphans(ctx, task, c.client)
 	default:
 		log.Printf("Unknown task type: %s", task.Type)
 	}
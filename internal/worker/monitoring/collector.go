@@ -64,6 +64,32 @@ func (c *StatsCollector) Start(ctx context.Context) {
 	}
 }
 
+// Deregister removes this worker from the backend stats so a stopped worker
+// does not keep showing up until its reports go stale
+func (c *StatsCollector) Deregister(ctx context.Context) error {
+	url := fmt.Sprintf("%s/api/v1/workers/stats/%s", c.config.BackendURL, c.workerID)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
+	if err != nil {
+		return err
+	}
+	if c.config.BackendToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.BackendToken)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("backend returned unexpected status: %s", resp.Status)
+	}
+
+	log.Printf("Worker %s deregistered", c.workerID)
+	return nil
+}
+
 func (c *StatsCollector) report(ctx context.Context) {
 	report, err := c.collect()
 	if err != nil {
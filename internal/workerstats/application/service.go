@@ -58,13 +58,26 @@ func (s *WorkerStatsService) PostStats(ctx context.Context, dto WorkerStatsRepor
 		return err
 	}
 
-	// Notify frontend
+	s.notifyUpdated()
+	return nil
+}
+
+// Deregister removes a worker that is shutting down
+func (s *WorkerStatsService) Deregister(ctx context.Context, workerID string) error {
+	if err := s.repo.DeleteStats(ctx, workerID); err != nil {
+		return err
+	}
+
+	s.notifyUpdated()
+	return nil
+}
+
+// notifyUpdated tells the frontend that worker stats changed
+func (s *WorkerStatsService) notifyUpdated() {
 	msg := map[string]string{"type": "worker_stats_updated"}
 	if data, err := json.Marshal(msg); err == nil {
 		s.hub.Broadcast(data)
 	}
-
-	return nil
 }
 
 // GetStats retrieves stats for all workers
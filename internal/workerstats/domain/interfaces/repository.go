@@ -11,4 +11,5 @@ type WorkerStatsRepository interface {
 	SaveReport(ctx context.Context, workerID string, report entities.WorkerStatsReport) error
 	GetStats(ctx context.Context, workerID string) (*entities.WorkerStatsWindow, error)
 	GetAllStats(ctx context.Context) ([]*entities.WorkerStatsWindow, error)
+	DeleteStats(ctx context.Context, workerID string) error
 }
@@ -93,3 +93,12 @@ func (r *WorkerStatsRepositoryMemory) GetAllStats(ctx context.Context) ([]*entit
 
 	return all, nil
 }
+
+// DeleteStats removes a worker and its stats window
+func (r *WorkerStatsRepositoryMemory) DeleteStats(ctx context.Context, workerID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.stats, workerID)
+	return nil
+}
@@ -72,8 +72,29 @@ func (h *WorkerStatsHandler) GetStats(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Deregister removes a worker that is shutting down
+// @Summary Deregister a worker
+// @Description Remove a worker from the stats list when it shuts down
+// @Tags workerstats
+// @Param id path string true "Worker ID"
+// @Security BasicAuth
+// @Success 204 "No Content"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string
+// @Router /workers/stats/{id} [delete]
+func (h *WorkerStatsHandler) Deregister(w http.ResponseWriter, r *http.Request) {
+	workerID := r.PathValue("id")
+	if err := h.service.Deregister(r.Context(), workerID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // RegisterRoutes registers the stats routes
 func (h *WorkerStatsHandler) RegisterRoutes(mux *http.ServeMux, protected func(http.HandlerFunc) http.HandlerFunc) {
 	mux.HandleFunc("POST /workers/stats", h.PostStats)
 	mux.HandleFunc("GET /workers/stats", protected(h.GetStats))
+	mux.HandleFunc("DELETE /workers/stats/{id}", protected(h.Deregister))
 }
@@ -62,3 +62,51 @@ func TestWorkerStatsHandler_GetStats(t *testing.T) {
 	assert.Equal(t, 1, len(result))
 	assert.Equal(t, "worker-1", result[0].WorkerID)
 }
+
+func TestWorkerStatsHandler_Deregister(t *testing.T) {
+	repo := memory.NewWorkerStatsRepositoryMemory()
+	service := application.NewWorkerStatsService(repo, websocket.NewHub())
+	handler := NewWorkerStatsHandler(service)
+
+	err := repo.SaveReport(context.Background(), "worker-1", entities.WorkerStatsReport{CPUUsage: 5.0})
+	assert.NoError(t, err)
+
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux, func(h http.HandlerFunc) http.HandlerFunc { return h })
+
+	req, _ := http.NewRequest("DELETE", "/workers/stats/worker-1", nil)
+	rr := httptest.NewRecorder()
+
+	mux.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	stats, _ := repo.GetStats(context.Background(), "worker-1")
+	assert.Nil(t, stats)
+}
+
+func TestWorkerStatsHandler_DeregisterRequiresAuth(t *testing.T) {
+	repo := memory.NewWorkerStatsRepositoryMemory()
+	service := application.NewWorkerStatsService(repo, websocket.NewHub())
+	handler := NewWorkerStatsHandler(service)
+
+	err := repo.SaveReport(context.Background(), "worker-1", entities.WorkerStatsReport{CPUUsage: 5.0})
+	assert.NoError(t, err)
+
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux, func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Authorization header required", http.StatusUnauthorized)
+		}
+	})
+
+	req, _ := http.NewRequest("DELETE", "/workers/stats/worker-1", nil)
+	rr := httptest.NewRecorder()
+
+	mux.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	stats, _ := repo.GetStats(context.Background(), "worker-1")
+	assert.NotNil(t, stats)
+}
@@ -0,0 +1,4 @@
+ALTER TABLE backups DROP COLUMN keep_yearly;
+ALTER TABLE backups DROP COLUMN keep_monthly;
+ALTER TABLE backups DROP COLUMN keep_weekly;
+ALTER TABLE backups DROP COLUMN keep_daily;
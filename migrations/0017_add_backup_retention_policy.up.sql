@@ -0,0 +1,4 @@
+ALTER TABLE backups ADD COLUMN keep_daily INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE backups ADD COLUMN keep_weekly INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE backups ADD COLUMN keep_monthly INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE backups ADD COLUMN keep_yearly INTEGER NOT NULL DEFAULT 0;
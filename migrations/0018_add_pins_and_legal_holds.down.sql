@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS legal_hold_events;
+DROP TABLE IF EXISTS snapshot_pins;
+ALTER TABLE hosts DROP COLUMN legal_hold;
+ALTER TABLE backups DROP COLUMN legal_hold;
@@ -0,0 +1,27 @@
+ALTER TABLE backups ADD COLUMN legal_hold BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE hosts ADD COLUMN legal_hold BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS snapshot_pins (
+    backup_id UUID NOT NULL,
+    snapshot VARCHAR(64) NOT NULL,
+    label VARCHAR(255) NOT NULL DEFAULT '',
+    note TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (backup_id, snapshot),
+    CONSTRAINT fk_backup FOREIGN KEY (backup_id) REFERENCES backups (id) ON DELETE CASCADE
+);
+
+-- Audit trail of legal hold changes. Rows are kept after the host or backup is
+-- gone, so there is no foreign key on target_id.
+CREATE TABLE IF NOT EXISTS legal_hold_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    scope VARCHAR(16) NOT NULL,
+    target_id UUID NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    user_id INTEGER,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_legal_hold_events_target ON legal_hold_events (scope, target_id);
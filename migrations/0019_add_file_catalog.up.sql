@@ -0,0 +1,17 @@
+-- One row per file per snapshot. Plain mirrors have a single catalog under the
+-- empty snapshot name, replaced by every run.
+CREATE TABLE IF NOT EXISTS file_catalog (
+    backup_id UUID NOT NULL,
+    snapshot VARCHAR(64) NOT NULL DEFAULT '',
+    path TEXT NOT NULL,
+    name TEXT NOT NULL,
+    size BIGINT NOT NULL DEFAULT 0,
+    mod_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    hash CHAR(64) NOT NULL DEFAULT '',
+    PRIMARY KEY (backup_id, snapshot, path),
+    CONSTRAINT fk_backup FOREIGN KEY (backup_id) REFERENCES backups (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_file_catalog_name ON file_catalog (name);
+CREATE INDEX IF NOT EXISTS idx_file_catalog_mod_time ON file_catalog (mod_time);
+CREATE INDEX IF NOT EXISTS idx_file_catalog_size ON file_catalog (size);
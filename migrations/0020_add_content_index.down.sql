@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_file_catalog_hash;
+DROP TABLE IF EXISTS file_contents;
+ALTER TABLE backups DROP COLUMN IF EXISTS content_index;
@@ -0,0 +1,17 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE backups ADD COLUMN IF NOT EXISTS content_index BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Text of the small text files of backups that opt into the content index,
+-- stored once per version. file_catalog maps each version to its paths and
+-- snapshots.
+CREATE TABLE IF NOT EXISTS file_contents (
+    backup_id UUID NOT NULL,
+    hash CHAR(64) NOT NULL,
+    content TEXT NOT NULL,
+    PRIMARY KEY (backup_id, hash),
+    CONSTRAINT fk_backup FOREIGN KEY (backup_id) REFERENCES backups (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_file_contents_content_trgm ON file_contents USING GIN (content gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_file_catalog_hash ON file_catalog (backup_id, hash);
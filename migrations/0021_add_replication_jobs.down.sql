@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS replication_runs;
+DROP TABLE IF EXISTS replication_jobs;
@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS replication_jobs (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    backup_id UUID NOT NULL,
+    path TEXT NOT NULL DEFAULT '',
+    snapshot VARCHAR(64) NOT NULL DEFAULT '',
+    target_host_id UUID NOT NULL,
+    target_path TEXT NOT NULL,
+    schedule VARCHAR(255) NOT NULL,
+    options JSONB NOT NULL DEFAULT '{}',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    status VARCHAR(16) NOT NULL,
+    current_task_id VARCHAR(64) NOT NULL DEFAULT '',
+    started_at TIMESTAMP WITH TIME ZONE,
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    next_run_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_backup FOREIGN KEY (backup_id) REFERENCES backups (id) ON DELETE CASCADE,
+    CONSTRAINT fk_target_host FOREIGN KEY (target_host_id) REFERENCES hosts (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS replication_runs (
+    id UUID PRIMARY KEY,
+    job_id UUID NOT NULL,
+    task_id VARCHAR(64) NOT NULL DEFAULT '',
+    trigger VARCHAR(16) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT fk_replication_job FOREIGN KEY (job_id) REFERENCES replication_jobs (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_replication_runs_job ON replication_runs (job_id, started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_replication_runs_task ON replication_runs (task_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS replica_snapshots;
+DROP TABLE IF EXISTS replica_targets;
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS replica_targets (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    kind VARCHAR(16) NOT NULL,
+    -- Encrypted JSON of the connection settings, which hold credentials
+    config BYTEA NOT NULL,
+    backup_ids TEXT[] NOT NULL DEFAULT '{}',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS replica_snapshots (
+    target_id UUID NOT NULL,
+    backup_id UUID NOT NULL,
+    snapshot VARCHAR(64) NOT NULL,
+    files BIGINT NOT NULL DEFAULT 0,
+    bytes BIGINT NOT NULL DEFAULT 0,
+    uploaded_bytes BIGINT NOT NULL DEFAULT 0,
+    replicated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (target_id, backup_id, snapshot),
+    CONSTRAINT fk_replica_target FOREIGN KEY (target_id) REFERENCES replica_targets (id) ON DELETE CASCADE,
+    CONSTRAINT fk_backup FOREIGN KEY (backup_id) REFERENCES backups (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_replica_snapshots_backup ON replica_snapshots (backup_id);
@@ -0,0 +1,2 @@
+ALTER TABLE replica_targets DROP COLUMN IF EXISTS retention;
+ALTER TABLE replica_targets DROP COLUMN IF EXISTS bandwidth_limit;
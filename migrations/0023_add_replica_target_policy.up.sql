@@ -0,0 +1,4 @@
+-- Upload rate limit in KiB/s, 0 for none
+ALTER TABLE replica_targets ADD COLUMN IF NOT EXISTS bandwidth_limit INTEGER NOT NULL DEFAULT 0;
+-- Snapshots kept on the target, apart from the retention of the backup; 0 keeps all
+ALTER TABLE replica_targets ADD COLUMN IF NOT EXISTS retention INTEGER NOT NULL DEFAULT 0;
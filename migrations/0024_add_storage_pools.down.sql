@@ -0,0 +1,3 @@
+ALTER TABLE hosts DROP COLUMN IF EXISTS storage_pool_id;
+ALTER TABLE backups DROP COLUMN IF EXISTS storage_pool_id;
+DROP TABLE IF EXISTS storage_pools;
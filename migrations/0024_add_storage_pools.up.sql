@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS storage_pools (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    -- Directory of the pool as the worker sees it
+    root_path TEXT NOT NULL UNIQUE,
+    -- Bytes the pool may fill, 0 for its whole filesystem
+    capacity BIGINT NOT NULL DEFAULT 0,
+    labels TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- NULL keeps the data in the default backup root
+ALTER TABLE backups ADD COLUMN IF NOT EXISTS storage_pool_id UUID REFERENCES storage_pools (id);
+ALTER TABLE hosts ADD COLUMN IF NOT EXISTS storage_pool_id UUID REFERENCES storage_pools (id);
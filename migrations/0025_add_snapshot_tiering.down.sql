@@ -0,0 +1,3 @@
+DELETE FROM maintenance_tasks WHERE type = 'tier';
+DROP TABLE IF EXISTS archived_snapshots;
+DROP TABLE IF EXISTS tiering_policies;
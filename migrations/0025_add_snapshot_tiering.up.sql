@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS tiering_policies (
+    backup_id UUID PRIMARY KEY,
+    after_days INTEGER NOT NULL,
+    -- Exactly one of the cold storage pool and the replica target is set
+    storage_pool_id UUID REFERENCES storage_pools (id),
+    replica_target_id UUID,
+    encrypt BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_backup FOREIGN KEY (backup_id) REFERENCES backups (id) ON DELETE CASCADE,
+    CONSTRAINT fk_replica_target FOREIGN KEY (replica_target_id) REFERENCES replica_targets (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS archived_snapshots (
+    backup_id UUID NOT NULL,
+    snapshot VARCHAR(64) NOT NULL,
+    tier VARCHAR(16) NOT NULL,
+    storage_pool_id UUID REFERENCES storage_pools (id),
+    replica_target_id UUID,
+    -- File name of the archive in a cold storage pool
+    archive TEXT NOT NULL DEFAULT '',
+    size BIGINT NOT NULL DEFAULT 0,
+    encrypted BOOLEAN NOT NULL DEFAULT FALSE,
+    archived_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (backup_id, snapshot),
+    CONSTRAINT fk_backup FOREIGN KEY (backup_id) REFERENCES backups (id) ON DELETE CASCADE,
+    CONSTRAINT fk_replica_target FOREIGN KEY (replica_target_id) REFERENCES replica_targets (id) ON DELETE CASCADE
+);
+
+INSERT INTO
+    maintenance_tasks (
+        id,
+        name,
+        type,
+        schedule,
+        next_run_at
+    )
+VALUES (
+        gen_random_uuid (),
+        'Tier Old Snapshots',
+        'tier',
+        '39 5 * * *',
+        CURRENT_TIMESTAMP
+    );
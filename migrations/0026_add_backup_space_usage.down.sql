@@ -0,0 +1,8 @@
+ALTER TABLE backups ADD COLUMN size VARCHAR(50);
+
+UPDATE backups SET size = (size_bytes / 1024) || 'KB' WHERE size_bytes > 0;
+
+ALTER TABLE backups
+DROP COLUMN size_bytes,
+DROP COLUMN unique_bytes,
+DROP COLUMN stored_bytes;
@@ -0,0 +1,17 @@
+ALTER TABLE backups
+ADD COLUMN size_bytes BIGINT NOT NULL DEFAULT 0,
+ADD COLUMN unique_bytes BIGINT NOT NULL DEFAULT 0,
+ADD COLUMN stored_bytes BIGINT NOT NULL DEFAULT 0;
+
+-- Sizes were reported by 'du -sk' as strings like '123KB'. They counted every
+-- hard-linked file of a snapshot, so they only carry over as apparent sizes;
+-- for plain mirrors that is also what they store.
+UPDATE backups
+SET
+    size_bytes = CAST(SUBSTRING(size FROM '^([0-9]+)KB$') AS BIGINT) * 1024
+WHERE
+    size ~ '^[0-9]+KB$';
+
+UPDATE backups SET stored_bytes = size_bytes WHERE incremental = FALSE;
+
+ALTER TABLE backups DROP COLUMN size;
@@ -0,0 +1,2 @@
+DELETE FROM maintenance_tasks WHERE type = 'forecast';
+DROP TABLE IF EXISTS backup_size_samples;
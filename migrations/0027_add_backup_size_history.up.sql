@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS backup_size_samples (
+    id BIGSERIAL PRIMARY KEY,
+    backup_id UUID NOT NULL,
+    host_id UUID NOT NULL,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    unique_bytes BIGINT NOT NULL DEFAULT 0,
+    stored_bytes BIGINT NOT NULL DEFAULT 0,
+    sampled_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT fk_backup FOREIGN KEY (backup_id) REFERENCES backups (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_backup_size_samples_backup ON backup_size_samples (backup_id, sampled_at);
+
+CREATE INDEX IF NOT EXISTS idx_backup_size_samples_host ON backup_size_samples (host_id, sampled_at);
+
+CREATE INDEX IF NOT EXISTS idx_backup_size_samples_sampled_at ON backup_size_samples (sampled_at);
+
+INSERT INTO
+    maintenance_tasks (
+        id,
+        name,
+        type,
+        schedule,
+        next_run_at
+    )
+VALUES (
+        gen_random_uuid (),
+        'Forecast Backup Capacity',
+        'forecast',
+        '17 6 * * *',
+        CURRENT_TIMESTAMP
+    );
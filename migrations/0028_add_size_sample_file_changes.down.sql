@@ -0,0 +1,3 @@
+ALTER TABLE backup_size_samples
+DROP COLUMN changed_files,
+DROP COLUMN files;
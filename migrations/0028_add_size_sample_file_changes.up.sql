@@ -0,0 +1,3 @@
+ALTER TABLE backup_size_samples
+ADD COLUMN files BIGINT NOT NULL DEFAULT 0,
+ADD COLUMN changed_files BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,7 @@
+ALTER TABLE backups
+DROP COLUMN quota_snapshot_bytes,
+DROP COLUMN quota_bytes;
+
+ALTER TABLE hosts
+DROP COLUMN quota_snapshot_bytes,
+DROP COLUMN quota_bytes;
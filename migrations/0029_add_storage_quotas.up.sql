@@ -0,0 +1,7 @@
+ALTER TABLE hosts
+ADD COLUMN quota_bytes BIGINT NOT NULL DEFAULT 0,
+ADD COLUMN quota_snapshot_bytes BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE backups
+ADD COLUMN quota_bytes BIGINT NOT NULL DEFAULT 0,
+ADD COLUMN quota_snapshot_bytes BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+DELETE FROM maintenance_tasks WHERE type = 'orphan_scan';
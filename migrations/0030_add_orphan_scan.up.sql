@@ -0,0 +1,15 @@
+INSERT INTO
+    maintenance_tasks (
+        id,
+        name,
+        type,
+        schedule,
+        next_run_at
+    )
+VALUES (
+        gen_random_uuid (),
+        'Scan For Orphaned Data',
+        'orphan_scan',
+        '43 5 * * 0',
+        CURRENT_TIMESTAMP
+    );
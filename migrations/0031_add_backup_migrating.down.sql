@@ -0,0 +1,2 @@
+ALTER TABLE backups
+DROP COLUMN migrating;
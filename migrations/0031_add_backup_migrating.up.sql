@@ -0,0 +1,2 @@
+ALTER TABLE backups
+ADD COLUMN migrating BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_file_catalog_path_trgm;
+DROP INDEX IF EXISTS idx_file_catalog_name_trgm;
@@ -0,0 +1,11 @@
+-- Glob searches match name and regex searches path with `~`, which the btree
+-- index on name cannot serve. Trigram indexes let the planner use a bitmap
+-- scan on the literal parts of the pattern instead of reading every catalog
+-- row. To check a search, EXPLAIN it on a populated catalog, e.g.
+-- `EXPLAIN SELECT * FROM file_catalog WHERE name ~ '^.*\.conf$'`, and look
+-- for a Bitmap Index Scan on idx_file_catalog_name_trgm. A pattern with no
+-- literal of three characters or more still scans the table.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_file_catalog_name_trgm ON file_catalog USING GIN (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_file_catalog_path_trgm ON file_catalog USING GIN (path gin_trgm_ops);
@@ -10,6 +10,7 @@ import {
 } from "@/services/dashboard-service";
 import { DiskUsageChart } from "@/components/dashboard/disk-usage-chart";
 import { WorkerMemoryChart } from "@/components/dashboard/worker-memory-chart";
+import { formatSize } from "@/shared/lib/utils";
 import {
   Server,
   Database,
@@ -110,9 +111,9 @@ export default function DashboardPage() {
           </CardHeader>
           <CardContent>
             <div className="text-2xl font-bold">
-              {stats.backup_stats.total_size || "0 B"}
+              {formatSize(stats.backup_stats.total_size)}
             </div>
-            <p className="text-xs text-muted-foreground">Total backed up</p>
+            <p className="text-xs text-muted-foreground">Stored on disk</p>
           </CardContent>
         </Card>
 
@@ -209,6 +210,59 @@ export default function DashboardPage() {
           <WorkerMemoryChart />
         </div>
       </div>
+
+      <Card>
+        <CardHeader>
+          <CardTitle>Storage by Host</CardTitle>
+        </CardHeader>
+        <CardContent>
+          {(stats.storage ?? []).length === 0 ? (
+            <p className="text-sm text-muted-foreground">
+              No backups have reported their size yet.
+            </p>
+          ) : (
+            <div className="space-y-4">
+              {stats.storage.map((host) => (
+                <div key={host.host_id} className="space-y-2">
+                  <div className="flex items-center justify-between">
+                    <p className="text-sm font-medium">{host.host_name}</p>
+                    <p className="text-sm font-medium">
+                      {formatSize(host.stored_size)}
+                      {host.quota && host.quota.bytes > 0 && (
+                        <span
+                          className={
+                            host.quota.exceeded
+                              ? "text-destructive"
+                              : "text-muted-foreground"
+                          }
+                        >
+                          {" "}
+                          of {formatSize(host.quota.bytes)} quota
+                        </span>
+                      )}
+                    </p>
+                  </div>
+                  {host.backups.map((backup) => (
+                    <div
+                      key={backup.backup_id}
+                      className="flex items-center justify-between pl-4 text-sm text-muted-foreground"
+                    >
+                      <code className="font-mono">{backup.path}</code>
+                      <span>
+                        {formatSize(backup.stored_size)} on disk,{" "}
+                        {formatSize(backup.unique_size)} added by last run
+                        {backup.quota && backup.quota.bytes > 0 && (
+                          <>, {formatSize(backup.quota.bytes)} quota</>
+                        )}
+                      </span>
+                    </div>
+                  ))}
+                </div>
+              ))}
+            </div>
+          )}
+        </CardContent>
+      </Card>
     </div>
   );
 }
@@ -217,6 +217,52 @@ export function BackupDetailsSheet({
                   </code>
                 </div>
 
+                <div className="grid grid-cols-2 gap-3">
+                  <div className="flex flex-col gap-1">
+                    <span className="text-xs font-medium text-muted-foreground uppercase tracking-wide">
+                      Added by Last Run
+                    </span>
+                    <code className="text-sm bg-muted px-2 py-1 rounded font-mono">
+                      {backup.uniqueSize ? formatSize(backup.uniqueSize) : "-"}
+                    </code>
+                  </div>
+                  <div className="flex flex-col gap-1">
+                    <span className="text-xs font-medium text-muted-foreground uppercase tracking-wide">
+                      On Disk
+                    </span>
+                    <code className="text-sm bg-muted px-2 py-1 rounded font-mono">
+                      {backup.storedSize ? formatSize(backup.storedSize) : "-"}
+                    </code>
+                  </div>
+                </div>
+
+                {backup.quota && (
+                  <div className="grid grid-cols-2 gap-3">
+                    <div className="flex flex-col gap-1">
+                      <span className="text-xs font-medium text-muted-foreground uppercase tracking-wide">
+                        Quota
+                      </span>
+                      <code
+                        className={`text-sm bg-muted px-2 py-1 rounded font-mono ${backup.quota.exceeded ? "text-destructive" : ""}`}
+                      >
+                        {backup.quota.bytes
+                          ? `${formatSize(backup.quota.used)} of ${formatSize(backup.quota.bytes)}`
+                          : "-"}
+                      </code>
+                    </div>
+                    <div className="flex flex-col gap-1">
+                      <span className="text-xs font-medium text-muted-foreground uppercase tracking-wide">
+                        Per Run
+                      </span>
+                      <code className="text-sm bg-muted px-2 py-1 rounded font-mono">
+                        {backup.quota.snapshotBytes
+                          ? formatSize(backup.quota.snapshotBytes)
+                          : "-"}
+                      </code>
+                    </div>
+                  </div>
+                )}
+
                 <div className="grid grid-cols-2 gap-3">
                   <div className="flex flex-col gap-1">
                     <span className="text-xs font-medium text-muted-foreground uppercase tracking-wide">
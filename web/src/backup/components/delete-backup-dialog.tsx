@@ -10,6 +10,8 @@ import {
   AlertDialogHeader,
   AlertDialogTitle,
 } from "@/shared/ui/alert-dialog";
+import { Checkbox } from "@/shared/ui/checkbox";
+import { Label } from "@/shared/ui/label";
 import {
   BackupResponse,
   deleteBackup,
@@ -31,6 +33,7 @@ export function DeleteBackupDialog({
   onSuccess,
 }: DeleteBackupDialogProps) {
   const [isDeleting, setIsDeleting] = useState(false);
+  const [withData, setWithData] = useState(false);
   const router = useRouter();
 
   const handleDelete = async () => {
@@ -38,7 +41,7 @@ export function DeleteBackupDialog({
 
     setIsDeleting(true);
     try {
-      await deleteBackup(backup.id);
+      await deleteBackup(backup.id, withData);
       onOpenChange(false);
       router.refresh();
       onSuccess?.();
@@ -60,6 +63,17 @@ export function DeleteBackupDialog({
             backup configuration for <b>{backup?.path}</b>.
           </AlertDialogDescription>
         </AlertDialogHeader>
+        <div className="flex items-center gap-2">
+          <Checkbox
+            id="delete-backup-data"
+            checked={withData}
+            onCheckedChange={(checked) => setWithData(checked === true)}
+            disabled={isDeleting}
+          />
+          <Label htmlFor="delete-backup-data">
+            Also delete its snapshots from storage
+          </Label>
+        </div>
         <AlertDialogFooter>
           <AlertDialogCancel disabled={isDeleting}>Cancel</AlertDialogCancel>
           <AlertDialogAction
@@ -6,6 +6,13 @@ export interface Hook {
   params: Record<string, string>;
 }
 
+export interface Quota {
+  bytes: number;
+  snapshotBytes: number;
+  used: number;
+  exceeded: boolean;
+}
+
 export interface Backup {
   id: string;
   hostId: string;
@@ -19,6 +26,9 @@ export interface Backup {
   excludes: string[];
   incremental: boolean;
   size?: number;
+  uniqueSize?: number;
+  storedSize?: number;
+  quota?: Quota;
 
   retention: number;
   encrypted: boolean;
@@ -75,8 +75,12 @@ export async function updateBackup(
   return toDomain(dto);
 }
 
-export async function deleteBackup(id: string): Promise<void> {
-  return ApiClient.delete<void>(`/api/backups/${id}`);
+export async function deleteBackup(
+  id: string,
+  withData = false,
+): Promise<void> {
+  const query = withData ? "?with_data=true" : "";
+  await ApiClient.delete<void>(`/api/backups/${id}${query}`);
 }
 
 export async function runBackup(id: string): Promise<void> {
@@ -6,6 +6,13 @@ export interface HookDTO {
   params: Record<string, string>;
 }
 
+export interface QuotaDTO {
+  bytes: number;
+  snapshot_bytes: number;
+  used: number;
+  exceeded: boolean;
+}
+
 export interface BackupDTO {
   id: string;
   host_id: string;
@@ -18,7 +25,10 @@ export interface BackupDTO {
   last_run: string;
   excludes: string[];
   incremental: boolean;
-  size?: string;
+  size?: number;
+  unique_size?: number;
+  stored_size?: number;
+  quota?: QuotaDTO;
   retention: number;
   encrypted: boolean;
   hooks: HookDTO[];
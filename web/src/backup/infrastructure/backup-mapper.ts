@@ -14,7 +14,15 @@ export const toDomain = (dto: BackupDTO): Backup => {
     lastRun: dto.last_run,
     excludes: dto.excludes || [],
     incremental: dto.incremental,
-    size: dto.size ? parseInt(dto.size) : 0,
+    size: dto.size ?? 0,
+    uniqueSize: dto.unique_size ?? 0,
+    storedSize: dto.stored_size ?? 0,
+    quota: dto.quota && {
+      bytes: dto.quota.bytes,
+      snapshotBytes: dto.quota.snapshot_bytes,
+      used: dto.quota.used,
+      exceeded: dto.quota.exceeded,
+    },
     retention: dto.retention,
     encrypted: dto.encrypted,
     hooks: (dto.hooks || []).map(
@@ -10,6 +10,8 @@ import {
   AlertDialogHeader,
   AlertDialogTitle,
 } from "@/shared/ui/alert-dialog";
+import { Checkbox } from "@/shared/ui/checkbox";
+import { Label } from "@/shared/ui/label";
 import { HostResponse, deleteHost } from "@/host/infrastructure/host-api";
 import { useState } from "react";
 import { useRouter } from "next/navigation";
@@ -28,6 +30,7 @@ export function DeleteHostDialog({
   onSuccess,
 }: DeleteHostDialogProps) {
   const [isDeleting, setIsDeleting] = useState(false);
+  const [withData, setWithData] = useState(false);
   const router = useRouter();
 
   const handleDelete = async () => {
@@ -35,7 +38,7 @@ export function DeleteHostDialog({
 
     setIsDeleting(true);
     try {
-      await deleteHost(host.id);
+      await deleteHost(host.id, withData);
       onOpenChange(false);
       router.refresh();
       onSuccess?.();
@@ -57,6 +60,17 @@ export function DeleteHostDialog({
             <b>{host?.name}</b> and all its associated backups.
           </AlertDialogDescription>
         </AlertDialogHeader>
+        <div className="flex items-center gap-2">
+          <Checkbox
+            id="delete-host-data"
+            checked={withData}
+            onCheckedChange={(checked) => setWithData(checked === true)}
+            disabled={isDeleting}
+          />
+          <Label htmlFor="delete-host-data">
+            Also delete the snapshots of its backups from storage
+          </Label>
+        </div>
         <AlertDialogFooter>
           <AlertDialogCancel disabled={isDeleting}>Cancel</AlertDialogCancel>
           <AlertDialogAction
@@ -29,8 +29,12 @@ export const getHost = async (id: string): Promise<Host> => {
   return toDomain(dto);
 };
 
-export const deleteHost = async (id: string): Promise<void> => {
-  return ApiClient.delete<void>(`/api/hosts/${id}`);
+export const deleteHost = async (
+  id: string,
+  withData = false,
+): Promise<void> => {
+  const query = withData ? "?with_data=true" : "";
+  await ApiClient.delete<void>(`/api/hosts/${id}${query}`);
 };
 
 export const runHostBackups = async (
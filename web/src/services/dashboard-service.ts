@@ -1,11 +1,36 @@
 import { ApiClient as api } from "@/shared/infrastructure/api-client";
 import { BackupStats } from "@/shared/types";
 
+export interface QuotaUsage {
+  bytes: number;
+  snapshot_bytes: number;
+  used: number;
+  exceeded: boolean;
+}
+
+export interface BackupStorage {
+  backup_id: string;
+  path: string;
+  size: number;
+  unique_size: number;
+  stored_size: number;
+  quota?: QuotaUsage;
+}
+
+export interface HostStorage {
+  host_id: string;
+  host_name: string;
+  stored_size: number;
+  quota?: QuotaUsage;
+  backups: BackupStorage[];
+}
+
 export interface DashboardStats {
   total_hosts: number;
   total_backups: number;
   active_workers: number;
   backup_stats: BackupStats;
+  storage: HostStorage[];
 }
 
 export async function getDashboardStats(): Promise<DashboardStats> {
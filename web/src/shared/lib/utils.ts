@@ -5,9 +5,12 @@ export function cn(...inputs: ClassValue[]) {
   return twMerge(clsx(inputs));
 }
 
-export function formatSize(kb: number): string {
-  if (kb === 0) return "0 B";
-  const units = ["KB", "MB", "GB", "TB", "PB"];
-  const i = Math.floor(Math.log(kb) / Math.log(1024));
-  return `${(kb / Math.pow(1024, i)).toFixed(2)} ${units[i]}`;
+export function formatSize(bytes: number): string {
+  if (bytes <= 0) return "0 B";
+  const units = ["B", "KB", "MB", "GB", "TB", "PB"];
+  const i = Math.min(
+    Math.floor(Math.log(bytes) / Math.log(1024)),
+    units.length - 1,
+  );
+  return `${(bytes / Math.pow(1024, i)).toFixed(2)} ${units[i]}`;
 }
@@ -3,5 +3,5 @@ export interface BackupStats {
   pending: number;
   completed: number;
   failed: number;
-  total_size: string;
+  total_size: number;
 }
@@ -3,6 +3,9 @@ package main
 import (
 	"context"
 	"log"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/rrbarrero/justbackup/internal/shared/infrastructure/config"
 	"github.com/rrbarrero/justbackup/internal/worker/infrastructure"
@@ -18,7 +21,11 @@ func main() {
 		log.Fatal(err)
 	}
 
-	ctx := context.Background()
+	// Cancelled on SIGTERM/SIGINT: the consumer stops taking new tasks and
+	// gives the in-flight task the configured grace period to finish.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
 	queueName := "backup_tasks"
 	resultQueue := "backup_results"
 
@@ -27,5 +34,13 @@ func main() {
 	go collector.Start(ctx)
 
 	consumer := infrastructure.NewRedisTaskConsumer(cfg.RedisURL, queueName, resultQueue)
-	consumer.Start(ctx)
+	consumer.Start(ctx, cfg.ShutdownGracePeriod)
+
+	deregisterCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := collector.Deregister(deregisterCtx); err != nil {
+		log.Printf("Failed to deregister worker: %v", err)
+	}
+
+	log.Println("Backup Worker stopped")
 }